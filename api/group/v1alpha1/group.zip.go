@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package v1alpha1
+
+import (
+	"context"
+
+	zip "api.zip"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GroupLabelName is the label which is set on each machine of a group, whose
+// value is the name of the group.
+const GroupLabelName = "group.kraftkit.sh/name"
+
+type (
+	// Group is the mutable API object that represents a named group of
+	// machines which are created from the same source.
+	Group = zip.Object[GroupSpec, GroupStatus]
+
+	// GroupList is the mutable API object that represents a list of groups.
+	GroupList = zip.ObjectList[GroupSpec, GroupStatus]
+)
+
+// GroupSpec contains the template from which each machine of the group is
+// created, as well as the number of machines.
+type GroupSpec struct {
+	// Source is the package, project directory or kernel of each machine.
+	Source string `json:"source"`
+
+	// Platform and Architecture of each machine.
+	Platform     string `json:"platform,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+
+	// Memory of each machine, in the format accepted by `kraft run -M`.
+	Memory string `json:"memory,omitempty"`
+
+	// Networks which are shared by all machines of the group, in the format
+	// accepted by `kraft run --network`.
+	Networks []string `json:"networks,omitempty"`
+
+	// Env and Volumes of each machine, in the format accepted by `kraft run`.
+	Env     []string `json:"env,omitempty"`
+	Volumes []string `json:"volumes,omitempty"`
+
+	// Replicas is the number of machines in the group.
+	Replicas int `json:"replicas"`
+}
+
+// GroupStatus contains the machines which currently belong to the group.
+type GroupStatus struct {
+	Machines []metav1.ObjectMeta `json:"machines,omitempty"`
+}
+
+// GroupService is the interface of available methods
+type GroupService interface {
+	Create(ctx context.Context, req *Group) (*Group, error)
+	Delete(ctx context.Context, req *Group) (*Group, error)
+	Get(ctx context.Context, req *Group) (*Group, error)
+	List(ctx context.Context, req *GroupList) (*GroupList, error)
+	Update(ctx context.Context, req *Group) (*Group, error)
+}
+
+// GroupServiceHandler provides a Zip API Object Framework service for a
+// group of machines.
+type GroupServiceHandler struct {
+	create zip.MethodStrategy[*Group, *Group]
+	delete zip.MethodStrategy[*Group, *Group]
+	get    zip.MethodStrategy[*Group, *Group]
+	list   zip.MethodStrategy[*GroupList, *GroupList]
+	update zip.MethodStrategy[*Group, *Group]
+}
+
+// Create implements GroupService
+func (client *GroupServiceHandler) Create(ctx context.Context, req *Group) (*Group, error) {
+	return client.create.Do(ctx, req)
+}
+
+// Delete implements GroupService
+func (client *GroupServiceHandler) Delete(ctx context.Context, req *Group) (*Group, error) {
+	return client.delete.Do(ctx, req)
+}
+
+// Get implements GroupService
+func (client *GroupServiceHandler) Get(ctx context.Context, req *Group) (*Group, error) {
+	return client.get.Do(ctx, req)
+}
+
+// List implements GroupService
+func (client *GroupServiceHandler) List(ctx context.Context, req *GroupList) (*GroupList, error) {
+	return client.list.Do(ctx, req)
+}
+
+// Update implements GroupService
+func (client *GroupServiceHandler) Update(ctx context.Context, req *Group) (*Group, error) {
+	return client.update.Do(ctx, req)
+}
+
+// NewGroupServiceHandler returns a service based on an inline API client which
+// essentially wraps the specific call, enabling pre- and post- call hooks.
+// This is useful for wrapping the command with decorators, for example, a
+// cache, error handlers, etc.  Simultaneously, it enables access to the
+// service via inline code without having to make invocations to an external
+// handler.
+func NewGroupServiceHandler(ctx context.Context, impl GroupService, opts ...zip.ClientOption) (GroupService, error) {
+	create, err := zip.NewMethodClient(ctx, impl.Create, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	delete, err := zip.NewMethodClient(ctx, impl.Delete, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	get, err := zip.NewMethodClient(ctx, impl.Get, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := zip.NewMethodClient(ctx, impl.List, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	update, err := zip.NewMethodClient(ctx, impl.Update, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupServiceHandler{
+		create,
+		delete,
+		get,
+		list,
+		update,
+	}, nil
+}
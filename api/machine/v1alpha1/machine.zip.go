@@ -6,6 +6,7 @@ package v1alpha1
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	zip "api.zip"
@@ -43,6 +44,167 @@ type MachinePort struct {
 // MachinePorts is a slice of MachinePort
 type MachinePorts []MachinePort
 
+// MachineRTCBase is the time the real-time clock of a machine starts from.
+type MachineRTCBase string
+
+const (
+	MachineRTCBaseUTC       = MachineRTCBase("utc")
+	MachineRTCBaseLocaltime = MachineRTCBase("localtime")
+)
+
+// MachineRTCBases returns all supported real-time clock bases.
+func MachineRTCBases() []MachineRTCBase {
+	return []MachineRTCBase{
+		MachineRTCBaseUTC,
+		MachineRTCBaseLocaltime,
+	}
+}
+
+// MachineClock describes how time is presented to the guest of a machine.
+type MachineClock struct {
+	// RTCBase is the time the real-time clock starts from.  Defaults to UTC.
+	RTCBase MachineRTCBase `json:"rtcBase,omitempty"`
+
+	// NoParavirt hides paravirtualized clocks, such as kvmclock, from the guest
+	// such that it falls back to emulated timers.
+	NoParavirt bool `json:"noParavirt,omitempty"`
+
+	// SyncOnResume keeps the real-time clock in step with the host whilst the
+	// machine is paused or the host sleeps, such that the guest observes the
+	// correct time once it resumes.
+	SyncOnResume bool `json:"syncOnResume,omitempty"`
+}
+
+// MachineHardening describes how the virtual machine monitor of a machine is
+// confined on the host.
+type MachineHardening struct {
+	// Seccomp restricts the system calls which the VMM is able to make.
+	Seccomp bool `json:"seccomp,omitempty"`
+
+	// DropCapabilities drops all capabilities of the VMM once it has
+	// initialised by switching to an unprivileged user.
+	DropCapabilities bool `json:"dropCapabilities,omitempty"`
+
+	// User is the unprivileged user which the VMM switches to once it has
+	// initialised.  Defaults to `nobody` if capabilities are dropped.
+	User string `json:"user,omitempty"`
+}
+
+// MachineLogDriver is the driver which records the console output of a
+// machine.
+type MachineLogDriver string
+
+const (
+	// MachineLogDriverRaw records the console output as is.
+	MachineLogDriverRaw = MachineLogDriver("raw")
+
+	// MachineLogDriverJSONFile additionally records each line of the console
+	// output as a JSON object with the time it was written and its stream, for
+	// as long as the process which started the machine is alive.
+	MachineLogDriverJSONFile = MachineLogDriver("json-file")
+
+	// MachineLogDriverNone discards the console output.
+	MachineLogDriverNone = MachineLogDriver("none")
+)
+
+// MachineLogDrivers returns all supported log drivers.
+func MachineLogDrivers() []MachineLogDriver {
+	return []MachineLogDriver{
+		MachineLogDriverRaw,
+		MachineLogDriverJSONFile,
+		MachineLogDriverNone,
+	}
+}
+
+// MachineLogging describes how the console output of a machine is recorded.
+type MachineLogging struct {
+	// Driver records the console output.  Defaults to raw.
+	Driver MachineLogDriver `json:"driver,omitempty"`
+
+	// Options of the driver, such as `max-size` and `max-file`, which override
+	// the rotation of logs set in the configuration.
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// MachineAccelerator is the accelerator which the virtual machine monitor of
+// a machine uses to execute the guest.
+type MachineAccelerator string
+
+const (
+	// MachineAcceleratorAuto selects the hypervisor of the host if it is
+	// available and otherwise falls back to emulation with TCG.  Unless it is
+	// requested, a machine which cannot be accelerated fails to be created.
+	MachineAcceleratorAuto = MachineAccelerator("auto")
+
+	MachineAcceleratorKVM  = MachineAccelerator("kvm")
+	MachineAcceleratorHVF  = MachineAccelerator("hvf")
+	MachineAcceleratorWHPX = MachineAccelerator("whpx")
+	MachineAcceleratorXen  = MachineAccelerator("xen")
+	MachineAcceleratorTCG  = MachineAccelerator("tcg")
+)
+
+// MachineAccelerators returns all supported accelerators.
+func MachineAccelerators() []MachineAccelerator {
+	return []MachineAccelerator{
+		MachineAcceleratorAuto,
+		MachineAcceleratorKVM,
+		MachineAcceleratorHVF,
+		MachineAcceleratorWHPX,
+		MachineAcceleratorXen,
+		MachineAcceleratorTCG,
+	}
+}
+
+// MachineVMM describes the virtual machine monitor which runs a machine.
+// Unset fields are populated with the platform's defaults once the machine is
+// created, such that the machine records what it was run with.
+type MachineVMM struct {
+	// Binary is the path to the executable of the VMM.
+	Binary string `json:"binary,omitempty"`
+
+	// MachineType is the type of machine which the VMM emulates, e.g. `pc`,
+	// `q35` or `microvm` for QEMU.
+	MachineType string `json:"machineType,omitempty"`
+
+	// Accelerator is the accelerator which the VMM uses.  Defaults to the
+	// hypervisor of the host, or emulation if it is requested.
+	Accelerator MachineAccelerator `json:"accelerator,omitempty"`
+
+	// CPUModel is the model of the virtual CPU, e.g. `Skylake-Server` for QEMU,
+	// or the CPU template, e.g. `T2`, for Firecracker.  Defaults to the
+	// platform's choice for the architecture.
+	CPUModel string `json:"cpuModel,omitempty"`
+
+	// CPUFeatures are the features of the virtual CPU which are enabled or
+	// disabled over those of its model, e.g. `+avx2` or `-aes`.
+	CPUFeatures []string `json:"cpuFeatures,omitempty"`
+}
+
+// ParseMachineCPUFeature returns the name of the CPU feature and whether it is
+// enabled, which is denoted by a `+` prefix, or disabled, which is denoted by
+// a `-` prefix.
+func ParseMachineCPUFeature(feature string) (string, bool, error) {
+	if len(feature) < 2 || (feature[0] != '+' && feature[0] != '-') {
+		return "", false, fmt.Errorf("invalid CPU feature '%s': expected +feature or -feature", feature)
+	}
+
+	return feature[1:], feature[0] == '+', nil
+}
+
+// MachineHardeningStatus records the hardening which was applied to the
+// virtual machine monitor of a machine.
+type MachineHardeningStatus struct {
+	// Seccomp is the seccomp policy which was applied to the VMM, if any.
+	Seccomp string `json:"seccomp,omitempty"`
+
+	// CapabilitiesDropped is set if the VMM runs without capabilities.
+	CapabilitiesDropped bool `json:"capabilitiesDropped,omitempty"`
+
+	// User is the user which the VMM runs as after it has initialised, if it
+	// switched users.
+	User string `json:"user,omitempty"`
+}
+
 type (
 	// Machine is the mutable API object that represents a machine instance.
 	Machine = zip.Object[MachineSpec, MachineStatus]
@@ -63,6 +225,10 @@ type MachineSpec struct {
 	// Kernel represents the
 	Kernel string `json:"kernel,omitempty"`
 
+	// ImageDigest is the digest of the package image which the kernel was
+	// retrieved from (if applicable).
+	ImageDigest string `json:"imageDigest,omitempty"`
+
 	// Rootfs the fully-qualified path to the target root file system.  This can
 	// be device path, a mount-path, initramdisk.
 	Rootfs string `json:"rootfs,omitempty"`
@@ -93,6 +259,27 @@ type MachineSpec struct {
 
 	// Emulation indicates whether to use VMM emulation.
 	Emulation bool `json:"emulation,omitempty"`
+
+	// Clock describes how time is presented to the guest.
+	Clock MachineClock `json:"clock,omitempty"`
+
+	// NoRNG disables the paravirtualized random number generator which is
+	// otherwise attached to the machine as a source of entropy.
+	NoRNG bool `json:"noRNG,omitempty"`
+
+	// Metrics attaches a channel over which the counters which the libraries of
+	// the guest export through ukstore, and the hit counts of its uktrace
+	// tracepoints, can be read from the host.
+	Metrics bool `json:"metrics,omitempty"`
+
+	// Hardening describes how the VMM of the machine is confined on the host.
+	Hardening MachineHardening `json:"hardening,omitempty"`
+
+	// Logging describes how the console output of the machine is recorded.
+	Logging MachineLogging `json:"logging,omitempty"`
+
+	// VMM describes the virtual machine monitor which runs the machine.
+	VMM MachineVMM `json:"vmm,omitempty"`
 }
 
 // MachineState indicates the state of the machine.
@@ -129,9 +316,24 @@ type MachineStatus struct {
 	// The fully-qualified path to the initramfs file of the machine instance.
 	InitrdPath string `json:"initrdPath,omitempty"`
 
-	// ExitCode is the ...
+	// InitrdLayers are the fully-qualified paths to the initramfs layers of the
+	// machine instance, in the order in which they are applied.  When set, the
+	// platform merges them and sets InitrdPath to the result.
+	InitrdLayers []string `json:"initrdLayers,omitempty"`
+
+	// ExitCode is the exit code of the machine once it has exited, or -1
+	// whilst it is running.
 	ExitCode int `json:"exitCode,omitempty"`
 
+	// ExitReason is the reason reported by the platform for the exit of the
+	// machine, e.g. `guest-panic`.
+	ExitReason string `json:"exitReason,omitempty"`
+
+	// Diagnostics is the in-host path to the diagnostics bundle, holding a
+	// memory dump and the tail of the console output, which was captured when
+	// the guest of the machine panicked.
+	Diagnostics string `json:"diagnostics,omitempty"`
+
 	// StartedAt represents when the machine was started.
 	StartedAt time.Time `json:"startedAt,omitempty"`
 
@@ -144,6 +346,35 @@ type MachineStatus struct {
 	// LogFile is the in-host path to the log file of the machine.
 	LogFile string `json:"logFile,omitempty"`
 
+	// ConsoleSocket is the in-host path to the unix socket of the serial
+	// console of the machine, if its platform supports attaching to it.
+	ConsoleSocket string `json:"consoleSocket,omitempty"`
+
+	// MetricsSocket is the in-host path to the unix socket over which the
+	// ukstore counters of the guest are read, if the machine was created with
+	// metrics enabled.
+	MetricsSocket string `json:"metricsSocket,omitempty"`
+
+	// CurrentMemory is the amount of memory in bytes which is currently
+	// available to the guest, as reported by its balloon device (if applicable).
+	CurrentMemory int64 `json:"currentMemory,omitempty"`
+
+	// TargetMemory is the amount of memory in bytes which the guest was last
+	// requested to make available through its balloon device (if applicable).
+	TargetMemory int64 `json:"targetMemory,omitempty"`
+
+	// Hardening records the confinement which was applied to the VMM of the
+	// machine, for audit.
+	Hardening MachineHardeningStatus `json:"hardening,omitempty"`
+
+	// Accelerator is the accelerator which the VMM of the machine uses, which
+	// is tcg if the machine is emulated in software.
+	Accelerator MachineAccelerator `json:"accelerator,omitempty"`
+
+	// Cgroup is the path of the cgroup which limits the resources of the VMM of
+	// the machine, if any.
+	Cgroup string `json:"cgroup,omitempty"`
+
 	// PlatformConfig is platform-specific attributes which are populated by the
 	// underlying machine service implementation.
 	PlatformConfig interface{} `json:"platformConfig,omitempty"`
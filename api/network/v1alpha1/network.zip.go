@@ -34,10 +34,51 @@ type NetworkSpec struct {
 	// range.
 	Netmask string `json:"netmask,omitempty"`
 
+	// The range of IP addresses in CIDR notation, within the subnet of the
+	// network, from which addresses are allocated to interfaces which do not
+	// request a specific address.  The whole subnet is used if it is empty.
+	IPRange string `json:"ipRange,omitempty"`
+
+	// IP addresses which are statically reserved for specific machines.
+	Reservations []NetworkReservation `json:"reservations,omitempty"`
+
 	// Network interfaces associated with this network.
 	Interfaces []NetworkInterfaceTemplateSpec `json:"interfaces,omitempty"`
 }
 
+// NetworkReservation reserves an IP address of the network for a machine, such
+// that the machine is always attached with the same address.
+type NetworkReservation struct {
+	// Name of the machine for which the address is reserved.
+	Machine string `json:"machine"`
+
+	// The reserved IPv4 address.
+	IP string `json:"ip"`
+}
+
+// Reservation returns the IP address which is reserved for the named machine.
+func (spec NetworkSpec) Reservation(machine string) (string, bool) {
+	for _, reservation := range spec.Reservations {
+		if reservation.Machine == machine {
+			return reservation.IP, true
+		}
+	}
+
+	return "", false
+}
+
+// ReservedFor returns the name of the machine for which the IP address is
+// reserved.
+func (spec NetworkSpec) ReservedFor(ip string) (string, bool) {
+	for _, reservation := range spec.Reservations {
+		if reservation.IP == ip {
+			return reservation.Machine, true
+		}
+	}
+
+	return "", false
+}
+
 // NetworkTemplateSpec describes the data a network should have when created
 // from a template.
 type NetworkTemplateSpec struct {
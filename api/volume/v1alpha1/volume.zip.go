@@ -11,6 +11,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// VolumeLabelAnonymous is the label which is set on volumes which were
+// created implicitly for a single machine rather than by name, such that they
+// can be removed together with the machine.
+const VolumeLabelAnonymous = "volume.kraftkit.sh/anonymous"
+
 type (
 	// Volume is the mutable API object that represents a volume.
 	Volume = zip.Object[VolumeSpec, VolumeStatus]
@@ -40,6 +45,10 @@ type VolumeSpec struct {
 	// Mark whether the volume is readonly.
 	ReadOnly bool `json:"readOnly,omitempty"`
 
+	// Options are driver-specific options which tune how the volume is exposed
+	// to the machine.
+	Options map[string]string `json:"options,omitempty"`
+
 	// Managed is a flag that indicates whether the volume is managed
 	// by kraftkit or not.
 	Managed bool `json:"managed,omitempty"`
@@ -0,0 +1,19 @@
+//go:build linux
+// +build linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package main
+
+import (
+	containerdshim "github.com/containerd/containerd/runtime/v2/shim"
+
+	"kraftkit.sh/internal/shim"
+)
+
+func main() {
+	containerdshim.Run(shim.RuntimeName, shim.New)
+}
@@ -7,6 +7,7 @@ package cmdfactory
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -182,6 +183,11 @@ func Main(ctx context.Context, cmd *cobra.Command) int {
 	expandRegisteredFlags(cmd)
 
 	if err := cmd.ExecuteContext(ctx); err != nil {
+		var exitErr *ExitCodeError
+		if errors.As(err, &exitErr) {
+			return exitErr.Code
+		}
+
 		log.G(ctx).Error(err)
 		return 1
 	}
@@ -194,13 +200,13 @@ func Main(ctx context.Context, cmd *cobra.Command) int {
 // CLI flags.
 func AttributeFlags(c *cobra.Command, obj any, args ...string) error {
 	var (
-		envs      []func()
 		arrays    = map[string]reflect.Value{}
 		slices    = map[string]reflect.Value{}
 		maps      = map[string]reflect.Value{}
 		optString = map[string]reflect.Value{}
 		optBool   = map[string]reflect.Value{}
 		optInt    = map[string]reflect.Value{}
+		rules     []*flagRule
 	)
 
 	for _, info := range fields(obj) {
@@ -252,81 +258,99 @@ func AttributeFlags(c *cobra.Command, obj any, args ...string) error {
 				dur = 0
 			}
 			flags.DurationVarP((*time.Duration)(unsafe.Pointer(v.Addr().Pointer())), name, alias, dur, usage)
-			continue
 		case types.ShellCommand:
 			flags.StringSliceVarP((*[]string)(unsafe.Pointer(v.Addr().Pointer())), name, alias, nil, usage)
-			continue
-		}
-
-		switch fieldType.Type.Kind() {
-		case reflect.Uint, reflect.Uint64:
-			flags.UintVarP((*uint)(unsafe.Pointer(v.Addr().Pointer())), name, alias, uint(defInt), usage)
-		case reflect.Int, reflect.Int64:
-			flags.IntVarP((*int)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defInt, usage)
-		case reflect.String:
-			flags.StringVarP((*string)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defValue, usage)
-			if err := flags.Set(name, strValue); err != nil {
-				return err
-			}
-		case reflect.Bool:
-			flags.BoolVarP((*bool)(unsafe.Pointer(v.Addr().Pointer())), name, alias, false, usage)
-			if err := flags.Set(name, strValue); err != nil {
-				return err
-			}
-		case reflect.Slice:
-			switch fieldType.Tag.Get("split") {
-			case "false":
-				arrays[name] = v
-				if ptr := (*[]string)(unsafe.Pointer(v.Addr().Pointer())); *ptr != nil {
-					flags.StringArrayVarP(ptr, name, alias, *ptr, usage)
-				} else {
-					flags.StringArrayP(name, alias, nil, usage)
+		default:
+			switch fieldType.Type.Kind() {
+			case reflect.Uint, reflect.Uint64:
+				flags.UintVarP((*uint)(unsafe.Pointer(v.Addr().Pointer())), name, alias, uint(defInt), usage)
+			case reflect.Int, reflect.Int64:
+				flags.IntVarP((*int)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defInt, usage)
+			case reflect.String:
+				flags.StringVarP((*string)(unsafe.Pointer(v.Addr().Pointer())), name, alias, defValue, usage)
+				if err := flags.Lookup(name).Value.Set(strValue); err != nil {
+					return err
 				}
-			default:
-				slices[name] = v
+			case reflect.Bool:
+				flags.BoolVarP((*bool)(unsafe.Pointer(v.Addr().Pointer())), name, alias, false, usage)
+				if err := flags.Lookup(name).Value.Set(strValue); err != nil {
+					return err
+				}
+			case reflect.Slice:
+				switch fieldType.Tag.Get("split") {
+				case "false":
+					arrays[name] = v
+					if ptr := (*[]string)(unsafe.Pointer(v.Addr().Pointer())); *ptr != nil {
+						flags.StringArrayVarP(ptr, name, alias, *ptr, usage)
+					} else {
+						flags.StringArrayP(name, alias, nil, usage)
+					}
+				default:
+					slices[name] = v
+					if ptr := (*[]string)(unsafe.Pointer(v.Addr().Pointer())); *ptr != nil {
+						flags.StringSliceVarP(ptr, name, alias, *ptr, usage)
+					} else {
+						flags.StringSliceP(name, alias, nil, usage)
+					}
+				}
+			case reflect.Map:
+				maps[name] = v
 				if ptr := (*[]string)(unsafe.Pointer(v.Addr().Pointer())); *ptr != nil {
 					flags.StringSliceVarP(ptr, name, alias, *ptr, usage)
 				} else {
 					flags.StringSliceP(name, alias, nil, usage)
 				}
-			}
-		case reflect.Map:
-			maps[name] = v
-			if ptr := (*[]string)(unsafe.Pointer(v.Addr().Pointer())); *ptr != nil {
-				flags.StringSliceVarP(ptr, name, alias, *ptr, usage)
-			} else {
-				flags.StringSliceP(name, alias, nil, usage)
-			}
-		case reflect.Pointer:
-			switch fieldType.Type.Elem().Kind() {
-			case reflect.Int, reflect.Int64:
-				optInt[name] = v
-				flags.IntP(name, alias, defInt, usage)
-			case reflect.String:
-				optString[name] = v
-				flags.StringP(name, alias, defValue, usage)
-			case reflect.Bool:
-				optBool[name] = v
-				flags.BoolP(name, alias, false, usage)
-			}
-			if strValue == "<nil>" && fieldType.Type.Elem().Kind() != reflect.String {
+			case reflect.Pointer:
+				switch fieldType.Type.Elem().Kind() {
+				case reflect.Int, reflect.Int64:
+					optInt[name] = v
+					flags.IntP(name, alias, defInt, usage)
+				case reflect.String:
+					optString[name] = v
+					flags.StringP(name, alias, defValue, usage)
+				case reflect.Bool:
+					optBool[name] = v
+					flags.BoolP(name, alias, false, usage)
+				}
+				if strValue != "<nil>" || fieldType.Type.Elem().Kind() == reflect.String {
+					if err := flags.Set(name, strValue); err != nil {
+						return err
+					}
+				}
+			case reflect.Struct:
+				if !v.CanAddr() {
+					continue
+				}
+
+				// Recursively set embedded anonymous structs
+				if err := AttributeFlags(c, v.Addr().Interface()); err != nil {
+					return err
+				}
+
 				continue
-			}
-			if err := flags.Set(name, strValue); err != nil {
-				return err
-			}
-		case reflect.Struct:
-			if !v.CanAddr() {
+			default:
+				// Unknown kind on field " + fieldType.Name + " on " + objValue.Type().Name()
 				continue
 			}
+		}
 
-			// Recursively set embedded anonymous structs
-			if err := AttributeFlags(c, v.Addr().Interface()); err != nil {
+		// Flags of kinds which are not populated from strValue above take the
+		// value of their environmental variable as their initial value, such that
+		// it is still replaced by the value provided on the command line.
+		if envName != "" {
+			if err := flags.SetAnnotation(name, AnnotationFlagEnv, []string{envName}); err != nil {
 				return err
 			}
-		default:
-			// Unknown kind on field " + fieldType.Name + " on " + objValue.Type().Name()
-			continue
+
+			switch fieldType.Type.Kind() {
+			case reflect.String, reflect.Bool, reflect.Pointer:
+			default:
+				if envValue := os.Getenv(envName); envValue != "" {
+					if err := setFlag(flags.Lookup(name), envName, envValue); err != nil {
+						return err
+					}
+				}
+			}
 		}
 
 		hidden := fieldType.Tag.Get("hidden")
@@ -335,11 +359,44 @@ func AttributeFlags(c *cobra.Command, obj any, args ...string) error {
 				return err
 			}
 		}
+
+		if message, ok := fieldType.Tag.Lookup("deprecated"); ok {
+			if err := flags.SetAnnotation(name, AnnotationFlagDeprecated, []string{message}); err != nil {
+				return err
+			}
+			if err := flags.MarkHidden(name); err != nil {
+				return err
+			}
+		}
+
+		if group := fieldType.Tag.Get("group"); group != "" {
+			if err := flags.SetAnnotation(name, AnnotationFlagGroup, []string{group}); err != nil {
+				return err
+			}
+		}
+
+		if rule := newFlagRule(name, fieldType.Tag.Get); rule != nil {
+			rules = append(rules, rule)
+
+			if len(rule.enum) > 0 {
+				if err := flags.SetAnnotation(name, AnnotationFlagEnum, rule.enum); err != nil {
+					return err
+				}
+
+				_ = c.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(rule.enum, cobra.ShellCompDirectiveNoFileComp))
+			}
+		}
+	}
+
+	// Check the constraints of the flags before any user-defined pre-run, such
+	// that it can rely on them.
+	if len(rules) > 0 {
+		c.PreRunE = validate(c.PreRunE, rules)
 	}
 
-	c.PersistentPreRunE = bind(c.PersistentPreRunE, arrays, slices, maps, optInt, optBool, optString, envs)
-	c.PreRunE = bind(c.PreRunE, arrays, slices, maps, optInt, optBool, optString, envs)
-	c.RunE = bind(c.RunE, arrays, slices, maps, optInt, optBool, optString, envs)
+	c.PersistentPreRunE = bind(c.PersistentPreRunE, arrays, slices, maps, optInt, optBool, optString)
+	c.PreRunE = bind(c.PreRunE, arrays, slices, maps, optInt, optBool, optString)
+	c.RunE = bind(c.RunE, arrays, slices, maps, optInt, optBool, optString)
 
 	return nil
 }
@@ -522,14 +579,26 @@ func bind(next func(*cobra.Command, []string) error,
 	optInt map[string]reflect.Value,
 	optBool map[string]reflect.Value,
 	optString map[string]reflect.Value,
-	envs []func(),
 ) func(*cobra.Command, []string) error {
 	if next == nil {
 		return nil
 	}
 	return func(cmd *cobra.Command, args []string) error {
-		for _, envCallback := range envs {
-			envCallback()
+		if _, ok := cmd.Annotations[annotationBound]; !ok {
+			if err := bindEnv(cmd); err != nil {
+				return err
+			}
+			if err := bindDefaults(cmd); err != nil {
+				return err
+			}
+			if err := checkDeprecated(cmd); err != nil {
+				return err
+			}
+
+			if cmd.Annotations == nil {
+				cmd.Annotations = map[string]string{}
+			}
+			cmd.Annotations[annotationBound] = "true"
 		}
 		if err := assignArrays(cmd, arrays); err != nil {
 			return err
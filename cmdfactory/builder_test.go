@@ -6,8 +6,11 @@
 package cmdfactory
 
 import (
+	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -106,6 +109,283 @@ func TestAttributeFlags_StructFields(t *testing.T) {
 	})
 }
 
+func TestAttributeFlags_Constraints(t *testing.T) {
+	type TestObj struct {
+		Output string   `long:"output" usage:"Output format" enum:"table,json" default:"table"`
+		Arch   []string `long:"arch" usage:"Architectures" enum:"x86_64,arm64"`
+		All    bool     `long:"all" usage:"All machines" conflicts-with:"name"`
+		Name   string   `long:"name" usage:"Machine name" group:"Machine"`
+		Port   int      `long:"port" usage:"Port" requires:"name" group:"Machine"`
+	}
+
+	execute := func(args ...string) (*cobra.Command, error) {
+		cmd := makeCommand("kraft", "cmd1")
+		cmd.Root().SilenceErrors = true
+		cmd.Root().SilenceUsage = true
+
+		if err := AttributeFlags(cmd, &TestObj{}); err != nil {
+			t.Fatal("Failed to associate flags with struct fields:", err)
+		}
+
+		cmd.Root().SetArgs(append([]string{"cmd1"}, args...))
+		_, err := cmd.ExecuteC()
+
+		return cmd, err
+	}
+
+	testCases := []struct {
+		desc  string
+		args  []string
+		error string
+	}{
+		{
+			desc: "no flags",
+		},
+		{
+			desc: "valid flags",
+			args: []string{"--output=json", "--arch=arm64", "--name=foo", "--port=80"},
+		},
+		{
+			desc:  "invalid enum value",
+			args:  []string{"--output=yaml"},
+			error: `invalid value "yaml" for --output: must be one of: table, json`,
+		},
+		{
+			desc:  "invalid enum slice value",
+			args:  []string{"--arch=x86_64,riscv64"},
+			error: `invalid value "riscv64" for --arch: must be one of: x86_64, arm64`,
+		},
+		{
+			desc:  "conflicting flags",
+			args:  []string{"--all", "--name=foo"},
+			error: "--all cannot be used with --name",
+		},
+		{
+			desc:  "missing required flag",
+			args:  []string{"--port=80"},
+			error: "--port requires --name to be set",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := execute(tc.args...)
+			if tc.error == "" && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			} else if tc.error != "" && (err == nil || err.Error() != tc.error) {
+				t.Fatalf("Expected error %q, got %v", tc.error, err)
+			}
+
+			var flagErr *FlagError
+			if err != nil && !errors.As(err, &flagErr) {
+				t.Errorf("Expected a flag error, got %T", err)
+			}
+		})
+	}
+
+	t.Run("Help groups", func(t *testing.T) {
+		cmd, _ := execute()
+
+		ungrouped, groups, grouped := groupFlags(cmd.LocalFlags())
+		if expect, got := []string{"Machine"}, groups; !equalSlices(got, expect) {
+			t.Fatalf("Unexpected flag groups. Expected %v, got %v", expect, got)
+		}
+		if grouped["Machine"].Lookup("port") == nil || ungrouped.Lookup("port") != nil {
+			t.Errorf("Expected --port to be listed in its group only")
+		}
+		if ungrouped.Lookup("output") == nil {
+			t.Errorf("Expected --output to be listed without a group")
+		}
+	})
+}
+
+func TestAttributeFlags_Env(t *testing.T) {
+	type TestObj struct {
+		String   string        `long:"string" usage:"String arg" env:"KRAFTKIT_TEST_STRING"`
+		Int      int           `long:"int" usage:"Integer arg" env:"KRAFTKIT_TEST_INT"`
+		Duration time.Duration `long:"duration" usage:"Duration arg" env:"KRAFTKIT_TEST_DURATION"`
+		Slice    []string      `long:"slice" usage:"Slice arg" env:"KRAFTKIT_TEST_SLICE"`
+		Bool     bool          `long:"auto-bool" usage:"Boolean arg"`
+		Auto     []string      `long:"auto-slice" usage:"Slice arg"`
+		Override string        `long:"override" usage:"String arg"`
+	}
+
+	t.Setenv("KRAFTKIT_TEST_STRING", "env")
+	t.Setenv("KRAFTKIT_TEST_INT", "42")
+	t.Setenv("KRAFTKIT_TEST_DURATION", "5s")
+	t.Setenv("KRAFTKIT_TEST_SLICE", "a,b")
+	t.Setenv("KRAFT_CMD1_AUTO_BOOL", "true")
+	t.Setenv("KRAFT_CMD1_AUTO_SLICE", "c,d")
+	t.Setenv("KRAFT_CMD1_OVERRIDE", "env")
+
+	cmd := makeCommand("kraft", "cmd1")
+	obj := &TestObj{}
+
+	if err := AttributeFlags(cmd, obj); err != nil {
+		t.Fatal("Failed to associate flags with struct fields:", err)
+	}
+
+	cmd.Root().SetArgs([]string{"cmd1", "--slice=e", "--override=flag"})
+	if _, err := cmd.ExecuteC(); err != nil {
+		t.Fatal("Failed to execute command:", err)
+	}
+
+	if expect, got := "env", obj.String; expect != got {
+		t.Errorf("Unexpected value for string struct field from environment. Expected %q, got %q", expect, got)
+	}
+	if expect, got := 42, obj.Int; expect != got {
+		t.Errorf("Unexpected value for int struct field from environment. Expected %d, got %d", expect, got)
+	}
+	if expect, got := 5*time.Second, obj.Duration; expect != got {
+		t.Errorf("Unexpected value for duration struct field from environment. Expected %s, got %s", expect, got)
+	}
+	if expect, got := []string{"e"}, obj.Slice; !equalSlices(got, expect) {
+		t.Errorf("Unexpected value for slice struct field provided by flag. Expected %v, got %v", expect, got)
+	}
+	if expect, got := true, obj.Bool; expect != got {
+		t.Errorf("Unexpected value for bool struct field from automatic environment. Expected %t, got %t", expect, got)
+	}
+	if expect, got := []string{"c", "d"}, obj.Auto; !equalSlices(got, expect) {
+		t.Errorf("Unexpected value for slice struct field from automatic environment. Expected %v, got %v", expect, got)
+	}
+	if expect, got := "flag", obj.Override; expect != got {
+		t.Errorf("Unexpected value for string struct field provided by flag. Expected %q, got %q", expect, got)
+	}
+}
+
+func TestAttributeFlags_CommandDefaults(t *testing.T) {
+	type TestObj struct {
+		Output string   `long:"output" usage:"String arg" default:"table"`
+		Plat   string   `long:"plat" usage:"String arg"`
+		Memory string   `long:"memory" usage:"String arg"`
+		Slice  []string `long:"slice" usage:"Slice arg"`
+		Env    string   `long:"env" usage:"String arg"`
+	}
+
+	t.Setenv("KRAFT_CMD1_SUBCMD1_ENV", "env")
+
+	cmd := makeCommand("kraft", "cmd1", "subcmd1")
+	obj := &TestObj{}
+
+	if err := AttributeFlags(cmd, obj); err != nil {
+		t.Fatal("Failed to associate flags with struct fields:", err)
+	}
+
+	ctx := WithCommandDefaults(context.Background(), CommandDefaults{
+		"cmd1": {
+			"output": "json",
+			"plat":   "fc",
+		},
+		"cmd1 subcmd1": {
+			"plat":   "qemu",
+			"memory": "64Mi",
+			"slice":  "a,b",
+			"env":    "config",
+		},
+	})
+
+	cmd.Root().SetArgs([]string{"cmd1", "subcmd1", "--memory=128Mi"})
+	if _, err := cmd.ExecuteContextC(ctx); err != nil {
+		t.Fatal("Failed to execute command:", err)
+	}
+
+	if expect, got := "json", obj.Output; expect != got {
+		t.Errorf("Unexpected value for default of parent command. Expected %q, got %q", expect, got)
+	}
+	if expect, got := "qemu", obj.Plat; expect != got {
+		t.Errorf("Unexpected value for default of command. Expected %q, got %q", expect, got)
+	}
+	if expect, got := "128Mi", obj.Memory; expect != got {
+		t.Errorf("Unexpected value for flag provided on the command line. Expected %q, got %q", expect, got)
+	}
+	if expect, got := []string{"a", "b"}, obj.Slice; !equalSlices(got, expect) {
+		t.Errorf("Unexpected value for slice default of command. Expected %v, got %v", expect, got)
+	}
+	if expect, got := "env", obj.Env; expect != got {
+		t.Errorf("Unexpected value for flag provided through the environment. Expected %q, got %q", expect, got)
+	}
+	if cmd.Flags().Changed("plat") {
+		t.Errorf("Expected flag set from the defaults to remain unchanged")
+	}
+}
+
+func TestAttributeFlags_Deprecated(t *testing.T) {
+	type TestObj struct {
+		Old string `long:"old" usage:"String arg" deprecated:"use --new instead"`
+		New string `long:"new" usage:"String arg"`
+	}
+
+	execute := func(strict bool, args ...string) error {
+		cmd := makeCommand("kraft", "cmd1")
+		cmd.Root().SilenceErrors = true
+		cmd.Root().SilenceUsage = true
+		DeprecateAlias(cmd, "cmd0")
+
+		if err := AttributeFlags(cmd, &TestObj{}); err != nil {
+			t.Fatal("Failed to associate flags with struct fields:", err)
+		}
+
+		if !cmd.PersistentFlags().Lookup("old").Hidden {
+			t.Errorf("Expected deprecated flag to be hidden")
+		}
+		if aliases := visibleAliases(cmd); len(aliases) != 0 {
+			t.Errorf("Expected deprecated aliases to be hidden, got %v", aliases)
+		}
+
+		cmd.Root().SetArgs(args)
+		_, err := cmd.Root().ExecuteContextC(WithStrict(context.Background(), strict))
+
+		return err
+	}
+
+	testCases := []struct {
+		desc  string
+		args  []string
+		error string
+	}{
+		{
+			desc: "no deprecations",
+			args: []string{"cmd1", "--new=val"},
+		},
+		{
+			desc:  "deprecated flag",
+			args:  []string{"cmd1", "--old=val"},
+			error: "--old is deprecated: use --new instead (strict mode)",
+		},
+		{
+			desc:  "deprecated alias",
+			args:  []string{"cmd0"},
+			error: "'kraft cmd0' is deprecated: use 'kraft cmd1' instead (strict mode)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if err := execute(false, tc.args...); err != nil {
+				t.Fatalf("Unexpected error outside of strict mode: %v", err)
+			}
+
+			err := execute(true, tc.args...)
+			if tc.error == "" && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			} else if tc.error != "" && (err == nil || err.Error() != tc.error) {
+				t.Fatalf("Expected error %q, got %v", tc.error, err)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	cmd := makeCommand("kraft", "pkg", "list")
+
+	if expect, got := "KRAFT_PKG_LIST_NO_CACHE", EnvName(cmd, "no-cache"); expect != got {
+		t.Errorf("Unexpected environmental variable name. Expected %q, got %q", expect, got)
+	}
+	if expect, got := "KRAFT_LOG_LEVEL", EnvName(cmd.Root(), "log-level"); expect != got {
+		t.Errorf("Unexpected environmental variable name. Expected %q, got %q", expect, got)
+	}
+}
+
 func TestFilterOutRegisteredFlags(t *testing.T) {
 	flagOverridesOrig := copyFlagOverrides()
 	t.Cleanup(func() { flagOverrides = flagOverridesOrig })
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package cmdfactory
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/log"
+)
+
+// CommandDefaults holds the default values of the flags of commands, keyed by
+// the path of the command without the name of the root command, e.g. `run` or
+// `pkg list`, and then by the long name of the flag.  The defaults of a
+// command also apply to the flags of the same name of all of its subcommands,
+// unless they are overridden by the defaults of the subcommand.
+type CommandDefaults map[string]map[string]string
+
+// annotationBound is the annotation of a command whose flags have been set
+// from the environment and CommandDefaults, which is done only once even
+// though multiple of its run functions are bound.
+const annotationBound = "cmdfactory:bound"
+
+// commandDefaultsKey is used to retrieve the CommandDefaults from a context.
+type commandDefaultsKey struct{}
+
+// WithCommandDefaults returns a context which holds the provided defaults of
+// the flags of commands.
+func WithCommandDefaults(ctx context.Context, defaults CommandDefaults) context.Context {
+	return context.WithValue(ctx, commandDefaultsKey{}, defaults)
+}
+
+// commandPath returns the path of the command without the name of the root
+// command.
+func commandPath(cmd *cobra.Command) string {
+	return strings.Join(strings.Fields(cmd.CommandPath())[1:], " ")
+}
+
+// fromEnv returns whether the flag was set from an environmental variable.
+func fromEnv(flag *pflag.Flag) bool {
+	names, ok := flag.Annotations[AnnotationFlagEnv]
+	return ok && len(names) > 0 && os.Getenv(names[0]) != ""
+}
+
+// bindDefaults sets the flags of the command which were neither provided on
+// the command line nor through an environmental variable to the defaults of
+// the command, or of its closest ancestor which has a default for the flag, as
+// held by the context of the command.  Unlike flags set through either of the
+// former, the flags remain unchanged, such that commands can still apply their
+// own, more specific, defaults, e.g. those of a preset.
+func bindDefaults(cmd *cobra.Command) error {
+	if cmd.Context() == nil {
+		return nil
+	}
+
+	defaults, ok := cmd.Context().Value(commandDefaultsKey{}).(CommandDefaults)
+	if !ok || len(defaults) == 0 {
+		return nil
+	}
+
+	values := map[string]string{}
+
+	var chain []*cobra.Command
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		chain = append([]*cobra.Command{c}, chain...)
+	}
+
+	for _, c := range chain {
+		for name, value := range defaults[commandPath(c)] {
+			values[name] = value
+		}
+	}
+
+	for name := range defaults[commandPath(cmd)] {
+		if cmd.Flags().Lookup(name) == nil {
+			log.G(cmd.Context()).Warnf("ignoring default of unknown flag --%s of command '%s' in configuration", name, commandPath(cmd))
+		}
+	}
+
+	for name, value := range values {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed || fromEnv(flag) {
+			continue
+		}
+
+		if err := setFlag(flag, "commands."+commandPath(cmd), value); err != nil {
+			return err
+		}
+
+		flag.DefValue = flag.Value.String()
+	}
+
+	return nil
+}
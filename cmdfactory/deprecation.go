@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package cmdfactory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/log"
+)
+
+const (
+	// AnnotationDeprecated marks a command as deprecated.  Its value is shown
+	// alongside the deprecation warning, e.g. which command to use instead.  The
+	// command is hidden from help.
+	AnnotationDeprecated = "deprecated"
+
+	// AnnotationDeprecatedAliases holds the comma-separated aliases of a command
+	// which are deprecated, as set by DeprecateAlias.
+	AnnotationDeprecatedAliases = "deprecated:aliases"
+
+	// AnnotationFlagDeprecated is the flag annotation which holds the message of
+	// a deprecated flag, as set by the `deprecated` tag.
+	AnnotationFlagDeprecated = "deprecated"
+)
+
+// strictKey is used to retrieve whether strict mode is enabled from a context.
+type strictKey struct{}
+
+// WithStrict returns a context in which deprecation warnings are turned into
+// errors if strict is set, e.g. to catch the use of deprecated commands and
+// flags in CI pipelines.
+func WithStrict(ctx context.Context, strict bool) context.Context {
+	return context.WithValue(ctx, strictKey{}, strict)
+}
+
+// IsStrict returns whether deprecation warnings are errors in the context.
+func IsStrict(ctx context.Context) bool {
+	strict, _ := ctx.Value(strictKey{}).(bool)
+	return strict
+}
+
+// DeprecateAlias forwards the provided names to the command, for example after
+// it was renamed, and warns that they are deprecated whenever the command is
+// invoked through them.  The aliases are not shown in help.
+func DeprecateAlias(cmd *cobra.Command, aliases ...string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+
+	deprecated := deprecatedAliases(cmd)
+
+	for _, alias := range aliases {
+		if !slices.Contains(cmd.Aliases, alias) {
+			cmd.Aliases = append(cmd.Aliases, alias)
+		}
+
+		if !slices.Contains(deprecated, alias) {
+			deprecated = append(deprecated, alias)
+		}
+	}
+
+	cmd.Annotations[AnnotationDeprecatedAliases] = strings.Join(deprecated, ",")
+}
+
+// deprecatedAliases returns the aliases of the command which are deprecated.
+func deprecatedAliases(cmd *cobra.Command) []string {
+	return tagList(cmd.Annotations[AnnotationDeprecatedAliases])
+}
+
+// visibleAliases returns the aliases of the command which are not deprecated.
+func visibleAliases(cmd *cobra.Command) []string {
+	deprecated := deprecatedAliases(cmd)
+
+	var aliases []string
+	for _, alias := range cmd.Aliases {
+		if !slices.Contains(deprecated, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases
+}
+
+// deprecation formats a deprecation warning of the subject with the optional
+// message.
+func deprecation(subject, message string) string {
+	if message == "" {
+		return subject + " is deprecated"
+	}
+
+	return subject + " is deprecated: " + message
+}
+
+// checkDeprecated warns about the use of the command, the alias through which
+// it was invoked, or any of its flags if they are deprecated.  In strict mode
+// the first deprecation is returned as an error instead.
+func checkDeprecated(cmd *cobra.Command) error {
+	var warnings []string
+
+	if message, ok := cmd.Annotations[AnnotationDeprecated]; ok {
+		warnings = append(warnings, deprecation(fmt.Sprintf("'%s'", cmd.CommandPath()), message))
+	}
+
+	if calledAs := cmd.CalledAs(); calledAs != cmd.Name() && slices.Contains(deprecatedAliases(cmd), calledAs) {
+		path := strings.TrimSuffix(cmd.CommandPath(), cmd.Name()) + calledAs
+		warnings = append(warnings, deprecation(fmt.Sprintf("'%s'", path), fmt.Sprintf("use '%s' instead", cmd.CommandPath())))
+	}
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		message, ok := flag.Annotations[AnnotationFlagDeprecated]
+		if !ok || !(flag.Changed || fromEnv(flag)) {
+			return
+		}
+
+		warnings = append(warnings, deprecation("--"+flag.Name, strings.Join(message, "")))
+	})
+
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if IsStrict(ctx) {
+		return fmt.Errorf("%s (strict mode)", warnings[0])
+	}
+
+	for _, warning := range warnings {
+		log.G(ctx).Warn(warning)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package cmdfactory
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// AnnotationFlagEnv is the flag annotation which holds the name of the
+	// environmental variable of the flag, as set by the `env` tag.
+	AnnotationFlagEnv = "env:name"
+
+	// EnvPrefix is the prefix of the environmental variables through which the
+	// flags without an `env` tag can be set.
+	EnvPrefix = "KRAFT"
+)
+
+var envReplacer = regexp.MustCompile("[^A-Z0-9]+")
+
+// EnvName returns the name of the environmental variable through which the
+// flag with the provided name of the command can be set, in the format
+// KRAFT_<COMMAND>_<FLAG>, e.g. KRAFT_PKG_LIST_REMOTE for `kraft pkg list
+// --remote`.  Flags with an `env` tag are set through the variable named in
+// the tag instead.
+func EnvName(cmd *cobra.Command, flag string) string {
+	parts := append([]string{EnvPrefix}, strings.Fields(cmd.CommandPath())[1:]...)
+	parts = append(parts, flag)
+
+	for i := range parts {
+		parts[i] = strings.Trim(envReplacer.ReplaceAllString(strings.ToUpper(parts[i]), "_"), "_")
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// setFlag sets the flag to the provided value which originates from source,
+// e.g. the name of an environmental variable.  Slice and map flags take a
+// comma-separated list of values, replacing, rather than appending to, their
+// current values.
+func setFlag(flag *pflag.Flag, source, value string) error {
+	if flag == nil {
+		return nil
+	}
+
+	var err error
+
+	switch v := flag.Value.(type) {
+	case pflag.SliceValue:
+		if flag.Value.Type() == "stringArray" {
+			err = v.Replace([]string{value})
+		} else {
+			err = v.Replace(strings.Split(value, ","))
+		}
+	default:
+		err = flag.Value.Set(value)
+	}
+
+	if err != nil {
+		return FlagErrorf("invalid value %q of %s for --%s: %v", value, source, flag.Name, err)
+	}
+
+	return nil
+}
+
+// definingCommand returns the command, out of the provided command and its
+// ancestors, which defines the flag.
+func definingCommand(cmd *cobra.Command, flag *pflag.Flag) *cobra.Command {
+	for c := cmd.Parent(); c != nil; c = c.Parent() {
+		if c.PersistentFlags().Lookup(flag.Name) == flag {
+			return c
+		}
+	}
+
+	return cmd
+}
+
+// bindEnv sets the flags of the command which were not provided on the command
+// line and do not have an `env` tag from their environmental variables, as
+// named by EnvName.  Flags which are set are marked as changed, such that
+// they are treated as if they were provided on the command line.
+func bindEnv(cmd *cobra.Command) error {
+	var err error
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed {
+			return
+		}
+
+		if _, ok := flag.Annotations[AnnotationFlagEnv]; ok {
+			return
+		}
+
+		envName := EnvName(definingCommand(cmd, flag), flag.Name)
+
+		value := os.Getenv(envName)
+		if value == "" {
+			return
+		}
+
+		if err = setFlag(flag, envName, value); err == nil {
+			flag.Changed = true
+		}
+	})
+
+	return err
+}
@@ -37,6 +37,16 @@ func (fe *FlagError) Unwrap() error {
 // ErrSilent is an error that triggers exit code 1 without any error messaging
 var ErrSilent = errors.New("ErrSilent")
 
+// ExitCodeError is an error that triggers the provided exit code without any
+// error messaging, e.g. to propagate the exit code of a machine.
+type ExitCodeError struct {
+	Code int
+}
+
+func (ee *ExitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", ee.Code)
+}
+
 // ErrCancel signals user-initiated cancellation
 var ErrCancel = errors.New("ErrCancel")
 
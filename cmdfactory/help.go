@@ -35,6 +35,9 @@ func rootUsageFunc(command *cobra.Command) error {
 			if _, ok := c.Annotations[AnnotationHelpHidden]; ok {
 				continue
 			}
+			if _, ok := c.Annotations[AnnotationDeprecated]; ok {
+				continue
+			}
 			command.Printf("  %s\n", c.Name())
 		}
 		return nil
@@ -145,10 +148,17 @@ func rootHelpFunc(cmd *cobra.Command, args []string) {
 	}
 	helpEntries = append(helpEntries, helpEntry{"USAGE", cmd.UseLine()})
 
-	if len(cmd.Aliases) > 0 {
+	if aliases := visibleAliases(cmd); len(aliases) > 0 {
 		helpEntries = append(helpEntries, helpEntry{
 			title: "ALIASES",
-			body:  strings.Join(cmd.Aliases, " "),
+			body:  strings.Join(aliases, " "),
+		})
+	}
+
+	if message, ok := cmd.Annotations[AnnotationDeprecated]; ok {
+		helpEntries = append(helpEntries, helpEntry{
+			title: "DEPRECATED",
+			body:  deprecation("This command", message),
 		})
 	}
 
@@ -163,6 +173,9 @@ func rootHelpFunc(cmd *cobra.Command, args []string) {
 			if _, ok := c.Annotations[AnnotationHelpHidden]; ok {
 				continue
 			}
+			if _, ok := c.Annotations[AnnotationDeprecated]; ok {
+				continue
+			}
 
 			group, ok := c.Annotations[AnnotationHelpGroup]
 			if !ok {
@@ -194,6 +207,9 @@ func rootHelpFunc(cmd *cobra.Command, args []string) {
 		if _, ok := c.Annotations[AnnotationHelpHidden]; ok {
 			continue
 		}
+		if _, ok := c.Annotations[AnnotationDeprecated]; ok {
+			continue
+		}
 
 		// Ignore if already in a printable group
 		if group, ok := c.Annotations[AnnotationHelpGroup]; ok {
@@ -243,11 +259,20 @@ func rootHelpFunc(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	flagUsages := cmd.LocalFlags().FlagUsages()
+	ungroupedFlags, flagGroups, groupedFlags := groupFlags(cmd.LocalFlags())
+
+	flagUsages := ungroupedFlags.FlagUsages()
 	if flagUsages != "" {
 		helpEntries = append(helpEntries, helpEntry{"FLAGS", dedent(flagUsages)})
 	}
 
+	for _, group := range flagGroups {
+		helpEntries = append(helpEntries, helpEntry{
+			title: strings.ToUpper(group) + " FLAGS",
+			body:  dedent(groupedFlags[group].FlagUsages()),
+		})
+	}
+
 	inheritedFlagUsages := cmd.InheritedFlags().FlagUsages()
 	if inheritedFlagUsages != "" {
 		helpEntries = append(helpEntries, helpEntry{"INHERITED FLAGS", dedent(inheritedFlagUsages)})
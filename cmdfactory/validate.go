@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package cmdfactory
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// AnnotationFlagGroup is the flag annotation which holds the name of the
+	// help group in which the flag is displayed, as set by the `group` tag.
+	AnnotationFlagGroup = "help:flag-group"
+
+	// AnnotationFlagEnum is the flag annotation which holds the values which
+	// the flag accepts, as set by the `enum` tag.
+	AnnotationFlagEnum = "validate:enum"
+)
+
+// flagRule holds the constraints of a single flag as declared through the
+// `conflicts-with`, `requires` and `enum` tags of its structure attribute.
+type flagRule struct {
+	name      string
+	conflicts []string
+	requires  []string
+	enum      []string
+}
+
+// newFlagRule parses the constraints of the flag with the provided name from
+// the tags of its structure attribute.  A nil rule is returned if the flag has
+// no constraints.
+func newFlagRule(name string, tag func(string) string) *flagRule {
+	rule := flagRule{
+		name:      name,
+		conflicts: tagList(tag("conflicts-with")),
+		requires:  tagList(tag("requires")),
+		enum:      tagList(tag("enum")),
+	}
+
+	if len(rule.conflicts) == 0 && len(rule.requires) == 0 && len(rule.enum) == 0 {
+		return nil
+	}
+
+	return &rule
+}
+
+// tagList splits a comma-separated tag value into its trimmed, non-empty
+// elements.
+func tagList(value string) []string {
+	var list []string
+
+	for _, elem := range strings.Split(value, ",") {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			list = append(list, elem)
+		}
+	}
+
+	return list
+}
+
+// flagValues returns the individual values of the flag, which is more than one
+// for slice and array flags.
+func flagValues(flag *pflag.Flag) []string {
+	if sv, ok := flag.Value.(pflag.SliceValue); ok {
+		return sv.GetSlice()
+	}
+
+	return []string{flag.Value.String()}
+}
+
+// isSet returns whether the flag holds a value other than its default.  This
+// is used in favour of pflag's Changed, which is not set when AttributeFlags
+// populates a flag from its structure attribute or the environmental variable
+// of its `env` tag.
+func isSet(flag *pflag.Flag) bool {
+	return flag != nil && flag.Value.String() != flag.DefValue
+}
+
+// check validates the flag against its constraints once the flags of the
+// command have been parsed.  Conflicts and requirements only apply to flags
+// which were set to a value other than their default.
+func (rule *flagRule) check(cmd *cobra.Command) error {
+	flag := cmd.Flags().Lookup(rule.name)
+	if flag == nil {
+		return nil
+	}
+
+	if len(rule.enum) > 0 {
+		for _, value := range flagValues(flag) {
+			if value == "" {
+				continue
+			}
+
+			if !slices.Contains(rule.enum, value) {
+				return FlagErrorf("invalid value %q for --%s: must be one of: %s", value, rule.name, strings.Join(rule.enum, ", "))
+			}
+		}
+	}
+
+	if !isSet(flag) {
+		return nil
+	}
+
+	for _, name := range rule.conflicts {
+		if isSet(cmd.Flags().Lookup(name)) {
+			return FlagErrorf("--%s cannot be used with --%s", rule.name, name)
+		}
+	}
+
+	for _, name := range rule.requires {
+		if !isSet(cmd.Flags().Lookup(name)) {
+			return FlagErrorf("--%s requires --%s to be set", rule.name, name)
+		}
+	}
+
+	return nil
+}
+
+// validate checks the provided rules before calling next, such that commands
+// do not have to check the constraints of their flags themselves.
+func validate(next func(*cobra.Command, []string) error, rules []*flagRule) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		for _, rule := range rules {
+			if err := rule.check(cmd); err != nil {
+				return err
+			}
+		}
+
+		if next != nil {
+			return next(cmd, args)
+		}
+
+		return nil
+	}
+}
+
+// groupFlags splits the provided flags into those without a help group and
+// those of each help group, in alphabetical order of the groups.
+func groupFlags(flags *pflag.FlagSet) (*pflag.FlagSet, []string, map[string]*pflag.FlagSet) {
+	ungrouped := pflag.NewFlagSet("", pflag.ContinueOnError)
+	grouped := map[string]*pflag.FlagSet{}
+
+	var groups []string
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		group, ok := flag.Annotations[AnnotationFlagGroup]
+		if !ok || len(group) == 0 {
+			ungrouped.AddFlag(flag)
+			return
+		}
+
+		if _, ok := grouped[group[0]]; !ok {
+			grouped[group[0]] = pflag.NewFlagSet(group[0], pflag.ContinueOnError)
+			groups = append(groups, group[0])
+		}
+
+		grouped[group[0]].AddFlag(flag)
+	})
+
+	slices.Sort(groups)
+
+	return ungrouped, groups, grouped
+}
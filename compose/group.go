@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package compose
+
+import (
+	"github.com/compose-spec/compose-go/v2/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	groupapi "kraftkit.sh/api/group/v1alpha1"
+)
+
+// Replicas returns the number of machines of the service, as set by either
+// its `deploy.replicas` or its `scale` attribute, and whether it was set.
+func Replicas(service types.ServiceConfig) (int, bool) {
+	if service.Deploy != nil && service.Deploy.Replicas != nil {
+		return *service.Deploy.Replicas, true
+	}
+
+	if service.Scale != nil {
+		return *service.Scale, true
+	}
+
+	return 1, false
+}
+
+// IsReplicated returns whether the machines of the service are managed as a
+// group named after the service, rather than as a single machine.
+func IsReplicated(service types.ServiceConfig) bool {
+	replicas, ok := Replicas(service)
+	return ok && replicas != 1
+}
+
+// IsServiceMachine returns whether the machine belongs to the service, either
+// as its single machine or as a machine of its group.
+func IsServiceMachine(service types.ServiceConfig, machine metav1.ObjectMeta) bool {
+	return machine.Name == service.ContainerName ||
+		machine.Labels[groupapi.GroupLabelName] == service.ContainerName
+}
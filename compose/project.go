@@ -225,6 +225,12 @@ func (project *Project) AssignIPs(ctx context.Context) error {
 				continue
 			}
 
+			// The machines of a replicated service share its configuration
+			// and are hence addressed by the network instead.
+			if IsReplicated(service) {
+				continue
+			}
+
 			// Start at the network's subnet IP and increment until we find
 			// a free one
 			_, subnet, err := net.ParseCIDR(project.Networks[name].Ipam.Config[0].Subnet)
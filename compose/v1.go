@@ -33,7 +33,8 @@ type v1Compose struct {
 var ErrInvalidComposefile = fmt.Errorf("the Composefile for the project is either missing or invalid")
 
 func NewComposeProjectV1(ctx context.Context, opts ...any) (composev1.ComposeService, error) {
-	embeddedStore, err := store.NewEmbeddedStore[composev1.ComposeSpec, composev1.ComposeStatus](
+	embeddedStore, err := store.New[composev1.ComposeSpec, composev1.ComposeStatus](
+		store.Backend(config.G[config.KraftKit](ctx).StoreBackend),
 		filepath.Join(
 			config.G[config.KraftKit](ctx).RuntimeDir,
 			"composev1",
@@ -84,7 +85,7 @@ func (v1 *v1Compose) refreshRunningServices(ctx context.Context, embeddedProject
 	for _, machine := range embeddedProject.Status.Machines {
 		isService := false
 		for _, service := range project.Services {
-			if service.ContainerName == machine.Name {
+			if IsServiceMachine(service, machine) {
 				isService = true
 				break
 			}
@@ -112,7 +113,7 @@ func (v1 *v1Compose) refreshRunningServices(ctx context.Context, embeddedProject
 		}
 		isService := false
 		for _, service := range project.Services {
-			if service.ContainerName == m.Name {
+			if IsServiceMachine(service, m.ObjectMeta) {
 				isService = true
 				break
 			}
@@ -12,6 +12,8 @@ type AuthConfig struct {
 	Token     string `yaml:"token" env:"KRAFTKIT_AUTH_%s_TOKEN" long:"auth-%s-token"`
 	Endpoint  string `yaml:"endpoint" env:"KRAFTKIT_AUTH_%s_ENDPOINT" long:"auth-%s-endpoint"`
 	VerifySSL bool   `yaml:"verify_ssl" env:"KRAFTKIT_AUTH_%s_VERIFY_SSL" long:"auth-%s-verify-ssl" default:"true"`
+	CACert    string `yaml:"ca_cert,omitempty" env:"KRAFTKIT_AUTH_%s_CA_CERT" long:"auth-%s-ca-cert"`
+	SSHKey    string `yaml:"ssh_key,omitempty" env:"KRAFTKIT_AUTH_%s_SSH_KEY" long:"auth-%s-ssh-key"`
 }
 
 type KraftKit struct {
@@ -20,32 +22,79 @@ type KraftKit struct {
 	NoEmojis       bool   `yaml:"no_emojis" env:"KRAFTKIT_NO_EMOJIS" long:"no-emojis" usage:"Do not use emojis in any console output" default:"true"`
 	NoCheckUpdates bool   `yaml:"no_check_updates" env:"KRAFTKIT_NO_CHECK_UPDATES" long:"no-check-updates" usage:"Do not check for updates" default:"false"`
 	NoColor        bool   `yaml:"no_color" env:"KRAFTKIT_NO_COLOR" long:"no-color" usage:"Disable color output"`
+	Offline        bool   `yaml:"offline" env:"KRAFTKIT_OFFLINE" long:"offline" usage:"Do not access remote registries or manifests and only use pre-fetched packages and sources" default:"false"`
 	NoWarnSudo     bool   `yaml:"no_warn_sudo" env:"KRAFTKIT_NO_WARN_SUDO" long:"no-warn-sudo" usage:"Do not warn on running via sudo" default:"false"`
+	Quiet          bool   `yaml:"quiet" env:"KRAFTKIT_QUIET" long:"quiet" usage:"Only output warnings and errors"`
+	Strict         bool   `yaml:"strict,omitempty" env:"KRAFTKIT_STRICT" long:"strict" usage:"Treat the use of deprecated commands and flags as an error"`
+	Progress       string `yaml:"progress" env:"KRAFTKIT_PROGRESS" long:"progress" usage:"Progress output. Choice of: [auto, fancy, plain, json, rawjson]" default:"auto"`
 	Editor         string `yaml:"editor" env:"KRAFTKIT_EDITOR" long:"editor" usage:"Set the text editor to open when prompt to edit a file"`
 	GitProtocol    string `yaml:"git_protocol" env:"KRAFTKIT_GIT_PROTOCOL" long:"git-protocol" usage:"Preferred Git protocol to use" default:"https"`
 	Pager          string `yaml:"pager,omitempty" env:"KRAFTKIT_PAGER" long:"pager" usage:"System pager to pipe output to" default:"cat"`
 	Qemu           string `yaml:"qemu,omitempty" env:"KRAFTKIT_QEMU" long:"qemu" usage:"Path to QEMU executable" default:""`
+	QemuMachine    string `yaml:"qemu_machine,omitempty" env:"KRAFTKIT_QEMU_MACHINE" long:"qemu-machine" usage:"Default QEMU machine type, e.g. pc, q35 or microvm"`
+	QemuAccel      string `yaml:"qemu_accel,omitempty" env:"KRAFTKIT_QEMU_ACCEL" long:"qemu-accel" usage:"Default QEMU accelerator, where auto falls back to emulation if the host hypervisor is unavailable. Choice of: [auto, kvm, hvf, whpx, xen, tcg]"`
 	HTTPUnixSocket string `yaml:"http_unix_socket,omitempty" env:"KRAFTKIT_HTTP_UNIX_SOCKET" long:"http-unix-sock" usage:"When making HTTP(S) connections, pipe requests via this shared socket"`
 	RuntimeDir     string `yaml:"runtime_dir" env:"KRAFTKIT_RUNTIME_DIR" long:"runtime-dir" usage:"Directory for placing runtime files (e.g. pidfiles)"`
+	StoreBackend   string `yaml:"store_backend,omitempty" env:"KRAFTKIT_STORE_BACKEND" long:"store-backend" usage:"Database which machines, networks and volumes are stored in. Choice of: [badger, bolt]" default:"badger"`
 	DefaultPlat    string `yaml:"default_plat" env:"KRAFTKIT_DEFAULT_PLAT" usage:"The default platform to use when invoking platform-specific code" noattribute:"true"`
 	DefaultArch    string `yaml:"default_arch" env:"KRAFTKIT_DEFAULT_ARCH" usage:"The default architecture to use when invoking architecture-specific code" noattribute:"true"`
 	ContainerdAddr string `yaml:"containerd_addr,omitempty" env:"KRAFTKIT_CONTAINERD_ADDR" long:"containerd-addr" usage:"Address of containerd daemon socket" default:""`
 	EventsPidFile  string `yaml:"events_pidfile" env:"KRAFTKIT_EVENTS_PIDFILE" long:"events-pid-file" usage:"Events process ID used when running multiple unikernels"`
 	BuildKitHost   string `yaml:"buildkit_host" env:"KRAFTKIT_BUILDKIT_HOST" long:"buildkit-host" usage:"Path to the buildkit host" default:""`
+	CurrentContext string `yaml:"current_context,omitempty" env:"KRAFTKIT_CONTEXT" long:"context" usage:"Use the named configuration context"`
 
 	Paths struct {
 		Plugins   string `yaml:"plugins,omitempty" env:"KRAFTKIT_PATHS_PLUGINS" long:"plugins-dir" usage:"Path to KraftKit plugin directory"`
 		Config    string `yaml:"-" env:"KRAFTKIT_PATHS_CONFIG" long:"config-dir" usage:"Path to KraftKit config directory"`
 		Manifests string `yaml:"manifests,omitempty" env:"KRAFTKIT_PATHS_MANIFESTS" long:"manifests-dir" usage:"Path to Unikraft manifest cache"`
 		Sources   string `yaml:"sources,omitempty" env:"KRAFTKIT_PATHS_SOURCES" long:"sources-dir" usage:"Path to Unikraft component cache"`
+		Cache     string `yaml:"cache,omitempty" env:"KRAFTKIT_PATHS_CACHE" long:"cache-dir" usage:"Path to the cache of remote catalog metadata"`
 	} `yaml:"paths,omitempty"`
 
+	Catalog struct {
+		CacheTTL string `yaml:"cache_ttl,omitempty" env:"KRAFTKIT_CATALOG_CACHE_TTL" long:"catalog-cache-ttl" usage:"Duration for which metadata of remote catalogs is cached on disk (0 disables the cache)" default:"5m"`
+		NoCache  bool   `yaml:"-" env:"KRAFTKIT_CATALOG_NO_CACHE" usage:"Do not use cached metadata of remote catalogs" noattribute:"true"`
+	} `yaml:"catalog,omitempty"`
+
+	Unpack struct {
+		HardLink bool `yaml:"hard_link,omitempty" env:"KRAFTKIT_UNPACK_HARD_LINK" long:"unpack-hard-link" usage:"Hard-link files unpacked from packages to a single read-only copy of their contents instead of copying them"`
+	} `yaml:"unpack,omitempty"`
+
+	Hardening struct {
+		Seccomp          bool   `yaml:"seccomp,omitempty" env:"KRAFTKIT_HARDENING_SECCOMP" long:"hardening-seccomp" usage:"Restrict the system calls of VMMs with seccomp (QEMU only)"`
+		DropCapabilities bool   `yaml:"drop_capabilities,omitempty" env:"KRAFTKIT_HARDENING_DROP_CAPABILITIES" long:"hardening-drop-capabilities" usage:"Drop the capabilities of VMMs by running them as an unprivileged user (QEMU only)"`
+		User             string `yaml:"user,omitempty" env:"KRAFTKIT_HARDENING_USER" long:"hardening-user" usage:"Unprivileged user which VMMs run as once they have initialised (QEMU only)"`
+	} `yaml:"hardening,omitempty"`
+
+	Cgroup struct {
+		Disable  bool   `yaml:"disable,omitempty" env:"KRAFTKIT_CGROUP_DISABLE" long:"cgroup-disable" usage:"Do not place VMMs in cgroups which limit their resources"`
+		Required bool   `yaml:"required,omitempty" env:"KRAFTKIT_CGROUP_REQUIRED" long:"cgroup-required" usage:"Fail to create machines whose VMMs cannot be placed in cgroups which limit their resources, e.g. as the cgroup root is not delegated to the user"`
+		Root     string `yaml:"root,omitempty" env:"KRAFTKIT_CGROUP_ROOT" long:"cgroup-root" usage:"cgroup v2 directory under which each VMM is placed in its own cgroup" default:"/sys/fs/cgroup/kraftkit"`
+	} `yaml:"cgroup,omitempty"`
+
 	Log struct {
-		Level      string `yaml:"level" env:"KRAFTKIT_LOG_LEVEL" long:"log-level" usage:"Log level verbosity. Choice of: [panic, fatal, error, warn, info, debug, trace]" default:"info"`
-		Timestamps bool   `yaml:"timestamps" env:"KRAFTKIT_LOG_TIMESTAMPS" long:"log-timestamps" usage:"Enable log timestamps"`
-		Type       string `yaml:"type" env:"KRAFTKIT_LOG_TYPE" long:"log-type" usage:"Log type. Choice of: [fancy, basic, json]" default:"fancy"`
+		Level      string   `yaml:"level" env:"KRAFTKIT_LOG_LEVEL" long:"log-level" usage:"Log level verbosity. Choice of: [panic, fatal, error, warn, info, debug, trace]" default:"info"`
+		Timestamps bool     `yaml:"timestamps" env:"KRAFTKIT_LOG_TIMESTAMPS" long:"log-timestamps" usage:"Enable log timestamps"`
+		Type       string   `yaml:"type" env:"KRAFTKIT_LOG_TYPE" long:"log-type" usage:"Log type. Choice of: [fancy, basic, json]" default:"fancy"`
+		CRI        bool     `yaml:"cri,omitempty" env:"KRAFTKIT_LOG_CRI" long:"log-cri" usage:"Additionally write the console output of machines in the Kubernetes CRI log format"`
+		MaxSize    string   `yaml:"max_size" env:"KRAFTKIT_LOG_MAX_SIZE" long:"log-max-size" usage:"Size of the console log of a machine after which it is rotated (0 disables rotation)" default:"10MiB"`
+		MaxFiles   int      `yaml:"max_files" env:"KRAFTKIT_LOG_MAX_FILES" long:"log-max-files" usage:"Number of console log files kept per machine, including the current one" default:"5"`
+		Output     string   `yaml:"output,omitempty" env:"KRAFTKIT_LOG_OUTPUT" long:"log-output" usage:"Additionally write JSON-formatted logs to the provided file or to journald"`
+		Subsystems []string `yaml:"subsystems,omitempty" env:"KRAFTKIT_LOG_SUBSYSTEMS" long:"log-subsystem" usage:"Override the log level of a subsystem, in the format subsystem=level, e.g. daemon=debug"`
 	} `yaml:"log"`
 
+	Network struct {
+		Pools []string `yaml:"pools,omitempty" env:"KRAFTKIT_NETWORK_POOLS" long:"network-pool" usage:"Private ranges from which the subnets of new networks are selected, in the format <subnet>:<size>, e.g. 10.10.0.0/16:24"`
+	} `yaml:"network,omitempty"`
+
+	HTTP struct {
+		Proxy      string `yaml:"proxy,omitempty" env:"KRAFTKIT_HTTP_PROXY" long:"http-proxy" usage:"Proxy for outbound HTTP(S) connections, e.g. http://proxy:3128 or socks5://proxy:1080"`
+		NoProxy    string `yaml:"no_proxy,omitempty" env:"KRAFTKIT_HTTP_NO_PROXY" long:"http-no-proxy" usage:"Comma-separated hosts which are connected to without the proxy"`
+		CACert     string `yaml:"ca_cert,omitempty" env:"KRAFTKIT_HTTP_CA_CERT" long:"http-ca-cert" usage:"Path to a PEM bundle of certificate authorities to trust in addition to those of the system"`
+		ClientCert string `yaml:"client_cert,omitempty" env:"KRAFTKIT_HTTP_CLIENT_CERT" long:"http-client-cert" usage:"Path to a PEM client certificate presented to servers requesting mutual TLS"`
+		ClientKey  string `yaml:"client_key,omitempty" env:"KRAFTKIT_HTTP_CLIENT_KEY" long:"http-client-key" usage:"Path to the PEM key of the client certificate"`
+	} `yaml:"http,omitempty"`
+
 	Unikraft struct {
 		Mirrors   []string `yaml:"mirrors" env:"KRAFTKIT_UNIKRAFT_MIRRORS" long:"with-mirror" usage:"Paths to mirrors of Unikraft component artifacts"`
 		Manifests []string `yaml:"manifests" env:"KRAFTKIT_UNIKRAFT_MANIFESTS" long:"with-manifest" usage:"Paths to package or component manifests"`
@@ -54,6 +103,16 @@ type KraftKit struct {
 	Auth map[string]AuthConfig `yaml:"auth,omitempty" noattribute:"true"`
 
 	Aliases map[string]map[string]string `yaml:"aliases" noattribute:"true"`
+
+	Contexts map[string]Context `yaml:"contexts,omitempty" noattribute:"true"`
+
+	Presets map[string]Preset `yaml:"presets,omitempty" noattribute:"true"`
+
+	// Commands holds the defaults of the flags of commands, keyed by the command
+	// without the leading `kraft`, e.g. `run` or `pkg list`, and then by the long
+	// name of the flag.  Flags provided on the command line or through the
+	// environment take precedence over these defaults.
+	Commands map[string]map[string]string `yaml:"commands,omitempty" noattribute:"true"`
 }
 
 type ConfigDetail struct {
@@ -68,6 +127,10 @@ var configDetails = []ConfigDetail{
 		Key:         "no_prompt",
 		Description: "toggle interactive prompting in the terminal",
 	},
+	{
+		Key:         "offline",
+		Description: "forbid access to remote registries and manifests and only use pre-fetched packages and sources",
+	},
 	{
 		Key:         "editor",
 		Description: "the text editor program to use for authoring text",
@@ -88,6 +151,85 @@ var configDetails = []ConfigDetail{
 		Key:         "pager",
 		Description: "the terminal pager program to send standard output to",
 	},
+	{
+		Key:         "current_context",
+		Description: "the configuration context which is in use",
+	},
+	{
+		Key:         "quiet",
+		Description: "only output warnings and errors",
+	},
+	{
+		Key:         "strict",
+		Description: "treat the use of deprecated commands and flags as an error, e.g. in CI pipelines",
+	},
+	{
+		Key:         "progress",
+		Description: "how the progress of long-running tasks is displayed",
+		AllowedValues: []string{
+			"auto",
+			"fancy",
+			"plain",
+			"json",
+			"rawjson",
+		},
+	},
+	{
+		Key:         "catalog.cache_ttl",
+		Description: "how long metadata of remote catalogs, e.g. manifest indexes and OCI indexes, is cached on disk, e.g. 10m, or 0 to disable the cache",
+	},
+	{
+		Key:         "hardening.seccomp",
+		Description: "restrict the system calls of QEMU processes with its seccomp sandbox",
+	},
+	{
+		Key:         "hardening.drop_capabilities",
+		Description: "drop the capabilities of QEMU processes by running them as hardening.user, or nobody, once they have initialised",
+	},
+	{
+		Key:         "hardening.user",
+		Description: "the unprivileged user which QEMU processes switch to once they have initialised",
+	},
+	{
+		Key:         "cgroup.disable",
+		Description: "do not place VMM processes in cgroups which limit their memory, CPU and I/O",
+	},
+	{
+		Key:         "cgroup.root",
+		Description: "the cgroup v2 directory under which each VMM process is placed in its own cgroup, which must be writable, e.g. a delegated directory for rootless use",
+	},
+	{
+		Key:         "network.pools",
+		Description: "the private ranges, in the format <subnet>:<size>, from which the subnets of networks created without one are selected, avoiding those of existing networks and of the host's routes and interfaces",
+	},
+	{
+		Key:         "store_backend",
+		Description: "the database which machines, networks and volumes are stored in; existing objects are not migrated when it is changed",
+		AllowedValues: []string{
+			"badger",
+			"bolt",
+		},
+	},
+	{
+		Key:         "http.proxy",
+		Description: "the proxy for outbound HTTP(S) connections, e.g. http://proxy:3128 or socks5://proxy:1080",
+	},
+	{
+		Key:         "http.no_proxy",
+		Description: "comma-separated hosts which are connected to without the proxy",
+	},
+	{
+		Key:         "http.ca_cert",
+		Description: "path to a PEM bundle of certificate authorities to trust in addition to those of the system",
+	},
+	{
+		Key:         "http.client_cert",
+		Description: "path to a PEM client certificate presented to servers requesting mutual TLS",
+	},
+	{
+		Key:         "http.client_key",
+		Description: "path to the PEM key of the client certificate",
+	},
 	{
 		Key:         "log.level",
 		Description: "Set the logging verbosity",
@@ -114,6 +256,26 @@ var configDetails = []ConfigDetail{
 		Key:         "log.timestamps",
 		Description: "Show timestamps with log output",
 	},
+	{
+		Key:         "log.cri",
+		Description: "Additionally write the console output of machines in the Kubernetes CRI log format",
+	},
+	{
+		Key:         "log.max_size",
+		Description: "Size of the console log of a machine after which it is rotated, e.g. 10MiB, or 0 to disable rotation",
+	},
+	{
+		Key:         "log.max_files",
+		Description: "Number of console log files kept per machine, including the current one",
+	},
+	{
+		Key:         "log.output",
+		Description: "Additionally write JSON-formatted logs to the provided file, or to the systemd journal if set to journald",
+	},
+	{
+		Key:         "log.subsystems",
+		Description: "Log level overrides of subsystems, e.g. daemon=debug or compose=trace",
+	},
 }
 
 func ConfigDetails() []ConfigDetail {
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Context is a named set of configuration values which, whilst the context is
+// in use, take precedence over the respective top-level values.  Contexts allow
+// switching between, for example, different registries and credentials.
+type Context struct {
+	DefaultPlat string                `yaml:"default_plat,omitempty"`
+	DefaultArch string                `yaml:"default_arch,omitempty"`
+	Manifests   []string              `yaml:"manifests,omitempty"`
+	Auth        map[string]AuthConfig `yaml:"auth,omitempty"`
+	HTTPProxy   string                `yaml:"http_proxy,omitempty"`
+	HTTPSProxy  string                `yaml:"https_proxy,omitempty"`
+	NoProxy     string                `yaml:"no_proxy,omitempty"`
+}
+
+// ContextNames returns the sorted names of all known contexts.
+func (k *KraftKit) ContextNames() []string {
+	names := make([]string, 0, len(k.Contexts))
+	for name := range k.Contexts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ApplyContext overrides the configuration with the values of the named
+// context.  Proxy settings are applied to the environment of the process such
+// that they are respected by all HTTP clients.  An empty name is a no-op.
+func (k *KraftKit) ApplyContext(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	c, ok := k.Contexts[name]
+	if !ok {
+		return fmt.Errorf("unknown context: %s", name)
+	}
+
+	if c.DefaultPlat != "" {
+		k.DefaultPlat = c.DefaultPlat
+	}
+
+	if c.DefaultArch != "" {
+		k.DefaultArch = c.DefaultArch
+	}
+
+	if len(c.Manifests) > 0 {
+		k.Unikraft.Manifests = c.Manifests
+	}
+
+	if len(c.Auth) > 0 && k.Auth == nil {
+		k.Auth = make(map[string]AuthConfig, len(c.Auth))
+	}
+
+	for host, auth := range c.Auth {
+		k.Auth[host] = auth
+	}
+
+	for env, value := range map[string]string{
+		"HTTP_PROXY":  c.HTTPProxy,
+		"HTTPS_PROXY": c.HTTPSProxy,
+		"NO_PROXY":    c.NoProxy,
+	} {
+		if value == "" {
+			continue
+		}
+
+		if err := os.Setenv(env, value); err != nil {
+			return fmt.Errorf("could not set %s: %w", env, err)
+		}
+	}
+
+	k.CurrentContext = name
+
+	return nil
+}
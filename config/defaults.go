@@ -59,11 +59,16 @@ func NewDefaultKraftKitConfig() (*KraftKit, error) {
 		c.EventsPidFile = filepath.Join(c.RuntimeDir, "events.pid")
 	}
 
-	// ..and for cached source files
+	// ..for cached source files..
 	if len(c.Paths.Sources) == 0 {
 		c.Paths.Sources = filepath.Join(DataDir(), "sources")
 	}
 
+	// ..and for cached catalog metadata
+	if len(c.Paths.Cache) == 0 {
+		c.Paths.Cache = filepath.Join(DataDir(), "cache")
+	}
+
 	if len(c.Unikraft.Manifests) == 0 {
 		c.Unikraft.Manifests = append(c.Unikraft.Manifests, DefaultManifestIndex)
 	}
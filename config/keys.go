@@ -0,0 +1,314 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolveKey splits the dot-separated key into the path of the respective YAML
+// mapping keys of the configuration and returns the type of the value it
+// refers to.  Keys of maps, e.g. the hostnames of authentication entries, may
+// themselves contain dots and are resolved to the shortest key for which the
+// remainder is a valid key of the map's values.
+func resolveKey(key string) ([]string, reflect.Type, error) {
+	if key == "" {
+		return nil, nil, fmt.Errorf("empty key")
+	}
+
+	path, t, ok := resolvePath(reflect.TypeOf(KraftKit{}), strings.Split(key, "."))
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown key: %s", key)
+	}
+
+	return path, t, nil
+}
+
+func resolvePath(t reflect.Type, segs []string) ([]string, reflect.Type, bool) {
+	if len(segs) == 0 {
+		return nil, t, true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" || name != segs[0] {
+				continue
+			}
+
+			path, leaf, ok := resolvePath(t.Field(i).Type, segs[1:])
+			if !ok {
+				return nil, nil, false
+			}
+
+			return append([]string{name}, path...), leaf, true
+		}
+
+	case reflect.Map:
+		for n := 1; n <= len(segs); n++ {
+			path, leaf, ok := resolvePath(t.Elem(), segs[n:])
+			if !ok {
+				continue
+			}
+
+			return append([]string{strings.Join(segs[:n], ".")}, path...), leaf, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// lookupNode returns the value node at the provided path of mapping keys.
+func lookupNode(node *yaml.Node, path []string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, key := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		node = next
+	}
+
+	return node
+}
+
+// GetKey returns the value of the dot-separated key in the provided
+// configuration.  Values which are not scalars are returned as YAML.
+func GetKey(structure any, key string) (string, error) {
+	path, _, err := resolveKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := yaml.Marshal(structure)
+	if err != nil {
+		return "", err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return "", err
+	}
+
+	node := lookupNode(&doc, path)
+	if node == nil {
+		return "", nil
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+
+	b, err = yaml.Marshal(node)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+// valueNode returns the YAML node which represents the value for the type of
+// a configuration key.  Slices are provided as comma-separated values.
+func valueNode(key string, t reflect.Type, value string) (*yaml.Node, error) {
+	switch t.Kind() {
+	case reflect.String:
+		if allowed := AllowedValues(key); len(allowed) > 0 {
+			found := false
+			for _, a := range allowed {
+				if a == value {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return nil, fmt.Errorf("unsupported value for %s: %s (allowed: %s)", key, value, strings.Join(allowed, ", "))
+			}
+		}
+
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects a boolean: %w", key, err)
+		}
+
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects an integer: %w", key, err)
+		}
+
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(i, 10)}, nil
+
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.String {
+			break
+		}
+
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v})
+			}
+		}
+
+		return seq, nil
+	}
+
+	return nil, fmt.Errorf("%s cannot be set directly, set one of its keys instead", key)
+}
+
+// readDocument reads the YAML document of the provided file, returning an
+// empty mapping if the file does not exist or is empty.
+func readDocument(file string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", file, err)
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("could not parse %s: not a mapping", file)
+	}
+
+	return doc, nil
+}
+
+// writeDocument writes the YAML document to the provided file.
+func writeDocument(file string, doc *yaml.Node) error {
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return WriteConfigFile(file, b)
+}
+
+// SetKey sets the dot-separated key to the provided value in the YAML
+// configuration file, creating any intermediate mappings.  All other contents
+// of the file, including comments, are retained.
+func SetKey(file, key, value string) error {
+	path, t, err := resolveKey(key)
+	if err != nil {
+		return err
+	}
+
+	val, err := valueNode(key, t, value)
+	if err != nil {
+		return err
+	}
+
+	doc, err := readDocument(file)
+	if err != nil {
+		return err
+	}
+
+	node := doc.Content[0]
+	for i, k := range path {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("could not set %s: %s is not a mapping", key, strings.Join(path[:i], "."))
+		}
+
+		var next *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == k {
+				next = node.Content[j+1]
+				if i == len(path)-1 {
+					node.Content[j+1] = val
+				}
+				break
+			}
+		}
+
+		if next == nil {
+			next = val
+			if i < len(path)-1 {
+				next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k},
+				next,
+			)
+		} else if next.Kind != yaml.MappingNode && i < len(path)-1 {
+			// Replace empty values, e.g. `contexts:` or `contexts: {}`
+			if next.Kind == yaml.ScalarNode && (next.Tag == "!!null" || next.Value == "") {
+				next.Kind = yaml.MappingNode
+				next.Tag = "!!map"
+				next.Value = ""
+			}
+		}
+
+		node = next
+	}
+
+	return writeDocument(file, doc)
+}
+
+// UnsetKey removes the dot-separated key from the YAML configuration file such
+// that its default value is used.
+func UnsetKey(file, key string) error {
+	path, _, err := resolveKey(key)
+	if err != nil {
+		return err
+	}
+
+	doc, err := readDocument(file)
+	if err != nil {
+		return err
+	}
+
+	parent := lookupNode(doc, path[:len(path)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == path[len(path)-1] {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			break
+		}
+	}
+
+	return writeDocument(file, doc)
+}
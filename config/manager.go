@@ -44,6 +44,7 @@ func WithFile[C any](file string, forceCreate bool) ConfigManagerOption[C] {
 			yml := YamlFeeder{
 				File: file,
 			}
+			cm.ConfigFile = file
 			if os.IsNotExist(err) {
 				err := yml.Write(cm.Config, forceCreate)
 				if err != nil {
@@ -161,22 +162,35 @@ func AllowedValues(key string) []string {
 // that can be set via the --config-dir flag. This needs to be fetched before flags
 // are populated with AttributeFlags to ensure that the function is called only once.
 func FetchConfigDirFromArgs(args []string) (path string) {
+	return fetchFlagFromArgs(args, "--config-dir")
+}
+
+// FetchContextFromArgs returns the value of the `--context` flag if it is
+// present in the provided arguments.
+func FetchContextFromArgs(args []string) string {
+	return fetchFlagFromArgs(args, "--context")
+}
+
+// fetchFlagFromArgs returns the value of the named flag in either of the forms
+// `--flag=value` or `--flag value` before the flags have been parsed.
+func fetchFlagFromArgs(args []string, flag string) string {
 	for idx, arg := range args {
-		if !strings.HasPrefix(arg, "--config-dir") {
+		if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+			return value
+		}
+
+		if arg != flag {
 			continue
 		}
-		if strings.Contains(arg, "=") {
-			if split := strings.Split(arg, "="); len(split) == 2 {
-				path = split[1]
-			}
-		} else {
-			if !strings.HasPrefix(args[idx+1], "-") {
-				path = args[idx+1]
-			}
+
+		if idx+1 < len(args) && !strings.HasPrefix(args[idx+1], "-") {
+			return args[idx+1]
 		}
+
 		break
 	}
-	return
+
+	return ""
 }
 
 func Default[C any](key string) string {
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Preset is a named set of defaults for running machines, which saves from
+// repeating the same flags on every invocation of `kraft run`.  Presets can be
+// distributed as part of a configuration file to share defaults across an
+// organisation.
+type Preset struct {
+	Memory       string   `yaml:"memory,omitempty"`
+	CPUs         int      `yaml:"cpus,omitempty"`
+	Networks     []string `yaml:"networks,omitempty"`
+	Volumes      []string `yaml:"volumes,omitempty"`
+	Ports        []string `yaml:"ports,omitempty"`
+	Env          []string `yaml:"env,omitempty"`
+	Platform     string   `yaml:"plat,omitempty"`
+	Architecture string   `yaml:"arch,omitempty"`
+}
+
+// PresetNames returns the sorted names of all known presets.
+func (k *KraftKit) PresetNames() []string {
+	names := make([]string, 0, len(k.Presets))
+	for name := range k.Presets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Preset returns the preset with the provided name.
+func (k *KraftKit) Preset(name string) (Preset, error) {
+	preset, ok := k.Presets[name]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset: %s", name)
+	}
+
+	return preset, nil
+}
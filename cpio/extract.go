@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package cpio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extract extracts the directories, regular files and symbolic links of the
+// archive into dir.  Other entries, e.g. devices, are skipped as they
+// cannot be created without privileges.  Entries whose path would resolve
+// outside of dir, including through symbolic links of earlier entries, are
+// rejected, and existing entries at the path of an entry, e.g. symbolic
+// links of earlier entries, are replaced rather than followed.  Hard links
+// are extracted as separate copies of their contents.
+func Extract(r io.Reader, dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return err
+	}
+
+	within := func(path string) bool {
+		return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+	}
+
+	cr := NewReader(r)
+
+	for {
+		hdr, _, err := cr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(filepath.Clean("/"+hdr.Name)))
+		if !within(path) {
+			return fmt.Errorf("entry %s is outside of the destination", hdr.Name)
+		}
+
+		// The closest existing ancestor of the entry is resolved before any
+		// missing parent is created, such that a symbolic link cannot lead
+		// outside of the destination.
+		ancestor := filepath.Dir(path)
+		for {
+			if _, err := os.Lstat(ancestor); err == nil || ancestor == dir {
+				break
+			}
+
+			ancestor = filepath.Dir(ancestor)
+		}
+
+		if resolved, err := filepath.EvalSymlinks(ancestor); err != nil {
+			return fmt.Errorf("resolving parent of %s: %w", hdr.Name, err)
+		} else if !within(resolved) {
+			return fmt.Errorf("entry %s is outside of the destination", hdr.Name)
+		}
+
+		// Parent directories are not necessarily entries of the archive.
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("creating parent of %s: %w", hdr.Name, err)
+		}
+
+		typ := hdr.Mode &^ (ModePerm | ModeSetuid | ModeSetgid | ModeSticky)
+
+		// An existing entry at the path, e.g. a symbolic link of an earlier
+		// entry, is replaced rather than followed, unless both are directories.
+		if fi, err := os.Lstat(path); err == nil && (typ != TypeDir || !fi.IsDir()) {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("replacing %s: %w", hdr.Name, err)
+			}
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("accessing %s: %w", hdr.Name, err)
+		}
+
+		switch typ {
+		case TypeDir:
+			if err := os.MkdirAll(path, os.FileMode(hdr.Mode.Perm())|0o700); err != nil {
+				return fmt.Errorf("creating %s: %w", hdr.Name, err)
+			}
+
+		case TypeReg:
+			// O_EXCL fails rather than follows a symbolic link which would have
+			// been created at the path since.
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(hdr.Mode.Perm())|0o600)
+			if err != nil {
+				return fmt.Errorf("creating %s: %w", hdr.Name, err)
+			}
+
+			if _, err := io.Copy(f, cr); err != nil {
+				f.Close()
+				return fmt.Errorf("writing %s: %w", hdr.Name, err)
+			}
+
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("writing %s: %w", hdr.Name, err)
+			}
+
+		case TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, path); err != nil {
+				return fmt.Errorf("creating %s: %w", hdr.Name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package cpio_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kraftkit.sh/cpio"
+)
+
+func TestExtract(t *testing.T) {
+	archive := writeArchive(t,
+		testEntry{name: "etc", mode: cpio.TypeDir | 0o755},
+		testEntry{name: "etc/os-release", mode: cpio.TypeReg | 0o644, contents: "ID=alpine"},
+		testEntry{name: "lib/libc.so", mode: cpio.TypeReg | 0o755, contents: "libc"},
+		testEntry{name: "lib/libc.so.1", mode: cpio.TypeSymlink | 0o777, linkname: "libc.so"},
+	)
+
+	dir := t.TempDir()
+	if err := cpio.Extract(archive, dir); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	for path, expected := range map[string]string{
+		"etc/os-release": "ID=alpine",
+		"lib/libc.so":    "libc",
+		"lib/libc.so.1":  "libc",
+	} {
+		contents, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Errorf("ReadFile(%s): %v", path, err)
+		} else if string(contents) != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, contents)
+		}
+	}
+}
+
+func TestExtractOutside(t *testing.T) {
+	outside := t.TempDir()
+
+	archive := writeArchive(t,
+		testEntry{name: "escape", mode: cpio.TypeSymlink | 0o777, linkname: outside},
+		testEntry{name: "escape/dir/file", mode: cpio.TypeReg | 0o644, contents: "oops"},
+	)
+
+	if err := cpio.Extract(archive, t.TempDir()); err == nil {
+		t.Errorf("expected error")
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "dir")); err == nil {
+		t.Errorf("directory was created outside of the destination")
+	}
+}
+
+func TestExtractReplacesSymlink(t *testing.T) {
+	outside := t.TempDir()
+	target := filepath.Join(outside, "file")
+
+	if err := os.WriteFile(target, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := writeArchive(t,
+		testEntry{name: "file", mode: cpio.TypeSymlink | 0o777, linkname: target},
+		testEntry{name: "file", mode: cpio.TypeReg | 0o644, contents: "pwned"},
+		testEntry{name: "dir", mode: cpio.TypeSymlink | 0o777, linkname: outside},
+		testEntry{name: "dir", mode: cpio.TypeDir | 0o755},
+		testEntry{name: "dir/file", mode: cpio.TypeReg | 0o644, contents: "inside"},
+	)
+
+	dir := t.TempDir()
+	if err := cpio.Extract(archive, dir); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	if contents, err := os.ReadFile(target); err != nil || string(contents) != "original" {
+		t.Errorf("file outside of the destination was modified: %q, %v", contents, err)
+	}
+
+	for path, expected := range map[string]string{
+		"file":     "pwned",
+		"dir/file": "inside",
+	} {
+		fi, err := os.Lstat(filepath.Join(dir, path))
+		if err != nil || !fi.Mode().IsRegular() {
+			t.Errorf("%s: expected regular file, got %v, %v", path, fi, err)
+			continue
+		}
+
+		if contents, _ := os.ReadFile(filepath.Join(dir, path)); string(contents) != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, contents)
+		}
+	}
+}
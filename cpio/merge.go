@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package cpio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+)
+
+// mergeEntry identifies an entry of one of the archives passed to Merge.
+type mergeEntry struct {
+	archive int
+	index   int
+}
+
+// Merge writes a single CPIO archive to w which holds the entries of all the
+// provided archives, as if they were extracted one after the other onto the
+// same file system.  That is, a file, symbolic link or device of a later
+// archive replaces the entry at the same path of an earlier archive, whereas
+// a directory keeps the position of its first occurrence, such that it always
+// precedes its contents, and takes the attributes of its last occurrence.
+//
+// The archives are read twice and must hence be seekable.
+func Merge(w io.Writer, archives ...io.ReadSeeker) error {
+	// First pass: find which entry provides each path of the merged archive,
+	// as well as the attributes of the last occurrence of each directory.
+	final := map[string]mergeEntry{}
+	dirs := map[string]*Header{}
+
+	for i, archive := range archives {
+		if err := walkArchive(archive, func(j int, hdr *Header, _ *Reader) error {
+			name := path.Clean("/" + hdr.Name)
+
+			if hdr.Mode.IsDir() {
+				if _, ok := dirs[name]; !ok {
+					final[name] = mergeEntry{i, j}
+				}
+				dirs[name] = hdr
+				return nil
+			}
+
+			final[name] = mergeEntry{i, j}
+			delete(dirs, name)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("reading archive %d: %w", i, err)
+		}
+	}
+
+	// Second pass: write the entries in the order of the archives, skipping
+	// those which are replaced by a later archive.
+	cw := NewWriter(w)
+
+	for i, archive := range archives {
+		if _, err := archive.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("rewinding archive %d: %w", i, err)
+		}
+
+		if err := walkArchive(archive, func(j int, hdr *Header, r *Reader) error {
+			name := path.Clean("/" + hdr.Name)
+			if final[name] != (mergeEntry{i, j}) {
+				return nil
+			}
+
+			if dir, ok := dirs[name]; ok {
+				entry := *dir
+				entry.Name = hdr.Name
+				return copyEntry(cw, &entry, nil)
+			}
+
+			return copyEntry(cw, hdr, r)
+		}); err != nil {
+			return fmt.Errorf("reading archive %d: %w", i, err)
+		}
+	}
+
+	return cw.Close()
+}
+
+// walkArchive calls fn with the position, header and reader of each entry of
+// the archive.
+func walkArchive(archive io.Reader, fn func(int, *Header, *Reader) error) error {
+	r := NewReader(archive)
+
+	for i := 0; ; i++ {
+		hdr, _, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := fn(i, hdr, r); err != nil {
+			return err
+		}
+	}
+}
+
+// copyEntry writes the entry to the writer, reading its contents from r.
+func copyEntry(w *Writer, hdr *Header, r *Reader) error {
+	entry := *hdr
+
+	// Inodes are only unique within their original archive.
+	entry.Inode = 0
+
+	// Only CRC-less headers are written, as the checksum is not recomputed.
+	entry.Checksum = 0
+
+	if entry.Mode&^ModePerm == TypeSymlink {
+		entry.Size = int64(len(entry.Linkname))
+	}
+
+	if err := w.WriteHeader(&entry); err != nil {
+		return fmt.Errorf("writing header of %s: %w", entry.Name, err)
+	}
+
+	switch {
+	case entry.Mode&^ModePerm == TypeSymlink:
+		if _, err := io.WriteString(w, entry.Linkname); err != nil {
+			return fmt.Errorf("writing link of %s: %w", entry.Name, err)
+		}
+
+	case entry.Size > 0 && r != nil:
+		if _, err := io.CopyN(w, r, entry.Size); err != nil {
+			return fmt.Errorf("writing contents of %s: %w", entry.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package cpio_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"kraftkit.sh/cpio"
+)
+
+type testEntry struct {
+	name     string
+	mode     cpio.FileMode
+	contents string
+	linkname string
+}
+
+func writeArchive(t *testing.T, entries ...testEntry) *bytes.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := cpio.NewWriter(&buf)
+
+	for _, entry := range entries {
+		hdr := &cpio.Header{
+			Name:     entry.name,
+			Mode:     entry.mode,
+			Size:     int64(len(entry.contents)),
+			Linkname: entry.linkname,
+		}
+
+		data := entry.contents
+		if entry.mode&^cpio.ModePerm == cpio.TypeSymlink {
+			hdr.Size = int64(len(entry.linkname))
+			data = entry.linkname
+		}
+
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatalf("WriteHeader: %v", err)
+		}
+		if _, err := io.WriteString(w, data); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func readArchive(t *testing.T, r io.Reader) []testEntry {
+	t.Helper()
+
+	entries := []testEntry{}
+	cr := cpio.NewReader(r)
+
+	for {
+		hdr, _, err := cr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries
+		} else if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+
+		contents, err := io.ReadAll(cr)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+
+		entries = append(entries, testEntry{
+			name:     hdr.Name,
+			mode:     hdr.Mode,
+			contents: string(contents),
+			linkname: hdr.Linkname,
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := writeArchive(t,
+		testEntry{name: "app", mode: cpio.TypeDir | 0o755},
+		testEntry{name: "app/index.js", mode: cpio.TypeReg | 0o644, contents: "old"},
+		testEntry{name: "app/lib.js", mode: cpio.TypeReg | 0o644, contents: "lib"},
+		testEntry{name: "bin", mode: cpio.TypeDir | 0o755},
+		testEntry{name: "bin/node", mode: cpio.TypeReg | 0o755, contents: "node"},
+	)
+
+	layer := writeArchive(t,
+		testEntry{name: "app", mode: cpio.TypeDir | 0o700},
+		testEntry{name: "app/index.js", mode: cpio.TypeReg | 0o644, contents: "new"},
+		testEntry{name: "app/current", mode: cpio.TypeSymlink | 0o777, linkname: "index.js"},
+	)
+
+	var out bytes.Buffer
+	if err := cpio.Merge(&out, base, layer); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+
+	expected := []testEntry{
+		{name: "app", mode: cpio.TypeDir | 0o700},
+		{name: "app/lib.js", mode: cpio.TypeReg | 0o644, contents: "lib"},
+		{name: "bin", mode: cpio.TypeDir | 0o755},
+		{name: "bin/node", mode: cpio.TypeReg | 0o755, contents: "node"},
+		{name: "app/index.js", mode: cpio.TypeReg | 0o644, contents: "new"},
+		{name: "app/current", mode: cpio.TypeSymlink | 0o777, linkname: "index.js"},
+	}
+
+	entries := readArchive(t, &out)
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d: %v", len(expected), len(entries), entries)
+	}
+
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, expected[i], entries[i])
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package group
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	zip "api.zip"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/store"
+
+	groupv1alpha1 "kraftkit.sh/api/group/v1alpha1"
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type v1alpha1Group struct {
+	machineController machineapi.MachineService
+}
+
+// NewGroupV1alpha1 returns the service of machine groups, which are persisted
+// in their own store within the runtime directory.
+func NewGroupV1alpha1(ctx context.Context, opts ...any) (groupv1alpha1.GroupService, error) {
+	embeddedStore, err := store.New[groupv1alpha1.GroupSpec, groupv1alpha1.GroupStatus](
+		store.Backend(config.G[config.KraftKit](ctx).StoreBackend),
+		filepath.Join(
+			config.G[config.KraftKit](ctx).RuntimeDir,
+			"groupv1alpha1",
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	service := &v1alpha1Group{}
+
+	service.machineController, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupv1alpha1.NewGroupServiceHandler(
+		ctx,
+		service,
+		zip.WithStore[groupv1alpha1.GroupSpec, groupv1alpha1.GroupStatus](embeddedStore, zip.StoreRehydrationSpecNil),
+	)
+}
+
+// refreshStatus sets the machines of the group to those which carry its
+// label, ordered by name.
+func (service *v1alpha1Group) refreshStatus(ctx context.Context, group *groupv1alpha1.Group) error {
+	machines, err := service.machineController.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	group.Status.Machines = []metav1.ObjectMeta{}
+	for _, machine := range machines.Items {
+		if machine.Labels[groupv1alpha1.GroupLabelName] == group.Name {
+			group.Status.Machines = append(group.Status.Machines, machine.ObjectMeta)
+		}
+	}
+
+	sort.Slice(group.Status.Machines, func(i, j int) bool {
+		return group.Status.Machines[i].Name < group.Status.Machines[j].Name
+	})
+
+	return nil
+}
+
+// Create implements kraftkit.sh/api/group/v1alpha1.GroupService
+func (service *v1alpha1Group) Create(ctx context.Context, group *groupv1alpha1.Group) (*groupv1alpha1.Group, error) {
+	if group.Name == "" {
+		return group, fmt.Errorf("group name cannot be empty")
+	}
+
+	if group.Spec.Replicas < 0 {
+		return group, fmt.Errorf("number of replicas cannot be negative: %d", group.Spec.Replicas)
+	}
+
+	group.ObjectMeta.UID = uuid.NewUUID()
+	group.CreationTimestamp = metav1.Now()
+
+	return group, nil
+}
+
+// Delete implements kraftkit.sh/api/group/v1alpha1.GroupService
+func (service *v1alpha1Group) Delete(ctx context.Context, group *groupv1alpha1.Group) (*groupv1alpha1.Group, error) {
+	return nil, nil
+}
+
+// Get implements kraftkit.sh/api/group/v1alpha1.GroupService
+func (service *v1alpha1Group) Get(ctx context.Context, group *groupv1alpha1.Group) (*groupv1alpha1.Group, error) {
+	if group.UID == "" {
+		return nil, fmt.Errorf("no such group: %s", group.Name)
+	}
+
+	if err := service.refreshStatus(ctx, group); err != nil {
+		return group, err
+	}
+
+	return group, nil
+}
+
+// List implements kraftkit.sh/api/group/v1alpha1.GroupService
+func (service *v1alpha1Group) List(ctx context.Context, groups *groupv1alpha1.GroupList) (*groupv1alpha1.GroupList, error) {
+	for i := range groups.Items {
+		if err := service.refreshStatus(ctx, &groups.Items[i]); err != nil {
+			return groups, err
+		}
+	}
+
+	return groups, nil
+}
+
+// Update implements kraftkit.sh/api/group/v1alpha1.GroupService
+func (service *v1alpha1Group) Update(ctx context.Context, group *groupv1alpha1.Group) (*groupv1alpha1.Group, error) {
+	if group.Spec.Replicas < 0 {
+		return group, fmt.Errorf("number of replicas cannot be negative: %d", group.Spec.Replicas)
+	}
+
+	return group, nil
+}
@@ -5,14 +5,19 @@
 package initrd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/opencontainers/go-digest"
+
 	"kraftkit.sh/cpio"
 	"kraftkit.sh/log"
 )
@@ -58,8 +63,33 @@ func (initrd *directory) Name() string {
 	return "directory"
 }
 
+// directoryEntry is a file of the directory which is archived.
+type directoryEntry struct {
+	path   string
+	header *cpio.Header
+
+	// reuse is set if the file did not change since the previous build, such
+	// that its entry can be copied from the previous archive.
+	reuse bool
+}
+
 // Build implements Initrd.
+//
+// If both the output and the cache directory are set, the files which are
+// archived are recorded in an index within the cache directory.  Subsequent
+// builds then only read the files which have changed since, whereas the
+// entries of the others are copied from the previous archive, and leave the
+// archive untouched if no file has changed at all.  Files are considered
+// unchanged if their attributes are, or if only their modification time
+// differs but not their contents, in which case the archive keeps their
+// previous modification time.
 func (initrd *directory) Build(ctx context.Context) (string, error) {
+	// A temporary output differs with every build, hence it is not indexed.
+	var indexPath string
+	if initrd.opts.output != "" && initrd.opts.cacheDir != "" {
+		indexPath = directoryIndexPath(initrd.opts.cacheDir, initrd.opts.output)
+	}
+
 	if initrd.opts.output == "" {
 		fi, err := os.CreateTemp("", "")
 		if err != nil {
@@ -77,20 +107,178 @@ func (initrd *directory) Build(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("could not create output directory: %w", err)
 	}
 
-	f, err := os.OpenFile(initrd.opts.output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	var prev *directoryIndex
+	if indexPath != "" {
+		prev = readDirectoryIndex(ctx,
+			indexPath,
+			initrd.path,
+			initrd.opts.output,
+			initrd.opts.compress,
+		)
+	}
+
+	index := &directoryIndex{
+		Source:   initrd.path,
+		Compress: initrd.opts.compress,
+	}
+
+	entries, err := initrd.walk(ctx, prev, index)
+	if err != nil {
+		return "", fmt.Errorf("could not walk output path: %w", err)
+	}
+
+	reused := 0
+	for _, entry := range entries {
+		if entry.reuse {
+			reused++
+		}
+	}
+
+	if prev != nil && reused == len(entries) && len(entries) == len(prev.Entries) {
+		log.G(ctx).
+			WithField("output", initrd.opts.output).
+			Debug("rootfs is up to date")
+
+		// The index still changes if files were only touched.
+		if !slices.Equal(index.Entries, prev.Entries) {
+			if err := index.write(indexPath, initrd.opts.output); err != nil {
+				return "", fmt.Errorf("could not write rootfs index: %w", err)
+			}
+		}
+
+		return initrd.opts.output, nil
+	}
+
+	log.G(ctx).
+		WithField("files", len(entries)).
+		WithField("reused", reused).
+		Debug("archiving rootfs")
+
+	var cursor *archiveCursor
+	if reused > 0 {
+		cursor, err = openArchiveCursor(initrd.opts.output, initrd.opts.compress)
+		if err != nil {
+			log.G(ctx).Debugf("could not reuse previous archive: %v", err)
+			cursor = nil
+		} else {
+			defer cursor.Close()
+		}
+	}
+
+	// The archive is written next to the output and only replaces it once
+	// complete, since unchanged entries are read from the previous archive.
+	f, err := os.CreateTemp(filepath.Dir(initrd.opts.output), "."+filepath.Base(initrd.opts.output)+"-*")
 	if err != nil {
 		return "", fmt.Errorf("could not open initramfs file: %w", err)
 	}
 
+	defer os.Remove(f.Name())
 	defer f.Close()
 
-	writer := cpio.NewWriter(f)
-	defer writer.Close()
-	defer func() {
-		if err := f.Sync(); err != nil {
-			log.G(ctx).Errorf("syncing cpio archive failed: %s", err)
+	bw := bufio.NewWriter(f)
+	writer := cpio.NewWriter(bw)
+
+	// Most files are small, hence a single buffer is used to copy them.
+	buf := make([]byte, 32*1024)
+
+	for i, entry := range entries {
+		if entry.reuse && cursor != nil {
+			if header, ok := cursor.next(entry.header.Name); ok {
+				if err := writer.WriteHeader(header); err != nil {
+					return "", fmt.Errorf("writing cpio header for %q: %w", header.Name, err)
+				}
+
+				if _, err := io.CopyBuffer(writer, cursor, buf); err != nil {
+					return "", fmt.Errorf("could not copy CPIO data for %s: %w", header.Name, err)
+				}
+
+				continue
+			}
+		}
+
+		log.G(ctx).
+			WithField("file", entry.header.Name).
+			Trace("archiving")
+
+		var data []byte
+		if entry.header.Mode.IsRegular() {
+			data, err = os.ReadFile(entry.path)
+			if err != nil {
+				return "", fmt.Errorf("could not read file: %w", err)
+			}
+
+			if indexPath != "" {
+				index.Entries[i].Digest = digest.FromBytes(data)
+			}
+		} else if entry.header.Mode&cpio.ModeType == cpio.TypeSymlink {
+			data = []byte(entry.header.Linkname)
 		}
-	}()
+
+		if err := writer.WriteHeader(entry.header); err != nil {
+			return "", fmt.Errorf("writing cpio header for %q: %w", entry.header.Name, err)
+		}
+
+		if _, err := writer.Write(data); err != nil {
+			return "", fmt.Errorf("could not write CPIO data for %s: %w", entry.header.Name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("could not close CPIO writer: %w", err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return "", fmt.Errorf("could not write initramfs file: %w", err)
+	}
+
+	if initrd.opts.compress {
+		if err := compressFiles(f.Name(), writer, f); err != nil {
+			return "", fmt.Errorf("could not compress files: %w", err)
+		}
+	}
+
+	if err := f.Sync(); err != nil {
+		log.G(ctx).Errorf("syncing cpio archive failed: %s", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("could not close initramfs file: %w", err)
+	}
+
+	if cursor != nil {
+		cursor.Close()
+	}
+
+	// Temporary files are only accessible by their owner.
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return "", fmt.Errorf("could not set permissions of initramfs file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), initrd.opts.output); err != nil {
+		return "", fmt.Errorf("could not replace initramfs file: %w", err)
+	}
+
+	if indexPath != "" {
+		if err := index.write(indexPath, initrd.opts.output); err != nil {
+			return "", fmt.Errorf("could not write rootfs index: %w", err)
+		}
+	}
+
+	return initrd.opts.output, nil
+}
+
+// walk returns the files of the directory in the order in which they are
+// archived and records them in the index.  Files are marked for reuse if
+// they did not change since the previous build, if any.
+func (initrd *directory) walk(ctx context.Context, prev *directoryIndex, index *directoryIndex) ([]directoryEntry, error) {
+	prevEntries := map[string]directoryIndexEntry{}
+	if prev != nil {
+		for _, entry := range prev.Entries {
+			prevEntries[entry.Name] = entry
+		}
+	}
+
+	var entries []directoryEntry
 
 	// Recursively walk the output directory on successful build and serialize to
 	// the output
@@ -110,42 +298,6 @@ func (initrd *directory) Build(ctx context.Context) (string, error) {
 			return fmt.Errorf("could not get directory entry info: %w", err)
 		}
 
-		if d.Type().IsDir() {
-			header := &cpio.Header{
-				Name:    internal,
-				Mode:    cpio.FileMode(info.Mode().Perm()) | cpio.TypeDir,
-				ModTime: info.ModTime(),
-				Size:    0, // Directories have size 0 in cpio
-			}
-
-			// Populate platform specific information
-			populateCPIO(info, header)
-
-			if err := writer.WriteHeader(header); err != nil {
-				return fmt.Errorf("could not write CPIO header: %w", err)
-			}
-			return nil
-		}
-
-		log.G(ctx).
-			WithField("file", internal).
-			Trace("archiving")
-
-		var data []byte
-		targetLink := ""
-		if info.Mode()&os.ModeSymlink != 0 {
-			targetLink, err = os.Readlink(path)
-			data = []byte(targetLink)
-		} else if d.Type().IsRegular() {
-			data, err = os.ReadFile(path)
-		} else {
-			log.G(ctx).Warnf("unsupported file: %s", path)
-			return nil
-		}
-		if err != nil {
-			return fmt.Errorf("could not read file: %w", err)
-		}
-
 		header := &cpio.Header{
 			Name:    internal,
 			Mode:    cpio.FileMode(info.Mode().Perm()),
@@ -157,37 +309,56 @@ func (initrd *directory) Build(ctx context.Context) (string, error) {
 		populateCPIO(info, header)
 
 		switch {
-		case info.Mode().IsRegular():
-			header.Mode |= cpio.TypeReg
+		case d.Type().IsDir():
+			header.Mode |= cpio.TypeDir
+			header.Size = 0 // Directories have size 0 in cpio
 
 		case info.Mode()&fs.ModeSymlink != 0:
 			header.Mode |= cpio.TypeSymlink
-			header.Linkname = targetLink
+			header.Linkname, err = os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("could not read file: %w", err)
+			}
+
+		case d.Type().IsRegular():
+			header.Mode |= cpio.TypeReg
 
-		case header.Links > 0:
-			header.Size = 0
+		default:
+			log.G(ctx).Warnf("unsupported file: %s", path)
+			return nil
 		}
 
-		if err := writer.WriteHeader(header); err != nil {
-			return fmt.Errorf("writing cpio header for %q: %w", internal, err)
+		entry := directoryEntry{
+			path:   path,
+			header: header,
 		}
 
-		if _, err := writer.Write(data); err != nil {
-			return fmt.Errorf("could not write CPIO data for %s: %w", internal, err)
+		state := newDirectoryIndexEntry(header, info)
+
+		if prevState, ok := prevEntries[internal]; ok {
+			if state.unchanged(prevState) {
+				entry.reuse = true
+				state.Digest = prevState.Digest
+			} else if state.touched(prevState) {
+				dgst, err := fileDigest(path)
+				if err != nil {
+					return fmt.Errorf("could not read file: %w", err)
+				}
+
+				entry.reuse = dgst == prevState.Digest
+				state.Digest = dgst
+			}
 		}
 
+		entries = append(entries, entry)
+		index.Entries = append(index.Entries, state)
+
 		return nil
 	}); err != nil {
-		return "", fmt.Errorf("could not walk output path: %w", err)
+		return nil, err
 	}
 
-	if initrd.opts.compress {
-		if err := compressFiles(initrd.opts.output, writer, f); err != nil {
-			return "", fmt.Errorf("could not compress files: %w", err)
-		}
-	}
-
-	return initrd.opts.output, nil
+	return entries, nil
 }
 
 // Env implements Initrd.
@@ -0,0 +1,251 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+
+	"kraftkit.sh/cpio"
+	"kraftkit.sh/log"
+)
+
+// directoryIndexVersion is the version of the format of the index, which is
+// incremented whenever an older index can no longer be interpreted.
+const directoryIndexVersion = 1
+
+// directoryIndex records the files which were archived by the previous build
+// of a directory, such that the next build only has to read the files which
+// have changed since.
+type directoryIndex struct {
+	Version int `json:"version"`
+
+	// Source is the directory which was archived.
+	Source string `json:"source"`
+
+	// Compress is whether the archive was compressed.
+	Compress bool `json:"compress"`
+
+	// Size and ModTime are those of the archive, such that an archive which was
+	// modified or replaced since is not reused.
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"modTime"`
+
+	// Entries are the entries of the archive in order.
+	Entries []directoryIndexEntry `json:"entries"`
+}
+
+// directoryIndexEntry is the state of a file when it was archived.
+type directoryIndexEntry struct {
+	Name     string      `json:"name"`
+	Mode     fs.FileMode `json:"mode"`
+	Size     int64       `json:"size"`
+	ModTime  int64       `json:"modTime"`
+	Uid      int         `json:"uid"`
+	Gid      int         `json:"gid"`
+	Inode    int64       `json:"inode"`
+	Links    int         `json:"links"`
+	Linkname string      `json:"linkname,omitempty"`
+
+	// Digest is the digest of the contents of regular files.
+	Digest digest.Digest `json:"digest,omitempty"`
+}
+
+// newDirectoryIndexEntry returns the state of the file described by the
+// header and its file info.
+func newDirectoryIndexEntry(header *cpio.Header, info fs.FileInfo) directoryIndexEntry {
+	return directoryIndexEntry{
+		Name:     header.Name,
+		Mode:     info.Mode(),
+		Size:     header.Size,
+		ModTime:  info.ModTime().UnixNano(),
+		Uid:      header.Uid,
+		Gid:      header.Guid,
+		Inode:    header.Inode,
+		Links:    header.Links,
+		Linkname: header.Linkname,
+	}
+}
+
+// unchanged returns whether the file has the same state as when it was
+// archived, without reading its contents.
+func (entry directoryIndexEntry) unchanged(prev directoryIndexEntry) bool {
+	entry.Digest, prev.Digest = "", ""
+	return entry == prev
+}
+
+// touched returns whether the file is a regular file which differs from when
+// it was archived only in its modification time or inode, as is the case when
+// it is rewritten with the same contents, e.g. by a checkout or a package
+// manager.  Whether its contents are the same must then be checked against
+// the digest.  Hard links are excluded as their entries are identified by
+// their inode.
+func (entry directoryIndexEntry) touched(prev directoryIndexEntry) bool {
+	if !entry.Mode.IsRegular() || prev.Digest == "" || entry.Links > 1 || prev.Links > 1 {
+		return false
+	}
+
+	entry.ModTime, prev.ModTime = 0, 0
+	entry.Inode, prev.Inode = 0, 0
+
+	return entry.unchanged(prev)
+}
+
+// directoryIndexPath returns the path of the index of the archive at output
+// within the cache directory.
+func directoryIndexPath(cacheDir, output string) string {
+	return filepath.Join(cacheDir, "directory", filepath.Base(output)+".json")
+}
+
+// readDirectoryIndex returns the index of the previous build of source into
+// output, or nil if there is none which can be reused.
+func readDirectoryIndex(ctx context.Context, path, source, output string, compress bool) *directoryIndex {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var index directoryIndex
+	if err := json.Unmarshal(raw, &index); err != nil {
+		log.G(ctx).Debugf("ignoring invalid rootfs index: %v", err)
+		return nil
+	}
+
+	fi, err := os.Stat(output)
+	if err != nil {
+		return nil
+	}
+
+	if index.Version != directoryIndexVersion ||
+		index.Source != source ||
+		index.Compress != compress ||
+		index.Size != fi.Size() ||
+		index.ModTime != fi.ModTime().UnixNano() {
+		log.G(ctx).
+			WithField("index", path).
+			Debug("ignoring outdated rootfs index")
+		return nil
+	}
+
+	return &index
+}
+
+// write saves the index of the archive at output to path.
+func (index *directoryIndex) write(path, output string) error {
+	fi, err := os.Stat(output)
+	if err != nil {
+		return err
+	}
+
+	index.Version = directoryIndexVersion
+	index.Size = fi.Size()
+	index.ModTime = fi.ModTime().UnixNano()
+
+	raw, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, raw, 0o644)
+}
+
+// fileDigest returns the digest of the contents of the file at path.
+func fileDigest(path string) (digest.Digest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	return digest.Canonical.FromReader(f)
+}
+
+// archiveCursor reads the entries of the previous archive in order, such that
+// the entries which did not change can be copied from it instead of being read
+// from their files again.
+type archiveCursor struct {
+	r       *cpio.Reader
+	closers []io.Closer
+}
+
+// openArchiveCursor opens the archive at path, which is compressed if
+// compress is set.
+func openArchiveCursor(path string, compress bool) (*archiveCursor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &archiveCursor{closers: []io.Closer{f}}
+
+	var r io.Reader = bufio.NewReader(f)
+	if compress {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not decompress previous archive: %w", err)
+		}
+
+		cursor.closers = append(cursor.closers, gr)
+		r = gr
+	}
+
+	cursor.r = cpio.NewReader(r)
+
+	return cursor, nil
+}
+
+// next skips to the entry with the provided name and returns its header, in
+// which case its contents can be read from the cursor.  Entries are only
+// found if they follow the last one which was found.
+func (cursor *archiveCursor) next(name string) (*cpio.Header, bool) {
+	for cursor.r != nil {
+		hdr, _, err := cursor.r.Next()
+		if err != nil {
+			cursor.r = nil
+			break
+		}
+
+		if hdr.Name == name {
+			return hdr, true
+		}
+	}
+
+	return nil, false
+}
+
+// Read implements io.Reader
+func (cursor *archiveCursor) Read(p []byte) (int, error) {
+	return cursor.r.Read(p)
+}
+
+// Close implements io.Closer.  It is safe to call more than once.
+func (cursor *archiveCursor) Close() error {
+	var err error
+	for i := len(cursor.closers) - 1; i >= 0; i-- {
+		if cerr := cursor.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	cursor.closers = nil
+	cursor.r = nil
+
+	return err
+}
@@ -5,10 +5,14 @@
 package initrd_test
 
 import (
+	"compress/gzip"
 	"context"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	"kraftkit.sh/cpio"
 	"kraftkit.sh/initrd"
@@ -104,3 +108,123 @@ func openFile(t *testing.T, path string) io.Reader {
 
 	return f
 }
+
+func TestNewFromDirectoryIncremental(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		ctx := context.Background()
+		rootDir := t.TempDir()
+		workDir := t.TempDir()
+		output := filepath.Join(workDir, "initramfs.cpio")
+		cacheDir := filepath.Join(workDir, "cache")
+
+		writeFile(t, filepath.Join(rootDir, "etc", "app.conf"), "listen = 8080\n")
+		writeFile(t, filepath.Join(rootDir, "app", "index.js"), "console.log('v1')\n")
+		writeFile(t, filepath.Join(rootDir, "app", "lib.js"), "module.exports = {}\n")
+
+		build := func() os.FileInfo {
+			t.Helper()
+
+			ird, err := initrd.NewFromDirectory(ctx, rootDir,
+				initrd.WithOutput(output),
+				initrd.WithCacheDir(cacheDir),
+				initrd.WithCompression(compress),
+			)
+			if err != nil {
+				t.Fatal("NewFromDirectory:", err)
+			}
+
+			if _, err := ird.Build(ctx); err != nil {
+				t.Fatal("Build:", err)
+			}
+
+			fi, err := os.Stat(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return fi
+		}
+
+		first := build()
+
+		// Neither unchanged nor touched files change the archive.
+		now := time.Now().Add(time.Hour)
+		if err := os.Chtimes(filepath.Join(rootDir, "app", "lib.js"), now, now); err != nil {
+			t.Fatal(err)
+		}
+
+		if second := build(); !second.ModTime().Equal(first.ModTime()) {
+			t.Errorf("compress=%v: archive was rewritten although no file changed", compress)
+		}
+
+		writeFile(t, filepath.Join(rootDir, "app", "index.js"), "console.log('v2')\n")
+		writeFile(t, filepath.Join(rootDir, "app", "new.js"), "// new\n")
+		if err := os.Remove(filepath.Join(rootDir, "etc", "app.conf")); err != nil {
+			t.Fatal(err)
+		}
+
+		build()
+
+		got := readArchive(t, output, compress)
+		want := map[string]string{
+			"./app":          "",
+			"./app/index.js": "console.log('v2')\n",
+			"./app/lib.js":   "module.exports = {}\n",
+			"./app/new.js":   "// new\n",
+			"./etc":          "",
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("compress=%v: got archive %v, want %v", compress, got, want)
+		}
+	}
+}
+
+// writeFile writes contents to path, creating its parent directories.
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readArchive returns the contents of the entries of the archive by name.
+func readArchive(t *testing.T, path string, compress bool) map[string]string {
+	t.Helper()
+
+	r := openFile(t, path)
+	if compress {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	}
+
+	cr := cpio.NewReader(r)
+	entries := map[string]string{}
+
+	for {
+		hdr, _, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal("Failed to read next cpio header:", err)
+		}
+
+		data, err := io.ReadAll(cr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries[hdr.Name] = string(data)
+	}
+
+	return entries
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package bench
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/bench/boot"
+)
+
+type BenchOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&BenchOptions{}, cobra.Command{
+		Short: "Benchmark unikernels",
+		Use:   "bench SUBCOMMAND",
+		Long:  "Benchmark unikernels.",
+		Example: heredoc.Doc(`
+			# Measure the boot time of a unikernel over 20 runs
+			$ kraft bench boot unikraft.org/nginx:latest --iterations 20
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(boot.NewCmd())
+
+	return cmd
+}
+
+func (opts *BenchOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
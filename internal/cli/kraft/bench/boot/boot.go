@@ -0,0 +1,464 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package boot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/logs"
+	"kraftkit.sh/internal/cli/kraft/run"
+	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/packmanager"
+)
+
+type BootOptions struct {
+	Architecture string        `long:"arch" short:"m" usage:"Set the architecture"`
+	Iterations   int           `long:"iterations" short:"n" usage:"Number of times to boot the unikernel" default:"10"`
+	Marker       string        `long:"marker" usage:"Regular expression matching the console line which marks the end of the kernel boot" default:"Powered by"`
+	Memory       string        `long:"memory" short:"M" usage:"Assign memory to the unikernel (K/Ki, M/Mi, G/Gi)" default:"64Mi"`
+	Output       string        `long:"output" short:"o" usage:"Set output format. Options: table,json" default:"table"`
+	Platform     string        `long:"plat" usage:"Set the platform virtual machine monitor driver"`
+	Ports        []string      `long:"port" short:"p" usage:"Publish a machine's port(s) to the host" split:"false"`
+	Probe        string        `long:"probe" usage:"Measure the latency until the first byte is received from the host address, in the format [host:]port"`
+	ProbePayload string        `long:"probe-payload" usage:"Payload sent to the probed address to elicit a response, in which escape sequences are interpreted" default:"GET / HTTP/1.0\r\n\r\n"`
+	Timeout      time.Duration `long:"timeout" usage:"Time to wait for each boot to complete" default:"30s"`
+}
+
+// Boot benchmarks the boot time of a unikernel.
+func Boot(ctx context.Context, opts *BootOptions, args ...string) error {
+	if opts == nil {
+		opts = &BootOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&BootOptions{}, cobra.Command{
+		Short: "Measure the boot time of a unikernel",
+		Use:   "boot [FLAGS] PROJECT|PACKAGE|BINARY",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Repeatedly boot a unikernel and measure the time it takes to start the
+			virtual machine monitor, to boot the kernel, which is marked by a line on
+			its console, and optionally to respond on the network.
+
+			The mean, 95th percentile, minimum and maximum of each measurement are
+			reported.
+		`),
+		Example: heredoc.Doc(`
+			# Measure the boot time of a unikernel over 20 runs
+			$ kraft bench boot unikraft.org/nginx:latest --iterations 20
+
+			# Also measure the time until nginx responds to a request
+			$ kraft bench boot unikraft.org/nginx:latest -p 8080:80 --probe 8080
+
+			# Export the measurements as JSON
+			$ kraft bench boot -o json unikraft.org/helloworld:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *BootOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.Iterations <= 0 {
+		return fmt.Errorf("number of iterations must be positive")
+	}
+
+	if opts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	if opts.Output != "table" && opts.Output != "json" {
+		return fmt.Errorf("invalid output format: %s", opts.Output)
+	}
+
+	if _, err := regexp.Compile(opts.Marker); err != nil {
+		return fmt.Errorf("invalid regular expression for --marker: %w", err)
+	}
+
+	payload, err := strconv.Unquote(`"` + strings.ReplaceAll(opts.ProbePayload, `"`, `\"`) + `"`)
+	if err != nil {
+		return fmt.Errorf("invalid escape sequence in --probe-payload: %w", err)
+	}
+
+	opts.ProbePayload = payload
+
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+// Measurements of the boot phases of a unikernel.
+const (
+	metricVMMStart  = "vmm_start"
+	metricBoot      = "kernel_boot"
+	metricFirstByte = "first_byte"
+)
+
+// sample holds the measurements of a single boot, each relative to the start
+// of the virtual machine monitor.
+type sample map[string]time.Duration
+
+// summary holds the statistics of a measurement across all boots.
+type summary struct {
+	Mean time.Duration `json:"mean_ns"`
+	P95  time.Duration `json:"p95_ns"`
+	Min  time.Duration `json:"min_ns"`
+	Max  time.Duration `json:"max_ns"`
+}
+
+func (opts *BootOptions) Run(ctx context.Context, args []string) error {
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	samples := make([]sample, 0, opts.Iterations)
+
+	for i := 0; i < opts.Iterations; i++ {
+		s, err := opts.iteration(ctx, controller, args[0])
+		if err != nil {
+			return fmt.Errorf("iteration %d: %w", i+1, err)
+		}
+
+		log.G(ctx).
+			WithField("iteration", i+1).
+			WithField("vmm_start", s[metricVMMStart]).
+			WithField("kernel_boot", s[metricBoot]).
+			Info("booted")
+
+		samples = append(samples, s)
+	}
+
+	metrics := []string{metricVMMStart}
+	if opts.Marker != "" {
+		metrics = append(metrics, metricBoot)
+	}
+	if opts.Probe != "" {
+		metrics = append(metrics, metricFirstByte)
+	}
+
+	summaries := map[string]summary{}
+	for _, metric := range metrics {
+		summaries[metric] = summarize(samples, metric)
+	}
+
+	if opts.Output == "json" {
+		encoder := json.NewEncoder(iostreams.G(ctx).Out)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(struct {
+			Iterations int                `json:"iterations"`
+			Samples    []sample           `json:"samples"`
+			Summary    map[string]summary `json:"summary"`
+		}{
+			Iterations: opts.Iterations,
+			Samples:    samples,
+			Summary:    summaries,
+		})
+	}
+
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(opts.Output),
+	)
+	if err != nil {
+		return err
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	for _, field := range []string{"MEASUREMENT", "MEAN", "P95", "MIN", "MAX"} {
+		table.AddField(field, cs.Bold)
+	}
+	table.EndRow()
+
+	for _, metric := range metrics {
+		s := summaries[metric]
+
+		table.AddField(metric, nil)
+		table.AddField(s.Mean.Round(time.Microsecond).String(), nil)
+		table.AddField(s.P95.Round(time.Microsecond).String(), nil)
+		table.AddField(s.Min.Round(time.Microsecond).String(), nil)
+		table.AddField(s.Max.Round(time.Microsecond).String(), nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
+
+// iteration creates a machine from the unikernel, boots it until all
+// measurements have been taken and removes it again.
+func (opts *BootOptions) iteration(ctx context.Context, controller machineapi.MachineService, ref string) (sample, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	name := "kraft-bench-" + hex.EncodeToString(b)
+
+	// The machine is only created such that preparing the unikernel, e.g.
+	// pulling its package, is not part of the measurements.
+	runOptions := run.RunOptions{
+		Architecture: opts.Architecture,
+		Memory:       opts.Memory,
+		Name:         name,
+		NoStart:      true,
+		Platform:     opts.Platform,
+		Ports:        opts.Ports,
+		Pull:         "missing",
+		Quiet:        true,
+	}
+
+	if err := runOptions.Run(ctx, []string{ref}); err != nil {
+		return nil, fmt.Errorf("could not create machine: %w", err)
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return nil, err
+	}
+
+	selected := utils.SelectMachines(machines.Items, []string{name}, false, nil)
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("could not find machine %s", name)
+	}
+
+	machine := &selected[0]
+
+	defer func() {
+		ctx := context.WithoutCancel(ctx)
+
+		if _, err := controller.Stop(ctx, machine); err != nil {
+			log.G(ctx).Debugf("could not stop machine %s: %v", name, err)
+		}
+
+		if _, err := controller.Delete(ctx, machine); err != nil {
+			log.G(ctx).Warnf("could not delete machine %s: %v", name, err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	machine, err = controller.Start(ctx, machine)
+	if err != nil {
+		return nil, fmt.Errorf("could not start machine: %w", err)
+	}
+
+	s := sample{
+		metricVMMStart: time.Since(start),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs []error
+
+	record := func(metric string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", metric, err))
+			return
+		}
+
+		s[metric] = time.Since(start)
+	}
+
+	if opts.Marker != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record(metricBoot, waitMarker(ctx, controller, machine, regexp.MustCompile(opts.Marker)))
+		}()
+	}
+
+	if opts.Probe != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record(metricFirstByte, waitFirstByte(ctx, opts.Probe, opts.ProbePayload))
+		}()
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	return s, nil
+}
+
+// markerConsumer signals the first line of the console which matches the
+// marker.
+type markerConsumer struct {
+	marker *regexp.Regexp
+	found  chan struct{}
+	once   sync.Once
+}
+
+// Consume implements logs.LogConsumer
+func (consumer *markerConsumer) Consume(lines ...string) {
+	for _, line := range lines {
+		if consumer.marker.MatchString(line) {
+			consumer.once.Do(func() { close(consumer.found) })
+		}
+	}
+}
+
+// waitMarker blocks until a line of the console of the machine matches the
+// marker.
+func waitMarker(ctx context.Context, controller machineapi.MachineService, machine *machineapi.Machine, marker *regexp.Regexp) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	consumer := &markerConsumer{
+		marker: marker,
+		found:  make(chan struct{}),
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- logs.FollowLogs(ctx, machine, controller, consumer, -1)
+	}()
+
+	select {
+	case <-consumer.found:
+		return nil
+	case err := <-exited:
+		select {
+		case <-consumer.found:
+			return nil
+		default:
+		}
+
+		if err == nil {
+			err = fmt.Errorf("unikernel exited")
+		}
+
+		return fmt.Errorf("marker not found: %w", err)
+	case <-ctx.Done():
+		return fmt.Errorf("marker not found: %w", ctx.Err())
+	}
+}
+
+// waitFirstByte blocks until the first byte of a response to the payload is
+// received from the address.
+func waitFirstByte(ctx context.Context, addr, payload string) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort("localhost", addr)
+	}
+
+	var dialer net.Dialer
+	buf := make([]byte, 1)
+
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			if deadline, ok := ctx.Deadline(); ok {
+				_ = conn.SetDeadline(deadline)
+			}
+
+			if payload != "" {
+				_, err = conn.Write([]byte(payload))
+			}
+			if err == nil {
+				_, err = conn.Read(buf)
+			}
+
+			conn.Close()
+
+			if err == nil {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+// summarize computes the statistics of the provided measurement.
+func summarize(samples []sample, metric string) summary {
+	values := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		if v, ok := s[metric]; ok {
+			values = append(values, v)
+		}
+	}
+
+	if len(values) == 0 {
+		return summary{}
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var total time.Duration
+	for _, v := range values {
+		total += v
+	}
+
+	// Use the nearest-rank method for the percentile.
+	rank := int(math.Ceil(0.95*float64(len(values)))) - 1
+
+	return summary{
+		Mean: total / time.Duration(len(values)),
+		P95:  values[rank],
+		Min:  values[0],
+		Max:  values[len(values)-1],
+	}
+}
+
+// MarshalJSON encodes the measurements of a sample in nanoseconds.
+func (s sample) MarshalJSON() ([]byte, error) {
+	ret := make(map[string]int64, len(s))
+	for metric, d := range s {
+		ret[metric+"_ns"] = d.Nanoseconds()
+	}
+
+	return json.Marshal(ret)
+}
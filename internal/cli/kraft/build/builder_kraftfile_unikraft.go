@@ -53,10 +53,6 @@ func (build *builderKraftfileUnikraft) Buildable(ctx context.Context, opts *Buil
 		return false, fmt.Errorf("cannot build without unikraft core specification")
 	}
 
-	if opts.Rootfs == "" {
-		opts.Rootfs = opts.Project.Rootfs()
-	}
-
 	return true, nil
 }
 
@@ -250,7 +246,7 @@ func (build *builderKraftfileUnikraft) pull(ctx context.Context, opts *BuildOpti
 				p, err := packmanager.G(ctx).Catalog(ctx,
 					packmanager.WithName(component.Name()),
 					packmanager.WithTypes(component.Type()),
-					packmanager.WithVersion(component.Version()),
+					packmanager.WithVersion(opts.Project.LockedVersion(component)),
 					packmanager.WithSource(component.Source()),
 					packmanager.WithRemote(opts.NoCache),
 					packmanager.WithAuthConfig(auths),
@@ -369,6 +365,10 @@ func (build *builderKraftfileUnikraft) Prepare(ctx context.Context, opts *BuildO
 		opts.Target = &selected[0]
 	}
 
+	if opts.Rootfs == "" {
+		opts.Rootfs = opts.Project.TargetRootfs(*opts.Target)
+	}
+
 	// Calculate the width of the longest process name so that we can align the
 	// two independent processtrees if we are using "render" mode (aka the fancy
 	// mode is enabled).
@@ -395,7 +395,9 @@ func (build *builderKraftfileUnikraft) Prepare(ctx context.Context, opts *BuildO
 		}
 	}
 
-	if opts.ForcePull || !opts.NoUpdate {
+	// Do not update the index in offline mode unless explicitly requested, in
+	// which case updating fails with an error that explains why.
+	if opts.ForcePull || (!opts.NoUpdate && !packmanager.IsOffline(ctx)) {
 		model, err := processtree.NewProcessTree(
 			ctx,
 			[]processtree.ProcessTreeOption{
@@ -434,7 +436,7 @@ func (build *builderKraftfileUnikraft) Build(ctx context.Context, opts *BuildOpt
 	}
 
 	allEnvs := map[string]string{}
-	for k, v := range opts.Project.Env() {
+	for k, v := range opts.Project.TargetEnv(*opts.Target) {
 		allEnvs[k] = v
 
 		if v == "" {
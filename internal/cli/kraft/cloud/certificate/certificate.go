@@ -17,6 +17,7 @@ import (
 	"kraftkit.sh/internal/cli/kraft/cloud/certificate/get"
 	"kraftkit.sh/internal/cli/kraft/cloud/certificate/list"
 	"kraftkit.sh/internal/cli/kraft/cloud/certificate/remove"
+	"kraftkit.sh/internal/cli/kraft/cloud/certificate/renew"
 )
 
 type CertificateOptions struct{}
@@ -39,6 +40,7 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(list.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
 	cmd.AddCommand(get.NewCmd())
+	cmd.AddCommand(renew.NewCmd())
 
 	return cmd
 }
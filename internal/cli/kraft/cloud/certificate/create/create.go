@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -27,9 +28,9 @@ type CreateOptions struct {
 	Auth   *config.AuthConfig                 `noattribute:"true"`
 	Client kccertificates.CertificatesService `noattribute:"true"`
 	Metro  string                             `noattribute:"true"`
-	Chain  string                             `local:"true" long:"chain" short:"C" usage:"The chain of the certificate"`
-	CN     string                             `local:"true" long:"cn" short:"c" usage:"The common name of the certificate"`
-	Name   string                             `local:"true" size:"name" short:"n" usage:"The name of the certificate"`
+	Chain  string                             `local:"true" long:"chain" short:"C" usage:"The chain of the certificate in PEM format, starting with the leaf certificate"`
+	CN     string                             `local:"true" long:"cn" short:"c" usage:"The common name of the certificate (default: common name of the leaf certificate)"`
+	Name   string                             `local:"true" long:"name" short:"n" usage:"The name of the certificate"`
 	PKey   string                             `local:"true" long:"pkey" short:"p" usage:"The private key of the certificate in PEM format"`
 	Token  string                             `noattribute:"true"`
 }
@@ -55,34 +56,30 @@ func Create(ctx context.Context, opts *CreateOptions) (*kccertificates.CreateRes
 		)
 	}
 
-	if _, err := os.Stat(opts.PKey); err != nil {
-		if os.IsNotExist(err) {
-			log.G(ctx).Info("reading private key from argument")
-		} else {
-			return nil, fmt.Errorf("could not read private key: %w", err)
-		}
-	} else {
-		b, err := os.ReadFile(opts.PKey)
-		if err != nil {
-			return nil, fmt.Errorf("could not read private key: %w", err)
-		}
+	if opts.PKey, err = ReadPEM(ctx, "private key", opts.PKey); err != nil {
+		return nil, err
+	}
 
-		opts.PKey = string(b)
+	if opts.Chain, err = ReadPEM(ctx, "chain", opts.Chain); err != nil {
+		return nil, err
 	}
 
-	if _, err := os.Stat(opts.Chain); err != nil {
-		if os.IsNotExist(err) {
-			log.G(ctx).Info("reading chain from argument")
-		} else {
-			return nil, fmt.Errorf("could not read chain: %w", err)
-		}
-	} else {
-		b, err := os.ReadFile(opts.Chain)
-		if err != nil {
-			return nil, fmt.Errorf("could not read chain: %w", err)
+	leaf, err := ValidateTLSMaterial(opts.PKey, opts.Chain)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.CN == "" {
+		opts.CN = CommonName(leaf)
+		if opts.CN == "" {
+			return nil, fmt.Errorf("could not determine common name from certificate: set it with --cn")
 		}
 
-		opts.Chain = string(b)
+		log.G(ctx).
+			WithField("cn", opts.CN).
+			Debug("using common name of certificate")
+	} else if !Covers(leaf, opts.CN) {
+		return nil, fmt.Errorf("certificate does not cover common name %s", opts.CN)
 	}
 
 	createResp, err := opts.Client.WithMetro(opts.Metro).Create(ctx, &kccertificates.CreateRequest{
@@ -110,10 +107,20 @@ func NewCmd() *cobra.Command {
 		Aliases: []string{"crt"},
 		Long: heredoc.Doc(`
 			Create a new certificate.
+
+			The private key and chain can either be given as paths to PEM files or
+			as PEM-encoded strings.  Before uploading, the private key is checked to
+			match the leaf certificate of the chain.
+
+			Services use the certificate for all of their domains which are covered
+			by its common name.
 		`),
 		Example: heredoc.Doc(`
 			# Create a new certificate with a given common name, private key file and chain.
 			$ kraft cloud certificate create --name my-cert --cn '*.example.com' --pkey 'private-key.pem' --chain 'chain.pem'
+
+			# Create a new certificate using the common name of the leaf certificate.
+			$ kraft cloud certificate create --name my-cert --pkey 'private-key.pem' --chain 'chain.pem'
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "kraftcloud-certificate",
@@ -127,10 +134,6 @@ func NewCmd() *cobra.Command {
 }
 
 func (opts *CreateOptions) Pre(cmd *cobra.Command, _ []string) error {
-	if opts.CN == "" {
-		return fmt.Errorf("common name (CN) is required")
-	}
-
 	if opts.PKey == "" {
 		return fmt.Errorf("private key is required")
 	}
@@ -156,3 +159,27 @@ func (opts *CreateOptions) Run(ctx context.Context, _ []string) error {
 	_, err = fmt.Fprintln(iostreams.G(ctx).Out, certificate.Name)
 	return err
 }
+
+// ReadPEM returns the contents of the file at the provided path, or the value
+// itself if it is PEM-encoded or no such file exists.
+func ReadPEM(ctx context.Context, what, value string) (string, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return value, nil
+	}
+
+	if _, err := os.Stat(value); err != nil {
+		if os.IsNotExist(err) {
+			log.G(ctx).Infof("reading %s from argument", what)
+			return value, nil
+		}
+
+		return "", fmt.Errorf("could not read %s: %w", what, err)
+	}
+
+	b, err := os.ReadFile(value)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", what, err)
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package create
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ValidateTLSMaterial checks that the PEM-encoded private key matches the
+// leaf certificate of the PEM-encoded chain and that the leaf certificate is
+// currently valid.  It returns the parsed leaf certificate.
+func ValidateTLSMaterial(pkey, chain string) (*x509.Certificate, error) {
+	pair, err := tls.X509KeyPair([]byte(chain), []byte(pkey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key or chain: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("could not parse leaf certificate: %w", err)
+	}
+
+	now := time.Now()
+	if now.Before(leaf.NotBefore) {
+		return nil, fmt.Errorf("certificate is not valid before %s", leaf.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(leaf.NotAfter) {
+		return nil, fmt.Errorf("certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	return leaf, nil
+}
+
+// CommonName returns the name which a certificate is issued for, preferring
+// the subject common name over the first subject alternative name.
+func CommonName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+
+	return ""
+}
+
+// Covers returns whether the certificate is issued for the provided name.
+// Wildcard names are only covered by certificates issued for the same
+// wildcard.
+func Covers(cert *x509.Certificate, name string) bool {
+	if cert.Subject.CommonName == name || slices.Contains(cert.DNSNames, name) {
+		return true
+	}
+
+	if strings.HasPrefix(name, "*.") {
+		return false
+	}
+
+	return cert.VerifyHostname(name) == nil
+}
@@ -19,7 +19,7 @@ import (
 )
 
 type ListOptions struct {
-	Output string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
+	Output string `long:"output" short:"o" usage:"Set output format. Options: table,wide,yaml,json,list,go-template=TEMPLATE" default:"table"`
 
 	metro string
 	token string
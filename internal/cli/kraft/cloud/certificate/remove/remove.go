@@ -21,7 +21,7 @@ import (
 )
 
 type RemoveOptions struct {
-	Output string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
+	Output string `long:"output" short:"o" usage:"Set output format. Options: table,wide,yaml,json,list,go-template=TEMPLATE" default:"table"`
 	All    bool   `long:"all" usage:"Remove all certificates"`
 
 	metro string
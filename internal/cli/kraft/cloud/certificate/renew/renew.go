@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package renew
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	kraftcloud "sdk.kraft.cloud"
+	kccertificates "sdk.kraft.cloud/certificates"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/cloud/certificate/create"
+	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+)
+
+type RenewOptions struct {
+	Auth   *config.AuthConfig                 `noattribute:"true"`
+	Chain  string                             `local:"true" long:"chain" short:"C" usage:"The renewed chain of the certificate in PEM format"`
+	Client kccertificates.CertificatesService `noattribute:"true"`
+	Keep   bool                               `local:"true" long:"keep" usage:"Keep the previous certificate after uploading the renewed one"`
+	Metro  string                             `noattribute:"true"`
+	Name   string                             `local:"true" long:"name" short:"n" usage:"The name of the renewed certificate (default: previous name suffixed with the expiry date)"`
+	PKey   string                             `local:"true" long:"pkey" short:"p" usage:"The private key of the renewed certificate in PEM format"`
+	Token  string                             `noattribute:"true"`
+}
+
+// expirySuffix matches the suffix which is appended to the name of renewed
+// certificates by default.
+var expirySuffix = regexp.MustCompile(`-\d{8}$`)
+
+// Renew a KraftCloud certificate by uploading new TLS material for the same
+// common name and removing the previous certificate.
+func Renew(ctx context.Context, opts *RenewOptions, nameOrUUID string) (*kccertificates.CreateResponseItem, error) {
+	var err error
+
+	if opts == nil {
+		opts = &RenewOptions{}
+	}
+
+	if opts.Auth == nil {
+		opts.Auth, err = config.GetKraftCloudAuthConfig(ctx, opts.Token)
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve credentials: %w", err)
+		}
+	}
+
+	if opts.Client == nil {
+		opts.Client = kraftcloud.NewCertificatesClient(
+			kraftcloud.WithToken(config.GetKraftCloudTokenAuthConfig(*opts.Auth)),
+		)
+	}
+
+	certResp, err := opts.Client.WithMetro(opts.Metro).Get(ctx, nameOrUUID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get certificate %s: %w", nameOrUUID, err)
+	}
+
+	previous, err := certResp.FirstOrErr()
+	if err != nil {
+		return nil, fmt.Errorf("could not get certificate %s: %w", nameOrUUID, err)
+	}
+
+	if opts.PKey, err = create.ReadPEM(ctx, "private key", opts.PKey); err != nil {
+		return nil, err
+	}
+
+	if opts.Chain, err = create.ReadPEM(ctx, "chain", opts.Chain); err != nil {
+		return nil, err
+	}
+
+	leaf, err := create.ValidateTLSMaterial(opts.PKey, opts.Chain)
+	if err != nil {
+		return nil, err
+	}
+
+	if !create.Covers(leaf, previous.CommonName) {
+		return nil, fmt.Errorf("renewed certificate does not cover common name %s", previous.CommonName)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", expirySuffix.ReplaceAllString(previous.Name, ""), leaf.NotAfter.Format("20060102"))
+	}
+
+	if name == previous.Name {
+		return nil, fmt.Errorf("renewed certificate must have a different name than %s", previous.Name)
+	}
+
+	renewed, err := create.Create(ctx, &create.CreateOptions{
+		Auth:   opts.Auth,
+		Chain:  opts.Chain,
+		Client: opts.Client,
+		CN:     previous.CommonName,
+		Metro:  opts.Metro,
+		Name:   name,
+		PKey:   opts.PKey,
+		Token:  opts.Token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Keep {
+		return renewed, nil
+	}
+
+	log.G(ctx).
+		WithField("name", previous.Name).
+		Info("removing previous certificate")
+
+	delResp, err := opts.Client.WithMetro(opts.Metro).Delete(ctx, previous.UUID)
+	if err != nil {
+		return renewed, fmt.Errorf("removing previous certificate %s: %w", previous.Name, err)
+	}
+	if _, err = delResp.AllOrErr(); err != nil {
+		return renewed, fmt.Errorf("removing previous certificate %s: %w", previous.Name, err)
+	}
+
+	return renewed, nil
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&RenewOptions{}, cobra.Command{
+		Short: "Renew a certificate",
+		Use:   "renew [FLAGS] UUID|NAME",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Renew a certificate with new TLS material.
+
+			The renewed private key and chain are uploaded as a new certificate for
+			the same common name, which services start using for their domains,
+			before the previous certificate is removed.  Certificates cannot be
+			renamed, so the renewed certificate is named after the previous one
+			suffixed with its expiry date unless a name is given.
+		`),
+		Example: heredoc.Doc(`
+			# Renew a certificate with a new private key file and chain.
+			$ kraft cloud certificate renew my-cert --pkey 'private-key.pem' --chain 'chain.pem'
+
+			# Renew a certificate but keep the previous one.
+			$ kraft cloud certificate renew my-cert --keep --pkey 'private-key.pem' --chain 'chain.pem'
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "kraftcloud-certificate",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *RenewOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.PKey == "" {
+		return fmt.Errorf("private key is required")
+	}
+
+	if opts.Chain == "" {
+		return fmt.Errorf("chain is required")
+	}
+
+	err := utils.PopulateMetroToken(cmd, &opts.Metro, &opts.Token)
+	if err != nil {
+		return fmt.Errorf("could not populate metro and token: %w", err)
+	}
+
+	return nil
+}
+
+func (opts *RenewOptions) Run(ctx context.Context, args []string) error {
+	certificate, err := Renew(ctx, opts, args[0])
+	if certificate != nil {
+		if _, perr := fmt.Fprintln(iostreams.G(ctx).Out, certificate.Name); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("could not renew certificate: %w", err)
+	}
+
+	return nil
+}
@@ -81,6 +81,8 @@ type DeployOptions struct {
 	Vcpus               uint                           `local:"true" long:"vcpus" short:"V" usage:"Specify the number of vCPUs to allocate"`
 	Volumes             []string                       `long:"volume" short:"v" usage:"Specify the volume mapping(s) in the form NAME:DEST or NAME:DEST:OPTIONS"`
 	Workdir             string                         `local:"true" long:"workdir" short:"w" usage:"Set an alternative working directory (default is cwd)"`
+
+	metros []string
 }
 
 func NewCmd() *cobra.Command {
@@ -95,6 +97,12 @@ func NewCmd() *cobra.Command {
 			'kraft cloud deploy' combines a number of kraft cloud sub-commands
 			to enable you to build, package, ship and deploy your application
 			with a single command.
+
+			A comma-separated list of metros can be provided via --metro to deploy
+			to multiple metros.  The application is packaged and deployed to the
+			first metro, after which the resulting image is deployed to the
+			remaining metros in parallel.  If the deployment to any metro fails, the
+			instances and services created in all metros are removed again.
 		`),
 		Example: heredoc.Docf(`
 			# Deploy a working directory with a Kraftfile or Dockerfile:
@@ -111,6 +119,9 @@ func NewCmd() *cobra.Command {
 
 			# Immediately start following the log tail
 			$ kraft cloud --metro fra0 deploy -p 443:8080 -f caddy:latest
+
+			# Deploy the same image to multiple metros in parallel
+			$ kraft cloud --metro fra0,sfo0,was1 deploy -p 443:8080 caddy:latest
 		`),
 	})
 	if err != nil {
@@ -171,6 +182,13 @@ func (opts *DeployOptions) Pre(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("could not populate metro and token: %w", err)
 	}
 
+	opts.metros = parseMetros(opts.Metro)
+	if len(opts.metros) == 0 {
+		return fmt.Errorf("no metro provided")
+	}
+
+	opts.Metro = opts.metros[0]
+
 	opts.RestartPolicy = kcinstances.RestartPolicy(cmd.Flag("restart").Value.String())
 	opts.Rollout = create.RolloutStrategy(cmd.Flag("rollout").Value.String())
 	opts.RolloutQualifier = create.RolloutQualifier(cmd.Flag("rollout-qualifier").Value.String())
@@ -190,6 +208,16 @@ func (opts *DeployOptions) Pre(cmd *cobra.Command, _ []string) error {
 		opts.ScaleToZeroStateful = &statefulFlag
 	}
 
+	if len(opts.metros) > 1 {
+		if opts.Follow {
+			return fmt.Errorf("cannot follow the logs when deploying to multiple metros")
+		}
+
+		if opts.Rollout == create.StrategyPrompt && opts.ServiceNameOrUUID != "" {
+			return fmt.Errorf("the rollout strategy must be set with --rollout when deploying to multiple metros")
+		}
+	}
+
 	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
 	if err != nil {
 		return err
@@ -291,6 +319,20 @@ func (opts *DeployOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if len(opts.metros) > 1 {
+		primary := metroDeployment{metro: opts.Metro, insts: insts}
+		if svcResp != nil {
+			primary.svc, _ = svcResp.FirstOrErr()
+		}
+
+		deployments, err := opts.deployToMetros(ctx, primary)
+		if perr := printDeployments(ctx, opts.Output, deployments); perr != nil {
+			return errors.Join(err, perr)
+		}
+
+		return err
+	}
+
 	if !opts.Follow {
 		if len(insts) == 1 && opts.Output == "" {
 			// No need to check for error, we check if-nil inside PrettyPrintInstance.
@@ -0,0 +1,288 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	kcinstances "sdk.kraft.cloud/instances"
+	kcservices "sdk.kraft.cloud/services"
+
+	"kraftkit.sh/internal/cli/kraft/cloud/instance/create"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+)
+
+// metroDeployment is the outcome of deploying to a single metro.
+type metroDeployment struct {
+	metro string
+	insts []kcinstances.GetResponseItem
+	svc   *kcservices.GetResponseItem
+	err   error
+
+	// rolledBack is set once the deployment has been rolled back, and
+	// rollbackErr if rolling it back failed.
+	rolledBack  bool
+	rollbackErr error
+}
+
+// status returns the outcome of the deployment, i.e. whether it failed, was
+// rolled back or failed to be rolled back, or is deployed.
+func (deployment metroDeployment) status() string {
+	switch {
+	case deployment.err != nil:
+		return "failed"
+	case deployment.rollbackErr != nil:
+		return "rollback failed"
+	case deployment.rolledBack:
+		return "rolled back"
+	default:
+		return "deployed"
+	}
+}
+
+// parseMetros splits a comma-separated list of metros, dropping empty and
+// duplicate entries.
+func parseMetros(value string) []string {
+	var metros []string
+
+	for _, metro := range strings.Split(value, ",") {
+		metro = strings.TrimSpace(metro)
+		if metro == "" {
+			continue
+		}
+
+		found := false
+		for _, m := range metros {
+			if m == metro {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			metros = append(metros, metro)
+		}
+	}
+
+	return metros
+}
+
+// deployToMetros deploys the image of the primary deployment to the remaining
+// metros in parallel.  The instances are created with the same arguments and
+// resources as in the primary metro.  If the deployment to any metro fails,
+// the deployments to all metros are rolled back.
+func (opts *DeployOptions) deployToMetros(ctx context.Context, primary metroDeployment) ([]metroDeployment, error) {
+	if len(primary.insts) == 0 {
+		return nil, fmt.Errorf("no instances were deployed in %s", primary.metro)
+	}
+
+	tmpl := primary.insts[0]
+
+	deployments := make([]metroDeployment, len(opts.metros))
+	deployments[0] = primary
+
+	var wg sync.WaitGroup
+
+	for i, metro := range opts.metros[1:] {
+		wg.Add(1)
+
+		go func(i int, metro string) {
+			defer wg.Done()
+
+			deployment := metroDeployment{metro: metro}
+			defer func() {
+				deployments[i] = deployment
+			}()
+
+			log.G(ctx).
+				WithField("metro", metro).
+				WithField("image", tmpl.Image).
+				Info("deploying")
+
+			instsResp, svcResp, err := create.Create(ctx, &create.CreateOptions{
+				Auth:                opts.Auth,
+				Certificate:         opts.Certificate,
+				Client:              opts.Client,
+				Domain:              opts.Domain,
+				Entrypoint:          tmpl.Args,
+				Env:                 opts.Env,
+				Features:            opts.Features,
+				Image:               tmpl.Image,
+				Memory:              strconv.Itoa(tmpl.MemoryMB),
+				Metro:               metro,
+				Name:                opts.Name,
+				Ports:               opts.Ports,
+				Replicas:            opts.Replicas,
+				RestartPolicy:       &opts.RestartPolicy,
+				Rollout:             &opts.Rollout,
+				RolloutQualifier:    &opts.RolloutQualifier,
+				RolloutWait:         opts.RolloutWait,
+				ScaleToZero:         opts.ScaleToZero,
+				ScaleToZeroStateful: opts.ScaleToZeroStateful,
+				ScaleToZeroCooldown: opts.ScaleToZeroCooldown,
+				ServiceNameOrUUID:   opts.ServiceNameOrUUID,
+				Start:               !opts.NoStart,
+				SubDomain:           opts.SubDomain,
+				Token:               opts.Token,
+				Vcpus:               uint(tmpl.Vcpus),
+				Volumes:             opts.Volumes,
+				WaitForImage:        true,
+				WaitForImageTimeout: opts.Timeout,
+			})
+			if err != nil {
+				deployment.err = err
+				return
+			}
+
+			if deployment.insts, err = instsResp.AllOrErr(); err != nil {
+				deployment.err = err
+				return
+			}
+
+			if svcResp != nil {
+				deployment.svc, _ = svcResp.FirstOrErr()
+			}
+		}(i+1, metro)
+	}
+
+	wg.Wait()
+
+	return deployments, settleDeployments(ctx, deployments, opts.rollback)
+}
+
+// settleDeployments returns the errors of the deployments which failed, in
+// which case each of the deployments which succeeded is rolled back and the
+// outcome of rolling it back is recorded on the deployment.
+func settleDeployments(ctx context.Context, deployments []metroDeployment, rollback func(context.Context, metroDeployment) error) error {
+	var errs []error
+	for _, deployment := range deployments {
+		if deployment.err != nil {
+			log.G(ctx).
+				WithField("metro", deployment.metro).
+				Errorf("could not deploy: %v", deployment.err)
+			errs = append(errs, fmt.Errorf("%s: %w", deployment.metro, deployment.err))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	for i := range deployments {
+		if deployments[i].err != nil {
+			continue
+		}
+
+		if err := rollback(ctx, deployments[i]); err != nil {
+			deployments[i].rollbackErr = err
+			errs = append(errs, fmt.Errorf("rolling back %s: %w", deployments[i].metro, err))
+			continue
+		}
+
+		deployments[i].rolledBack = true
+	}
+
+	return errors.Join(errs...)
+}
+
+// rollbackResources returns the UUIDs of the instances and of the service, if
+// any, which are removed to roll back the deployment.  A service which existed
+// before the deployment, i.e. which was provided via --service, is kept.
+func rollbackResources(deployment metroDeployment, existingService bool) ([]string, string) {
+	uuids := make([]string, len(deployment.insts))
+	for i, inst := range deployment.insts {
+		uuids[i] = inst.UUID
+	}
+
+	var svc string
+	if deployment.svc != nil && !existingService {
+		svc = deployment.svc.UUID
+	}
+
+	return uuids, svc
+}
+
+// rollback removes the instances of a deployment as well as its service, if
+// the service was created as part of the deployment.
+func (opts *DeployOptions) rollback(ctx context.Context, deployment metroDeployment) error {
+	log.G(ctx).
+		WithField("metro", deployment.metro).
+		Warn("rolling back")
+
+	uuids, svc := rollbackResources(deployment, opts.ServiceNameOrUUID != "")
+
+	if len(uuids) > 0 {
+		if _, err := opts.Client.Instances().WithMetro(deployment.metro).Delete(ctx, uuids...); err != nil {
+			return fmt.Errorf("removing instances: %w", err)
+		}
+	}
+
+	if svc != "" {
+		if _, err := opts.Client.Services().WithMetro(deployment.metro).Delete(ctx, svc); err != nil {
+			return fmt.Errorf("removing service: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// printDeployments prints the status of the deployment in each metro.
+func printDeployments(ctx context.Context, format string, deployments []metroDeployment) error {
+	if format == "" {
+		format = "table"
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(format),
+	)
+	if err != nil {
+		return err
+	}
+
+	table.AddField("METRO", cs.Bold)
+	table.AddField("STATUS", cs.Bold)
+	table.AddField("INSTANCES", cs.Bold)
+	table.AddField("FQDN", cs.Bold)
+	table.EndRow()
+
+	for _, deployment := range deployments {
+		table.AddField(deployment.metro, nil)
+
+		switch status := deployment.status(); status {
+		case "deployed":
+			table.AddField(status, cs.Green)
+		case "rolled back":
+			table.AddField(status, cs.Yellow)
+		default:
+			table.AddField(status, cs.Red)
+		}
+
+		names := make([]string, len(deployment.insts))
+		for i, inst := range deployment.insts {
+			names[i] = inst.Name
+		}
+		table.AddField(strings.Join(names, ", "), nil)
+
+		var fqdn string
+		if deployment.svc != nil && len(deployment.svc.Domains) > 0 {
+			fqdn = deployment.svc.Domains[0].FQDN
+		}
+		table.AddField(fqdn, nil)
+
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
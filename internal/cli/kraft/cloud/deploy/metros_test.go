@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	kcinstances "sdk.kraft.cloud/instances"
+	kcservices "sdk.kraft.cloud/services"
+)
+
+func TestParseMetros(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{value: "", expected: nil},
+		{value: "fra0", expected: []string{"fra0"}},
+		{value: "fra0,was1,sin0", expected: []string{"fra0", "was1", "sin0"}},
+		{value: " fra0 , was1 ", expected: []string{"fra0", "was1"}},
+		{value: "fra0,,was1,", expected: []string{"fra0", "was1"}},
+		{value: "fra0,was1,fra0", expected: []string{"fra0", "was1"}},
+		{value: " , ", expected: nil},
+	}
+
+	for _, tt := range tests {
+		if metros := parseMetros(tt.value); !slices.Equal(metros, tt.expected) {
+			t.Errorf("%q: expected %v, got %v", tt.value, tt.expected, metros)
+		}
+	}
+}
+
+func TestSettleDeployments(t *testing.T) {
+	errDeploy := errors.New("image not found")
+	errRollback := errors.New("instance is locked")
+
+	tests := []struct {
+		name       string
+		failed     []string
+		rollback   map[string]error
+		err        bool
+		rolledBack []string
+		status     map[string]string
+	}{
+		{
+			name: "all deployed",
+			status: map[string]string{
+				"fra0": "deployed",
+				"was1": "deployed",
+				"sin0": "deployed",
+			},
+		},
+		{
+			name:       "one failed",
+			failed:     []string{"was1"},
+			err:        true,
+			rolledBack: []string{"fra0", "sin0"},
+			status: map[string]string{
+				"fra0": "rolled back",
+				"was1": "failed",
+				"sin0": "rolled back",
+			},
+		},
+		{
+			name:       "rollback failed",
+			failed:     []string{"sin0"},
+			rollback:   map[string]error{"was1": errRollback},
+			err:        true,
+			rolledBack: []string{"fra0", "was1"},
+			status: map[string]string{
+				"fra0": "rolled back",
+				"was1": "rollback failed",
+				"sin0": "failed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployments := []metroDeployment{}
+			for _, metro := range []string{"fra0", "was1", "sin0"} {
+				deployment := metroDeployment{metro: metro}
+				if slices.Contains(tt.failed, metro) {
+					deployment.err = errDeploy
+				}
+
+				deployments = append(deployments, deployment)
+			}
+
+			var rolledBack []string
+			err := settleDeployments(context.Background(), deployments, func(_ context.Context, deployment metroDeployment) error {
+				rolledBack = append(rolledBack, deployment.metro)
+				return tt.rollback[deployment.metro]
+			})
+			if (err != nil) != tt.err {
+				t.Errorf("expected error: %t, got: %v", tt.err, err)
+			}
+
+			if !slices.Equal(rolledBack, tt.rolledBack) {
+				t.Errorf("expected %v to be rolled back, got %v", tt.rolledBack, rolledBack)
+			}
+
+			if len(tt.rollback) > 0 && !errors.Is(err, errRollback) {
+				t.Errorf("expected the rollback error to be returned, got %v", err)
+			}
+
+			for _, deployment := range deployments {
+				if status := deployment.status(); status != tt.status[deployment.metro] {
+					t.Errorf("%s: expected status %q, got %q", deployment.metro, tt.status[deployment.metro], status)
+				}
+			}
+		})
+	}
+}
+
+func TestRollbackResources(t *testing.T) {
+	deployment := metroDeployment{
+		metro: "fra0",
+		insts: []kcinstances.GetResponseItem{
+			{UUID: "inst-1", Name: "nginx-1"},
+			{UUID: "inst-2", Name: "nginx-2"},
+		},
+		svc: &kcservices.GetResponseItem{UUID: "svc-1"},
+	}
+
+	uuids, svc := rollbackResources(deployment, false)
+	if !slices.Equal(uuids, []string{"inst-1", "inst-2"}) || svc != "svc-1" {
+		t.Errorf("expected the instances and the created service to be removed, got %v and %q", uuids, svc)
+	}
+
+	// The service provided via --service existed before the deployment, hence
+	// only the new instances are removed.
+	uuids, svc = rollbackResources(deployment, true)
+	if !slices.Equal(uuids, []string{"inst-1", "inst-2"}) || svc != "" {
+		t.Errorf("expected only the instances to be removed, got %v and %q", uuids, svc)
+	}
+
+	uuids, svc = rollbackResources(metroDeployment{metro: "fra0"}, false)
+	if len(uuids) != 0 || svc != "" {
+		t.Errorf("expected nothing to be removed, got %v and %q", uuids, svc)
+	}
+}
@@ -31,7 +31,7 @@ import (
 
 type ListOptions struct {
 	All    bool   `long:"all" usage:"Also show available official images"`
-	Output string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
+	Output string `long:"output" short:"o" usage:"Set output format. Options: table,wide,yaml,json,list,go-template=TEMPLATE" default:"table"`
 
 	metro string
 	token string
@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package export
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	kraftcloud "sdk.kraft.cloud"
+	kcinstances "sdk.kraft.cloud/instances"
+	kcservices "sdk.kraft.cloud/services"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+	"kraftkit.sh/internal/cli/kraft/run"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/packmanager"
+)
+
+type ExportOptions struct {
+	Auth     *config.AuthConfig    `noattribute:"true"`
+	Client   kraftcloud.KraftCloud `noattribute:"true"`
+	Metro    string                `noattribute:"true"`
+	Name     string                `local:"true" long:"name" short:"n" usage:"Name of the local machine (default is the name of the instance)"`
+	Platform string                `local:"true" long:"plat" short:"p" usage:"Set the platform of the local machine" default:"fc"`
+	Start    bool                  `local:"true" long:"start" short:"S" usage:"Start the local machine after creating it"`
+	ToLocal  bool                  `local:"true" long:"to-local" usage:"Create the machine in the local store instead of printing its specification"`
+	Token    string                `noattribute:"true"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ExportOptions{}, cobra.Command{
+		Short: "Export an instance as a local machine",
+		Use:   "export [FLAGS] UUID|NAME",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+			# Print the 'kraft run' command equivalent to an instance
+			$ kraft cloud instance export my-instance-431342
+
+			# Pull the image of the instance and create an equivalent local machine
+			$ kraft cloud instance export --to-local my-instance-431342
+
+			# Create and start the local machine under a different name
+			$ kraft cloud instance export --to-local --start --name repro my-instance-431342
+		`),
+		Long: heredoc.Doc(`
+			Export an instance on Unikraft Cloud as a local machine.
+
+			The image, memory, arguments, environment variables, published ports
+			and volume mount points of the instance are translated into the
+			specification of a local machine, such that issues seen in production
+			can be reproduced locally.  The contents of the volumes of the
+			instance are not copied: each is replaced with an empty local volume
+			at the same mount point.
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "kraftcloud-instance",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ExportOptions) Pre(cmd *cobra.Command, _ []string) error {
+	err := utils.PopulateMetroToken(cmd, &opts.Metro, &opts.Token)
+	if err != nil {
+		return fmt.Errorf("could not populate metro and token: %w", err)
+	}
+
+	if opts.Start && !opts.ToLocal {
+		return fmt.Errorf("the --start flag requires --to-local")
+	}
+
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *ExportOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	if opts.Auth == nil {
+		opts.Auth, err = config.GetKraftCloudAuthConfig(ctx, opts.Token)
+		if err != nil {
+			return fmt.Errorf("could not retrieve credentials: %w", err)
+		}
+	}
+
+	if opts.Client == nil {
+		opts.Client = kraftcloud.NewClient(
+			kraftcloud.WithToken(config.GetKraftCloudTokenAuthConfig(*opts.Auth)),
+		)
+	}
+
+	instanceResp, err := opts.Client.Instances().WithMetro(opts.Metro).Get(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("could not get instance %s: %w", args[0], err)
+	}
+
+	instance, err := instanceResp.FirstOrErr()
+	if err != nil {
+		return fmt.Errorf("could not get instance %s: %w", args[0], err)
+	}
+
+	var serviceGroup *kcservices.GetResponseItem
+	if sg := instance.ServiceGroup; sg != nil && sg.UUID != "" {
+		serviceResp, err := opts.Client.Services().WithMetro(opts.Metro).Get(ctx, sg.UUID)
+		if err != nil {
+			return fmt.Errorf("could not get service %s: %w", sg.UUID, err)
+		}
+
+		serviceGroup, err = serviceResp.FirstOrErr()
+		if err != nil {
+			return fmt.Errorf("could not get service %s: %w", sg.UUID, err)
+		}
+	}
+
+	runOpts, runArgs := opts.runOptions(ctx, instance, serviceGroup)
+
+	if !opts.ToLocal {
+		fmt.Fprintln(iostreams.G(ctx).Out, runCommand(runOpts, runArgs))
+		return nil
+	}
+
+	log.G(ctx).
+		WithField("instance", instance.Name).
+		WithField("image", runArgs[0]).
+		Info("creating local machine")
+
+	return runOpts.Run(ctx, runArgs)
+}
+
+// runOptions translates the instance and its service group, if any, into the
+// options and arguments of an equivalent local machine.
+func (opts *ExportOptions) runOptions(ctx context.Context, instance *kcinstances.GetResponseItem, serviceGroup *kcservices.GetResponseItem) (run.RunOptions, []string) {
+	name := opts.Name
+	if name == "" {
+		name = instance.Name
+	}
+
+	env := make([]string, 0, len(instance.Env))
+	for k, v := range instance.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(env)
+
+	// The services of the instance terminate TLS and redirect HTTP in front of
+	// the instance, which is not the case locally.  Hence, the port on which
+	// the instance listens is published as-is and redirects are dropped.
+	ports := []string{}
+	if serviceGroup != nil {
+		for _, service := range serviceGroup.Services {
+			if slices.Contains(service.Handlers, kcservices.HandlerRedirect) {
+				continue
+			}
+
+			port := fmt.Sprintf("%d:%d", service.DestinationPort, service.DestinationPort)
+			if !slices.Contains(ports, port) {
+				ports = append(ports, port)
+			}
+		}
+	}
+
+	volumes := []string{}
+	for _, vol := range instance.Volumes {
+		log.G(ctx).
+			WithField("volume", vol.Name).
+			WithField("at", vol.At).
+			Warn("contents of volume are not exported, using an empty local volume")
+
+		volumes = append(volumes, vol.At)
+	}
+
+	runOpts := run.RunOptions{
+		Architecture: "x86_64",
+		Detach:       true,
+		Env:          env,
+		Memory:       fmt.Sprintf("%dMi", instance.MemoryMB),
+		Name:         name,
+		NoStart:      !opts.Start,
+		Platform:     opts.Platform,
+		Ports:        ports,
+		Pull:         "missing",
+		Volumes:      volumes,
+	}
+
+	return runOpts, append([]string{imageRef(instance.Image)}, instance.Args...)
+}
+
+// imageRef returns the fully qualified reference of the image of an instance,
+// which is reported relative to the registry of Unikraft Cloud.
+func imageRef(image string) string {
+	if strings.HasPrefix(image, "unikraft.io") {
+		return "index." + image
+	} else if !strings.HasPrefix(image, "index.unikraft.io") {
+		return "index.unikraft.io/" + image
+	}
+
+	return image
+}
+
+// runCommand returns the 'kraft run' command line which creates the machine
+// described by the options and arguments, the first of which is the image.
+func runCommand(opts run.RunOptions, args []string) string {
+	cmd := []string{"kraft", "run", "--detach"}
+
+	cmd = append(cmd, "--name", quote(opts.Name))
+	cmd = append(cmd, "--plat", opts.Platform)
+	cmd = append(cmd, "--arch", opts.Architecture)
+	cmd = append(cmd, "--memory", opts.Memory)
+
+	for _, env := range opts.Env {
+		cmd = append(cmd, "--env", quote(env))
+	}
+
+	for _, port := range opts.Ports {
+		cmd = append(cmd, "--port", port)
+	}
+
+	for _, vol := range opts.Volumes {
+		cmd = append(cmd, "--volume", quote(vol))
+	}
+
+	// The arguments of the application follow the image and are separated
+	// from it, such that they are not parsed as flags of 'kraft run'.
+	for i, arg := range args {
+		if i == 1 {
+			cmd = append(cmd, "--")
+		}
+
+		cmd = append(cmd, quote(arg))
+	}
+
+	return strings.Join(cmd, " ")
+}
+
+// quote single-quotes the argument if it contains characters which would be
+// interpreted by a shell.
+func quote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
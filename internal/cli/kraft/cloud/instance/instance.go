@@ -14,6 +14,7 @@ import (
 	"kraftkit.sh/cmdfactory"
 
 	"kraftkit.sh/internal/cli/kraft/cloud/instance/create"
+	"kraftkit.sh/internal/cli/kraft/cloud/instance/export"
 	"kraftkit.sh/internal/cli/kraft/cloud/instance/get"
 	"kraftkit.sh/internal/cli/kraft/cloud/instance/list"
 	"kraftkit.sh/internal/cli/kraft/cloud/instance/logs"
@@ -40,6 +41,7 @@ func NewCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(create.NewCmd())
+	cmd.AddCommand(export.NewCmd())
 	cmd.AddCommand(list.NewCmd())
 	cmd.AddCommand(logs.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MakeNowJust/heredoc"
@@ -30,14 +32,35 @@ import (
 type LogOptions struct {
 	Auth     *config.AuthConfig    `noattribute:"true"`
 	Client   kraftcloud.KraftCloud `noattribute:"true"`
-	Follow   bool                  `local:"true" long:"follow" short:"f" usage:"Follow the logs of the instance every half second" default:"false"`
+	Follow   bool                  `local:"true" long:"follow" short:"f" usage:"Follow the logs of the instance, reconnecting if the stream drops" default:"false"`
 	Metro    string                `noattribute:"true"`
 	NoPrefix bool                  `long:"no-prefix" usage:"When logging multiple machines, do not prefix each log line with the name"`
 	Prefix   string                `local:"true" long:"prefix" short:"p" usage:"Prefix the logs with a given string"`
+	Since    string                `local:"true" long:"since" usage:"Show logs since a timestamp (e.g. 2024-01-02T13:23:37Z) or relative duration (e.g. 42m)"`
 	Tail     int                   `local:"true" long:"tail" short:"n" usage:"Show the last given lines from the logs" default:"-1"`
 	Token    string                `noattribute:"true"`
+
+	since time.Time
 }
 
+const (
+	// pollInterval is the interval at which the logs and the state of an
+	// instance are polled.
+	pollInterval = 500 * time.Millisecond
+
+	// reconnectOverlap is the number of lines which are requested again when
+	// the log stream is re-established, in order to resume where it dropped.
+	reconnectOverlap = 100
+
+	// maxReconnects is the number of consecutive attempts at re-establishing
+	// the log stream before giving up.
+	maxReconnects = 10
+
+	// maxBackoff is the maximum time waited between attempts at re-establishing
+	// the log stream.
+	maxBackoff = 30 * time.Second
+)
+
 // Log retrieves the console output from a KraftCloud instance.
 func Log(ctx context.Context, opts *LogOptions, args ...string) error {
 	if opts == nil {
@@ -68,9 +91,24 @@ func NewCmd() *cobra.Command {
 
 			# Get the last 10 lines of a instance by name continuously
 			$ kraft cloud instance logs my-instance-431342 --follow --tail 10
+
+			# Follow the console output of multiple instances
+			$ kraft cloud instance logs my-instance-431342 my-instance-837213 --follow
+
+			# Follow the console output of an instance written from 5 minutes ago
+			$ kraft cloud instance logs my-instance-431342 --follow --since 5m
 		`),
 		Long: heredoc.Doc(`
 			Get console output of an instance.
+
+			When following the console output, the stream is re-established should
+			it drop, resuming where it left off.  The console output of multiple
+			instances is prefixed with the name of each instance.
+
+			The console output of instances is not timestamped, so --since shows
+			all of the console output of an instance which was started after the
+			provided time and otherwise only the output which is written from now
+			on.
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "kraftcloud-instance",
@@ -93,6 +131,10 @@ func (opts *LogOptions) Pre(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("invalid value for --tail: %d, should be -1 for all logs, or positive for length of truncated logs", opts.Tail)
 	}
 
+	if opts.since, err = logs.ParseTime(opts.Since, time.Now()); err != nil {
+		return fmt.Errorf("parsing --since: %w", err)
+	}
+
 	return nil
 }
 
@@ -128,109 +170,208 @@ func Logs(ctx context.Context, opts *LogOptions, args ...string) error {
 		opts.NoPrefix = true
 	}
 
+	var mu sync.Mutex
 	var errGroup []error
 	observations := waitgroup.WaitGroup[string]{}
 
 	for _, instance := range args {
 		instance := instance
-		prefix := ""
+		prefix := opts.Prefix
 		if !opts.NoPrefix {
-			prefix = instance + strings.Repeat(" ", longestName-len(instance))
-		}
+			if prefix != "" {
+				prefix += " "
+			}
 
-		consumer, err := logs.NewColorfulConsumer(iostreams.G(ctx), !config.G[config.KraftKit](ctx).NoColor, prefix)
-		if err != nil {
-			errGroup = append(errGroup, err)
+			prefix += instance + strings.Repeat(" ", longestName-len(instance))
 		}
 
-		logChan, errChan, err := opts.Client.Instances().WithMetro(opts.Metro).TailLogs(ctx, instance, opts.Follow, opts.Tail, 500*time.Millisecond)
+		consumer, err := logs.NewColorfulConsumer(iostreams.G(ctx), !config.G[config.KraftKit](ctx).NoColor, prefix)
 		if err != nil {
-			return fmt.Errorf("initializing log tailing: %w", err)
+			return err
 		}
 
 		observations.Add(instance)
 
-		var inst *kcinstances.GetResponseItem
-
-		// Continuously check the state in a separate thread every 1 second.
 		go func() {
-			for {
-				resp, err := opts.Client.Instances().WithMetro(opts.Metro).Get(ctx, instance)
-				if err != nil {
-					// Likely there was an issue performing the request; so we'll just
-					// skip and attempt to retrieve more logs.
-					if !errors.Is(err, io.EOF) {
-						log.G(ctx).Error(err)
-					}
-
-					continue
-				}
-
-				inst, err = resp.FirstOrErr()
-				if err != nil {
-					errGroup = append(errGroup, err)
-				}
-
-				if len(observations.Items()) == 0 {
-					return
-				}
+			defer observations.Done(instance)
 
-				time.Sleep(time.Second)
+			if err := opts.stream(ctx, instance, consumer); err != nil {
+				mu.Lock()
+				errGroup = append(errGroup, fmt.Errorf("%s: %w", instance, err))
+				mu.Unlock()
 			}
 		}()
+	}
 
-		go func() {
-			defer observations.Done(instance)
+	observations.Wait()
 
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case err := <-errChan:
-					if err != nil && !strings.Contains(err.Error(), "operation timed out") && !errors.Is(err, io.EOF) {
-						errGroup = append(errGroup, err)
-						return
-					}
-				case line, ok := <-logChan:
-					if ok {
-						consumer.Consume(line)
-					} else {
-						if inst != nil && inst.State == kcinstances.InstanceStateStopped {
-							consumer.Consume(
-								"",
-								fmt.Sprintf("The instance has exited (%s).", inst.DescribeStopReason()),
-								"",
-								"To see more details about why, run:",
-								"",
-								fmt.Sprintf("\tkraft cloud instance get %s", inst.Name),
-								"",
-							)
-						}
-						return
-					}
-				case <-time.After(time.Second):
-					// If we have not received anything after 1 second through any of the
-					// other channels, check if the instance has stopped and exit if it
-					// has.
-					if inst != nil && inst.State == kcinstances.InstanceStateStopped {
-						consumer.Consume(
-							"",
-							fmt.Sprintf("The instance has exited (%s).", inst.DescribeStopReason()),
-							"",
-							"To see more details about why, run:",
-							"",
-							fmt.Sprintf("\tkraft cloud instance get %s", inst.Name),
-							"",
-						)
-
-						return
-					}
+	return errors.Join(errGroup...)
+}
+
+// stream consumes the console output of the instance until its end is
+// reached or, when following, the instance has stopped.  A stream which drops
+// whilst following is re-established with backoff.
+func (opts *LogOptions) stream(ctx context.Context, instance string, consumer logs.LogConsumer) error {
+	client := opts.Client.Instances().WithMetro(opts.Metro)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Continuously check the state of the instance in a separate thread.
+	var state atomic.Pointer[kcinstances.GetResponseItem]
+
+	go func() {
+		for {
+			if resp, err := client.Get(ctx, instance); err != nil {
+				// Likely there was an issue performing the request; so we'll just
+				// skip and attempt again.
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					log.G(ctx).Debugf("could not get state of %s: %v", instance, err)
 				}
+			} else if inst, err := resp.FirstOrErr(); err == nil {
+				state.Store(inst)
 			}
-		}()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+
+	tail, err := opts.initialTail(ctx, client, instance)
+	if err != nil {
+		return err
 	}
 
-	observations.Wait()
+	resync := newResync(reconnectOverlap)
+	backoff := pollInterval
+	attempts := 0
 
-	return errors.Join(errGroup...)
+	for {
+		received, err := opts.consume(ctx, client, instance, tail, consumer, resync, &state)
+		if err == nil || !opts.Follow || ctx.Err() != nil {
+			return err
+		}
+
+		if received {
+			attempts = 0
+			backoff = pollInterval
+		}
+
+		attempts++
+		if attempts > maxReconnects {
+			return fmt.Errorf("giving up after %d attempts at reconnecting: %w", maxReconnects, err)
+		}
+
+		log.G(ctx).
+			WithField("instance", instance).
+			Debugf("log stream dropped, reconnecting in %s: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+		tail = reconnectOverlap
+		resync.reconnect()
+	}
+}
+
+// initialTail returns the number of existing lines of the console output of
+// the instance which are shown, taking --since into account.  As the console
+// output is not timestamped, it is shown in full if the instance was started
+// after the provided time and not at all otherwise.
+func (opts *LogOptions) initialTail(ctx context.Context, client kcinstances.InstancesService, instance string) (int, error) {
+	if opts.since.IsZero() {
+		return opts.Tail, nil
+	}
+
+	resp, err := client.Get(ctx, instance)
+	if err != nil {
+		return 0, fmt.Errorf("could not get instance: %w", err)
+	}
+
+	inst, err := resp.FirstOrErr()
+	if err != nil {
+		return 0, err
+	}
+
+	startedAt, err := time.Parse(time.RFC3339, inst.StartedAt)
+	if err != nil || startedAt.Before(opts.since) {
+		return 0, nil
+	}
+
+	return opts.Tail, nil
+}
+
+// consume prints the lines of a single log stream of the instance until it
+// ends.  It returns whether any line was received and an error if the stream
+// dropped, or nil if it ended because the instance has stopped or the end of
+// the log was reached without following.
+func (opts *LogOptions) consume(ctx context.Context, client kcinstances.InstancesService, instance string, tail int, consumer logs.LogConsumer, resync *resync, state *atomic.Pointer[kcinstances.GetResponseItem]) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	logChan, errChan, err := client.TailLogs(ctx, instance, opts.Follow, tail, pollInterval)
+	if err != nil {
+		return false, fmt.Errorf("initializing log tailing: %w", err)
+	}
+
+	received := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return received, nil
+
+		case err := <-errChan:
+			if err != nil {
+				return received, err
+			}
+
+		case line, ok := <-logChan:
+			if ok {
+				received = true
+				consumer.Consume(resync.push(line)...)
+				continue
+			}
+
+			if inst := state.Load(); inst != nil && inst.State == kcinstances.InstanceStateStopped {
+				printExited(consumer, inst)
+				return received, nil
+			}
+
+			if !opts.Follow {
+				return received, nil
+			}
+
+			return received, fmt.Errorf("log stream closed")
+
+		case <-time.After(time.Second):
+			// If we have not received anything after 1 second through any of the
+			// other channels, check if the instance has stopped and exit if it
+			// has.
+			if inst := state.Load(); opts.Follow && inst != nil && inst.State == kcinstances.InstanceStateStopped {
+				printExited(consumer, inst)
+				return received, nil
+			}
+		}
+	}
+}
+
+// printExited informs that the instance has exited.
+func printExited(consumer logs.LogConsumer, inst *kcinstances.GetResponseItem) {
+	consumer.Consume(
+		"",
+		fmt.Sprintf("The instance has exited (%s).", inst.DescribeStopReason()),
+		"",
+		"To see more details about why, run:",
+		"",
+		fmt.Sprintf("\tkraft cloud instance get %s", inst.Name),
+		"",
+	)
 }
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package logs
+
+import "slices"
+
+// resync suppresses the lines which are printed again after the log stream of
+// an instance has been re-established.  The console output of instances
+// carries neither offsets nor timestamps, so the stream is re-established
+// with the last lines of the log, and incoming lines are held back for as long
+// as they repeat the most recently printed lines.
+type resync struct {
+	// history holds the most recently printed lines.
+	history []string
+
+	// size is the maximum number of lines held in the history.
+	size int
+
+	// pending holds the lines which have been received since the stream was
+	// re-established and which repeat lines of the history.
+	pending []string
+
+	// syncing is set whilst the stream is being re-established.
+	syncing bool
+}
+
+// newResync returns a resync which remembers the provided number of lines.
+func newResync(size int) *resync {
+	return &resync{size: size}
+}
+
+// reconnect signals that the stream is re-established, after which the first
+// lines received are expected to repeat the history.
+func (r *resync) reconnect() {
+	r.pending = nil
+	r.syncing = len(r.history) > 0
+}
+
+// push accepts the next line received from the stream and returns the lines
+// which should be printed.
+func (r *resync) push(line string) []string {
+	if !r.syncing {
+		r.remember(line)
+		return []string{line}
+	}
+
+	r.pending = append(r.pending, line)
+
+	for start := 0; start+len(r.pending) <= len(r.history); start++ {
+		if !slices.Equal(r.history[start:start+len(r.pending)], r.pending) {
+			continue
+		}
+
+		// The pending lines repeat the end of the history, so any further line
+		// is new.
+		if start+len(r.pending) == len(r.history) {
+			r.pending = nil
+			r.syncing = false
+		}
+
+		return nil
+	}
+
+	// The pending lines do not repeat the history, e.g. because more lines than
+	// were requested have been written since the stream dropped, so they are
+	// all printed.
+	lines := r.pending
+	r.pending = nil
+	r.syncing = false
+
+	for _, line := range lines {
+		r.remember(line)
+	}
+
+	return lines
+}
+
+// remember adds the line to the history.
+func (r *resync) remember(line string) {
+	r.history = append(r.history, line)
+	if len(r.history) > r.size {
+		r.history = r.history[len(r.history)-r.size:]
+	}
+}
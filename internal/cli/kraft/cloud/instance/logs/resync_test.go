@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package logs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResync(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   []string
+		after    []string
+		expected []string
+	}{
+		{
+			name:     "repeated tail is suppressed",
+			before:   []string{"a", "b", "c", "d"},
+			after:    []string{"b", "c", "d", "e", "f"},
+			expected: []string{"e", "f"},
+		},
+		{
+			name:     "repeated line within the tail",
+			before:   []string{"x", "x", "y"},
+			after:    []string{"x", "y", "x"},
+			expected: []string{"x"},
+		},
+		{
+			name:     "unrelated lines are printed",
+			before:   []string{"a", "b"},
+			after:    []string{"q", "z"},
+			expected: []string{"q", "z"},
+		},
+		{
+			name:     "nothing printed before",
+			before:   []string{},
+			after:    []string{"a", "b"},
+			expected: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newResync(3)
+
+			for _, line := range tt.before {
+				r.push(line)
+			}
+
+			r.reconnect()
+
+			var printed []string
+			for _, line := range tt.after {
+				printed = append(printed, r.push(line)...)
+			}
+
+			if !slices.Equal(printed, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, printed)
+			}
+		})
+	}
+}
@@ -25,7 +25,7 @@ type StopOptions struct {
 	Auth         *config.AuthConfig    `noattribute:"true"`
 	Client       kraftcloud.KraftCloud `noattribute:"true"`
 	Wait         time.Duration         `local:"true" long:"wait" short:"w" usage:"Time to wait for the instance to drain all connections before it is stopped (ms/s/m/h)"`
-	DrainTimeout time.Duration         `local:"true" long:"drain-timeout" short:"d" usage:"Timeout for the instance to stop (ms/s/m/h)"`
+	DrainTimeout time.Duration         `local:"true" long:"drain-timeout" short:"d" usage:"Timeout for the instance to stop (ms/s/m/h)" deprecated:"use --wait instead"`
 	All          bool                  `long:"all" short:"a" usage:"Stop all instances"`
 	Force        bool                  `long:"force" short:"f" usage:"Force stop the instance(s)"`
 	Metro        string                `noattribute:"true"`
@@ -92,7 +92,6 @@ func Stop(ctx context.Context, opts *StopOptions, args ...string) error {
 
 	if opts.DrainTimeout != 0 && opts.Wait == 0 {
 		opts.Wait = opts.DrainTimeout
-		log.G(ctx).Warnf("drain timeout is deprecated, use wait instead")
 	}
 
 	if opts.Wait < time.Millisecond && opts.Wait != 0 {
@@ -22,7 +22,9 @@ import (
 )
 
 type QuotasOptions struct {
-	Output string `local:"true" long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"list"`
+	AllMetros bool   `local:"true" long:"all-metros" usage:"Show the quotas of all metros"`
+	Output    string `local:"true" long:"output" short:"o" usage:"Set output format. Options: table,wide,yaml,json,list,go-template=TEMPLATE" default:"list"`
+	Threshold int    `local:"true" long:"threshold" usage:"Exit with a non-zero code if the usage of any resource reaches the given percentage of its limit"`
 
 	metro string
 	token string
@@ -37,12 +39,27 @@ func NewCmd() *cobra.Command {
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "kraftcloud",
 		},
+		Long: heredoc.Doc(`
+			View your resource quota on Unikraft Cloud.
+
+			The usage of instances, vCPUs, memory, services, volumes and image storage
+			is shown against the limits of your account.  With --threshold, the
+			command exits with a non-zero code if the usage of any of these resources
+			reaches the given percentage of its limit, which allows checking for
+			sufficient capacity before deploying, e.g. in CI.
+		`),
 		Example: heredoc.Doc(`
 			# View your resource quota on Unikraft Cloud
 			$ kraft cloud quota
 
 			# View your resource quota on Unikraft Cloud in JSON format
 			$ kraft cloud quota -o json
+
+			# View your resource quota in all metros as a table
+			$ kraft cloud quota --all-metros -o table
+
+			# Fail if any resource is at 90% of its limit or above
+			$ kraft cloud quota --threshold 90
 		`),
 	})
 	if err != nil {
@@ -62,6 +79,10 @@ func (opts *QuotasOptions) Pre(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("invalid output format: %s", opts.Output)
 	}
 
+	if opts.Threshold < 0 || opts.Threshold > 100 {
+		return fmt.Errorf("threshold must be a percentage between 0 and 100")
+	}
+
 	return nil
 }
 
@@ -75,21 +96,116 @@ func (opts *QuotasOptions) Run(ctx context.Context, _ []string) error {
 		kraftcloud.WithToken(config.GetKraftCloudTokenAuthConfig(*auth)),
 	)
 
-	resp, err := client.Users().WithMetro(opts.metro).Quotas(ctx)
-	if err != nil {
-		return fmt.Errorf("could not get quotas: %w", err)
+	metros := []string{opts.metro}
+	if opts.AllMetros {
+		list, err := kraftcloud.NewMetrosClient().List(ctx, false)
+		if err != nil {
+			return fmt.Errorf("could not list metros: %w", err)
+		}
+
+		metros = make([]string, len(list))
+		for i, metro := range list {
+			metros[i] = metro.Code
+		}
 	}
 
-	imageResp, err := client.Images().WithMetro(opts.metro).Quotas(ctx)
-	if err != nil {
-		return fmt.Errorf("could not get image quotas: %w", err)
+	quotas := make([]utils.MetroQuotas, 0, len(metros))
+
+	for _, metro := range metros {
+		resp, err := client.Users().WithMetro(metro).Quotas(ctx)
+		if err != nil {
+			return fmt.Errorf("could not get quotas in %s: %w", metro, err)
+		}
+
+		imageResp, err := client.Images().WithMetro(metro).Quotas(ctx)
+		if err != nil {
+			return fmt.Errorf("could not get image quotas in %s: %w", metro, err)
+		}
+
+		quotas = append(quotas, utils.MetroQuotas{
+			Metro:  metro,
+			Resp:   *resp,
+			Images: imageResp,
+		})
 	}
 
 	if err = iostreams.G(ctx).StartPager(); err != nil {
 		log.G(ctx).Errorf("error starting pager: %v", err)
 	}
 
-	defer iostreams.G(ctx).StopPager()
+	// The pager is stopped before reporting any resources above the threshold.
+	err = utils.PrintQuotas(ctx, *auth, opts.Output, quotas...)
+	iostreams.G(ctx).StopPager()
+	if err != nil {
+		return err
+	}
+
+	if opts.Threshold == 0 {
+		return nil
+	}
+
+	exceeded := false
+
+	for _, quota := range quotas {
+		quotaUsages, err := usages(quota)
+		if err != nil {
+			return fmt.Errorf("could not determine usage in %s: %w", quota.Metro, err)
+		}
+
+		for _, usage := range quotaUsages {
+			if usage.hard <= 0 || usage.used*100 < usage.hard*int64(opts.Threshold) {
+				continue
+			}
+
+			exceeded = true
+			log.G(ctx).
+				WithField("metro", quota.Metro).
+				Errorf("%s usage at %d%% of limit (%d/%d)", usage.resource, usage.used*100/usage.hard, usage.used, usage.hard)
+		}
+	}
+
+	if exceeded {
+		return &cmdfactory.ExitCodeError{Code: 1}
+	}
+
+	return nil
+}
+
+// usage is the amount of a resource which is in use against its limit.
+type usage struct {
+	resource string
+	used     int64
+	hard     int64
+}
+
+// usages returns the usage of each resource with a limit in the quotas.
+func usages(quota utils.MetroQuotas) ([]usage, error) {
+	q, err := quota.Resp.FirstOrErr()
+	if err != nil {
+		return nil, err
+	}
+
+	// Both the active and the stopped instances count towards the limit of
+	// instances, as shown in the table.
+	ret := []usage{
+		{"active instance", int64(q.Used.LiveInstances), int64(q.Hard.Instances)},
+		{"instance", int64(q.Used.Instances), int64(q.Hard.Instances)},
+		{"vCPU", int64(q.Used.LiveVcpus), int64(q.Hard.LiveVcpus)},
+		{"memory", int64(q.Used.LiveMemoryMb), int64(q.Hard.LiveMemoryMb)},
+		{"exposed service", int64(q.Used.Services), int64(q.Hard.Services)},
+		{"service", int64(q.Used.ServiceGroups), int64(q.Hard.ServiceGroups)},
+	}
+
+	if q.Limits.MaxVolumeMb > 0 {
+		ret = append(ret,
+			usage{"volume", int64(q.Used.Volumes), int64(q.Hard.Volumes)},
+			usage{"volume space", int64(q.Used.TotalVolumeMb), int64(q.Hard.TotalVolumeMb)},
+		)
+	}
+
+	if quota.Images != nil {
+		ret = append(ret, usage{"image storage", int64(quota.Images.Used), int64(quota.Images.Hard)})
+	}
 
-	return utils.PrintQuotas(ctx, *auth, opts.Output, *resp, imageResp)
+	return ret, nil
 }
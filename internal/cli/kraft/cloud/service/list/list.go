@@ -20,7 +20,7 @@ import (
 )
 
 type ListOptions struct {
-	Output string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
+	Output string `long:"output" short:"o" usage:"Set output format. Options: table,wide,yaml,json,list,go-template=TEMPLATE" default:"table"`
 	Watch  bool   `long:"watch" short:"w" usage:"After listing watch for changes."`
 
 	metro string
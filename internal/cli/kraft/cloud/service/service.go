@@ -16,6 +16,7 @@ import (
 	"kraftkit.sh/internal/cli/kraft/cloud/service/get"
 	"kraftkit.sh/internal/cli/kraft/cloud/service/list"
 	"kraftkit.sh/internal/cli/kraft/cloud/service/remove"
+	"kraftkit.sh/internal/cli/kraft/cloud/service/split"
 
 	"kraftkit.sh/cmdfactory"
 )
@@ -45,6 +46,7 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(list.NewCmd())
 	cmd.AddCommand(get.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
+	cmd.AddCommand(split.NewCmd())
 
 	return cmd
 }
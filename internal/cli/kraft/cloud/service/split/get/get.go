@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package get
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	kraftcloud "sdk.kraft.cloud"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+	"kraftkit.sh/internal/tableprinter"
+)
+
+type GetOptions struct {
+	Auth   *config.AuthConfig    `noattribute:"true"`
+	Client kraftcloud.KraftCloud `noattribute:"true"`
+	Metro  string                `noattribute:"true"`
+	Output string                `long:"output" short:"o" usage:"Set output format. Options: table,wide,yaml,json,list" default:"table"`
+	Token  string                `noattribute:"true"`
+}
+
+// Get the traffic split of a KraftCloud service.
+func Get(ctx context.Context, opts *GetOptions, args ...string) error {
+	if opts == nil {
+		opts = &GetOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&GetOptions{}, cobra.Command{
+		Short:   "Show the traffic split of a service",
+		Use:     "get [FLAGS] UUID|NAME",
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"gt"},
+		Example: heredoc.Doc(`
+			# Show the share of traffic served by each image of a service
+			$ kraft cloud service split get my-service
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "kraftcloud-svc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *GetOptions) Pre(cmd *cobra.Command, _ []string) error {
+	err := utils.PopulateMetroToken(cmd, &opts.Metro, &opts.Token)
+	if err != nil {
+		return fmt.Errorf("could not populate metro and token: %w", err)
+	}
+
+	if !tableprinter.IsValidOutputFormat(opts.Output) {
+		return fmt.Errorf("invalid output format: %s", opts.Output)
+	}
+
+	return nil
+}
+
+func (opts *GetOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	if opts.Auth == nil {
+		opts.Auth, err = config.GetKraftCloudAuthConfig(ctx, opts.Token)
+		if err != nil {
+			return fmt.Errorf("could not retrieve credentials: %w", err)
+		}
+	}
+
+	if opts.Client == nil {
+		opts.Client = kraftcloud.NewClient(
+			kraftcloud.WithToken(config.GetKraftCloudTokenAuthConfig(*opts.Auth)),
+		)
+	}
+
+	split, err := utils.GetTrafficSplit(ctx, opts.Client, opts.Metro, args[0])
+	if err != nil {
+		return err
+	}
+
+	return utils.PrintTrafficSplit(ctx, opts.Output, split)
+}
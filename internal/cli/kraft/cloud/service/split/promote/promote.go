@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package promote
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	kraftcloud "sdk.kraft.cloud"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+)
+
+type PromoteOptions struct {
+	Auth      *config.AuthConfig    `noattribute:"true"`
+	Client    kraftcloud.KraftCloud `noattribute:"true"`
+	Instances int                   `long:"instances" short:"n" usage:"Number of instances of the promoted image (default: current number of instances)"`
+	Metro     string                `noattribute:"true"`
+	Token     string                `noattribute:"true"`
+}
+
+// Promote an image to serve all traffic of a KraftCloud service.
+func Promote(ctx context.Context, opts *PromoteOptions, args ...string) error {
+	if opts == nil {
+		opts = &PromoteOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&PromoteOptions{}, cobra.Command{
+		Short: "Send all traffic of a service to an image",
+		Use:   "promote [FLAGS] UUID|NAME IMAGE",
+		Args:  cobra.ExactArgs(2),
+		Long: heredoc.Doc(`
+			Send all traffic of a service to an image.
+
+			The instances of all other images in the service are replaced by
+			instances of the promoted image, which are created first such that the
+			service keeps serving traffic throughout.
+		`),
+		Example: heredoc.Doc(`
+			# Promote a canary image after it has been validated
+			$ kraft cloud service split promote my-service nginx:1.26
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "kraftcloud-svc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *PromoteOptions) Pre(cmd *cobra.Command, _ []string) error {
+	err := utils.PopulateMetroToken(cmd, &opts.Metro, &opts.Token)
+	if err != nil {
+		return fmt.Errorf("could not populate metro and token: %w", err)
+	}
+
+	if opts.Instances < 0 {
+		return fmt.Errorf("number of instances cannot be negative")
+	}
+
+	return nil
+}
+
+func (opts *PromoteOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	if opts.Auth == nil {
+		opts.Auth, err = config.GetKraftCloudAuthConfig(ctx, opts.Token)
+		if err != nil {
+			return fmt.Errorf("could not retrieve credentials: %w", err)
+		}
+	}
+
+	if opts.Client == nil {
+		opts.Client = kraftcloud.NewClient(
+			kraftcloud.WithToken(config.GetKraftCloudTokenAuthConfig(*opts.Auth)),
+		)
+	}
+
+	split, err := utils.GetTrafficSplit(ctx, opts.Client, opts.Metro, args[0])
+	if err != nil {
+		return err
+	}
+
+	return utils.ApplyTrafficSplit(ctx, opts.Client, opts.Metro, split, map[string]int{args[1]: 100}, opts.Instances)
+}
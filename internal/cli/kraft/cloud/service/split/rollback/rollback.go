@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package rollback
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	kraftcloud "sdk.kraft.cloud"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+)
+
+type RollbackOptions struct {
+	Auth   *config.AuthConfig    `noattribute:"true"`
+	Client kraftcloud.KraftCloud `noattribute:"true"`
+	Metro  string                `noattribute:"true"`
+	Token  string                `noattribute:"true"`
+}
+
+// Rollback removes an image from the traffic split of a KraftCloud service.
+func Rollback(ctx context.Context, opts *RollbackOptions, args ...string) error {
+	if opts == nil {
+		opts = &RollbackOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&RollbackOptions{}, cobra.Command{
+		Short: "Stop sending traffic of a service to an image",
+		Use:   "rollback [FLAGS] UUID|NAME IMAGE",
+		Args:  cobra.ExactArgs(2),
+		Long: heredoc.Doc(`
+			Stop sending traffic of a service to an image.
+
+			The instances of the image are replaced by instances of the remaining
+			images of the service, keeping their relative weights and the total
+			number of instances.
+		`),
+		Example: heredoc.Doc(`
+			# Roll back a canary image
+			$ kraft cloud service split rollback my-service nginx:1.26
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "kraftcloud-svc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *RollbackOptions) Pre(cmd *cobra.Command, _ []string) error {
+	err := utils.PopulateMetroToken(cmd, &opts.Metro, &opts.Token)
+	if err != nil {
+		return fmt.Errorf("could not populate metro and token: %w", err)
+	}
+
+	return nil
+}
+
+func (opts *RollbackOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	if opts.Auth == nil {
+		opts.Auth, err = config.GetKraftCloudAuthConfig(ctx, opts.Token)
+		if err != nil {
+			return fmt.Errorf("could not retrieve credentials: %w", err)
+		}
+	}
+
+	if opts.Client == nil {
+		opts.Client = kraftcloud.NewClient(
+			kraftcloud.WithToken(config.GetKraftCloudTokenAuthConfig(*opts.Auth)),
+		)
+	}
+
+	split, err := utils.GetTrafficSplit(ctx, opts.Client, opts.Metro, args[0])
+	if err != nil {
+		return err
+	}
+
+	rolledBack := split.Share(args[1])
+	if rolledBack == nil {
+		return fmt.Errorf("image %s is not serving traffic in service %s", args[1], args[0])
+	}
+
+	if len(split.Shares) == 1 {
+		return fmt.Errorf("cannot roll back %s: it is the only image serving traffic in service %s", args[1], args[0])
+	}
+
+	// The remaining images keep their relative weights, which are given by
+	// their number of instances.
+	weights := make(map[string]int, len(split.Shares))
+	for _, share := range split.Shares {
+		weights[share.Image] = len(share.Instances)
+	}
+	weights[rolledBack.Image] = 0
+
+	return utils.ApplyTrafficSplit(ctx, opts.Client, opts.Metro, split, weights, split.Total())
+}
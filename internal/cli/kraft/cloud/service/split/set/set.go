@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package set
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	kraftcloud "sdk.kraft.cloud"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+)
+
+type SetOptions struct {
+	Auth      *config.AuthConfig    `noattribute:"true"`
+	Client    kraftcloud.KraftCloud `noattribute:"true"`
+	Instances int                   `long:"instances" short:"n" usage:"Total number of instances to split the traffic across (default: current number of instances)"`
+	Metro     string                `noattribute:"true"`
+	Token     string                `noattribute:"true"`
+}
+
+// Set the traffic split of a KraftCloud service.
+func Set(ctx context.Context, opts *SetOptions, args ...string) error {
+	if opts == nil {
+		opts = &SetOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SetOptions{}, cobra.Command{
+		Short: "Set the traffic split of a service",
+		Use:   "set [FLAGS] UUID|NAME IMAGE=WEIGHT [IMAGE=WEIGHT [...]]",
+		Args:  cobra.MinimumNArgs(2),
+		Long: heredoc.Doc(`
+			Set the traffic split of a service.
+
+			Each weight is the percentage of traffic served by the image and the
+			weights must sum to 100.  Images of the service which are not listed no
+			longer receive traffic and their instances are removed.  New instances
+			are created with the resources of an existing instance of the service
+			before any surplus instances are removed.
+
+			Since traffic is balanced across instances, the split is approximated by
+			the number of instances of each image and every image with a non-zero
+			weight runs at least one instance.
+		`),
+		Example: heredoc.Doc(`
+			# Send 10% of the traffic to a canary image
+			$ kraft cloud service split set my-service nginx:1.25=90 nginx:1.26=10
+
+			# Split the traffic evenly across 4 instances
+			$ kraft cloud service split set --instances 4 my-service nginx:1.25=50 nginx:1.26=50
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "kraftcloud-svc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *SetOptions) Pre(cmd *cobra.Command, _ []string) error {
+	err := utils.PopulateMetroToken(cmd, &opts.Metro, &opts.Token)
+	if err != nil {
+		return fmt.Errorf("could not populate metro and token: %w", err)
+	}
+
+	if opts.Instances < 0 {
+		return fmt.Errorf("number of instances cannot be negative")
+	}
+
+	return nil
+}
+
+func (opts *SetOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	weights, err := utils.ParseTrafficWeights(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if opts.Auth == nil {
+		opts.Auth, err = config.GetKraftCloudAuthConfig(ctx, opts.Token)
+		if err != nil {
+			return fmt.Errorf("could not retrieve credentials: %w", err)
+		}
+	}
+
+	if opts.Client == nil {
+		opts.Client = kraftcloud.NewClient(
+			kraftcloud.WithToken(config.GetKraftCloudTokenAuthConfig(*opts.Auth)),
+		)
+	}
+
+	split, err := utils.GetTrafficSplit(ctx, opts.Client, opts.Metro, args[0])
+	if err != nil {
+		return err
+	}
+
+	return utils.ApplyTrafficSplit(ctx, opts.Client, opts.Metro, split, weights, opts.Instances)
+}
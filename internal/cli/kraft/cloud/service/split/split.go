@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package split
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/internal/cli/kraft/cloud/service/split/get"
+	"kraftkit.sh/internal/cli/kraft/cloud/service/split/promote"
+	"kraftkit.sh/internal/cli/kraft/cloud/service/split/rollback"
+	"kraftkit.sh/internal/cli/kraft/cloud/service/split/set"
+
+	"kraftkit.sh/cmdfactory"
+)
+
+type SplitOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SplitOptions{}, cobra.Command{
+		Short:   "Manage the traffic split between images of a service",
+		Use:     "split SUBCOMMAND",
+		Aliases: []string{"traffic"},
+		Long: heredoc.Doc(`
+			Manage the traffic split between images of a service.
+
+			The load balancer of a service distributes connections evenly across its
+			instances.  The traffic served by an image is therefore determined by the
+			share of instances in the service running that image, and a split is
+			applied by scaling the number of instances of each image accordingly.
+		`),
+		Example: heredoc.Doc(`
+			# Show the traffic split of a service
+			$ kraft cloud service split get my-service
+
+			# Send 10% of the traffic to a canary image
+			$ kraft cloud service split set my-service nginx:1.25=90 nginx:1.26=10
+
+			# Send all traffic to the canary image
+			$ kraft cloud service split promote my-service nginx:1.26
+
+			# Remove the canary image from the service
+			$ kraft cloud service split rollback my-service nginx:1.26
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "kraftcloud-svc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(get.NewCmd())
+	cmd.AddCommand(set.NewCmd())
+	cmd.AddCommand(promote.NewCmd())
+	cmd.AddCommand(rollback.NewCmd())
+
+	return cmd
+}
+
+func (opts *SplitOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
@@ -674,6 +674,44 @@ func PrintServices(ctx context.Context, format string, resp kcclient.ServiceResp
 	return table.Render(iostreams.G(ctx).Out)
 }
 
+// PrintTrafficSplit pretty-prints the traffic split of a service or returns
+// an error if unable to send to stdout via the provided context.
+func PrintTrafficSplit(ctx context.Context, format string, split *TrafficSplit) error {
+	cs := iostreams.G(ctx).ColorScheme()
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(format),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Header row
+	table.AddField("IMAGE", cs.Bold)
+	table.AddField("INSTANCES", cs.Bold)
+	table.AddField("WEIGHT", cs.Bold)
+	if format != "table" {
+		table.AddField("UUIDS", cs.Bold)
+	}
+	table.EndRow()
+
+	for _, share := range split.Shares {
+		table.AddField(share.Image, nil)
+		table.AddField(strconv.Itoa(len(share.Instances)), nil)
+		table.AddField(fmt.Sprintf("%d%%", share.Weight), nil)
+		if format != "table" {
+			uuids := make([]string, len(share.Instances))
+			for i, instance := range share.Instances {
+				uuids[i] = instance.UUID
+			}
+			table.AddField(strings.Join(uuids, ", "), nil)
+		}
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
+
 // An internal utility method for printing a bar based on the provided progress
 // and max values and the width of the bar.
 func printBar(cs *iostreams.ColorScheme, progress, max int) string {
@@ -696,17 +734,41 @@ func printBar(cs *iostreams.ColorScheme, progress, max int) string {
 	return ret.String()
 }
 
-// PrintQuotas pretty-prints the provided set of user quotas or returns
-// an error if unable to send to stdout via the provided context.
-func PrintQuotas(ctx context.Context, auth config.AuthConfig, format string, resp kcclient.ServiceResponse[kcusers.QuotasResponseItem], imageResp *kcimages.QuotasResponseItem) error {
+// MetroQuotas are the quotas of a user in a single metro.
+type MetroQuotas struct {
+	Metro  string
+	Resp   kcclient.ServiceResponse[kcusers.QuotasResponseItem]
+	Images *kcimages.QuotasResponseItem
+}
+
+// PrintQuotas pretty-prints the provided set of user quotas, one row per
+// metro, or returns an error if unable to send to stdout via the provided
+// context.
+func PrintQuotas(ctx context.Context, auth config.AuthConfig, format string, metros ...MetroQuotas) error {
 	if format == "raw" {
-		printRaw(ctx, resp)
+		resps := make([]kcclient.ServiceResponse[kcusers.QuotasResponseItem], len(metros))
+		for i, metro := range metros {
+			resps[i] = metro.Resp
+		}
+		printRaw(ctx, resps...)
 		return nil
 	}
 
-	quota, err := resp.FirstOrErr()
-	if err != nil {
-		return err
+	quotas := make([]*kcusers.QuotasResponseItem, len(metros))
+	for i, metro := range metros {
+		quota, err := metro.Resp.FirstOrErr()
+		if err != nil {
+			return err
+		}
+		quotas[i] = quota
+	}
+
+	// Optional columns are shown if they apply to any of the metros.
+	var showImages, showVolumes, showAutoscale bool
+	for i, quota := range quotas {
+		showImages = showImages || (metros[i].Images != nil && metros[i].Images.Hard > 0)
+		showVolumes = showVolumes || quota.Limits.MaxVolumeMb > 0
+		showAutoscale = showAutoscale || quota.Limits.MaxAutoscaleSize > 1
 	}
 
 	cs := iostreams.G(ctx).ColorScheme()
@@ -718,6 +780,8 @@ func PrintQuotas(ctx context.Context, auth config.AuthConfig, format string, res
 		return err
 	}
 
+	table.AddField("METRO", cs.Bold)
+
 	if format != "table" {
 		table.AddField("USER UUID", cs.Bold)
 	}
@@ -729,7 +793,7 @@ func PrintQuotas(ctx context.Context, auth config.AuthConfig, format string, res
 		table.AddField("", nil)
 	}
 
-	if imageResp != nil && imageResp.Hard > 0 {
+	if showImages {
 		table.AddField("IMAGE STORAGE", cs.Bold)
 
 		// Blank line on list view
@@ -765,7 +829,7 @@ func PrintQuotas(ctx context.Context, auth config.AuthConfig, format string, res
 	}
 
 	table.AddField("VOLUMES", cs.Bold)
-	if quota.Limits.MaxVolumeMb > 0 {
+	if showVolumes {
 		table.AddField("ACTIVE VOLUMES", cs.Bold)
 		table.AddField("USED VOLUME SPACE", cs.Bold)
 		table.AddField("VOLUME SIZE LIMITS", cs.Bold)
@@ -777,180 +841,199 @@ func PrintQuotas(ctx context.Context, auth config.AuthConfig, format string, res
 	}
 
 	table.AddField("AUTOSCALE", cs.Bold)
-	if quota.Limits.MaxAutoscaleSize > 1 {
+	if showAutoscale {
 		table.AddField("AUTOSCALE LIMIT", cs.Bold)
 	}
 	table.AddField("SCALE-TO-ZERO", cs.Bold)
 
 	table.EndRow()
 
-	if format != "table" {
-		// USER UUID
-		table.AddField(quota.UUID, nil)
-	}
-
-	// USER NAME
-	table.AddField(strings.TrimSuffix(strings.TrimPrefix(auth.User, "robot$"), ".users.kraftcloud"), nil)
+	for i, quota := range quotas {
+		imageResp := metros[i].Images
 
-	// Blank line on list view
-	if format == "list" {
-		table.AddField("", nil)
-	}
+		// METRO
+		table.AddField(metros[i].Metro, nil)
 
-	// IMAGE STORAGE
-	if imageResp != nil && imageResp.Hard > 0 {
-		var storedImages string
-		if format == "list" {
-			storedImages = printBar(cs, int(imageResp.Used), int(imageResp.Hard)) + " "
+		if format != "table" {
+			// USER UUID
+			table.AddField(quota.UUID, nil)
 		}
-		storedImages += fmt.Sprintf("%s/%s",
-			humanize.IBytes(uint64(imageResp.Used)*humanize.Byte),
-			humanize.IBytes(uint64(imageResp.Hard)*humanize.Byte),
-		)
-		table.AddField(storedImages, nil)
+
+		// USER NAME
+		table.AddField(strings.TrimSuffix(strings.TrimPrefix(auth.User, "robot$"), ".users.kraftcloud"), nil)
 
 		// Blank line on list view
 		if format == "list" {
 			table.AddField("", nil)
 		}
-	}
-
-	// ACTIVE INSTANCES
-	var activeInstances string
-	if format == "list" {
-		activeInstances = printBar(cs, quota.Used.LiveInstances, quota.Hard.Instances) + " "
-	}
-	activeInstances += fmt.Sprintf("%d/%d", quota.Used.LiveInstances, quota.Hard.Instances)
-	table.AddField(activeInstances, nil)
-
-	// TOTAL INSTANCES
-	var totalInstances string
-	if format == "list" {
-		totalInstances = printBar(cs, quota.Used.Instances, quota.Hard.Instances) + " "
-	}
-	totalInstances += fmt.Sprintf("%d/%d", quota.Used.Instances, quota.Hard.Instances)
-	table.AddField(totalInstances, nil)
-
-	// ACTIVE VCPUS
-	var activeVcpus string
-	if format == "list" {
-		activeVcpus = printBar(cs, quota.Used.LiveVcpus, quota.Hard.LiveVcpus) + " "
-	}
-	activeVcpus += fmt.Sprintf("%d/%d", quota.Used.LiveVcpus, quota.Hard.LiveVcpus)
-	table.AddField(activeVcpus, nil)
-
-	// VCPU LIMIT
-	table.AddField(fmt.Sprintf("%d-%d",
-		quota.Limits.MinVcpus,
-		quota.Limits.MaxVcpus,
-	), nil)
-
-	// Blank line on list view
-	if format == "list" {
-		table.AddField("", nil)
-	}
 
-	// ACTIVE USED MEMORY
-	var activeUsedMemory string
-	if format == "list" {
-		activeUsedMemory = printBar(cs, quota.Used.LiveMemoryMb, quota.Hard.LiveMemoryMb) + " "
-	}
-	activeUsedMemory += fmt.Sprintf("%s/%s",
-		humanize.IBytes(uint64(quota.Used.LiveMemoryMb)*humanize.MiByte),
-		humanize.IBytes(uint64(quota.Hard.LiveMemoryMb)*humanize.MiByte),
-	)
-	table.AddField(activeUsedMemory, nil)
-
-	// MEMORY SIZE LIMITS
-	table.AddField(
-		fmt.Sprintf("%s-%s",
-			humanize.IBytes(uint64(quota.Limits.MinMemoryMb)*humanize.MiByte),
-			humanize.IBytes(uint64(quota.Limits.MaxMemoryMb)*humanize.MiByte),
-		), nil,
-	)
+		// IMAGE STORAGE
+		if showImages {
+			var storedImages string
+			if imageResp != nil && imageResp.Hard > 0 {
+				if format == "list" {
+					storedImages = printBar(cs, int(imageResp.Used), int(imageResp.Hard)) + " "
+				}
+				storedImages += fmt.Sprintf("%s/%s",
+					humanize.IBytes(uint64(imageResp.Used)*humanize.Byte),
+					humanize.IBytes(uint64(imageResp.Hard)*humanize.Byte),
+				)
+			}
+			table.AddField(storedImages, nil)
 
-	// Blank line on list view
-	if format == "list" {
-		table.AddField("", nil)
-	}
+			// Blank line on list view
+			if format == "list" {
+				table.AddField("", nil)
+			}
+		}
 
-	// EXPOSED SERVICES
-	var exposedServices string
-	if format == "list" {
-		exposedServices = printBar(cs, quota.Used.Services, quota.Hard.Services) + " "
-	}
-	exposedServices += fmt.Sprintf("%d/%d", quota.Used.Services, quota.Hard.Services)
-	table.AddField(exposedServices, nil)
+		// ACTIVE INSTANCES
+		var activeInstances string
+		if format == "list" {
+			activeInstances = printBar(cs, quota.Used.LiveInstances, quota.Hard.Instances) + " "
+		}
+		activeInstances += fmt.Sprintf("%d/%d", quota.Used.LiveInstances, quota.Hard.Instances)
+		table.AddField(activeInstances, nil)
 
-	// SERVICES
-	var services string
-	if format == "list" {
-		services = printBar(cs, quota.Used.ServiceGroups, quota.Hard.ServiceGroups) + " "
-	}
-	services += fmt.Sprintf("%d/%d", quota.Used.ServiceGroups, quota.Hard.ServiceGroups)
-	table.AddField(services, nil)
+		// TOTAL INSTANCES
+		var totalInstances string
+		if format == "list" {
+			totalInstances = printBar(cs, quota.Used.Instances, quota.Hard.Instances) + " "
+		}
+		totalInstances += fmt.Sprintf("%d/%d", quota.Used.Instances, quota.Hard.Instances)
+		table.AddField(totalInstances, nil)
 
-	// Blank line on list view
-	if format == "list" {
-		table.AddField("", nil)
-	}
+		// ACTIVE VCPUS
+		var activeVcpus string
+		if format == "list" {
+			activeVcpus = printBar(cs, quota.Used.LiveVcpus, quota.Hard.LiveVcpus) + " "
+		}
+		activeVcpus += fmt.Sprintf("%d/%d", quota.Used.LiveVcpus, quota.Hard.LiveVcpus)
+		table.AddField(activeVcpus, nil)
 
-	// VOLUMES
-	if quota.Limits.MaxVolumeMb == 0 {
-		table.AddField("disabled", cs.Gray)
-	} else {
-		table.AddField("enabled", cs.Green)
+		// VCPU LIMIT
+		table.AddField(fmt.Sprintf("%d-%d",
+			quota.Limits.MinVcpus,
+			quota.Limits.MaxVcpus,
+		), nil)
 
-		// ACTIVE VOLUMES
-		var activeVolumes string
+		// Blank line on list view
 		if format == "list" {
-			activeVolumes = printBar(cs, quota.Used.Volumes, quota.Hard.Volumes) + " "
+			table.AddField("", nil)
 		}
-		activeVolumes += fmt.Sprintf("%d/%d", quota.Used.Volumes, quota.Hard.Volumes)
-		table.AddField(activeVolumes, nil)
 
-		// USED VOLUME SPACE
-		var usedVolumeSpace string
+		// ACTIVE USED MEMORY
+		var activeUsedMemory string
 		if format == "list" {
-			usedVolumeSpace = printBar(cs, quota.Used.TotalVolumeMb, quota.Hard.TotalVolumeMb) + " "
+			activeUsedMemory = printBar(cs, quota.Used.LiveMemoryMb, quota.Hard.LiveMemoryMb) + " "
 		}
-		usedVolumeSpace += fmt.Sprintf("%s/%s",
-			humanize.IBytes(uint64(quota.Used.TotalVolumeMb)*humanize.MiByte),
-			humanize.IBytes(uint64(quota.Hard.TotalVolumeMb)*humanize.MiByte),
+		activeUsedMemory += fmt.Sprintf("%s/%s",
+			humanize.IBytes(uint64(quota.Used.LiveMemoryMb)*humanize.MiByte),
+			humanize.IBytes(uint64(quota.Hard.LiveMemoryMb)*humanize.MiByte),
 		)
-		table.AddField(usedVolumeSpace, nil)
+		table.AddField(activeUsedMemory, nil)
 
-		// VOLUME SIZE LIMITS
+		// MEMORY SIZE LIMITS
 		table.AddField(
 			fmt.Sprintf("%s-%s",
-				humanize.IBytes(uint64(quota.Limits.MinVolumeMb)*humanize.MiByte),
-				humanize.IBytes(uint64(quota.Limits.MaxVolumeMb)*humanize.MiByte),
+				humanize.IBytes(uint64(quota.Limits.MinMemoryMb)*humanize.MiByte),
+				humanize.IBytes(uint64(quota.Limits.MaxMemoryMb)*humanize.MiByte),
 			), nil,
 		)
-	}
 
-	// Blank line on list view
-	if format == "list" {
-		table.AddField("", nil)
-	}
+		// Blank line on list view
+		if format == "list" {
+			table.AddField("", nil)
+		}
 
-	// AUTOSCALE
-	if quota.Limits.MaxAutoscaleSize == 1 {
-		table.AddField("disabled", cs.Gray)
-	} else {
-		table.AddField("enabled", cs.Green)
+		// EXPOSED SERVICES
+		var exposedServices string
+		if format == "list" {
+			exposedServices = printBar(cs, quota.Used.Services, quota.Hard.Services) + " "
+		}
+		exposedServices += fmt.Sprintf("%d/%d", quota.Used.Services, quota.Hard.Services)
+		table.AddField(exposedServices, nil)
 
-		// AUTOSCALE LIMIT
-		table.AddField(fmt.Sprintf("%d-%d",
-			quota.Limits.MinAutoscaleSize,
-			quota.Limits.MaxAutoscaleSize,
-		), nil)
-	}
+		// SERVICES
+		var services string
+		if format == "list" {
+			services = printBar(cs, quota.Used.ServiceGroups, quota.Hard.ServiceGroups) + " "
+		}
+		services += fmt.Sprintf("%d/%d", quota.Used.ServiceGroups, quota.Hard.ServiceGroups)
+		table.AddField(services, nil)
+
+		// Blank line on list view
+		if format == "list" {
+			table.AddField("", nil)
+		}
 
-	// SCALE-TO-ZERO
-	table.AddField("enabled", cs.Green)
+		// VOLUMES
+		if quota.Limits.MaxVolumeMb == 0 {
+			table.AddField("disabled", cs.Gray)
 
-	table.EndRow()
+			if showVolumes {
+				table.AddField("", nil)
+				table.AddField("", nil)
+				table.AddField("", nil)
+			}
+		} else {
+			table.AddField("enabled", cs.Green)
+
+			// ACTIVE VOLUMES
+			var activeVolumes string
+			if format == "list" {
+				activeVolumes = printBar(cs, quota.Used.Volumes, quota.Hard.Volumes) + " "
+			}
+			activeVolumes += fmt.Sprintf("%d/%d", quota.Used.Volumes, quota.Hard.Volumes)
+			table.AddField(activeVolumes, nil)
+
+			// USED VOLUME SPACE
+			var usedVolumeSpace string
+			if format == "list" {
+				usedVolumeSpace = printBar(cs, quota.Used.TotalVolumeMb, quota.Hard.TotalVolumeMb) + " "
+			}
+			usedVolumeSpace += fmt.Sprintf("%s/%s",
+				humanize.IBytes(uint64(quota.Used.TotalVolumeMb)*humanize.MiByte),
+				humanize.IBytes(uint64(quota.Hard.TotalVolumeMb)*humanize.MiByte),
+			)
+			table.AddField(usedVolumeSpace, nil)
+
+			// VOLUME SIZE LIMITS
+			table.AddField(
+				fmt.Sprintf("%s-%s",
+					humanize.IBytes(uint64(quota.Limits.MinVolumeMb)*humanize.MiByte),
+					humanize.IBytes(uint64(quota.Limits.MaxVolumeMb)*humanize.MiByte),
+				), nil,
+			)
+		}
+
+		// Blank line on list view
+		if format == "list" {
+			table.AddField("", nil)
+		}
+
+		// AUTOSCALE
+		if quota.Limits.MaxAutoscaleSize <= 1 {
+			table.AddField("disabled", cs.Gray)
+
+			if showAutoscale {
+				table.AddField("", nil)
+			}
+		} else {
+			table.AddField("enabled", cs.Green)
+
+			// AUTOSCALE LIMIT
+			table.AddField(fmt.Sprintf("%d-%d",
+				quota.Limits.MinAutoscaleSize,
+				quota.Limits.MaxAutoscaleSize,
+			), nil)
+		}
+
+		// SCALE-TO-ZERO
+		table.AddField("enabled", cs.Green)
+
+		table.EndRow()
+	}
 
 	return table.Render(iostreams.G(ctx).Out)
 }
@@ -992,6 +1075,8 @@ func PrintCertificates(ctx context.Context, format string, resp kcclient.Service
 	if format != "table" {
 		table.AddField("VALIDATION ATTEMPTS", cs.Bold)
 		table.AddField("NEXT ATTEMPT", cs.Bold)
+	} else {
+		table.AddField("VALIDATION", cs.Bold)
 	}
 	table.AddField("COMMON NAME", cs.Bold)
 	if format != "table" {
@@ -1042,6 +1127,17 @@ func PrintCertificates(ctx context.Context, format string, resp kcclient.Service
 			}
 			table.AddField(validationAttempt, nil)
 			table.AddField(validationNext, nil)
+		} else {
+			// Certificates issued for the domains of a service are pending until
+			// their DNS challenge succeeds, which is retried periodically.
+			var validation string
+			if cert.State == "pending" && cert.Validation != nil {
+				validation = fmt.Sprintf("attempt %d", cert.Validation.Attempt)
+				if next, err := time.Parse(time.RFC3339, cert.Validation.Next); err == nil {
+					validation += ", next " + humanize.Time(next)
+				}
+			}
+			table.AddField(validation, nil)
 		}
 
 		table.AddField(cert.CommonName, nil)
@@ -1206,11 +1302,10 @@ func printRaw[T kcclient.APIResponseDataEntry](ctx context.Context, resps ...kcc
 	}
 }
 
+// IsValidOutputFormat returns whether the provided format is either one of
+// those supported by the table printer, the raw API response or unset.
 func IsValidOutputFormat(format string) bool {
-	return format == "json" ||
-		format == "table" ||
-		format == "yaml" ||
-		format == "list" ||
-		format == "raw" ||
-		format == ""
+	return format == "raw" ||
+		format == "" ||
+		tableprinter.IsValidOutputFormat(format)
 }
@@ -0,0 +1,370 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	kraftcloud "sdk.kraft.cloud"
+	kcinstances "sdk.kraft.cloud/instances"
+	kcservices "sdk.kraft.cloud/services"
+
+	"kraftkit.sh/log"
+)
+
+// TrafficShare is the portion of a service's traffic which is served by the
+// instances of a single image.  The load balancer of a service distributes
+// connections evenly across its instances, so the weight of an image is the
+// percentage of instances in the service which run that image.
+type TrafficShare struct {
+	Image     string
+	Instances []kcinstances.GetResponseItem
+	Weight    int
+}
+
+// TrafficSplit is the set of traffic shares of a service, ordered by
+// descending weight.
+type TrafficSplit struct {
+	Service *kcservices.GetResponseItem
+	Shares  []TrafficShare
+}
+
+// Total returns the total number of instances in the split.
+func (split *TrafficSplit) Total() int {
+	total := 0
+	for _, share := range split.Shares {
+		total += len(share.Instances)
+	}
+
+	return total
+}
+
+// Weights returns the weight of each image in the split.
+func (split *TrafficSplit) Weights() map[string]int {
+	weights := make(map[string]int, len(split.Shares))
+	for _, share := range split.Shares {
+		weights[share.Image] = share.Weight
+	}
+
+	return weights
+}
+
+// Share returns the share of the split serving the provided image, if any.
+func (split *TrafficSplit) Share(image string) *TrafficShare {
+	for i, share := range split.Shares {
+		if sameImage(share.Image, image) {
+			return &split.Shares[i]
+		}
+	}
+
+	return nil
+}
+
+// ParseTrafficWeights parses IMAGE=WEIGHT pairs into a weight map.  The
+// weights are percentages and must sum to 100.
+func ParseTrafficWeights(args []string) (map[string]int, error) {
+	weights := make(map[string]int, len(args))
+	sum := 0
+
+	for _, arg := range args {
+		idx := strings.LastIndex(arg, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid traffic weight '%s': expected IMAGE=WEIGHT", arg)
+		}
+
+		image := arg[:idx]
+		weight, err := strconv.Atoi(arg[idx+1:])
+		if err != nil || weight < 0 || weight > 100 {
+			return nil, fmt.Errorf("invalid traffic weight '%s': weight must be a percentage between 0 and 100", arg)
+		}
+
+		if _, ok := weights[image]; ok {
+			return nil, fmt.Errorf("duplicate traffic weight for image '%s'", image)
+		}
+
+		weights[image] = weight
+		sum += weight
+	}
+
+	if sum != 100 {
+		return nil, fmt.Errorf("traffic weights must sum to 100, got %d", sum)
+	}
+
+	return weights, nil
+}
+
+// PlanTrafficSplit distributes total instances across the images in weights
+// proportionally to their weight using the largest remainder method.  Every
+// image with a positive weight receives at least one instance, which may
+// raise the total beyond the requested number.
+func PlanTrafficSplit(total int, weights map[string]int) map[string]int {
+	images := make([]string, 0, len(weights))
+	sum := 0
+	for image, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+
+		images = append(images, image)
+		sum += weight
+	}
+
+	plan := make(map[string]int, len(weights))
+	for image := range weights {
+		plan[image] = 0
+	}
+
+	if len(images) == 0 {
+		return plan
+	}
+
+	if total < len(images) {
+		total = len(images)
+	}
+
+	// Sort by weight and then by name, such that ties are broken
+	// deterministically.
+	sort.Slice(images, func(i, j int) bool {
+		if weights[images[i]] != weights[images[j]] {
+			return weights[images[i]] > weights[images[j]]
+		}
+		return images[i] < images[j]
+	})
+
+	remainders := make(map[string]int, len(images))
+	assigned := 0
+
+	for _, image := range images {
+		count := total * weights[image] / sum
+		if count == 0 {
+			count = 1
+		}
+
+		plan[image] = count
+		remainders[image] = total * weights[image] % sum
+		assigned += count
+	}
+
+	// Hand out the remaining instances to the images with the largest
+	// remainders.
+	byRemainder := append([]string{}, images...)
+	sort.SliceStable(byRemainder, func(i, j int) bool {
+		return remainders[byRemainder[i]] > remainders[byRemainder[j]]
+	})
+
+	for i := 0; assigned < total; i++ {
+		plan[byRemainder[i%len(byRemainder)]]++
+		assigned++
+	}
+
+	// Reclaim instances handed out to guarantee the minimum of one from the
+	// images with the most instances.
+	for i := 0; assigned > total; i++ {
+		image := images[i%len(images)]
+		if plan[image] > 1 {
+			plan[image]--
+			assigned--
+		}
+	}
+
+	return plan
+}
+
+// GetTrafficSplit retrieves the service and its instances and groups them by
+// image.
+func GetTrafficSplit(ctx context.Context, client kraftcloud.KraftCloud, metro, nameOrUUID string) (*TrafficSplit, error) {
+	serviceResp, err := client.Services().WithMetro(metro).Get(ctx, nameOrUUID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get service %s: %w", nameOrUUID, err)
+	}
+
+	service, err := serviceResp.FirstOrErr()
+	if err != nil {
+		return nil, fmt.Errorf("could not get service %s: %w", nameOrUUID, err)
+	}
+
+	split := &TrafficSplit{Service: service}
+
+	if len(service.Instances) == 0 {
+		return split, nil
+	}
+
+	uuids := make([]string, len(service.Instances))
+	for i, instance := range service.Instances {
+		uuids[i] = instance.UUID
+	}
+
+	instancesResp, err := client.Instances().WithMetro(metro).Get(ctx, uuids...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get instances of service %s: %w", nameOrUUID, err)
+	}
+
+	instances, err := instancesResp.AllOrErr()
+	if err != nil {
+		return nil, fmt.Errorf("could not get instances of service %s: %w", nameOrUUID, err)
+	}
+
+	for _, instance := range instances {
+		if share := split.Share(instance.Image); share != nil {
+			share.Instances = append(share.Instances, instance)
+			continue
+		}
+
+		split.Shares = append(split.Shares, TrafficShare{
+			Image:     instance.Image,
+			Instances: []kcinstances.GetResponseItem{instance},
+		})
+	}
+
+	for i := range split.Shares {
+		split.Shares[i].Weight = len(split.Shares[i].Instances) * 100 / len(instances)
+	}
+
+	sort.SliceStable(split.Shares, func(i, j int) bool {
+		if split.Shares[i].Weight != split.Shares[j].Weight {
+			return split.Shares[i].Weight > split.Shares[j].Weight
+		}
+		return split.Shares[i].Image < split.Shares[j].Image
+	})
+
+	return split, nil
+}
+
+// ApplyTrafficSplit scales the instances of each image in the service such
+// that the traffic is distributed according to weights.  New instances are
+// created before surplus instances are removed, such that the service keeps
+// serving traffic throughout.  If total is not positive, the current number
+// of instances in the service is kept.
+func ApplyTrafficSplit(ctx context.Context, client kraftcloud.KraftCloud, metro string, split *TrafficSplit, weights map[string]int, total int) error {
+	if total <= 0 {
+		total = split.Total()
+	}
+
+	// Resolve each image to the digest which instances of the service report,
+	// such that tags are matched against running instances.
+	resolved := make(map[string]int, len(weights))
+	for image, weight := range weights {
+		if share := split.Share(image); share != nil {
+			resolved[share.Image] += weight
+			continue
+		}
+
+		if weight == 0 {
+			continue
+		}
+
+		imageResp, err := client.Images().WithMetro(metro).Get(ctx, image)
+		if err != nil {
+			return fmt.Errorf("could not get image %s: %w", image, err)
+		}
+
+		img, err := imageResp.FirstOrErr()
+		if err != nil {
+			return fmt.Errorf("could not get image %s: %w", image, err)
+		}
+
+		ref := image
+		if img.Digest != "" {
+			ref = img.Digest
+		}
+
+		if share := split.Share(ref); share != nil {
+			resolved[share.Image] += weight
+		} else {
+			resolved[ref] += weight
+		}
+	}
+
+	// Images which are currently serving traffic but which were not mentioned
+	// no longer receive any.
+	for _, share := range split.Shares {
+		if _, ok := resolved[share.Image]; !ok {
+			resolved[share.Image] = 0
+		}
+	}
+
+	// New instances inherit their resources from an existing instance of the
+	// service, preferring the one with the most traffic.
+	var template *kcinstances.GetResponseItem
+	if len(split.Shares) > 0 {
+		template = &split.Shares[0].Instances[0]
+	}
+
+	plan := PlanTrafficSplit(total, resolved)
+
+	var surplus []string
+
+	for image, want := range plan {
+		var have []kcinstances.GetResponseItem
+		if share := split.Share(image); share != nil {
+			have = share.Instances
+		}
+
+		for i := len(have); i < want; i++ {
+			log.G(ctx).
+				WithField("image", image).
+				WithField("service", split.Service.Name).
+				Info("creating instance")
+
+			req := kcinstances.CreateRequest{
+				Autostart: ptr(true),
+				Image:     image,
+				ServiceGroup: &kcinstances.CreateRequestServiceGroup{
+					UUID: &split.Service.UUID,
+				},
+			}
+			if template != nil {
+				req.Env = template.Env
+				req.MemoryMB = ptr(template.MemoryMB)
+				req.Vcpus = ptr(template.Vcpus)
+			}
+
+			if _, err := client.Instances().WithMetro(metro).Create(ctx, req); err != nil {
+				return fmt.Errorf("could not create instance of %s: %w", image, err)
+			}
+		}
+
+		// Remove the most recently created instances first, such that the
+		// longest running ones remain.
+		for i := len(have) - 1; i >= want; i-- {
+			surplus = append(surplus, have[i].UUID)
+		}
+	}
+
+	if len(surplus) == 0 {
+		return nil
+	}
+
+	log.G(ctx).
+		WithField("service", split.Service.Name).
+		Infof("removing %d instance(s)", len(surplus))
+
+	if _, err := client.Instances().WithMetro(metro).Delete(ctx, surplus...); err != nil {
+		return fmt.Errorf("could not remove instances: %w", err)
+	}
+
+	return nil
+}
+
+// sameImage returns whether two image references refer to the same image.
+// References with digests are compared by digest alone, since instances
+// report their image by the name under which it was pushed.
+func sameImage(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	_, da, oka := strings.Cut(a, "@")
+	_, db, okb := strings.Cut(b, "@")
+
+	return oka && okb && da == db
+}
+
+func ptr[T any](v T) *T { return &v }
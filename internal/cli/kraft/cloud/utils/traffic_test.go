@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseTrafficWeights(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected map[string]int
+		err      bool
+	}{
+		{
+			name:     "split",
+			args:     []string{"nginx:1.25=90", "nginx:1.26=10"},
+			expected: map[string]int{"nginx:1.25": 90, "nginx:1.26": 10},
+		},
+		{
+			name:     "digest",
+			args:     []string{"nginx@sha256:8f1c=100"},
+			expected: map[string]int{"nginx@sha256:8f1c": 100},
+		},
+		{
+			name:     "zero weight",
+			args:     []string{"nginx:1.25=0", "nginx:1.26=100"},
+			expected: map[string]int{"nginx:1.25": 0, "nginx:1.26": 100},
+		},
+		{
+			name: "no weight",
+			args: []string{"nginx:1.25"},
+			err:  true,
+		},
+		{
+			name: "no image",
+			args: []string{"=100"},
+			err:  true,
+		},
+		{
+			name: "not a number",
+			args: []string{"nginx:1.25=all"},
+			err:  true,
+		},
+		{
+			name: "negative",
+			args: []string{"nginx:1.25=-10", "nginx:1.26=110"},
+			err:  true,
+		},
+		{
+			name: "above 100",
+			args: []string{"nginx:1.25=110"},
+			err:  true,
+		},
+		{
+			name: "duplicate",
+			args: []string{"nginx:1.25=50", "nginx:1.25=50"},
+			err:  true,
+		},
+		{
+			name: "sum below 100",
+			args: []string{"nginx:1.25=50", "nginx:1.26=40"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights, err := ParseTrafficWeights(tt.args)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error: %t, got: %v", tt.err, err)
+			}
+
+			if !maps.Equal(weights, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, weights)
+			}
+		})
+	}
+}
+
+func TestPlanTrafficSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    int
+		weights  map[string]int
+		expected map[string]int
+	}{
+		{
+			name:     "90/10",
+			total:    10,
+			weights:  map[string]int{"a": 90, "b": 10},
+			expected: map[string]int{"a": 9, "b": 1},
+		},
+		{
+			name:     "90/10 of three",
+			total:    3,
+			weights:  map[string]int{"a": 90, "b": 10},
+			expected: map[string]int{"a": 2, "b": 1},
+		},
+		{
+			name:     "90/10 of two",
+			total:    2,
+			weights:  map[string]int{"a": 90, "b": 10},
+			expected: map[string]int{"a": 1, "b": 1},
+		},
+		{
+			name:     "zero weight",
+			total:    3,
+			weights:  map[string]int{"a": 100, "b": 0},
+			expected: map[string]int{"a": 3, "b": 0},
+		},
+		{
+			name:     "fewer instances than images",
+			total:    1,
+			weights:  map[string]int{"a": 50, "b": 30, "c": 20},
+			expected: map[string]int{"a": 1, "b": 1, "c": 1},
+		},
+		{
+			name:     "largest remainder",
+			total:    6,
+			weights:  map[string]int{"a": 50, "b": 30, "c": 20},
+			expected: map[string]int{"a": 3, "b": 2, "c": 1},
+		},
+		{
+			name:     "minimum of one",
+			total:    4,
+			weights:  map[string]int{"a": 50, "b": 30, "c": 20},
+			expected: map[string]int{"a": 2, "b": 1, "c": 1},
+		},
+		{
+			name:     "reclaimed",
+			total:    5,
+			weights:  map[string]int{"a": 98, "b": 1, "c": 1},
+			expected: map[string]int{"a": 3, "b": 1, "c": 1},
+		},
+		{
+			name:     "no traffic",
+			total:    3,
+			weights:  map[string]int{"a": 0},
+			expected: map[string]int{"a": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if plan := PlanTrafficSplit(tt.total, tt.weights); !maps.Equal(plan, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, plan)
+			}
+		})
+	}
+}
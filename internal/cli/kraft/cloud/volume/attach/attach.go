@@ -41,11 +41,11 @@ func Attach(ctx context.Context, opts *AttachOptions, args ...string) (*kcvolume
 	}
 
 	if opts.At == "" {
-		return nil, fmt.Errorf("required to set the destination instance")
+		return nil, fmt.Errorf("required to set the destination path in the instance")
 	}
 
 	if opts.To == "" {
-		return nil, fmt.Errorf("required to set the destination path in the instance")
+		return nil, fmt.Errorf("required to set the destination instance")
 	}
 
 	if opts.Auth == nil {
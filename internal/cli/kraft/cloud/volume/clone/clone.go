@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package clone
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	kraftcloud "sdk.kraft.cloud"
+	kcvolumes "sdk.kraft.cloud/volumes"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+	"kraftkit.sh/internal/cli/kraft/cloud/volume/snapshot"
+	"kraftkit.sh/iostreams"
+)
+
+type CloneOptions struct {
+	Auth         *config.AuthConfig    `noattribute:"true"`
+	Client       kraftcloud.KraftCloud `noattribute:"true"`
+	Force        bool                  `local:"true" long:"force" short:"f" usage:"Clone the volume even if it is mounted read-write by a running instance"`
+	FromSnapshot string                `local:"true" long:"from-snapshot" short:"s" usage:"Snapshot to clone the volume from"`
+	Image        string                `local:"true" long:"image" usage:"Image of the instance which copies the contents of the volume"`
+	Metro        string                `noattribute:"true"`
+	Name         string                `local:"true" long:"name" short:"n" usage:"Name of the new volume"`
+	Size         string                `local:"true" long:"size" usage:"Size of the new volume (MiB increments or suffixes like Mi, Gi, etc.; default: size of the source)"`
+	Timeout      time.Duration         `local:"true" long:"timeout" short:"t" usage:"Timeout for cloning the volume (ms/s/m/h)" default:"10m"`
+	Token        string                `noattribute:"true"`
+}
+
+// Clone a KraftCloud persistent volume or snapshot into a new volume.
+func Clone(ctx context.Context, opts *CloneOptions, source string) (*kcvolumes.GetResponseItem, error) {
+	var err error
+
+	if opts == nil {
+		opts = &CloneOptions{}
+	}
+
+	if opts.Auth == nil {
+		opts.Auth, err = config.GetKraftCloudAuthConfig(ctx, opts.Token)
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve credentials: %w", err)
+		}
+	}
+
+	if opts.Client == nil {
+		opts.Client = kraftcloud.NewClient(
+			kraftcloud.WithToken(config.GetKraftCloudTokenAuthConfig(*opts.Auth)),
+		)
+	}
+
+	var sizeMB int
+	if opts.Size != "" {
+		if _, err := strconv.ParseUint(opts.Size, 10, 64); err == nil {
+			opts.Size = fmt.Sprintf("%sMi", opts.Size)
+		}
+
+		qty, err := resource.ParseQuantity(opts.Size)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse size quantity: %w", err)
+		}
+
+		sizeMB = int(qty.Value() / (1024 * 1024))
+	}
+
+	volResp, err := opts.Client.Volumes().WithMetro(opts.Metro).Get(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("could not get volume %s: %w", source, err)
+	}
+
+	src, err := volResp.FirstOrErr()
+	if err != nil {
+		return nil, fmt.Errorf("could not get volume %s: %w", source, err)
+	}
+
+	if sizeMB > 0 && sizeMB < src.SizeMB {
+		return nil, fmt.Errorf("size must be at least the size of %s (%d MiB)", src.Name, src.SizeMB)
+	}
+
+	return snapshot.CloneVolume(ctx, opts.Client, opts.Metro, src, opts.Name, sizeMB, snapshot.CopyOptions{
+		Force:   opts.Force,
+		Image:   opts.Image,
+		Timeout: opts.Timeout,
+	})
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CloneOptions{}, cobra.Command{
+		Short: "Clone a persistent volume",
+		Use:   "clone [FLAGS] [UUID|NAME]",
+		Args:  cobra.MaximumNArgs(1),
+		Long: heredoc.Doc(`
+			Clone a persistent volume or snapshot into a new volume.
+
+			The contents are copied by a temporary instance with both volumes
+			attached.  The new volume is at least as large as the source and can be
+			attached to instances like any other volume.
+
+			The copy instance runs the image provided with --image with the arguments
+			/src and /dst, at which the source volume is mounted read-only and the
+			destination volume read-write.  It must copy all data from /src to /dst
+			and exit with code 0, e.g. an image whose entrypoint is 'cp -aT'.  The
+			copy fails if the instance exits otherwise or is stopped by the platform.
+		`),
+		Example: heredoc.Doc(`
+			# Restore a snapshot into a new volume named data-restored
+			$ kraft cloud volume clone --image myorg/volcopy:latest --from-snapshot data-20240101120000 --name data-restored
+
+			# Clone the volume data into a larger volume
+			$ kraft cloud volume clone --image myorg/volcopy:latest data --name data-large --size 1Gi
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "kraftcloud-vol",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *CloneOptions) Pre(cmd *cobra.Command, args []string) error {
+	if (len(args) == 0) == (opts.FromSnapshot == "") {
+		return fmt.Errorf("specify either a volume or a snapshot with --from-snapshot")
+	}
+
+	if opts.Image == "" {
+		return snapshot.ErrNoCopyImage
+	}
+
+	err := utils.PopulateMetroToken(cmd, &opts.Metro, &opts.Token)
+	if err != nil {
+		return fmt.Errorf("could not populate metro and token: %w", err)
+	}
+
+	return nil
+}
+
+func (opts *CloneOptions) Run(ctx context.Context, args []string) error {
+	source := opts.FromSnapshot
+	if len(args) > 0 {
+		source = args[0]
+	}
+
+	volume, err := Clone(ctx, opts, source)
+	if err != nil {
+		return fmt.Errorf("could not clone volume: %w", err)
+	}
+
+	_, err = fmt.Fprintln(iostreams.G(ctx).Out, volume.Name)
+	return err
+}
@@ -27,7 +27,7 @@ type CreateOptions struct {
 	Auth   *config.AuthConfig       `noattribute:"true"`
 	Client kcvolumes.VolumesService `noattribute:"true"`
 	Metro  string                   `noattribute:"true"`
-	Name   string                   `local:"true" size:"name" short:"n" usage:"Name of the volume"`
+	Name   string                   `local:"true" long:"name" short:"n" usage:"Name of the volume"`
 	Size   string                   `local:"true" long:"size" short:"s" usage:"Size (MiB increments or suffixes like Mi, Gi, etc.)"`
 	Token  string                   `noattribute:"true"`
 }
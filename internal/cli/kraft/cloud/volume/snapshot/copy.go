@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	kraftcloud "sdk.kraft.cloud"
+	kcinstances "sdk.kraft.cloud/instances"
+	kcvolumes "sdk.kraft.cloud/volumes"
+
+	"kraftkit.sh/log"
+)
+
+// ErrNoCopyImage is returned when no image is provided for the instance which
+// copies the contents of a volume.
+var ErrNoCopyImage = errors.New("no volume copy image provided: specify one with --image")
+
+// copyPollInterval is the interval at which the state of the copy instance is
+// checked.
+const copyPollInterval = time.Second
+
+// CopyOptions are the options of copying the contents of a volume.
+type CopyOptions struct {
+	// Force copying a volume which is mounted read-write by a running
+	// instance, whose contents may change during the copy.
+	Force bool
+
+	// Image is the image of the copy instance.  It is run with the arguments
+	// /src and /dst, at which the source volume is mounted read-only and the
+	// destination volume read-write, and must exit with code 0 once all data
+	// has been copied, e.g. an image whose entrypoint is `cp -aT`.
+	Image string
+
+	// Timeout is the maximum duration of the copy.
+	Timeout time.Duration
+}
+
+// CopyVolume copies the contents of the volume src to the volume dst, which
+// must be at least as large, using a temporary instance with both volumes
+// attached.
+func CopyVolume(ctx context.Context, client kraftcloud.KraftCloud, metro string, src, dst *kcvolumes.GetResponseItem, opts CopyOptions) (retErr error) {
+	if dst.SizeMB < src.SizeMB {
+		return fmt.Errorf("volume %s is smaller than %s (%d/%d MiB)", dst.Name, src.Name, dst.SizeMB, src.SizeMB)
+	}
+
+	if !opts.Force {
+		for _, mnt := range src.MountedBy {
+			if !mnt.ReadOnly {
+				return fmt.Errorf("volume %s is mounted read-write by instance %s: stop the instance or use --force", src.Name, mnt.Name)
+			}
+		}
+	}
+
+	if opts.Image == "" {
+		return ErrNoCopyImage
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	icli := client.Instances().WithMetro(metro)
+
+	instResp, err := icli.Create(ctx, kcinstances.CreateRequest{
+		Image:    opts.Image,
+		MemoryMB: ptr(128),
+		Args:     []string{"/src", "/dst"},
+		Volumes: []kcinstances.CreateRequestVolume{
+			{UUID: &src.UUID, At: ptr("/src"), ReadOnly: ptr(true)},
+			{UUID: &dst.UUID, At: ptr("/dst"), ReadOnly: ptr(false)},
+		},
+		Autostart: ptr(true),
+	})
+	if err != nil {
+		return fmt.Errorf("creating volume copy instance: %w", err)
+	}
+
+	inst, err := instResp.FirstOrErr()
+	if inst != nil && inst.UUID != "" {
+		defer func() {
+			// Use a fresh context, such that the instance is also removed when
+			// the copy was interrupted.
+			if _, err := icli.Delete(context.WithoutCancel(ctx), inst.UUID); err != nil && retErr == nil {
+				retErr = fmt.Errorf("removing volume copy instance: %w", err)
+			}
+		}()
+	}
+	if err != nil {
+		return fmt.Errorf("creating volume copy instance: %w", err)
+	}
+
+	log.G(ctx).
+		WithField("instance", inst.Name).
+		WithField("from", src.Name).
+		WithField("to", dst.Name).
+		Debug("copying volume")
+
+	ticker := time.NewTicker(copyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("copying volume %s: %w", src.Name, ctx.Err())
+		case <-ticker.C:
+		}
+
+		getResp, err := icli.Get(ctx, inst.UUID)
+		if err != nil {
+			return fmt.Errorf("getting volume copy instance: %w", err)
+		}
+
+		state, err := getResp.FirstOrErr()
+		if err != nil {
+			return fmt.Errorf("getting volume copy instance: %w", err)
+		}
+
+		if state.State != kcinstances.InstanceStateStopped {
+			continue
+		}
+
+		// An instance which was stopped by the platform, e.g. as it ran out of
+		// memory, has no exit code and may not have copied all data.
+		if state.ExitCode == nil {
+			return fmt.Errorf("copying volume %s failed: %s", src.Name, state.DescribeStopReason())
+		} else if *state.ExitCode != 0 {
+			return fmt.Errorf("copying volume %s failed with exit code %d: %s", src.Name, *state.ExitCode, state.DescribeStopReason())
+		}
+
+		return nil
+	}
+}
+
+// CloneVolume creates a volume with the provided name and size holding a copy
+// of the contents of the volume src.  The new volume is at least as large as
+// src and is removed again if the copy fails.
+func CloneVolume(ctx context.Context, client kraftcloud.KraftCloud, metro string, src *kcvolumes.GetResponseItem, name string, sizeMB int, opts CopyOptions) (*kcvolumes.GetResponseItem, error) {
+	if opts.Image == "" {
+		return nil, ErrNoCopyImage
+	}
+
+	if sizeMB < src.SizeMB {
+		sizeMB = src.SizeMB
+	}
+
+	vcli := client.Volumes().WithMetro(metro)
+
+	createResp, err := vcli.Create(ctx, name, sizeMB)
+	if err != nil {
+		return nil, fmt.Errorf("creating volume: %w", err)
+	}
+
+	created, err := createResp.FirstOrErr()
+	if err != nil {
+		return nil, fmt.Errorf("creating volume: %w", err)
+	}
+
+	getResp, err := vcli.Get(ctx, created.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("getting volume %s: %w", created.UUID, err)
+	}
+
+	dst, err := getResp.FirstOrErr()
+	if err != nil {
+		return nil, fmt.Errorf("getting volume %s: %w", created.UUID, err)
+	}
+
+	if err := CopyVolume(ctx, client, metro, src, dst, opts); err != nil {
+		if _, derr := vcli.Delete(context.WithoutCancel(ctx), dst.UUID); derr != nil {
+			log.G(ctx).
+				WithField("volume", dst.Name).
+				Warnf("could not remove incomplete volume: %v", derr)
+		}
+
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+func ptr[T comparable](v T) *T { return &v }
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	kraftcloud "sdk.kraft.cloud"
+	kcvolumes "sdk.kraft.cloud/volumes"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+	"kraftkit.sh/iostreams"
+)
+
+type SnapshotOptions struct {
+	Auth    *config.AuthConfig    `noattribute:"true"`
+	Client  kraftcloud.KraftCloud `noattribute:"true"`
+	Force   bool                  `local:"true" long:"force" short:"f" usage:"Snapshot the volume even if it is mounted read-write by a running instance"`
+	Image   string                `local:"true" long:"image" usage:"Image of the instance which copies the contents of the volume"`
+	Metro   string                `noattribute:"true"`
+	Name    string                `local:"true" long:"name" short:"n" usage:"Name of the snapshot (default: volume name suffixed with the current time)"`
+	Timeout time.Duration         `local:"true" long:"timeout" short:"t" usage:"Timeout for taking the snapshot (ms/s/m/h)" default:"10m"`
+	Token   string                `noattribute:"true"`
+}
+
+// Snapshot a KraftCloud persistent volume.
+func Snapshot(ctx context.Context, opts *SnapshotOptions, volume string) (*kcvolumes.GetResponseItem, error) {
+	var err error
+
+	if opts == nil {
+		opts = &SnapshotOptions{}
+	}
+
+	if opts.Auth == nil {
+		opts.Auth, err = config.GetKraftCloudAuthConfig(ctx, opts.Token)
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve credentials: %w", err)
+		}
+	}
+
+	if opts.Client == nil {
+		opts.Client = kraftcloud.NewClient(
+			kraftcloud.WithToken(config.GetKraftCloudTokenAuthConfig(*opts.Auth)),
+		)
+	}
+
+	volResp, err := opts.Client.Volumes().WithMetro(opts.Metro).Get(ctx, volume)
+	if err != nil {
+		return nil, fmt.Errorf("could not get volume %s: %w", volume, err)
+	}
+
+	src, err := volResp.FirstOrErr()
+	if err != nil {
+		return nil, fmt.Errorf("could not get volume %s: %w", volume, err)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", src.Name, time.Now().UTC().Format("20060102150405"))
+	}
+
+	return CloneVolume(ctx, opts.Client, opts.Metro, src, name, src.SizeMB, CopyOptions{
+		Force:   opts.Force,
+		Image:   opts.Image,
+		Timeout: opts.Timeout,
+	})
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SnapshotOptions{}, cobra.Command{
+		Short:   "Take a snapshot of a persistent volume",
+		Use:     "snapshot [FLAGS] UUID|NAME",
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"snap"},
+		Long: heredoc.Doc(`
+			Take a snapshot of a persistent volume.
+
+			A snapshot is a new persistent volume of the same size holding a copy of
+			the contents of the volume, which is copied by a temporary instance with
+			both volumes attached.  To guarantee a consistent snapshot, the volume
+			must not be mounted read-write by a running instance.
+
+			Snapshots are regular volumes which can be cloned into new volumes with
+			'kraft cloud volume clone --from-snapshot', listed and removed.
+
+			The copy instance runs the image provided with --image with the arguments
+			/src and /dst, at which the source volume is mounted read-only and the
+			destination volume read-write.  It must copy all data from /src to /dst
+			and exit with code 0, e.g. an image whose entrypoint is 'cp -aT'.  The
+			copy fails if the instance exits otherwise or is stopped by the platform.
+		`),
+		Example: heredoc.Doc(`
+			# Take a snapshot of the volume data
+			$ kraft cloud volume snapshot --image myorg/volcopy:latest data
+
+			# Take a snapshot of the volume data with a given name
+			$ kraft cloud volume snapshot --image myorg/volcopy:latest data --name data-before-migration
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "kraftcloud-vol",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *SnapshotOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.Image == "" {
+		return ErrNoCopyImage
+	}
+
+	err := utils.PopulateMetroToken(cmd, &opts.Metro, &opts.Token)
+	if err != nil {
+		return fmt.Errorf("could not populate metro and token: %w", err)
+	}
+
+	return nil
+}
+
+func (opts *SnapshotOptions) Run(ctx context.Context, args []string) error {
+	snapshot, err := Snapshot(ctx, opts, args[0])
+	if err != nil {
+		return fmt.Errorf("could not snapshot volume: %w", err)
+	}
+
+	_, err = fmt.Fprintln(iostreams.G(ctx).Out, snapshot.Name)
+	return err
+}
@@ -13,12 +13,14 @@ import (
 	"github.com/spf13/pflag"
 
 	"kraftkit.sh/internal/cli/kraft/cloud/volume/attach"
+	"kraftkit.sh/internal/cli/kraft/cloud/volume/clone"
 	"kraftkit.sh/internal/cli/kraft/cloud/volume/create"
 	"kraftkit.sh/internal/cli/kraft/cloud/volume/detach"
 	"kraftkit.sh/internal/cli/kraft/cloud/volume/get"
 	vimport "kraftkit.sh/internal/cli/kraft/cloud/volume/import"
 	"kraftkit.sh/internal/cli/kraft/cloud/volume/list"
 	"kraftkit.sh/internal/cli/kraft/cloud/volume/remove"
+	"kraftkit.sh/internal/cli/kraft/cloud/volume/snapshot"
 
 	"kraftkit.sh/cmdfactory"
 )
@@ -51,6 +53,8 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(remove.NewCmd())
 	cmd.AddCommand(get.NewCmd())
 	cmd.AddCommand(vimport.NewCmd())
+	cmd.AddCommand(snapshot.NewCmd())
+	cmd.AddCommand(clone.NewCmd())
 
 	return cmd
 }
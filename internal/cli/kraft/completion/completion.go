@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package completion provides shell completion functions for the dynamic
+// resources which are managed by kraft, such as machines, networks, volumes,
+// packages and compose services.
+package completion
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/machine/network"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/packmanager"
+)
+
+// Func is the signature of a cobra dynamic argument completion function.
+type Func func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// Limit only completes the first n positional arguments with fn.
+func Limit(n int, fn Func) Func {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= n {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return fn(cmd, args, toComplete)
+	}
+}
+
+// Machines completes the names of the machines known to any platform.
+func Machines(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx := cmd.Context()
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var names []string
+	for _, machine := range machines.Items {
+		names = append(names, machine.Name)
+	}
+
+	return candidates(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Networks completes the names of the networks of the driver selected with
+// the command's --driver flag or of all drivers if it has no such flag.
+func Networks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx := cmd.Context()
+
+	strategies := network.Strategies()
+	if flag := cmd.Flag("driver"); flag != nil {
+		strategy, ok := strategies[flag.Value.String()]
+		if !ok {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		strategies = map[string]*network.Strategy{
+			flag.Value.String(): strategy,
+		}
+	}
+
+	var names []string
+	for _, strategy := range strategies {
+		controller, err := strategy.NewNetworkV1alpha1(ctx)
+		if err != nil {
+			continue
+		}
+
+		networks, err := controller.List(ctx, &networkapi.NetworkList{})
+		if err != nil {
+			continue
+		}
+
+		for _, network := range networks.Items {
+			names = append(names, network.Name)
+		}
+	}
+
+	return candidates(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Volumes completes the names of the volumes known to any volume driver.
+func Volumes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx := cmd.Context()
+
+	controller, err := volume.NewVolumeV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	volumes, err := controller.List(ctx, &volumeapi.VolumeList{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var names []string
+	for _, volume := range volumes.Items {
+		names = append(names, volume.Name)
+	}
+
+	return candidates(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Packages completes the references of the packages which are available
+// locally.  Files are completed as well since package arguments may also
+// refer to a project directory.
+func Packages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	packages, err := packmanager.G(ctx).Catalog(ctx,
+		packmanager.WithRemote(false),
+	)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	var refs []string
+	for _, pack := range packages {
+		if pack.Version() == "" {
+			refs = append(refs, pack.Name())
+		} else {
+			refs = append(refs, pack.Name()+":"+pack.Version())
+		}
+	}
+
+	return candidates(refs, args, toComplete), cobra.ShellCompDirectiveDefault
+}
+
+// ComposeServices completes the names of the services of the compose project
+// in the working directory or of the one selected with the --file flag.
+func ComposeServices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx := cmd.Context()
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var composefile string
+	if flag := cmd.Flag("file"); flag != nil {
+		composefile = flag.Value.String()
+	}
+
+	project, err := compose.NewProjectFromComposeFile(ctx, workdir, composefile)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return candidates(project.ServiceNames(), args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// candidates returns the sorted, unique names which start with the prefix
+// being completed and which have not already been provided as arguments.
+func candidates(names, args []string, toComplete string) []string {
+	seen := make(map[string]bool, len(args))
+	for _, arg := range args {
+		seen[arg] = true
+	}
+
+	var ret []string
+	for _, name := range names {
+		if name == "" || seen[name] || !strings.HasPrefix(name, toComplete) {
+			continue
+		}
+
+		seen[name] = true
+		ret = append(ret, name)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
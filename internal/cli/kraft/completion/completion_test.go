@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package completion
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCandidates(t *testing.T) {
+	tests := []struct {
+		name       string
+		names      []string
+		args       []string
+		toComplete string
+		expected   []string
+	}{
+		{
+			name:     "sorted",
+			names:    []string{"web", "db", "cache"},
+			expected: []string{"cache", "db", "web"},
+		},
+		{
+			name:       "prefix",
+			names:      []string{"web-1", "web-2", "db"},
+			toComplete: "web",
+			expected:   []string{"web-1", "web-2"},
+		},
+		{
+			name:     "already provided",
+			names:    []string{"web", "db", "cache"},
+			args:     []string{"db"},
+			expected: []string{"cache", "web"},
+		},
+		{
+			name:     "duplicates and empty names",
+			names:    []string{"web", "", "web", "db"},
+			expected: []string{"db", "web"},
+		},
+		{
+			name:       "no match",
+			names:      []string{"web", "db"},
+			toComplete: "x",
+			expected:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := candidates(tt.names, tt.args, tt.toComplete); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	fn := Limit(1, func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"web"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	if got, _ := fn(&cobra.Command{}, nil, ""); !slices.Equal(got, []string{"web"}) {
+		t.Errorf("expected first argument to be completed, got %v", got)
+	}
+
+	got, directive := fn(&cobra.Command{}, []string{"web"}, "")
+	if got != nil || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("expected no completion after the first argument, got %v (%d)", got, directive)
+	}
+}
+
+func TestComposeServices(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"docker-compose.yaml": "services:\n  web:\n    image: nginx\n  db:\n    image: postgres\n",
+		"other.yaml":          "services:\n  cache:\n    image: redis\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+
+	newCmd := func(file string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("file", file, "")
+		cmd.SetContext(context.Background())
+		return cmd
+	}
+
+	tests := []struct {
+		name       string
+		file       string
+		args       []string
+		toComplete string
+		expected   []string
+	}{
+		{
+			name:     "default compose file",
+			expected: []string{"db", "web"},
+		},
+		{
+			name:       "prefix",
+			toComplete: "w",
+			expected:   []string{"web"},
+		},
+		{
+			name:     "already provided",
+			args:     []string{"web"},
+			expected: []string{"db"},
+		},
+		{
+			name:     "compose file flag",
+			file:     "other.yaml",
+			expected: []string{"cache"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, directive := ComposeServices(newCmd(tt.file), tt.args, tt.toComplete)
+			if directive != cobra.ShellCompDirectiveNoFileComp {
+				t.Errorf("unexpected directive: %d", directive)
+			}
+
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if _, directive := ComposeServices(newCmd("missing.yaml"), nil, ""); directive != cobra.ShellCompDirectiveError {
+		t.Errorf("expected error for missing compose file, got %d", directive)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
 	"kraftkit.sh/internal/cli/kraft/build"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/internal/cli/kraft/compose/utils"
 	"kraftkit.sh/internal/cli/kraft/pkg"
 	"kraftkit.sh/log"
@@ -28,8 +29,9 @@ type BuildOptions struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&BuildOptions{}, cobra.Command{
-		Short: "Build or rebuild services",
-		Use:   "build",
+		Short:             "Build or rebuild services",
+		Use:               "build",
+		ValidArgsFunction: completion.ComposeServices,
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "compose",
 		},
@@ -26,6 +26,7 @@ import (
 	"kraftkit.sh/internal/cli/kraft/compose/stop"
 	"kraftkit.sh/internal/cli/kraft/compose/unpause"
 	"kraftkit.sh/internal/cli/kraft/compose/up"
+	"kraftkit.sh/log"
 )
 
 type ComposeOptions struct {
@@ -70,6 +71,13 @@ func NewCmd() *cobra.Command {
 	return cmd
 }
 
+// PersistentPre tags the logs of all compose subcommands with the compose
+// subsystem.
+func (opts *ComposeOptions) PersistentPre(cmd *cobra.Command, _ []string) error {
+	cmd.SetContext(log.WithSubsystem(cmd.Context(), "compose"))
+	return nil
+}
+
 func (opts *ComposeOptions) Pre(cmd *cobra.Command, _ []string) error {
 	return nil
 }
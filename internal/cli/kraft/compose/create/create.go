@@ -7,8 +7,10 @@ package create
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/MakeNowJust/heredoc"
@@ -17,8 +19,11 @@ import (
 
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
+	"kraftkit.sh/group"
 	"kraftkit.sh/internal/cli/kraft/build"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/internal/cli/kraft/compose/utils"
+	grouputils "kraftkit.sh/internal/cli/kraft/group/utils"
 	netcreate "kraftkit.sh/internal/cli/kraft/net/create"
 	"kraftkit.sh/internal/cli/kraft/pkg"
 	"kraftkit.sh/internal/cli/kraft/pkg/pull"
@@ -31,6 +36,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	composeapi "kraftkit.sh/api/compose/v1"
+	groupapi "kraftkit.sh/api/group/v1alpha1"
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
@@ -42,18 +48,23 @@ import (
 
 type CreateOptions struct {
 	Composefile   string `noattribute:"true"`
+	DryRun        bool   `long:"dry-run" usage:"Print the networks, volumes and machines which would be created without creating them"`
 	RemoveOrphans bool   `long:"remove-orphans" usage:"Remove machines for services not defined in the Compose file"`
 }
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&CreateOptions{}, cobra.Command{
-		Short:   "Create a compose project",
-		Use:     "create [FLAGS]",
-		Aliases: []string{},
-		Long:    "Create the services and networks for a project.",
+		Short:             "Create a compose project",
+		Use:               "create [FLAGS]",
+		ValidArgsFunction: completion.ComposeServices,
+		Aliases:           []string{},
+		Long:              "Create the services and networks for a project.",
 		Example: heredoc.Doc(`
 			# Create the networks and services without running them
 			$ kraft compose create 
+
+			# Print what would be created without creating anything
+			$ kraft compose create --dry-run
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "compose",
@@ -102,7 +113,7 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 	}
 
 	if opts.RemoveOrphans {
-		if err := utils.RemoveOrphans(ctx, project); err != nil {
+		if err := utils.RemoveOrphans(ctx, project, opts.DryRun); err != nil {
 			return err
 		}
 	}
@@ -130,6 +141,10 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 		projectVolumes = embeddedProject.Status.Volumes
 	}
 	defer func() {
+		if opts.DryRun {
+			return
+		}
+
 		if _, err := composeController.Update(ctx, &composeapi.Compose{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: project.Name,
@@ -199,6 +214,7 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 		}
 		createOptions := netcreate.CreateOptions{
 			Driver:  driver,
+			DryRun:  opts.DryRun,
 			Network: subnet,
 		}
 
@@ -207,6 +223,10 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 			return err
 		}
 
+		if opts.DryRun {
+			continue
+		}
+
 		if network, err := networkController.Get(ctx, &networkapi.Network{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: network.Name,
@@ -249,6 +269,7 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 
 		createOptions := volcreate.CreateOptions{
 			Driver: driver,
+			DryRun: opts.DryRun,
 		}
 
 		log.G(ctx).Infof("creating volume %s...", volume.Name)
@@ -256,6 +277,10 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 			return err
 		}
 
+		if opts.DryRun {
+			continue
+		}
+
 		volume, err := volumeController.Get(ctx, &volumeapi.Volume{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: volume.Name,
@@ -300,6 +325,7 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 				break
 			}
 			rmOpts := remove.RemoveOptions{
+				DryRun:   opts.DryRun,
 				Platform: machine.Spec.Platform,
 			}
 
@@ -319,17 +345,46 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 			continue
 		}
 		if service.Image == "" {
+			if opts.DryRun {
+				log.G(ctx).Infof("service %s would be built before its machine can be previewed", service.Name)
+				continue
+			}
+
 			if err := buildService(ctx, service); err != nil {
 				return err
 			}
-		} else if err := ensureServiceIsPackaged(ctx, service); err != nil {
+		} else if err := ensureServiceIsPackaged(ctx, service, opts.DryRun); errors.Is(err, errNotPackaged) {
+			log.G(ctx).Infof("service %s would be built and packaged before its machine can be previewed", service.Name)
+			continue
+		} else if err != nil {
 			return err
 		}
 
-		if err := createService(ctx, project, service); err != nil {
+		if compose.IsReplicated(service) {
+			groupMachines, err := createGroup(ctx, project, service, opts.DryRun)
+			if err != nil {
+				log.G(ctx).WithError(err).Errorf("failed to create service %s", service.Name)
+			}
+
+			for _, machine := range groupMachines {
+				if !slices.ContainsFunc(projectMachines, func(m metav1.ObjectMeta) bool {
+					return m.Name == machine.Name
+				}) {
+					projectMachines = append(projectMachines, machine)
+				}
+			}
+
+			continue
+		}
+
+		if err := createService(ctx, project, service, opts.DryRun); err != nil {
 			log.G(ctx).WithError(err).Errorf("failed to create service %s", service.Name)
 		}
 
+		if opts.DryRun {
+			continue
+		}
+
 		if machine, err := machineController.Get(ctx, &machineapi.Machine{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: service.ContainerName,
@@ -341,6 +396,10 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 		}
 	}
 
+	if opts.DryRun {
+		return nil
+	}
+
 	if _, err := composeController.Update(ctx, &composeapi.Compose{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: project.Name,
@@ -361,7 +420,11 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 	return nil
 }
 
-func ensureServiceIsPackaged(ctx context.Context, service types.ServiceConfig) error {
+// errNotPackaged is returned during a dry run for a service whose image would
+// have to be built and packaged first.
+var errNotPackaged = errors.New("service is not packaged")
+
+func ensureServiceIsPackaged(ctx context.Context, service types.ServiceConfig, dryRun bool) error {
 	plat, arch, err := utils.PlatArchFromService(service)
 	if err != nil {
 		return err
@@ -416,6 +479,10 @@ func ensureServiceIsPackaged(ctx context.Context, service types.ServiceConfig) e
 	}
 
 	// Otherwise, we need to build and package it
+	if dryRun {
+		return errNotPackaged
+	}
+
 	if err := buildService(ctx, service); err != nil {
 		return err
 	}
@@ -459,15 +526,101 @@ func pkgService(ctx context.Context, service types.ServiceConfig) error {
 	return pkgOptions.Run(ctx, []string{service.Build.Context})
 }
 
-func createService(ctx context.Context, project *compose.Project, service types.ServiceConfig) error {
-	// The service should be packaged at this point
-	plat, arch, err := utils.PlatArchFromService(service)
+func createService(ctx context.Context, project *compose.Project, service types.ServiceConfig, dryRun bool) error {
+	runOptions, args, err := serviceRunOptions(ctx, project, service, dryRun)
 	if err != nil {
 		return err
 	}
 
 	log.G(ctx).Infof("creating service %s...", service.Name)
 
+	return runOptions.Run(ctx, args)
+}
+
+// createGroup creates the group of a replicated service, or scales it to the
+// number of replicas of the service if it already exists, and returns the
+// machines of the group.
+func createGroup(ctx context.Context, project *compose.Project, service types.ServiceConfig, dryRun bool) ([]metav1.ObjectMeta, error) {
+	runOptions, args, err := serviceRunOptions(ctx, project, service, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if runOptions.IP != "" || runOptions.MacAddress != "" {
+		log.G(ctx).Warnf("service %s is replicated, ignoring its static addresses", service.Name)
+	}
+	if len(runOptions.Ports) > 0 {
+		log.G(ctx).Warnf("service %s is replicated, ignoring its published ports", service.Name)
+	}
+
+	groupController, err := group.NewGroupV1alpha1(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := groupapi.GroupSpec{
+		Architecture: runOptions.Architecture,
+		Env:          runOptions.Env,
+		Memory:       runOptions.Memory,
+		Networks:     runOptions.Networks,
+		Platform:     runOptions.Platform,
+		Source:       args[0],
+		Volumes:      runOptions.Volumes,
+	}
+
+	serviceGroup, err := groupController.Get(ctx, &groupapi.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: service.ContainerName,
+		},
+	})
+	if err != nil {
+		serviceGroup = &groupapi.Group{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: service.ContainerName,
+			},
+			Spec: spec,
+		}
+
+		if !dryRun {
+			serviceGroup, err = groupController.Create(ctx, serviceGroup)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		spec.Replicas = serviceGroup.Spec.Replicas
+		serviceGroup.Spec = spec
+	}
+
+	replicas, _ := compose.Replicas(service)
+
+	log.G(ctx).Infof("creating service %s with %d replicas...", service.Name, replicas)
+
+	serviceGroup, err = grouputils.Scale(ctx, groupController, serviceGroup, replicas, grouputils.ScaleOptions{
+		DryRun:  dryRun,
+		NoStart: true,
+	})
+	if err != nil || dryRun {
+		return nil, err
+	}
+
+	serviceGroup, err = groupController.Get(ctx, serviceGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	return serviceGroup.Status.Machines, nil
+}
+
+// serviceRunOptions returns the options and the arguments with which the
+// machines of the service are run.
+func serviceRunOptions(ctx context.Context, project *compose.Project, service types.ServiceConfig, dryRun bool) (run.RunOptions, []string, error) {
+	// The service should be packaged at this point
+	plat, arch, err := utils.PlatArchFromService(service)
+	if err != nil {
+		return run.RunOptions{}, nil, err
+	}
+
 	networks := []string{}
 	if len(service.DNS) > 2 {
 		log.G(ctx).Warnf("service %s has more than 2 DNS servers, only the first 2 will be used", service.Name)
@@ -481,6 +634,17 @@ func createService(ctx context.Context, project *compose.Project, service types.
 	if len(service.DNS) > 1 {
 		dns1 = service.DNS[1]
 	}
+	// The static addresses of a service which is attached to a single network
+	// are provided via the --ip and --mac flags, such that they are validated
+	// against the network.
+	ip := ""
+	mac := ""
+	if len(service.Networks) == 1 {
+		mac = service.MacAddress
+	} else if service.MacAddress != "" {
+		log.G(ctx).Warnf("service %s is attached to more than one network, ignoring its MAC address", service.Name)
+	}
+
 	for name, network := range service.Networks {
 		arg := uknetdev.NetdevIp{
 			CIDR:     network.Ipv4Address,
@@ -489,6 +653,18 @@ func createService(ctx context.Context, project *compose.Project, service types.
 			Hostname: service.Hostname,
 			Domain:   service.DomainName,
 		}
+
+		if len(service.Networks) == 1 {
+			ip = arg.CIDR
+			arg.CIDR = ""
+
+			if network.MacAddress != "" {
+				mac = network.MacAddress
+			}
+		} else if network.MacAddress != "" {
+			log.G(ctx).Warnf("service %s is attached to more than one network, ignoring the MAC address on %s", service.Name, name)
+		}
+
 		networks = append(networks, fmt.Sprintf("%s:%s", project.Networks[name].Name, arg.String()))
 	}
 
@@ -526,7 +702,10 @@ func createService(ctx context.Context, project *compose.Project, service types.
 	runOptions := run.RunOptions{
 		Architecture: arch,
 		Detach:       true,
+		DryRun:       dryRun,
 		Env:          environ,
+		IP:           ip,
+		MacAddress:   mac,
 		Memory:       memory,
 		Name:         service.ContainerName,
 		Networks:     networks,
@@ -537,8 +716,8 @@ func createService(ctx context.Context, project *compose.Project, service types.
 	}
 
 	if service.Image != "" {
-		return runOptions.Run(ctx, []string{service.Image})
+		return runOptions, []string{service.Image}, nil
 	}
 
-	return runOptions.Run(ctx, []string{service.Build.Context})
+	return runOptions, []string{service.Build.Context}, nil
 }
@@ -15,12 +15,16 @@ import (
 	"github.com/spf13/cobra"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
+	"kraftkit.sh/group"
 	"kraftkit.sh/internal/cli/kraft/compose/utils"
+	grouputils "kraftkit.sh/internal/cli/kraft/group/utils"
 	networkremove "kraftkit.sh/internal/cli/kraft/net/remove"
 	machineremove "kraftkit.sh/internal/cli/kraft/remove"
 	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	groupapi "kraftkit.sh/api/group/v1alpha1"
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	mnetwork "kraftkit.sh/machine/network"
@@ -84,7 +88,7 @@ func (opts *DownOptions) Run(ctx context.Context, args []string) error {
 	}
 
 	if opts.RemoveOrphans {
-		if err := utils.RemoveOrphans(ctx, project); err != nil {
+		if err := utils.RemoveOrphans(ctx, project, false); err != nil {
 			return err
 		}
 	}
@@ -101,6 +105,13 @@ func (opts *DownOptions) Run(ctx context.Context, args []string) error {
 
 	orderedServices := project.ServicesReversedByDependencies(ctx, project.Services, false)
 	for _, service := range orderedServices {
+		if compose.IsReplicated(service) {
+			if err := removeGroup(ctx, service); err != nil {
+				return err
+			}
+			continue
+		}
+
 		for _, machine := range machines.Items {
 			if service.ContainerName == machine.Name {
 				if err := removeService(ctx, service); err != nil {
@@ -143,6 +154,29 @@ func removeService(ctx context.Context, service types.ServiceConfig) error {
 	return removeOptions.Run(ctx, []string{service.ContainerName})
 }
 
+// removeGroup removes the group of a replicated service together with its
+// machines.
+func removeGroup(ctx context.Context, service types.ServiceConfig) error {
+	groupController, err := group.NewGroupV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing, err := groupController.Get(ctx, &groupapi.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: service.ContainerName,
+		},
+	})
+	if err != nil {
+		log.G(ctx).Debugf("service %s has no group: %v", service.Name, err)
+		return nil
+	}
+
+	log.G(ctx).Infof("removing service %s...", service.Name)
+
+	return grouputils.Remove(ctx, groupController, existing, false)
+}
+
 func removeNetwork(ctx context.Context, network types.NetworkConfig) error {
 	log.G(ctx).Infof("removing network %s...", network.Name)
 	driver := "bridge"
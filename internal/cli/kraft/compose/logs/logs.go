@@ -17,6 +17,7 @@ import (
 
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	kernellogs "kraftkit.sh/internal/cli/kraft/logs"
 	"kraftkit.sh/log"
 
@@ -33,8 +34,9 @@ type LogsOptions struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&LogsOptions{}, cobra.Command{
-		Short: "Print the logs of services in a project",
-		Use:   "logs",
+		Short:             "Print the logs of services in a project",
+		Use:               "logs",
+		ValidArgsFunction: completion.ComposeServices,
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "compose",
 		},
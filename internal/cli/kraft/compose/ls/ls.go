@@ -26,8 +26,9 @@ import (
 )
 
 type LsOptions struct {
-	ShowAll bool   `long:"all" short:"a" usage:"Show all projects (default shows just running)"`
-	Output  string `long:"output" short:"o" usage:"Set output format" default:"table"`
+	tableprinter.OutputOptions
+
+	ShowAll bool `long:"all" short:"a" usage:"Show all projects (default shows just running)"`
 }
 
 func NewCmd() *cobra.Command {
@@ -82,10 +83,7 @@ func (opts *LsOptions) Run(ctx context.Context, args []string) error {
 
 	cs := iostreams.G(ctx).ColorScheme()
 
-	table, err := tableprinter.NewTablePrinter(ctx,
-		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
-		tableprinter.WithOutputFormatFromString(opts.Output),
-	)
+	table, err := opts.NewTablePrinter(ctx)
 	if err != nil {
 		return err
 	}
@@ -13,6 +13,7 @@ import (
 
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
 
@@ -27,9 +28,10 @@ type PauseOptions struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&PauseOptions{}, cobra.Command{
-		Short:   "Pause a compose project",
-		Use:     "pause [FLAGS]",
-		Aliases: []string{},
+		Short:             "Pause a compose project",
+		Use:               "pause [FLAGS]",
+		ValidArgsFunction: completion.ComposeServices,
+		Aliases:           []string{},
 		Example: heredoc.Doc(`
 			# Pause a compose project
 			$ kraft compose pause 
@@ -95,7 +97,7 @@ func (opts *PauseOptions) Run(ctx context.Context, args []string) error {
 	machinesToPause := []string{}
 	for _, service := range orderedServices {
 		for _, machine := range machines.Items {
-			if service.ContainerName == machine.Name && machine.Status.State == machineapi.MachineStateRunning {
+			if compose.IsServiceMachine(service, machine.ObjectMeta) && machine.Status.State == machineapi.MachineStateRunning {
 				machinesToPause = append(machinesToPause, machine.Name)
 			}
 		}
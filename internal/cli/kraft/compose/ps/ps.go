@@ -17,16 +17,18 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	composeapi "kraftkit.sh/api/compose/v1"
 	pslist "kraftkit.sh/internal/cli/kraft/ps"
+	"kraftkit.sh/internal/tableprinter"
 	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
 )
 
 type PsOptions struct {
-	Long    bool   `long:"long" short:"l" usage:"Show more information"`
-	Orphans bool   `long:"orphans" usage:"Include orphaned services (default: true)" default:"true"`
-	Output  string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
-	Quiet   bool   `long:"quiet" short:"q" usage:"Only display machine IDs"`
-	ShowAll bool   `long:"all" short:"a" usage:"Show all machines (default shows just running)"`
+	tableprinter.OutputOptions
+
+	Long    bool `long:"long" short:"l" usage:"Show more information"`
+	Orphans bool `long:"orphans" usage:"Include orphaned services (default: true)" default:"true"`
+	Quiet   bool `long:"quiet" short:"q" usage:"Only display machine IDs"`
+	ShowAll bool `long:"all" short:"a" usage:"Show all machines (default shows just running)"`
 
 	composefile string
 }
@@ -84,10 +86,10 @@ func (opts *PsOptions) Run(ctx context.Context, args []string) error {
 	}
 
 	pslistOptions := pslist.PsOptions{
-		Long:    opts.Long,
-		Output:  opts.Output,
-		Quiet:   opts.Quiet,
-		ShowAll: opts.ShowAll,
+		OutputOptions: opts.OutputOptions,
+		Long:          opts.Long,
+		Quiet:         opts.Quiet,
+		ShowAll:       opts.ShowAll,
 	}
 
 	psTable, err := pslistOptions.PsTable(ctx)
@@ -114,7 +116,7 @@ func (opts *PsOptions) Run(ctx context.Context, args []string) error {
 		for _, machine := range embeddedProject.Status.Machines {
 			orphaned := true
 			for _, service := range project.Services {
-				if service.ContainerName == machine.Name {
+				if compose.IsServiceMachine(service, machine) {
 					orphaned = false
 					break
 				}
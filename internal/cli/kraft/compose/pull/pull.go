@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/internal/cli/kraft/compose/utils"
 
 	pkgpull "kraftkit.sh/internal/cli/kraft/pkg/pull"
@@ -26,10 +27,11 @@ type PullOptions struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&PullOptions{}, cobra.Command{
-		Short:   "Pull images of services of current project",
-		Use:     "pull [FLAGS]",
-		Args:    cobra.ArbitraryArgs,
-		Aliases: []string{},
+		Short:             "Pull images of services of current project",
+		Use:               "pull [FLAGS]",
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completion.ComposeServices,
+		Aliases:           []string{},
 		Example: heredoc.Doc(`
 			# Pull images for current project
 			$ kraft compose pull
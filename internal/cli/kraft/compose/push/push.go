@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/completion"
 
 	pkgpush "kraftkit.sh/internal/cli/kraft/pkg/push"
 	"kraftkit.sh/log"
@@ -25,10 +26,11 @@ type PushOptions struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&PushOptions{}, cobra.Command{
-		Short:   "Push images of services of current project",
-		Use:     "push [FLAGS]",
-		Args:    cobra.ArbitraryArgs,
-		Aliases: []string{},
+		Short:             "Push images of services of current project",
+		Use:               "push [FLAGS]",
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completion.ComposeServices,
+		Aliases:           []string{},
 		Example: heredoc.Doc(`
 			# Push images for current project
 			$ kraft compose push
@@ -13,6 +13,7 @@ import (
 
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
 
@@ -27,9 +28,10 @@ type StartOptions struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&StartOptions{}, cobra.Command{
-		Short:   "Start a compose project",
-		Use:     "start [FLAGS]",
-		Aliases: []string{},
+		Short:             "Start a compose project",
+		Use:               "start [FLAGS]",
+		ValidArgsFunction: completion.ComposeServices,
+		Aliases:           []string{},
 		Example: heredoc.Doc(`
 			# Start a compose project
 			$ kraft compose start 
@@ -95,7 +97,7 @@ func (opts *StartOptions) Run(ctx context.Context, args []string) error {
 	machinesToStart := []string{}
 	for _, service := range orderedServices {
 		for _, machine := range machines.Items {
-			if service.ContainerName == machine.Name {
+			if compose.IsServiceMachine(service, machine.ObjectMeta) {
 				if machine.Status.State == machineapi.MachineStateCreated || machine.Status.State == machineapi.MachineStateExited {
 					machinesToStart = append(machinesToStart, machine.Name)
 				}
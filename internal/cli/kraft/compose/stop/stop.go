@@ -13,6 +13,7 @@ import (
 
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
 
@@ -27,9 +28,10 @@ type StopOptions struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&StopOptions{}, cobra.Command{
-		Short:   "Stop a compose project",
-		Use:     "stop [FLAGS]",
-		Aliases: []string{},
+		Short:             "Stop a compose project",
+		Use:               "stop [FLAGS]",
+		ValidArgsFunction: completion.ComposeServices,
+		Aliases:           []string{},
 		Example: heredoc.Doc(`
 			# Stop a compose project
 			$ kraft compose stop 
@@ -95,7 +97,7 @@ func (opts *StopOptions) Run(ctx context.Context, args []string) error {
 	machinesToStop := []string{}
 	for _, service := range orderedServices {
 		for _, machine := range machines.Items {
-			if service.ContainerName == machine.Name &&
+			if compose.IsServiceMachine(service, machine.ObjectMeta) &&
 				(machine.Status.State == machineapi.MachineStateRunning ||
 					machine.Status.State == machineapi.MachineStatePaused) {
 				machinesToStop = append(machinesToStop, machine.Name)
@@ -13,6 +13,7 @@ import (
 
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
 
@@ -27,9 +28,10 @@ type UnpauseOptions struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&UnpauseOptions{}, cobra.Command{
-		Short:   "Unpause a compose project",
-		Use:     "unpause [FLAGS]",
-		Aliases: []string{},
+		Short:             "Unpause a compose project",
+		Use:               "unpause [FLAGS]",
+		ValidArgsFunction: completion.ComposeServices,
+		Aliases:           []string{},
 		Example: heredoc.Doc(`
 			# Unpause a compose project
 			$ kraft compose unpause 
@@ -95,7 +97,7 @@ func (opts *UnpauseOptions) Run(ctx context.Context, args []string) error {
 	machinesToUnpause := []string{}
 	for _, service := range orderedServices {
 		for _, machine := range machines.Items {
-			if service.ContainerName == machine.Name {
+			if compose.IsServiceMachine(service, machine.ObjectMeta) {
 				if machine.Status.State == machineapi.MachineStatePaused {
 					machinesToUnpause = append(machinesToUnpause, machine.Name)
 				}
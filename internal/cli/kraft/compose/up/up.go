@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
 	"kraftkit.sh/internal/cli/kraft/compose/create"
 	"kraftkit.sh/internal/cli/kraft/compose/logs"
 	"kraftkit.sh/internal/cli/kraft/compose/start"
@@ -22,6 +23,8 @@ import (
 
 type UpOptions struct {
 	Detach        bool `long:"detach" short:"d" usage:"Run in background"`
+	DryRun        bool `long:"dry-run" usage:"Print the networks, volumes and machines which would be created without creating or running them"`
+	NoCache       bool `long:"no-cache" usage:"Do not use cached metadata of remote catalogs"`
 	RemoveOrphans bool `long:"remove-orphans" usage:"Remove machines for services not defined in the Compose file."`
 
 	composefile string
@@ -36,6 +39,9 @@ func NewCmd() *cobra.Command {
 		Example: heredoc.Doc(`
 			# Run a compose project
 			$ kraft compose up
+
+			# Print what would be created without running anything
+			$ kraft compose up --dry-run
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "compose",
@@ -56,6 +62,10 @@ func (opts *UpOptions) Pre(cmd *cobra.Command, _ []string) error {
 
 	cmd.SetContext(ctx)
 
+	if opts.NoCache {
+		config.G[config.KraftKit](ctx).Catalog.NoCache = true
+	}
+
 	if cmd.Flag("file").Changed {
 		opts.composefile = cmd.Flag("file").Value.String()
 	}
@@ -67,6 +77,7 @@ func (opts *UpOptions) Pre(cmd *cobra.Command, _ []string) error {
 func (opts *UpOptions) Run(ctx context.Context, _ []string) error {
 	createOptions := create.CreateOptions{
 		Composefile:   opts.composefile,
+		DryRun:        opts.DryRun,
 		RemoveOrphans: opts.RemoveOrphans,
 	}
 
@@ -74,6 +85,10 @@ func (opts *UpOptions) Run(ctx context.Context, _ []string) error {
 		return err
 	}
 
+	if opts.DryRun {
+		return nil
+	}
+
 	startOptions := start.StartOptions{
 		Composefile: opts.composefile,
 	}
@@ -21,7 +21,9 @@ import (
 	mplatform "kraftkit.sh/machine/platform"
 )
 
-func RemoveOrphans(ctx context.Context, project *compose.Project) error {
+// RemoveOrphans removes the running machines of the project whose services
+// are no longer defined.  During a dry run, the machines are only listed.
+func RemoveOrphans(ctx context.Context, project *compose.Project, dryRun bool) error {
 	composeController, err := compose.NewComposeProjectV1(ctx)
 	if err != nil {
 		return err
@@ -50,7 +52,7 @@ func RemoveOrphans(ctx context.Context, project *compose.Project) error {
 	for _, machine := range embeddedProject.Status.Machines {
 		isService := false
 		for _, service := range project.Services {
-			if service.Name == machine.Name {
+			if compose.IsServiceMachine(service, machine) {
 				isService = true
 				break
 			}
@@ -69,8 +71,14 @@ func RemoveOrphans(ctx context.Context, project *compose.Project) error {
 		return nil
 	}
 
-	log.G(ctx).Info("removing orphan machines...")
+	if dryRun {
+		log.G(ctx).Info("would remove orphan machines:")
+	} else {
+		log.G(ctx).Info("removing orphan machines...")
+	}
+
 	removeOptions := remove.RemoveOptions{
+		DryRun:   dryRun,
 		Platform: "auto",
 	}
 
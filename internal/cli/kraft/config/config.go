@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package config
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/config/get"
+	"kraftkit.sh/internal/cli/kraft/config/set"
+	"kraftkit.sh/internal/cli/kraft/config/unset"
+	"kraftkit.sh/internal/cli/kraft/config/usecontext"
+)
+
+type ConfigOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ConfigOptions{}, cobra.Command{
+		Short: "Manage the kraft configuration",
+		Use:   "config SUBCOMMAND",
+		Long: heredoc.Doc(`
+			Manage the kraft configuration.
+
+			Keys are dot-separated paths into the configuration file, for example
+			'log.level' or 'contexts.staging.default_plat'.  Named contexts group
+			values such as registries, the default platform and architecture,
+			credentials and proxy settings, which take precedence over the
+			top-level values whilst the context is in use.
+		`),
+		Example: heredoc.Doc(`
+			# Show the log level
+			$ kraft config get log.level
+
+			# Create a context which uses a different registry and platform
+			$ kraft config set contexts.staging.manifests registry.example.com
+			$ kraft config set contexts.staging.default_plat fc
+
+			# Switch to the context
+			$ kraft config use-context staging
+
+			# Use a context for a single invocation
+			$ kraft --context staging pkg ls
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "misc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(get.NewCmd())
+	cmd.AddCommand(set.NewCmd())
+	cmd.AddCommand(unset.NewCmd())
+	cmd.AddCommand(usecontext.NewCmd())
+
+	return cmd
+}
+
+func (opts *ConfigOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package get
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/iostreams"
+)
+
+type GetOptions struct{}
+
+// Get the value of a configuration key.
+func Get(ctx context.Context, opts *GetOptions, args ...string) error {
+	if opts == nil {
+		opts = &GetOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&GetOptions{}, cobra.Command{
+		Short: "Show the value of a configuration key",
+		Use:   "get KEY",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Show the value of a configuration key.
+
+			The value which is in effect is shown, taking into account the context
+			in use, environmental variables and flags.
+		`),
+		Example: heredoc.Doc(`
+			# Show the log level
+			$ kraft config get log.level
+
+			# Show all contexts
+			$ kraft config get contexts
+		`),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *GetOptions) Run(ctx context.Context, args []string) error {
+	value, err := config.GetKey(config.G[config.KraftKit](ctx), args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, value)
+
+	return nil
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package set
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+)
+
+type SetOptions struct{}
+
+// Set the value of a configuration key.
+func Set(ctx context.Context, opts *SetOptions, args ...string) error {
+	if opts == nil {
+		opts = &SetOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SetOptions{}, cobra.Command{
+		Short: "Set the value of a configuration key",
+		Use:   "set KEY VALUE",
+		Args:  cobra.ExactArgs(2),
+		Long: heredoc.Doc(`
+			Set the value of a configuration key in the configuration file.
+
+			Lists, such as manifests, are provided as comma-separated values.
+		`),
+		Example: heredoc.Doc(`
+			# Never prompt for user interaction
+			$ kraft config set no_prompt true
+
+			# Set the default platform of the staging context
+			$ kraft config set contexts.staging.default_plat fc
+
+			# Set the registry credentials of the staging context
+			$ kraft config set contexts.staging.auth.registry.example.com.token $TOKEN
+		`),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *SetOptions) Run(ctx context.Context, args []string) error {
+	file := config.M[config.KraftKit](ctx).ConfigFile
+	if file == "" {
+		file = config.DefaultConfigFile()
+	}
+
+	return config.SetKey(file, args[0], args[1])
+}
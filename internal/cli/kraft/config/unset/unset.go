@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package unset
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+)
+
+type UnsetOptions struct{}
+
+// Unset a configuration key.
+func Unset(ctx context.Context, opts *UnsetOptions, args ...string) error {
+	if opts == nil {
+		opts = &UnsetOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&UnsetOptions{}, cobra.Command{
+		Short: "Unset a configuration key",
+		Use:   "unset KEY",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Remove a configuration key from the configuration file such that its
+			default value is used.
+		`),
+		Example: heredoc.Doc(`
+			# Reset the log level to its default
+			$ kraft config unset log.level
+
+			# Remove the staging context
+			$ kraft config unset contexts.staging
+		`),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *UnsetOptions) Run(ctx context.Context, args []string) error {
+	file := config.M[config.KraftKit](ctx).ConfigFile
+	if file == "" {
+		file = config.DefaultConfigFile()
+	}
+
+	return config.UnsetKey(file, args[0])
+}
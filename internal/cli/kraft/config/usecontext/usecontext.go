@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package usecontext
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+)
+
+type UseContextOptions struct{}
+
+// UseContext switches to the named configuration context.
+func UseContext(ctx context.Context, opts *UseContextOptions, args ...string) error {
+	if opts == nil {
+		opts = &UseContextOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&UseContextOptions{}, cobra.Command{
+		Short: "Switch to a configuration context",
+		Use:   "use-context NAME",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Switch to a configuration context such that its values are used by all
+			subsequent invocations of kraft.
+		`),
+		Example: heredoc.Doc(`
+			# Switch to the staging context
+			$ kraft config use-context staging
+		`),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			var names []string
+			for _, name := range config.G[config.KraftKit](cmd.Context()).ContextNames() {
+				if strings.HasPrefix(name, toComplete) {
+					names = append(names, name)
+				}
+			}
+
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *UseContextOptions) Run(ctx context.Context, args []string) error {
+	cfg := config.G[config.KraftKit](ctx)
+
+	if _, ok := cfg.Contexts[args[0]]; !ok {
+		return fmt.Errorf("unknown context: %s (known: %s)", args[0], strings.Join(cfg.ContextNames(), ", "))
+	}
+
+	file := config.M[config.KraftKit](ctx).ConfigFile
+	if file == "" {
+		file = config.DefaultConfigFile()
+	}
+
+	if err := config.SetKey(file, "current_context", args[0]); err != nil {
+		return err
+	}
+
+	log.G(ctx).Infof("switched to context %s", args[0])
+
+	return nil
+}
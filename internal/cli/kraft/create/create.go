@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package create
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/cli/kraft/run"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+// CreateOptions accept the same flags as run, except for those which only
+// apply once the machine has been started.
+type CreateOptions struct {
+	run.RunOptions
+}
+
+// startFlags are the flags of run which only apply when the machine is
+// started and are therefore hidden.
+var startFlags = []string{"detach", "no-start", "prefix", "prefix-name", "rm"}
+
+// Create a Unikraft unikernel virtual machine locally without starting it.
+func Create(ctx context.Context, opts *CreateOptions, args ...string) error {
+	if opts == nil {
+		opts = &CreateOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CreateOptions{}, cobra.Command{
+		Short:             "Create a unikernel without starting it",
+		Use:               "create [FLAGS] PROJECT|PACKAGE|BINARY -- [APP ARGS]",
+		ValidArgsFunction: completion.Limit(1, completion.Packages),
+		Aliases:           []string{},
+		Long: heredoc.Doc(`
+			Create a unikernel virtual machine without starting it.
+
+			The machine is prepared exactly as with 'kraft run': its name is
+			allocated, it is attached to its networks and volumes and its
+			specification is saved, but it is not booted.  The name of the machine
+			is printed such that it can be started later with 'kraft start', which
+			allows the machine to be provisioned ahead of the time it is needed.
+		`),
+		Example: heredoc.Doc(`
+			# Create a machine from an OCI-compatible unikernel and start it later
+			$ kraft create --name my-nginx -p 8080:80 unikraft.org/nginx:latest
+			$ kraft start --detach my-nginx
+
+			# Create a machine from the project in the current working directory
+			$ kraft create
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().Var(
+		cmdfactory.NewEnumFlag[mplatform.Platform](
+			mplatform.Platforms(),
+			mplatform.Platform("auto"),
+		),
+		"plat",
+		"Set the platform virtual machine monitor driver.",
+	)
+
+	for _, flag := range startFlags {
+		if err := cmd.PersistentFlags().MarkHidden(flag); err != nil {
+			panic(err)
+		}
+	}
+
+	return cmd
+}
+
+func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
+	opts.NoStart = true
+	opts.Detach = false
+	opts.Remove = false
+
+	return opts.RunOptions.Run(ctx, args)
+}
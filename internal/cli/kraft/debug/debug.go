@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package debug
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/debug/dump"
+	"kraftkit.sh/internal/cli/kraft/debug/symbolize"
+)
+
+type DebugOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DebugOptions{}, cobra.Command{
+		Short: "Debug local machines",
+		Use:   "debug SUBCOMMAND",
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(dump.NewCmd())
+	cmd.AddCommand(symbolize.NewCmd())
+
+	return cmd
+}
+
+func (opts *DebugOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
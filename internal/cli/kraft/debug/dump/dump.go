@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dump
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/diagnostics"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type DumpOptions struct {
+	Output string `long:"output" short:"o" usage:"Path of the archive to write, or '-' for standard output (default: MACHINE-diagnostics.tar.gz)"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DumpOptions{}, cobra.Command{
+		Short:             "Retrieve the diagnostics captured when a machine crashed",
+		Use:               "dump [FLAGS] MACHINE",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Machines),
+		Long: heredoc.Doc(`
+			Retrieve the diagnostics bundle which was captured when the guest of a
+			machine panicked.
+
+			The bundle holds a dump of the memory of the guest in the ELF core format,
+			which can be inspected with gdb alongside the debug image of the kernel,
+			the last part of the console output of the guest, the symbolic kernel if
+			it was built or packaged with its symbols and a description of the crash.
+			It is written as a gzip-compressed tarball.
+
+			Bundles outlive their machine, such that the diagnostics of a machine
+			which was removed, e.g. because it was started with 'kraft run --rm', can
+			still be retrieved by its name.
+		`),
+		Example: heredoc.Doc(`
+			# Save the diagnostics of a crashed machine to my-machine-diagnostics.tar.gz
+			$ kraft debug dump my-machine
+
+			# Inspect the memory dump of a crashed machine
+			$ kraft debug dump -o - my-machine | tar -xzf -
+			$ gdb .unikraft/build/app_qemu-x86_64.dbg my-machine/memory.elf
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DumpOptions) Run(ctx context.Context, args []string) error {
+	name := args[0]
+
+	bundle, err := opts.bundle(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if info, err := diagnostics.ReadInfo(bundle); err == nil {
+		name = info.Machine
+
+		if info.MemoryError != "" {
+			log.G(ctx).Warnf("the bundle does not contain a memory dump: %s", info.MemoryError)
+		}
+	}
+
+	if opts.Output == "-" {
+		return diagnostics.Archive(bundle, name, iostreams.G(ctx).Out)
+	}
+
+	output := opts.Output
+	if output == "" {
+		output = name + "-diagnostics.tar.gz"
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("could not create archive: %w", err)
+	}
+
+	if err := diagnostics.Archive(bundle, name, f); err != nil {
+		f.Close()
+		os.Remove(output)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, output)
+
+	return nil
+}
+
+// bundle returns the path of the diagnostics bundle of the machine with the
+// provided name or UID: the bundle announced in its status or otherwise the
+// most recent bundle captured for a machine of that name, which may have been
+// removed.
+func (opts *DumpOptions) bundle(ctx context.Context, name string) (string, error) {
+	if controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx); err == nil {
+		if machines, err := controller.List(ctx, &machineapi.MachineList{}); err == nil {
+			for _, machine := range machines.Items {
+				if name != machine.Name && name != string(machine.UID) {
+					continue
+				}
+
+				if machine.Status.Diagnostics != "" {
+					if _, err := os.Stat(machine.Status.Diagnostics); err == nil {
+						return machine.Status.Diagnostics, nil
+					}
+				}
+
+				name = machine.Name
+				break
+			}
+		}
+	}
+
+	bundle, err := diagnostics.Latest(config.G[config.KraftKit](ctx).RuntimeDir, filepath.Base(name))
+	if err != nil {
+		return "", err
+	}
+
+	return bundle, nil
+}
@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package symbolize
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/diagnostics"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/unikraft/symbolize"
+)
+
+type SymbolizeOptions struct {
+	Symbols string `long:"symbols" short:"s" usage:"Path to the symbolic kernel image (default: the one of the machine)"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SymbolizeOptions{}, cobra.Command{
+		Short:             "Resolve addresses of a machine to functions and source lines",
+		Use:               "symbolize [FLAGS] MACHINE [ADDRESS...|FILE|-]",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Machines),
+		Long: heredoc.Doc(`
+			Resolve addresses of the guest of a machine to the functions and source
+			lines they belong to, using the symbols and DWARF debugging information of
+			the symbolic kernel image of the machine.
+
+			The addresses are either provided as arguments or found in a trace, e.g.
+			the output of a crashed guest, which is read from a file or, with '-',
+			from standard input.  Every line of the trace is printed followed by the
+			locations of the addresses it contains.  Without addresses or a trace,
+			the console output saved when the guest of the machine last panicked is
+			symbolized.
+
+			The symbolic kernel is found next to the kernel of the machine when it
+			was built locally or packaged with 'kraft pkg --symbols', or in the
+			diagnostics bundle which was captured when its guest panicked, such that
+			machines which were removed can still be symbolized.
+		`),
+		Example: heredoc.Doc(`
+			# Symbolize the crash of a machine
+			$ kraft debug symbolize my-machine
+
+			# Resolve individual addresses
+			$ kraft debug symbolize my-machine 0x113a2f 0x10f0c4
+
+			# Symbolize a trace using a particular symbolic kernel
+			$ kraft logs my-machine | kraft debug symbolize -s .unikraft/build/app_qemu-x86_64.dbg my-machine -
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *SymbolizeOptions) Run(ctx context.Context, args []string) error {
+	name := args[0]
+	args = args[1:]
+
+	machine := lookup(ctx, name)
+	bundle := opts.bundle(ctx, name, machine)
+
+	symbols, err := opts.symbols(machine, bundle)
+	if err != nil {
+		return err
+	}
+
+	s, err := symbolize.Open(symbols)
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	if !s.HasLineInfo() {
+		log.G(ctx).Warnf("%s does not contain debugging information: only functions are resolved", symbols)
+	}
+
+	out := iostreams.G(ctx).Out
+
+	// Resolve the addresses provided as arguments.
+	if len(args) > 0 && args[0] != "-" {
+		if _, err := symbolize.ParseAddress(args[0]); err == nil {
+			for _, arg := range args {
+				addr, err := symbolize.ParseAddress(arg)
+				if err != nil {
+					return err
+				}
+
+				frame, _ := s.Resolve(addr)
+				fmt.Fprintf(out, "0x%x: %s\n", addr, frame)
+			}
+
+			return nil
+		}
+	}
+
+	if len(args) > 1 {
+		return fmt.Errorf("expected a single trace, got %d", len(args))
+	}
+
+	var trace io.Reader
+
+	switch {
+	case len(args) == 1 && args[0] == "-":
+		trace = iostreams.G(ctx).In
+
+	case len(args) == 1:
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("could not open trace: %w", err)
+		}
+
+		defer f.Close()
+
+		trace = f
+
+	case bundle != "":
+		f, err := os.Open(filepath.Join(bundle, diagnostics.ConsoleFile))
+		if err != nil {
+			return fmt.Errorf("could not open console output of crash: %w", err)
+		}
+
+		defer f.Close()
+
+		trace = f
+
+	default:
+		return fmt.Errorf("no crash of %s was captured: provide addresses or a trace to symbolize", name)
+	}
+
+	return s.Trace(trace, out)
+}
+
+// lookup returns the machine with the provided name or UID, or nil if there is
+// no such machine, e.g. because it was removed.
+func lookup(ctx context.Context, name string) *machineapi.Machine {
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return nil
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return nil
+	}
+
+	for _, machine := range machines.Items {
+		if name == machine.Name || name == string(machine.UID) {
+			return &machine
+		}
+	}
+
+	return nil
+}
+
+// bundle returns the path of the diagnostics bundle of the machine, which is
+// empty if none was captured.
+func (opts *SymbolizeOptions) bundle(ctx context.Context, name string, machine *machineapi.Machine) string {
+	if machine != nil {
+		if machine.Status.Diagnostics != "" {
+			if _, err := os.Stat(machine.Status.Diagnostics); err == nil {
+				return machine.Status.Diagnostics
+			}
+		}
+
+		name = machine.Name
+	}
+
+	bundle, err := diagnostics.Latest(config.G[config.KraftKit](ctx).RuntimeDir, filepath.Base(name))
+	if err != nil {
+		return ""
+	}
+
+	return bundle
+}
+
+// symbols returns the path to the symbolic kernel of the machine, preferring
+// the one next to its kernel over the copy kept in its diagnostics bundle.
+func (opts *SymbolizeOptions) symbols(machine *machineapi.Machine, bundle string) (string, error) {
+	if opts.Symbols != "" {
+		return opts.Symbols, nil
+	}
+
+	var candidates []string
+
+	if machine != nil && machine.Status.KernelPath != "" {
+		candidates = append(candidates, machine.Status.KernelPath+".dbg")
+	}
+
+	if bundle != "" {
+		candidates = append(candidates, filepath.Join(bundle, diagnostics.SymbolsFile))
+
+		if info, err := diagnostics.ReadInfo(bundle); err == nil && info.Kernel != "" {
+			candidates = append(candidates, info.Kernel+".dbg")
+		}
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find the symbolic kernel of the machine: build it locally, package it with 'kraft pkg --symbols' or provide it with --symbols")
+}
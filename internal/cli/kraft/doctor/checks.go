@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/qemu"
+)
+
+// status is the outcome of a single check.
+type status int
+
+const (
+	statusPass status = iota
+	statusWarn
+	statusFail
+)
+
+// result is returned by a check and contains a human-readable message as well
+// as, if the check did not pass, an actionable fix.
+type result struct {
+	status  status
+	message string
+	fix     string
+}
+
+func pass(format string, a ...any) result {
+	return result{status: statusPass, message: fmt.Sprintf(format, a...)}
+}
+
+func warn(fix, format string, a ...any) result {
+	return result{status: statusWarn, message: fmt.Sprintf(format, a...), fix: fix}
+}
+
+func fail(fix, format string, a ...any) result {
+	return result{status: statusFail, message: fmt.Sprintf(format, a...), fix: fix}
+}
+
+// check is a single, named diagnosis of the host.
+type check struct {
+	name string
+	run  func(ctx context.Context) result
+}
+
+// checks returns all checks which are performed on the host in the order in
+// which they are performed.
+func checks(opts *DoctorOptions) []check {
+	ret := hostChecks()
+
+	ret = append(ret,
+		check{name: "qemu", run: checkQemu},
+		check{name: "registry", run: func(ctx context.Context) result {
+			return checkRegistry(ctx, opts)
+		}},
+		check{name: "config", run: checkConfig},
+	)
+
+	return ret
+}
+
+// checkQemu checks whether any QEMU binary is installed and reports the
+// versions of those which are found.
+func checkQemu(ctx context.Context) result {
+	bins := []string{
+		qemu.QemuSystemX86,
+		qemu.QemuSystemArm,
+		qemu.QemuSystemAarch64,
+		qemu.QemuSystemRiscv64,
+	}
+
+	if custom := config.G[config.KraftKit](ctx).Qemu; custom != "" {
+		bins = []string{custom}
+	}
+
+	var found []string
+	for _, bin := range bins {
+		path, err := exec.LookPath(bin)
+		if err != nil {
+			continue
+		}
+
+		version, err := qemu.GetQemuVersionFromBin(ctx, path)
+		if err != nil {
+			log.G(ctx).
+				WithField("bin", path).
+				Debugf("could not determine version: %v", err)
+			found = append(found, fmt.Sprintf("%s (unknown version)", bin))
+			continue
+		}
+
+		found = append(found, fmt.Sprintf("%s %s", bin, version.String()))
+	}
+
+	if len(found) == 0 {
+		return fail(
+			"install QEMU with your package manager, e.g. `apt install qemu-system` or `brew install qemu`",
+			"no QEMU binary found in PATH (looked for %s)", strings.Join(bins, ", "),
+		)
+	}
+
+	return pass("%s", strings.Join(found, ", "))
+}
+
+// checkRegistry checks whether the registry can be reached via HTTPS.  Any
+// HTTP response, including those requiring authentication, is sufficient.
+func checkRegistry(ctx context.Context, opts *DoctorOptions) result {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("https://%s/v2/", opts.Registry)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fail("", "could not prepare request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fail(
+			"check your network connection and whether a proxy must be set via HTTPS_PROXY",
+			"%s is not reachable: %v", opts.Registry, err,
+		)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return warn(
+			"retry later, the registry may be experiencing an outage",
+			"%s responded with %s", opts.Registry, resp.Status,
+		)
+	}
+
+	return pass("%s is reachable", opts.Registry)
+}
+
+// checkConfig checks whether the configuration holds only supported values
+// and whether the directories it refers to are writable.
+func checkConfig(ctx context.Context) result {
+	cfg := config.G[config.KraftKit](ctx)
+
+	values := map[string]string{
+		"git_protocol": cfg.GitProtocol,
+		"log.level":    cfg.Log.Level,
+		"log.type":     cfg.Log.Type,
+		"progress":     cfg.Progress,
+	}
+
+	var problems []string
+
+	for _, detail := range config.ConfigDetails() {
+		value, ok := values[detail.Key]
+		if !ok || value == "" || len(detail.AllowedValues) == 0 {
+			continue
+		}
+
+		allowed := false
+		for _, v := range detail.AllowedValues {
+			if v == value {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			problems = append(problems, fmt.Sprintf("%s is set to unsupported value '%s' (allowed: %s)",
+				detail.Key, value, strings.Join(detail.AllowedValues, ", "),
+			))
+		}
+	}
+
+	for name, dir := range map[string]string{
+		"paths.manifests": cfg.Paths.Manifests,
+		"paths.sources":   cfg.Paths.Sources,
+		"runtime_dir":     cfg.RuntimeDir,
+	} {
+		if dir == "" {
+			continue
+		}
+
+		if err := writable(dir); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not writable: %v", name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fail(
+			fmt.Sprintf("edit %s or set the respective KRAFTKIT_* environment variables", config.DefaultConfigFile()),
+			"%s", strings.Join(problems, "; "),
+		)
+	}
+
+	return pass("%s", config.DefaultConfigFile())
+}
+
+// writable returns an error if files cannot be created in the provided
+// directory or, if it does not yet exist, in its closest existing parent.
+func writable(dir string) error {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".kraft-doctor-*")
+	if err != nil {
+		return err
+	}
+
+	name := f.Name()
+	f.Close()
+
+	return os.Remove(name)
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package doctor
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// hostChecks returns the checks which are specific to macOS hosts.
+func hostChecks() []check {
+	return []check{
+		{name: "hypervisor framework", run: checkHypervisorFramework},
+	}
+}
+
+// sysctl returns the value of the named kernel state variable.
+func sysctl(ctx context.Context, name string) (string, error) {
+	out, err := exec.CommandContext(ctx, "sysctl", "-n", name).Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// checkHypervisorFramework checks whether the Hypervisor framework, which is
+// used by QEMU for acceleration, is supported.  When running within a virtual
+// machine, this requires nested virtualization to be enabled.
+func checkHypervisorFramework(ctx context.Context) result {
+	nested, _ := sysctl(ctx, "kern.hv_vmm_present")
+
+	support, err := sysctl(ctx, "kern.hv_support")
+	if err != nil || support != "1" {
+		if nested == "1" {
+			return fail(
+				"enable nested virtualization for this virtual machine in the settings of its hypervisor",
+				"the Hypervisor framework is not supported within this virtual machine",
+			)
+		}
+
+		return warn(
+			"unikernels will be emulated without acceleration, which is considerably slower",
+			"the Hypervisor framework is not supported on this host",
+		)
+	}
+
+	if nested == "1" {
+		return pass("the Hypervisor framework is supported through nested virtualization")
+	}
+
+	return pass("the Hypervisor framework is supported")
+}
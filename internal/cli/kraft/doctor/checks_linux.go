@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"kraftkit.sh/machine/firecracker"
+)
+
+// hostChecks returns the checks which are specific to Linux hosts.
+func hostChecks() []check {
+	return []check{
+		{name: "kvm", run: checkKVM},
+		{name: "kvm permissions", run: checkKVMPermissions},
+		{name: "bridge", run: checkKernelModule("bridge", "")},
+		{name: "tun", run: checkKernelModule("tun", "/dev/net/tun")},
+		{name: "firecracker", run: checkFirecracker},
+	}
+}
+
+// checkKVM checks whether the KVM device exists and, if not, whether the CPU
+// supports hardware virtualization at all.
+func checkKVM(ctx context.Context) result {
+	if fi, err := os.Stat("/dev/kvm"); err == nil {
+		if fi.Mode()&os.ModeCharDevice == 0 {
+			return fail("recreate it with `sudo mknod /dev/kvm c 10 232`", "/dev/kvm is not a character device")
+		}
+
+		return pass("/dev/kvm is available")
+	}
+
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return fail("", "/dev/kvm does not exist and /proc/cpuinfo could not be read: %v", err)
+	}
+
+	var module string
+	switch {
+	case bytes.Contains(cpuinfo, []byte(" vmx")):
+		module = "kvm_intel"
+	case bytes.Contains(cpuinfo, []byte(" svm")):
+		module = "kvm_amd"
+	default:
+		return fail(
+			"enable hardware virtualization (VT-x/AMD-V) in the firmware settings or, in a virtual machine, enable nested virtualization",
+			"/dev/kvm does not exist and the CPU does not advertise hardware virtualization",
+		)
+	}
+
+	return fail(
+		fmt.Sprintf("load the KVM module with `sudo modprobe %s`", module),
+		"/dev/kvm does not exist although the CPU supports hardware virtualization",
+	)
+}
+
+// checkKVMPermissions checks whether the current user may open the KVM device.
+func checkKVMPermissions(ctx context.Context) result {
+	if _, err := os.Stat("/dev/kvm"); err != nil {
+		return warn("see the kvm check", "skipped since /dev/kvm does not exist")
+	}
+
+	if err := unix.Access("/dev/kvm", unix.R_OK|unix.W_OK); err != nil {
+		return fail(
+			"add your user to the kvm group with `sudo usermod -aG kvm $USER` and log in again",
+			"/dev/kvm cannot be opened for reading and writing: %v", err,
+		)
+	}
+
+	return pass("/dev/kvm can be opened for reading and writing")
+}
+
+// checkKernelModule returns a check whether the named kernel module is loaded,
+// built into the kernel or otherwise present since the provided path, if any,
+// exists.
+func checkKernelModule(name, path string) func(ctx context.Context) result {
+	return func(ctx context.Context) result {
+		if _, err := os.Stat(filepath.Join("/sys/module", name)); err == nil {
+			return pass("kernel module %s is loaded", name)
+		}
+
+		if path != "" {
+			if _, err := os.Stat(path); err == nil {
+				return pass("kernel support for %s is available", name)
+			}
+		}
+
+		var uts unix.Utsname
+		if err := unix.Uname(&uts); err == nil {
+			release := unix.ByteSliceToString(uts.Release[:])
+			builtin, err := os.ReadFile(filepath.Join("/lib/modules", release, "modules.builtin"))
+			if err == nil && bytes.Contains(builtin, []byte("/"+name+".ko")) {
+				return pass("kernel module %s is built-in", name)
+			}
+		}
+
+		return warn(
+			fmt.Sprintf("load the module with `sudo modprobe %s`, it is required for networking", name),
+			"kernel module %s is not loaded", name,
+		)
+	}
+}
+
+// checkFirecracker checks whether Firecracker is installed and reports its
+// version.  Firecracker is optional since QEMU can be used instead.
+func checkFirecracker(ctx context.Context) result {
+	path, err := exec.LookPath(firecracker.FirecrackerBin)
+	if err != nil {
+		return warn(
+			"install Firecracker from https://github.com/firecracker-microvm/firecracker/releases to use the fc platform",
+			"%s not found in PATH", firecracker.FirecrackerBin,
+		)
+	}
+
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return warn("reinstall Firecracker", "could not determine version of %s: %v", path, err)
+	}
+
+	version := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+
+	return pass("%s", version)
+}
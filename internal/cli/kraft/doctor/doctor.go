@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+)
+
+type DoctorOptions struct {
+	Registry string        `long:"registry" usage:"Registry to check the reachability of" default:"index.unikraft.io"`
+	Timeout  time.Duration `long:"timeout" usage:"Timeout of each check which reaches out to the network" default:"5s"`
+}
+
+// Doctor diagnoses the host environment of kraft.
+func Doctor(ctx context.Context, opts *DoctorOptions) error {
+	if opts == nil {
+		opts = &DoctorOptions{}
+	}
+
+	return opts.Run(ctx, []string{})
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DoctorOptions{}, cobra.Command{
+		Short: "Diagnose the host environment",
+		Use:   "doctor [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Diagnose the host environment.
+
+			Checks whether the host is able to build, package and run unikernels,
+			for example whether hardware virtualization is available and usable,
+			which hypervisors are installed and whether registries are reachable.
+			For every problem that is found, a possible fix is suggested.
+		`),
+		Example: heredoc.Doc(`
+			# Diagnose the host environment
+			$ kraft doctor
+
+			# Diagnose the host environment and check a custom registry
+			$ kraft doctor --registry registry.example.com
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "misc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DoctorOptions) Run(ctx context.Context, _ []string) error {
+	if opts.Registry == "" {
+		opts.Registry = "index.unikraft.io"
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	out := iostreams.G(ctx).Out
+	cs := iostreams.G(ctx).ColorScheme()
+
+	failed := 0
+	warned := 0
+
+	for _, check := range checks(opts) {
+		res := check.run(ctx)
+
+		var icon string
+		switch res.status {
+		case statusPass:
+			icon = cs.SuccessIcon()
+		case statusWarn:
+			icon = cs.WarningIcon()
+			warned++
+		case statusFail:
+			icon = cs.FailureIcon()
+			failed++
+		}
+
+		fmt.Fprintf(out, "%s %s: %s\n", icon, cs.Bold(check.name), res.message)
+		if res.fix != "" && res.status != statusPass {
+			fmt.Fprintf(out, "  %s %s\n", cs.Gray("fix:"), res.fix)
+		}
+	}
+
+	fmt.Fprintln(out)
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed and %d check(s) raised a warning", failed, warned)
+	}
+
+	if warned > 0 {
+		fmt.Fprintf(out, "%s all checks passed, %d check(s) raised a warning\n", cs.SuccessIcon(), warned)
+	} else {
+		fmt.Fprintf(out, "%s all checks passed\n", cs.SuccessIcon())
+	}
+
+	return nil
+}
@@ -20,7 +20,11 @@ import (
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/cli/kraft/logs"
+	"kraftkit.sh/internal/eventlog"
 	"kraftkit.sh/internal/waitgroup"
+	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
 	mplatform "kraftkit.sh/machine/platform"
 	"kraftkit.sh/machine/qemu/qmp"
@@ -28,27 +32,44 @@ import (
 
 type EventOptions struct {
 	platform     string
+	since        time.Time
+	until        time.Time
 	Granularity  time.Duration `long:"poll-granularity" short:"g" usage:"How often the machine store and state should polled (ms/s/m/h)"`
 	QuitTogether bool          `long:"quit-together" short:"q" usage:"Exit event loop when machine exits"`
+	Since        string        `long:"since" usage:"Show recorded events since a timestamp (e.g. 2024-01-02T13:23:37Z) or relative duration (e.g. 42m)"`
+	Until        string        `long:"until" usage:"Show recorded events until a timestamp (e.g. 2024-01-02T13:23:37Z) or relative duration (e.g. 42m) and exit"`
 }
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&EventOptions{}, cobra.Command{
-		Short:   "Follow the events of a unikernel",
-		Hidden:  true,
-		Use:     "events [FLAGS] [MACHINE ID]",
-		Args:    cobra.MaximumNArgs(1),
-		Aliases: []string{"event"},
+		Short:             "Follow the events of a unikernel",
+		Hidden:            true,
+		Use:               "events [FLAGS] [MACHINE ID]",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Machines),
+		Aliases:           []string{"event"},
 		Long: heredoc.Doc(`
 			Follow the events of a unikernel
+
+			The state transitions of machines are recorded in a bounded history as
+			they are observed, such that past events can be shown with --since and
+			--until, including those of machines which have since exited.  With
+			--since alone, the recorded events are shown before following the live
+			events.  With --until, the command exits after showing them.
 		`),
 		Example: heredoc.Doc(`
 			# Follow the events of a unikernel
 			$ kraft events ID
+
+			# Show the events of the last hour and follow new ones
+			$ kraft events --since 1h
+
+			# Show the events of a unikernel between two points in time
+			$ kraft events --since 2h --until 1h ID
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup:  "run",
-			cmdfactory.AnnotationHelpHidden: "true",
+			cmdfactory.AnnotationDeprecated: "it will be removed in a future release",
 		},
 	})
 	if err != nil {
@@ -71,14 +92,74 @@ func NewCmd() *cobra.Command {
 var observations = waitgroup.WaitGroup[*machineapi.Machine]{}
 
 func (opts *EventOptions) Pre(cmd *cobra.Command, _ []string) error {
+	var err error
+
 	opts.platform = cmd.Flag("plat").Value.String()
+
+	now := time.Now()
+
+	if opts.since, err = logs.ParseTime(opts.Since, now); err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+
+	if opts.until, err = logs.ParseTime(opts.Until, now); err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+
+	if !opts.since.IsZero() && !opts.until.IsZero() && opts.until.Before(opts.since) {
+		return fmt.Errorf("--until must not be before --since")
+	}
+
+	return nil
+}
+
+// history prints the recorded events which are selected by the options.
+func (opts *EventOptions) history(ctx context.Context, args []string) error {
+	filter := eventlog.Filter{
+		Since: opts.since,
+		Until: opts.until,
+	}
+	if len(args) > 0 {
+		filter.Machine = args[0]
+	}
+
+	events, err := mplatform.EventLog(ctx).List(filter)
+	if err != nil {
+		return fmt.Errorf("could not read event history: %w", err)
+	}
+
+	for _, event := range events {
+		line := fmt.Sprintf("%s %s : %s", event.Time.Format(time.RFC3339), event.Machine, event.State)
+		if event.Previous != "" {
+			line = fmt.Sprintf("%s %s : %s -> %s", event.Time.Format(time.RFC3339), event.Machine, event.Previous, event.State)
+		}
+
+		if event.State == machineapi.MachineStateExited.String() || event.State == machineapi.MachineStateFailed.String() {
+			line += fmt.Sprintf(" (exit code %d)", event.ExitCode)
+		}
+
+		if _, err := fmt.Fprintln(iostreams.G(ctx).Out, line); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (opts *EventOptions) Run(ctx context.Context, args []string) error {
 	var err error
 
-	log.G(ctx).Warnf("This command is DEPRECATED and should not be used")
+	if !opts.since.IsZero() || !opts.until.IsZero() {
+		if err := opts.history(ctx, args); err != nil {
+			return err
+		}
+
+		if !opts.until.IsZero() {
+			return nil
+		}
+	}
+
+	events := mplatform.EventLog(ctx)
 
 	ctx, cancel := context.WithCancel(ctx)
 	platform := mplatform.PlatformUnknown
@@ -199,7 +280,7 @@ seek:
 			machine := machine // loop closure
 
 			go func() {
-				events, errs, err := controller.Watch(ctx, machine)
+				updates, errs, err := controller.Watch(ctx, machine)
 				if err != nil {
 					log.G(ctx).Debugf("could not listen for status updates for %s: %v", machine.Name, err)
 					return
@@ -208,8 +289,20 @@ seek:
 				for {
 					// Wait on either channel
 					select {
-					case machine := <-events:
+					case machine := <-updates:
 						log.G(ctx).Infof("%s : %s", machine.Name, machine.Status.State.String())
+
+						if _, err := events.Observe(eventlog.Event{
+							Time:     time.Now(),
+							Machine:  machine.Name,
+							UID:      string(machine.UID),
+							Platform: machine.Spec.Platform,
+							State:    machine.Status.State.String(),
+							ExitCode: machine.Status.ExitCode,
+						}); err != nil {
+							log.G(ctx).Debugf("could not record event: %v", err)
+						}
+
 						switch machine.Status.State {
 						case machineapi.MachineStateExited, machineapi.MachineStateFailed:
 							observations.Done(machine)
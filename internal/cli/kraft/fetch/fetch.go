@@ -77,7 +77,7 @@ func NewCmd() *cobra.Command {
 			$ kraft fetch path/to/app`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup:  "build",
-			cmdfactory.AnnotationHelpHidden: "true",
+			cmdfactory.AnnotationDeprecated: "sources are fetched by 'kraft build'",
 		},
 	})
 	if err != nil {
@@ -294,7 +294,7 @@ func (opts *FetchOptions) pull(ctx context.Context, project app.Application, wor
 				p, err := packmanager.G(ctx).Catalog(ctx,
 					packmanager.WithName(component.Name()),
 					packmanager.WithTypes(component.Type()),
-					packmanager.WithVersion(component.Version()),
+					packmanager.WithVersion(opts.project.LockedVersion(component)),
 					packmanager.WithSource(component.Source()),
 					packmanager.WithRemote(opts.NoCache),
 					packmanager.WithAuthConfig(auths),
@@ -380,8 +380,6 @@ func (opts *FetchOptions) pull(ctx context.Context, project app.Application, wor
 }
 
 func (opts *FetchOptions) Run(ctx context.Context, _ []string) error {
-	log.G(ctx).Warnf("This command is DEPRECATED and should not be used")
-
 	// Filter project targets by any provided CLI options
 	selected := opts.project.Targets()
 
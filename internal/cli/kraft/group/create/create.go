@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	groupapi "kraftkit.sh/api/group/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/group"
+	"kraftkit.sh/internal/cli/kraft/group/utils"
+)
+
+type CreateOptions struct {
+	Architecture string   `long:"arch" short:"m" usage:"Set the architecture of the machines"`
+	DryRun       bool     `long:"dry-run" usage:"Print the machines which would be created without creating them"`
+	Env          []string `long:"env" short:"e" usage:"Set environment variables of the machines, in the format key[=value]"`
+	Memory       string   `long:"memory" short:"M" usage:"Assign memory to each machine (K/Ki, M/Mi, G/Gi)" default:"64Mi"`
+	Networks     []string `long:"network" usage:"Attach the machines to the provided network, in the format accepted by 'kraft run --network'"`
+	NoStart      bool     `long:"no-start" usage:"Do not start the machines"`
+	Platform     string   `long:"plat" short:"p" usage:"Set the platform of the machines" default:"auto"`
+	Replicas     int      `long:"replicas" short:"r" usage:"Number of machines in the group" default:"1"`
+	Volumes      []string `long:"volume" short:"v" usage:"Bind a volume to each machine, in the format accepted by 'kraft run --volume'"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CreateOptions{}, cobra.Command{
+		Short: "Create a group of machines",
+		Use:   "create [FLAGS] NAME SOURCE",
+		Args:  cobra.ExactArgs(2),
+		Long: heredoc.Doc(`
+			Create a named group of machines from the same source.
+
+			The SOURCE is any package, project directory or kernel accepted by
+			'kraft run'.  Each machine is attached to the provided networks, so
+			that the machines of the group can reach one another.
+		`),
+		Example: heredoc.Doc(`
+			# Create a group of three machines on the kraft0 network
+			$ kraft group create --replicas 3 --network kraft0 web nginx:latest
+
+			# Create a group from the project in the current directory
+			$ kraft group create --plat qemu --arch x86_64 web .
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *CreateOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.Replicas < 0 {
+		return fmt.Errorf("number of replicas cannot be negative: %d", opts.Replicas)
+	}
+
+	return nil
+}
+
+func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
+	controller, err := group.NewGroupV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+
+	if _, err := controller.Get(ctx, &groupapi.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}); err == nil {
+		return fmt.Errorf("group %s already exists", name)
+	}
+
+	newGroup := &groupapi.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: groupapi.GroupSpec{
+			Architecture: opts.Architecture,
+			Env:          opts.Env,
+			Memory:       opts.Memory,
+			Networks:     opts.Networks,
+			Platform:     opts.Platform,
+			Source:       args[1],
+			Volumes:      opts.Volumes,
+		},
+	}
+
+	if !opts.DryRun {
+		newGroup, err = controller.Create(ctx, newGroup)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = utils.Scale(ctx, controller, newGroup, opts.Replicas, utils.ScaleOptions{
+		DryRun:  opts.DryRun,
+		NoStart: opts.NoStart,
+	})
+
+	return err
+}
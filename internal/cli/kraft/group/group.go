@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package group
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/group/create"
+	"kraftkit.sh/internal/cli/kraft/group/list"
+	"kraftkit.sh/internal/cli/kraft/group/remove"
+	"kraftkit.sh/internal/cli/kraft/group/scale"
+)
+
+type GroupOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&GroupOptions{}, cobra.Command{
+		Short: "Manage groups of machines",
+		Use:   "group SUBCOMMAND",
+		Long: heredoc.Doc(`
+			Manage groups of machines.
+
+			A group is a named set of machines which are created from the same
+			source and attached to the same networks.  Its machines are named
+			after the group and numbered from 1, e.g. web-1, web-2, and carry
+			the label group.kraftkit.sh/name.
+		`),
+		Example: heredoc.Doc(`
+			# Create a group of three machines
+			$ kraft group create --replicas 3 --network kraft0 web nginx:latest
+
+			# Scale the group to five machines
+			$ kraft group scale web 5
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(create.NewCmd())
+	cmd.AddCommand(list.NewCmd())
+	cmd.AddCommand(remove.NewCmd())
+	cmd.AddCommand(scale.NewCmd())
+
+	return cmd
+}
+
+func (opts *GroupOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package list
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	groupapi "kraftkit.sh/api/group/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/group"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+)
+
+type ListOptions struct {
+	tableprinter.OutputOptions
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ListOptions{}, cobra.Command{
+		Short:   "List groups of machines",
+		Use:     "list [FLAGS]",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		Long: heredoc.Doc(`
+			List groups of machines.
+		`),
+		Example: heredoc.Doc(`
+			# List all groups
+			$ kraft group list
+
+			# List all groups in JSON format
+			$ kraft group list -o json
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ListOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (opts *ListOptions) Run(ctx context.Context, _ []string) error {
+	controller, err := group.NewGroupV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	groups, err := controller.List(ctx, &groupapi.GroupList{})
+	if err != nil {
+		return err
+	}
+
+	err = iostreams.G(ctx).StartPager()
+	if err != nil {
+		log.G(ctx).Errorf("error starting pager: %v", err)
+	}
+
+	defer iostreams.G(ctx).StopPager()
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := opts.NewTablePrinter(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Header row
+	table.AddField("NAME", cs.Bold)
+	table.AddField("SOURCE", cs.Bold)
+	table.AddField("REPLICAS", cs.Bold)
+	table.AddField("MACHINES", cs.Bold)
+	table.EndRow()
+
+	for _, item := range groups.Items {
+		table.AddField(item.Name, nil)
+		table.AddField(item.Spec.Source, nil)
+		table.AddField(fmt.Sprintf("%d/%d", len(item.Status.Machines), item.Spec.Replicas), nil)
+		names := []string{}
+		for _, machine := range item.Status.Machines {
+			names = append(names, machine.Name)
+		}
+		table.AddField(strings.Join(names, ","), nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package remove
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	groupapi "kraftkit.sh/api/group/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/group"
+	"kraftkit.sh/internal/cli/kraft/group/utils"
+)
+
+type RemoveOptions struct {
+	DryRun bool `long:"dry-run" usage:"Print the machines which would be removed without removing them"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&RemoveOptions{}, cobra.Command{
+		Short:   "Remove groups and their machines",
+		Use:     "remove [FLAGS] NAME [NAME [...]]",
+		Aliases: []string{"rm", "delete", "del"},
+		Args:    cobra.MinimumNArgs(1),
+		Long:    "Remove groups and all of their machines.",
+		Example: heredoc.Doc(`
+			# Remove the group and its machines
+			$ kraft group remove web
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
+	controller, err := group.NewGroupV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range args {
+		existing, err := controller.Get(ctx, &groupapi.Group{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := utils.Remove(ctx, controller, existing, opts.DryRun); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
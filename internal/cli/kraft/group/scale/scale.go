@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package scale
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	groupapi "kraftkit.sh/api/group/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/group"
+	"kraftkit.sh/internal/cli/kraft/group/utils"
+)
+
+type ScaleOptions struct {
+	DryRun  bool `long:"dry-run" usage:"Print the machines which would be created or removed without changing the group"`
+	NoStart bool `long:"no-start" usage:"Do not start the new machines"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ScaleOptions{}, cobra.Command{
+		Short: "Set the number of machines of a group",
+		Use:   "scale [FLAGS] NAME REPLICAS",
+		Args:  cobra.ExactArgs(2),
+		Long: heredoc.Doc(`
+			Set the number of machines of a group.
+
+			Missing machines are created from the source of the group, and
+			surplus machines are removed starting with the highest number.
+		`),
+		Example: heredoc.Doc(`
+			# Scale the group to five machines
+			$ kraft group scale web 5
+
+			# Remove all machines of the group but keep the group
+			$ kraft group scale web 0
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ScaleOptions) Run(ctx context.Context, args []string) error {
+	replicas, err := strconv.Atoi(args[1])
+	if err != nil || replicas < 0 {
+		return fmt.Errorf("invalid number of replicas: %s", args[1])
+	}
+
+	controller, err := group.NewGroupV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing, err := controller.Get(ctx, &groupapi.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: args[0],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = utils.Scale(ctx, controller, existing, replicas, utils.ScaleOptions{
+		DryRun:  opts.DryRun,
+		NoStart: opts.NoStart,
+	})
+
+	return err
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kraftkit.sh/internal/cli/kraft/remove"
+	"kraftkit.sh/internal/cli/kraft/run"
+	"kraftkit.sh/log"
+
+	groupapi "kraftkit.sh/api/group/v1alpha1"
+)
+
+// ScaleOptions customise how the machines of a group are created.
+type ScaleOptions struct {
+	// DryRun previews the machines which would be created or removed.
+	DryRun bool
+
+	// NoStart creates the new machines without starting them.
+	NoStart bool
+}
+
+// MachineName returns the name of the n-th machine of the group.
+func MachineName(group string, n int) string {
+	return fmt.Sprintf("%s-%d", group, n)
+}
+
+// machineIndex returns the position of the machine within the group, or 0 if
+// its name does not follow the naming of the group.
+func machineIndex(group, machine string) int {
+	suffix, ok := strings.CutPrefix(machine, group+"-")
+	if !ok {
+		return 0
+	}
+
+	n, err := strconv.Atoi(suffix)
+	if err != nil || n < 1 {
+		return 0
+	}
+
+	return n
+}
+
+// Scale creates or removes machines such that the group contains the provided
+// number of replicas, and records it in the store.  Machines are numbered
+// from 1, and the ones with the highest numbers are removed first.
+func Scale(ctx context.Context, controller groupapi.GroupService, group *groupapi.Group, replicas int, sopts ScaleOptions) (*groupapi.Group, error) {
+	if replicas < 0 {
+		return group, fmt.Errorf("number of replicas cannot be negative: %d", replicas)
+	}
+
+	existing := map[int]string{}
+	surplus := []string{}
+	for _, machine := range group.Status.Machines {
+		if n := machineIndex(group.Name, machine.Name); n > 0 && n <= replicas {
+			existing[n] = machine.Name
+		} else {
+			surplus = append(surplus, machine.Name)
+		}
+	}
+
+	// Remove the machines with the highest numbers first.
+	sort.Slice(surplus, func(i, j int) bool {
+		return machineIndex(group.Name, surplus[i]) > machineIndex(group.Name, surplus[j])
+	})
+
+	if len(surplus) > 0 {
+		log.G(ctx).
+			WithField("group", group.Name).
+			WithField("machines", surplus).
+			Info("removing machines")
+
+		removeOpts := remove.RemoveOptions{
+			DryRun:   sopts.DryRun,
+			Platform: "auto",
+		}
+
+		if err := removeOpts.Run(ctx, surplus); err != nil {
+			return group, fmt.Errorf("could not remove machines of group %s: %w", group.Name, err)
+		}
+	}
+
+	for n := 1; n <= replicas; n++ {
+		if _, ok := existing[n]; ok {
+			continue
+		}
+
+		name := MachineName(group.Name, n)
+
+		log.G(ctx).
+			WithField("group", group.Name).
+			WithField("machine", name).
+			Info("creating machine")
+
+		runOpts := run.RunOptions{
+			Architecture: group.Spec.Architecture,
+			Detach:       true,
+			DryRun:       sopts.DryRun,
+			Env:          group.Spec.Env,
+			Labels:       []string{groupapi.GroupLabelName + "=" + group.Name},
+			Memory:       group.Spec.Memory,
+			Name:         name,
+			Networks:     group.Spec.Networks,
+			NoStart:      sopts.NoStart,
+			Platform:     group.Spec.Platform,
+			Volumes:      group.Spec.Volumes,
+		}
+
+		if err := runOpts.Run(ctx, []string{group.Spec.Source}); err != nil {
+			return group, fmt.Errorf("could not create machine %s of group %s: %w", name, group.Name, err)
+		}
+	}
+
+	if sopts.DryRun {
+		return group, nil
+	}
+
+	group.Spec.Replicas = replicas
+
+	return controller.Update(ctx, group)
+}
+
+// Remove removes all machines of the group and then the group itself.
+func Remove(ctx context.Context, controller groupapi.GroupService, group *groupapi.Group, dryRun bool) error {
+	if _, err := Scale(ctx, controller, group, 0, ScaleOptions{DryRun: dryRun}); err != nil {
+		return err
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	_, err := controller.Delete(ctx, group)
+	return err
+}
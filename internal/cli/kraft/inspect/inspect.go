@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/volume"
+)
+
+type InspectOptions struct {
+	Output string `long:"output" short:"o" usage:"Set output format. Options: json,yaml" default:"json"`
+	Schema bool   `long:"schema" usage:"Print the JSON Schema of the output instead of inspecting a resource"`
+	Type   string `long:"type" short:"t" usage:"Kind of the resource to inspect. Options: machine,network,volume (default: any)"`
+}
+
+// ErrNotFound is returned when no resource with the provided name exists.
+var ErrNotFound = errors.New("not found")
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&InspectOptions{}, cobra.Command{
+		Short:             "Display detailed information of a machine, network or volume",
+		Use:               "inspect [FLAGS] NAME",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Machines),
+		Long: heredoc.Docf(`
+			Display detailed information of a machine, network or volume.
+
+			The output follows a versioned schema, identified by its %[1]sapiVersion%[1]s
+			field, which consists of the identity of the resource, its driver, its
+			desired configuration (%[1]sspec%[1]s) and its observed state
+			(%[1]sstatus%[1]s).  Fields may be added within a schema version, but are
+			never renamed, retyped or removed, such that scripts and infrastructure
+			as code providers can rely on it across releases.  The only exception
+			are the driver-specific details in %[1]sdriver.config%[1]s.
+
+			Use --schema to print the JSON Schema of the output.
+		`, "`"),
+		Example: heredoc.Doc(`
+			# Inspect a machine
+			$ kraft inspect my-machine
+
+			# Inspect a network in YAML format
+			$ kraft inspect --type network -o yaml my-network
+
+			# Print the JSON Schema of the output
+			$ kraft inspect --schema
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *InspectOptions) Pre(cmd *cobra.Command, args []string) error {
+	if opts.Output != "json" && opts.Output != "yaml" {
+		return fmt.Errorf("invalid output format: %s", opts.Output)
+	}
+
+	if opts.Schema != (len(args) == 0) {
+		return fmt.Errorf("either provide the name of a resource or use --schema")
+	}
+
+	switch opts.Type {
+	case "", "machine", "network", "volume":
+	default:
+		return fmt.Errorf("unknown resource type: %s", opts.Type)
+	}
+
+	return nil
+}
+
+func (opts *InspectOptions) Run(ctx context.Context, args []string) error {
+	if opts.Schema {
+		return opts.print(ctx, JSONSchema())
+	}
+
+	var errs []error
+
+	for _, lookup := range []struct {
+		kind string
+		fn   func(context.Context, string) (any, error)
+	}{
+		{"machine", inspectMachine},
+		{"network", inspectNetwork},
+		{"volume", inspectVolume},
+	} {
+		if opts.Type != "" && opts.Type != lookup.kind {
+			continue
+		}
+
+		doc, err := lookup.fn(ctx, args[0])
+		if err == nil {
+			return opts.print(ctx, doc)
+		}
+
+		if !errors.Is(err, ErrNotFound) {
+			errs = append(errs, fmt.Errorf("inspecting %s: %w", lookup.kind, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	kind := opts.Type
+	if kind == "" {
+		kind = "machine, network or volume"
+	}
+
+	return fmt.Errorf("%s %s: %w", kind, args[0], ErrNotFound)
+}
+
+// print writes the provided value to the output stream in the selected
+// format.
+func (opts *InspectOptions) print(ctx context.Context, v any) error {
+	return Print(ctx, opts.Output, v)
+}
+
+// Print writes the provided document to the output stream in the provided
+// format, either json or yaml.
+func Print(ctx context.Context, format string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if format == "yaml" {
+		// Convert via the JSON representation, such that the field names of the
+		// schema are retained.
+		var doc any
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return err
+		}
+
+		if b, err = yaml.Marshal(doc); err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprint(iostreams.G(ctx).Out, string(b))
+		return err
+	}
+
+	_, err = fmt.Fprintf(iostreams.G(ctx).Out, "%s\n", b)
+	return err
+}
+
+func inspectMachine(ctx context.Context, name string) (any, error) {
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, machine := range machines.Items {
+		if machine.Name == name || string(machine.UID) == name {
+			return FromMachine(&machine), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func inspectNetwork(ctx context.Context, name string) (any, error) {
+	for driver, strategy := range network.Strategies() {
+		controller, err := strategy.NewNetworkV1alpha1(ctx)
+		if err != nil {
+			log.G(ctx).
+				WithField("driver", driver).
+				Debugf("could not initialize network driver: %v", err)
+			continue
+		}
+
+		found, err := controller.Get(ctx, &networkapi.Network{
+			ObjectMeta: v1.ObjectMeta{
+				Name: name,
+			},
+		})
+		if err != nil {
+			log.G(ctx).
+				WithField("driver", driver).
+				Tracef("could not get network: %v", err)
+			continue
+		}
+
+		if found.Spec.Driver == "" {
+			found.Spec.Driver = driver
+		}
+
+		return FromNetwork(found), nil
+	}
+
+	return nil, ErrNotFound
+}
+
+func inspectVolume(ctx context.Context, name string) (any, error) {
+	for driver, strategy := range volume.Strategies() {
+		controller, err := strategy.NewVolumeV1alpha1(ctx)
+		if err != nil {
+			log.G(ctx).
+				WithField("driver", driver).
+				Debugf("could not initialize volume driver: %v", err)
+			continue
+		}
+
+		found, err := controller.Get(ctx, &volumeapi.Volume{
+			ObjectMeta: v1.ObjectMeta{
+				Name: name,
+			},
+		})
+		if err != nil {
+			log.G(ctx).
+				WithField("driver", driver).
+				Tracef("could not get volume: %v", err)
+			continue
+		}
+
+		if found.Spec.Driver == "" {
+			found.Spec.Driver = driver
+		}
+
+		return FromVolume(found), nil
+	}
+
+	return nil, ErrNotFound
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package inspect
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of inspected resources of
+// all kinds.  It is derived from the json, description and enum struct tags
+// of the document types, such that it cannot diverge from the output.
+func JSONSchema() map[string]any {
+	defs := map[string]any{}
+	var oneOf []any
+
+	for kind, doc := range map[Kind]any{
+		KindMachine: MachineDocument{},
+		KindNetwork: NetworkDocument{},
+		KindVolume:  VolumeDocument{},
+	} {
+		schema := typeSchema(reflect.TypeOf(doc))
+		properties := schema["properties"].(map[string]any)
+		properties["apiVersion"].(map[string]any)["const"] = APIVersion
+		properties["kind"].(map[string]any)["const"] = string(kind)
+
+		defs[string(kind)] = schema
+	}
+
+	for _, kind := range Kinds() {
+		oneOf = append(oneOf, map[string]any{"$ref": "#/$defs/" + string(kind)})
+	}
+
+	return map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   APIVersion,
+		"oneOf":   oneOf,
+		"$defs":   defs,
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// typeSchema returns the JSON Schema of the provided type.
+func typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			schema := typeSchema(field.Type)
+			if description := field.Tag.Get("description"); description != "" {
+				schema["description"] = description
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				var values []any
+				for _, value := range strings.Split(enum, ",") {
+					values = append(values, value)
+				}
+				schema["enum"] = values
+			}
+
+			properties[name] = schema
+
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+
+		return map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	}
+
+	// Interfaces and any other types are unconstrained.
+	return map[string]any{}
+}
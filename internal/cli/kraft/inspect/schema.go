@@ -0,0 +1,371 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package inspect
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// APIVersion is the version of the schema of inspected resources.  Fields may
+// be added within a version, but are never renamed, retyped or removed.
+const APIVersion = "inspect.kraftkit.sh/v1"
+
+// Kind is the kind of an inspected resource.
+type Kind string
+
+const (
+	KindMachine = Kind("Machine")
+	KindNetwork = Kind("Network")
+	KindVolume  = Kind("Volume")
+)
+
+// Kinds returns the kinds of resources which can be inspected.
+func Kinds() []Kind {
+	return []Kind{KindMachine, KindNetwork, KindVolume}
+}
+
+// Document is the representation of an inspected resource.
+type Document[Spec, Status any] struct {
+	APIVersion string   `json:"apiVersion" description:"Version of the schema of the document."`
+	Kind       Kind     `json:"kind" description:"Kind of the resource." enum:"Machine,Network,Volume"`
+	Metadata   Metadata `json:"metadata" description:"Identity of the resource."`
+	Driver     Driver   `json:"driver" description:"Driver which manages the resource."`
+	Spec       Spec     `json:"spec" description:"Desired configuration of the resource."`
+	Status     Status   `json:"status" description:"Observed state of the resource."`
+}
+
+type (
+	// MachineDocument is the representation of an inspected machine.
+	MachineDocument = Document[MachineSpec, MachineStatus]
+
+	// NetworkDocument is the representation of an inspected network.
+	NetworkDocument = Document[NetworkSpec, NetworkStatus]
+
+	// VolumeDocument is the representation of an inspected volume.
+	VolumeDocument = Document[VolumeSpec, VolumeStatus]
+)
+
+// Metadata identifies a resource.
+type Metadata struct {
+	Name      string            `json:"name" description:"Name of the resource."`
+	UID       string            `json:"uid,omitempty" description:"Unique identifier of the resource."`
+	CreatedAt *time.Time        `json:"createdAt,omitempty" description:"Time at which the resource was created (RFC 3339)."`
+	Labels    map[string]string `json:"labels,omitempty" description:"Labels attached to the resource."`
+}
+
+// Driver describes the implementation which manages a resource.
+type Driver struct {
+	Name   string `json:"name" description:"Name of the driver, e.g. qemu, firecracker or bridge."`
+	Config any    `json:"config,omitempty" description:"Driver-specific details, whose shape depends on the driver and is not covered by the schema version."`
+}
+
+// MachineSpec is the configuration of a machine.
+type MachineSpec struct {
+	Architecture string            `json:"architecture,omitempty" description:"Architecture of the machine, e.g. x86_64 or arm64."`
+	Platform     string            `json:"platform,omitempty" description:"Platform of the machine, e.g. qemu or firecracker."`
+	Kernel       string            `json:"kernel,omitempty" description:"Reference to the kernel of the machine."`
+	ImageDigest  string            `json:"imageDigest,omitempty" description:"Digest of the image the machine was created from."`
+	Rootfs       string            `json:"rootfs,omitempty" description:"Path to the root filesystem of the machine."`
+	KernelArgs   []string          `json:"kernelArgs,omitempty" description:"Arguments passed to the kernel."`
+	Args         []string          `json:"args,omitempty" description:"Arguments passed to the application."`
+	Env          map[string]string `json:"env,omitempty" description:"Environment variables of the application."`
+	MemoryBytes  int64             `json:"memoryBytes,omitempty" description:"Memory assigned to the machine in bytes."`
+	CPUs         int64             `json:"cpus,omitempty" description:"Number of vCPUs assigned to the machine."`
+	Emulation    bool              `json:"emulation,omitempty" description:"Whether the machine runs without hardware acceleration."`
+	LogDriver    string            `json:"logDriver,omitempty" description:"Driver recording the console output of the machine." enum:"raw,json-file,none"`
+	LogOptions   map[string]string `json:"logOptions,omitempty" description:"Options of the log driver."`
+	VMMBinary    string            `json:"vmmBinary,omitempty" description:"Path to the executable of the virtual machine monitor."`
+	MachineType  string            `json:"machineType,omitempty" description:"Type of machine emulated by the virtual machine monitor, e.g. pc or microvm."`
+	Accelerator  string            `json:"accelerator,omitempty" description:"Accelerator used by the virtual machine monitor." enum:"auto,kvm,hvf,whpx,xen,tcg"`
+	CPUModel     string            `json:"cpuModel,omitempty" description:"Model of the virtual CPU, or the CPU template for Firecracker."`
+	CPUFeatures  []string          `json:"cpuFeatures,omitempty" description:"Features of the virtual CPU which are enabled (+) or disabled (-) over those of its model."`
+	Ports        []Port            `json:"ports,omitempty" description:"Ports of the machine published on the host."`
+	Networks     []NetworkSpec     `json:"networks,omitempty" description:"Networks the machine is connected to."`
+	Volumes      []VolumeSpec      `json:"volumes,omitempty" description:"Volumes mounted in the machine."`
+}
+
+// Port is a port of a machine which is published on the host.
+type Port struct {
+	HostIP      string `json:"hostIP,omitempty" description:"Address on the host the port is bound to."`
+	HostPort    int32  `json:"hostPort,omitempty" description:"Port on the host."`
+	MachinePort int32  `json:"machinePort" description:"Port in the machine."`
+	Protocol    string `json:"protocol,omitempty" description:"Protocol of the port." enum:"TCP,UDP,SCTP"`
+}
+
+// MachineStatus is the observed state of a machine.
+type MachineStatus struct {
+	State         string     `json:"state" description:"State of the machine." enum:"unknown,created,failed,restarting,running,paused,suspended,exited,errored"`
+	Pid           int32      `json:"pid,omitempty" description:"Process ID of the virtual machine monitor."`
+	ExitCode      int        `json:"exitCode" description:"Exit code of the machine, if it has exited."`
+	ExitReason    string     `json:"exitReason,omitempty" description:"Reason reported by the platform for the exit of the machine, e.g. guest-panic."`
+	Diagnostics   string     `json:"diagnostics,omitempty" description:"Path to the diagnostics bundle on the host which was captured when the guest panicked."`
+	StartedAt     *time.Time `json:"startedAt,omitempty" description:"Time at which the machine was last started (RFC 3339)."`
+	ExitedAt      *time.Time `json:"exitedAt,omitempty" description:"Time at which the machine last exited (RFC 3339)."`
+	KernelPath    string     `json:"kernelPath,omitempty" description:"Path to the kernel on the host."`
+	InitrdPath    string     `json:"initrdPath,omitempty" description:"Path to the initial ramdisk on the host."`
+	InitrdLayers  []string   `json:"initrdLayers,omitempty" description:"Paths to the layers on the host which are merged into the initial ramdisk, in order."`
+	StateDir      string     `json:"stateDir,omitempty" description:"Directory on the host holding the state of the machine."`
+	LogFile       string     `json:"logFile,omitempty" description:"Path to the console log of the machine on the host."`
+	ConsoleSocket string     `json:"consoleSocket,omitempty" description:"Path to the serial console socket of the machine on the host."`
+	MetricsSocket string     `json:"metricsSocket,omitempty" description:"Path to the socket on the host over which the ukstore counters of the guest are read."`
+	Cgroup        string     `json:"cgroup,omitempty" description:"Path to the cgroup confining the machine on the host."`
+	Accelerator   string     `json:"accelerator,omitempty" description:"Accelerator used to run the machine, which is tcg if it is emulated in software." enum:"kvm,hvf,whpx,xen,tcg"`
+	MemoryBytes   int64      `json:"memoryBytes,omitempty" description:"Memory currently available to the machine in bytes."`
+}
+
+// NetworkSpec is the configuration of a network.
+type NetworkSpec struct {
+	Driver       string        `json:"driver,omitempty" description:"Driver of the network, e.g. bridge."`
+	Bridge       string        `json:"bridge,omitempty" description:"Name of the network interface on the host."`
+	Gateway      string        `json:"gateway,omitempty" description:"IPv4 address of the gateway."`
+	Netmask      string        `json:"netmask,omitempty" description:"IPv4 netmask of the network."`
+	IPRange      string        `json:"ipRange,omitempty" description:"Range of IPv4 addresses in CIDR notation from which addresses are allocated."`
+	Reservations []Reservation `json:"reservations,omitempty" description:"IPv4 addresses reserved for specific machines."`
+	Interfaces   []Interface   `json:"interfaces,omitempty" description:"Interfaces of machines on the network."`
+}
+
+// Reservation is an address of a network reserved for a machine.
+type Reservation struct {
+	Machine string `json:"machine" description:"Name of the machine for which the address is reserved."`
+	IP      string `json:"ip" description:"Reserved IPv4 address."`
+}
+
+// Interface is the interface of a machine on a network.
+type Interface struct {
+	Name       string   `json:"name,omitempty" description:"Name of the interface."`
+	CIDR       string   `json:"cidr,omitempty" description:"IPv4 address of the interface in CIDR notation."`
+	Gateway    string   `json:"gateway,omitempty" description:"IPv4 address of the gateway."`
+	DNS        []string `json:"dns,omitempty" description:"IPv4 addresses of the DNS servers."`
+	Hostname   string   `json:"hostname,omitempty" description:"Hostname of the interface."`
+	Domain     string   `json:"domain,omitempty" description:"Search domain of the interface."`
+	MacAddress string   `json:"macAddress,omitempty" description:"Hardware address of the interface."`
+}
+
+// NetworkStatus is the observed state of a network.
+type NetworkStatus struct {
+	State     string `json:"state" description:"State of the network." enum:"unknown,up,down"`
+	RxBytes   uint64 `json:"rxBytes" description:"Number of bytes received."`
+	RxPackets uint64 `json:"rxPackets" description:"Number of packets received."`
+	RxErrors  uint64 `json:"rxErrors" description:"Number of receive errors."`
+	RxDropped uint64 `json:"rxDropped" description:"Number of received packets which were dropped."`
+	TxBytes   uint64 `json:"txBytes" description:"Number of bytes transmitted."`
+	TxPackets uint64 `json:"txPackets" description:"Number of packets transmitted."`
+	TxErrors  uint64 `json:"txErrors" description:"Number of transmit errors."`
+	TxDropped uint64 `json:"txDropped" description:"Number of transmitted packets which were dropped."`
+}
+
+// VolumeSpec is the configuration of a volume.
+type VolumeSpec struct {
+	Name        string `json:"name,omitempty" description:"Name of the volume."`
+	Driver      string `json:"driver,omitempty" description:"Driver of the volume, e.g. 9pfs."`
+	Source      string `json:"source,omitempty" description:"Path to the contents of the volume on the host."`
+	Destination string `json:"destination,omitempty" description:"Path the volume is mounted at in machines."`
+	ReadOnly    bool   `json:"readOnly,omitempty" description:"Whether the volume is mounted read-only."`
+	Managed     bool   `json:"managed,omitempty" description:"Whether the contents of the volume are managed by KraftKit."`
+}
+
+// VolumeStatus is the observed state of a volume.
+type VolumeStatus struct {
+	State string `json:"state" description:"State of the volume." enum:"Pending,Bound,Lost"`
+}
+
+// FromMachine returns the document of a machine.
+func FromMachine(machine *machineapi.Machine) *MachineDocument {
+	doc := &MachineDocument{
+		APIVersion: APIVersion,
+		Kind:       KindMachine,
+		Metadata: Metadata{
+			Name:      machine.Name,
+			UID:       string(machine.UID),
+			CreatedAt: timePtr(machine.CreationTimestamp.Time),
+			Labels:    machine.Labels,
+		},
+		Driver: Driver{
+			Name:   machine.Spec.Platform,
+			Config: machine.Status.PlatformConfig,
+		},
+		Spec: MachineSpec{
+			Architecture: machine.Spec.Architecture,
+			Platform:     machine.Spec.Platform,
+			Kernel:       machine.Spec.Kernel,
+			ImageDigest:  machine.Spec.ImageDigest,
+			Rootfs:       machine.Spec.Rootfs,
+			KernelArgs:   machine.Spec.KernelArgs,
+			Args:         machine.Spec.ApplicationArgs,
+			Env:          machine.Spec.Env,
+			Emulation:    machine.Spec.Emulation,
+			LogDriver:    string(machine.Spec.Logging.Driver),
+			LogOptions:   machine.Spec.Logging.Options,
+			VMMBinary:    machine.Spec.VMM.Binary,
+			MachineType:  machine.Spec.VMM.MachineType,
+			Accelerator:  string(machine.Spec.VMM.Accelerator),
+			CPUModel:     machine.Spec.VMM.CPUModel,
+			CPUFeatures:  machine.Spec.VMM.CPUFeatures,
+		},
+		Status: MachineStatus{
+			State:         string(machine.Status.State),
+			Pid:           machine.Status.Pid,
+			ExitCode:      machine.Status.ExitCode,
+			ExitReason:    machine.Status.ExitReason,
+			Diagnostics:   machine.Status.Diagnostics,
+			StartedAt:     timePtr(machine.Status.StartedAt),
+			ExitedAt:      timePtr(machine.Status.ExitedAt),
+			KernelPath:    machine.Status.KernelPath,
+			InitrdPath:    machine.Status.InitrdPath,
+			InitrdLayers:  machine.Status.InitrdLayers,
+			StateDir:      machine.Status.StateDir,
+			LogFile:       machine.Status.LogFile,
+			ConsoleSocket: machine.Status.ConsoleSocket,
+			MetricsSocket: machine.Status.MetricsSocket,
+			Cgroup:        machine.Status.Cgroup,
+			Accelerator:   string(machine.Status.Accelerator),
+			MemoryBytes:   machine.Status.CurrentMemory,
+		},
+	}
+
+	if memory, ok := machine.Spec.Resources.Requests[corev1.ResourceMemory]; ok {
+		doc.Spec.MemoryBytes = memory.Value()
+	}
+
+	if cpus, ok := machine.Spec.Resources.Requests[corev1.ResourceCPU]; ok {
+		doc.Spec.CPUs = cpus.Value()
+	}
+
+	for _, port := range machine.Spec.Ports {
+		doc.Spec.Ports = append(doc.Spec.Ports, Port{
+			HostIP:      port.HostIP,
+			HostPort:    port.HostPort,
+			MachinePort: port.MachinePort,
+			Protocol:    string(port.Protocol),
+		})
+	}
+
+	for _, network := range machine.Spec.Networks {
+		doc.Spec.Networks = append(doc.Spec.Networks, networkSpec(network))
+	}
+
+	for i := range machine.Spec.Volumes {
+		doc.Spec.Volumes = append(doc.Spec.Volumes, volumeSpec(&machine.Spec.Volumes[i]))
+	}
+
+	return doc
+}
+
+// FromNetwork returns the document of a network.
+func FromNetwork(network *networkapi.Network) *NetworkDocument {
+	return &NetworkDocument{
+		APIVersion: APIVersion,
+		Kind:       KindNetwork,
+		Metadata: Metadata{
+			Name:      network.Name,
+			UID:       string(network.UID),
+			CreatedAt: timePtr(network.CreationTimestamp.Time),
+			Labels:    network.Labels,
+		},
+		Driver: Driver{
+			Name:   network.Spec.Driver,
+			Config: network.Status.DriverConfig,
+		},
+		Spec: networkSpec(network.Spec),
+		Status: NetworkStatus{
+			State:     string(network.Status.State),
+			RxBytes:   network.Status.RxBytes,
+			RxPackets: network.Status.RxPackets,
+			RxErrors:  network.Status.RxErrors,
+			RxDropped: network.Status.RxDropped,
+			TxBytes:   network.Status.TxBytes,
+			TxPackets: network.Status.TxPackets,
+			TxErrors:  network.Status.TxErrors,
+			TxDropped: network.Status.TxDropped,
+		},
+	}
+}
+
+// FromVolume returns the document of a volume.
+func FromVolume(volume *volumeapi.Volume) *VolumeDocument {
+	return &VolumeDocument{
+		APIVersion: APIVersion,
+		Kind:       KindVolume,
+		Metadata: Metadata{
+			Name:      volume.Name,
+			UID:       string(volume.UID),
+			CreatedAt: timePtr(volume.CreationTimestamp.Time),
+			Labels:    volume.Labels,
+		},
+		Driver: Driver{
+			Name:   volume.Spec.Driver,
+			Config: volume.Status.DriverConfig,
+		},
+		Spec: volumeSpec(volume),
+		Status: VolumeStatus{
+			State: string(volume.Status.State),
+		},
+	}
+}
+
+func networkSpec(spec networkapi.NetworkSpec) NetworkSpec {
+	ret := NetworkSpec{
+		Driver:  spec.Driver,
+		Bridge:  spec.IfName,
+		Gateway: spec.Gateway,
+		Netmask: spec.Netmask,
+		IPRange: spec.IPRange,
+	}
+
+	for _, reservation := range spec.Reservations {
+		ret.Reservations = append(ret.Reservations, Reservation{
+			Machine: reservation.Machine,
+			IP:      reservation.IP,
+		})
+	}
+
+	for _, iface := range spec.Interfaces {
+		var dns []string
+		for _, server := range []string{iface.Spec.DNS0, iface.Spec.DNS1} {
+			if server != "" {
+				dns = append(dns, server)
+			}
+		}
+
+		ret.Interfaces = append(ret.Interfaces, Interface{
+			Name:       iface.Spec.IfName,
+			CIDR:       iface.Spec.CIDR,
+			Gateway:    iface.Spec.Gateway,
+			DNS:        dns,
+			Hostname:   iface.Spec.Hostname,
+			Domain:     iface.Spec.Domain,
+			MacAddress: iface.Spec.MacAddress,
+		})
+	}
+
+	return ret
+}
+
+func volumeSpec(volume *volumeapi.Volume) VolumeSpec {
+	return VolumeSpec{
+		Name:        volume.Name,
+		Driver:      volume.Spec.Driver,
+		Source:      volume.Spec.Source,
+		Destination: volume.Spec.Destination,
+		ReadOnly:    volume.Spec.ReadOnly,
+		Managed:     volume.Spec.Managed,
+	}
+}
+
+// timePtr returns nil for the zero time, such that it is omitted.
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package inspect
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+)
+
+func TestFromMachine(t *testing.T) {
+	machine := &machineapi.Machine{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "my-machine",
+			UID:  "1234",
+		},
+		Spec: machineapi.MachineSpec{
+			Architecture: "x86_64",
+			Platform:     "qemu",
+			Ports: machineapi.MachinePorts{
+				{HostPort: 8080, MachinePort: 80, Protocol: corev1.ProtocolTCP},
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("64Mi"),
+					corev1.ResourceCPU:    resource.MustParse("2"),
+				},
+			},
+		},
+		Status: machineapi.MachineStatus{
+			State: machineapi.MachineStateRunning,
+			Pid:   42,
+		},
+	}
+
+	b, err := json.Marshal(FromMachine(machine))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		path []string
+		want any
+	}{
+		{[]string{"apiVersion"}, APIVersion},
+		{[]string{"kind"}, "Machine"},
+		{[]string{"metadata", "name"}, "my-machine"},
+		{[]string{"driver", "name"}, "qemu"},
+		{[]string{"spec", "memoryBytes"}, float64(64 << 20)},
+		{[]string{"spec", "cpus"}, float64(2)},
+		{[]string{"status", "state"}, "running"},
+		{[]string{"status", "exitCode"}, float64(0)},
+	} {
+		var v any = got
+		for _, key := range tc.path {
+			v = v.(map[string]any)[key]
+		}
+
+		if v != tc.want {
+			t.Errorf("%v: expected %v, got %v", tc.path, tc.want, v)
+		}
+	}
+
+	if _, ok := got["metadata"].(map[string]any)["createdAt"]; ok {
+		t.Errorf("expected zero creation time to be omitted")
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema()
+
+	defs := schema["$defs"].(map[string]any)
+	if len(defs) != len(Kinds()) {
+		t.Fatalf("expected %d definitions, got %d", len(Kinds()), len(defs))
+	}
+
+	machine := defs["Machine"].(map[string]any)
+	properties := machine["properties"].(map[string]any)
+
+	if kind := properties["kind"].(map[string]any)["const"]; kind != "Machine" {
+		t.Errorf("expected kind to be constant Machine, got %v", kind)
+	}
+
+	status := properties["status"].(map[string]any)
+	state := status["properties"].(map[string]any)["state"].(map[string]any)
+	if state["description"] == "" || len(state["enum"].([]any)) == 0 {
+		t.Errorf("expected state to be documented and enumerated, got %v", state)
+	}
+
+	required := status["required"].([]string)
+	if len(required) != 2 || required[0] != "state" || required[1] != "exitCode" {
+		t.Errorf("expected state and exitCode to be required, got %v", required)
+	}
+
+	started := status["properties"].(map[string]any)["startedAt"].(map[string]any)
+	if started["format"] != "date-time" {
+		t.Errorf("expected startedAt to be a date-time, got %v", started)
+	}
+
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatal(err)
+	}
+}
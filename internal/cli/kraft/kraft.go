@@ -24,29 +24,48 @@ import (
 	kitversion "kraftkit.sh/internal/version"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
+	"kraftkit.sh/tui"
 
+	"kraftkit.sh/internal/cli/kraft/bench"
 	"kraftkit.sh/internal/cli/kraft/build"
 	"kraftkit.sh/internal/cli/kraft/clean"
 	"kraftkit.sh/internal/cli/kraft/cloud"
 	"kraftkit.sh/internal/cli/kraft/compose"
+	kraftconfig "kraftkit.sh/internal/cli/kraft/config"
+	"kraftkit.sh/internal/cli/kraft/create"
+	"kraftkit.sh/internal/cli/kraft/debug"
+	"kraftkit.sh/internal/cli/kraft/doctor"
 	"kraftkit.sh/internal/cli/kraft/events"
 	"kraftkit.sh/internal/cli/kraft/fetch"
+	"kraftkit.sh/internal/cli/kraft/group"
+	"kraftkit.sh/internal/cli/kraft/inspect"
 	"kraftkit.sh/internal/cli/kraft/lib"
 	"kraftkit.sh/internal/cli/kraft/login"
 	"kraftkit.sh/internal/cli/kraft/logs"
+	"kraftkit.sh/internal/cli/kraft/machine"
 	"kraftkit.sh/internal/cli/kraft/menu"
+	"kraftkit.sh/internal/cli/kraft/metrics"
 	"kraftkit.sh/internal/cli/kraft/net"
+	kraftnew "kraftkit.sh/internal/cli/kraft/new"
 	"kraftkit.sh/internal/cli/kraft/pause"
 	"kraftkit.sh/internal/cli/kraft/pkg"
 	"kraftkit.sh/internal/cli/kraft/ps"
 	"kraftkit.sh/internal/cli/kraft/remove"
 	"kraftkit.sh/internal/cli/kraft/run"
+	"kraftkit.sh/internal/cli/kraft/search"
+	"kraftkit.sh/internal/cli/kraft/selfupdate"
 	"kraftkit.sh/internal/cli/kraft/set"
 	"kraftkit.sh/internal/cli/kraft/start"
+	"kraftkit.sh/internal/cli/kraft/stats"
 	"kraftkit.sh/internal/cli/kraft/stop"
+	"kraftkit.sh/internal/cli/kraft/system"
+	"kraftkit.sh/internal/cli/kraft/test"
 	"kraftkit.sh/internal/cli/kraft/unset"
+	"kraftkit.sh/internal/cli/kraft/update"
+	"kraftkit.sh/internal/cli/kraft/validate"
 	"kraftkit.sh/internal/cli/kraft/version"
 	"kraftkit.sh/internal/cli/kraft/volume"
+	"kraftkit.sh/internal/cli/kraft/wait"
 	"kraftkit.sh/internal/cli/kraft/x"
 
 	// Additional initializers
@@ -82,8 +101,12 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(clean.NewCmd())
 	cmd.AddCommand(fetch.NewCmd())
 	cmd.AddCommand(menu.NewCmd())
+	cmd.AddCommand(kraftnew.NewCmd())
+	cmd.AddCommand(search.NewCmd())
+	cmd.AddCommand(test.NewCmd())
 	cmd.AddCommand(set.NewCmd())
 	cmd.AddCommand(unset.NewCmd())
+	cmd.AddCommand(validate.NewCmd())
 
 	cmd.AddGroup(&cobra.Group{ID: "lib", Title: "PROJECT LIBRARY COMMANDS"})
 	cmd.AddCommand(lib.NewCmd())
@@ -92,14 +115,24 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(pkg.NewCmd())
 
 	cmd.AddGroup(&cobra.Group{ID: "run", Title: "LOCAL RUNTIME COMMANDS"})
+	cmd.AddCommand(bench.NewCmd())
+	cmd.AddCommand(create.NewCmd())
+	cmd.AddCommand(debug.NewCmd())
 	cmd.AddCommand(events.NewCmd())
+	cmd.AddCommand(group.NewCmd())
+	cmd.AddCommand(inspect.NewCmd())
 	cmd.AddCommand(logs.NewCmd())
+	cmd.AddCommand(machine.NewCmd())
+	cmd.AddCommand(metrics.NewCmd())
 	cmd.AddCommand(ps.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
 	cmd.AddCommand(run.NewCmd())
 	cmd.AddCommand(start.NewCmd())
 	cmd.AddCommand(stop.NewCmd())
 	cmd.AddCommand(pause.NewCmd())
+	cmd.AddCommand(stats.NewCmd())
+	cmd.AddCommand(update.NewCmd())
+	cmd.AddCommand(wait.NewCmd())
 
 	cmd.AddGroup(&cobra.Group{ID: "net", Title: "LOCAL NETWORKING COMMANDS"})
 	cmd.AddCommand(net.NewCmd())
@@ -122,7 +155,11 @@ func NewCmd() *cobra.Command {
 	cmd.AddGroup(&cobra.Group{ID: "kraftcloud-compose", Title: "UNIKRAFT CLOUD COMPOSE COMMANDS"})
 
 	cmd.AddGroup(&cobra.Group{ID: "misc", Title: "MISCELLANEOUS COMMANDS"})
+	cmd.AddCommand(kraftconfig.NewCmd())
+	cmd.AddCommand(doctor.NewCmd())
+	cmd.AddCommand(system.NewCmd())
 	cmd.AddCommand(login.NewCmd())
+	cmd.AddCommand(selfupdate.NewCmd())
 	cmd.AddCommand(version.NewCmd())
 	cmd.AddCommand(x.NewCmd())
 
@@ -164,6 +201,7 @@ func Main(args []string) int {
 		cli.WithDefaultIOStreams(),
 		cli.WithDefaultPluginManager(),
 		cli.WithDefaultLogger(),
+		cli.WithDefaultProgressEmitter(),
 		cli.WithDefaultHTTPClient(),
 	} {
 		if err := o(copts); err != nil {
@@ -172,9 +210,19 @@ func Main(args []string) int {
 		}
 	}
 
+	// Surface executable plugins as subcommands
+	if err := registerPlugins(cmd, copts); err != nil && copts.Logger != nil {
+		copts.Logger.Debugf("could not discover plugins: %v", err)
+	}
+
 	// Set up the config manager in the context if it is available
 	if copts.ConfigManager != nil {
 		ctx = config.WithConfigManager(ctx, copts.ConfigManager)
+
+		// Apply the defaults of the flags of commands and strict mode from the
+		// configuration
+		ctx = cmdfactory.WithCommandDefaults(ctx, copts.ConfigManager.Config.Commands)
+		ctx = cmdfactory.WithStrict(ctx, copts.ConfigManager.Config.Strict)
 	}
 
 	// Hydrate KraftCloud configuration
@@ -192,6 +240,10 @@ func Main(args []string) int {
 		ctx = iostreams.WithIOStreams(ctx, copts.IOStreams)
 	}
 
+	if copts.ProgressEmitter != nil {
+		ctx = tui.WithProgressEmitter(ctx, copts.ProgressEmitter)
+	}
+
 	if (os.Getenv("SUDO_UID") != "" || os.Getenv("SUDO_GID") != "" || os.Getenv("SUDO_USER") != "") && !config.G[config.KraftKit](ctx).NoWarnSudo {
 		log.G(ctx).Warn("detected invocation via sudo!")
 		log.G(ctx).Warn("")
@@ -209,7 +261,7 @@ func Main(args []string) int {
 	// Add the kraftkit version to the debug logs
 	log.G(ctx).Debugf("kraftkit %s", kitversion.Version())
 
-	if !config.G[config.KraftKit](ctx).NoCheckUpdates {
+	if !config.G[config.KraftKit](ctx).NoCheckUpdates && !config.G[config.KraftKit](ctx).Offline {
 		if err := kitupdate.Check(ctx); err != nil {
 			log.G(ctx).Debugf("could not check for updates: %v", err)
 			log.G(ctx).Debug("")
@@ -11,6 +11,7 @@ import (
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
 	"kraftkit.sh/internal/cli/kraft/pkg/pull"
+	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/app"
@@ -18,9 +19,10 @@ import (
 )
 
 type AddOptions struct {
-	Kraftfile string `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
-	NoUpdate  bool   `long:"no-update" usage:"Do not update package index before running the build"`
-	Workdir   string `long:"workdir" short:"w" usage:"workdir to add the package to"`
+	KConfig   []string `long:"kconfig" short:"k" usage:"Set a KConfig option of the library, in the format KEY=value"`
+	Kraftfile string   `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	NoUpdate  bool     `long:"no-update" usage:"Do not update package index before running the build"`
+	Workdir   string   `long:"workdir" short:"w" usage:"workdir to add the package to"`
 }
 
 // Add adds a Unikraft library to the project directory and updates the Kraftfile
@@ -41,6 +43,11 @@ func NewCmd() *cobra.Command {
 		Long: heredoc.Doc(`
 			Pull a Unikraft component microlibrary from a remote location
 			and add to the project directory
+
+			If no version is specified, the version of the library which is
+			compatible with the Unikraft core of the project is used.  Only the
+			entry of the library in the Kraftfile is changed, such that comments
+			and formatting are preserved.
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "lib",
@@ -57,6 +64,9 @@ func NewCmd() *cobra.Command {
 
 			# Add a library from a registry
 			$ kraft lib add unikraft.org/nginx:stable
+
+			# Add a library and set KConfig options for it
+			$ kraft lib add -k CONFIG_LWIP_IPV6=y lwip
 		`),
 	})
 	if err != nil {
@@ -76,13 +86,26 @@ func (opts *AddOptions) Pre(cmd *cobra.Command, _ []string) error {
 }
 
 func (opts *AddOptions) Run(ctx context.Context, args []string) error {
-	var workdir, packName, packVersion string
+	var packName, packVersion string
 	var packType unikraft.ComponentType
 	var err error
 	var library lib.LibraryConfig
 	isPackUndefindable := false
 	packageManager := packmanager.G(ctx)
 
+	kconfig, err := utils.ParseKConfig(opts.KConfig)
+	if err != nil {
+		return err
+	}
+
+	workdir := opts.Workdir
+	if workdir == "" {
+		workdir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
 	if f, err := os.Stat(args[0]); err == nil && f.IsDir() {
 		if err = packageManager.AddSource(ctx, args[0]); err != nil {
 			return err
@@ -122,15 +145,6 @@ func (opts *AddOptions) Run(ctx context.Context, args []string) error {
 		}
 	}
 
-	if !isPackUndefindable && !strings.HasPrefix(args[0], "lib") {
-		args[0] = "lib/" + args[0]
-	}
-
-	// Pulling library.
-	if err = pull.Pull(ctx, &pull.PullOptions{}, args...); err != nil {
-		return err
-	}
-
 	popts := []app.ProjectOption{}
 	if len(opts.Kraftfile) > 0 {
 		popts = append(popts, app.WithProjectKraftfile(opts.Kraftfile))
@@ -146,6 +160,28 @@ func (opts *AddOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	// Resolve the version of the library which is compatible with the project
+	// if none has been requested.
+	if packVersion == "" {
+		packVersion, err = utils.ResolveLibraryVersion(ctx, project, packName, !opts.NoUpdate)
+		if err != nil {
+			return err
+		}
+
+		if !isPackUndefindable {
+			args[0] = fmt.Sprintf("%s:%s", packName, packVersion)
+		}
+	}
+
+	if !isPackUndefindable && !strings.HasPrefix(args[0], "lib") {
+		args[0] = "lib/" + args[0]
+	}
+
+	// Pulling library.
+	if err = pull.Pull(ctx, &pull.PullOptions{}, args...); err != nil {
+		return err
+	}
+
 	packs, err := packageManager.Catalog(ctx,
 		packmanager.WithName(packName),
 		packmanager.WithTypes(unikraft.ComponentTypeLib),
@@ -170,5 +206,5 @@ func (opts *AddOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	return project.Save(ctx)
+	return app.SetKraftfileLibrary(project.Kraftfile(), library.Name(), library.Version(), kconfig)
 }
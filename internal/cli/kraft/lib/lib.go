@@ -14,6 +14,7 @@ import (
 	"kraftkit.sh/internal/cli/kraft/lib/add"
 	"kraftkit.sh/internal/cli/kraft/lib/create"
 	"kraftkit.sh/internal/cli/kraft/lib/remove"
+	"kraftkit.sh/internal/cli/kraft/lib/update"
 )
 
 type LibOptions struct{}
@@ -40,6 +41,7 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(remove.NewCmd())
 	cmd.AddCommand(add.NewCmd())
 	cmd.AddCommand(create.NewCmd())
+	cmd.AddCommand(update.NewCmd())
 
 	return cmd
 }
@@ -2,17 +2,22 @@ package remove
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/app"
 )
 
 type RemoveOptions struct {
 	Kraftfile string `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Unused    bool   `long:"unused" usage:"Remove the sources of libraries which are no longer used by the project"`
 	Workdir   string `long:"workdir" short:"w" usage:"workdir to remove lib from"`
 }
 
@@ -28,15 +33,21 @@ func Remove(ctx context.Context, opts *RemoveOptions, args ...string) error {
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&RemoveOptions{}, cobra.Command{
 		Short:   "Removes a library dependency from the project directory",
-		Use:     "remove [FLAGS] LIB",
+		Use:     "remove [FLAGS] [LIB]",
 		Aliases: []string{"rm", "delete", "del"},
-		Args:    cmdfactory.MinimumArgs(1, "library name is not specified to remove from the project"),
+		Args:    cobra.MaximumNArgs(1),
 		Long: heredoc.Doc(`
 			Remove a Unikraft library from the project directory.
+
+			The library is removed from the Kraftfile whilst preserving its comments
+			and formatting.
 		`),
 		Example: heredoc.Doc(`
 			# Remove a library from the project
 			$ kraft lib remove libfoo
+
+			# Remove the sources of libraries no longer listed in the Kraftfile
+			$ kraft lib remove --unused
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "lib",
@@ -49,7 +60,11 @@ func NewCmd() *cobra.Command {
 	return cmd
 }
 
-func (opts *RemoveOptions) Pre(cmd *cobra.Command, _ []string) error {
+func (opts *RemoveOptions) Pre(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && !opts.Unused {
+		return fmt.Errorf("library name is not specified to remove from the project")
+	}
+
 	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
 	if err != nil {
 		return err
@@ -89,9 +104,51 @@ func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	if err = project.RemoveLibrary(ctx, args[0]); err != nil {
+	if len(args) > 0 {
+		if err = project.RemoveLibrary(ctx, args[0]); err != nil {
+			return err
+		}
+	}
+
+	if opts.Unused {
+		return removeUnused(ctx, project, workdir)
+	}
+
+	return nil
+}
+
+// removeUnused removes the directories of libraries which have been pulled
+// into the project but are no longer referenced by it.
+func removeUnused(ctx context.Context, project app.Application, workdir string) error {
+	libraries, err := project.Libraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	libsDir := filepath.Join(workdir, unikraft.LibsDir)
+
+	entries, err := os.ReadDir(libsDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
 		return err
 	}
 
-	return project.Save(ctx)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		if _, ok := libraries[entry.Name()]; ok {
+			continue
+		}
+
+		log.G(ctx).WithField("lib", entry.Name()).Info("removing unused library")
+
+		if err := os.RemoveAll(filepath.Join(libsDir, entry.Name())); err != nil {
+			return fmt.Errorf("could not remove library %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package update
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/log"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft/app"
+)
+
+type UpdateOptions struct {
+	Kraftfile string `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	NoUpdate  bool   `long:"no-update" usage:"Do not update package index before resolving versions"`
+	Workdir   string `long:"workdir" short:"w" usage:"workdir of the project to update"`
+}
+
+// Update the versions of the libraries of a project in its Kraftfile.
+func Update(ctx context.Context, opts *UpdateOptions, args ...string) error {
+	if opts == nil {
+		opts = &UpdateOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&UpdateOptions{}, cobra.Command{
+		Short:   "Update the library dependencies of the project",
+		Use:     "update [FLAGS] [LIB...]",
+		Aliases: []string{"upgrade"},
+		Long: heredoc.Doc(`
+			Update the libraries of the project to the versions compatible with its
+			Unikraft core.
+
+			Only the versions of the libraries in the Kraftfile are changed, such
+			that comments and formatting are preserved.
+		`),
+		Example: heredoc.Doc(`
+			# Update all libraries of the project
+			$ kraft lib update
+
+			# Update a specific library of the project
+			$ kraft lib update musl
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "lib",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *UpdateOptions) Pre(cmd *cobra.Command, _ []string) error {
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+	cmd.SetContext(ctx)
+	return nil
+}
+
+func (opts *UpdateOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	workdir := opts.Workdir
+	if workdir == "" {
+		workdir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	popts := []app.ProjectOption{}
+	if len(opts.Kraftfile) > 0 {
+		popts = append(popts, app.WithProjectKraftfile(opts.Kraftfile))
+	} else {
+		popts = append(popts, app.WithProjectDefaultKraftfiles())
+	}
+
+	project, err := app.NewProjectFromOptions(
+		ctx,
+		append(popts, app.WithProjectWorkdir(workdir))...,
+	)
+	if err != nil {
+		return err
+	}
+
+	libraries, err := app.KraftfileLibraries(project.Kraftfile())
+	if err != nil {
+		return err
+	}
+
+	names := args
+	if len(names) == 0 {
+		for name := range libraries {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+	}
+
+	if !opts.NoUpdate && len(names) > 0 {
+		if err := packmanager.G(ctx).Update(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		current, ok := libraries[name]
+		if !ok {
+			return fmt.Errorf("library %s does not exist in the Kraftfile", name)
+		}
+
+		version, err := utils.ResolveLibraryVersion(ctx, project, name, false)
+		if err != nil {
+			return err
+		}
+
+		if version == current {
+			log.G(ctx).WithField("lib", name).Debug("library is up-to-date")
+			continue
+		}
+
+		if err := app.SetKraftfileLibrary(project.Kraftfile(), name, version, nil); err != nil {
+			return err
+		}
+
+		log.G(ctx).
+			WithField("lib", name).
+			WithField("from", current).
+			WithField("to", version).
+			Info("updated library")
+	}
+
+	return nil
+}
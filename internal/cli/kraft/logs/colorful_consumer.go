@@ -6,6 +6,7 @@ package logs
 
 import (
 	"fmt"
+	"sync"
 
 	rainbow "kraftkit.sh/internal/rainbowprint"
 
@@ -16,6 +17,11 @@ type LogConsumer interface {
 	Consume(line ...string)
 }
 
+// consumeMu serializes the output of consumers, such that lines of machines
+// which are logged simultaneously are not interleaved mid-line and batches of
+// lines are printed contiguously.
+var consumeMu sync.Mutex
+
 type ColorfulConsumer struct {
 	streams *iostreams.IOStreams
 	color   rainbow.ColorFunc
@@ -41,6 +47,9 @@ func NewColorfulConsumer(streams *iostreams.IOStreams, color bool, prefix string
 
 // Consume implements logConsumer
 func (c *ColorfulConsumer) Consume(strs ...string) {
+	consumeMu.Lock()
+	defer consumeMu.Unlock()
+
 	for _, s := range strs {
 		if c.prefix != "" {
 			s = fmt.Sprintf("%s | %s", c.color(c.prefix), s)
@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LineFilter selects the lines of the logs of a machine which match a regular
+// expression and optionally extracts fields from those which are JSON objects.
+// Lines are filtered before they are prefixed with the name of their machine,
+// such that the prefixes stay aligned.
+type LineFilter struct {
+	grep   *regexp.Regexp
+	fields [][]selectorStep
+}
+
+// selectorStep is either the key of an object or the index of an array.
+type selectorStep struct {
+	key   string
+	index int
+}
+
+// NewLineFilter returns a filter which keeps the lines matching the regular
+// expression, if any, and replaces each with the values at the jq-like paths
+// of the selectors, e.g. `.level` or `.http.headers[0]`, if any.  The
+// values are separated by a space, where strings are printed as-is and other
+// values as JSON.  It returns nil if neither is provided.
+func NewLineFilter(grep string, selectors []string) (*LineFilter, error) {
+	if grep == "" && len(selectors) == 0 {
+		return nil, nil
+	}
+
+	filter := &LineFilter{}
+
+	if grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+
+		filter.grep = re
+	}
+
+	for _, selector := range selectors {
+		steps, err := parseSelector(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+
+		filter.fields = append(filter.fields, steps)
+	}
+
+	return filter, nil
+}
+
+// parseSelector parses a jq-like path, which is a sequence of `.key`,
+// `."quoted key"` and `[index]` steps, or `.` for the whole value.
+func parseSelector(selector string) ([]selectorStep, error) {
+	if !strings.HasPrefix(selector, ".") {
+		return nil, fmt.Errorf("must start with '.'")
+	}
+
+	steps := []selectorStep{}
+	rest := selector
+
+	if rest == "." {
+		return steps, nil
+	}
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.Index(rest[1:], `"`)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated quoted key")
+				}
+
+				steps = append(steps, selectorStep{key: rest[1 : end+1], index: -1})
+				rest = rest[end+2:]
+				continue
+			}
+
+			// An index may directly follow the dot, e.g. `.[0]`.
+			if strings.HasPrefix(rest, "[") {
+				continue
+			}
+
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			if end == 0 {
+				return nil, fmt.Errorf("empty key")
+			}
+
+			steps = append(steps, selectorStep{key: rest[:end], index: -1})
+			rest = rest[end:]
+
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index")
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("index must be a non-negative integer: %s", rest[1:end])
+			}
+
+			steps = append(steps, selectorStep{index: index})
+			rest = rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("unexpected character %q", rest[0])
+		}
+	}
+
+	return steps, nil
+}
+
+// Apply returns the line as it should be printed and whether it should be
+// printed at all.  When fields are selected, lines which are not JSON objects
+// are dropped and missing fields are printed as null, like jq does.
+func (filter *LineFilter) Apply(line string) (string, bool) {
+	if filter == nil {
+		return line, true
+	}
+
+	if filter.grep != nil && !filter.grep.MatchString(line) {
+		return "", false
+	}
+
+	if len(filter.fields) == 0 {
+		return line, true
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(line), &doc); err != nil {
+		return "", false
+	}
+
+	values := make([]string, len(filter.fields))
+	for i, steps := range filter.fields {
+		values[i] = formatValue(lookup(doc, steps))
+	}
+
+	return strings.Join(values, " "), true
+}
+
+// lookup returns the value at the path of the steps, or nil if there is none.
+func lookup(value any, steps []selectorStep) any {
+	for _, step := range steps {
+		switch v := value.(type) {
+		case map[string]any:
+			if step.index >= 0 {
+				return nil
+			}
+
+			value = v[step.key]
+
+		case []any:
+			if step.index < 0 || step.index >= len(v) {
+				return nil
+			}
+
+			value = v[step.index]
+
+		default:
+			return nil
+		}
+	}
+
+	return value
+}
+
+// formatValue formats strings as-is and any other value as JSON.
+func formatValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "null"
+	}
+
+	return string(b)
+}
+
+// filteringConsumer passes the lines which the filter keeps on to the next
+// consumer.
+type filteringConsumer struct {
+	next   LogConsumer
+	filter *LineFilter
+}
+
+// Consume implements LogConsumer
+func (c *filteringConsumer) Consume(lines ...string) {
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line, ok := c.filter.Apply(line); ok {
+			kept = append(kept, line)
+		}
+	}
+
+	if len(kept) > 0 {
+		c.next.Consume(kept...)
+	}
+}
+
+// NewFilteringConsumer returns a consumer which passes the lines which the
+// filter keeps on to the provided consumer, or the consumer itself if the
+// filter is nil.
+func NewFilteringConsumer(consumer LogConsumer, filter *LineFilter) LogConsumer {
+	if filter == nil {
+		return consumer
+	}
+
+	return &filteringConsumer{next: consumer, filter: filter}
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package logs
+
+import (
+	"testing"
+)
+
+func TestLineFilter(t *testing.T) {
+	line := `{"level":"error","msg":"request failed","http":{"status":502,"headers":["a","b"]},"a.b":true}`
+
+	tests := []struct {
+		grep      string
+		selectors []string
+		line      string
+		want      string
+		wantOk    bool
+	}{
+		{"", nil, "anything", "anything", true},
+		{"(?i)error", nil, "ERROR: boot", "ERROR: boot", true},
+		{"(?i)error", nil, "booted", "", false},
+		{"", []string{".level", ".msg"}, line, "error request failed", true},
+		{"", []string{".http.status"}, line, "502", true},
+		{"", []string{".http.headers[1]"}, line, "b", true},
+		{"", []string{".http.headers.[0]"}, line, "a", true},
+		{"", []string{`."a.b"`}, line, "true", true},
+		{"", []string{".missing", ".http.headers[5]"}, line, "null null", true},
+		{"", []string{".http"}, line, `{"headers":["a","b"],"status":502}`, true},
+		{"", []string{".level"}, "not json", "", false},
+		{"warn", []string{".level"}, line, "", false},
+	}
+
+	for _, tt := range tests {
+		filter, err := NewLineFilter(tt.grep, tt.selectors)
+		if err != nil {
+			t.Fatalf("NewLineFilter(%q, %q): %v", tt.grep, tt.selectors, err)
+		}
+
+		got, ok := filter.Apply(tt.line)
+		if ok != tt.wantOk || got != tt.want {
+			t.Errorf("Apply(%q) with %q and %q: got %q, %v, want %q, %v", tt.line, tt.grep, tt.selectors, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestNewLineFilterInvalid(t *testing.T) {
+	tests := []struct {
+		grep      string
+		selectors []string
+	}{
+		{"(", nil},
+		{"", []string{"level"}},
+		{"", []string{".a..b"}},
+		{"", []string{".a[x]"}},
+		{"", []string{".a[0"}},
+		{"", []string{`."a`}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewLineFilter(tt.grep, tt.selectors); err == nil {
+			t.Errorf("NewLineFilter(%q, %q): expected error", tt.grep, tt.selectors)
+		}
+	}
+}
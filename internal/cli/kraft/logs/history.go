@@ -0,0 +1,296 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package logs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/internal/crilog"
+	"kraftkit.sh/internal/logrotate"
+)
+
+// ParseTime parses either an RFC3339 timestamp or a duration, which is taken
+// relative to the provided time.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC3339 timestamp or a duration: %s", value)
+	}
+
+	return now.Add(-d), nil
+}
+
+// needsTimestamps returns whether the options can only be satisfied with the
+// timestamped CRI log of a machine.
+func (opts *LogOptions) needsTimestamps() bool {
+	return opts.Timestamps || !opts.since.IsZero() || !opts.until.IsZero()
+}
+
+// history consumes the existing logs of a machine across all its rotated log
+// files according to the provided options.
+func (opts *LogOptions) history(machine *machineapi.Machine, consumer LogConsumer) error {
+	if opts.needsTimestamps() {
+		return opts.criHistory(machine, consumer)
+	}
+
+	files, err := logrotate.Files(machine.Status.LogFile)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("could not find logs of %s", machine.Name)
+	}
+
+	// Unless the lines are filtered, the last of them are read from the end of
+	// the files, whereas otherwise they are the last which remain after
+	// filtering, which requires reading all of them.
+	if opts.Tail >= 0 && opts.filter == nil {
+		lines, err := logrotate.Tail(files, opts.Tail)
+		if err != nil {
+			return err
+		}
+
+		consumer.Consume(lines...)
+		return nil
+	}
+
+	var lines []string
+	for _, file := range files {
+		if err := readLines(file, func(line string) error {
+			line, ok := opts.filter.Apply(line)
+			if !ok {
+				return nil
+			}
+
+			if opts.Tail < 0 {
+				consumer.Consume(line)
+				return nil
+			}
+
+			lines = append(lines, line)
+			if len(lines) > opts.Tail {
+				lines = lines[1:]
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	consumer.Consume(lines...)
+
+	return nil
+}
+
+// criHistory consumes the existing logs of a machine from its CRI or JSON log
+// files, which record the time each line was written.
+func (opts *LogOptions) criHistory(machine *machineapi.Machine, consumer LogConsumer) error {
+	lines, err := opts.criLines(machine)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		consumer.Consume(line.content)
+	}
+
+	return nil
+}
+
+// timedLine is a line of the logs of a machine and the time it was written.
+type timedLine struct {
+	time    time.Time
+	content string
+}
+
+// criLines returns the existing lines of the logs of a machine from its CRI or
+// JSON log files according to the provided options.  Rotated files which were last
+// modified before the start of the requested period are skipped entirely and
+// reading stops at the first line after its end.
+func (opts *LogOptions) criLines(machine *machineapi.Machine) ([]timedLine, error) {
+	files, parse, err := timestampedLog(machine)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("could not find timestamped logs of %s: run machines with --log-cri or --log-driver json-file to record them", machine.Name)
+	}
+
+	var lines []timedLine
+	emit := func(line timedLine) {
+		lines = append(lines, line)
+		if opts.Tail >= 0 && len(lines) > opts.Tail {
+			lines = lines[len(lines)-opts.Tail:]
+		}
+	}
+
+	// Lines which were split are only filtered and emitted once complete, with
+	// the time of their first chunk.
+	var partial strings.Builder
+	var partialTime time.Time
+
+	errDone := errors.New("done")
+
+	for _, file := range files {
+		if !opts.since.IsZero() {
+			if fi, err := os.Stat(file); err == nil && fi.ModTime().Before(opts.since) {
+				continue
+			}
+		}
+
+		err := readLines(file, func(line string) error {
+			entry, err := parse(line)
+			if err != nil {
+				return err
+			}
+
+			if partialTime.IsZero() {
+				partialTime = entry.Time
+			}
+
+			partial.WriteString(entry.Content)
+			if entry.Tag == crilog.TagPartial {
+				return nil
+			}
+
+			content := partial.String()
+			ts := partialTime
+			partial.Reset()
+			partialTime = time.Time{}
+
+			if !opts.until.IsZero() && ts.After(opts.until) {
+				return errDone
+			}
+
+			if !opts.since.IsZero() && ts.Before(opts.since) {
+				return nil
+			}
+
+			// Only lines which remain after filtering count towards the tail.
+			content, ok := opts.filter.Apply(content)
+			if !ok {
+				return nil
+			}
+
+			if opts.Timestamps {
+				content = ts.Format(time.RFC3339Nano) + " " + content
+			}
+
+			emit(timedLine{time: ts, content: content})
+
+			return nil
+		})
+		if errors.Is(err, errDone) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", file, err)
+		}
+	}
+
+	return lines, nil
+}
+
+// timestampedLog returns the files of the log of the machine which records the
+// time each line was written, preferring the CRI log over the JSON log, and
+// the parser of its lines.
+func timestampedLog(machine *machineapi.Machine) ([]string, func(string) (crilog.Entry, error), error) {
+	files, err := logrotate.Files(filepath.Join(machine.Status.StateDir, crilog.Filename))
+	if err != nil || len(files) > 0 {
+		return files, crilog.ParseLine, err
+	}
+
+	files, err = logrotate.Files(filepath.Join(machine.Status.StateDir, crilog.JSONFilename))
+	return files, crilog.ParseJSONLine, err
+}
+
+// hasTimestampedLog returns whether the time each line of the logs of the
+// machine was written has been recorded.
+func hasTimestampedLog(machine *machineapi.Machine) bool {
+	files, _, err := timestampedLog(machine)
+	return err == nil && len(files) > 0
+}
+
+// interleavedHistory consumes the existing logs of multiple machines from
+// their CRI or JSON log files, ordered by the time each line was written across all
+// machines.  Lines which were written at the same time retain the order of the
+// machines.
+func (opts *LogOptions) interleavedHistory(machines []*machineapi.Machine, consumers []LogConsumer) error {
+	lines := make([][]timedLine, len(machines))
+
+	for i, machine := range machines {
+		var err error
+		if lines[i], err = opts.criLines(machine); err != nil {
+			return err
+		}
+	}
+
+	for {
+		next := -1
+		for i := range lines {
+			if len(lines[i]) == 0 {
+				continue
+			}
+
+			if next < 0 || lines[i][0].time.Before(lines[next][0].time) {
+				next = i
+			}
+		}
+
+		if next < 0 {
+			return nil
+		}
+
+		consumers[next].Consume(lines[next][0].content)
+		lines[next] = lines[next][1:]
+	}
+}
+
+// readLines calls the provided function with each line of the file at the
+// provided path until it returns an error.
+func readLines(path string, fn func(string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			if ferr := fn(line); ferr != nil {
+				return ferr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
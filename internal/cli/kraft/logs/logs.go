@@ -5,13 +5,13 @@
 package logs
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -19,6 +19,9 @@ import (
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/internal/logrotate"
 	"kraftkit.sh/internal/waitgroup"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
@@ -26,19 +29,56 @@ import (
 )
 
 type LogOptions struct {
-	Follow   bool   `long:"follow" short:"f" usage:"Follow log output"`
-	Platform string `noattribute:"true"`
-	NoPrefix bool   `long:"no-prefix" usage:"When logging multiple machines, do not prefix each log line with the name"`
+	All        bool     `long:"all" short:"a" usage:"Fetch the logs of all machines"`
+	Follow     bool     `long:"follow" short:"f" usage:"Follow log output"`
+	Grep       string   `long:"grep" usage:"Only show lines matching the regular expression"`
+	Platform   string   `noattribute:"true"`
+	NoPrefix   bool     `long:"no-prefix" usage:"When logging multiple machines, do not prefix each log line with the name"`
+	Select     []string `long:"select" usage:"Only show the fields of JSON lines at the provided jq-like paths (e.g. .level,.msg)"`
+	Since      string   `long:"since" usage:"Show logs since a timestamp (e.g. 2024-01-02T13:23:37Z) or relative duration (e.g. 42m)"`
+	Tail       int      `long:"tail" short:"n" usage:"Number of lines to show from the end of the logs (-1 shows all lines)" default:"-1"`
+	Timestamps bool     `long:"timestamps" short:"t" usage:"Show the time each line was written"`
+	Until      string   `long:"until" usage:"Show logs before a timestamp (e.g. 2024-01-02T13:23:37Z) or relative duration (e.g. 42m)"`
+
+	filter *LineFilter
+	prompt bool
+	since  time.Time
+	until  time.Time
 }
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&LogOptions{}, cobra.Command{
-		Short:   "Fetch the logs of a unikernel",
-		Use:     "logs [FLAGS] MACHINE",
-		Args:    cobra.MinimumNArgs(1),
-		Aliases: []string{"log"},
+		Short:             "Fetch the logs of a unikernel",
+		Use:               "logs [FLAGS] [MACHINE [MACHINE [...]]]",
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completion.Machines,
+		Aliases:           []string{"log"},
 		Long: heredoc.Doc(`
 			Fetch the logs of a unikernel.
+
+			The console output of a machine is rotated once it exceeds the size set
+			by --log-max-size, keeping the number of files set by --log-max-files,
+			and the logs are read across all of these files.
+
+			The --since, --until and --timestamps flags rely on the time at which each
+			line was written, which is only recorded when machines are run with
+			--log-cri or --log-driver json-file.
+
+			When fetching the logs of multiple machines, each line is prefixed with
+			the name of its machine.  If the time each line was written has been
+			recorded for all of them, the existing lines are ordered by it across
+			machines, and followed lines are printed as they are written.
+
+			Lines can be filtered with --grep, which keeps those matching a regular
+			expression, and --select, which replaces lines holding a JSON object
+			with the values at jq-like paths, e.g. .level or .http.status, and drops
+			the others.  Both are applied before lines are prefixed with the name
+			of their machine.  Of the existing lines, --tail shows the last which
+			remain after filtering, except when following, where it counts lines
+			before they are filtered.
+
+			If no machine is provided, the machine can be selected interactively,
+			unless prompting is disabled via --no-prompt.
 		`),
 		Example: heredoc.Doc(`
 			# Fetch the logs of a unikernel
@@ -49,6 +89,21 @@ func NewCmd() *cobra.Command {
 
 			# Fetch the logs of multiple unikernels and follow the output
 			$ kraft logs --follow my-machine1 my-machine2
+
+			# Follow the logs of all unikernels
+			$ kraft logs --follow --all
+
+			# Fetch the last 100 lines of the logs of a unikernel
+			$ kraft logs --tail 100 my-machine
+
+			# Fetch the logs of the last hour of a unikernel with timestamps
+			$ kraft logs --since 1h --timestamps my-machine
+
+			# Follow the lines of all unikernels which mention an error
+			$ kraft logs --follow --all --grep '(?i)error'
+
+			# Show the level and message of the JSON lines of a unikernel
+			$ kraft logs --select .level,.msg my-machine
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
@@ -71,9 +126,36 @@ func NewCmd() *cobra.Command {
 	return cmd
 }
 
-func (opts *LogOptions) Pre(cmd *cobra.Command, _ []string) error {
+func (opts *LogOptions) Pre(cmd *cobra.Command, args []string) error {
+	var err error
+
+	if opts.All && len(args) > 0 {
+		return fmt.Errorf("cannot provide machines when using --all")
+	}
+
+	// Only prompt for the machine when invoked from the command-line.
+	opts.prompt = len(args) == 0 && !opts.All
+
 	opts.Platform = cmd.Flag("plat").Value.String()
 
+	now := time.Now()
+
+	if opts.since, err = ParseTime(opts.Since, now); err != nil {
+		return fmt.Errorf("parsing --since: %w", err)
+	}
+
+	if opts.until, err = ParseTime(opts.Until, now); err != nil {
+		return fmt.Errorf("parsing --until: %w", err)
+	}
+
+	if opts.Follow && opts.needsTimestamps() {
+		return fmt.Errorf("--since, --until and --timestamps cannot be combined with --follow")
+	}
+
+	if opts.filter, err = NewLineFilter(opts.Grep, opts.Select); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -117,6 +199,24 @@ func (opts *LogOptions) Run(ctx context.Context, args []string) error {
 
 	loggedMachines := []*machineapi.Machine{}
 
+	if opts.prompt {
+		machine, err := utils.PromptMachine(ctx, "select machine to fetch the logs of", machines.Items)
+		if err != nil {
+			return err
+		}
+
+		args = []string{machine.Name}
+	} else if opts.All {
+		args = []string{}
+		for _, machine := range machines.Items {
+			args = append(args, machine.Name)
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("no machines to fetch the logs of")
+		}
+	}
+
 	// Although this looks duplicated, it allows us to check whether all arguments
 	// are a valid machine while also not having duplicated logging in case of
 	// multiple equal arguments (or both the name and UID).
@@ -155,18 +255,49 @@ func (opts *LogOptions) Run(ctx context.Context, args []string) error {
 		opts.NoPrefix = true
 	}
 
-	var errGroup []error
-	observations := waitgroup.WaitGroup[*machineapi.Machine]{}
-
-	for _, machine := range loggedMachines {
+	consumers := make([]LogConsumer, len(loggedMachines))
+	for i, machine := range loggedMachines {
 		prefix := ""
 		if !opts.NoPrefix {
 			prefix = machine.Name + strings.Repeat(" ", longestName-len(machine.Name))
 		}
-		consumer, err := NewColorfulConsumer(iostreams.G(ctx), !config.G[config.KraftKit](ctx).NoColor, prefix)
+
+		consumers[i], err = NewColorfulConsumer(iostreams.G(ctx), !config.G[config.KraftKit](ctx).NoColor, prefix)
 		if err != nil {
-			errGroup = append(errGroup, err)
+			return err
+		}
+	}
+
+	// Existing lines can only be ordered across machines by the time they were
+	// written, so they are otherwise printed one machine after another.
+	if !opts.Follow && len(loggedMachines) > 1 {
+		interleave := true
+		for _, machine := range loggedMachines {
+			if !hasTimestampedLog(machine) {
+				interleave = false
+				break
+			}
+		}
+
+		if interleave {
+			return opts.interleavedHistory(loggedMachines, consumers)
 		}
+	}
+
+	var mu sync.Mutex
+	var errGroup []error
+	observations := waitgroup.WaitGroup[*machineapi.Machine]{}
+
+	for i, machine := range loggedMachines {
+		consumer := consumers[i]
+
+		if machine.Spec.Logging.Driver == machineapi.MachineLogDriverNone {
+			mu.Lock()
+			errGroup = append(errGroup, fmt.Errorf("the output of %s is discarded by its log driver", machine.Name))
+			mu.Unlock()
+			continue
+		}
+
 		if opts.Follow && machine.Status.State == machineapi.MachineStateRunning {
 			observations.Add(machine)
 			go func(machine *machineapi.Machine) {
@@ -174,28 +305,16 @@ func (opts *LogOptions) Run(ctx context.Context, args []string) error {
 					observations.Done(machine)
 				}()
 
-				if err = FollowLogs(ctx, machine, controller, consumer); err != nil {
+				if err := FollowLogs(ctx, machine, controller, NewFilteringConsumer(consumer, opts.filter), opts.Tail); err != nil {
+					mu.Lock()
 					errGroup = append(errGroup, err)
-					return
+					mu.Unlock()
 				}
 			}(machine)
-		} else {
-			fd, err := os.Open(machine.Status.LogFile)
-			if err != nil {
-				return err
-			}
-			defer fd.Close()
-
-			if prefix == "" {
-				if _, err := io.Copy(iostreams.G(ctx).Out, fd); err != nil {
-					errGroup = append(errGroup, err)
-				}
-			} else {
-				scanner := bufio.NewScanner(fd)
-				for scanner.Scan() {
-					consumer.Consume(scanner.Text())
-				}
-			}
+		} else if err := opts.history(machine, consumer); err != nil {
+			mu.Lock()
+			errGroup = append(errGroup, err)
+			mu.Unlock()
 		}
 	}
 
@@ -205,7 +324,8 @@ func (opts *LogOptions) Run(ctx context.Context, args []string) error {
 }
 
 // FollowLogs tracks the logs generated by a machine and prints them to the context out stream.
-func FollowLogs(ctx context.Context, machine *machineapi.Machine, controller machineapi.MachineService, consumer LogConsumer) error {
+// Of the lines which already exist, only the last tail lines are printed, unless tail is negative.
+func FollowLogs(ctx context.Context, machine *machineapi.Machine, controller machineapi.MachineService, consumer LogConsumer, tail int) error {
 	ctx, cancel := context.WithCancel(ctx)
 
 	var exitErr error
@@ -272,21 +392,76 @@ func FollowLogs(ctx context.Context, machine *machineapi.Machine, controller mac
 		}
 	}()
 
+	// The log only follows the current log file, so lines of rotated files are
+	// printed beforehand.
+	files, err := logrotate.Files(machine.Status.LogFile)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("accessing logs: %w", err)
+	}
+
+	var rotated []string
+	if len(files) > 0 && files[len(files)-1] == machine.Status.LogFile {
+		rotated = files[:len(files)-1]
+	}
+
+	if tail < 0 {
+		for _, file := range rotated {
+			if err := readLines(file, func(line string) error {
+				consumer.Consume(line)
+				return nil
+			}); err != nil {
+				cancel()
+				return fmt.Errorf("accessing logs: %w", err)
+			}
+		}
+	}
+
 	logs, errs, err := controller.Logs(ctx, machine)
 	if err != nil {
 		cancel()
 		return fmt.Errorf("accessing logs: %w", err)
 	}
 
+	// Existing lines are buffered until the end of the log is first reached
+	// such that only the last of them are printed.
+	var buffered []string
+	buffering := tail >= 0
+
 loop:
 	for {
 		// Wait on either channel
 		select {
 		case line := <-logs:
-			consumer.Consume(line)
+			if !buffering {
+				consumer.Consume(line)
+				continue
+			}
+
+			buffered = append(buffered, line)
+			if len(buffered) > tail {
+				buffered = buffered[len(buffered)-tail:]
+			}
 
 		case err := <-errs:
 			eof = true
+
+			if buffering {
+				if len(buffered) < tail && len(rotated) > 0 {
+					older, terr := logrotate.Tail(rotated, tail-len(buffered))
+					if terr != nil {
+						cancel()
+						return fmt.Errorf("accessing logs: %w", terr)
+					}
+
+					buffered = append(older, buffered...)
+				}
+
+				consumer.Consume(buffered...)
+				buffered = nil
+				buffering = false
+			}
+
 			if !errors.Is(err, io.EOF) {
 				log.G(ctx).Errorf("received event error: %v", err)
 				return fmt.Errorf("event: %w", err)
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package machine
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/machine/qmp"
+)
+
+type MachineOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&MachineOptions{}, cobra.Command{
+		Short:   "Low-level operations on local machines",
+		Use:     "machine SUBCOMMAND",
+		Aliases: []string{"machines", "vm"},
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(qmp.NewCmd())
+
+	return cmd
+}
+
+func (opts *MachineOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
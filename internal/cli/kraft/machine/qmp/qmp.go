@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qmp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/iostreams"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/qemu"
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+)
+
+type QMPOptions struct {
+	Interactive bool `long:"interactive" short:"i" usage:"Read commands from standard input, one per line"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&QMPOptions{}, cobra.Command{
+		Short:             "Send raw QMP commands to a QEMU machine",
+		Use:               "qmp [FLAGS] MACHINE [COMMAND]",
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completion.Limit(1, completion.Machines),
+		Long: heredoc.Doc(`
+			Send raw QEMU Machine Protocol (QMP) commands to a running QEMU machine.
+
+			This is intended for debugging and for advanced operations which are not
+			otherwise offered by KraftKit.  Commands are sent as is, so they may leave
+			the machine in a state which KraftKit does not expect.
+
+			A command is either a JSON object with an "execute" key and optional
+			"arguments", or the name of a command which takes no arguments.  The
+			value returned by the command is printed as JSON.
+
+			With --interactive, commands are read from standard input, one per line,
+			until the end of the input (Ctrl+D).  A command which fails does not end
+			the session.
+		`),
+		Example: heredoc.Doc(`
+			# Query the status of a machine
+			$ kraft machine qmp my-machine query-status
+
+			# Send a command with arguments
+			$ kraft machine qmp my-machine '{"execute": "human-monitor-command", "arguments": {"command-line": "info mtree"}}'
+
+			# Send commands interactively
+			$ kraft machine qmp -i my-machine
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *QMPOptions) Pre(cmd *cobra.Command, args []string) error {
+	if opts.Interactive == (len(args) == 2) {
+		return fmt.Errorf("either provide a command or use --interactive")
+	}
+
+	return nil
+}
+
+func (opts *QMPOptions) Run(ctx context.Context, args []string) error {
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	var machine *machineapi.Machine
+	for _, candidate := range machines.Items {
+		if args[0] == candidate.Name || args[0] == string(candidate.UID) {
+			machine = &candidate
+			break
+		}
+	}
+
+	if machine == nil {
+		return fmt.Errorf("could not find machine %s", args[0])
+	}
+
+	if machine.Spec.Platform != mplatform.PlatformQEMU.String() {
+		return fmt.Errorf("machine %s runs on %s: QMP is only available on %s", machine.Name, machine.Spec.Platform, mplatform.PlatformQEMU)
+	}
+
+	switch machine.Status.State {
+	case machineapi.MachineStateRunning, machineapi.MachineStatePaused, machineapi.MachineStateSuspended:
+	default:
+		return fmt.Errorf("machine %s is %s", machine.Name, machine.Status.State)
+	}
+
+	client, err := qemu.NewQMPClient(ctx, machine)
+	if err != nil {
+		return fmt.Errorf("could not connect to QMP socket of %s: %w", machine.Name, err)
+	}
+
+	defer client.Close()
+
+	if !opts.Interactive {
+		return execute(ctx, client, args[1], iostreams.G(ctx).Out)
+	}
+
+	return interactive(ctx, client, iostreams.G(ctx))
+}
+
+// ParseCommand returns the QMP command represented by the provided input,
+// which is either a JSON object or the name of a command without arguments.
+func ParseCommand(input string) (json.RawMessage, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	if strings.HasPrefix(input, "{") {
+		if !json.Valid([]byte(input)) {
+			return nil, fmt.Errorf("invalid JSON: %s", input)
+		}
+
+		return json.RawMessage(input), nil
+	}
+
+	if strings.ContainsAny(input, " \t\"") {
+		return nil, fmt.Errorf("invalid command '%s': expected a JSON object or the name of a command", input)
+	}
+
+	return json.Marshal(map[string]string{"execute": input})
+}
+
+// execute sends the command and writes its indented result to out.
+func execute(ctx context.Context, client *qmpapi.QEMUMachineProtocolClient, input string, out io.Writer) error {
+	command, err := ParseCommand(input)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Execute(ctx, command)
+	if err != nil {
+		return err
+	}
+
+	if len(res) == 0 {
+		res = json.RawMessage("{}")
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, res, "", "  "); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "%s\n", buf.String())
+	return err
+}
+
+// interactive executes each line of the standard input as a command until it
+// is exhausted.
+func interactive(ctx context.Context, client *qmpapi.QEMUMachineProtocolClient, streams *iostreams.IOStreams) error {
+	prompt := func() {
+		if streams.IsStdinTTY() {
+			fmt.Fprint(streams.ErrOut, "qmp> ")
+		}
+	}
+
+	scanner := bufio.NewScanner(streams.In)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	prompt()
+
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			if err := execute(ctx, client, line, streams.Out); err != nil {
+				fmt.Fprintf(streams.ErrOut, "error: %v\n", err)
+			}
+		}
+
+		prompt()
+	}
+
+	if streams.IsStdinTTY() {
+		fmt.Fprintln(streams.ErrOut)
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qmp
+
+import "testing"
+
+func TestParseCommand(t *testing.T) {
+	for input, expected := range map[string]string{
+		"query-status":        `{"execute":"query-status"}`,
+		"  query-kvm\n":       `{"execute":"query-kvm"}`,
+		`{"execute": "stop"}`: `{"execute": "stop"}`,
+		`{"execute":"balloon","arguments":{"value":1}}`: `{"execute":"balloon","arguments":{"value":1}}`,
+	} {
+		command, err := ParseCommand(input)
+		if err != nil {
+			t.Errorf("%q: %v", input, err)
+			continue
+		}
+
+		if string(command) != expected {
+			t.Errorf("%q: expected %s, got %s", input, expected, command)
+		}
+	}
+
+	for _, input := range []string{"", "{broken", "query status", `"stop"`} {
+		if _, err := ParseCommand(input); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
@@ -285,7 +285,7 @@ func (opts *MenuOptions) pull(ctx context.Context, project app.Application, work
 				p, err := packmanager.G(ctx).Catalog(ctx,
 					packmanager.WithName(component.Name()),
 					packmanager.WithTypes(component.Type()),
-					packmanager.WithVersion(component.Version()),
+					packmanager.WithVersion(opts.project.LockedVersion(component)),
 					packmanager.WithSource(component.Source()),
 					packmanager.WithRemote(opts.NoCache),
 					packmanager.WithAuthConfig(auths),
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/unikraft/ukstore"
+)
+
+type MetricsOptions struct {
+	Interval time.Duration `long:"interval" usage:"Interval at which the metrics are refreshed with --watch" default:"2s"`
+	Output   string        `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table" enum:"table,yaml,json,list"`
+	Timeout  time.Duration `long:"timeout" usage:"Time to wait for the unikernel to respond" default:"5s"`
+	Watch    bool          `long:"watch" short:"w" usage:"Continuously refresh the metrics"`
+}
+
+// Metrics displays the ukstore counters of a local Unikraft virtual machine.
+func Metrics(ctx context.Context, opts *MetricsOptions, args ...string) error {
+	if opts == nil {
+		opts = &MetricsOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&MetricsOptions{}, cobra.Command{
+		Short:             "Display the internal counters of a unikernel",
+		Use:               "metrics [FLAGS] MACHINE [LIBRARY [LIBRARY [...]]]",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Machines),
+		Long: heredoc.Doc(`
+			Display the internal counters of a unikernel, giving visibility into its
+			scheduler, allocator, network stack and other libraries.
+
+			The counters are those which the libraries of the unikernel export
+			through ukstore, alongside the number of times each of its uktrace
+			tracepoints was hit.  They are read from the unikernel over a dedicated
+			virtio-serial port which is only attached to machines started with
+			'kraft run --metrics' and served by unikernels built with ukstore.
+
+			The counters of every library are displayed unless particular libraries
+			are provided.
+		`),
+		Example: heredoc.Doc(`
+			# Display the counters of a unikernel
+			$ kraft run --metrics --name my-machine unikraft.org/nginx:latest
+			$ kraft metrics my-machine
+
+			# Continuously display the counters of the allocator and scheduler
+			$ kraft metrics --watch my-machine ukalloc uksched
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *MetricsOptions) Run(ctx context.Context, args []string) error {
+	if opts.Watch && opts.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	var machine *machineapi.Machine
+
+	for i := range machines.Items {
+		if args[0] == machines.Items[i].Name || args[0] == string(machines.Items[i].UID) {
+			machine = &machines.Items[i]
+			break
+		}
+	}
+
+	if machine == nil {
+		return fmt.Errorf("machine not found: %s", args[0])
+	}
+
+	if machine.Status.MetricsSocket == "" {
+		return fmt.Errorf("machine %s was not started with metrics: use 'kraft run --metrics'", machine.Name)
+	}
+
+	if machine.Status.State != machineapi.MachineStateRunning {
+		return fmt.Errorf("machine %s is not running", machine.Name)
+	}
+
+	if !opts.Watch {
+		return opts.render(ctx, machine, args[1:])
+	}
+
+	iostreams.G(ctx).StartAlternateScreenBuffer()
+	defer iostreams.G(ctx).StopAlternateScreenBuffer()
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		iostreams.G(ctx).RefreshScreen()
+
+		if err := opts.render(ctx, machine, args[1:]); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// render queries the counters of the provided libraries of the machine and
+// prints them.
+func (opts *MetricsOptions) render(ctx context.Context, machine *machineapi.Machine, libraries []string) error {
+	entries, err := opts.query(ctx, machine, libraries)
+	if err != nil {
+		return err
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(opts.Output),
+	)
+	if err != nil {
+		return err
+	}
+
+	table.AddField("LIBRARY", cs.Bold)
+	table.AddField("ENTRY", cs.Bold)
+	table.AddField("TYPE", cs.Bold)
+	table.AddField("VALUE", cs.Bold)
+	table.EndRow()
+
+	for _, entry := range entries {
+		table.AddField(entry.Library, nil)
+		table.AddField(entry.Name, nil)
+		table.AddField(string(entry.Type), nil)
+		table.AddField(entry.Value, nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
+
+// query reads a snapshot of the counters of the provided libraries from the
+// guest of the machine.
+func (opts *MetricsOptions) query(ctx context.Context, machine *machineapi.Machine, libraries []string) ([]ukstore.Entry, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	conn, err := ukstore.Dial(ctx, machine.Status.MetricsSocket)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	entries, err := ukstore.Query(ctx, conn, libraries...)
+	if err != nil {
+		return nil, fmt.Errorf("could not read metrics of %s: %w", machine.Name, err)
+	}
+
+	return entries, nil
+}
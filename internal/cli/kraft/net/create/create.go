@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -16,13 +17,20 @@ import (
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/inspect"
 	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network"
 )
 
 type CreateOptions struct {
-	Driver  string `noattribute:"true"`
-	Network string `long:"network" short:"n" usage:"Set the gateway IP address and the subnet of the network in CIDR format."`
+	Driver       string   `noattribute:"true"`
+	DryRun       bool     `noattribute:"true"`
+	Gateway      string   `long:"gateway" short:"g" usage:"Set the gateway IP address of the network, which must be within its subnet"`
+	IPRange      string   `long:"ip-range" usage:"Only allocate IP addresses from this range within the subnet, in CIDR format"`
+	Network      string   `long:"network" short:"n" usage:"Set the gateway IP address and the subnet of the network in CIDR format."`
+	Reservations []string `long:"reserve" usage:"Reserve an IP address for a machine, in the format <machine>=<ip>"`
 }
 
 // Create a new local machine network.
@@ -40,10 +48,31 @@ func NewCmd() *cobra.Command {
 		Use:     "create [FLAGS] NETWORK",
 		Aliases: []string{"add"},
 		Args:    cobra.ExactArgs(1),
-		Long:    "Create a new machine network.",
+		Long: heredoc.Doc(`
+			Create a new machine network.
+
+			If no subnet is provided with --network, a free one is selected from the
+			ranges of the network.pools configuration, or from the default private
+			ranges, which does not overlap with any other network nor with the
+			routes and interface addresses of the host, e.g. those of a LAN or VPN.
+
+			Machines which are attached to the network without requesting a
+			specific address are allocated one from the subnet of the network, or
+			only from the range set with --ip-range.  Addresses reserved with
+			--reserve are never allocated to other machines and are always assigned
+			to the machine with the provided name, such that its address remains
+			the same when it is recreated.
+		`),
 		Example: heredoc.Doc(`
 			# Create a new machine network
 			$ kraft network create my-network --network 133.37.0.1/12
+
+			# Create a network whose gateway is the last address of the subnet
+			$ kraft network create my-network --network 172.30.0.0/24 --gateway 172.30.0.254
+
+			# Create a network which allocates addresses from a part of its subnet
+			# and always assigns 172.30.0.10 to the machine named db
+			$ kraft network create my-network --network 172.30.0.1/24 --ip-range 172.30.0.128/25 --reserve db=172.30.0.10
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "net",
@@ -75,17 +104,40 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if opts.Network == "" && (opts.Gateway != "" || opts.IPRange != "" || len(opts.Reservations) > 0) {
+		return fmt.Errorf("the --gateway, --ip-range and --reserve flags require --network")
+	}
+
 	if opts.Network == "" {
 		existingNetworks, err := controller.List(ctx, &networkapi.NetworkList{})
 		if err != nil {
 			return err
 		}
 
-		freeNetwork, err := network.FindFreeNetwork(network.DefaultNetworkPool, existingNetworks)
+		pool := network.NetworkPool(network.DefaultNetworkPool)
+		if pools := config.G[config.KraftKit](ctx).Network.Pools; len(pools) > 0 {
+			pool, err = network.ParseNetworkPool(pools)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Avoid the networks of the host, e.g. of its LAN or of a VPN, since the
+		// host could otherwise not reach either of them.
+		hostNetworks, err := network.HostNetworks()
+		if err != nil {
+			log.G(ctx).Warnf("could not determine the networks of the host: %v", err)
+		}
+
+		freeNetwork, err := network.FindFreeNetwork(pool, existingNetworks, hostNetworks...)
 		if err != nil {
 			return err
 		}
 
+		log.G(ctx).
+			WithField("network", freeNetwork.String()).
+			Debug("selected free network")
+
 		opts.Network = freeNetwork.String()
 	}
 
@@ -94,15 +146,49 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	if _, err := controller.Create(ctx, &networkapi.Network{
+	gateway := addr.IP
+	if opts.Gateway != "" {
+		gateway = net.ParseIP(opts.Gateway)
+		if gateway == nil {
+			return fmt.Errorf("invalid gateway: %s", opts.Gateway)
+		}
+
+		if !addr.IPNet.Contains(gateway) {
+			return fmt.Errorf("gateway %s is not within %s", opts.Gateway, opts.Network)
+		}
+	}
+
+	var reservations []networkapi.NetworkReservation
+	for _, reservation := range opts.Reservations {
+		machine, ip, ok := strings.Cut(reservation, "=")
+		if !ok || machine == "" || ip == "" {
+			return fmt.Errorf("invalid syntax for --reserve=%s expected --reserve=<machine>=<ip>", reservation)
+		}
+
+		reservations = append(reservations, networkapi.NetworkReservation{
+			Machine: machine,
+			IP:      ip,
+		})
+	}
+
+	newNetwork := &networkapi.Network{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: args[0],
 		},
 		Spec: networkapi.NetworkSpec{
-			Gateway: addr.IP.String(),
-			Netmask: net.IP(addr.Mask).String(),
+			Gateway:      gateway.String(),
+			Netmask:      net.IP(addr.Mask).String(),
+			IPRange:      opts.IPRange,
+			Reservations: reservations,
 		},
-	}); err != nil {
+	}
+
+	if opts.DryRun {
+		newNetwork.Spec.Driver = opts.Driver
+		return inspect.Print(ctx, "json", inspect.FromNetwork(newNetwork))
+	}
+
+	if _, err := controller.Create(ctx, newNetwork); err != nil {
 		return err
 	}
 
@@ -14,6 +14,7 @@ import (
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/network"
 )
@@ -33,11 +34,12 @@ func Down(ctx context.Context, opts *DownOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&DownOptions{}, cobra.Command{
-		Short:   "Bring a network offline",
-		Use:     "down",
-		Aliases: []string{"stop"},
-		Args:    cobra.ExactArgs(1),
-		Long:    "Bring a network offline.",
+		Short:             "Bring a network offline",
+		Use:               "down",
+		Aliases:           []string{"stop"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Networks),
+		Long:              "Bring a network offline.",
 		Example: heredoc.Doc(`
 			# Bring a network offline
 			$ kraft network down my-network
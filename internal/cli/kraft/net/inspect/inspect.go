@@ -15,6 +15,7 @@ import (
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/network"
 )
@@ -25,11 +26,12 @@ type InspectOptions struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&InspectOptions{}, cobra.Command{
-		Short:   "Inspect a machine network",
-		Use:     "inspect NETWORK",
-		Aliases: []string{"list"},
-		Args:    cobra.ExactArgs(1),
-		Long:    "Inspect a machine network.",
+		Short:             "Inspect a machine network",
+		Use:               "inspect NETWORK",
+		Aliases:           []string{"list"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Networks),
+		Long:              "Inspect a machine network.",
 		Example: heredoc.Doc(`
 			# Inspect a machine network
 			$ kraft network inspect my-network
@@ -14,7 +14,6 @@ import (
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/cmdfactory"
-	"kraftkit.sh/internal/cli/kraft/cloud/utils"
 	"kraftkit.sh/internal/tableprinter"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
@@ -22,9 +21,10 @@ import (
 )
 
 type ListOptions struct {
+	tableprinter.OutputOptions
+
 	Driver string `noattribute:"true"`
 	Long   bool   `long:"long" short:"l" usage:"Show more information"`
-	Output string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
 }
 
 func NewCmd() *cobra.Command {
@@ -60,8 +60,8 @@ func NewCmd() *cobra.Command {
 func (opts *ListOptions) Pre(cmd *cobra.Command, _ []string) error {
 	opts.Driver = cmd.Flag("driver").Value.String()
 
-	if !utils.IsValidOutputFormat(opts.Output) {
-		return fmt.Errorf("invalid output format: %s", opts.Output)
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
 	return nil
@@ -119,10 +119,7 @@ func (opts *ListOptions) Run(ctx context.Context, _ []string) error {
 
 	cs := iostreams.G(ctx).ColorScheme()
 
-	table, err := tableprinter.NewTablePrinter(ctx,
-		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
-		tableprinter.WithOutputFormatFromString(opts.Output),
-	)
+	table, err := opts.NewTablePrinter(ctx)
 	if err != nil {
 		return err
 	}
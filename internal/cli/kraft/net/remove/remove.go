@@ -15,6 +15,7 @@ import (
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network"
@@ -37,11 +38,12 @@ func Remove(ctx context.Context, opts *RemoveOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&RemoveOptions{}, cobra.Command{
-		Short:   "Remove a network",
-		Use:     "remove",
-		Aliases: []string{"rm", "delete", "del"},
-		Args:    cobra.ExactArgs(1),
-		Long:    "Remove a network.",
+		Short:             "Remove a network",
+		Use:               "remove",
+		Aliases:           []string{"rm", "delete", "del"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Networks),
+		Long:              "Remove a network.",
 		Example: heredoc.Doc(`
 			# Remove a network
 			$ kraft network remove my-network
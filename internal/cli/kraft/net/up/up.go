@@ -14,6 +14,7 @@ import (
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/network"
 )
@@ -33,11 +34,12 @@ func Up(ctx context.Context, opts *UpOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&UpOptions{}, cobra.Command{
-		Short:   "Bring a network online",
-		Use:     "up",
-		Aliases: []string{},
-		Args:    cobra.ExactArgs(1),
-		Long:    "Bring a network online.",
+		Short:             "Bring a network online",
+		Use:               "up",
+		Aliases:           []string{},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Networks),
+		Long:              "Bring a network online.",
 		Example: heredoc.Doc(`
 			# Bring a network online
 			$ kraft network up my-network
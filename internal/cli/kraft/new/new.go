@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package new
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/tui"
+	"kraftkit.sh/tui/confirm"
+	"kraftkit.sh/tui/selection"
+	"kraftkit.sh/tui/textinput"
+	"kraftkit.sh/unikraft/arch"
+)
+
+type NewOptions struct {
+	Architecture string `long:"arch" short:"m" usage:"Set the architecture of the project's target"`
+	Compose      bool   `long:"compose" usage:"Also create a compose file for the project"`
+	Force        bool   `long:"force" short:"f" usage:"Overwrite existing files"`
+	Name         string `long:"name" short:"n" usage:"Set the name of the project"`
+	Platform     string `long:"plat" short:"p" usage:"Set the platform of the project's target"`
+	Rootfs       string `long:"rootfs" usage:"Set how the root filesystem is provided (dockerfile, directory)"`
+	Runtime      string `long:"runtime" short:"r" usage:"Set the language of the project (c, go, rust, node, python)"`
+}
+
+// New scaffolds a new project.
+func New(ctx context.Context, opts *NewOptions, args ...string) error {
+	if opts == nil {
+		opts = &NewOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&NewOptions{}, cobra.Command{
+		Short: "Create a new project from a template",
+		Use:   "new [FLAGS] [DIR]",
+		Args:  cobra.MaximumNArgs(1),
+		Long: heredoc.Doc(`
+			Create a new project which runs on a runtime from the Unikraft catalog.
+
+			A Kraftfile, a list of files excluded from the build context, sources of
+			an example application and, optionally, a compose file are created in
+			the provided directory.  Options which are not set via flags are asked
+			for interactively.
+		`),
+		Example: heredoc.Doc(`
+			# Create a new project in the current directory interactively
+			$ kraft new
+
+			# Create a new Go project in the directory my-app
+			$ kraft new --runtime go my-app
+
+			# Create a new Python project whose rootfs is a directory
+			$ kraft new --runtime python --rootfs directory --plat fc --arch x86_64
+
+			# Create a new Node.js project together with a compose file
+			$ kraft new --runtime node --compose
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "build",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *NewOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	workdir := "."
+	if len(args) > 0 {
+		workdir = args[0]
+	}
+
+	workdir, err = filepath.Abs(workdir)
+	if err != nil {
+		return err
+	}
+
+	prompt := !config.G[config.KraftKit](ctx).NoPrompt && tui.IsInteractive()
+
+	if opts.Name == "" {
+		opts.Name = filepath.Base(workdir)
+
+		if prompt {
+			opts.Name, err = textinput.NewTextInput(
+				"Project name:",
+				"Project name cannot be empty",
+				opts.Name,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.Name == "" {
+		return fmt.Errorf("project name cannot be empty")
+	}
+
+	var rt *scaffoldRuntime
+	if opts.Runtime != "" {
+		rt, err = scaffoldRuntimeByName(opts.Runtime)
+		if err != nil {
+			return err
+		}
+	} else if prompt {
+		rt, err = selection.Select("select a runtime:", scaffoldRuntimes()...)
+		if err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("no runtime specified: use --runtime to select one")
+	}
+
+	if opts.Platform == "" {
+		opts.Platform = mplatform.PlatformQEMU.String()
+
+		if prompt {
+			plat, err := selection.Select("select a platform:", mplatform.Platforms()...)
+			if err != nil {
+				return err
+			}
+
+			opts.Platform = plat.String()
+		}
+	}
+
+	if _, ok := mplatform.PlatformsByName()[opts.Platform]; !ok {
+		return fmt.Errorf("unsupported platform '%s'", opts.Platform)
+	}
+
+	if opts.Architecture == "" {
+		opts.Architecture, err = arch.HostArchitecture()
+		if err != nil {
+			opts.Architecture = arch.ArchitectureX86_64.String()
+		}
+
+		if prompt {
+			architecture, err := selection.Select("select an architecture:", arch.Architectures()...)
+			if err != nil {
+				return err
+			}
+
+			opts.Architecture = architecture.String()
+		}
+	}
+
+	if arch.ArchitectureByName(opts.Architecture) == arch.ArchitectureUnknown {
+		return fmt.Errorf("unsupported architecture '%s'", opts.Architecture)
+	}
+
+	if opts.Rootfs == "" {
+		opts.Rootfs = rootfsDockerfile
+
+		// Only interpreted runtimes can have their sources placed directly in
+		// the root filesystem.
+		if prompt && rt.Interpreted {
+			strategy, err := selection.Select("select how the rootfs is provided:", rootfsStrategyOptions()...)
+			if err != nil {
+				return err
+			}
+
+			opts.Rootfs = string(*strategy)
+		}
+	}
+
+	if !opts.Compose && prompt {
+		opts.Compose, err = confirm.NewConfirm("Do you want to create a compose file:")
+		if err != nil {
+			return err
+		}
+	}
+
+	s, err := newScaffold(opts.Name, rt, opts.Rootfs, opts.Platform, opts.Architecture, opts.Compose)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(workdir, 0o755); err != nil {
+		return err
+	}
+
+	written, err := s.write(workdir, opts.Force)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(written)
+
+	for _, name := range written {
+		log.G(ctx).WithField("file", filepath.Join(workdir, name)).Info("created")
+	}
+
+	return nil
+}
+
+// rootfsStrategy is a rootfs strategy which can be selected interactively.
+type rootfsStrategy string
+
+// String implements fmt.Stringer
+func (strategy rootfsStrategy) String() string {
+	return string(strategy)
+}
+
+// rootfsStrategyOptions returns the rootfs strategies as selectable options.
+func rootfsStrategyOptions() []rootfsStrategy {
+	ret := []rootfsStrategy{}
+	for _, strategy := range rootfsStrategies() {
+		ret = append(ret, rootfsStrategy(strategy))
+	}
+
+	return ret
+}
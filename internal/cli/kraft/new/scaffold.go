@@ -0,0 +1,280 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package new
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var templates embed.FS
+
+const (
+	rootfsDockerfile = "dockerfile"
+	rootfsDirectory  = "directory"
+)
+
+// rootfsStrategies returns the supported ways of providing the root filesystem
+// of the project.
+func rootfsStrategies() []string {
+	return []string{
+		rootfsDockerfile,
+		rootfsDirectory,
+	}
+}
+
+// scaffoldRuntime describes how a project written in a given language is
+// packaged on top of a runtime from the Unikraft catalog.
+type scaffoldRuntime struct {
+	// Name is the identifier of the language, e.g. `go`.
+	Name string
+
+	// Description is the human-readable name shown when prompting.
+	Description string
+
+	// Runtime is the catalog entry which the project runs on.
+	Runtime string
+
+	// Cmd is the command which starts the application inside the unikernel.
+	Cmd []string
+
+	// Interpreted is set for languages whose sources are run as-is and can
+	// therefore be placed in the root filesystem without being compiled.
+	Interpreted bool
+}
+
+// String implements fmt.Stringer
+func (rt scaffoldRuntime) String() string {
+	return fmt.Sprintf("%s (%s)", rt.Name, rt.Description)
+}
+
+// scaffoldRuntimes returns the languages which projects can be scaffolded for.
+func scaffoldRuntimes() []scaffoldRuntime {
+	return []scaffoldRuntime{
+		{
+			Name:        "c",
+			Description: "C",
+			Runtime:     "base:latest",
+			Cmd:         []string{"/hello"},
+		},
+		{
+			Name:        "go",
+			Description: "Go",
+			Runtime:     "base:latest",
+			Cmd:         []string{"/hello"},
+		},
+		{
+			Name:        "rust",
+			Description: "Rust",
+			Runtime:     "base:latest",
+			Cmd:         []string{"/hello"},
+		},
+		{
+			Name:        "node",
+			Description: "Node.js",
+			Runtime:     "node:21",
+			Cmd:         []string{"/usr/bin/node", "/app/app.js"},
+			Interpreted: true,
+		},
+		{
+			Name:        "python",
+			Description: "Python",
+			Runtime:     "python:3.12",
+			Cmd:         []string{"/usr/bin/python3", "/app/app.py"},
+			Interpreted: true,
+		},
+	}
+}
+
+// scaffoldRuntimeByName returns the language with the provided name.
+func scaffoldRuntimeByName(name string) (*scaffoldRuntime, error) {
+	names := []string{}
+
+	for _, rt := range scaffoldRuntimes() {
+		if rt.Name == name {
+			return &rt, nil
+		}
+
+		names = append(names, rt.Name)
+	}
+
+	return nil, fmt.Errorf("unsupported runtime '%s': expected one of %s", name, strings.Join(names, ", "))
+}
+
+// scaffold holds the values which the templates of a project are rendered
+// with.
+type scaffold struct {
+	Name    string
+	Runtime string
+	Rootfs  string
+	Cmd     string
+	Plat    string
+	Arch    string
+	Triple  string
+
+	runtime  *scaffoldRuntime
+	strategy string
+	compose  bool
+}
+
+// newScaffold prepares the rendering of a project.
+func newScaffold(name string, rt *scaffoldRuntime, strategy, plat, arch string, compose bool) (*scaffold, error) {
+	s := &scaffold{
+		Name:     name,
+		Runtime:  rt.Runtime,
+		Plat:     plat,
+		Arch:     arch,
+		runtime:  rt,
+		strategy: strategy,
+		compose:  compose,
+	}
+
+	switch strategy {
+	case rootfsDockerfile:
+		s.Rootfs = "./Dockerfile"
+	case rootfsDirectory:
+		if !rt.Interpreted {
+			return nil, fmt.Errorf("the %s rootfs strategy requires an interpreted runtime: use %s instead", rootfsDirectory, rootfsDockerfile)
+		}
+
+		s.Rootfs = "./rootfs"
+	default:
+		return nil, fmt.Errorf("unsupported rootfs strategy '%s': expected one of %s", strategy, strings.Join(rootfsStrategies(), ", "))
+	}
+
+	cmd := make([]string, len(rt.Cmd))
+	for i, arg := range rt.Cmd {
+		cmd[i] = fmt.Sprintf("%q", arg)
+	}
+
+	s.Cmd = strings.Join(cmd, ", ")
+
+	switch arch {
+	case "arm64":
+		s.Triple = "aarch64-unknown-linux-gnu"
+	case "riscv64":
+		s.Triple = "riscv64gc-unknown-linux-gnu"
+	default:
+		s.Triple = "x86_64-unknown-linux-gnu"
+	}
+
+	return s, nil
+}
+
+// files renders the templates of the project and returns their contents
+// indexed by their path relative to the project directory.
+func (s *scaffold) files() (map[string][]byte, error) {
+	ret := map[string][]byte{}
+
+	common := map[string]string{
+		"Kraftfile.tmpl":    "Kraftfile",
+		"dockerignore.tmpl": ".dockerignore",
+	}
+	if s.compose {
+		common["compose.yaml.tmpl"] = "compose.yaml"
+	}
+
+	for src, dst := range common {
+		raw, err := s.render(path.Join("templates", src))
+		if err != nil {
+			return nil, err
+		}
+
+		ret[dst] = raw
+	}
+
+	root := path.Join("templates", s.runtime.Name)
+
+	err := fs.WalkDir(templates, root, func(src string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		dst := strings.TrimSuffix(strings.TrimPrefix(src, root+"/"), ".tmpl")
+
+		switch {
+		case dst == "Dockerfile" && s.strategy != rootfsDockerfile:
+			return nil
+		case dst != "Dockerfile" && s.strategy == rootfsDirectory:
+			// Sources of interpreted runtimes are placed where the command of
+			// the runtime expects them.
+			dst = path.Join("rootfs", "app", dst)
+		}
+
+		raw, err := s.render(src)
+		if err != nil {
+			return err
+		}
+
+		ret[filepath.FromSlash(dst)] = raw
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// render executes the template at the provided path of the embedded
+// filesystem.
+func (s *scaffold) render(name string) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, name)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, s); err != nil {
+		return nil, fmt.Errorf("could not render template %s: %w", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// write renders the project into the provided directory.  Existing files are
+// only overwritten if force is set.
+func (s *scaffold) write(workdir string, force bool) ([]string, error) {
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		for name := range files {
+			if _, err := os.Stat(filepath.Join(workdir, name)); err == nil {
+				return nil, fmt.Errorf("%s already exists: use --force to overwrite it", filepath.Join(workdir, name))
+			}
+		}
+	}
+
+	written := make([]string, 0, len(files))
+
+	for name, raw := range files {
+		dst := filepath.Join(workdir, name)
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, err
+		}
+
+		if err := os.WriteFile(dst, raw, 0o644); err != nil {
+			return nil, err
+		}
+
+		written = append(written, name)
+	}
+
+	return written, nil
+}
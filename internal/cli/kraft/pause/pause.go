@@ -13,14 +13,16 @@ import (
 
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/iostreams"
-	"kraftkit.sh/log"
 	mplatform "kraftkit.sh/machine/platform"
 )
 
 type PauseOptions struct {
-	All      bool   `long:"all" usage:"Pause all machines"`
-	Platform string `noattribute:"true"`
+	All      bool     `long:"all" usage:"Pause all machines"`
+	Filter   []string `long:"filter" short:"f" usage:"Select machines matching the filter, in the format key=value or key!=value (label, name, status or plat)"`
+	Platform string   `noattribute:"true"`
 }
 
 // Pause a local Unikraft virtual machine.
@@ -34,15 +36,19 @@ func Pause(ctx context.Context, opts *PauseOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&PauseOptions{}, cobra.Command{
-		Short:   "Pause one or more running unikernels",
-		Use:     "pause [FLAGS] MACHINE [MACHINE [...]]",
-		Aliases: []string{},
+		Short:             "Pause one or more running unikernels",
+		Use:               "pause [FLAGS] MACHINE [MACHINE [...]]",
+		ValidArgsFunction: completion.Machines,
+		Aliases:           []string{},
 		Long: heredoc.Doc(`
 			Pause one or more running unikernels
 		`),
 		Example: heredoc.Doc(`
 			# Pause a running unikernel
 			$ kraft pause my-machine
+
+			# Pause all running unikernels with the label app=web
+			$ kraft pause --filter label=app=web
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
@@ -66,8 +72,8 @@ func NewCmd() *cobra.Command {
 }
 
 func (opts *PauseOptions) Pre(cmd *cobra.Command, args []string) error {
-	if len(args) == 0 && !opts.All {
-		return fmt.Errorf("please supply a machine ID or name or use the --all flag")
+	if len(args) == 0 && !opts.All && len(opts.Filter) == 0 {
+		return fmt.Errorf("please supply a machine ID or name or use the --all or --filter flags")
 	}
 
 	opts.Platform = cmd.Flag("plat").Value.String()
@@ -75,16 +81,19 @@ func (opts *PauseOptions) Pre(cmd *cobra.Command, args []string) error {
 }
 
 func (opts *PauseOptions) Run(ctx context.Context, args []string) error {
-	if len(args) == 0 && !opts.All {
-		return fmt.Errorf("please supply a machine ID or name or use the --all flag")
+	if len(args) == 0 && !opts.All && len(opts.Filter) == 0 {
+		return fmt.Errorf("please supply a machine ID or name or use the --all or --filter flags")
 	}
 
-	var err error
+	filters, err := utils.ParseMachineFilters(opts.Filter)
+	if err != nil {
+		return err
+	}
 
 	platform := mplatform.PlatformUnknown
 	var controller machineapi.MachineService
 
-	if opts.All || opts.Platform == "auto" {
+	if opts.All || len(args) == 0 || opts.Platform == "auto" {
 		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
 	} else {
 		if opts.Platform == "host" {
@@ -116,33 +125,22 @@ func (opts *PauseOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	var pause []machineapi.Machine
-
-	for _, machine := range machines.Items {
-		if opts.All {
-			pause = append(pause, machine)
-			continue
-		}
-		for _, arg := range args {
-			if arg == machine.Name || arg == string(machine.UID) {
-				pause = append(pause, machine)
-			}
-		}
-	}
-
+	pause := utils.SelectMachines(machines.Items, args, opts.All, filters)
 	if len(pause) == 0 {
 		return fmt.Errorf("machine(s) not found")
 	}
 
-	for _, machine := range pause {
+	return utils.ForEachMachine(ctx, pause, func(ctx context.Context, machine *machineapi.Machine) error {
 		if machine.Status.State != machineapi.MachineStateRunning {
-			continue
-		} else if _, err := controller.Pause(ctx, &machine); err != nil {
-			log.G(ctx).Errorf("could not pause machine %s: %v", machine.Name, err)
-		} else {
-			fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+			return nil
 		}
-	}
 
-	return nil
+		if _, err := controller.Pause(ctx, machine); err != nil {
+			return fmt.Errorf("could not pause machine: %w", err)
+		}
+
+		fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+
+		return nil
+	})
 }
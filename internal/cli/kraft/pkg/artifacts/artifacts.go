@@ -0,0 +1,269 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/tui/processtree"
+)
+
+type ArtifactsOptions struct {
+	tableprinter.OutputOptions
+
+	Fetch string   `long:"fetch" short:"f" usage:"Download the matching artifacts into the provided directory"`
+	Type  []string `long:"type" short:"t" usage:"Filter the artifacts by kind (sbom/signature/attestation/symbols/other) or by artifact type"`
+}
+
+// Artifacts lists and fetches the artifacts which refer to a package.
+func Artifacts(ctx context.Context, opts *ArtifactsOptions, args ...string) error {
+	if opts == nil {
+		opts = &ArtifactsOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ArtifactsOptions{}, cobra.Command{
+		Short:             "List and fetch the artifacts attached to a package",
+		Use:               "artifacts [FLAGS] PACKAGE",
+		Aliases:           []string{"referrers"},
+		Args:              cmdfactory.MinimumArgs(1, "package not specified"),
+		ValidArgsFunction: completion.Packages,
+		Long: heredoc.Doc(`
+			List and fetch the artifacts attached to a package in a registry, e.g.
+			its SBOMs, signatures, attestations or debug symbols.
+
+			Artifacts are attached to a package by referring to it as their subject,
+			as defined by the OCI distribution specification v1.1.  Both the
+			artifacts which refer to the index of the package and those which refer
+			to the manifest of any of its platforms are listed.  Registries which do
+			not implement the referrers API are queried through the fallback tag
+			schema, as used by e.g. cosign.
+
+			Each artifact is categorised by its artifact type as an sbom, signature,
+			attestation, symbols or other.  With --type, only the artifacts of the
+			provided kinds or artifact types are listed and fetched.
+
+			With --fetch, the files of each artifact are downloaded into a directory
+			named after the digest of the artifact within the provided directory.
+		`),
+		Example: heredoc.Doc(`
+			# List the artifacts attached to a package
+			$ kraft pkg artifacts unikraft.org/nginx:latest
+
+			# List only the SBOMs and signatures attached to a package
+			$ kraft pkg artifacts --type sbom,signature unikraft.org/nginx:latest
+
+			# Download the debug symbols attached to a package
+			$ kraft pkg artifacts --type symbols --fetch ./symbols unikraft.org/nginx:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ArtifactsOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	// Allow both repeated flags and comma-separated values.
+	var types []string
+	for _, t := range opts.Type {
+		for _, t := range strings.Split(t, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+
+	opts.Type = types
+
+	return nil
+}
+
+// matches returns whether the referrer is of any of the kinds or artifact
+// types to filter by, if any.
+func (opts *ArtifactsOptions) matches(referrer oci.Referrer) bool {
+	if len(opts.Type) == 0 {
+		return true
+	}
+
+	return slices.Contains(opts.Type, referrer.Kind) ||
+		slices.Contains(opts.Type, referrer.Descriptor.ArtifactType)
+}
+
+func (opts *ArtifactsOptions) Run(ctx context.Context, args []string) error {
+	ref := args[0]
+	parallel := !config.G[config.KraftKit](ctx).NoParallel
+	norender := log.LoggerTypeFromString(config.G[config.KraftKit](ctx).Log.Type) != log.FANCY
+
+	var referrers []oci.Referrer
+
+	treemodel, err := processtree.NewProcessTree(
+		ctx,
+		[]processtree.ProcessTreeOption{
+			processtree.IsParallel(parallel),
+			processtree.WithRenderer(norender),
+			processtree.WithFailFast(true),
+			processtree.WithHideOnSuccess(true),
+		},
+		processtree.NewProcessTreeItem(
+			fmt.Sprintf("listing artifacts of %s", ref), "",
+			func(ctx context.Context) error {
+				all, err := oci.ListReferrers(ctx, ref, nil)
+				if err != nil {
+					return err
+				}
+
+				for _, referrer := range all {
+					if opts.matches(referrer) {
+						referrers = append(referrers, referrer)
+					}
+				}
+
+				return nil
+			},
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := treemodel.Start(); err != nil {
+		return fmt.Errorf("could not list artifacts: %v", err)
+	}
+
+	// Each artifact is fetched into its own directory, since the files of
+	// different artifacts, e.g. two SBOMs, are commonly named alike.
+	files := make(map[int][]string, len(referrers))
+
+	if opts.Fetch != "" && len(referrers) > 0 {
+		var mu sync.Mutex
+		var fetches []*processtree.ProcessTreeItem
+
+		for i, referrer := range referrers {
+			fetches = append(fetches, processtree.NewProcessTreeItem(
+				fmt.Sprintf("fetching %s", referrer.Descriptor.Digest), "",
+				func(ctx context.Context) error {
+					paths, err := oci.FetchReferrer(ctx,
+						ref,
+						referrer.Descriptor.Digest,
+						filepath.Join(opts.Fetch, referrer.Descriptor.Digest.Encoded()),
+						nil,
+					)
+					if err != nil {
+						return err
+					}
+
+					mu.Lock()
+					files[i] = paths
+					mu.Unlock()
+
+					return nil
+				},
+			))
+		}
+
+		treemodel, err := processtree.NewProcessTree(
+			ctx,
+			[]processtree.ProcessTreeOption{
+				processtree.IsParallel(parallel),
+				processtree.WithRenderer(norender),
+				processtree.WithFailFast(false),
+				processtree.WithHideOnSuccess(true),
+			},
+			fetches...,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := treemodel.Start(); err != nil {
+			return fmt.Errorf("could not fetch artifacts: %v", err)
+		}
+	}
+
+	if len(referrers) == 0 && opts.Output == "table" {
+		fmt.Fprintf(iostreams.G(ctx).ErrOut, "no artifacts found for %s\n", ref)
+		return nil
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := opts.NewTablePrinter(ctx)
+	if err != nil {
+		return err
+	}
+
+	table.AddField("DIGEST", cs.Bold)
+	table.AddField("KIND", cs.Bold)
+	table.AddField("ARTIFACT TYPE", cs.Bold)
+	table.AddField("SUBJECT", cs.Bold)
+	table.AddField("SIZE", cs.Bold)
+	table.AddField("CREATED", cs.Bold)
+	if opts.Fetch != "" {
+		table.AddField("FILES", cs.Bold)
+	}
+	table.EndRow()
+
+	for i, referrer := range referrers {
+		table.AddField(referrer.Descriptor.Digest.String(), nil)
+		table.AddField(referrer.Kind, nil)
+		table.AddField(referrer.Descriptor.ArtifactType, nil)
+		table.AddField(subject(referrer.Subject), nil)
+		table.AddField(humanize.IBytes(uint64(referrer.Descriptor.Size)), nil)
+		table.AddField(referrer.Descriptor.Annotations[ocispec.AnnotationCreated], nil)
+		if opts.Fetch != "" {
+			table.AddField(strings.Join(files[i], ", "), nil)
+		}
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
+
+// subject returns a short description of the subject of an artifact: the
+// platform of a manifest, or otherwise its media type.
+func subject(desc ocispec.Descriptor) string {
+	if desc.Platform != nil {
+		if desc.Platform.Variant != "" {
+			return fmt.Sprintf("%s/%s/%s", desc.Platform.OS, desc.Platform.Architecture, desc.Platform.Variant)
+		}
+
+		return fmt.Sprintf("%s/%s", desc.Platform.OS, desc.Platform.Architecture)
+	}
+
+	if desc.MediaType == ocispec.MediaTypeImageIndex {
+		return "index"
+	}
+
+	return "manifest"
+}
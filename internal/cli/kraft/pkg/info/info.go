@@ -14,6 +14,7 @@ import (
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
 	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	pkgutils "kraftkit.sh/internal/cli/kraft/pkg/utils"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
@@ -23,7 +24,7 @@ import (
 )
 
 type InfoOptions struct {
-	Output string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
+	Output string `long:"output" short:"o" usage:"Set output format. Options: table,wide,yaml,json,list,go-template=TEMPLATE" default:"table"`
 	Update bool   `long:"update" short:"u" usage:"Get latest information about components before listing results"`
 }
 
@@ -40,14 +41,23 @@ func New() *cobra.Command {
 	cmd, err := cmdfactory.New(&InfoOptions{}, cobra.Command{
 		Short:   "Show information about a package",
 		Use:     "info [FLAGS] [PACKAGE|DIR]",
-		Aliases: []string{"show", "get", "i"},
+		Aliases: []string{"show", "get", "inspect", "i"},
 		Long: heredoc.Doc(`
 			Shows a Unikraft package like library, core, etc.
+
+			For unikernel packages, the effective command is shown, which consists of
+			the package's entrypoint followed by its command.  The command can be
+			overridden when running the package with 'kraft run PACKAGE -- ARGS' and
+			the entrypoint with 'kraft run --entrypoint'.
 		`),
-		Args: cmdfactory.MinimumArgs(1, "package name(s) not specified"),
+		Args:              cmdfactory.MinimumArgs(1, "package name(s) not specified"),
+		ValidArgsFunction: completion.Packages,
 		Example: heredoc.Doc(`
 			# Shows details for the library nginx
 			$ kraft pkg info nginx
+
+			# Shows details, including the effective command, of a unikernel package
+			$ kraft pkg inspect unikraft.org/nginx:latest
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "pkg",
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -18,8 +19,8 @@ import (
 	"kraftkit.sh/unikraft/app"
 
 	"kraftkit.sh/cmdfactory"
-	"kraftkit.sh/internal/cli/kraft/cloud/utils"
 	pkgutils "kraftkit.sh/internal/cli/kraft/pkg/utils"
+	"kraftkit.sh/internal/tableprinter"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
 	mplatform "kraftkit.sh/machine/platform"
@@ -30,21 +31,24 @@ import (
 )
 
 type ListOptions struct {
-	All       bool   `long:"all" usage:"Show everything"`
-	Arch      string `long:"arch" usage:"Set a specific arhitecture to list for"`
-	Kraftfile string `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
-	Limit     int    `long:"limit" short:"l" usage:"Set the maximum number of results" default:"50"`
-	Local     bool   `long:"local" usage:"Show local packages only"`
-	NoLimit   bool   `long:"no-limit" usage:"Do not limit the number of items to print"`
-	Output    string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
-	Plat      string `long:"plat" usage:"Set a specific platform to list for"`
-	Remote    bool   `long:"remote" short:"u" usage:"Show remote packages only"`
-	ShowApps  bool   `long:"apps" short:"" usage:"Show applications"`
-	ShowArchs bool   `long:"archs" short:"M" usage:"Show architectures"`
-	ShowCore  bool   `long:"core" short:"C" usage:"Show Unikraft core versions"`
-	ShowLibs  bool   `long:"libs" short:"L" usage:"Show libraries"`
-	ShowPlats bool   `long:"plats" short:"P" usage:"Show platforms"`
-	Update    bool   `long:"update" short:"U" usage:"Update package indexes before listing"`
+	tableprinter.OutputOptions
+
+	All       bool          `long:"all" usage:"Show everything"`
+	Arch      string        `long:"arch" usage:"Set a specific arhitecture to list for"`
+	Kraftfile string        `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Limit     int           `long:"limit" short:"l" usage:"Set the maximum number of results" default:"50"`
+	Local     bool          `long:"local" usage:"Show local packages only" conflicts-with:"remote"`
+	NoCache   bool          `long:"no-cache" usage:"Do not use cached metadata of remote catalogs"`
+	NoLimit   bool          `long:"no-limit" usage:"Do not limit the number of items to print"`
+	Plat      string        `long:"plat" usage:"Set a specific platform to list for"`
+	Remote    bool          `long:"remote" short:"u" usage:"Show remote packages only"`
+	ShowApps  bool          `long:"apps" short:"" usage:"Show applications"`
+	ShowArchs bool          `long:"archs" short:"M" usage:"Show architectures"`
+	ShowCore  bool          `long:"core" short:"C" usage:"Show Unikraft core versions"`
+	ShowLibs  bool          `long:"libs" short:"L" usage:"Show libraries"`
+	ShowPlats bool          `long:"plats" short:"P" usage:"Show platforms"`
+	Timeout   time.Duration `long:"timeout" usage:"Skip the catalog of a package manager which does not respond within this duration" default:"30s"`
+	Update    bool          `long:"update" short:"U" usage:"Update package indexes before listing"`
 }
 
 func NewCmd() *cobra.Command {
@@ -80,12 +84,16 @@ func (opts *ListOptions) Pre(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	if opts.Local && opts.Remote {
-		return fmt.Errorf("cannot use --local and --remote")
+	if opts.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
 	}
 
-	if !utils.IsValidOutputFormat(opts.Output) {
-		return fmt.Errorf("invalid output format: %s", opts.Output)
+	if opts.NoCache {
+		config.G[config.KraftKit](ctx).Catalog.NoCache = true
+	}
+
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
 	cmd.SetContext(ctx)
@@ -177,6 +185,7 @@ func (opts *ListOptions) Run(ctx context.Context, args []string) error {
 
 		qopts := []packmanager.QueryOption{
 			packmanager.WithRemote(opts.Remote),
+			packmanager.WithTimeout(opts.Timeout),
 			packmanager.WithTypes(types...),
 		}
 
@@ -94,7 +94,7 @@ func (p *packagerCliKernel) Pack(ctx context.Context, opts *PkgOptions, args ...
 					packmanager.PackOutput(opts.Output),
 				)
 
-				envs := opts.aggregateEnvs()
+				envs := opts.aggregateEnvs(targ)
 				if len(envs) > 0 {
 					popts = append(popts, packmanager.PackWithEnvs(envs))
 				} else if len(opts.Env) > 0 {
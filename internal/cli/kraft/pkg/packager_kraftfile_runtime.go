@@ -386,7 +386,7 @@ func (p *packagerKraftfileRuntime) Pack(ctx context.Context, opts *PkgOptions, a
 					)
 				}
 
-				envs := opts.aggregateEnvs()
+				envs := opts.aggregateEnvs(targ)
 				if len(envs) > 0 {
 					popts = append(popts, packmanager.PackWithEnvs(envs))
 				} else if len(opts.Env) > 0 {
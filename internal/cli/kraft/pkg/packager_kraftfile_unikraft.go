@@ -43,10 +43,6 @@ func (p *packagerKraftfileUnikraft) Packagable(ctx context.Context, opts *PkgOpt
 		return false, fmt.Errorf("cannot package without unikraft core specification")
 	}
 
-	if opts.Project.Rootfs() != "" && opts.Rootfs == "" {
-		opts.Rootfs = opts.Project.Rootfs()
-	}
-
 	return true, nil
 }
 
@@ -94,6 +90,9 @@ func (p *packagerKraftfileUnikraft) Pack(ctx context.Context, opts *PkgOptions,
 		var cmds []string
 		var envs []string
 		rootfs := opts.Rootfs
+		if rootfs == "" {
+			rootfs = opts.Project.TargetRootfs(targ)
+		}
 
 		// Reset the rootfs, such that it is not packaged as an initrd if it is
 		// already embedded inside of the kernel.
@@ -118,19 +117,18 @@ func (p *packagerKraftfileUnikraft) Pack(ctx context.Context, opts *PkgOptions,
 			opts.Env = append(opts.Env, envs...)
 		}
 
-		// If no arguments have been specified, use the ones which are default and
-		// that have been included in the package.
-		if len(opts.Args) == 0 {
-			if len(opts.Project.Command()) > 0 {
-				opts.Args = opts.Project.Command()
-			} else if len(targ.Command()) > 0 {
-				opts.Args = targ.Command()
+		// If no arguments have been specified, use the ones which are default for
+		// the target and that have been included in the package.
+		args := opts.Args
+		if len(args) == 0 {
+			if command := opts.Project.TargetCommand(targ); len(command) > 0 {
+				args = command
 			} else if cmds != nil {
-				opts.Args = cmds
+				args = cmds
 			}
 		}
 
-		cmdShellArgs, err := shellwords.Parse(strings.Join(opts.Args, " "))
+		cmdShellArgs, err := shellwords.Parse(strings.Join(args, " "))
 		if err != nil {
 			return nil, err
 		}
@@ -164,6 +162,7 @@ func (p *packagerKraftfileUnikraft) Pack(ctx context.Context, opts *PkgOptions,
 					packmanager.PackArgs(cmdShellArgs...),
 					packmanager.PackInitrd(rootfs),
 					packmanager.PackKConfig(!opts.NoKConfig),
+					packmanager.PackKernelDbg(opts.Symbols),
 					packmanager.PackName(opts.Name),
 					packmanager.PackOutput(opts.Output),
 					packmanager.PackLabels(labels),
@@ -175,7 +174,7 @@ func (p *packagerKraftfileUnikraft) Pack(ctx context.Context, opts *PkgOptions,
 					)
 				}
 
-				envs := opts.aggregateEnvs()
+				envs := opts.aggregateEnvs(targ)
 				if len(envs) > 0 {
 					popts = append(popts, packmanager.PackWithEnvs(envs))
 				} else if len(opts.Env) > 0 {
@@ -11,6 +11,7 @@ import (
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/dustin/go-humanize"
+	"github.com/mattn/go-shellwords"
 	"github.com/spf13/cobra"
 
 	"kraftkit.sh/config"
@@ -24,14 +25,18 @@ import (
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/packmanager"
 
+	"kraftkit.sh/internal/cli/kraft/pkg/artifacts"
 	"kraftkit.sh/internal/cli/kraft/pkg/info"
 	"kraftkit.sh/internal/cli/kraft/pkg/list"
+	"kraftkit.sh/internal/cli/kraft/pkg/prefetch"
 	"kraftkit.sh/internal/cli/kraft/pkg/pull"
 	"kraftkit.sh/internal/cli/kraft/pkg/push"
 	"kraftkit.sh/internal/cli/kraft/pkg/remove"
+	"kraftkit.sh/internal/cli/kraft/pkg/scan"
 	"kraftkit.sh/internal/cli/kraft/pkg/source"
 	"kraftkit.sh/internal/cli/kraft/pkg/unsource"
 	"kraftkit.sh/internal/cli/kraft/pkg/update"
+	"kraftkit.sh/internal/cli/kraft/pkg/vendor"
 )
 
 type PkgOptions struct {
@@ -39,6 +44,7 @@ type PkgOptions struct {
 	Args         []string                  `local:"true" long:"args" short:"a" usage:"Pass arguments that will be part of the running kernel's command line"`
 	Compress     bool                      `local:"true" long:"compress" short:"c" usage:"Compress the initrd package (experimental)"`
 	Dbg          bool                      `local:"true" long:"dbg" usage:"Package the debuggable (symbolic) kernel image instead of the stripped image"`
+	Entrypoint   string                    `local:"true" long:"entrypoint" usage:"Set the arguments which precede the arguments of the application, even when they are overridden at run time"`
 	Env          []string                  `local:"true" long:"env" short:"e" usage:"Set environment variables to be packed into the package"`
 	Force        bool                      `local:"true" long:"force-format" usage:"Force the use of a packaging handler format"`
 	Format       string                    `local:"true" long:"as" short:"M" usage:"Force the packaging despite possible conflicts" default:"oci"`
@@ -55,6 +61,7 @@ type PkgOptions struct {
 	Rootfs       string                    `local:"true" long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
 	Runtime      string                    `local:"true" long:"runtime" short:"r" usage:"Set the runtime to use for the package"`
 	Strategy     packmanager.MergeStrategy `noattribute:"true"`
+	Symbols      bool                      `local:"true" long:"symbols" usage:"Include the symbolic kernel image, such that crashes can be symbolized with 'kraft debug symbolize'"`
 	Target       string                    `local:"true" long:"target" short:"t" usage:"Package a particular known target"`
 	Workdir      string                    `local:"true" long:"workdir" short:"w" usage:"Set an alternative working directory (default is cwd)"`
 
@@ -169,6 +176,17 @@ func Pkg(ctx context.Context, opts *PkgOptions, args ...string) ([]pack.Package,
 		)
 	}
 
+	if len(opts.Entrypoint) > 0 {
+		entrypoint, err := shellwords.Parse(opts.Entrypoint)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse entrypoint: %w", err)
+		}
+
+		opts.packopts = append(opts.packopts,
+			packmanager.PackEntrypoint(entrypoint...),
+		)
+	}
+
 	var pkgr packager
 
 	packagers := packagers()
@@ -267,14 +285,18 @@ func NewCmd() *cobra.Command {
 		panic(err)
 	}
 
+	cmd.AddCommand(artifacts.NewCmd())
 	cmd.AddCommand(info.New())
 	cmd.AddCommand(list.NewCmd())
+	cmd.AddCommand(prefetch.NewCmd())
 	cmd.AddCommand(pull.NewCmd())
 	cmd.AddCommand(push.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
+	cmd.AddCommand(scan.NewCmd())
 	cmd.AddCommand(source.NewCmd())
 	cmd.AddCommand(unsource.NewCmd())
 	cmd.AddCommand(update.NewCmd())
+	cmd.AddCommand(vendor.NewCmd())
 
 	cmd.Flags().Var(
 		cmdfactory.NewEnumFlag[packmanager.MergeStrategy](
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package prefetch
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"gopkg.in/yaml.v3"
+
+	"kraftkit.sh/machine/platform"
+)
+
+// Manifest declares the images which are prefetched into the local store.
+type Manifest struct {
+	// Platforms is the default matrix of platforms and architectures, in the
+	// format plat/arch, which is used for images without their own.
+	Platforms []string `yaml:"platforms,omitempty"`
+
+	// Images are the images which are prefetched.
+	Images []ManifestImage `yaml:"images"`
+}
+
+// ManifestImage is an image of the manifest.
+type ManifestImage struct {
+	// Ref is the reference of the image, e.g. unikraft.org/nginx:latest.
+	Ref string `yaml:"ref"`
+
+	// Digest is the expected digest of the index of the image, if any.
+	Digest string `yaml:"digest,omitempty"`
+
+	// Platforms is the matrix of platforms and architectures of the image, in
+	// the format plat/arch, which overrides that of the manifest.
+	Platforms []string `yaml:"platforms,omitempty"`
+}
+
+// Job is a single image of the manifest for a single platform and
+// architecture.
+type Job struct {
+	Ref          string
+	Digest       digest.Digest
+	Platform     string
+	Architecture string
+}
+
+// String implements fmt.Stringer
+func (job Job) String() string {
+	return fmt.Sprintf("%s (%s/%s)", job.Ref, job.Platform, job.Architecture)
+}
+
+// NewManifestFromFile reads the manifest at the provided path.
+func NewManifestFromFile(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read manifest: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+
+	manifest := &Manifest{}
+	if err := dec.Decode(manifest); err != nil {
+		return nil, fmt.Errorf("could not parse manifest %s: %w", path, err)
+	}
+
+	return manifest, nil
+}
+
+// Jobs expands the images of the manifest over their matrix of platforms and
+// architectures.  Images without a matrix are fetched for the provided host
+// platform and architecture.  Platforms are normalised, e.g. firecracker to
+// fc, and duplicate jobs are only returned once.
+func (manifest *Manifest) Jobs(hostPlatform, hostArchitecture string) ([]Job, error) {
+	if len(manifest.Images) == 0 {
+		return nil, fmt.Errorf("manifest does not declare any images")
+	}
+
+	var jobs []Job
+	seen := map[Job]bool{}
+
+	for i, image := range manifest.Images {
+		if image.Ref == "" {
+			return nil, fmt.Errorf("image %d does not declare a ref", i)
+		}
+
+		var dgst digest.Digest
+		if image.Digest != "" {
+			var err error
+			if dgst, err = digest.Parse(image.Digest); err != nil {
+				return nil, fmt.Errorf("invalid digest of %s: %w", image.Ref, err)
+			}
+		}
+
+		// A reference pinned to a digest is validated against it.
+		if ref, pinned, ok := strings.Cut(image.Ref, "@"); ok {
+			pinnedDigest, err := digest.Parse(pinned)
+			if err != nil {
+				return nil, fmt.Errorf("invalid digest of %s: %w", image.Ref, err)
+			}
+
+			if dgst != "" && dgst != pinnedDigest {
+				return nil, fmt.Errorf("digest of %s does not match the digest of its ref", ref)
+			}
+
+			dgst = pinnedDigest
+		}
+
+		platforms := image.Platforms
+		if len(platforms) == 0 {
+			platforms = manifest.Platforms
+		}
+		if len(platforms) == 0 {
+			if hostPlatform == "" || hostArchitecture == "" {
+				return nil, fmt.Errorf("no platforms declared for %s and the host platform is unknown", image.Ref)
+			}
+
+			platforms = []string{hostPlatform + "/" + hostArchitecture}
+		}
+
+		for _, pa := range platforms {
+			plat, arch, ok := strings.Cut(pa, "/")
+			if !ok || plat == "" || arch == "" {
+				return nil, fmt.Errorf("expected platform of %s in the format plat/arch: %s", image.Ref, pa)
+			}
+
+			job := Job{
+				Ref:          image.Ref,
+				Digest:       dgst,
+				Platform:     platform.PlatformByName(plat).String(),
+				Architecture: arch,
+			}
+
+			if seen[job] {
+				continue
+			}
+
+			seen[job] = true
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
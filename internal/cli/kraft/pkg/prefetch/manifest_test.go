@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package prefetch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifestJobs(t *testing.T) {
+	dgst := "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+	manifest := &Manifest{
+		Platforms: []string{"qemu/x86_64", "fc/x86_64", "firecracker/x86_64"},
+		Images: []ManifestImage{
+			{Ref: "unikraft.org/nginx:1.25"},
+			{Ref: "unikraft.org/redis:7.2", Digest: dgst, Platforms: []string{"qemu/arm64"}},
+			{Ref: "unikraft.org/node@" + dgst, Platforms: []string{"qemu/x86_64"}},
+		},
+	}
+
+	jobs, err := manifest.Jobs("qemu", "x86_64")
+	if err != nil {
+		t.Fatalf("Jobs: %v", err)
+	}
+
+	expected := []Job{
+		{Ref: "unikraft.org/nginx:1.25", Platform: "qemu", Architecture: "x86_64"},
+		{Ref: "unikraft.org/nginx:1.25", Platform: "fc", Architecture: "x86_64"},
+		{Ref: "unikraft.org/redis:7.2", Digest: "sha256:0000000000000000000000000000000000000000000000000000000000000000", Platform: "qemu", Architecture: "arm64"},
+		{Ref: "unikraft.org/node@" + dgst, Digest: "sha256:0000000000000000000000000000000000000000000000000000000000000000", Platform: "qemu", Architecture: "x86_64"},
+	}
+
+	if !reflect.DeepEqual(jobs, expected) {
+		t.Errorf("expected %v, got %v", expected, jobs)
+	}
+}
+
+func TestManifestJobsInvalid(t *testing.T) {
+	tests := []*Manifest{
+		{},
+		{Images: []ManifestImage{{}}},
+		{Images: []ManifestImage{{Ref: "nginx", Digest: "sha256:xyz"}}},
+		{Images: []ManifestImage{{Ref: "nginx", Platforms: []string{"qemu"}}}},
+		{Images: []ManifestImage{{Ref: "nginx@sha256:0000000000000000000000000000000000000000000000000000000000000000", Digest: "sha256:1111111111111111111111111111111111111111111111111111111111111111"}}},
+	}
+
+	for _, manifest := range tests {
+		if _, err := manifest.Jobs("qemu", "x86_64"); err == nil {
+			t.Errorf("Jobs(%+v): expected error", manifest)
+		}
+	}
+
+	if _, err := (&Manifest{Images: []ManifestImage{{Ref: "nginx"}}}).Jobs("", ""); err == nil {
+		t.Errorf("Jobs without host: expected error")
+	}
+}
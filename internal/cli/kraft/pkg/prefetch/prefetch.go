@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package prefetch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/platform"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/tui/paraprogress"
+	"kraftkit.sh/tui/processtree"
+	"kraftkit.sh/unikraft/arch"
+)
+
+type PrefetchOptions struct {
+	File   string `long:"file" short:"f" usage:"Set the path of the manifest of images to prefetch"`
+	Update bool   `long:"update" short:"u" usage:"Pull the images even if they already exist in the local store"`
+}
+
+// Prefetch the images declared in a manifest into the local store.
+func Prefetch(ctx context.Context, opts *PrefetchOptions, args ...string) error {
+	if opts == nil {
+		opts = &PrefetchOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&PrefetchOptions{}, cobra.Command{
+		Short: "Pull the images declared in a manifest into the local store",
+		Use:   "prefetch [FLAGS] -f MANIFEST",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Pull the images declared in a manifest into the local store, such that
+			machines can be started from them without reaching out to a registry,
+			e.g. to warm edge nodes before a rollout.
+
+			The manifest lists the images by reference and optionally the expected
+			digest of their index, which is validated before any image is pulled.
+			Each image is pulled for the platforms and architectures of its own
+			matrix, or otherwise that of the manifest, or otherwise the host:
+
+			  platforms:
+			    - qemu/x86_64
+			    - fc/x86_64
+			  images:
+			    - ref: unikraft.org/nginx:1.25
+			    - ref: unikraft.org/redis:7.2
+			      digest: sha256:...
+			      platforms:
+			        - qemu/arm64
+
+			The images are resolved and pulled in parallel, unless parallelism is
+			disabled in the configuration.
+		`),
+		Example: heredoc.Doc(`
+			# Pull the images declared in a manifest
+			$ kraft pkg prefetch -f manifest.yaml
+
+			# Pull the images declared in a manifest, even those which exist locally
+			$ kraft pkg prefetch --update -f manifest.yaml
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *PrefetchOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.File == "" {
+		return fmt.Errorf("please supply the manifest of images with --file")
+	}
+
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *PrefetchOptions) Run(ctx context.Context, _ []string) error {
+	manifest, err := NewManifestFromFile(opts.File)
+	if err != nil {
+		return err
+	}
+
+	// The host is only used for images without a matrix, hence failing to
+	// detect it is not an error by itself.
+	hostArchitecture, err := arch.HostArchitecture()
+	if err != nil {
+		log.G(ctx).Debugf("could not determine host architecture: %v", err)
+	}
+
+	var hostPlatform string
+	if plat, _, err := platform.Detect(ctx); err == nil {
+		hostPlatform = plat.String()
+	} else {
+		log.G(ctx).Debugf("could not detect host platform: %v", err)
+	}
+
+	jobs, err := manifest.Jobs(hostPlatform, hostArchitecture)
+	if err != nil {
+		return err
+	}
+
+	pm := packmanager.G(ctx)
+	parallel := !config.G[config.KraftKit](ctx).NoParallel
+	norender := log.LoggerTypeFromString(config.G[config.KraftKit](ctx).Log.Type) != log.FANCY
+
+	// Each job writes only its own entry, such that they can be resolved in
+	// parallel.
+	found := make([]pack.Package, len(jobs))
+	var treeItems []*processtree.ProcessTreeItem
+
+	for i, job := range jobs {
+		i, job := i, job // Go closures
+
+		treeItems = append(treeItems,
+			processtree.NewProcessTreeItem(
+				fmt.Sprintf("resolving %s", job.String()),
+				"",
+				func(ctx context.Context) error {
+					packages, err := pm.Catalog(ctx,
+						packmanager.WithName(job.Ref),
+						packmanager.WithPlatform(job.Platform),
+						packmanager.WithArchitecture(job.Architecture),
+						packmanager.WithRemote(true),
+					)
+					if err != nil {
+						return err
+					}
+
+					if len(packages) == 0 {
+						return fmt.Errorf("could not find %s", job.String())
+					}
+
+					if err := validateDigest(job, packages[0]); err != nil {
+						return err
+					}
+
+					found[i] = packages[0]
+
+					return nil
+				},
+			),
+		)
+	}
+
+	tree, err := processtree.NewProcessTree(
+		ctx,
+		[]processtree.ProcessTreeOption{
+			processtree.IsParallel(parallel),
+			processtree.WithRenderer(norender),
+			processtree.WithFailFast(false),
+			processtree.WithHideOnSuccess(true),
+		},
+		treeItems...,
+	)
+	if err != nil {
+		return err
+	}
+
+	// No image is pulled unless all of them could be resolved and validated,
+	// such that a node is never partially warmed with unexpected images.
+	if err := tree.Start(); err != nil {
+		return fmt.Errorf("could not resolve all images: %w", err)
+	}
+
+	var processes []*paraprogress.Process
+
+	for _, p := range found {
+		p := p // Go closures
+		processes = append(processes, paraprogress.NewProcess(
+			fmt.Sprintf("pulling %s", p.String()),
+			func(ctx context.Context, w func(progress float64)) error {
+				return p.Pull(
+					ctx,
+					pack.WithPullProgressFunc(w),
+					pack.WithPullChecksum(true),
+					pack.WithPullCache(!opts.Update),
+				)
+			},
+		))
+	}
+
+	model, err := paraprogress.NewParaProgress(
+		ctx,
+		processes,
+		paraprogress.IsParallel(parallel),
+		paraprogress.WithRenderer(norender),
+		paraprogress.WithFailFast(false),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := model.Start(); err != nil {
+		return fmt.Errorf("could not pull all images: %w", err)
+	}
+
+	return nil
+}
+
+// validateDigest checks that the digest of the resolved package is the one
+// which the manifest expects, if any.
+func validateDigest(job Job, p pack.Package) error {
+	if job.Digest == "" {
+		return nil
+	}
+
+	digested, ok := p.(interface{ Digest() string })
+	if !ok {
+		return fmt.Errorf("cannot validate the digest of %s: unsupported package format %s", job.String(), p.Format())
+	}
+
+	if digested.Digest() != job.Digest.String() {
+		return fmt.Errorf("digest of %s does not match: expected %s, got %s", job.String(), job.Digest, digested.Digest())
+	}
+
+	return nil
+}
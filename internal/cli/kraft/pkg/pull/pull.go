@@ -315,7 +315,7 @@ func (opts *PullOptions) Run(ctx context.Context, args []string) error {
 		for _, c := range components {
 			queries = append(queries, []packmanager.QueryOption{
 				packmanager.WithName(c.Name()),
-				packmanager.WithVersion(c.Version()),
+				packmanager.WithVersion(project.LockedVersion(c)),
 				packmanager.WithSource(c.Source()),
 				packmanager.WithTypes(c.Type()),
 				packmanager.WithRemote(opts.Update),
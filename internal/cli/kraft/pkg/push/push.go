@@ -16,6 +16,7 @@ import (
 
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
 	"kraftkit.sh/packmanager"
@@ -39,9 +40,10 @@ func Push(ctx context.Context, opts *PushOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&PushOptions{}, cobra.Command{
-		Short:   "Push a Unikraft unikernel package to registry",
-		Use:     "push [FLAGS] [PACKAGE]",
-		Aliases: []string{"ph"},
+		Short:             "Push a Unikraft unikernel package to registry",
+		Use:               "push [FLAGS] [PACKAGE]",
+		ValidArgsFunction: completion.Limit(1, completion.Packages),
+		Aliases:           []string{"ph"},
 		Long: heredoc.Doc(`
 			Push a Unikraft unikernel, component microlibrary to a remote location
 		`),
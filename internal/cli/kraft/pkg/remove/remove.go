@@ -12,6 +12,7 @@ import (
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/packmanager"
 )
 
@@ -32,11 +33,12 @@ func Remove(ctx context.Context, opts *RemoveOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&RemoveOptions{}, cobra.Command{
-		Short:   "Removes selected local packages",
-		Use:     "remove [FLAGS] [PACKAGE]",
-		Args:    cobra.ArbitraryArgs,
-		Aliases: []string{"rm"},
-		Long:    "Remove a Unikraft component.",
+		Short:             "Removes selected local packages",
+		Use:               "remove [FLAGS] [PACKAGE]",
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completion.Packages,
+		Aliases:           []string{"rm"},
+		Long:              "Remove a Unikraft component.",
 		Example: heredoc.Doc(`
 			# Remove all packages
 			kraft pkg remove --all
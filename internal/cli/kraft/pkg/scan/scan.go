@@ -0,0 +1,364 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/cpio"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/scanner"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/tui/paraprogress"
+	"kraftkit.sh/tui/processtree"
+	"kraftkit.sh/tui/selection"
+	"kraftkit.sh/unikraft/target"
+)
+
+type ScanOptions struct {
+	tableprinter.OutputOptions
+
+	Architecture string `long:"arch" short:"m" usage:"Set the architecture of the package to scan"`
+	FailOn       string `long:"fail-on" usage:"Fail if a vulnerability of at least this severity is found (negligible/low/medium/high/critical)"`
+	Platform     string `long:"plat" short:"p" usage:"Set the platform of the package to scan"`
+	Scanner      string `long:"scanner" short:"s" usage:"Set the scanner to use (auto/grype/trivy)" default:"auto"`
+
+	failOn scanner.Severity
+}
+
+// Scan the root file system of a package for known vulnerabilities.
+func Scan(ctx context.Context, opts *ScanOptions, args ...string) error {
+	if opts == nil {
+		opts = &ScanOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ScanOptions{}, cobra.Command{
+		Short:             "Scan the root file system of a package for known vulnerabilities",
+		Use:               "scan [FLAGS] PACKAGE",
+		Args:              cmdfactory.MinimumArgs(1, "package not specified"),
+		ValidArgsFunction: completion.Packages,
+		Long: heredoc.Doc(`
+			Scan the root file system of a package for known vulnerabilities.
+
+			The package is pulled if it does not yet exist in the local store, after
+			which its initramfs is extracted and scanned with an external scanner,
+			either grype or trivy, which must be installed on the host.  By default,
+			the first of the two which is installed is used.
+
+			The vulnerabilities reported by either scanner are normalised to the same
+			severities: unknown, negligible, low, medium, high and critical.  With
+			--fail-on, the command fails if a vulnerability of at least the provided
+			severity is found, e.g. to gate the release of a package in CI.
+		`),
+		Example: heredoc.Doc(`
+			# Scan a package for known vulnerabilities
+			$ kraft pkg scan unikraft.org/nginx:latest
+
+			# Scan a package with trivy and fail on high or critical vulnerabilities
+			$ kraft pkg scan --scanner trivy --fail-on high unikraft.org/nginx:latest
+
+			# Scan the package of a particular platform and architecture
+			$ kraft pkg scan --plat qemu --arch x86_64 unikraft.org/nginx:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ScanOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	if opts.FailOn != "" {
+		opts.failOn = scanner.ParseSeverity(opts.FailOn)
+		if opts.failOn == scanner.SeverityUnknown {
+			return fmt.Errorf("unknown severity: %s (choice of negligible, low, medium, high, critical)", opts.FailOn)
+		}
+	}
+
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *ScanOptions) Run(ctx context.Context, args []string) error {
+	// Fail before searching for and pulling the package if no scanner can be
+	// used.
+	scan, err := scanner.New(opts.Scanner)
+	if err != nil {
+		return err
+	}
+
+	ref := args[0]
+	parallel := !config.G[config.KraftKit](ctx).NoParallel
+	norender := log.LoggerTypeFromString(config.G[config.KraftKit](ctx).Log.Type) != log.FANCY
+
+	var packs []pack.Package
+
+	treemodel, err := processtree.NewProcessTree(
+		ctx,
+		[]processtree.ProcessTreeOption{
+			processtree.IsParallel(parallel),
+			processtree.WithRenderer(norender),
+			processtree.WithFailFast(true),
+			processtree.WithHideOnSuccess(true),
+		},
+		processtree.NewProcessTreeItem(
+			fmt.Sprintf("finding %s", ref), "",
+			func(ctx context.Context) error {
+				packs, err = packmanager.G(ctx).Catalog(ctx,
+					packmanager.WithName(ref),
+					packmanager.WithPlatform(opts.Platform),
+					packmanager.WithArchitecture(opts.Architecture),
+					packmanager.WithRemote(true),
+				)
+				return err
+			},
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := treemodel.Start(); err != nil {
+		return fmt.Errorf("could not complete search: %v", err)
+	}
+
+	if len(packs) == 0 {
+		return fmt.Errorf("could not find package '%s'", ref)
+	}
+
+	selected := packs[0]
+	if len(packs) > 1 {
+		if config.G[config.KraftKit](ctx).NoPrompt {
+			return fmt.Errorf("found %d packages named '%s' but prompting has been disabled: please set --plat and --arch", len(packs), ref)
+		}
+
+		p, err := selection.Select[pack.Package]("select package to scan", packs...)
+		if err != nil {
+			return fmt.Errorf("could not select package: %w", err)
+		}
+
+		selected = *p
+	}
+
+	if exists, _, err := selected.PulledAt(ctx); !exists || err != nil {
+		paramodel, err := paraprogress.NewParaProgress(
+			ctx,
+			[]*paraprogress.Process{paraprogress.NewProcess(
+				fmt.Sprintf("pulling %s", ref),
+				func(ctx context.Context, w func(progress float64)) error {
+					return selected.Pull(
+						ctx,
+						pack.WithPullProgressFunc(w),
+					)
+				},
+			)},
+			paraprogress.IsParallel(false),
+			paraprogress.WithRenderer(norender),
+			paraprogress.WithFailFast(true),
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := paramodel.Start(); err != nil {
+			return err
+		}
+	}
+
+	tempDir, err := os.MkdirTemp("", "kraft-scan-")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(tempDir)
+
+	unpackDir := filepath.Join(tempDir, "pkg")
+	rootfsDir := filepath.Join(tempDir, "rootfs")
+
+	if err := selected.Unpack(ctx, unpackDir); err != nil {
+		return fmt.Errorf("unpacking the package: %w", err)
+	}
+
+	if err := extractRootfs(filepath.Join(unpackDir, oci.WellKnownInitrdPath), rootfsDir); err != nil {
+		return err
+	}
+
+	var report *scanner.Report
+
+	treemodel, err = processtree.NewProcessTree(
+		ctx,
+		[]processtree.ProcessTreeOption{
+			processtree.IsParallel(parallel),
+			processtree.WithRenderer(norender),
+			processtree.WithFailFast(true),
+			processtree.WithHideOnSuccess(true),
+		},
+		processtree.NewProcessTreeItem(
+			fmt.Sprintf("scanning %s with %s", ref, scan), "",
+			func(ctx context.Context) error {
+				report, err = scan.Scan(ctx, rootfsDir)
+				return err
+			},
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := treemodel.Start(); err != nil {
+		return fmt.Errorf("could not complete scan: %v", err)
+	}
+
+	if targ, ok := selected.(target.Target); ok {
+		log.G(ctx).
+			WithField("arch", targ.Architecture().Name()).
+			WithField("plat", targ.Platform().Name()).
+			WithField("scanner", report.Scanner).
+			Debugf("found %d vulnerabilities", len(report.Vulnerabilities))
+	}
+
+	if err := printReport(ctx, opts.Output, report); err != nil {
+		return err
+	}
+
+	if opts.failOn == "" {
+		return nil
+	}
+
+	if found := report.AtLeast(opts.failOn); len(found) > 0 {
+		return fmt.Errorf("found %d vulnerabilities of severity %s or higher in '%s'", len(found), opts.failOn, ref)
+	}
+
+	return nil
+}
+
+// extractRootfs extracts the initramfs at path, which may be compressed with
+// gzip, into dir.
+func extractRootfs(path, dir string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("package has no root file system to scan")
+	} else if err != nil {
+		return fmt.Errorf("could not open root file system: %w", err)
+	}
+
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+
+	var r io.Reader = br
+
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("could not decompress root file system: %w", err)
+		}
+
+		defer gr.Close()
+
+		r = gr
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	if err := cpio.Extract(r, dir); err != nil {
+		return fmt.Errorf("could not extract root file system: %w", err)
+	}
+
+	return nil
+}
+
+// printReport prints the vulnerabilities of the report from the most to the
+// least severe.
+func printReport(ctx context.Context, format string, report *scanner.Report) error {
+	if len(report.Vulnerabilities) == 0 && format == "table" {
+		fmt.Fprintf(iostreams.G(ctx).Out, "no vulnerabilities found by %s\n", report.Scanner)
+		return nil
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(format),
+	)
+	if err != nil {
+		return err
+	}
+
+	table.AddField("ID", cs.Bold)
+	table.AddField("SEVERITY", cs.Bold)
+	table.AddField("PACKAGE", cs.Bold)
+	table.AddField("VERSION", cs.Bold)
+	table.AddField("FIXED IN", cs.Bold)
+	if format != "table" {
+		table.AddField("TITLE", cs.Bold)
+		table.AddField("URL", cs.Bold)
+	}
+	table.EndRow()
+
+	for _, vuln := range report.Vulnerabilities {
+		var color func(string) string
+		switch vuln.Severity {
+		case scanner.SeverityCritical, scanner.SeverityHigh:
+			color = cs.Red
+		case scanner.SeverityMedium:
+			color = cs.Yellow
+		}
+
+		table.AddField(vuln.ID, nil)
+		table.AddField(strings.ToUpper(vuln.Severity.String()), color)
+		table.AddField(vuln.Package, nil)
+		table.AddField(vuln.Version, nil)
+		table.AddField(vuln.FixedIn, nil)
+		if format != "table" {
+			table.AddField(vuln.Title, nil)
+			table.AddField(vuln.URL, nil)
+		}
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
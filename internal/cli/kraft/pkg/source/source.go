@@ -18,7 +18,12 @@ import (
 )
 
 type SourceOptions struct {
-	Force bool `short:"F" long:"force" usage:"Do not run a compatibility test before sourcing."`
+	CACert   string `long:"ca-cert" usage:"Path to a PEM bundle of certificate authorities to trust for this source."`
+	Force    bool   `short:"F" long:"force" usage:"Do not run a compatibility test before sourcing."`
+	Insecure bool   `long:"insecure" usage:"Do not verify the TLS certificate of this source."`
+	SSHKey   string `long:"ssh-key" usage:"Path to the SSH private key used to access this source."`
+	Token    string `long:"token" usage:"Token (or SSH key passphrase) used to access this source."`
+	User     string `long:"user" usage:"User used to access this source."`
 }
 
 // Source adds a remote location for discovering one-or-many Unikraft
@@ -39,6 +44,9 @@ func NewCmd() *cobra.Command {
 		Aliases: []string{"src"},
 		Long: heredoc.Docf(`
 			Add a remote location for discovering one-or-many Unikraft components.
+
+			Credentials and TLS settings provided via flags are saved for the source
+			in the configuration file and take precedence over those of its host.
 		`),
 		Example: heredoc.Docf(`
 			# Add a single component as a Git repository
@@ -47,6 +55,12 @@ func NewCmd() *cobra.Command {
 			# Add a manifest of components
 			$ kraft pkg source https://manifests.kraftkit.sh/index.yaml
 
+			# Add a private Git repository accessed over SSH with a specific key
+			$ kraft pkg source --ssh-key ~/.ssh/id_ed25519 git@git.example.com:acme/lib-foo.git
+
+			# Add a private index served with a self-signed certificate
+			$ kraft pkg source --token $TOKEN --ca-cert ./ca.pem https://manifests.example.com/index.yaml
+
 			# Add a Unikraft-compatible OCI compatible registry
 			$ kraft pkg source unikraft.org
 		`),
@@ -74,6 +88,22 @@ func (*SourceOptions) Pre(cmd *cobra.Command, _ []string) error {
 
 func (opts *SourceOptions) Run(ctx context.Context, args []string) error {
 	for _, source := range args {
+		// Register the credentials of the source first such that they are also
+		// used for the compatibility test.
+		if opts.hasAuth() {
+			if config.G[config.KraftKit](ctx).Auth == nil {
+				config.G[config.KraftKit](ctx).Auth = map[string]config.AuthConfig{}
+			}
+
+			config.G[config.KraftKit](ctx).Auth[source] = config.AuthConfig{
+				User:      opts.User,
+				Token:     opts.Token,
+				VerifySSL: !opts.Insecure,
+				CACert:    opts.CACert,
+				SSHKey:    opts.SSHKey,
+			}
+		}
+
 		if !opts.Force {
 			_, compatible, err := packmanager.G(ctx).IsCompatible(ctx,
 				source,
@@ -89,6 +119,12 @@ func (opts *SourceOptions) Run(ctx context.Context, args []string) error {
 		for _, manifest := range config.G[config.KraftKit](ctx).Unikraft.Manifests {
 			if source == manifest {
 				log.G(ctx).Warnf("manifest already saved: %s", source)
+
+				// Still save updated credentials of an existing source.
+				if opts.hasAuth() {
+					return config.M[config.KraftKit](ctx).Write(true)
+				}
+
 				return nil
 			}
 		}
@@ -105,3 +141,9 @@ func (opts *SourceOptions) Run(ctx context.Context, args []string) error {
 
 	return nil
 }
+
+// hasAuth returns whether credentials or TLS settings have been provided for
+// the source.
+func (opts *SourceOptions) hasAuth() bool {
+	return opts.User != "" || opts.Token != "" || opts.SSHKey != "" || opts.CACert != "" || opts.Insecure
+}
@@ -6,7 +6,9 @@ package update
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -14,13 +16,17 @@ import (
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
 	"kraftkit.sh/log"
+	"kraftkit.sh/manifest"
 	"kraftkit.sh/pack"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/tui/processtree"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/app"
 )
 
 type UpdateOptions struct {
-	Manager string `long:"manager" short:"m" usage:"Force the handler type" default:"all" local:"true"`
+	Kraftfile string `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile" local:"true"`
+	Manager   string `long:"manager" short:"m" usage:"Force the handler type" default:"all" local:"true"`
 }
 
 // Update the local index of known locations for remote Unikraft components.
@@ -37,12 +43,22 @@ func NewCmd() *cobra.Command {
 		Short:   "Retrieve new lists of Unikraft components, libraries and packages",
 		Use:     "update [FLAGS]",
 		Aliases: []string{"upd"},
-		Long: heredoc.Doc(`
+		Long: heredoc.Docf(`
 			Retrieve new lists of Unikraft components, libraries and packages.
-		`),
+
+			When run inside of a project whose Kraftfile specifies the version of the
+			Unikraft core or of a library as a semantic version range constraint, e.g.
+			'^0.16' or '~1.2', the newest version which satisfies each constraint is
+			resolved and pinned in the project's '%s'.  Subsequent builds retrieve the
+			pinned versions until the constraint is changed or the lockfile is updated
+			again.
+		`, app.LockfileName),
 		Example: heredoc.Doc(`
 			# Update the local index of known locations for remote Unikraft components
 			$ kraft pkg update
+
+			# Update the index and lock the versions of the project's components
+			$ kraft pkg update --kraftfile path/to/Kraftfile
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "pkg",
@@ -120,5 +136,96 @@ func (opts *UpdateOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	return model.Start()
+	if err := model.Start(); err != nil {
+		return err
+	}
+
+	return opts.lock(ctx)
+}
+
+// lock resolves the semantic version range constraints of the components of
+// the project in the current working directory, if there is one, and pins the
+// newest versions which satisfy them in the project's lockfile.
+func (opts *UpdateOptions) lock(ctx context.Context) error {
+	workdir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	popts := []app.ProjectOption{
+		app.WithProjectWorkdir(workdir),
+	}
+
+	if len(opts.Kraftfile) > 0 {
+		popts = append(popts, app.WithProjectKraftfile(opts.Kraftfile))
+	} else {
+		popts = append(popts, app.WithProjectDefaultKraftfiles())
+	}
+
+	project, err := app.NewProjectFromOptions(ctx, popts...)
+	if err != nil && errors.Is(err, app.ErrNoKraftfile) && len(opts.Kraftfile) == 0 {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not initialize project directory: %w", err)
+	}
+
+	components, err := project.Components(ctx)
+	if err != nil {
+		return err
+	}
+
+	lockfile := project.Lockfile()
+	if lockfile == nil {
+		lockfile = &app.Lockfile{}
+	}
+
+	changed := false
+
+	for _, component := range components {
+		if !manifest.IsVersionConstraint(component.Version()) {
+			continue
+		}
+
+		packages, err := packmanager.G(ctx).Catalog(ctx,
+			packmanager.WithName(component.Name()),
+			packmanager.WithTypes(component.Type()),
+			packmanager.WithVersion(component.Version()),
+			packmanager.WithSource(component.Source()),
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(packages) == 0 {
+			return fmt.Errorf("could not resolve: %s", unikraft.TypeNameVersion(component))
+		}
+
+		version := packages[0].Version()
+
+		if locked, ok := lockfile.Locked(component); ok && locked == version {
+			continue
+		}
+
+		log.G(ctx).
+			WithField("constraint", component.Version()).
+			WithField("version", version).
+			Infof("locking %s", unikraft.TypeNameVersion(component))
+
+		lockfile.Lock(component, version)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	// The lockfile is kept next to the Kraftfile, which is not necessarily in
+	// the current working directory when it is provided with --kraftfile.
+	if err := lockfile.Save(app.LockfileDir(project.Kraftfile(), project.WorkingDir())); err != nil {
+		return fmt.Errorf("could not save %s: %w", app.LockfileName, err)
+	}
+
+	log.G(ctx).Infof("updated %s: run 'kraft build --force-pull' to retrieve the locked versions", app.LockfileName)
+
+	return nil
 }
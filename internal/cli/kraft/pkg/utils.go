@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/target"
 )
 
 // initProject sets up the project based on the provided context and
@@ -37,13 +38,16 @@ func (opts *PkgOptions) initProject(ctx context.Context) error {
 	return nil
 }
 
-// aggregateEnvs aggregates the environment variables from the project and
-// the cli options, filling in missing values with the host environment.
-func (opts *PkgOptions) aggregateEnvs() []string {
+// aggregateEnvs aggregates the environment variables from the project, its
+// provided target and the cli options, filling in missing values with the host
+// environment.
+func (opts *PkgOptions) aggregateEnvs(targ target.Target) []string {
 	envs := make(map[string]string)
 
-	if opts.Project != nil && opts.Project.Env() != nil {
-		envs = opts.Project.Env()
+	if opts.Project != nil {
+		for k, v := range opts.Project.TargetEnv(targ) {
+			envs[k] = v
+		}
 	}
 
 	// Add the cli environment
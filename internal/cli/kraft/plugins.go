@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package kraft
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli"
+	kitversion "kraftkit.sh/internal/version"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/plugins"
+)
+
+// registerPlugins surfaces each discovered executable plugin as a subcommand
+// of the provided root command.  Plugins cannot shadow built-in commands.
+func registerPlugins(root *cobra.Command, copts *cli.CliOptions) error {
+	if copts.PluginManager == nil {
+		return nil
+	}
+
+	executables, err := copts.PluginManager.Executables()
+	if err != nil {
+		return err
+	}
+
+	reserved := map[string]bool{
+		"help":       true,
+		"completion": true,
+	}
+	for _, sub := range root.Commands() {
+		reserved[sub.Name()] = true
+		for _, alias := range sub.Aliases {
+			reserved[alias] = true
+		}
+	}
+
+	for _, plugin := range executables {
+		if reserved[plugin.Name()] {
+			if copts.Logger != nil {
+				copts.Logger.Debugf("ignoring plugin '%s' which shadows a built-in command", plugin.Path())
+			}
+			continue
+		}
+
+		reserved[plugin.Name()] = true
+		root.AddCommand(newPluginCmd(plugin, copts))
+	}
+
+	return nil
+}
+
+// newPluginCmd wraps an executable plugin in a command which passes through all
+// arguments verbatim.
+func newPluginCmd(plugin *plugins.Plugin, copts *cli.CliOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:                plugin.Name(),
+		Short:              fmt.Sprintf("Run the %s plugin", plugin.Name()),
+		Long:               fmt.Sprintf("Run the %s plugin located at %s.", plugin.Name(), plugin.Path()),
+		DisableFlagParsing: true,
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "misc",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			pctx, err := pluginContext(copts)
+			if err != nil {
+				return err
+			}
+
+			ecmd, err := plugin.Command(ctx, *pctx, args...)
+			if err != nil {
+				return err
+			}
+
+			ios := iostreams.G(ctx)
+			ecmd.Stdin = ios.In
+			ecmd.Stdout = ios.Out
+			ecmd.Stderr = ios.ErrOut
+
+			log.G(ctx).
+				WithField("path", plugin.Path()).
+				Debug("running plugin")
+
+			if err := ecmd.Run(); err != nil {
+				return fmt.Errorf("plugin '%s': %w", plugin.Name(), err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// pluginContext builds the shared context which is handed to plugins.  The
+// configuration is re-encoded through YAML so that plugins observe the same
+// keys as those used in the configuration file.  Any executable on the PATH
+// can be a plugin, hence credentials are never part of the context.
+func pluginContext(copts *cli.CliOptions) (*plugins.PluginContext, error) {
+	pctx := &plugins.PluginContext{
+		Version: kitversion.Version(),
+	}
+
+	if copts.ConfigManager == nil || copts.ConfigManager.Config == nil {
+		return pctx, nil
+	}
+
+	b, err := yaml.Marshal(withoutCredentials(*copts.ConfigManager.Config))
+	if err != nil {
+		return nil, fmt.Errorf("could not encode configuration: %w", err)
+	}
+
+	var cfg map[string]any
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("could not decode configuration: %w", err)
+	}
+
+	pctx.ConfigFile = copts.ConfigManager.ConfigFile
+	pctx.Config = cfg
+	pctx.LogLevel = copts.ConfigManager.Config.Log.Level
+	pctx.LogType = copts.ConfigManager.Config.Log.Type
+
+	return pctx, nil
+}
+
+// withoutCredentials returns a copy of the configuration from which the
+// registry credentials, cloud tokens and proxy passwords have been removed.
+func withoutCredentials(cfg config.KraftKit) config.KraftKit {
+	cfg.Auth = nil
+	cfg.HTTP.Proxy = withoutUserinfo(cfg.HTTP.Proxy)
+
+	if cfg.Contexts != nil {
+		contexts := make(map[string]config.Context, len(cfg.Contexts))
+		for name, kctx := range cfg.Contexts {
+			kctx.Auth = nil
+			kctx.HTTPProxy = withoutUserinfo(kctx.HTTPProxy)
+			kctx.HTTPSProxy = withoutUserinfo(kctx.HTTPSProxy)
+			contexts[name] = kctx
+		}
+
+		cfg.Contexts = contexts
+	}
+
+	return cfg
+}
+
+// withoutUserinfo removes the username and password from the proxy URL, if
+// any.
+func withoutUserinfo(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return proxy
+	}
+
+	u.User = nil
+
+	return u.String()
+}
@@ -8,11 +8,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
-	"kraftkit.sh/internal/cli/kraft/cloud/utils"
 	"kraftkit.sh/internal/tableprinter"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
@@ -24,12 +24,13 @@ import (
 )
 
 type PsOptions struct {
+	tableprinter.OutputOptions
+
 	Architecture string `long:"arch" short:"m" usage:"Filter the list by architecture"`
 	Long         bool   `long:"long" short:"l" usage:"Show more information"`
 	platform     string
-	Quiet        bool   `long:"quiet" short:"q" usage:"Only display machine IDs"`
-	ShowAll      bool   `long:"all" short:"a" usage:"Show all machines (default shows just running)"`
-	Output       string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
+	Quiet        bool `long:"quiet" short:"q" usage:"Only display machine IDs"`
+	ShowAll      bool `long:"all" short:"a" usage:"Show all machines (default shows just running)"`
 }
 
 const (
@@ -52,6 +53,9 @@ func NewCmd() *cobra.Command {
 
 			# List all unikernels with more information
 			$ kraft ps --long
+
+			# List all unikernels with their resources, image and uptime
+			$ kraft ps --all -o wide
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
@@ -77,26 +81,30 @@ func NewCmd() *cobra.Command {
 func (opts *PsOptions) Pre(cmd *cobra.Command, _ []string) error {
 	opts.platform = cmd.Flag("plat").Value.String()
 
-	if !utils.IsValidOutputFormat(opts.Output) {
-		return fmt.Errorf("invalid output format: %s", opts.Output)
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
 type PsEntry struct {
-	ID      string
-	Name    string
-	Kernel  string
-	Args    string
-	Created string
-	State   machineapi.MachineState
-	Mem     string
-	Ports   string
-	Pid     int32
-	Arch    string
-	Plat    string
-	IPs     []string
+	ID         string
+	Name       string
+	Kernel     string
+	Args       string
+	Created    string
+	State      machineapi.MachineState
+	Mem        string
+	CurrentMem string
+	CPUs       string
+	Ports      string
+	Pid        int32
+	Arch       string
+	Plat       string
+	IPs        []string
+	Digest     string
+	Uptime     string
 }
 
 type colorFunc func(string) string
@@ -185,15 +193,27 @@ func (opts *PsOptions) PsTable(ctx context.Context) ([]PsEntry, error) {
 			Kernel:  machine.Spec.Kernel,
 			State:   machine.Status.State,
 			Mem:     machine.Spec.Resources.Requests.Memory().String(),
+			CPUs:    machine.Spec.Resources.Requests.Cpu().String(),
 			Created: humanize.Time(machine.ObjectMeta.CreationTimestamp.Time),
 			Arch:    machine.Spec.Architecture,
 			Pid:     machine.Status.Pid,
 			Plat:    machine.Spec.Platform,
 			IPs:     []string{},
+			Digest:  machine.Spec.ImageDigest,
+		}
+
+		// The current memory is reported live by the driver, e.g. via the
+		// balloon device of the machine, when it was listed.
+		if machine.Status.CurrentMemory > 0 {
+			entry.CurrentMem = humanize.IBytes(uint64(machine.Status.CurrentMemory))
 		}
 
 		if machine.Status.State == machineapi.MachineStateRunning {
 			entry.Ports = machine.Spec.Ports.String()
+
+			if !machine.Status.StartedAt.IsZero() {
+				entry.Uptime = time.Since(machine.Status.StartedAt).Round(time.Second).String()
+			}
 		}
 
 		for _, net := range machine.Spec.Networks {
@@ -218,31 +238,43 @@ func (opts *PsOptions) PrintPsTable(ctx context.Context, items []PsEntry) error
 
 	cs := iostreams.G(ctx).ColorScheme()
 
-	table, err := tableprinter.NewTablePrinter(ctx,
-		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
-		tableprinter.WithOutputFormatFromString(opts.Output),
-	)
+	// The wide output includes everything shown with --long as well as the
+	// resources, image and uptime of each machine.
+	wide := opts.Wide()
+	long := opts.Long || wide
+
+	table, err := opts.NewTablePrinter(ctx)
 	if err != nil {
 		return err
 	}
 
 	// Header row
-	if opts.Long {
+	if long {
 		table.AddField("MACHINE ID", cs.Bold)
 	}
 	table.AddField("NAME", cs.Bold)
 	table.AddField("KERNEL", cs.Bold)
+	if wide {
+		table.AddField("DIGEST", cs.Bold)
+	}
 	table.AddField("ARGS", cs.Bold)
 	table.AddField("CREATED", cs.Bold)
 	table.AddField("STATUS", cs.Bold)
+	if wide {
+		table.AddField("UPTIME", cs.Bold)
+		table.AddField("CPUS", cs.Bold)
+	}
 	table.AddField("MEM", cs.Bold)
+	if wide {
+		table.AddField("CURRENT MEM", cs.Bold)
+	}
 	table.AddField("PORTS", cs.Bold)
-	if opts.Long {
+	if long {
 		table.AddField("IP", cs.Bold)
 		table.AddField("PID", cs.Bold)
 	}
 	table.AddField("PLAT", cs.Bold)
-	if opts.Long {
+	if long {
 		table.AddField("ARCH", cs.Bold)
 	}
 	table.EndRow()
@@ -252,24 +284,34 @@ func (opts *PsOptions) PrintPsTable(ctx context.Context, items []PsEntry) error
 	}
 
 	for _, item := range items {
-		if opts.Long {
+		if long {
 			table.AddField(item.ID, nil)
 		}
 		table.AddField(item.Name, nil)
 		table.AddField(item.Kernel, nil)
+		if wide {
+			table.AddField(item.Digest, nil)
+		}
 		table.AddField(item.Args, nil)
 		table.AddField(item.Created, nil)
 		table.AddField(item.State.String(), MachineStateColor[item.State])
+		if wide {
+			table.AddField(item.Uptime, nil)
+			table.AddField(item.CPUs, nil)
+		}
 		table.AddField(item.Mem, nil)
+		if wide {
+			table.AddField(item.CurrentMem, nil)
+		}
 		table.AddField(item.Ports, nil)
-		if opts.Long {
+		if long {
 			table.AddField(strings.Join(item.IPs, ","), nil)
 			table.AddField(fmt.Sprintf("%d", item.Pid), nil)
 			table.AddField(item.Plat, nil)
 		} else {
 			table.AddField(fmt.Sprintf("%s/%s", item.Plat, item.Arch), nil)
 		}
-		if opts.Long {
+		if long {
 			table.AddField(item.Arch, nil)
 		}
 		table.EndRow()
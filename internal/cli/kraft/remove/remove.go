@@ -7,6 +7,7 @@ package remove
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -15,6 +16,8 @@ import (
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network"
@@ -23,8 +26,12 @@ import (
 )
 
 type RemoveOptions struct {
-	All      bool   `long:"all" usage:"Remove all machines"`
-	Platform string `noattribute:"true"`
+	All      bool     `long:"all" usage:"Remove all machines"`
+	DryRun   bool     `long:"dry-run" usage:"Print the machines which would be removed without removing them"`
+	Filter   []string `long:"filter" short:"f" usage:"Select machines matching the filter, in the format key=value or key!=value (label, name, status or plat)"`
+	Platform string   `noattribute:"true"`
+
+	prompt bool
 }
 
 // Remove stops and deletes a local Unikraft virtual machine.
@@ -38,16 +45,26 @@ func Remove(ctx context.Context, opts *RemoveOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&RemoveOptions{}, cobra.Command{
-		Short:   "Remove one or more running unikernels",
-		Use:     "remove [FLAGS] MACHINE [MACHINE [...]]",
-		Args:    cobra.MinimumNArgs(0),
-		Aliases: []string{"rm"},
+		Short:             "Remove one or more running unikernels",
+		Use:               "remove [FLAGS] MACHINE [MACHINE [...]]",
+		Args:              cobra.MinimumNArgs(0),
+		ValidArgsFunction: completion.Machines,
+		Aliases:           []string{"rm"},
 		Long: heredoc.Doc(`
-			Remove one or more running unikernels
+			Remove one or more running unikernels.
+
+			If no machines are provided, the machines to remove can be selected
+			interactively, unless prompting is disabled via --no-prompt.
 		`),
 		Example: heredoc.Doc(`
 			# Remove a running unikernel
 			$ kraft rm my-machine
+
+			# Remove all exited unikernels
+			$ kraft rm --filter status=exited
+
+			# List the unikernels which would be removed by a filter
+			$ kraft rm --dry-run --filter label=env=staging
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
@@ -70,22 +87,28 @@ func NewCmd() *cobra.Command {
 	return cmd
 }
 
-func (opts *RemoveOptions) Pre(cmd *cobra.Command, _ []string) error {
+func (opts *RemoveOptions) Pre(cmd *cobra.Command, args []string) error {
+	// Only prompt for the machines to remove when invoked from the command-line.
+	opts.prompt = len(args) == 0 && !opts.All && len(opts.Filter) == 0
+
 	opts.Platform = cmd.Flag("plat").Value.String()
 	return nil
 }
 
 func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
-	var err error
-
-	if len(args) == 0 && !opts.All {
+	if len(args) == 0 && !opts.All && len(opts.Filter) == 0 && !opts.prompt {
 		return fmt.Errorf("no machine(s) specified")
 	}
 
+	filters, err := utils.ParseMachineFilters(opts.Filter)
+	if err != nil {
+		return err
+	}
+
 	platform := mplatform.PlatformUnknown
 	var controller machineapi.MachineService
 
-	if opts.All || opts.Platform == "auto" {
+	if opts.All || len(args) == 0 || opts.Platform == "auto" {
 		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
 	} else {
 		if opts.Platform == "host" {
@@ -119,125 +142,160 @@ func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
 
 	var remove []machineapi.Machine
 
-	for _, machine := range machines.Items {
-		if len(args) == 0 && opts.All {
-			remove = append(remove, machine)
-			continue
+	if opts.prompt {
+		remove, err = utils.PromptMachines(ctx, "remove", machines.Items)
+		if err != nil {
+			return err
 		}
-
-		if args[0] == machine.Name || args[0] == string(machine.UID) {
-			remove = append(remove, machine)
+	} else {
+		remove = utils.SelectMachines(machines.Items, args, opts.All, filters)
+		if len(remove) == 0 {
+			return fmt.Errorf("machine(s) not found")
 		}
 	}
 
-	if len(remove) == 0 {
-		return fmt.Errorf("machine(s) not found")
+	if opts.DryRun {
+		for _, machine := range remove {
+			fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+		}
+
+		return nil
 	}
 
 	netcontrollers := make(map[string]networkapi.NetworkService, 0)
 
+	removing := make(map[string]bool, len(remove))
 	for _, machine := range remove {
-		// First remove all the associated network interfaces.
-		for _, net := range machine.Spec.Networks {
-			netcontroller, ok := netcontrollers[net.Driver]
+		removing[string(machine.UID)] = true
+	}
 
-			// Store the instantiation of the network controller strategy.
-			if !ok {
-				strategy, ok := network.Strategies()[net.Driver]
-				if !ok {
-					return fmt.Errorf("unknown machine network driver: %s", net.Driver)
-				}
+	// Releasing the networks and volumes of a machine updates state which may
+	// be shared with other machines, so it is not done concurrently.
+	var mu sync.Mutex
 
-				netcontroller, err = strategy.NewNetworkV1alpha1(ctx)
-				if err != nil {
-					return err
-				}
+	return utils.ForEachMachine(ctx, remove, func(ctx context.Context, machine *machineapi.Machine) error {
+		mu.Lock()
+		err := opts.release(ctx, controller, netcontrollers, removing, machine)
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		// Stop the machine before deleting it.
+		if _, err := controller.Stop(ctx, machine); err != nil {
+			log.G(ctx).Errorf("could not stop machine %s: %v", machine.Name, err)
+		}
+
+		// Now delete the machine.
+		if _, err := controller.Delete(ctx, machine); err != nil {
+			return fmt.Errorf("could not delete machine: %w", err)
+		}
+
+		fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+
+		return nil
+	})
+}
 
-				netcontrollers[net.Driver] = netcontroller
+// release detaches the provided machine from its networks and marks volumes
+// which are no longer used by any other machine, besides those which are being
+// removed, as pending.
+func (opts *RemoveOptions) release(ctx context.Context, controller machineapi.MachineService, netcontrollers map[string]networkapi.NetworkService, removing map[string]bool, machine *machineapi.Machine) error {
+	var err error
+
+	// First remove all the associated network interfaces.
+	for _, net := range machine.Spec.Networks {
+		// The user-mode network is torn down along with the machine's VMM.
+		if net.Driver == network.DriverUser {
+			continue
+		}
+
+		netcontroller, ok := netcontrollers[net.Driver]
+
+		// Store the instantiation of the network controller strategy.
+		if !ok {
+			strategy, ok := network.Strategies()[net.Driver]
+			if !ok {
+				return fmt.Errorf("unknown machine network driver: %s", net.Driver)
 			}
 
-			networks, err := netcontroller.List(ctx, &networkapi.NetworkList{})
+			netcontroller, err = strategy.NewNetworkV1alpha1(ctx)
 			if err != nil {
 				return err
 			}
-			var found *networkapi.Network
 
-			for _, network := range networks.Items {
-				if network.Spec.IfName == net.IfName {
-					found = &network
-					break
-				}
-			}
-			if found == nil {
-				log.G(ctx).Warnf("could not get network information for %s", net.IfName)
-				continue
+			netcontrollers[net.Driver] = netcontroller
+		}
+
+		networks, err := netcontroller.List(ctx, &networkapi.NetworkList{})
+		if err != nil {
+			return err
+		}
+		var found *networkapi.Network
+
+		for _, network := range networks.Items {
+			if network.Spec.IfName == net.IfName {
+				found = &network
+				break
 			}
+		}
+		if found == nil {
+			log.G(ctx).Warnf("could not get network information for %s", net.IfName)
+			continue
+		}
 
+		// Remove the associated network interfaces from the latest version of the
+		// network.
+		if _, err := network.UpdateV1alpha1(ctx, found.Name, func(latest *networkapi.Network) error {
 			for _, machineIface := range net.Interfaces {
-				// Remove the associated network interfaces
-				for i, netIface := range found.Spec.Interfaces {
+				for i, netIface := range latest.Spec.Interfaces {
 					if machineIface.UID == netIface.UID {
 						ret := make([]networkapi.NetworkInterfaceTemplateSpec, 0)
-						ret = append(ret, found.Spec.Interfaces[:i]...)
-						found.Spec.Interfaces = append(ret, found.Spec.Interfaces[i+1:]...)
+						ret = append(ret, latest.Spec.Interfaces[:i]...)
+						latest.Spec.Interfaces = append(ret, latest.Spec.Interfaces[i+1:]...)
 						break
 					}
 				}
-
-				if _, err = netcontroller.Update(ctx, found); err != nil {
-					log.G(ctx).Warnf("could not update network %s: %v", net.IfName, err)
-					continue
-				}
 			}
+
+			return nil
+		}); err != nil {
+			log.G(ctx).Warnf("could not update network %s: %v", net.IfName, err)
 		}
+	}
 
-		// Update volume information.
-		if len(machine.Spec.Volumes) > 0 {
-			volumeController, err := volume.NewVolumeV1alpha1ServiceIterator(ctx)
+	// Update volume information.
+	if len(machine.Spec.Volumes) > 0 {
+		for _, vol := range machine.Spec.Volumes {
+			stillUsed := false
+			allMachines, err := controller.List(ctx, &machineapi.MachineList{})
 			if err != nil {
-				return fmt.Errorf("could not get volume controller: %v", err)
+				return err
 			}
-			for _, vol := range machine.Spec.Volumes {
-				stillUsed := false
-				allMachines, err := controller.List(ctx, &machineapi.MachineList{})
-				if err != nil {
-					return err
+			for _, m := range allMachines.Items {
+				if removing[string(m.ObjectMeta.UID)] {
+					continue
 				}
-				for _, m := range allMachines.Items {
-					if m.ObjectMeta.UID == machine.ObjectMeta.UID {
-						continue
-					}
-					for _, v := range m.Spec.Volumes {
-						if v.ObjectMeta.UID == vol.ObjectMeta.UID {
-							stillUsed = true
-							break
-						}
-					}
-
-					if stillUsed {
+				for _, v := range m.Spec.Volumes {
+					if v.ObjectMeta.UID == vol.ObjectMeta.UID {
+						stillUsed = true
 						break
 					}
 				}
 
-				if !stillUsed {
-					vol.Status.State = volumeapi.VolumeStatePending
-					if _, err := volumeController.Update(ctx, &vol); err != nil {
-						log.G(ctx).Warnf("could not update volume %s: %v", vol.Name, err)
-					}
+				if stillUsed {
+					break
 				}
 			}
-		}
 
-		// Stop the machine before deleting it.
-		if _, err := controller.Stop(ctx, &machine); err != nil {
-			log.G(ctx).Errorf("could not stop machine %s: %v", machine.Name, err)
-		}
-
-		// Now delete the machine.
-		if _, err := controller.Delete(ctx, &machine); err != nil {
-			log.G(ctx).Errorf("could not delete machine %s: %v", machine.Name, err)
-		} else {
-			fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+			if !stillUsed {
+				if _, err := volume.UpdateV1alpha1(ctx, vol.Name, func(latest *volumeapi.Volume) error {
+					latest.Status.State = volumeapi.VolumeStatePending
+					return nil
+				}); err != nil {
+					log.G(ctx).Warnf("could not update volume %s: %v", vol.Name, err)
+				}
+			}
 		}
 	}
 
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package run
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/machine/qemu"
+)
+
+// kernelArgsVolume is a volume of a machine as available to kernel argument
+// templates.
+type kernelArgsVolume struct {
+	// Tag with which the volume is exposed to the guest, if applicable.
+	Tag         string
+	Source      string
+	Destination string
+	Driver      string
+}
+
+// kernelArgsData contains the values of a machine which are available to
+// kernel argument templates, e.g. `myapp.hostname={{ .Name }}`.
+type kernelArgsData struct {
+	Name    string
+	ID      string
+	IP      string
+	IPs     []string
+	Volumes []kernelArgsVolume
+}
+
+// templateKernelArgs appends the kernel arguments provided via --append to
+// those of the machine and renders each of them as a template, such that
+// per-instance values can be passed without repackaging the unikernel.
+func (opts *RunOptions) templateKernelArgs(_ context.Context, machine *machineapi.Machine) error {
+	machine.Spec.KernelArgs = append(machine.Spec.KernelArgs, opts.Append...)
+
+	if len(machine.Spec.KernelArgs) == 0 {
+		return nil
+	}
+
+	if machine.ObjectMeta.UID == "" {
+		machine.ObjectMeta.UID = uuid.NewUUID()
+	}
+
+	data := kernelArgsData{
+		Name: machine.Name,
+		ID:   string(machine.UID),
+	}
+
+	for _, network := range machine.Spec.Networks {
+		for _, iface := range network.Interfaces {
+			ip, _, _ := strings.Cut(iface.Spec.CIDR, "/")
+			data.IPs = append(data.IPs, ip)
+		}
+	}
+
+	if len(data.IPs) > 0 {
+		data.IP = data.IPs[0]
+	}
+
+	for i, vol := range machine.Spec.Volumes {
+		volume := kernelArgsVolume{
+			Source:      vol.Spec.Source,
+			Destination: vol.Spec.Destination,
+			Driver:      vol.Spec.Driver,
+		}
+
+		if vol.Spec.Driver == "9pfs" {
+			volume.Tag = qemu.MountTag(i)
+		}
+
+		data.Volumes = append(data.Volumes, volume)
+	}
+
+	for i, arg := range machine.Spec.KernelArgs {
+		if !strings.Contains(arg, "{{") {
+			continue
+		}
+
+		tmpl, err := template.New("").Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return fmt.Errorf("parsing kernel argument '%s': %w", arg, err)
+		}
+
+		var rendered strings.Builder
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return fmt.Errorf("rendering kernel argument '%s': %w", arg, err)
+		}
+
+		machine.Spec.KernelArgs[i] = rendered.String()
+	}
+
+	return nil
+}
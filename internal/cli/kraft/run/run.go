@@ -8,10 +8,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,6 +22,8 @@ import (
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/cli/kraft/inspect"
 	"kraftkit.sh/internal/cli/kraft/start"
 	"kraftkit.sh/internal/set"
 	"kraftkit.sh/iostreams"
@@ -30,34 +35,63 @@ import (
 )
 
 type RunOptions struct {
-	Architecture  string   `long:"arch" short:"m" usage:"Set the architecture"`
-	Detach        bool     `long:"detach" short:"d" usage:"Run unikernel in background"`
-	DisableAccel  bool     `long:"disable-acceleration" short:"W" usage:"Disable acceleration of CPU (usually enables TCG)"`
-	Env           []string `long:"env" short:"e" usage:"Set environment variables, int the format key[=value]"`
-	InitRd        string   `long:"initrd" usage:"Use the specified initrd (readonly)" hidden:"true"`
-	IP            string   `long:"ip" usage:"Assign the provided IP address"`
-	KernelArgs    []string `long:"kernel-arg" short:"a" usage:"Set additional kernel arguments"`
-	Kraftfile     string   `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
-	MacAddress    string   `long:"mac" usage:"Assign the provided MAC address"`
-	Memory        string   `long:"memory" short:"M" usage:"Assign memory to the unikernel (K/Ki, M/Mi, G/Gi)" default:"64Mi"`
-	Name          string   `long:"name" short:"n" usage:"Name of the instance"`
-	Networks      []string `long:"network" usage:"Attach instance to the provided network, in the format <network>[:ip[/mask][:gw[:dns0[:dns1[:hostname[:domain]]]]]], e.g. kraft0:172.100.0.2"`
-	NoStart       bool     `long:"no-start" usage:"Do not start the machine"`
-	Platform      string   `noattribute:"true"`
-	Ports         []string `long:"port" short:"p" usage:"Publish a machine's port(s) to the host" split:"false"`
-	Prefix        string   `long:"prefix" usage:"Prefix each log line with the given string"`
-	PrefixName    bool     `long:"prefix-name" usage:"Prefix each log line with the machine name"`
-	Remove        bool     `long:"rm" usage:"Automatically remove the unikernel when it shutsdown"`
-	Rootfs        string   `long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
-	RunAs         string   `long:"as" usage:"Force a specific runner"`
-	Runtime       string   `long:"runtime" short:"r" usage:"Set an alternative unikernel runtime"`
-	Target        string   `long:"target" short:"t" usage:"Explicitly use the defined project target"`
-	Volumes       []string `long:"volume" short:"v" usage:"Bind a volume to the instance"`
-	WithKernelDbg bool     `long:"symbolic" usage:"Use the debuggable (symbolic) unikernel"`
+	Accel         string        `long:"accel" usage:"Set the accelerator of the VMM (kvm, hvf, whpx, xen, tcg), or auto to fall back to emulation if the host hypervisor is unavailable (QEMU only)"`
+	Append        []string      `long:"append" usage:"Append kernel arguments to those of the unikernel, in the format library.param=value"`
+	Architecture  string        `long:"arch" short:"m" usage:"Set the architecture"`
+	CPUFeatures   []string      `long:"cpu-features" usage:"Enable (+) or disable (-) features of the virtual CPU, e.g. +avx2,-aes (QEMU only)"`
+	CPUModel      string        `long:"cpu-model" usage:"Set the model of the virtual CPU, e.g. Skylake-Server (QEMU), or the CPU template, e.g. T2 (Firecracker)"`
+	CPUs          int           `long:"cpus" usage:"Number of vCPUs to assign to the unikernel"`
+	Detach        bool          `long:"detach" short:"d" usage:"Run unikernel in background"`
+	DetachKeys    string        `long:"detach-keys" usage:"Key sequence which detaches from an interactive unikernel, e.g. ctrl-p,ctrl-q"`
+	DisableAccel  bool          `long:"disable-acceleration" short:"W" usage:"Disable acceleration of CPU (usually enables TCG)"`
+	DropCaps      bool          `long:"drop-caps" usage:"Drop the capabilities of the VMM by running it as an unprivileged user (QEMU only)"`
+	DryRun        bool          `long:"dry-run" usage:"Print the specification of the machine which would be created, without creating any resources or starting a VMM"`
+	Env           []string      `long:"env" short:"e" usage:"Set environment variables, in the format key[=value]"`
+	EnvFile       []string      `long:"env-file" usage:"Read in a file of environment variables"`
+	Entrypoint    string        `long:"entrypoint" usage:"Override the arguments which precede the command of the package"`
+	InitRd        string        `long:"initrd" usage:"Use the specified initrd (readonly)" deprecated:"use --rootfs instead"`
+	Interactive   bool          `long:"interactive" short:"i" usage:"Forward standard input to the console of the unikernel (QEMU only)" conflicts-with:"detach"`
+	IP            string        `long:"ip" usage:"Assign the provided IP address on the network, which must be within its subnet and not in use"`
+	KernelArgs    []string      `long:"kernel-arg" short:"a" usage:"Set additional kernel arguments"`
+	Kraftfile     string        `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Labels        []string      `long:"label" usage:"Set a label on the instance, in the format key[=value]"`
+	LogDriver     string        `long:"log-driver" usage:"Record the console output of the unikernel with the provided driver (raw, json-file, none)"`
+	LogOpts       []string      `long:"log-opt" usage:"Set an option of the log driver, in the format key=value (max-size, max-file)"`
+	MachineType   string        `long:"machine-type" usage:"Set the type of machine emulated by the VMM, e.g. pc, q35 or microvm (QEMU only)"`
+	MacAddress    string        `long:"mac" usage:"Assign the provided MAC address on the network, which must not be in use"`
+	Memory        string        `long:"memory" short:"M" usage:"Assign memory to the unikernel (K/Ki, M/Mi, G/Gi)" default:"64Mi"`
+	Metrics       bool          `long:"metrics" usage:"Expose the ukstore counters of the unikernel to 'kraft metrics'"`
+	Name          string        `long:"name" short:"n" usage:"Name of the instance"`
+	Networks      []string      `long:"network" usage:"Attach instance to the provided network, in the format <network>[:ip[/mask][:gw[:dns0[:dns1[:hostname[:domain]]]]]], e.g. kraft0:172.100.0.2, or 'user' for rootless user-mode networking"`
+	NoCache       bool          `long:"no-cache" usage:"Do not use cached metadata of remote catalogs"`
+	NoPVClock     bool          `long:"no-pvclock" usage:"Hide paravirtualized clocks (e.g. kvmclock) from the unikernel"`
+	NoRNG         bool          `long:"no-rng" usage:"Do not attach a paravirtualized random number generator to the unikernel"`
+	NoStart       bool          `long:"no-start" usage:"Do not start the machine"`
+	Platform      string        `noattribute:"true"`
+	Ports         []string      `long:"port" short:"p" usage:"Publish a machine's port(s) to the host" split:"false"`
+	Prefix        string        `long:"prefix" usage:"Prefix each log line with the given string"`
+	PrefixName    bool          `long:"prefix-name" usage:"Prefix each log line with the machine name"`
+	Preset        string        `long:"preset" usage:"Apply the named preset of run flags from the configuration"`
+	Pull          string        `long:"pull" usage:"Pull the package before running (always, missing, never)" default:"missing"`
+	Quiet         bool          `noattribute:"true"`
+	Remove        bool          `long:"rm" usage:"Automatically remove the unikernel, its logs and its anonymous volumes when it exits"`
+	RTC           string        `long:"rtc" usage:"Set the base of the real-time clock of the unikernel (utc, localtime)"`
+	Rootfs        string        `long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
+	RootfsLayers  []string      `long:"rootfs-layer" usage:"Apply the provided path as an initramfs layer over the root file system, e.g. the files of the application over a cached runtime (can be repeated)"`
+	RunAs         string        `long:"as" usage:"Force a specific runner"`
+	Runtime       string        `long:"runtime" short:"r" usage:"Set an alternative unikernel runtime"`
+	Seccomp       bool          `long:"seccomp" usage:"Restrict the system calls of the VMM with seccomp (QEMU only)"`
+	SyncTime      bool          `long:"sync-time" usage:"Keep the clock of the unikernel in step with the host whilst paused"`
+	Target        string        `long:"target" short:"t" usage:"Explicitly use the defined project target"`
+	Timeout       time.Duration `long:"timeout" usage:"Stop the unikernel and fail if it has not exited within the provided duration, e.g. 120s"`
+	VMMUser       string        `long:"vmm-user" usage:"Run the VMM as the provided unprivileged user once it has initialised (QEMU only)"`
+	Volumes       []string      `long:"volume" short:"v" usage:"Bind a volume to the instance, in the format <host>:<machine>[:<options>], or <machine> for an anonymous volume"`
+	WithKernelDbg bool          `long:"symbolic" usage:"Use the debuggable (symbolic) unikernel"`
 
 	workdir           string
 	platform          mplatform.Platform
 	machineController machineapi.MachineService
+	entrypointSet     bool
 }
 
 // Run a Unikraft unikernel virtual machine locally.
@@ -71,11 +105,23 @@ func Run(ctx context.Context, opts *RunOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&RunOptions{}, cobra.Command{
-		Short:   "Run a unikernel",
-		Use:     "run [FLAGS] PROJECT|PACKAGE|BINARY -- [APP ARGS]",
-		Aliases: []string{"r"},
+		Short:             "Run a unikernel",
+		Use:               "run [FLAGS] PROJECT|PACKAGE|BINARY -- [APP ARGS]",
+		ValidArgsFunction: completion.Limit(1, completion.Packages),
+		Aliases:           []string{"r"},
 		Long: heredoc.Doc(`
 			Run a unikernel virtual machine
+
+			Kernel arguments, including those recorded in the project or package and
+			those provided via --append or --kernel-arg, are Go templates which can
+			refer to the following values of the instance:
+
+			  {{ .Name }}     the name of the instance
+			  {{ .ID }}       the unique identifier of the instance
+			  {{ .IP }}       the first IP address of the instance
+			  {{ .IPs }}      all IP addresses of the instance
+			  {{ .Volumes }}  the volumes of the instance, each with a Tag, Source,
+			                  Destination and Driver
 		`),
 		Example: heredoc.Doc(`
 			Run a built target in the current working directory project:
@@ -96,9 +142,55 @@ func NewCmd() *cobra.Command {
 			Run an OCI-compatible unikernel, mapping port 8080 on the host to port 80 in the unikernel:
 			$ kraft run -p 8080:80 unikraft.org/nginx:latest
 
+			Run an OCI-compatible unikernel by its immutable digest, without contacting the registry:
+			$ kraft run --pull never unikraft.org/nginx@sha256:...
+
+			Run a long-running unikernel whose real-time clock starts from the host's local time and keeps up with the host whilst paused:
+			$ kraft run --rtc localtime --sync-time unikraft.org/nginx:latest
+
+			Append a kernel argument to those of the unikernel, templated with the name of the instance:
+			$ kraft run --append "myapp.hostname={{ .Name }}" unikraft.org/nginx:latest
+
 			Attach the unikernel to an existing network kraft0:
 			$ kraft run --network kraft0
 
+			Attach the unikernel to an existing network kraft0 with a static IP and MAC address:
+			$ kraft run --network kraft0 --ip 172.100.0.10 --mac 02:b0:b0:00:00:0a unikraft.org/nginx:latest
+
+			Run a unikernel as an unprivileged user with outbound networking, mapping port 8080 on the host to port 80 in the unikernel:
+			$ kraft run --network user -p 8080:80 unikraft.org/nginx:latest
+
+			Run a unikernel with the memory, networks and volumes of the preset 'web'
+			defined in the configuration file, overriding its memory:
+			$ kraft run --preset web --memory 256Mi unikraft.org/nginx:latest
+
+			Run an OCI-compatible unikernel with environment variables set on the command-line and read from a file:
+			$ kraft run -e FOO=bar --env-file ./app.env unikraft.org/nginx:latest
+
+			Run an OCI-compatible unikernel, overriding the command recorded in the package:
+			$ kraft run unikraft.org/nginx:latest -- -c /nginx/conf/custom.conf
+
+			Run a unikernel and additionally record its output as JSON lines, which are rotated every 10MiB:
+			$ kraft run --log-driver json-file --log-opt max-size=10MiB unikraft.org/nginx:latest
+
+			Run a unikernel with QEMU emulating a q35 machine accelerated by KVM:
+			$ kraft run --plat qemu --machine-type q35 --accel kvm unikraft.org/nginx:latest
+
+			Run a unikernel on a virtual CPU of a given model with AVX2 enabled and AES disabled, e.g. to reproduce the CPU of a customer:
+			$ kraft run --plat qemu --cpu-model Skylake-Server --cpu-features +avx2,-aes unikraft.org/nginx:latest
+
+			Run a test unikernel in CI, stopping it and failing with exit code 124 if it has not exited after 2 minutes:
+			$ kraft run --rm --timeout 120s ./path/to/tests
+
+			Run a unikernel with KVM if it is available and otherwise fall back to slower software emulation:
+			$ kraft run --accel auto unikraft.org/nginx:latest
+
+			Run the arm64 variant of a multi-architecture package on an x86_64 host through emulation:
+			$ kraft run --plat qemu --arch arm64 unikraft.org/nginx:latest
+
+			Run a shell or REPL unikernel interactively, detaching from it with ctrl-p,ctrl-q:
+			$ kraft run -i unikraft.org/python:3.12
+
 			Run a Linux userspace binary in POSIX-/binary-compatibility mode:
 			$ kraft run a.out
 
@@ -114,8 +206,14 @@ func NewCmd() *cobra.Command {
 			Supply a read-only root file system at / via initramfs CPIO archive and mount a bi-directional volume at /dir:
 			$ kraft run --rootfs ./initramfs.cpio --volume ./path/to/dir:/dir
 
+			Mount a volume at /data with a larger 9P message size and without caching in the unikernel:
+			$ kraft run -v ./path/to/data:/data:msize=1048576,cache=none
+
 			Customize the default content directory of the official Unikraft NGINX OCI-compatible unikernel and map port 8080 to localhost:
 			$ kraft run -v ./path/to/html:/nginx/html -p 8080:80 unikraft.org/nginx:latest
+
+			Print the specification of the machine which would be run, e.g. to preview it in automation:
+			$ kraft run --dry-run --network kraft0 -p 8080:80 unikraft.org/nginx:latest
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
@@ -142,6 +240,79 @@ func (opts *RunOptions) Pre(cmd *cobra.Command, _ []string) error {
 
 	opts.Platform = cmd.Flag("plat").Value.String()
 
+	// An empty --entrypoint clears the entrypoint of the package, hence its
+	// presence rather than its value decides whether it is overridden.
+	opts.entrypointSet = cmd.Flags().Changed("entrypoint")
+
+	if opts.Preset != "" {
+		preset, err := config.G[config.KraftKit](ctx).Preset(opts.Preset)
+		if err != nil {
+			return err
+		}
+
+		opts.applyPreset(preset, cmd.Flags())
+	}
+
+	if !slices.Contains(pullPolicies(), opts.Pull) {
+		return fmt.Errorf("unsupported pull policy: %s (choice of %v)", opts.Pull, pullPolicies())
+	}
+
+	if opts.RTC != "" && !slices.Contains(machineapi.MachineRTCBases(), machineapi.MachineRTCBase(opts.RTC)) {
+		return fmt.Errorf("unsupported RTC base: %s (choice of %v)", opts.RTC, machineapi.MachineRTCBases())
+	}
+
+	if opts.DetachKeys != "" {
+		if _, err := start.ParseDetachKeys(opts.DetachKeys); err != nil {
+			return err
+		}
+	}
+
+	if opts.CPUs < 0 {
+		return fmt.Errorf("number of vCPUs must not be negative")
+	}
+
+	if opts.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	} else if opts.Timeout > 0 && (opts.Detach || opts.NoStart) {
+		return fmt.Errorf("the --timeout flag cannot be used with --detach or --no-start")
+	}
+
+	if opts.LogDriver != "" && !slices.Contains(machineapi.MachineLogDrivers(), machineapi.MachineLogDriver(opts.LogDriver)) {
+		return fmt.Errorf("unsupported log driver: %s (choice of %v)", opts.LogDriver, machineapi.MachineLogDrivers())
+	}
+
+	// The JSON logs are written by kraft itself whilst it follows the console
+	// output of the unikernel, which it stops doing once it has detached.
+	if opts.Detach && machineapi.MachineLogDriver(opts.LogDriver) == machineapi.MachineLogDriverJSONFile {
+		return fmt.Errorf("the %s log driver cannot be used with --detach", machineapi.MachineLogDriverJSONFile)
+	}
+
+	if opts.Accel == "" {
+		opts.Accel = config.G[config.KraftKit](ctx).QemuAccel
+	}
+
+	if opts.Accel != "" && !slices.Contains(machineapi.MachineAccelerators(), machineapi.MachineAccelerator(opts.Accel)) {
+		return fmt.Errorf("unsupported accelerator: %s (choice of %v)", opts.Accel, machineapi.MachineAccelerators())
+	}
+
+	if opts.DisableAccel && opts.Accel != "" && opts.Accel != string(machineapi.MachineAcceleratorAuto) && opts.Accel != string(machineapi.MachineAcceleratorTCG) {
+		return fmt.Errorf("cannot use accelerator %s when acceleration is disabled", opts.Accel)
+	}
+
+	if opts.MachineType == "" {
+		opts.MachineType = config.G[config.KraftKit](ctx).QemuMachine
+	}
+
+	for _, feature := range opts.CPUFeatures {
+		if _, _, err := machineapi.ParseMachineCPUFeature(feature); err != nil {
+			return err
+		}
+	}
+
+	if opts.NoCache {
+		config.G[config.KraftKit](ctx).Catalog.NoCache = true
+	}
+
 	if opts.RunAs == "" || !set.NewStringSet("kernel", "project").Contains(opts.RunAs) {
 		// Set use of the global package manager.
 		ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
@@ -171,8 +342,6 @@ func (opts *RunOptions) Pre(cmd *cobra.Command, _ []string) error {
 	}
 
 	if opts.InitRd != "" {
-		log.G(ctx).Warn("the --initrd flag is deprecated in favour of --rootfs")
-
 		if opts.Rootfs != "" {
 			log.G(ctx).Warn("both --initrd and --rootfs are set! ignorning value of --initrd")
 		} else {
@@ -195,6 +364,34 @@ func (opts *RunOptions) Pre(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
+// applyPreset sets the values of the preset for each flag which was not
+// explicitly provided, i.e. on the command line or through its environmental
+// variable, such that the preset takes precedence over the defaults of the
+// flags.  Networks, volumes, ports and environment variables of the preset are
+// combined with those provided.
+func (opts *RunOptions) applyPreset(preset config.Preset, flags *pflag.FlagSet) {
+	if preset.Memory != "" && !flags.Changed("memory") {
+		opts.Memory = preset.Memory
+	}
+
+	if preset.CPUs > 0 && !flags.Changed("cpus") {
+		opts.CPUs = preset.CPUs
+	}
+
+	if preset.Platform != "" && !flags.Changed("plat") {
+		opts.Platform = preset.Platform
+	}
+
+	if preset.Architecture != "" && !flags.Changed("arch") {
+		opts.Architecture = preset.Architecture
+	}
+
+	opts.Networks = slices.Concat(preset.Networks, opts.Networks)
+	opts.Volumes = slices.Concat(preset.Volumes, opts.Volumes)
+	opts.Ports = slices.Concat(preset.Ports, opts.Ports)
+	opts.Env = slices.Concat(preset.Env, opts.Env)
+}
+
 func (opts *RunOptions) discoverMachineController(ctx context.Context) error {
 	var err error
 
@@ -257,6 +454,8 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		}
 	}
 
+	hardening := config.G[config.KraftKit](ctx).Hardening
+
 	machine := &machineapi.Machine{
 		ObjectMeta: metav1.ObjectMeta{},
 		Spec: machineapi.MachineSpec{
@@ -265,9 +464,35 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 				Requests: corev1.ResourceList{},
 			},
 			Emulation: opts.DisableAccel,
+			NoRNG:     opts.NoRNG,
+			Metrics:   opts.Metrics,
+			Clock: machineapi.MachineClock{
+				RTCBase:      machineapi.MachineRTCBase(opts.RTC),
+				NoParavirt:   opts.NoPVClock,
+				SyncOnResume: opts.SyncTime,
+			},
+			Hardening: machineapi.MachineHardening{
+				Seccomp:          opts.Seccomp || hardening.Seccomp,
+				DropCapabilities: opts.DropCaps || hardening.DropCapabilities,
+				User:             opts.VMMUser,
+			},
+			VMM: machineapi.MachineVMM{
+				MachineType: opts.MachineType,
+				Accelerator: machineapi.MachineAccelerator(opts.Accel),
+				CPUModel:    opts.CPUModel,
+				CPUFeatures: opts.CPUFeatures,
+			},
 		},
 	}
 
+	if machine.Spec.Hardening.User == "" {
+		machine.Spec.Hardening.User = hardening.User
+	}
+
+	if opts.platform == mplatform.PlatformQEMU {
+		machine.Spec.VMM.Binary = config.G[config.KraftKit](ctx).Qemu
+	}
+
 	// Preemptively assign ports which can return early with an error if they are
 	// already in use.
 	if err := opts.assignPorts(ctx, machine); err != nil {
@@ -350,14 +575,28 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		machine.Spec.Resources.Requests[corev1.ResourceMemory] = quantity
 	}
 
-	if err := opts.parseNetworks(ctx, machine); err != nil {
+	if opts.CPUs > 0 {
+		machine.Spec.Resources.Requests[corev1.ResourceCPU] = *resource.NewQuantity(int64(opts.CPUs), resource.DecimalSI)
+	}
+
+	if err := opts.parseLabels(ctx, machine); err != nil {
+		return err
+	}
+
+	if err := opts.parseLogging(ctx, machine); err != nil {
 		return err
 	}
 
+	// The name is assigned first such that the addresses which are reserved for
+	// the machine on its networks can be looked up.
 	if err := opts.assignName(ctx, machine); err != nil {
 		return err
 	}
 
+	if err := opts.parseNetworks(ctx, machine); err != nil {
+		return err
+	}
+
 	if err := opts.parseVolumes(ctx, machine); err != nil {
 		return err
 	}
@@ -366,10 +605,24 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if err := opts.prepareRootfsLayers(ctx, machine); err != nil {
+		return err
+	}
+
 	if err := opts.parseEnvs(ctx, machine); err != nil {
 		return err
 	}
 
+	if err := opts.templateKernelArgs(ctx, machine); err != nil {
+		return err
+	}
+
+	// The machine is fully specified at this point, such that a dry run can
+	// report what would be created without invoking the VMM.
+	if opts.DryRun {
+		return inspect.Print(ctx, "json", inspect.FromMachine(machine))
+	}
+
 	// Create the machine
 	machine, err = opts.machineController.Create(ctx, machine)
 	if err != nil {
@@ -377,14 +630,20 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 	}
 
 	if opts.NoStart {
-		// Output the name of the instance such that it can be piped
-		fmt.Fprintf(iostreams.G(ctx).Out, "%s\n", machine.Name)
+		// Output the name of the instance such that it can be piped, unless the
+		// caller manages the machine itself.
+		if !opts.Quiet {
+			fmt.Fprintf(iostreams.G(ctx).Out, "%s\n", machine.Name)
+		}
 		return nil
 	}
 
 	return start.Start(ctx, &start.StartOptions{
-		Detach:   opts.Detach,
-		Platform: opts.platform.String(),
-		Remove:   opts.Remove,
+		Detach:      opts.Detach,
+		DetachKeys:  opts.DetachKeys,
+		Interactive: opts.Interactive,
+		Platform:    opts.platform.String(),
+		Remove:      opts.Remove,
+		Timeout:     opts.Timeout,
 	}, machine.Name)
 }
@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package run
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+)
+
+func TestApplyPreset(t *testing.T) {
+	preset := config.Preset{
+		Memory:       "1Gi",
+		CPUs:         2,
+		Platform:     "fc",
+		Architecture: "arm64",
+		Networks:     []string{"kraft0"},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		memory   string
+		cpus     int
+		plat     string
+		arch     string
+		networks []string
+	}{
+		{
+			name:     "preset fills defaults",
+			memory:   "1Gi",
+			cpus:     2,
+			plat:     "fc",
+			arch:     "arm64",
+			networks: []string{"kraft0"},
+		},
+		{
+			name:     "explicit flags win",
+			args:     []string{"--memory=256Mi", "--cpus=4", "--plat=qemu", "--arch=x86_64", "--network=user"},
+			memory:   "256Mi",
+			cpus:     4,
+			plat:     "qemu",
+			arch:     "x86_64",
+			networks: []string{"kraft0", "user"},
+		},
+		{
+			name:     "explicit default wins",
+			args:     []string{"--memory=64Mi"},
+			memory:   "64Mi",
+			cpus:     2,
+			plat:     "fc",
+			arch:     "arm64",
+			networks: []string{"kraft0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &RunOptions{}
+			cmd := &cobra.Command{Use: "run"}
+
+			// The flags are attributed as for the command, which populates them with
+			// the defaults of their structure attributes.
+			if err := cmdfactory.AttributeFlags(cmd, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd.Flags().String("plat", "auto", "")
+
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			opts.Platform = cmd.Flag("plat").Value.String()
+			opts.Networks, _ = cmd.Flags().GetStringSlice("network")
+
+			opts.applyPreset(preset, cmd.Flags())
+
+			if opts.Memory != tt.memory {
+				t.Errorf("expected memory %s, got %s", tt.memory, opts.Memory)
+			}
+			if opts.CPUs != tt.cpus {
+				t.Errorf("expected %d vCPUs, got %d", tt.cpus, opts.CPUs)
+			}
+			if opts.Platform != tt.plat {
+				t.Errorf("expected platform %s, got %s", tt.plat, opts.Platform)
+			}
+			if opts.Architecture != tt.arch {
+				t.Errorf("expected architecture %s, got %s", tt.arch, opts.Architecture)
+			}
+			if len(opts.Networks) != len(tt.networks) {
+				t.Fatalf("expected networks %v, got %v", tt.networks, opts.Networks)
+			}
+			for i := range tt.networks {
+				if opts.Networks[i] != tt.networks[i] {
+					t.Errorf("expected networks %v, got %v", tt.networks, opts.Networks)
+				}
+			}
+		})
+	}
+}
+
+func TestPreRejectsDetachedJSONLogs(t *testing.T) {
+	opts := &RunOptions{}
+	cmd := &cobra.Command{Use: "run"}
+
+	if err := cmdfactory.AttributeFlags(cmd, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd.Flags().String("plat", "auto", "")
+	cmd.SetContext(context.Background())
+
+	if err := cmd.ParseFlags([]string{"--detach", "--log-driver=json-file"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := opts.Pre(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "--detach") {
+		t.Errorf("expected detaching with JSON logs to be rejected, got %v", err)
+	}
+}
+
+func TestPreRejectsTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "negative", args: []string{"--timeout=-1s"}},
+		{name: "detached", args: []string{"--timeout=2m", "--detach"}},
+		{name: "not started", args: []string{"--timeout=2m", "--no-start"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &RunOptions{}
+			cmd := &cobra.Command{Use: "run"}
+
+			if err := cmdfactory.AttributeFlags(cmd, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd.Flags().String("plat", "auto", "")
+			cmd.SetContext(context.Background())
+
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := opts.Pre(cmd, nil); err == nil || !strings.Contains(err.Error(), "timeout") {
+				t.Errorf("expected timeout to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPreEntrypoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected bool
+	}{
+		{name: "unset", args: []string{}, expected: false},
+		{name: "empty", args: []string{"--entrypoint="}, expected: true},
+		{name: "override", args: []string{"--entrypoint=/bin/sh -c"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &RunOptions{}
+			cmd := &cobra.Command{Use: "run"}
+
+			if err := cmdfactory.AttributeFlags(cmd, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd.Flags().String("plat", "auto", "")
+			cmd.SetContext(context.Background())
+
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			_ = opts.Pre(cmd, nil)
+
+			if opts.entrypointSet != tt.expected {
+				t.Errorf("expected the entrypoint to be overridden: %t, got: %t", tt.expected, opts.entrypointSet)
+			}
+		})
+	}
+}
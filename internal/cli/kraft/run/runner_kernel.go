@@ -76,6 +76,12 @@ func (runner *runnerKernel) Prepare(ctx context.Context, opts *RunOptions, machi
 			machine.Spec.Architecture = arch.ArchitectureArm.String()
 		case elf.EM_AARCH64:
 			machine.Spec.Architecture = arch.ArchitectureArm64.String()
+		case elf.EM_RISCV:
+			if fe.Class != elf.ELFCLASS64 {
+				return fmt.Errorf("unsupported kernel architecture: 32-bit %v", fe.Machine.String())
+			}
+
+			machine.Spec.Architecture = arch.ArchitectureRiscv64.String()
 		default:
 			return fmt.Errorf("unsupported kernel architecture: %v", fe.Machine.String())
 		}
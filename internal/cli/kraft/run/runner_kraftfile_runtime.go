@@ -323,6 +323,10 @@ func (runner *runnerKraftfileRuntime) Prepare(ctx context.Context, opts *RunOpti
 	machine.Spec.Platform = runtime.Platform().Name()
 	machine.Spec.Kernel = fmt.Sprintf("%s://%s:%s", packs[0].Format(), runtime.Name(), runtime.Version())
 
+	if digested, ok := found.(interface{ Digest() string }); ok {
+		machine.Spec.ImageDigest = digested.Digest()
+	}
+
 	// Use the symbolic debuggable kernel image?
 	if opts.WithKernelDbg {
 		machine.Status.KernelPath = runtime.KernelDbg()
@@ -379,7 +383,7 @@ func (runner *runnerKraftfileRuntime) Prepare(ctx context.Context, opts *RunOpti
 		return err
 	}
 
-	if err := opts.parseKraftfileEnv(ctx, runner.project, machine); err != nil {
+	if err := opts.parseKraftfileEnv(ctx, runner.project.Env(), machine); err != nil {
 		return err
 	}
 
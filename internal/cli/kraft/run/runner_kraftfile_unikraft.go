@@ -143,7 +143,7 @@ func (runner *runnerKraftfileUnikraft) Prepare(ctx context.Context, opts *RunOpt
 	machine.Spec.Platform = t.Platform().Name()
 
 	if len(runner.args) == 0 {
-		runner.args = runner.project.Command()
+		runner.args = runner.project.TargetCommand(t)
 	}
 
 	noEmbedded := t.KConfig().AllNoOrUnset(
@@ -151,8 +151,8 @@ func (runner *runnerKraftfileUnikraft) Prepare(ctx context.Context, opts *RunOpt
 		"CONFIG_LIBVFSCORE_AUTOMOUNT_CI_EINITRD",
 	)
 
-	if runner.project.Rootfs() != "" && opts.Rootfs == "" && noEmbedded {
-		opts.Rootfs = runner.project.Rootfs()
+	if rootfs := runner.project.TargetRootfs(t); rootfs != "" && opts.Rootfs == "" && noEmbedded {
+		opts.Rootfs = rootfs
 	}
 
 	// If automounting is enabled, and an initramfs is provided, set it as a
@@ -202,7 +202,7 @@ func (runner *runnerKraftfileUnikraft) Prepare(ctx context.Context, opts *RunOpt
 		return err
 	}
 
-	if err := opts.parseKraftfileEnv(ctx, runner.project, machine); err != nil {
+	if err := opts.parseKraftfileEnv(ctx, runner.project.TargetEnv(t), machine); err != nil {
 		return err
 	}
 
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/mattn/go-shellwords"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
 
@@ -31,6 +32,28 @@ import (
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+const (
+	// pullPolicyAlways always retrieves the package from its remote registry,
+	// such that mutable references resolve to their latest version.
+	pullPolicyAlways = "always"
+
+	// pullPolicyMissing retrieves the package from its remote registry only if
+	// it is not available locally.
+	pullPolicyMissing = "missing"
+
+	// pullPolicyNever only uses packages which are available locally.
+	pullPolicyNever = "never"
+)
+
+// pullPolicies returns the list of supported values for the --pull flag.
+func pullPolicies() []string {
+	return []string{
+		pullPolicyAlways,
+		pullPolicyMissing,
+		pullPolicyNever,
+	}
+}
+
 // runnerPackage is a runner for a package defined through a respective
 // compatible package manager.  Utilizing the PackageManger interface,
 // determination of whether the provided positional argument represents a
@@ -38,6 +61,10 @@ import (
 // is referenced which contains a pre-built Unikraft unikernel.  E.g.:
 //
 //	$ kraft run unikraft.org/helloworld:latest
+//
+// Packages can also be referenced immutably by their digest, e.g.:
+//
+//	$ kraft run unikraft.org/helloworld@sha256:...
 type runnerPackage struct {
 	packName string
 	args     []string
@@ -82,7 +109,7 @@ func (runner *runnerPackage) Runnable(ctx context.Context, opts *RunOptions, arg
 		runner.packName,
 		packmanager.WithArchitecture(opts.Architecture),
 		packmanager.WithPlatform(opts.platform.String()),
-		packmanager.WithRemote(true),
+		packmanager.WithRemote(opts.Pull != pullPolicyNever),
 	)
 	if err == nil && compatible {
 		runner.pm = pm
@@ -111,7 +138,15 @@ func (runner *runnerPackage) Prepare(ctx context.Context, opts *RunOptions, mach
 		qopts = append(qopts, packmanager.WithPlatform(opts.Platform))
 	}
 
-	// First try the local cache of the catalog
+	// Unless pulling is always requested, first try the local cache of the
+	// catalog.
+	if opts.Pull == pullPolicyAlways {
+		qopts = append(qopts,
+			packmanager.WithLocal(false),
+			packmanager.WithRemote(true),
+		)
+	}
+
 	var packs []pack.Package
 
 	treemodel, err := processtree.NewProcessTree(
@@ -144,7 +179,7 @@ func (runner *runnerPackage) Prepare(ctx context.Context, opts *RunOptions, mach
 
 	if err != nil {
 		return fmt.Errorf("could not query catalog: %w", err)
-	} else if len(packs) == 0 {
+	} else if len(packs) == 0 && opts.Pull != pullPolicyNever && opts.Pull != pullPolicyAlways {
 		log.G(ctx).Debug("no local packages detected")
 
 		// Try again with a remote update request.
@@ -179,6 +214,14 @@ func (runner *runnerPackage) Prepare(ctx context.Context, opts *RunOptions, mach
 		}
 	}
 
+	if len(packs) == 0 {
+		if opts.Pull == pullPolicyNever {
+			return fmt.Errorf("could not find package '%s' locally and pulling is disabled", runner.packName)
+		}
+
+		return fmt.Errorf("could not find package '%s'", runner.packName)
+	}
+
 	var selected pack.Package
 
 	if len(packs) == 1 {
@@ -238,7 +281,12 @@ func (runner *runnerPackage) Prepare(ctx context.Context, opts *RunOptions, mach
 		}
 	}()
 
-	if exists, _, err := selected.PulledAt(ctx); !exists || err != nil {
+	exists, _, err := selected.PulledAt(ctx)
+	if opts.Pull == pullPolicyNever && (!exists || err != nil) {
+		return fmt.Errorf("package '%s' has not been pulled and pulling is disabled", runner.packName)
+	}
+
+	if opts.Pull == pullPolicyAlways || !exists || err != nil {
 		paramodel, err := paraprogress.NewParaProgress(
 			ctx,
 			[]*paraprogress.Process{paraprogress.NewProcess(
@@ -289,13 +337,29 @@ func (runner *runnerPackage) Prepare(ctx context.Context, opts *RunOptions, mach
 	machine.Spec.Platform = targ.Platform().Name()
 	machine.Spec.Kernel = fmt.Sprintf("%s://%s", runner.pm.Format(), runner.packName)
 
+	if digested, ok := selected.(interface{ Digest() string }); ok {
+		machine.Spec.ImageDigest = digested.Digest()
+	}
+
 	// If no arguments have been specified, use the ones which are default and
 	// that have been included in the package.
 	if len(runner.args) == 0 {
 		runner.args = targ.Command()
 	}
 
-	machine.Spec.ApplicationArgs = runner.args
+	// Unlike the command, the entrypoint of the package always precedes the
+	// arguments, unless it is explicitly overridden.
+	var entrypoint []string
+	if opts.entrypointSet || len(opts.Entrypoint) > 0 {
+		entrypoint, err = shellwords.Parse(opts.Entrypoint)
+		if err != nil {
+			return fmt.Errorf("could not parse entrypoint: %w", err)
+		}
+	} else if ep, ok := selected.(interface{ Entrypoint() []string }); ok {
+		entrypoint = ep.Entrypoint()
+	}
+
+	machine.Spec.ApplicationArgs = append(append([]string{}, entrypoint...), runner.args...)
 
 	// Set the path to the initramfs if present.
 	var ramfs initrd.Initrd
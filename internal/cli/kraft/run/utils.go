@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/containerd/nerdctl/pkg/strutil"
+	dockeropts "github.com/docker/cli/opts"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
 
@@ -18,15 +19,78 @@ import (
 	"kraftkit.sh/config"
 	"kraftkit.sh/initrd"
 	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/internal/crilog"
 	"kraftkit.sh/log"
 	machinename "kraftkit.sh/machine/name"
 	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/iputils"
 	"kraftkit.sh/machine/volume"
 	"kraftkit.sh/tui/processtree"
 	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/app"
+	volumecfg "kraftkit.sh/unikraft/app/volume"
 )
 
+// parseLabels sets the labels provided on the command-line on the machine, such
+// that it can later be selected with a filter, e.g. `kraft stop --filter
+// label=app=web`.
+func (opts *RunOptions) parseLabels(_ context.Context, machine *machineapi.Machine) error {
+	if len(opts.Labels) == 0 {
+		return nil
+	}
+
+	if machine.ObjectMeta.Labels == nil {
+		machine.ObjectMeta.Labels = make(map[string]string, len(opts.Labels))
+	}
+
+	for _, label := range opts.Labels {
+		key, value, _ := strings.Cut(label, "=")
+		if key == "" {
+			return fmt.Errorf("invalid label '%s': expected key[=value]", label)
+		}
+
+		machine.ObjectMeta.Labels[key] = value
+	}
+
+	return nil
+}
+
+// parseLogging sets the log driver of the machine and its options, which are
+// validated such that the machine is not created with options which its
+// driver would ignore.
+func (opts *RunOptions) parseLogging(_ context.Context, machine *machineapi.Machine) error {
+	if opts.LogDriver == "" && len(opts.LogOpts) == 0 {
+		return nil
+	}
+
+	machine.Spec.Logging.Driver = machineapi.MachineLogDriver(opts.LogDriver)
+
+	if len(opts.LogOpts) == 0 {
+		return nil
+	}
+
+	if machine.Spec.Logging.Driver == machineapi.MachineLogDriverNone {
+		return fmt.Errorf("log options cannot be set when the output is discarded")
+	}
+
+	machine.Spec.Logging.Options = make(map[string]string, len(opts.LogOpts))
+
+	for _, opt := range opts.LogOpts {
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid log option '%s': expected key=value", opt)
+		}
+
+		machine.Spec.Logging.Options[key] = value
+	}
+
+	if _, _, err := crilog.ParseRotation(machine.Spec.Logging.Options, 0, 1); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // Are we publishing ports? E.g. -p/--ports=127.0.0.1:80:8080/tcp ...
 func (opts *RunOptions) assignPorts(ctx context.Context, machine *machineapi.Machine) error {
 	if len(opts.Ports) == 0 {
@@ -51,6 +115,16 @@ func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.M
 		return fmt.Errorf("the --ip flag only works when providing exactly one network")
 	}
 
+	if opts.MacAddress != "" {
+		if len(opts.Networks) != 1 {
+			return fmt.Errorf("the --mac flag only works when providing exactly one network")
+		}
+
+		if _, err := net.ParseMAC(opts.MacAddress); err != nil {
+			return fmt.Errorf("invalid MAC address: %w", err)
+		}
+	}
+
 	if len(opts.Networks) == 0 {
 		return nil
 	}
@@ -65,6 +139,26 @@ func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.M
 		split := strings.SplitN(networkArg, ":", 2)
 		networkName := split[0]
 
+		// The user-mode network is provided by the VMM itself and is therefore
+		// not managed by a network driver.
+		if networkName == network.DriverUser {
+			if len(split) > 1 || opts.IP != "" {
+				return fmt.Errorf("the %s network does not accept addressing options", network.DriverUser)
+			}
+
+			for _, existing := range machineNetworks {
+				if existing.Driver == network.DriverUser {
+					return fmt.Errorf("the %s network can only be attached once", network.DriverUser)
+				}
+			}
+
+			spec := network.NewUserNetworkSpec()
+			spec.Interfaces[0].Spec.MacAddress = opts.MacAddress
+
+			machineNetworks = append(machineNetworks, spec)
+			continue
+		}
+
 		networkServiceIterator, err := network.NewNetworkV1alpha1ServiceIterator(ctx)
 		if err != nil {
 			return err
@@ -76,7 +170,19 @@ func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.M
 				Name: networkName,
 			},
 		})
-		if err != nil {
+		if err != nil && opts.DryRun {
+			// The network may be created before the machine is, e.g. by a compose
+			// project, so its absence does not prevent previewing the machine.
+			log.G(ctx).
+				WithField("network", networkName).
+				Warnf("could not find network: %v", err)
+
+			found = &networkapi.Network{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: networkName,
+				},
+			}
+		} else if err != nil {
 			return err
 		}
 
@@ -86,12 +192,6 @@ func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.M
 			fields := strings.Split(split[1], ":")
 			if len(fields) > 0 && fields[0] != "" {
 				interfaceSpec.CIDR = fields[0]
-				ipMaskSplit := strings.SplitN(interfaceSpec.CIDR, "/", 2)
-				if len(ipMaskSplit) != 2 {
-					sz, _ := net.IPMask(net.ParseIP(found.Spec.Netmask).To4()).Size()
-					interfaceSpec.CIDR = fmt.Sprintf("%s/%d", interfaceSpec.CIDR, sz)
-				}
-				opts.IP = ipMaskSplit[0]
 			}
 
 			if len(fields) > 1 {
@@ -111,10 +211,42 @@ func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.M
 			}
 		}
 
+		if opts.IP != "" {
+			if interfaceSpec.CIDR != "" {
+				return fmt.Errorf("cannot use the --ip flag together with an IP address in --network=%s", networkArg)
+			}
+
+			interfaceSpec.CIDR = opts.IP
+		}
+
+		interfaceSpec.MacAddress = opts.MacAddress
+
 		if interfaceSpec.Gateway == "" {
 			interfaceSpec.Gateway = found.Spec.Gateway
 		}
 
+		// Use the address which is reserved for the machine on the network, unless
+		// another one has been requested, and refuse those reserved for others.
+		if interfaceSpec.CIDR == "" {
+			if ip, ok := found.Spec.Reservation(machine.Name); ok {
+				interfaceSpec.CIDR = ip
+			}
+		} else if ip, _, _ := strings.Cut(interfaceSpec.CIDR, "/"); ip != "" {
+			if owner, ok := found.Spec.ReservedFor(ip); ok && owner != machine.Name {
+				return fmt.Errorf("IP address %s on network %s is reserved for machine %s", ip, networkName, owner)
+			}
+		}
+
+		// Complete the address with the mask of the network.
+		if interfaceSpec.CIDR != "" && !strings.Contains(interfaceSpec.CIDR, "/") && found.Spec.Netmask != "" {
+			sz, _ := net.IPMask(net.ParseIP(found.Spec.Netmask).To4()).Size()
+			interfaceSpec.CIDR = fmt.Sprintf("%s/%d", interfaceSpec.CIDR, sz)
+		}
+
+		if err := checkInterfaceAddresses(found, interfaceSpec); err != nil {
+			return err
+		}
+
 		// Generate the UID pre-emptively so that we can uniquely reference the
 		// network interface which will allow us to clean it up later. Additionally,
 		// it's OK if the IP or MAC address are empty, the network controller will
@@ -127,16 +259,26 @@ func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.M
 			Spec: interfaceSpec,
 		}
 
-		// Update the list of interfaces
-		if found.Spec.Interfaces == nil {
-			found.Spec.Interfaces = []networkapi.NetworkInterfaceTemplateSpec{}
-		}
-		found.Spec.Interfaces = append(found.Spec.Interfaces, newIface)
+		// Update the network with the new interface.  Since other machines may be
+		// attached to the network in the meantime, the interface is added to the
+		// network as it is stored, against which its addresses are checked again.
+		// During a dry run the interface is only planned, leaving its addresses to
+		// be assigned by the network controller.
+		if opts.DryRun {
+			found.Spec.Interfaces = append(found.Spec.Interfaces, newIface)
+		} else {
+			found, err = network.UpdateV1alpha1(ctx, found.Name, func(latest *networkapi.Network) error {
+				if err := checkInterfaceAddresses(latest, interfaceSpec); err != nil {
+					return err
+				}
 
-		// Update the network with the new interface.
-		found, err = networkServiceIterator.Update(ctx, found)
-		if err != nil {
-			return err
+				latest.Spec.Interfaces = append(latest.Spec.Interfaces, newIface)
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
 		}
 
 		// Only use the single new interface.
@@ -157,6 +299,57 @@ func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.M
 	return nil
 }
 
+// checkInterfaceAddresses validates the statically requested IP and MAC
+// addresses of an interface against the subnet of the network and ensures that
+// they are not already in use by another interface on the network.
+func checkInterfaceAddresses(found *networkapi.Network, iface networkapi.NetworkInterfaceSpec) error {
+	if iface.CIDR != "" {
+		ip, ipnet, err := net.ParseCIDR(iface.CIDR)
+		if err != nil {
+			return fmt.Errorf("invalid IP address: %w", err)
+		}
+
+		// The subnet of a network which does not exist yet, e.g. during a dry
+		// run, is unknown.
+		if netmask := net.ParseIP(found.Spec.Netmask).To4(); netmask != nil {
+			gateway := net.ParseIP(found.Spec.Gateway)
+			subnet := &net.IPNet{
+				IP:   gateway.Mask(net.IPMask(netmask)),
+				Mask: net.IPMask(netmask),
+			}
+
+			if !subnet.Contains(ip) || ipnet.String() != subnet.String() {
+				return fmt.Errorf("IP address %s is not within the subnet %s of network %s", iface.CIDR, subnet.String(), found.Name)
+			}
+
+			if ip.Equal(gateway) || ip.Equal(subnet.IP) || !iputils.IsUnicastIP(ip.To4(), subnet.Mask) {
+				return fmt.Errorf("IP address %s cannot be assigned to a machine on network %s", ip, found.Name)
+			}
+		}
+
+		for _, existing := range found.Spec.Interfaces {
+			if existingIP, _, err := net.ParseCIDR(existing.Spec.CIDR); err == nil && existingIP.Equal(ip) {
+				return fmt.Errorf("IP address %s is already in use on network %s", ip, found.Name)
+			}
+		}
+	}
+
+	if iface.MacAddress != "" {
+		mac, err := net.ParseMAC(iface.MacAddress)
+		if err != nil {
+			return fmt.Errorf("invalid MAC address: %w", err)
+		}
+
+		for _, existing := range found.Spec.Interfaces {
+			if existingMac, err := net.ParseMAC(existing.Spec.MacAddress); err == nil && existingMac.String() == mac.String() {
+				return fmt.Errorf("MAC address %s is already in use on network %s", mac, found.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
 // assignName determines the machine instance's name either from a provided
 // argument or randomly generates one.
 func (opts *RunOptions) assignName(ctx context.Context, machine *machineapi.Machine) error {
@@ -182,7 +375,7 @@ func (opts *RunOptions) assignName(ctx context.Context, machine *machineapi.Mach
 	return nil
 }
 
-// Was a volume specified? E.g. --volume=path:path
+// Was a volume specified? E.g. --volume=path:path[:options]
 func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Machine) error {
 	if len(opts.Volumes) == 0 {
 		return nil
@@ -196,12 +389,23 @@ func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Ma
 	}
 	for _, volLine := range opts.Volumes {
 		var volName, mountPath string
+		var options map[string]string
 		split := strings.Split(volLine, ":")
-		if len(split) == 2 {
+		if len(split) == 2 || len(split) == 3 {
 			volName = split[0]
 			mountPath = split[1]
+		} else if len(split) == 1 && split[0] != "" {
+			// An anonymous volume, whose contents are managed by KraftKit.
+			mountPath = split[0]
 		} else {
-			return fmt.Errorf("invalid syntax for --volume=%s expected --volume=<host>:<machine>", volLine)
+			return fmt.Errorf("invalid syntax for --volume=%s expected --volume=<host>:<machine>[:<options>] or --volume=<machine>", volLine)
+		}
+
+		if len(split) == 3 {
+			options, err = volumecfg.ParseOptions(split[2])
+			if err != nil {
+				return fmt.Errorf("invalid syntax for --volume=%s: %w", volLine, err)
+			}
 		}
 
 		var driver string
@@ -226,33 +430,40 @@ func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Ma
 		}
 
 		// Check if this could be a named volume
-		vol, err := controllers[driver].Get(ctx, &volumeapi.Volume{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: volName,
-			},
-		})
-		if err != nil {
-			return fmt.Errorf("failed to get volume: %w", err)
-		}
-		if vol != nil {
-			vol.Spec.Destination = mountPath
-			machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
-			continue
+		if volName != "" {
+			vol, err := controllers[driver].Get(ctx, &volumeapi.Volume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: volName,
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get volume: %w", err)
+			}
+			if vol != nil {
+				vol.Spec.Destination = mountPath
+				vol.Spec.Options = mergeVolumeOptions(vol.Spec.Options, options)
+				machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
+				continue
+			}
 		}
 
-		vol, err = controllers[driver].Create(ctx, &volumeapi.Volume{
+		vol, err := opts.createVolume(ctx, controllers[driver], &volumeapi.Volume{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: fmt.Sprintf("%s-%d", machine.ObjectMeta.Name, len(machine.Spec.Volumes)),
+				Labels: map[string]string{
+					volumeapi.VolumeLabelAnonymous: "true",
+				},
 			},
 			Spec: volumeapi.VolumeSpec{
 				Driver:      driver,
 				Source:      volName,
 				Destination: mountPath,
-				ReadOnly:    false, // TODO(nderjung): Options are not yet supported.
+				ReadOnly:    false, // TODO(nderjung): Read-only volumes are not yet supported.
+				Options:     options,
 			},
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create volume: %w", err)
+			return err
 		}
 
 		machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
@@ -322,23 +533,28 @@ func (opts *RunOptions) parseKraftfileVolumes(ctx context.Context, project app.A
 
 		if err == nil && vol.Spec.Source != "" {
 			vol.Spec.Destination = volcfg.Destination()
+			vol.Spec.Options = mergeVolumeOptions(vol.Spec.Options, volcfg.Options())
 			machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
 			continue
 		}
 
-		vol, err = controllers[driver].Create(ctx, &volumeapi.Volume{
+		vol, err = opts.createVolume(ctx, controllers[driver], &volumeapi.Volume{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: fmt.Sprintf("%s-%d", machine.ObjectMeta.Name, len(machine.Spec.Volumes)),
+				Labels: map[string]string{
+					volumeapi.VolumeLabelAnonymous: "true",
+				},
 			},
 			Spec: volumeapi.VolumeSpec{
 				Driver:      driver,
 				Source:      volcfg.Source(),
 				Destination: volcfg.Destination(),
 				ReadOnly:    volcfg.ReadOnly(),
+				Options:     volcfg.Options(),
 			},
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create volume: %w", err)
+			return err
 		}
 
 		machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
@@ -347,6 +563,40 @@ func (opts *RunOptions) parseKraftfileVolumes(ctx context.Context, project app.A
 	return nil
 }
 
+// createVolume creates the anonymous volume of a machine with the provided
+// controller, unless this is a dry run in which case the volume is returned as
+// it would be created.
+func (opts *RunOptions) createVolume(ctx context.Context, controller volumeapi.VolumeService, vol *volumeapi.Volume) (*volumeapi.Volume, error) {
+	if opts.DryRun {
+		return vol, nil
+	}
+
+	created, err := controller.Create(ctx, vol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create volume: %w", err)
+	}
+
+	return created, nil
+}
+
+// mergeVolumeOptions returns the options of an existing volume overridden by
+// the options with which it is mounted to the machine.
+func mergeVolumeOptions(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
+	}
+
+	ret := map[string]string{}
+	for k, v := range base {
+		ret[k] = v
+	}
+	for k, v := range override {
+		ret[k] = v
+	}
+
+	return ret
+}
+
 // parse the provided `--rootfs` flag which ultimately is passed into the
 // dynamic Initrd interface which either looks up or constructs the archive
 // based on the value of the flag.
@@ -423,8 +673,82 @@ func (opts *RunOptions) prepareRootfs(ctx context.Context, machine *machineapi.M
 	return treemodel.Start()
 }
 
-func (opts *RunOptions) parseKraftfileEnv(_ context.Context, project app.Application, machine *machineapi.Machine) error {
-	if project.Env() == nil {
+// prepareRootfsLayers builds each of the provided `--rootfs-layer` flags into
+// an initramfs which is applied over the root file system of the machine.
+// This way, a large and rarely changing layer, e.g. the runtime, is built
+// once and only the small layer of the application is rebuilt between runs.
+func (opts *RunOptions) prepareRootfsLayers(ctx context.Context, machine *machineapi.Machine) error {
+	if len(opts.RootfsLayers) == 0 {
+		return nil
+	}
+
+	layers := make([]string, len(opts.RootfsLayers))
+	items := make([]*processtree.ProcessTreeItem, len(opts.RootfsLayers))
+
+	for i, layer := range opts.RootfsLayers {
+		ramfs, err := initrd.New(ctx,
+			layer,
+			initrd.WithOutput(filepath.Join(
+				opts.workdir,
+				unikraft.BuildDir,
+				fmt.Sprintf("initramfs-layer%d-%s.cpio", i, machine.Spec.Architecture),
+			)),
+			initrd.WithCacheDir(filepath.Join(
+				opts.workdir,
+				unikraft.BuildDir,
+				"rootfs-cache",
+			)),
+			initrd.WithArchitecture(machine.Spec.Architecture),
+			initrd.WithWorkdir(opts.workdir),
+		)
+		if err != nil {
+			return fmt.Errorf("could not prepare initramfs layer %s: %w", layer, err)
+		}
+
+		items[i] = processtree.NewProcessTreeItem(
+			fmt.Sprintf("building rootfs layer via %s", ramfs.Name()),
+			machine.Spec.Architecture,
+			func(ctx context.Context) error {
+				var err error
+				layers[i], err = ramfs.Build(ctx)
+				return err
+			},
+		)
+	}
+
+	treemodel, err := processtree.NewProcessTree(
+		ctx,
+		[]processtree.ProcessTreeOption{
+			processtree.IsParallel(false),
+			processtree.WithRenderer(
+				log.LoggerTypeFromString(config.G[config.KraftKit](ctx).Log.Type) != log.FANCY,
+			),
+			processtree.WithFailFast(true),
+		},
+		items...,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := treemodel.Start(); err != nil {
+		return err
+	}
+
+	if machine.Status.InitrdPath != "" {
+		layers = append([]string{machine.Status.InitrdPath}, layers...)
+	}
+
+	machine.Status.InitrdLayers = layers
+
+	return nil
+}
+
+// parseKraftfileEnv sets the environmental variables of the machine which are
+// provided by the Kraftfile, filling in missing values with the host
+// environment.
+func (opts *RunOptions) parseKraftfileEnv(_ context.Context, env map[string]string, machine *machineapi.Machine) error {
+	if env == nil {
 		return nil
 	}
 
@@ -432,7 +756,7 @@ func (opts *RunOptions) parseKraftfileEnv(_ context.Context, project app.Applica
 		machine.Spec.Env = make(map[string]string)
 	}
 
-	for k, v := range project.Env() {
+	for k, v := range env {
 		if v != "" {
 			machine.Spec.Env[k] = v
 			continue
@@ -446,12 +770,31 @@ func (opts *RunOptions) parseKraftfileEnv(_ context.Context, project app.Applica
 	return nil
 }
 
+// parseEnvs sets the environment variables of the machine which are provided
+// on the command-line or read from environment files, in the same format as
+// accepted by docker run.  Variables which are set on the command-line take
+// precedence over those read from files and only a key may be provided to use
+// the value of the variable in the host environment.
 func (opts *RunOptions) parseEnvs(_ context.Context, machine *machineapi.Machine) error {
 	if machine.Spec.Env == nil {
 		machine.Spec.Env = make(map[string]string)
 	}
 
-	for _, env := range opts.Env {
+	var envs []string
+	for _, file := range opts.EnvFile {
+		// Keys without a value have already been looked up in the host
+		// environment, or discarded if unset.
+		fileEnvs, err := dockeropts.ParseEnvFile(file)
+		if err != nil {
+			return fmt.Errorf("could not parse environment file: %w", err)
+		}
+
+		envs = append(envs, fileEnvs...)
+	}
+
+	envs = append(envs, opts.Env...)
+
+	for _, env := range envs {
 		k, v, ok := strings.Cut(env, "=")
 		if ok {
 			machine.Spec.Env[k] = v
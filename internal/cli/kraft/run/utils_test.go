@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package run
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// volumeService records the volumes which are created through it.
+type volumeService struct {
+	volumeapi.VolumeService
+	created []string
+	err     error
+}
+
+func (s *volumeService) Create(_ context.Context, vol *volumeapi.Volume) (*volumeapi.Volume, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	s.created = append(s.created, vol.Name)
+
+	created := *vol
+	created.Status.State = volumeapi.VolumeStateBound
+
+	return &created, nil
+}
+
+func TestCreateVolume(t *testing.T) {
+	newVolume := func() *volumeapi.Volume {
+		return &volumeapi.Volume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "machine-0",
+			},
+			Spec: volumeapi.VolumeSpec{
+				Destination: "/data",
+			},
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		controller := &volumeService{}
+		opts := &RunOptions{DryRun: true}
+
+		vol, err := opts.createVolume(context.Background(), controller, newVolume())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(controller.created) > 0 {
+			t.Errorf("expected no volume to be created, got %v", controller.created)
+		}
+
+		if vol.Name != "machine-0" || vol.Spec.Destination != "/data" {
+			t.Errorf("expected the planned volume, got %+v", vol)
+		}
+	})
+
+	t.Run("create", func(t *testing.T) {
+		controller := &volumeService{}
+		opts := &RunOptions{}
+
+		vol, err := opts.createVolume(context.Background(), controller, newVolume())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(controller.created) != 1 {
+			t.Errorf("expected one volume to be created, got %v", controller.created)
+		}
+
+		if vol.Status.State != volumeapi.VolumeStateBound {
+			t.Errorf("expected the created volume, got %+v", vol)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		controller := &volumeService{err: errors.New("no space left")}
+		opts := &RunOptions{}
+
+		if _, err := opts.createVolume(context.Background(), controller, newVolume()); err == nil || !strings.Contains(err.Error(), "failed to create volume") {
+			t.Errorf("expected wrapped error, got %v", err)
+		}
+	})
+}
+
+func TestCheckInterfaceAddresses(t *testing.T) {
+	existing := networkapi.NetworkInterfaceTemplateSpec{
+		Spec: networkapi.NetworkInterfaceSpec{
+			CIDR:       "172.16.0.2/24",
+			MacAddress: "02:00:00:00:00:02",
+		},
+	}
+
+	bridge := &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "kraft0"},
+		Spec: networkapi.NetworkSpec{
+			Gateway:    "172.16.0.1",
+			Netmask:    "255.255.255.0",
+			Interfaces: []networkapi.NetworkInterfaceTemplateSpec{existing},
+		},
+	}
+
+	// A network which does not exist yet, as planned during a dry run.
+	planned := &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "kraft1"},
+	}
+
+	tests := []struct {
+		name    string
+		network *networkapi.Network
+		iface   networkapi.NetworkInterfaceSpec
+		err     bool
+	}{
+		{
+			name:    "within subnet",
+			network: bridge,
+			iface:   networkapi.NetworkInterfaceSpec{CIDR: "172.16.0.3/24"},
+		},
+		{
+			name:    "outside of subnet",
+			network: bridge,
+			iface:   networkapi.NetworkInterfaceSpec{CIDR: "10.0.0.3/24"},
+			err:     true,
+		},
+		{
+			name:    "gateway",
+			network: bridge,
+			iface:   networkapi.NetworkInterfaceSpec{CIDR: "172.16.0.1/24"},
+			err:     true,
+		},
+		{
+			name:    "IP address in use",
+			network: bridge,
+			iface:   networkapi.NetworkInterfaceSpec{CIDR: "172.16.0.2/24"},
+			err:     true,
+		},
+		{
+			name:    "MAC address in use",
+			network: bridge,
+			iface:   networkapi.NetworkInterfaceSpec{MacAddress: "02:00:00:00:00:02"},
+			err:     true,
+		},
+		{
+			name:    "invalid IP address",
+			network: bridge,
+			iface:   networkapi.NetworkInterfaceSpec{CIDR: "172.16.0"},
+			err:     true,
+		},
+		{
+			name:    "unknown subnet",
+			network: planned,
+			iface:   networkapi.NetworkInterfaceSpec{CIDR: "10.0.0.3/24"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkInterfaceAddresses(tt.network, tt.iface); (err != nil) != tt.err {
+				t.Errorf("expected error: %t, got: %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestParseEnvs(t *testing.T) {
+	dir := t.TempDir()
+	envFile := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	base := envFile("base.env", "# defaults\nLOG_LEVEL=info\nPORT=8080\nHOST_VAR\n")
+	override := envFile("override.env", "LOG_LEVEL=debug\n")
+	malformed := envFile("malformed.env", "LOG LEVEL=debug\n")
+
+	t.Setenv("HOST_VAR", "from-host")
+
+	tests := []struct {
+		name      string
+		kraftfile map[string]string
+		envFiles  []string
+		envs      []string
+		expected  map[string]string
+		err       bool
+	}{
+		{
+			name:      "file over Kraftfile",
+			kraftfile: map[string]string{"PORT": "80", "NAME": "app"},
+			envFiles:  []string{base},
+			expected:  map[string]string{"LOG_LEVEL": "info", "PORT": "8080", "NAME": "app", "HOST_VAR": "from-host"},
+		},
+		{
+			name:     "later file over earlier file",
+			envFiles: []string{base, override},
+			expected: map[string]string{"LOG_LEVEL": "debug", "PORT": "8080", "HOST_VAR": "from-host"},
+		},
+		{
+			name:     "flag over file",
+			envFiles: []string{base, override},
+			envs:     []string{"LOG_LEVEL=warn", "HOST_VAR"},
+			expected: map[string]string{"LOG_LEVEL": "warn", "PORT": "8080", "HOST_VAR": "from-host"},
+		},
+		{
+			name:     "malformed file",
+			envFiles: []string{base, malformed},
+			err:      true,
+		},
+		{
+			name:     "missing file",
+			envFiles: []string{filepath.Join(dir, "missing.env")},
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &RunOptions{EnvFile: tt.envFiles, Env: tt.envs}
+			machine := &machineapi.Machine{}
+
+			// The Kraftfile is applied by the runner before the command-line.
+			if err := opts.parseKraftfileEnv(context.Background(), tt.kraftfile, machine); err != nil {
+				t.Fatal(err)
+			}
+
+			err := opts.parseEnvs(context.Background(), machine)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error: %t, got: %v", tt.err, err)
+			}
+
+			if !tt.err && !maps.Equal(machine.Spec.Env, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, machine.Spec.Env)
+			}
+		})
+	}
+}
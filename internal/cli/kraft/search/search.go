@@ -0,0 +1,326 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	pkgutils "kraftkit.sh/internal/cli/kraft/pkg/utils"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/manifest"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/tui/processtree"
+	"kraftkit.sh/tui/selection"
+	"kraftkit.sh/unikraft"
+)
+
+type SearchOptions struct {
+	Architecture string        `long:"arch" short:"m" usage:"Only show entries for the architecture"`
+	Images       bool          `long:"images" usage:"Only show pre-built images" conflicts-with:"templates"`
+	Limit        int           `long:"limit" short:"l" usage:"Set the maximum number of results" default:"50"`
+	Output       string        `long:"output" short:"o" usage:"Set output format. Options: table,wide,yaml,json,list" default:"table"`
+	Platform     string        `long:"plat" short:"p" usage:"Only show entries for the platform"`
+	Runtime      string        `long:"runtime" short:"r" usage:"Only show entries of the runtime, e.g. python"`
+	Templates    bool          `long:"templates" usage:"Only show application templates"`
+	Timeout      time.Duration `long:"timeout" usage:"Skip the catalog of a package manager which does not respond within this duration" default:"30s"`
+	Use          bool          `long:"use" usage:"Create a new project from the selected entry"`
+	Workdir      string        `long:"workdir" short:"w" usage:"Set the directory of the project created with --use"`
+}
+
+// Search the catalog for application templates and pre-built images.
+func Search(ctx context.Context, opts *SearchOptions, args ...string) error {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SearchOptions{}, cobra.Command{
+		Short: "Search the catalog for application templates and pre-built images",
+		Use:   "search [FLAGS] [QUERY]",
+		Args:  cobra.MaximumNArgs(1),
+		Long: heredoc.Doc(`
+			Search the catalog for application templates and pre-built images.
+
+			Entries whose name contains the query are listed together with their
+			details.  The results can be narrowed down to an architecture, a platform
+			or a runtime.
+
+			With --use, a new project is created from the selected entry: the sources
+			of an application template are retrieved into the project directory and
+			a Kraftfile which runs a pre-built image is created.  If more than one
+			entry matches, one is selected interactively.
+		`),
+		Example: heredoc.Doc(`
+			# Search for entries related to nginx
+			$ kraft search nginx
+
+			# Search for pre-built Python images for Firecracker on arm64
+			$ kraft search --images --runtime python --plat fc --arch arm64
+
+			# Create a new project in the directory my-app from the nginx template
+			$ kraft search --templates --use --workdir my-app nginx
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "build",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *SearchOptions) Pre(cmd *cobra.Command, _ []string) error {
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if opts.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *SearchOptions) Run(ctx context.Context, args []string) error {
+	query := ""
+	if len(args) > 0 {
+		query = args[0]
+	}
+
+	qopts := []packmanager.QueryOption{
+		packmanager.WithTypes(unikraft.ComponentTypeApp),
+		packmanager.WithRemote(true),
+		packmanager.WithTimeout(opts.Timeout),
+	}
+
+	if len(opts.Architecture) > 0 {
+		qopts = append(qopts, packmanager.WithArchitecture(opts.Architecture))
+	}
+
+	if len(opts.Platform) > 0 {
+		qopts = append(qopts, packmanager.WithPlatform(opts.Platform))
+	}
+
+	var err error
+	var found []pack.Package
+
+	treemodel, err := processtree.NewProcessTree(
+		ctx,
+		[]processtree.ProcessTreeOption{
+			processtree.IsParallel(false),
+			processtree.WithRenderer(
+				log.LoggerTypeFromString(config.G[config.KraftKit](ctx).Log.Type) != log.FANCY,
+			),
+			processtree.WithFailFast(true),
+			processtree.WithHideOnSuccess(true),
+		},
+		processtree.NewProcessTreeItem(
+			"searching catalog", "",
+			func(ctx context.Context) error {
+				found, err = packmanager.G(ctx).Catalog(ctx, qopts...)
+				return err
+			},
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := treemodel.Start(); err != nil {
+		return fmt.Errorf("could not complete search: %v", err)
+	}
+
+	results := opts.filter(query, found)
+
+	if len(results) == 0 {
+		log.G(ctx).Info("no entries found")
+		return nil
+	}
+
+	if opts.Use {
+		return opts.use(ctx, results)
+	}
+
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		log.G(ctx).Infof("showing %d of %d entries: use --limit to show more", opts.Limit, len(results))
+		results = results[:opts.Limit]
+	}
+
+	return pkgutils.PrintPackages(ctx, iostreams.G(ctx).Out, opts.Output, results...)
+}
+
+// filter returns the packages which match the query and the filters, sorted by
+// name and version.
+func (opts *SearchOptions) filter(query string, packs []pack.Package) []pack.Package {
+	query = strings.ToLower(query)
+
+	var results []pack.Package
+	seen := map[string]bool{}
+
+	for _, p := range packs {
+		if opts.Images && p.Format() != oci.OCIFormat {
+			continue
+		}
+
+		if opts.Templates && p.Format() != manifest.ManifestFormat {
+			continue
+		}
+
+		name := strings.ToLower(p.Name())
+		if !strings.Contains(name, query) {
+			continue
+		}
+
+		if len(opts.Runtime) > 0 && !strings.HasPrefix(path.Base(name), strings.ToLower(opts.Runtime)) {
+			continue
+		}
+
+		id := fmt.Sprintf("%s:%s:%s", p.Format(), p.Name(), p.Version())
+		if seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		results = append(results, p)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Name() != results[j].Name() {
+			return results[i].Name() < results[j].Name()
+		}
+
+		return results[i].Version() > results[j].Version()
+	})
+
+	return results
+}
+
+// use creates a new project from one of the provided packages.
+func (opts *SearchOptions) use(ctx context.Context, results []pack.Package) error {
+	if len(results) > 1 && config.G[config.KraftKit](ctx).NoPrompt {
+		for _, p := range results {
+			log.G(ctx).
+				WithField("entry", p.String()).
+				Warn("possible")
+		}
+
+		return fmt.Errorf("too many entries match and prompting has been disabled: refine the query")
+	}
+
+	selected, err := selection.Select("select an entry:", results...)
+	if err != nil {
+		return err
+	}
+
+	entry := *selected
+
+	workdir := opts.Workdir
+	if len(workdir) == 0 {
+		workdir = path.Base(entry.Name())
+	}
+
+	workdir, err = filepath.Abs(workdir)
+	if err != nil {
+		return err
+	}
+
+	if entries, err := os.ReadDir(workdir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("cannot create project: directory '%s' is not empty", workdir)
+	}
+
+	switch entry.Format() {
+	case oci.OCIFormat:
+		if err := useImage(workdir, entry); err != nil {
+			return err
+		}
+
+	case manifest.ManifestFormat:
+		if err := useTemplate(ctx, workdir, entry); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("cannot create a project from %s entries", entry.Format())
+	}
+
+	log.G(ctx).
+		WithField("dir", workdir).
+		Infof("created project from %s", unikraft.TypeNameVersion(entry))
+
+	return nil
+}
+
+// useImage creates a project whose Kraftfile runs the pre-built image.
+func useImage(workdir string, image pack.Package) error {
+	if err := os.MkdirAll(workdir, 0o755); err != nil {
+		return err
+	}
+
+	kraftfile := fmt.Sprintf("spec: v0.6\n\nname: %s\n\nruntime: %s:%s\n",
+		filepath.Base(workdir),
+		image.Name(),
+		image.Version(),
+	)
+
+	return os.WriteFile(filepath.Join(workdir, "Kraftfile"), []byte(kraftfile), 0o644)
+}
+
+// useTemplate retrieves the sources of the application template into the
+// project directory.
+func useTemplate(ctx context.Context, workdir string, template pack.Package) error {
+	if err := os.MkdirAll(filepath.Dir(workdir), 0o755); err != nil {
+		return err
+	}
+
+	// Pull the template next to the project directory such that it can be
+	// moved into place afterwards.
+	tmp, err := os.MkdirTemp(filepath.Dir(workdir), ".kraft-search-")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmp)
+
+	if err := template.Pull(ctx,
+		pack.WithPullWorkdir(tmp),
+	); err != nil {
+		return fmt.Errorf("could not pull %s: %w", unikraft.TypeNameVersion(template), err)
+	}
+
+	pulled, err := unikraft.PlaceComponent(tmp, template.Type(), template.Name())
+	if err != nil {
+		return err
+	}
+
+	// The directory is known to be empty, if it exists.
+	if err := os.Remove(workdir); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return os.Rename(pulled, workdir)
+}
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package selfupdate
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/update"
+	"kraftkit.sh/internal/version"
+	"kraftkit.sh/log"
+)
+
+type SelfUpdateOptions struct {
+	Force              bool   `long:"force" short:"f" usage:"Install the latest release even if it is not newer, e.g. over a development build"`
+	InsecureSkipVerify bool   `long:"insecure-skip-verify" usage:"Install the latest release without verifying its signature"`
+	Key                string `long:"key" usage:"Path to the PEM-encoded public key with which to verify the signature of the release instead of the pinned release key" conflicts-with:"insecure-skip-verify"`
+}
+
+// SelfUpdate replaces the running kraft binary with that of the latest
+// release.
+func SelfUpdate(ctx context.Context, opts *SelfUpdateOptions) error {
+	if opts == nil {
+		opts = &SelfUpdateOptions{}
+	}
+
+	return opts.Run(ctx, nil)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SelfUpdateOptions{}, cobra.Command{
+		Short: "Update kraft to the latest release",
+		Use:   "self-update [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Update kraft to the latest release.
+
+			The archive of the latest release for the host is downloaded from GitHub
+			and its SHA-256 checksum is verified against the checksums published with
+			the release, whose signature is verified with the release key pinned in
+			this build of kraft or with the public key provided with --key.  Builds
+			without a pinned release key, e.g. those built from source, therefore
+			require either flag.  The running kraft binary is then atomically
+			replaced, such that it is never left partially written.
+
+			If kraft was installed through a package manager, update it through the
+			package manager instead.
+		`),
+		Example: heredoc.Doc(`
+			# Update kraft to the latest release
+			$ kraft self-update
+
+			# Update kraft and verify the signature of the release with a given key
+			$ kraft self-update --key cosign.pub
+
+			# Update kraft without verifying the signature of the release
+			$ kraft self-update --insecure-skip-verify
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "misc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *SelfUpdateOptions) Run(ctx context.Context, _ []string) error {
+	if config.G[config.KraftKit](ctx).Offline {
+		return fmt.Errorf("cannot update kraft in offline mode")
+	}
+
+	publicKey, err := opts.publicKey()
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not determine path of kraft: %w", err)
+	}
+
+	release, err := update.LatestRelease(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !opts.Force {
+		newer, err := update.IsNewer(version.Version(), release.Version())
+		if err != nil {
+			return fmt.Errorf("cannot compare development build with latest release %s: use --force to install it", release.Tag)
+		}
+
+		if !newer {
+			log.G(ctx).WithField("version", release.Tag).Info("kraft is already up to date")
+			return nil
+		}
+	}
+
+	checksums, err := opts.download(ctx, release, release.ChecksumsName())
+	if err != nil {
+		return err
+	}
+
+	if opts.InsecureSkipVerify {
+		log.G(ctx).Warn("not verifying the signature of the release as requested with --insecure-skip-verify")
+	} else {
+		signature, err := opts.download(ctx, release, release.ChecksumsName()+".sig")
+		if err != nil {
+			return err
+		}
+
+		if err := update.VerifySignature(publicKey, checksums, signature); err != nil {
+			return fmt.Errorf("could not verify signature of release %s: %w", release.Tag, err)
+		}
+	}
+
+	archive, err := opts.download(ctx, release, release.ArchiveName())
+	if err != nil {
+		return err
+	}
+
+	if err := update.VerifyChecksum(checksums, release.ArchiveName(), archive); err != nil {
+		return err
+	}
+
+	binary, err := update.ExtractBinary(archive, "kraft")
+	if err != nil {
+		return err
+	}
+
+	if err := update.ReplaceBinary(executable, binary); err != nil {
+		return fmt.Errorf("could not replace %s: %w", executable, err)
+	}
+
+	log.G(ctx).
+		WithField("version", release.Tag).
+		WithField("path", executable).
+		Info("updated kraft")
+
+	return nil
+}
+
+// publicKey returns the PEM-encoded public key with which the signature of the
+// release is verified, which is nil if verification is skipped.
+func (opts *SelfUpdateOptions) publicKey() ([]byte, error) {
+	if opts.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	if opts.Key != "" {
+		publicKey, err := os.ReadFile(opts.Key)
+		if err != nil {
+			return nil, fmt.Errorf("could not read public key: %w", err)
+		}
+
+		return publicKey, nil
+	}
+
+	publicKey, err := update.ReleaseKey()
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify the signature of the release: %w: provide the public key with --key or skip verification with --insecure-skip-verify", err)
+	}
+
+	return publicKey, nil
+}
+
+// download fetches the named asset of the release.
+func (opts *SelfUpdateOptions) download(ctx context.Context, release *update.Release, name string) ([]byte, error) {
+	asset, err := release.Asset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	log.G(ctx).WithField("asset", name).Debug("downloading")
+
+	contents, err := asset.Download(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %w", name, err)
+	}
+
+	return contents, nil
+}
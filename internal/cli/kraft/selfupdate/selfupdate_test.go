@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kraftkit.sh/internal/update"
+)
+
+func TestPublicKey(t *testing.T) {
+	key := filepath.Join(t.TempDir(), "cosign.pub")
+	if err := os.WriteFile(key, []byte("key"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts SelfUpdateOptions
+		key  string
+		err  error
+	}{
+		{
+			// Builds from source, such as this test, have no pinned release key.
+			name: "no pinned release key",
+			err:  update.ErrNoReleaseKey,
+		},
+		{
+			name: "provided key",
+			opts: SelfUpdateOptions{Key: key},
+			key:  "key",
+		},
+		{
+			name: "skip verification",
+			opts: SelfUpdateOptions{InsecureSkipVerify: true},
+		},
+		{
+			name: "missing key",
+			opts: SelfUpdateOptions{Key: filepath.Join(t.TempDir(), "missing.pub")},
+			err:  os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publicKey, err := tt.opts.publicKey()
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(publicKey) != tt.key {
+				t.Errorf("expected key %q, got %q", tt.key, publicKey)
+			}
+		})
+	}
+}
+
+func TestKeyConflictsWithInsecureSkipVerify(t *testing.T) {
+	cmd := NewCmd()
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{"--key=release.pub", "--insecure-skip-verify"})
+
+	if err := cmd.Execute(); err == nil || err.Error() != "--key cannot be used with --insecure-skip-verify" {
+		t.Errorf("expected --key and --insecure-skip-verify to be mutually exclusive, got %v", err)
+	}
+}
@@ -41,7 +41,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"kraftkit.sh/cmdfactory"
-	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/unikraft/app"
 )
@@ -78,7 +77,7 @@ func NewCmd() *cobra.Command {
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup:  "build",
-			cmdfactory.AnnotationHelpHidden: "true",
+			cmdfactory.AnnotationDeprecated: "set options in the Kraftfile instead",
 		},
 	})
 	if err != nil {
@@ -102,8 +101,6 @@ func (*SetOptions) Pre(cmd *cobra.Command, _ []string) error {
 func (opts *SetOptions) Run(ctx context.Context, args []string) error {
 	var err error
 
-	log.G(ctx).Warnf("This command is DEPRECATED and should not be used")
-
 	workdir := ""
 	confOpts := []string{}
 
@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package start
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"golang.org/x/term"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/iostreams"
+)
+
+// DefaultDetachKeys is the key sequence which detaches from the console of an
+// interactive machine whilst leaving it running.
+const DefaultDetachKeys = "ctrl-p,ctrl-q"
+
+// ErrDetached is returned once the detach key sequence has been read.
+var ErrDetached = errors.New("detached")
+
+// ParseDetachKeys parses a comma-separated key sequence, where each key is
+// either a single character or a control character in the format `ctrl-X`,
+// e.g. `ctrl-p,ctrl-q`.
+func ParseDetachKeys(keys string) ([]byte, error) {
+	var seq []byte
+
+	for _, key := range strings.Split(keys, ",") {
+		switch {
+		case len(key) == 1:
+			seq = append(seq, key[0])
+
+		case len(key) == 6 && strings.HasPrefix(strings.ToLower(key), "ctrl-"):
+			c := key[5]
+			switch {
+			case c >= 'a' && c <= 'z':
+				seq = append(seq, c-'a'+1)
+			case c >= 'A' && c <= 'Z':
+				seq = append(seq, c-'A'+1)
+			case c >= '@' && c <= '_':
+				seq = append(seq, c-'@')
+			default:
+				return nil, fmt.Errorf("invalid detach key: %s", key)
+			}
+
+		default:
+			return nil, fmt.Errorf("invalid detach key: %q", key)
+		}
+	}
+
+	return seq, nil
+}
+
+// detachReader forwards the input of the user until the detach key sequence
+// is read.  Keys which start the sequence are held back until it is clear
+// whether the sequence is completed, in which case they are dropped.
+type detachReader struct {
+	r       io.Reader
+	keys    []byte
+	matched int
+	pending []byte
+}
+
+// Read implements io.Reader.
+func (d *detachReader) Read(p []byte) (int, error) {
+	if len(d.pending) > 0 {
+		n := copy(p, d.pending)
+		d.pending = d.pending[n:]
+		return n, nil
+	}
+
+	buf := make([]byte, len(p))
+
+	for {
+		n, err := d.r.Read(buf)
+
+		var out []byte
+		for _, b := range buf[:n] {
+			if len(d.keys) == 0 {
+				out = append(out, b)
+				continue
+			}
+
+			if b == d.keys[d.matched] {
+				d.matched++
+
+				if d.matched == len(d.keys) {
+					d.pending = nil
+					return copy(p, out), ErrDetached
+				}
+
+				continue
+			}
+
+			// The sequence was interrupted, so forward the keys which were held
+			// back, unless the key restarts the sequence.
+			out = append(out, d.keys[:d.matched]...)
+			d.matched = 0
+
+			if b == d.keys[0] {
+				d.matched = 1
+				continue
+			}
+
+			out = append(out, b)
+		}
+
+		if len(out) > 0 {
+			written := copy(p, out)
+			d.pending = append(d.pending, out[written:]...)
+			return written, err
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// dialConsole connects to the serial console of the machine.
+func dialConsole(ctx context.Context, machine *machineapi.Machine) (net.Conn, error) {
+	if machine.Status.ConsoleSocket == "" {
+		return nil, fmt.Errorf("the platform of machine %s does not support attaching to its console", machine.Name)
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "unix", machine.Status.ConsoleSocket)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the console of %s: %w", machine.Name, err)
+	}
+
+	return conn, nil
+}
+
+// attachInteractive attaches the terminal of the user to the console.  If
+// standard input is a terminal, it is put into raw mode for the duration of
+// the session, such that keys, including control characters, are forwarded to
+// the machine as they are typed.
+func attachInteractive(ctx context.Context, console net.Conn, detachKeys []byte) error {
+	streams := iostreams.G(ctx)
+
+	if streams.IsStdinTTY() {
+		state, err := term.MakeRaw(int(streams.In.Fd()))
+		if err != nil {
+			console.Close()
+			return fmt.Errorf("could not put the terminal into raw mode: %w", err)
+		}
+
+		defer func() {
+			_ = term.Restore(int(streams.In.Fd()), state)
+		}()
+	}
+
+	return attach(ctx, console, streams.In, streams.Out, detachKeys)
+}
+
+// attach forwards the input of the user to the console and the output of the
+// console to the user until the machine closes the console, e.g. because it
+// exited, or the user enters the detach key sequence, in which case
+// ErrDetached is returned.
+func attach(ctx context.Context, console net.Conn, in io.Reader, out io.Writer, detachKeys []byte) error {
+	defer console.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		console.Close()
+	}()
+
+	detached := make(chan struct{})
+
+	go func() {
+		_, err := io.Copy(console, &detachReader{r: in, keys: detachKeys})
+		if errors.Is(err, ErrDetached) {
+			close(detached)
+			cancel()
+		}
+	}()
+
+	_, err := io.Copy(out, console)
+
+	select {
+	case <-detached:
+		return ErrDetached
+	default:
+	}
+
+	if err != nil && ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package start
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseDetachKeys(t *testing.T) {
+	tests := []struct {
+		keys    string
+		want    []byte
+		wantErr bool
+	}{
+		{"ctrl-p,ctrl-q", []byte{0x10, 0x11}, false},
+		{"ctrl-A,x", []byte{0x01, 'x'}, false},
+		{"ctrl-[", []byte{0x1b}, false},
+		{"ctrl-1", nil, true},
+		{"alt-x", nil, true},
+		{"", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDetachKeys(tt.keys)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDetachKeys(%q): got error %v, want error %v", tt.keys, err, tt.wantErr)
+			continue
+		}
+
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("ParseDetachKeys(%q): got %v, want %v", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestDetachReader(t *testing.T) {
+	keys := []byte{0x10, 0x11}
+
+	tests := []struct {
+		name         string
+		input        string
+		want         string
+		wantDetached bool
+	}{
+		{"no sequence", "hello\n", "hello\n", false},
+		{"sequence", "ls\x10\x11ignored", "ls", true},
+		{"interrupted sequence", "a\x10b\x10\x10\x11", "a\x10b\x10", true},
+		{"incomplete sequence", "a\x10", "a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			_, err := io.Copy(&out, &detachReader{r: strings.NewReader(tt.input), keys: keys})
+			if detached := errors.Is(err, ErrDetached); detached != tt.wantDetached {
+				t.Fatalf("got error %v, want detached %v", err, tt.wantDetached)
+			}
+
+			if out.String() != tt.want {
+				t.Errorf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
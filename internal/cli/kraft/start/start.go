@@ -8,6 +8,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -17,32 +19,47 @@ import (
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/internal/cli/kraft/logs"
 	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network"
 	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/vmm"
 	"kraftkit.sh/machine/volume"
 )
 
 type StartOptions struct {
-	All      bool   `long:"all" usage:"Start all machines"`
-	Detach   bool   `long:"detach" short:"d" usage:"Run in background"`
-	NoPrefix bool   `long:"no-prefix" usage:"When starting multiple machines, do not prefix each log line with the name"`
-	Platform string `noattribute:"true"`
-	Remove   bool   `long:"rm" usage:"Automatically remove the unikernel when it shutsdown"`
+	All         bool          `long:"all" usage:"Start all machines"`
+	Detach      bool          `long:"detach" short:"d" usage:"Run in background"`
+	DetachKeys  string        `long:"detach-keys" usage:"Key sequence which detaches from an interactive machine, e.g. ctrl-p,ctrl-q"`
+	Interactive bool          `long:"interactive" short:"i" usage:"Forward standard input to the console of the machine"`
+	NoPrefix    bool          `long:"no-prefix" usage:"When starting multiple machines, do not prefix each log line with the name"`
+	Platform    string        `noattribute:"true"`
+	Remove      bool          `long:"rm" usage:"Automatically remove the unikernel, its logs and its anonymous volumes when it exits"`
+	Timeout     time.Duration `long:"timeout" usage:"Stop the machines and fail if they have not exited within the provided duration, e.g. 120s"`
 }
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&StartOptions{}, cobra.Command{
-		Short:   "Start one or more machines",
-		Use:     "start [FLAGS] MACHINE [MACHINE [...]]",
-		Aliases: []string{},
-		Long:    "Start one or more machines",
+		Short:             "Start one or more machines",
+		Use:               "start [FLAGS] MACHINE [MACHINE [...]]",
+		ValidArgsFunction: completion.Machines,
+		Aliases:           []string{},
+		Long:              "Start one or more machines",
 		Example: heredoc.Doc(`
 			# Start a machine
 			$ kraft start my-machine
+
+			# Start a machine which was created with 'kraft create' in the background
+			$ kraft start --detach my-machine
+
+			# Start a machine and interact with its console, detaching with ctrl-p,ctrl-q
+			$ kraft start --interactive my-machine
+
+			# Start a machine and stop it, failing, if it has not exited after 2 minutes
+			$ kraft start --timeout 120s my-machine
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
@@ -70,6 +87,10 @@ func (opts *StartOptions) Pre(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("please supply a machine ID or name or use the --all flag")
 	}
 
+	if opts.Interactive && (opts.All || len(args) != 1) {
+		return fmt.Errorf("only a single machine can be started interactively")
+	}
+
 	opts.Platform = cmd.Flag("plat").Value.String()
 	return nil
 }
@@ -136,9 +157,44 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 		}
 	}
 
+	if opts.Interactive && opts.Detach {
+		return fmt.Errorf("cannot start machines interactively in the background")
+	}
+
+	if opts.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	} else if opts.Timeout > 0 && opts.Detach {
+		return fmt.Errorf("cannot time out machines which are started in the background")
+	}
+
+	// The JSON logs of a machine are written by this process whilst it follows
+	// the console output, which it stops doing once it has detached.
+	if opts.Detach {
+		for _, machine := range machines {
+			if machine.Spec.Logging.Driver == machineapi.MachineLogDriverJSONFile {
+				return fmt.Errorf("cannot start %s in the background as its %s log driver requires staying attached", machine.Name, machineapi.MachineLogDriverJSONFile)
+			}
+		}
+	}
+
+	var detachKeys []byte
+	if opts.Interactive {
+		if opts.DetachKeys == "" {
+			opts.DetachKeys = DefaultDetachKeys
+		}
+
+		if detachKeys, err = ParseDetachKeys(opts.DetachKeys); err != nil {
+			return err
+		}
+	}
+
 	var errGroup []error
 	loggedMachines := []string{}
 
+	// The console of an interactive machine is connected to before it is
+	// started, such that none of its output is missed.
+	var console net.Conn
+
 	volumeController, err := volume.NewVolumeV1alpha1ServiceIterator(ctx)
 	if err != nil {
 		return fmt.Errorf("instantiating volume service controller iterator: %w", err)
@@ -153,17 +209,29 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 			return err
 		}
 
+		if opts.Interactive {
+			if console, err = dialConsole(ctx, &machine); err != nil {
+				return err
+			}
+		}
+
 		log.G(ctx).
 			WithField("machine", machine.Name).
 			Trace("starting")
 
 		if _, err := machineController.Start(ctx, &machine); err != nil {
+			if console != nil {
+				console.Close()
+			}
+
 			return err
 		}
 
 		for _, vol := range machine.Spec.Volumes {
-			vol.Status.State = volumeapi.VolumeStateBound
-			if _, err := volumeController.Update(ctx, &vol); err != nil {
+			if _, err := volume.UpdateV1alpha1(ctx, vol.Name, func(latest *volumeapi.Volume) error {
+				latest.Status.State = volumeapi.VolumeStateBound
+				return nil
+			}); err != nil {
 				errGroup = append(errGroup, err)
 			}
 		}
@@ -187,8 +255,51 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 		Platform: opts.Platform,
 	}
 
-	if err := logOptions.Run(ctx, loggedMachines); err != nil {
-		return err
+	// The machines are followed until they exit or, if a timeout is set, until
+	// the deadline is exceeded.
+	followCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		followCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	if console != nil {
+		err = attachInteractive(followCtx, console, detachKeys)
+		if errors.Is(err, ErrDetached) {
+			fmt.Fprintf(iostreams.G(ctx).ErrOut, "detached from %s, which keeps running\n", loggedMachines[0])
+			if opts.Remove {
+				log.G(ctx).Warn("the machine is not removed once it exits as it was detached from")
+			}
+			for _, machine := range machines {
+				if machine.Spec.Logging.Driver == machineapi.MachineLogDriverJSONFile {
+					log.G(ctx).Warnf("the %s logs of %s are no longer written as it was detached from", machineapi.MachineLogDriverJSONFile, machine.Name)
+				}
+			}
+
+			return nil
+		}
+	} else {
+		err = logOptions.Run(followCtx, loggedMachines)
+	}
+
+	if ctx.Err() == nil && errors.Is(followCtx.Err(), context.DeadlineExceeded) {
+		err = nil
+		timeOut(ctx, machineController, machines, opts.Timeout)
+	}
+
+	// The exit code of the machines is determined before they are stopped and
+	// possibly removed, such that it can be propagated.
+	code := exitCode(ctx, machineController, machines)
+
+	// Clean up after the machines even if following their output failed, such
+	// that removed machines do not leave anything behind.
+	if err != nil {
+		if !opts.Remove {
+			return err
+		}
+
+		errGroup = append(errGroup, err)
 	}
 
 	for _, machine := range machines {
@@ -213,36 +324,18 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 		}
 	}
 
-	var networkController networkapi.NetworkService
-
 	for _, machine := range machines {
 		if !opts.Remove || opts.Detach {
 			continue
 		}
 		// Set up a clean up method to remove the interface if the machine exits and
 		// we are requesting to remove the machine.
-		if len(machine.Spec.Networks) > 0 {
-			if networkController == nil {
-				networkController, err = network.NewNetworkV1alpha1ServiceIterator(ctx)
-				if err != nil {
-					return fmt.Errorf("instantiating network service controller iterator: %w", err)
-				}
-			}
-
-			for _, network := range machine.Spec.Networks {
-				// Get the latest version of the network.
-				found, err := networkController.Get(ctx, &networkapi.Network{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: network.IfName,
-					},
-				})
-				if err != nil {
-					return fmt.Errorf("could not get network information for %s: %v", network.IfName, err)
-				}
-
-				// Remove the new network interface
+		for _, machineNetwork := range machine.Spec.Networks {
+			// Remove the new network interface from the latest version of the
+			// network.
+			if _, err := network.UpdateV1alpha1(ctx, machineNetwork.IfName, func(found *networkapi.Network) error {
 				for i, iface := range found.Spec.Interfaces {
-					if iface.UID == network.Interfaces[0].UID {
+					if iface.UID == machineNetwork.Interfaces[0].UID {
 						ret := make([]networkapi.NetworkInterfaceTemplateSpec, 0)
 						ret = append(ret, found.Spec.Interfaces[:i]...)
 						found.Spec.Interfaces = append(ret, found.Spec.Interfaces[i+1:]...)
@@ -250,9 +343,9 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 					}
 				}
 
-				if _, err = networkController.Update(ctx, found); err != nil {
-					return fmt.Errorf("could not update network %s: %v", network.IfName, err)
-				}
+				return nil
+			}); err != nil {
+				return fmt.Errorf("could not update network %s: %v", machineNetwork.IfName, err)
 			}
 		}
 
@@ -280,14 +373,109 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 				}
 			}
 
-			if !stillUsed {
-				vol.Status.State = volumeapi.VolumeStatePending
-				if _, err := volumeController.Update(ctx, &vol); err != nil {
-					errGroup = append(errGroup, err)
+			if stillUsed {
+				continue
+			}
+
+			// Anonymous volumes were created for this machine alone and are
+			// removed with it, whereas named volumes are kept.
+			if vol.Labels[volumeapi.VolumeLabelAnonymous] == "true" {
+				log.G(ctx).
+					WithField("volume", vol.Name).
+					Trace("removing")
+
+				if _, err := volumeController.Delete(ctx, &vol); err != nil {
+					errGroup = append(errGroup, fmt.Errorf("could not remove volume %s: %w", vol.Name, err))
 				}
+
+				continue
 			}
+
+			if _, err := volume.UpdateV1alpha1(ctx, vol.Name, func(latest *volumeapi.Volume) error {
+				latest.Status.State = volumeapi.VolumeStatePending
+				return nil
+			}); err != nil {
+				errGroup = append(errGroup, err)
+			}
+		}
+	}
+
+	if err := errors.Join(errGroup...); err != nil {
+		return err
+	}
+
+	if code != 0 {
+		return &cmdfactory.ExitCodeError{Code: code}
+	}
+
+	return nil
+}
+
+// timeOut forcefully stops the machines which are still running once the
+// timeout has been exceeded and records that they failed as a result, such that
+// the failure is reported by their status and propagated as the exit code.
+func timeOut(ctx context.Context, controller machineapi.MachineService, machines []machineapi.Machine, timeout time.Duration) {
+	for _, machine := range machines {
+		machine := machine // Go closures
+
+		found, err := controller.Get(ctx, &machine)
+		if err != nil {
+			log.G(ctx).
+				WithField("machine", machine.Name).
+				Debugf("could not determine state: %v", err)
+			continue
+		}
+
+		switch found.Status.State {
+		case machineapi.MachineStateRunning, machineapi.MachineStatePaused:
+		default:
+			continue
+		}
+
+		fmt.Fprintf(iostreams.G(ctx).ErrOut, "%s did not exit within %s, stopping\n", machine.Name, timeout)
+
+		if _, err := controller.Stop(ctx, found); err != nil {
+			log.G(ctx).
+				WithField("machine", machine.Name).
+				Errorf("could not stop: %v", err)
+			continue
+		}
+
+		if err := vmm.RecordExit(found.Status.StateDir, vmm.Exit{
+			Code:   vmm.ExitCodeDeadlineExceeded,
+			Reason: vmm.ExitReasonDeadlineExceeded,
+		}); err != nil {
+			log.G(ctx).
+				WithField("machine", machine.Name).
+				Errorf("could not record exit: %v", err)
+		}
+	}
+}
+
+// exitCode returns the exit code of the first of the machines which exited
+// unsuccessfully, or 0 if every machine exited successfully or is still
+// running, e.g. because following it was interrupted.
+func exitCode(ctx context.Context, controller machineapi.MachineService, machines []machineapi.Machine) int {
+	for _, machine := range machines {
+		machine := machine // Go closures
+
+		found, err := controller.Get(ctx, &machine)
+		if err != nil {
+			log.G(ctx).
+				WithField("machine", machine.Name).
+				Debugf("could not determine exit code: %v", err)
+			continue
+		}
+
+		if found.Status.ExitCode > 0 {
+			log.G(ctx).
+				WithField("machine", machine.Name).
+				WithField("reason", found.Status.ExitReason).
+				Debugf("exited with code %d", found.Status.ExitCode)
+
+			return found.Status.ExitCode
 		}
 	}
 
-	return errors.Join(errGroup...)
+	return 0
 }
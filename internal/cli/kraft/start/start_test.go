@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package start
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/machine/vmm"
+)
+
+// machineService serves the machines it holds by name and records which of
+// them are stopped.
+type machineService struct {
+	machineapi.MachineService
+	machines map[string]*machineapi.Machine
+	stopped  []string
+}
+
+func (s *machineService) Get(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	found, ok := s.machines[machine.Name]
+	if !ok {
+		return nil, errors.New("machine not found")
+	}
+
+	return found, nil
+}
+
+func (s *machineService) Stop(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	s.stopped = append(s.stopped, machine.Name)
+	machine.Status.State = machineapi.MachineStateExited
+
+	return machine, nil
+}
+
+func newMachine(name string, state machineapi.MachineState, exitCode int, stateDir string) *machineapi.Machine {
+	return &machineapi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: machineapi.MachineStatus{
+			State:    state,
+			ExitCode: exitCode,
+			StateDir: stateDir,
+		},
+	}
+}
+
+func TestTimeOut(t *testing.T) {
+	dirs := map[string]string{
+		"running": t.TempDir(),
+		"paused":  t.TempDir(),
+		"exited":  t.TempDir(),
+	}
+
+	controller := &machineService{
+		machines: map[string]*machineapi.Machine{
+			"running": newMachine("running", machineapi.MachineStateRunning, 0, dirs["running"]),
+			"paused":  newMachine("paused", machineapi.MachineStatePaused, 0, dirs["paused"]),
+			"exited":  newMachine("exited", machineapi.MachineStateExited, 0, dirs["exited"]),
+		},
+	}
+
+	machines := []machineapi.Machine{}
+	for _, name := range []string{"running", "paused", "exited", "unknown"} {
+		machines = append(machines, machineapi.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		})
+	}
+
+	timeOut(context.Background(), controller, machines, 2*time.Minute)
+
+	if expected := []string{"running", "paused"}; !slices.Equal(controller.stopped, expected) {
+		t.Errorf("expected %v to be stopped, got %v", expected, controller.stopped)
+	}
+
+	for name, dir := range dirs {
+		exit, err := vmm.RecordedExit(dir)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		if name == "exited" {
+			if exit != nil {
+				t.Errorf("%s: expected no exit to be recorded, got %+v", name, exit)
+			}
+			continue
+		}
+
+		if exit == nil || exit.Code != vmm.ExitCodeDeadlineExceeded || exit.Reason != vmm.ExitReasonDeadlineExceeded {
+			t.Errorf("%s: expected the deadline to be recorded as exceeded, got %+v", name, exit)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	controller := &machineService{
+		machines: map[string]*machineapi.Machine{
+			"ok":      newMachine("ok", machineapi.MachineStateExited, 0, ""),
+			"running": newMachine("running", machineapi.MachineStateRunning, 0, ""),
+			"failed":  newMachine("failed", machineapi.MachineStateFailed, vmm.ExitCodeDeadlineExceeded, ""),
+			"crashed": newMachine("crashed", machineapi.MachineStateFailed, 1, ""),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		machines []string
+		expected int
+	}{
+		{
+			name:     "successful",
+			machines: []string{"ok", "running"},
+			expected: 0,
+		},
+		{
+			name:     "timed out",
+			machines: []string{"ok", "failed", "crashed"},
+			expected: vmm.ExitCodeDeadlineExceeded,
+		},
+		{
+			name:     "first failure",
+			machines: []string{"crashed", "failed"},
+			expected: 1,
+		},
+		{
+			name:     "unknown machine",
+			machines: []string{"unknown"},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machines := []machineapi.Machine{}
+			for _, name := range tt.machines {
+				machines = append(machines, machineapi.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: name,
+					},
+				})
+			}
+
+			if code := exitCode(context.Background(), controller, machines); code != tt.expected {
+				t.Errorf("expected exit code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/cloud/utils"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/cli/kraft/ps"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/cgroup"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type StatsOptions struct {
+	All      bool   `long:"all" short:"a" usage:"Show all machines (default shows just running)"`
+	Output   string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
+	platform string
+}
+
+// Stats displays the resource usage of local Unikraft virtual machines.
+func Stats(ctx context.Context, opts *StatsOptions, args ...string) error {
+	if opts == nil {
+		opts = &StatsOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&StatsOptions{}, cobra.Command{
+		Short:             "Display the resource usage of unikernels",
+		Use:               "stats [FLAGS] [MACHINE [MACHINE [...]]]",
+		ValidArgsFunction: completion.Machines,
+		Aliases:           []string{},
+		Long: heredoc.Doc(`
+			Display the resource usage of unikernels.
+
+			The memory column shows the memory each unikernel was started with,
+			whereas the current and target columns show the memory which is
+			available to the guest and which it was last requested to make available
+			via 'kraft update', respectively.
+
+			The remaining columns show the resources accounted to the cgroup of
+			each unikernel's virtual machine monitor, which limits its memory, CPU
+			and I/O to the resources the unikernel was started with.  They are empty
+			if the virtual machine monitor was not placed in a cgroup, e.g. because
+			cgroup v2 is unavailable on the host.
+		`),
+		Example: heredoc.Doc(`
+			# Display the resource usage of all running unikernels
+			$ kraft stats
+
+			# Display the resource usage of a specific unikernel
+			$ kraft stats my-machine
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().VarP(
+		cmdfactory.NewEnumFlag[mplatform.Platform](
+			mplatform.Platforms(),
+			mplatform.Platform("all"),
+		),
+		"plat",
+		"p",
+		"Set the platform virtual machine monitor driver.",
+	)
+
+	return cmd
+}
+
+func (opts *StatsOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.platform = cmd.Flag("plat").Value.String()
+
+	if !utils.IsValidOutputFormat(opts.Output) {
+		return fmt.Errorf("invalid output format: %s", opts.Output)
+	}
+
+	return nil
+}
+
+func (opts *StatsOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	platform := mplatform.PlatformUnknown
+	var controller machineapi.MachineService
+
+	if opts.platform == "" || opts.platform == "all" {
+		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	} else {
+		if opts.platform == "auto" {
+			platform, _, err = mplatform.Detect(ctx)
+			if err != nil {
+				return err
+			}
+		} else {
+			var ok bool
+			platform, ok = mplatform.PlatformsByName()[opts.platform]
+			if !ok {
+				return fmt.Errorf("unknown platform driver: %s", opts.platform)
+			}
+		}
+
+		strategy, ok := mplatform.Strategies()[platform]
+		if !ok {
+			return fmt.Errorf("unsupported platform driver: %s (contributions welcome!)", platform.String())
+		}
+
+		controller, err = strategy.NewMachineV1alpha1(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(opts.Output),
+	)
+	if err != nil {
+		return err
+	}
+
+	table.AddField("NAME", cs.Bold)
+	table.AddField("STATUS", cs.Bold)
+	table.AddField("MEMORY", cs.Bold)
+	table.AddField("CURRENT", cs.Bold)
+	table.AddField("TARGET", cs.Bold)
+	table.AddField("CPU TIME", cs.Bold)
+	table.AddField("THROTTLED", cs.Bold)
+	table.AddField("MEM USAGE", cs.Bold)
+	table.AddField("MEM LIMIT", cs.Bold)
+	table.AddField("OOM KILLS", cs.Bold)
+	table.AddField("IO READ", cs.Bold)
+	table.AddField("IO WRITE", cs.Bold)
+	table.EndRow()
+
+	stateColor := ps.MachineStateColor
+	if config.G[config.KraftKit](ctx).NoColor {
+		stateColor = ps.MachineStateColorNil
+	}
+
+	found := false
+
+	for _, machine := range machines.Items {
+		if len(args) > 0 {
+			matched := false
+			for _, arg := range args {
+				if arg == machine.Name || arg == string(machine.UID) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		} else if !opts.All && machine.Status.State != machineapi.MachineStateRunning {
+			continue
+		}
+
+		found = true
+
+		table.AddField(machine.Name, nil)
+		table.AddField(machine.Status.State.String(), stateColor[machine.Status.State])
+		table.AddField(machine.Spec.Resources.Requests.Memory().String(), nil)
+		table.AddField(formatMemory(machine.Status.CurrentMemory), nil)
+		table.AddField(formatMemory(machine.Status.TargetMemory), nil)
+
+		usage, err := cgroupStats(machine)
+		if err != nil {
+			log.G(ctx).
+				WithField("machine", machine.Name).
+				Debugf("could not read cgroup: %v", err)
+		}
+
+		if usage != nil {
+			table.AddField(usage.CPUUsage.Round(time.Millisecond).String(), nil)
+			table.AddField(usage.CPUThrottled.Round(time.Millisecond).String(), nil)
+			table.AddField(formatMemory(usage.MemoryCurrent), nil)
+			table.AddField(formatMemory(usage.MemoryMax), nil)
+			table.AddField(fmt.Sprintf("%d", usage.OOMKills), nil)
+			table.AddField(humanize.IBytes(usage.IOReadBytes), nil)
+			table.AddField(humanize.IBytes(usage.IOWriteBytes), nil)
+		} else {
+			for i := 0; i < 7; i++ {
+				table.AddField("-", nil)
+			}
+		}
+
+		table.EndRow()
+	}
+
+	if len(args) > 0 && !found {
+		return fmt.Errorf("machine(s) not found")
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
+
+// cgroupStats returns the resource usage accounted to the cgroup of the
+// machine's VMM, or nil if it has none.  The cgroup retains its accounting
+// after the VMM has exited until the machine is removed.
+func cgroupStats(machine machineapi.Machine) (*cgroup.Stats, error) {
+	if machine.Status.Cgroup == "" {
+		return nil, nil
+	}
+
+	return cgroup.Read(machine.Status.Cgroup)
+}
+
+// formatMemory returns a human-readable representation of the provided number
+// of bytes or a dash if it is unknown.
+func formatMemory(bytes int64) string {
+	if bytes <= 0 {
+		return "-"
+	}
+
+	return humanize.IBytes(uint64(bytes))
+}
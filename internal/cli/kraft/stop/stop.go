@@ -13,14 +13,18 @@ import (
 
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/iostreams"
-	"kraftkit.sh/log"
 	mplatform "kraftkit.sh/machine/platform"
 )
 
 type StopOptions struct {
-	All      bool   `long:"all" usage:"Remove all machines"`
-	Platform string `noattribute:"true"`
+	All      bool     `long:"all" usage:"Remove all machines"`
+	Filter   []string `long:"filter" short:"f" usage:"Select machines matching the filter, in the format key=value or key!=value (label, name, status or plat)"`
+	Platform string   `noattribute:"true"`
+
+	prompt bool
 }
 
 // Stop a local Unikraft virtual machine.
@@ -34,15 +38,22 @@ func Stop(ctx context.Context, opts *StopOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&StopOptions{}, cobra.Command{
-		Short:   "Stop one or more running unikernels",
-		Use:     "stop [FLAGS] MACHINE [MACHINE [...]]",
-		Aliases: []string{},
+		Short:             "Stop one or more running unikernels",
+		Use:               "stop [FLAGS] MACHINE [MACHINE [...]]",
+		ValidArgsFunction: completion.Machines,
+		Aliases:           []string{},
 		Long: heredoc.Doc(`
-			Stop one or more running unikernels
+			Stop one or more running unikernels.
+
+			If no machines are provided, the running machines to stop can be
+			selected interactively, unless prompting is disabled via --no-prompt.
 		`),
 		Example: heredoc.Doc(`
 			# Stop a running unikernel
 			$ kraft stop my-machine
+
+			# Stop all running unikernels with the label app=web
+			$ kraft stop --filter label=app=web
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
@@ -66,25 +77,27 @@ func NewCmd() *cobra.Command {
 }
 
 func (opts *StopOptions) Pre(cmd *cobra.Command, args []string) error {
-	if len(args) == 0 && !opts.All {
-		return fmt.Errorf("please supply a machine ID or name or use the --all flag")
-	}
+	// Only prompt for the machines to stop when invoked from the command-line.
+	opts.prompt = len(args) == 0 && !opts.All && len(opts.Filter) == 0
 
 	opts.Platform = cmd.Flag("plat").Value.String()
 	return nil
 }
 
 func (opts *StopOptions) Run(ctx context.Context, args []string) error {
-	if len(args) == 0 && !opts.All {
-		return fmt.Errorf("please supply a machine ID or name or use the --all flag")
+	if len(args) == 0 && !opts.All && len(opts.Filter) == 0 && !opts.prompt {
+		return utils.ErrNoMachinesSpecified
 	}
 
-	var err error
+	filters, err := utils.ParseMachineFilters(opts.Filter)
+	if err != nil {
+		return err
+	}
 
 	platform := mplatform.PlatformUnknown
 	var controller machineapi.MachineService
 
-	if opts.All || opts.Platform == "auto" {
+	if opts.All || len(args) == 0 || opts.Platform == "auto" {
 		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
 	} else {
 		if opts.Platform == "host" {
@@ -118,31 +131,36 @@ func (opts *StopOptions) Run(ctx context.Context, args []string) error {
 
 	var stop []machineapi.Machine
 
-	for _, machine := range machines.Items {
-		if opts.All {
-			stop = append(stop, machine)
-			continue
-		}
-		for _, arg := range args {
-			if arg == machine.Name || arg == string(machine.UID) {
-				stop = append(stop, machine)
+	if opts.prompt {
+		var running []machineapi.Machine
+		for _, machine := range machines.Items {
+			if machine.Status.State != machineapi.MachineStateExited {
+				running = append(running, machine)
 			}
 		}
-	}
 
-	if len(stop) == 0 {
-		return fmt.Errorf("machine(s) not found")
+		stop, err = utils.PromptMachines(ctx, "stop", running)
+		if err != nil {
+			return err
+		}
+	} else {
+		stop = utils.SelectMachines(machines.Items, args, opts.All, filters)
+		if len(stop) == 0 {
+			return fmt.Errorf("machine(s) not found")
+		}
 	}
 
-	for _, machine := range stop {
+	return utils.ForEachMachine(ctx, stop, func(ctx context.Context, machine *machineapi.Machine) error {
 		if machine.Status.State == machineapi.MachineStateExited {
-			continue
-		} else if _, err := controller.Stop(ctx, &machine); err != nil {
-			log.G(ctx).Errorf("could not stop machine %s: %v", machine.Name, err)
-		} else {
-			fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+			return nil
 		}
-	}
 
-	return nil
+		if _, err := controller.Stop(ctx, machine); err != nil {
+			return fmt.Errorf("could not stop machine: %w", err)
+		}
+
+		fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+
+		return nil
+	})
 }
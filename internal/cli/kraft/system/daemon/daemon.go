@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/daemon"
+	"kraftkit.sh/log"
+)
+
+// DefaultSocketName is the name of the unix socket placed in the runtime
+// directory when no socket path is provided.
+const DefaultSocketName = "kraftd.sock"
+
+type DaemonOptions struct {
+	InteractiveConsole bool   `long:"interactive-console" usage:"Permit clients to send input to the serial consoles of machines"`
+	Listen             string `long:"listen" short:"l" usage:"Additionally serve the API over TCP at the given address (e.g. 127.0.0.1:8080)"`
+	Socket             string `long:"socket" short:"s" usage:"Path to the unix socket to serve the API on (default: <runtime_dir>/kraftd.sock)"`
+}
+
+// Daemon serves the machine, network, volume and compose APIs over HTTP.
+func Daemon(ctx context.Context, opts *DaemonOptions) error {
+	if opts == nil {
+		opts = &DaemonOptions{}
+	}
+
+	return opts.Run(ctx, []string{})
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DaemonOptions{}, cobra.Command{
+		Short:   "Serve the KraftKit REST API",
+		Use:     "daemon [FLAGS]",
+		Aliases: []string{"kraftd"},
+		Args:    cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Serve the KraftKit REST API.
+
+			Exposes the machine, network, volume and compose APIs over a unix socket
+			and optionally a TCP address such that external orchestrators and user
+			interfaces can manage unikernels without invoking the CLI.  The OpenAPI
+			specification of the API is served at /openapi.yaml.
+
+			The serial console of each machine is exposed read-only at
+			/v1/machines/NAME/console over a websocket or, after upgrading the
+			connection with 'Upgrade: tcp', over the raw connection, such that web
+			interfaces and remote users can view the boot log of a machine.  With
+			--interactive-console, clients may additionally send input to the
+			console by passing ?interactive=true, one client per machine at a time
+			(QEMU only).
+
+			The API is not authenticated.  Access to the unix socket is restricted to
+			the invoking user and group; only listen on a TCP address which is not
+			reachable by untrusted parties.
+		`),
+		Example: heredoc.Doc(`
+			# Serve the API on the default unix socket
+			$ kraft system daemon
+
+			# List machines via the API
+			$ curl --unix-socket ~/.local/share/kraftkit/runtime/kraftd.sock http://localhost/v1/machines
+
+			# Additionally serve the API on a local TCP port
+			$ kraft system daemon --listen 127.0.0.1:8080
+
+			# Permit clients to interact with the consoles of machines
+			$ kraft system daemon --listen 127.0.0.1:8080 --interactive-console
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "misc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DaemonOptions) Run(ctx context.Context, _ []string) error {
+	ctx = log.WithSubsystem(ctx, "daemon")
+
+	if opts.Socket == "" {
+		opts.Socket = filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, DefaultSocketName)
+	}
+
+	server, err := daemon.NewServer(ctx,
+		daemon.WithInteractiveConsole(opts.InteractiveConsole),
+	)
+	if err != nil {
+		return fmt.Errorf("could not initialize server: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.Socket), 0o775); err != nil {
+		return fmt.Errorf("could not create socket directory: %w", err)
+	}
+
+	// Remove any socket left behind by a previous instance.  A socket which is
+	// still in use is detected by successfully dialing it.
+	if _, err := os.Stat(opts.Socket); err == nil {
+		if conn, err := net.Dial("unix", opts.Socket); err == nil {
+			conn.Close()
+			return fmt.Errorf("another daemon is already listening on %s", opts.Socket)
+		}
+
+		if err := os.Remove(opts.Socket); err != nil {
+			return fmt.Errorf("could not remove stale socket: %w", err)
+		}
+	}
+
+	sock, err := net.Listen("unix", opts.Socket)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %w", opts.Socket, err)
+	}
+
+	defer func() {
+		if err := os.Remove(opts.Socket); err != nil && !os.IsNotExist(err) {
+			log.G(ctx).Debugf("could not remove socket: %v", err)
+		}
+	}()
+
+	if err := os.Chmod(opts.Socket, 0o660); err != nil {
+		sock.Close()
+		return fmt.Errorf("could not set socket permissions: %w", err)
+	}
+
+	listeners := []net.Listener{sock}
+
+	if opts.Listen != "" {
+		tcp, err := net.Listen("tcp", opts.Listen)
+		if err != nil {
+			sock.Close()
+			return fmt.Errorf("could not listen on %s: %w", opts.Listen, err)
+		}
+
+		listeners = append(listeners, tcp)
+	}
+
+	return server.Serve(ctx, listeners...)
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package df
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/oci/handler"
+)
+
+type DfOptions struct {
+	tableprinter.OutputOptions
+}
+
+// Df reports the disk usage of the local package store.
+func Df(ctx context.Context, opts *DfOptions) error {
+	if opts == nil {
+		opts = &DfOptions{}
+	}
+
+	return opts.Run(ctx, []string{})
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DfOptions{}, cobra.Command{
+		Short: "Show the disk usage of the local package store",
+		Use:   "df [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Show the disk usage of the local package store.
+
+			The store is content-addressable: blobs which are shared between
+			packages, e.g. identical layers, are stored once.  If hard-linking is
+			enabled with --unpack-hard-link, the files which are unpacked from
+			packages, e.g. the kernels in the state directories of machines, are
+			hard links to a single shared copy of their contents, too.
+
+			For both, the logical size is the size which would be used if nothing
+			was shared, whereas the size on disk is the size which is actually
+			used.  Their difference is the space saved by deduplication.
+		`),
+		Example: heredoc.Doc(`
+			# Show the disk usage of the local package store
+			$ kraft system df
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "misc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DfOptions) Run(ctx context.Context, _ []string) error {
+	if addr := config.G[config.KraftKit](ctx).ContainerdAddr; len(addr) > 0 {
+		return fmt.Errorf("the disk usage of packages managed by containerd at %s is not reported", addr)
+	}
+
+	handle, err := handler.NewDirectoryHandler(
+		filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "oci"),
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	usage, err := handle.Usage(ctx)
+	if err != nil {
+		return err
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := opts.NewTablePrinter(ctx)
+	if err != nil {
+		return err
+	}
+
+	table.AddField("TYPE", cs.Bold)
+	table.AddField("ITEMS", cs.Bold)
+	table.AddField("LOGICAL", cs.Bold)
+	table.AddField("ON DISK", cs.Bold)
+	table.AddField("SAVED", cs.Bold)
+	table.EndRow()
+
+	rows := []struct {
+		name    string
+		items   int
+		logical int64
+		size    int64
+	}{
+		{"packages", usage.Images, usage.ImagesLogical, usage.ImagesSize},
+		{"unpacked files", usage.Unpacked, usage.UnpackedLogical, usage.UnpackedSize},
+		{
+			"total",
+			usage.Images + usage.Unpacked,
+			usage.ImagesLogical + usage.UnpackedLogical,
+			usage.ImagesSize + usage.UnpackedSize,
+		},
+	}
+
+	for _, row := range rows {
+		// Blobs which are no longer referenced take space on disk without any
+		// logical size, in which case nothing is saved.
+		saved := row.logical - row.size
+		if saved < 0 {
+			saved = 0
+		}
+
+		table.AddField(row.name, nil)
+		table.AddField(fmt.Sprintf("%d", row.items), nil)
+		table.AddField(humanize.IBytes(uint64(row.logical)), nil)
+		table.AddField(humanize.IBytes(uint64(row.size)), nil)
+		table.AddField(humanize.IBytes(uint64(saved)), nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
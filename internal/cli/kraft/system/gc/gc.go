@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package gc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type GcOptions struct {
+	tableprinter.OutputOptions
+
+	DryRun bool          `long:"dry-run" usage:"Only report the drift without fixing it"`
+	Grace  time.Duration `long:"grace" usage:"Time given to an orphaned VMM to exit before it is killed" default:"10s"`
+	MinAge time.Duration `long:"min-age" usage:"Only consider VMMs without a machine orphaned once they are this old" default:"1m"`
+}
+
+// Gc reconciles the recorded state of machines with the VMM processes running
+// on the host.
+func Gc(ctx context.Context, opts *GcOptions) error {
+	if opts == nil {
+		opts = &GcOptions{}
+	}
+
+	return opts.Run(ctx, []string{})
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&GcOptions{}, cobra.Command{
+		Short: "Reconcile machines with their VMM processes",
+		Use:   "gc [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Reconcile the recorded state of machines with the VMM processes (e.g.
+			QEMU or Firecracker) running on the host.
+
+			- Machines which are recorded as running but whose VMM has gone, for
+			  example after the host was rebooted or the VMM was killed, are marked
+			  as exited.
+			- Machines which are recorded as exited but whose VMM is running have
+			  their state refreshed.
+			- VMMs which were started for a machine that no longer exists are
+			  terminated, and killed if they do not exit within the grace period.
+
+			Only processes which reference a machine state directory in the
+			runtime directory are considered, such that unrelated VMMs on the host
+			are never terminated.
+		`),
+		Example: heredoc.Doc(`
+			# Show the drift between machines and VMM processes
+			$ kraft system gc --dry-run
+
+			# Fix the state of machines and terminate orphaned VMMs
+			$ kraft system gc
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "misc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *GcOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.Grace < 0 {
+		return fmt.Errorf("grace period cannot be negative")
+	}
+
+	if opts.MinAge < 0 {
+		return fmt.Errorf("minimum age cannot be negative")
+	}
+
+	return nil
+}
+
+func (opts *GcOptions) Run(ctx context.Context, _ []string) error {
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	actions, err := mplatform.Reconcile(ctx, controller, mplatform.ReconcileOptions{
+		DryRun:     opts.DryRun,
+		Grace:      opts.Grace,
+		MinAge:     opts.MinAge,
+		RuntimeDir: config.G[config.KraftKit](ctx).RuntimeDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(actions) == 0 {
+		log.G(ctx).Info("machines and VMM processes are in sync")
+		return nil
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := opts.NewTablePrinter(ctx)
+	if err != nil {
+		return err
+	}
+
+	table.AddField("MACHINE", cs.Bold)
+	table.AddField("PID", cs.Bold)
+	table.AddField("ACTION", cs.Bold)
+	table.AddField("STATE DIR", cs.Bold)
+	table.AddField("ERROR", cs.Bold)
+	table.EndRow()
+
+	failed := 0
+
+	for _, action := range actions {
+		var description string
+
+		switch {
+		case action.Machine == "" && opts.DryRun:
+			description = "would terminate orphaned vmm"
+		case action.Machine == "":
+			description = "terminated orphaned vmm"
+		case opts.DryRun:
+			description = fmt.Sprintf("would mark %s as %s", action.From, action.To)
+		default:
+			description = fmt.Sprintf("marked %s as %s", action.From, action.To)
+		}
+
+		machine := action.Machine
+		if machine == "" {
+			machine = "-"
+		}
+
+		errMsg := ""
+		if action.Err != nil {
+			failed++
+			errMsg = action.Err.Error()
+		}
+
+		table.AddField(machine, nil)
+		table.AddField(fmt.Sprintf("%d", action.Pid), nil)
+		table.AddField(description, nil)
+		table.AddField(action.StateDir, nil)
+		table.AddField(errMsg, cs.Red)
+		table.EndRow()
+	}
+
+	if err := table.Render(iostreams.G(ctx).Out); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d action(s) failed", failed, len(actions))
+	}
+
+	return nil
+}
@@ -0,0 +1,382 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package prune
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/remove"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	"kraftkit.sh/oci/handler"
+)
+
+// candidate is a single item which can be pruned.
+type candidate struct {
+	name   string
+	size   int64
+	remove func(context.Context) error
+}
+
+// category groups candidates of the same kind.
+type category struct {
+	name string
+	find func(context.Context) ([]candidate, error)
+}
+
+// result accumulates the pruned (or prunable) items of a category.
+type result struct {
+	category string
+	items    int
+	size     int64
+}
+
+// prunable returns whether the machine has exited and can be removed.
+func prunable(machine machineapi.Machine) bool {
+	switch machine.Status.State {
+	case machineapi.MachineStateExited,
+		machineapi.MachineStateFailed,
+		machineapi.MachineStateErrored:
+		return true
+	}
+
+	return false
+}
+
+// exitedMachines returns the machines which are no longer running.
+func exitedMachines(machines []machineapi.Machine) func(context.Context) ([]candidate, error) {
+	return func(ctx context.Context) ([]candidate, error) {
+		var candidates []candidate
+
+		for _, machine := range machines {
+			if !prunable(machine) {
+				continue
+			}
+
+			name := machine.Name
+			candidates = append(candidates, candidate{
+				name: name,
+				size: dirSize(machine.Status.StateDir),
+				remove: func(ctx context.Context) error {
+					return remove.Remove(ctx, &remove.RemoveOptions{Platform: "auto"}, name)
+				},
+			})
+		}
+
+		return candidates, nil
+	}
+}
+
+// unusedNetworks returns the networks which are not attached to any machine
+// which is kept.
+func unusedNetworks(machines []machineapi.Machine) func(context.Context) ([]candidate, error) {
+	return func(ctx context.Context) ([]candidate, error) {
+		inuse := map[string]bool{}
+		for _, machine := range machines {
+			if prunable(machine) {
+				continue
+			}
+
+			for _, net := range machine.Spec.Networks {
+				inuse[net.IfName] = true
+			}
+		}
+
+		var candidates []candidate
+
+		for driver, strategy := range network.Strategies() {
+			controller, err := strategy.NewNetworkV1alpha1(ctx)
+			if err != nil {
+				log.G(ctx).Debugf("skipping network driver %s: %v", driver, err)
+				continue
+			}
+
+			networks, err := controller.List(ctx, &networkapi.NetworkList{})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, net := range networks.Items {
+				if inuse[net.Name] || inuse[net.Spec.IfName] {
+					continue
+				}
+
+				name := net.Name
+				candidates = append(candidates, candidate{
+					name: name,
+					remove: func(ctx context.Context) error {
+						// Clear any interfaces left behind by removed machines.
+						if _, err := controller.Update(ctx, &networkapi.Network{
+							ObjectMeta: metav1.ObjectMeta{
+								Name: name,
+							},
+						}); err != nil {
+							return err
+						}
+
+						_, err := controller.Delete(ctx, &networkapi.Network{
+							ObjectMeta: metav1.ObjectMeta{
+								Name: name,
+							},
+						})
+						return err
+					},
+				})
+			}
+		}
+
+		return candidates, nil
+	}
+}
+
+// danglingPackages returns the blobs of the local OCI store which are not
+// referenced by any package.  Stores managed by containerd are left to its own
+// garbage collector.
+func danglingPackages(ctx context.Context) ([]candidate, error) {
+	if addr := config.G[config.KraftKit](ctx).ContainerdAddr; len(addr) > 0 {
+		log.G(ctx).Debugf("skipping packages managed by containerd at %s", addr)
+		return nil, nil
+	}
+
+	handle, err := handler.NewDirectoryHandler(
+		filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "oci"),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dangling, err := handle.DanglingDigests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]candidate, len(dangling))
+	for i, desc := range dangling {
+		dgst := desc.Digest
+		candidates[i] = candidate{
+			name: dgst.String(),
+			size: desc.Size,
+			remove: func(ctx context.Context) error {
+				return handle.DeleteDigest(ctx, dgst)
+			},
+		}
+	}
+
+	return candidates, nil
+}
+
+// unreferencedBlobs returns the shared copies of files unpacked from packages
+// which are no longer referenced by any unpacked file, e.g. because the
+// machines they were unpacked for have been removed.
+func unreferencedBlobs(ctx context.Context) ([]candidate, error) {
+	if addr := config.G[config.KraftKit](ctx).ContainerdAddr; len(addr) > 0 {
+		return nil, nil
+	}
+
+	handle, err := handler.NewDirectoryHandler(
+		filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "oci"),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	unreferenced, err := handle.UnreferencedBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]candidate, len(unreferenced))
+	for i, desc := range unreferenced {
+		dgst := desc.Digest
+		candidates[i] = candidate{
+			name: dgst.String(),
+			size: desc.Size,
+			remove: func(ctx context.Context) error {
+				return handle.DeleteBlob(ctx, dgst)
+			},
+		}
+	}
+
+	return candidates, nil
+}
+
+// staleSources returns the entries of the component source cache which have
+// not been modified within the provided duration.
+func staleSources(age time.Duration) func(context.Context) ([]candidate, error) {
+	return func(ctx context.Context) ([]candidate, error) {
+		dir := config.G[config.KraftKit](ctx).Paths.Sources
+		if dir == "" {
+			return nil, nil
+		}
+
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var candidates []candidate
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if time.Since(lastModified(path)) < age {
+				continue
+			}
+
+			candidates = append(candidates, candidate{
+				name: path,
+				size: dirSize(path),
+				remove: func(context.Context) error {
+					return os.RemoveAll(path)
+				},
+			})
+		}
+
+		return candidates, nil
+	}
+}
+
+// orphanedRuntimeFiles returns the machine state directories, which contain
+// the sockets and pid files of a machine, that no longer belong to any known
+// machine as well as pid files whose process has gone away.
+func orphanedRuntimeFiles(machines []machineapi.Machine) func(context.Context) ([]candidate, error) {
+	return func(ctx context.Context) ([]candidate, error) {
+		runtimeDir := config.G[config.KraftKit](ctx).RuntimeDir
+
+		known := map[string]bool{}
+		for _, machine := range machines {
+			known[string(machine.ObjectMeta.UID)] = true
+		}
+
+		entries, err := os.ReadDir(runtimeDir)
+		if os.IsNotExist(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var candidates []candidate
+		var pidfiles []string
+
+		for _, entry := range entries {
+			path := filepath.Join(runtimeDir, entry.Name())
+
+			if entry.IsDir() {
+				if _, err := uuid.Parse(entry.Name()); err != nil || known[entry.Name()] {
+					continue
+				}
+
+				candidates = append(candidates, candidate{
+					name: path,
+					size: dirSize(path),
+					remove: func(context.Context) error {
+						return os.RemoveAll(path)
+					},
+				})
+			} else if strings.HasSuffix(entry.Name(), ".pid") {
+				pidfiles = append(pidfiles, path)
+			}
+		}
+
+		// The events pid file may be configured outside of the runtime directory.
+		if pidfile := config.G[config.KraftKit](ctx).EventsPidFile; pidfile != "" && filepath.Dir(pidfile) != filepath.Clean(runtimeDir) {
+			pidfiles = append(pidfiles, pidfile)
+		}
+
+		for _, pidfile := range pidfiles {
+			if !stalePidFile(pidfile) {
+				continue
+			}
+
+			path := pidfile
+			candidates = append(candidates, candidate{
+				name: path,
+				size: dirSize(path),
+				remove: func(context.Context) error {
+					return os.Remove(path)
+				},
+			})
+		}
+
+		return candidates, nil
+	}
+}
+
+// stalePidFile returns whether the pid file exists and the process it refers
+// to is no longer alive.
+func stalePidFile(path string) bool {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil || pid <= 0 {
+		// An unparsable pid file cannot refer to a running process.
+		return true
+	}
+
+	return !processAlive(pid)
+}
+
+// dirSize returns the total size of all regular files at the provided path.
+func dirSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+
+	var size int64
+
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+
+		return nil
+	})
+
+	return size
+}
+
+// lastModified returns the most recent modification time of any file at the
+// provided path.
+func lastModified(path string) time.Time {
+	var latest time.Time
+
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if info, err := d.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+
+		return nil
+	})
+
+	return latest
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package prune
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type PruneOptions struct {
+	tableprinter.OutputOptions
+
+	CacheAge time.Duration `long:"cache-age" usage:"Remove cached component sources which have not been modified for this long" default:"168h"`
+	DryRun   bool          `long:"dry-run" usage:"Only report what would be removed and the reclaimable space"`
+}
+
+// Prune removes exited machines, unused networks, dangling packages, stale
+// build caches and orphaned runtime files.
+func Prune(ctx context.Context, opts *PruneOptions) error {
+	if opts == nil {
+		opts = &PruneOptions{}
+	}
+
+	return opts.Run(ctx, []string{})
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&PruneOptions{}, cobra.Command{
+		Short: "Remove unused data",
+		Use:   "prune [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Remove unused data.
+
+			In one pass, removes:
+
+			- machines which have exited;
+			- networks which are not used by any machine;
+			- dangling package blobs which are not referenced by any package;
+			- shared copies of files unpacked from packages which are no longer
+			  referenced, e.g. by the machines they were unpacked for;
+			- cached component sources which have not been modified within the
+			  duration set by --cache-age; and,
+			- orphaned machine state directories (containing sockets and pid files)
+			  and stale pid files in the runtime directory.
+		`),
+		Example: heredoc.Doc(`
+			# Show how much space can be reclaimed without removing anything
+			$ kraft system prune --dry-run
+
+			# Remove unused data
+			$ kraft system prune
+
+			# Also remove component sources which have not been used for a day
+			$ kraft system prune --cache-age 24h
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "misc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *PruneOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.CacheAge < 0 {
+		return fmt.Errorf("cache age cannot be negative")
+	}
+
+	return nil
+}
+
+func (opts *PruneOptions) Run(ctx context.Context, _ []string) error {
+	if opts.CacheAge == 0 {
+		opts.CacheAge = 7 * 24 * time.Hour
+	}
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	// Candidates of every category are determined upfront such that the
+	// removal of one category does not affect the discovery of another.
+	categories := []category{
+		{name: "machines", find: exitedMachines(machines.Items)},
+		{name: "networks", find: unusedNetworks(machines.Items)},
+		{name: "packages", find: danglingPackages},
+		{name: "unpacked files", find: unreferencedBlobs},
+		{name: "build caches", find: staleSources(opts.CacheAge)},
+		{name: "runtime files", find: orphanedRuntimeFiles(machines.Items)},
+	}
+
+	results := make([]result, len(categories))
+
+	for i, cat := range categories {
+		results[i].category = cat.name
+
+		candidates, err := cat.find(ctx)
+		if err != nil {
+			log.G(ctx).Warnf("could not determine %s to prune: %v", cat.name, err)
+			continue
+		}
+
+		for _, c := range candidates {
+			if opts.DryRun {
+				log.G(ctx).
+					WithField("size", humanize.IBytes(uint64(c.size))).
+					Infof("would remove %s: %s", cat.name, c.name)
+			} else if err := c.remove(ctx); err != nil {
+				log.G(ctx).Warnf("could not remove %s: %v", c.name, err)
+				continue
+			} else {
+				log.G(ctx).Debugf("removed %s: %s", cat.name, c.name)
+			}
+
+			results[i].items++
+			results[i].size += c.size
+		}
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := opts.NewTablePrinter(ctx)
+	if err != nil {
+		return err
+	}
+
+	table.AddField("CATEGORY", cs.Bold)
+	table.AddField("ITEMS", cs.Bold)
+	if opts.DryRun {
+		table.AddField("RECLAIMABLE", cs.Bold)
+	} else {
+		table.AddField("RECLAIMED", cs.Bold)
+	}
+	table.EndRow()
+
+	total := result{category: "total"}
+	for _, res := range results {
+		total.items += res.items
+		total.size += res.size
+	}
+
+	for _, res := range append(results, total) {
+		table.AddField(res.category, nil)
+		table.AddField(fmt.Sprintf("%d", res.items), nil)
+		table.AddField(humanize.IBytes(uint64(res.size)), nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package system
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/system/daemon"
+	"kraftkit.sh/internal/cli/kraft/system/df"
+	"kraftkit.sh/internal/cli/kraft/system/gc"
+	"kraftkit.sh/internal/cli/kraft/system/prune"
+)
+
+type SystemOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SystemOptions{}, cobra.Command{
+		Short: "Manage the local KraftKit installation",
+		Use:   "system SUBCOMMAND",
+		Long:  "Manage the local KraftKit installation.",
+		Example: heredoc.Doc(`
+			# Show the disk usage of the local package store
+			$ kraft system df
+
+			# Remove unused data
+			$ kraft system prune
+
+			# Fix the state of machines whose VMM has gone and terminate orphaned VMMs
+			$ kraft system gc
+
+			# Serve the REST API
+			$ kraft system daemon
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "misc",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(daemon.NewCmd())
+	cmd.AddCommand(df.NewCmd())
+	cmd.AddCommand(gc.NewCmd())
+	cmd.AddCommand(prune.NewCmd())
+
+	return cmd
+}
+
+func (opts *SystemOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
@@ -0,0 +1,358 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/build"
+	"kraftkit.sh/internal/cli/kraft/logs"
+	"kraftkit.sh/internal/cli/kraft/remove"
+	"kraftkit.sh/internal/cli/kraft/run"
+	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/packmanager"
+)
+
+type TestOptions struct {
+	Architecture string        `long:"arch" short:"m" usage:"Set the architecture"`
+	Env          []string      `long:"env" short:"e" usage:"Set environment variables of the unikernel, in the format key[=value]"`
+	Exec         []string      `long:"exec" short:"x" usage:"Run the provided command on the host once the unikernel is ready (can be repeated)" split:"false"`
+	Keep         bool          `long:"keep" usage:"Do not remove the machine after the tests have run"`
+	Kraftfile    string        `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Memory       string        `long:"memory" short:"M" usage:"Assign memory to the unikernel (K/Ki, M/Mi, G/Gi)" default:"64Mi"`
+	NoBuild      bool          `long:"no-build" usage:"Do not build the project before testing it"`
+	Platform     string        `long:"plat" short:"p" usage:"Set the platform virtual machine monitor driver"`
+	Ports        []string      `long:"port" usage:"Publish a machine's port(s) to the host" split:"false"`
+	ReadyLog     string        `long:"ready-log" usage:"Consider the unikernel ready once a line of its console matches the regular expression"`
+	ReadyPort    string        `long:"ready-port" usage:"Consider the unikernel ready once the host port, in the format [host:]port, accepts connections"`
+	Target       string        `long:"target" short:"t" usage:"Explicitly use the defined project target"`
+	Timeout      time.Duration `long:"timeout" usage:"Time to wait for the unikernel to become ready" default:"1m"`
+}
+
+// Test a project by building it, booting it in a throwaway machine and running
+// commands against it.
+func Test(ctx context.Context, opts *TestOptions, args ...string) error {
+	if opts == nil {
+		opts = &TestOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&TestOptions{}, cobra.Command{
+		Short: "Build, boot and test a project",
+		Use:   "test [FLAGS] [DIR]",
+		Args:  cobra.MaximumNArgs(1),
+		Long: heredoc.Doc(`
+			Build the project, boot it in a throwaway machine, wait until it is ready
+			and run the provided commands on the host against it.
+
+			The machine is removed afterwards and the command exits with a non-zero
+			status if the unikernel did not become ready or any of the commands
+			failed, which makes it suitable for continuous integration.  The name of
+			the machine is passed to the commands via the KRAFT_TEST_MACHINE
+			environmental variable.
+		`),
+		Example: heredoc.Doc(`
+			# Wait until the unikernel listens on port 8080 and query it
+			$ kraft test --port 8080:8080 --ready-port 8080 -x "curl -sf localhost:8080"
+
+			# Wait until the unikernel prints a message on its console
+			$ kraft test --ready-log "Listening on" -x ./tests/smoke.sh
+
+			# Test an already built project
+			$ kraft test --no-build --ready-log "Hello" path/to/project
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "build",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *TestOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	if opts.ReadyLog != "" {
+		if _, err := regexp.Compile(opts.ReadyLog); err != nil {
+			return fmt.Errorf("invalid regular expression for --ready-log: %w", err)
+		}
+	}
+
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *TestOptions) Run(ctx context.Context, args []string) (err error) {
+	workdir := "."
+	if len(args) > 0 {
+		workdir = args[0]
+	}
+
+	workdir, err = filepath.Abs(workdir)
+	if err != nil {
+		return err
+	}
+
+	if !opts.NoBuild {
+		buildOptions := build.BuildOptions{
+			Architecture: opts.Architecture,
+			Kraftfile:    opts.Kraftfile,
+			Platform:     opts.Platform,
+			TargetName:   opts.Target,
+		}
+
+		if err := buildOptions.Run(ctx, []string{workdir}); err != nil {
+			return fmt.Errorf("could not build project: %w", err)
+		}
+	}
+
+	name, err := machineName()
+	if err != nil {
+		return err
+	}
+
+	runOptions := run.RunOptions{
+		Architecture: opts.Architecture,
+		Detach:       true,
+		Env:          opts.Env,
+		Kraftfile:    opts.Kraftfile,
+		Memory:       opts.Memory,
+		Name:         name,
+		Platform:     opts.Platform,
+		Ports:        opts.Ports,
+		Pull:         "missing",
+		Target:       opts.Target,
+	}
+
+	if err := runOptions.Run(ctx, []string{workdir}); err != nil {
+		return fmt.Errorf("could not start machine: %w", err)
+	}
+
+	if !opts.Keep {
+		defer func() {
+			removeOptions := remove.RemoveOptions{Platform: "auto"}
+			if rerr := removeOptions.Run(context.WithoutCancel(ctx), []string{name}); rerr != nil {
+				err = errors.Join(err, fmt.Errorf("could not remove machine: %w", rerr))
+			}
+		}()
+	}
+
+	log.G(ctx).WithField("machine", name).Info("waiting for the unikernel to become ready")
+
+	if err := opts.waitReady(ctx, name); err != nil {
+		return err
+	}
+
+	return opts.exec(ctx, name)
+}
+
+// machineName returns a unique name for the throwaway machine.
+func machineName() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "kraft-test-" + hex.EncodeToString(b), nil
+}
+
+// waitReady blocks until the readiness checks of the unikernel pass, the
+// unikernel exits or the timeout elapses.
+func (opts *TestOptions) waitReady(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	selected := utils.SelectMachines(machines.Items, []string{name}, false, nil)
+	if len(selected) == 0 {
+		return fmt.Errorf("could not find machine %s", name)
+	}
+
+	machine := &selected[0]
+
+	// Follow the console for the whole duration of the check such that the
+	// exit of the unikernel is noticed even when only a port is checked.
+	consumer := &readyConsumer{
+		ctx:   ctx,
+		ready: make(chan struct{}),
+	}
+	if opts.ReadyLog != "" {
+		consumer.pattern = regexp.MustCompile(opts.ReadyLog)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- logs.FollowLogs(ctx, machine, controller, consumer, -1)
+	}()
+
+	ports := make(chan error, 1)
+	if opts.ReadyPort != "" {
+		go func() {
+			ports <- waitPort(ctx, opts.ReadyPort)
+		}()
+	} else {
+		close(ports)
+	}
+
+	ready := consumer.ready
+	logReady := opts.ReadyLog == ""
+
+	for {
+		select {
+		case <-ready:
+			logReady = true
+			ready = nil
+
+		case err, ok := <-ports:
+			if ok && err != nil {
+				return err
+			}
+
+			ports = nil
+
+		case err := <-exited:
+			// The line which signals readiness may have been the last one
+			// before the unikernel exited.
+			if ready != nil {
+				select {
+				case <-ready:
+					logReady = true
+				default:
+				}
+			}
+
+			if logReady && ports == nil {
+				log.G(ctx).WithField("machine", name).Info("unikernel is ready")
+				return nil
+			} else if ctx.Err() != nil {
+				return fmt.Errorf("unikernel did not become ready within %s", opts.Timeout)
+			} else if err != nil {
+				return fmt.Errorf("unikernel exited before becoming ready: %w", err)
+			}
+
+			return fmt.Errorf("unikernel exited before becoming ready")
+
+		case <-ctx.Done():
+			return fmt.Errorf("unikernel did not become ready within %s", opts.Timeout)
+		}
+
+		if logReady && ports == nil {
+			log.G(ctx).WithField("machine", name).Info("unikernel is ready")
+			return nil
+		}
+	}
+}
+
+// waitPort blocks until a TCP connection to the provided address succeeds.
+func waitPort(ctx context.Context, addr string) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort("localhost", addr)
+	}
+
+	var dialer net.Dialer
+
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// readyConsumer checks each line of the console of the unikernel against the
+// readiness pattern, signalling the first match.
+type readyConsumer struct {
+	ctx     context.Context
+	pattern *regexp.Regexp
+	ready   chan struct{}
+	matched bool
+}
+
+// Consume implements logs.LogConsumer
+func (consumer *readyConsumer) Consume(lines ...string) {
+	for _, line := range lines {
+		log.G(consumer.ctx).WithField("console", true).Debug(line)
+
+		if consumer.pattern == nil || consumer.matched || !consumer.pattern.MatchString(line) {
+			continue
+		}
+
+		consumer.matched = true
+		close(consumer.ready)
+	}
+}
+
+// exec runs the test commands one after another, reporting all which failed.
+func (opts *TestOptions) exec(ctx context.Context, name string) error {
+	var errs []error
+
+	for _, command := range opts.Exec {
+		log.G(ctx).WithField("exec", command).Info("running")
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Env = append(os.Environ(), "KRAFT_TEST_MACHINE="+name)
+		cmd.Stdout = iostreams.G(ctx).Out
+		cmd.Stderr = iostreams.G(ctx).ErrOut
+
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", command, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d test command(s) failed:\n%w", len(errs), len(opts.Exec), errors.Join(errs...))
+	}
+
+	return nil
+}
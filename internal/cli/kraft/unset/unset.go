@@ -40,7 +40,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"kraftkit.sh/cmdfactory"
-	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/unikraft/app"
 )
@@ -76,7 +75,7 @@ func NewCmd() *cobra.Command {
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup:  "build",
-			cmdfactory.AnnotationHelpHidden: "true",
+			cmdfactory.AnnotationDeprecated: "remove options from the Kraftfile instead",
 		},
 	})
 	if err != nil {
@@ -100,8 +99,6 @@ func (*UnsetOptions) Pre(cmd *cobra.Command, _ []string) error {
 func (opts *UnsetOptions) Run(ctx context.Context, args []string) error {
 	var err error
 
-	log.G(ctx).Warnf("This command is DEPRECATED and should not be used")
-
 	workdir := ""
 	confOpts := []string{}
 
@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package update
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type UpdateOptions struct {
+	Memory   string `long:"memory" short:"M" usage:"Set the memory available to the unikernel (K/Ki, M/Mi, G/Gi)"`
+	Platform string `noattribute:"true"`
+
+	memory resource.Quantity
+}
+
+// Update the resources of a local Unikraft virtual machine.
+func Update(ctx context.Context, opts *UpdateOptions, args ...string) error {
+	if opts == nil {
+		opts = &UpdateOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&UpdateOptions{}, cobra.Command{
+		Short:             "Update the resources of one or more running unikernels",
+		Use:               "update [FLAGS] MACHINE [MACHINE [...]]",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completion.Machines,
+		Aliases:           []string{},
+		Long: heredoc.Doc(`
+			Update the resources of one or more running unikernels.
+
+			The memory of a unikernel is adjusted through its balloon device and can
+			be shrunk and grown again at runtime, but never beyond the memory the
+			unikernel was started with.  The guest adjusts to the new amount
+			asynchronously; use 'kraft stats' to follow its progress.
+		`),
+		Example: heredoc.Doc(`
+			# Shrink the memory of a running unikernel to 32MiB
+			$ kraft update --memory 32Mi my-machine
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().VarP(
+		cmdfactory.NewEnumFlag[mplatform.Platform](
+			mplatform.Platforms(),
+			mplatform.Platform("auto"),
+		),
+		"plat",
+		"p",
+		"Set the platform virtual machine monitor driver.  Set to 'auto' to detect the guest's platform and 'host' to use the host platform.",
+	)
+
+	return cmd
+}
+
+func (opts *UpdateOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.Memory == "" {
+		return fmt.Errorf("nothing to update: please supply --memory")
+	}
+
+	var err error
+	opts.memory, err = resource.ParseQuantity(opts.Memory)
+	if err != nil {
+		return fmt.Errorf("could not parse memory: %w", err)
+	}
+
+	opts.Platform = cmd.Flag("plat").Value.String()
+	return nil
+}
+
+func (opts *UpdateOptions) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("please supply a machine ID or name")
+	}
+
+	var err error
+
+	platform := mplatform.PlatformUnknown
+	var controller machineapi.MachineService
+
+	if opts.Platform == "" || opts.Platform == "auto" {
+		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	} else {
+		if opts.Platform == "host" {
+			platform, _, err = mplatform.Detect(ctx)
+			if err != nil {
+				return err
+			}
+		} else {
+			var ok bool
+			platform, ok = mplatform.PlatformsByName()[opts.Platform]
+			if !ok {
+				return fmt.Errorf("unknown platform driver: %s", opts.Platform)
+			}
+		}
+
+		strategy, ok := mplatform.Strategies()[platform]
+		if !ok {
+			return fmt.Errorf("unsupported platform driver: %s (contributions welcome!)", platform.String())
+		}
+
+		controller, err = strategy.NewMachineV1alpha1(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	var update []machineapi.Machine
+
+	for _, machine := range machines.Items {
+		for _, arg := range args {
+			if arg == machine.Name || arg == string(machine.UID) {
+				update = append(update, machine)
+			}
+		}
+	}
+
+	if len(update) == 0 {
+		return fmt.Errorf("machine(s) not found")
+	}
+
+	var errs int
+
+	for _, machine := range update {
+		if machine.Spec.Resources.Requests == nil {
+			machine.Spec.Resources.Requests = make(corev1.ResourceList, 1)
+		}
+
+		machine.Spec.Resources.Requests[corev1.ResourceMemory] = opts.memory
+
+		if _, err := controller.Update(ctx, &machine); err != nil {
+			log.G(ctx).Errorf("could not update machine %s: %v", machine.Name, err)
+			errs++
+		} else {
+			fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+		}
+	}
+
+	if errs > 0 {
+		return fmt.Errorf("could not update %d machine(s)", errs)
+	}
+
+	return nil
+}
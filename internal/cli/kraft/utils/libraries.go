@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/app"
+)
+
+// ResolveLibraryVersion returns the version of the named library which is most
+// suitable for the project, i.e. the version compatible with the Unikraft core
+// of the project if one is known and otherwise the default version of the
+// library.
+func ResolveLibraryVersion(ctx context.Context, project app.Application, name string, remote bool) (string, error) {
+	qopts := []packmanager.QueryOption{
+		packmanager.WithName(name),
+		packmanager.WithTypes(unikraft.ComponentTypeLib),
+		packmanager.WithRemote(remote),
+	}
+
+	if core := project.Unikraft(ctx); core != nil && core.Version() != "" {
+		packs, err := packmanager.G(ctx).Catalog(ctx,
+			append(qopts, packmanager.WithVersion(core.Version()))...,
+		)
+		if err == nil && len(packs) == 1 {
+			return packs[0].Version(), nil
+		}
+	}
+
+	packs, err := packmanager.G(ctx).Catalog(ctx, qopts...)
+	if err != nil {
+		return "", err
+	} else if len(packs) == 0 {
+		return "", fmt.Errorf("could not find library %s", name)
+	}
+
+	return packs[0].Version(), nil
+}
+
+// ParseKConfig parses KConfig options in the format KEY=value.
+func ParseKConfig(options []string) (map[string]string, error) {
+	ret := make(map[string]string, len(options))
+
+	for _, option := range options {
+		k, v, ok := strings.Cut(option, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid KConfig option '%s': expected KEY=value", option)
+		}
+
+		ret[k] = v
+	}
+
+	return ret, nil
+}
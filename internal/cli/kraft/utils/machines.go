@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+)
+
+// MachineFilter reports whether a machine matches a criterion provided via
+// the --filter flag.
+type MachineFilter func(*machineapi.Machine) bool
+
+// ParseMachineFilters parses filters in the format key=value, or key!=value to
+// match machines which do not satisfy the criterion.  Supported keys are:
+//
+//   - label: matches machines with the label key, or key=value if a value is
+//     provided, e.g. label=app or label=app=web;
+//   - name: matches machines with the provided name;
+//   - status: matches machines in the provided state, e.g. status=running;
+//   - plat: matches machines of the provided platform.
+func ParseMachineFilters(filters []string) ([]MachineFilter, error) {
+	ret := make([]MachineFilter, 0, len(filters))
+
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid filter '%s': expected key=value or key!=value", filter)
+		}
+
+		key, negate := strings.CutSuffix(key, "!")
+
+		var match MachineFilter
+
+		switch key {
+		case "label":
+			lkey, lvalue, hasValue := strings.Cut(value, "=")
+			if lkey == "" {
+				return nil, fmt.Errorf("invalid filter '%s': expected label=key or label=key=value", filter)
+			}
+
+			match = func(machine *machineapi.Machine) bool {
+				v, ok := machine.Labels[lkey]
+				return ok && (!hasValue || v == lvalue)
+			}
+
+		case "name":
+			match = func(machine *machineapi.Machine) bool {
+				return machine.Name == value
+			}
+
+		case "status":
+			match = func(machine *machineapi.Machine) bool {
+				return string(machine.Status.State) == value
+			}
+
+		case "plat":
+			match = func(machine *machineapi.Machine) bool {
+				return machine.Spec.Platform == value
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported filter '%s': expected one of label, name, status or plat", key)
+		}
+
+		if negate {
+			ret = append(ret, func(machine *machineapi.Machine) bool {
+				return !match(machine)
+			})
+		} else {
+			ret = append(ret, match)
+		}
+	}
+
+	return ret, nil
+}
+
+// SelectMachines returns the machines which match all provided filters and,
+// unless all is set, whose name or ID is among the provided arguments.  If no
+// arguments are provided, filters alone select machines.
+func SelectMachines(machines []machineapi.Machine, args []string, all bool, filters []MachineFilter) []machineapi.Machine {
+	var ret []machineapi.Machine
+
+	for _, machine := range machines {
+		if !all && len(args) > 0 {
+			found := false
+			for _, arg := range args {
+				if arg == machine.Name || arg == string(machine.UID) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				continue
+			}
+		}
+
+		matches := true
+		for _, filter := range filters {
+			if !filter(&machine) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			ret = append(ret, machine)
+		}
+	}
+
+	return ret
+}
+
+// ForEachMachine calls the provided function for each machine concurrently
+// with a bounded number of workers, or one after another if parallelism is
+// disabled in the configuration.  All machines are processed regardless of
+// failures, which are reported together.
+func ForEachMachine(ctx context.Context, machines []machineapi.Machine, fn func(context.Context, *machineapi.Machine) error) error {
+	var eg errgroup.Group
+
+	if config.G[config.KraftKit](ctx).NoParallel {
+		eg.SetLimit(1)
+	} else {
+		eg.SetLimit(runtime.NumCPU())
+	}
+
+	var mu sync.Mutex
+	var errs []error
+
+	for i := range machines {
+		machine := &machines[i]
+
+		eg.Go(func() error {
+			if err := fn(ctx, machine); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", machine.Name, err))
+				mu.Unlock()
+			}
+
+			return nil
+		})
+	}
+
+	_ = eg.Wait()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d machine(s) failed:\n%w", len(errs), len(machines), errors.Join(errs...))
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+)
+
+func testMachines() []machineapi.Machine {
+	newMachine := func(name, platform string, state machineapi.MachineState, labels map[string]string) machineapi.Machine {
+		return machineapi.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				UID:    types.UID("uid-" + name),
+				Labels: labels,
+			},
+			Spec: machineapi.MachineSpec{
+				Platform: platform,
+			},
+			Status: machineapi.MachineStatus{
+				State: state,
+			},
+		}
+	}
+
+	return []machineapi.Machine{
+		newMachine("web-1", "qemu", machineapi.MachineStateRunning, map[string]string{"app": "web", "env": "prod"}),
+		newMachine("web-2", "fc", machineapi.MachineStateExited, map[string]string{"app": "web", "env": "staging"}),
+		newMachine("db", "qemu", machineapi.MachineStateRunning, map[string]string{"app": "db"}),
+		newMachine("scratch", "fc", machineapi.MachineStatePaused, nil),
+	}
+}
+
+func machineNames(machines []machineapi.Machine) []string {
+	names := []string{}
+	for _, machine := range machines {
+		names = append(names, machine.Name)
+	}
+
+	return names
+}
+
+func TestParseMachineFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  []string
+		expected []string
+	}{
+		{
+			name:     "no filters",
+			expected: []string{"web-1", "web-2", "db", "scratch"},
+		},
+		{
+			name:     "label key",
+			filters:  []string{"label=env"},
+			expected: []string{"web-1", "web-2"},
+		},
+		{
+			name:     "label key and value",
+			filters:  []string{"label=app=web"},
+			expected: []string{"web-1", "web-2"},
+		},
+		{
+			name:     "label with empty value",
+			filters:  []string{"label=app="},
+			expected: []string{},
+		},
+		{
+			name:     "name",
+			filters:  []string{"name=db"},
+			expected: []string{"db"},
+		},
+		{
+			name:     "status",
+			filters:  []string{"status=running"},
+			expected: []string{"web-1", "db"},
+		},
+		{
+			name:     "platform",
+			filters:  []string{"plat=fc"},
+			expected: []string{"web-2", "scratch"},
+		},
+		{
+			name:     "all filters must match",
+			filters:  []string{"label=app=web", "status=running", "plat=qemu"},
+			expected: []string{"web-1"},
+		},
+		{
+			name:     "negated label",
+			filters:  []string{"label!=app=web"},
+			expected: []string{"db", "scratch"},
+		},
+		{
+			name:     "negated status",
+			filters:  []string{"status!=running"},
+			expected: []string{"web-2", "scratch"},
+		},
+		{
+			name:     "negated platform",
+			filters:  []string{"plat!=qemu"},
+			expected: []string{"web-2", "scratch"},
+		},
+		{
+			name:     "negated and plain filters",
+			filters:  []string{"label=app", "status!=exited"},
+			expected: []string{"web-1", "db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := ParseMachineFilters(tt.filters)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := machineNames(SelectMachines(testMachines(), nil, false, filters))
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseMachineFiltersMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+	}{
+		{name: "empty", filter: ""},
+		{name: "missing value", filter: "status"},
+		{name: "empty value", filter: "status="},
+		{name: "empty negated value", filter: "status!="},
+		{name: "empty key", filter: "=running"},
+		{name: "unsupported key", filter: "image=nginx"},
+		{name: "empty label key", filter: "label==web"},
+		{name: "double negation", filter: "status!!=running"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMachineFilters([]string{"status=running", tt.filter}); err == nil {
+				t.Errorf("expected error for filter %q", tt.filter)
+			}
+		})
+	}
+}
+
+func TestSelectMachines(t *testing.T) {
+	running, err := ParseMachineFilters([]string{"status=running"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		all      bool
+		filters  []MachineFilter
+		expected []string
+	}{
+		{
+			name:     "by name",
+			args:     []string{"db", "scratch"},
+			expected: []string{"db", "scratch"},
+		},
+		{
+			name:     "by ID",
+			args:     []string{"uid-web-2"},
+			expected: []string{"web-2"},
+		},
+		{
+			name:     "unknown argument",
+			args:     []string{"unknown"},
+			expected: []string{},
+		},
+		{
+			name:     "arguments and filters",
+			args:     []string{"web-1", "web-2"},
+			filters:  running,
+			expected: []string{"web-1"},
+		},
+		{
+			name:     "all ignores arguments",
+			args:     []string{"db"},
+			all:      true,
+			expected: []string{"web-1", "web-2", "db", "scratch"},
+		},
+		{
+			name:     "all and filters",
+			all:      true,
+			filters:  running,
+			expected: []string{"web-1", "db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := machineNames(SelectMachines(testMachines(), tt.args, tt.all, tt.filters))
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/tui"
+	"kraftkit.sh/tui/confirm"
+	"kraftkit.sh/tui/multiselect"
+	"kraftkit.sh/tui/selection"
+)
+
+// ErrNoMachinesSpecified is returned when no machines were provided on the
+// command-line and the user cannot be prompted to select them.
+var ErrNoMachinesSpecified = errors.New("please supply a machine ID or name or use the --all or --filter flags")
+
+// machineOption wraps a machine such that it can be presented in a prompt.
+type machineOption struct {
+	machine machineapi.Machine
+}
+
+// String implements fmt.Stringer
+func (option machineOption) String() string {
+	return fmt.Sprintf("%s (%s)", option.machine.Name, option.machine.Status.State)
+}
+
+// canPrompt returns whether the user can be prompted to select machines.
+func canPrompt(ctx context.Context) bool {
+	return !config.G[config.KraftKit](ctx).NoPrompt && tui.IsInteractive()
+}
+
+// PromptMachine asks the user to select one of the provided machines, which
+// is used when a command which acts on a single machine is invoked without
+// one.  If there is only a single machine, it is selected without prompting.
+func PromptMachine(ctx context.Context, question string, machines []machineapi.Machine) (*machineapi.Machine, error) {
+	if len(machines) == 0 {
+		return nil, fmt.Errorf("no machines found")
+	}
+
+	if len(machines) > 1 && !canPrompt(ctx) {
+		return nil, ErrNoMachinesSpecified
+	}
+
+	options := make([]machineOption, len(machines))
+	for i, machine := range machines {
+		options[i] = machineOption{machine}
+	}
+
+	selected, err := selection.Select(question, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &selected.machine, nil
+}
+
+// PromptMachines asks the user to select any of the provided machines, which
+// is used when a destructive command is invoked without naming the machines
+// to act on.  The selection must be confirmed, where the description of the
+// action is presented alongside the names of the selected machines.
+func PromptMachines(ctx context.Context, action string, machines []machineapi.Machine) ([]machineapi.Machine, error) {
+	if len(machines) == 0 {
+		return nil, fmt.Errorf("no machines found")
+	}
+
+	if !canPrompt(ctx) {
+		return nil, ErrNoMachinesSpecified
+	}
+
+	options := make([]machineOption, len(machines))
+	for i, machine := range machines {
+		options[i] = machineOption{machine}
+	}
+
+	selected, err := multiselect.MultiSelect(fmt.Sprintf("select machines to %s", action), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no machines selected")
+	}
+
+	names := make([]string, len(selected))
+	ret := make([]machineapi.Machine, len(selected))
+	for i, option := range selected {
+		names[i] = option.machine.Name
+		ret[i] = option.machine
+	}
+
+	confirmed, err := confirm.NewConfirm(fmt.Sprintf("%s %s:", action, strings.Join(names, ", ")))
+	if err != nil {
+		return nil, err
+	}
+
+	if !confirmed {
+		return nil, fmt.Errorf("aborted")
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package validate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/schema"
+	"kraftkit.sh/unikraft/app"
+)
+
+type ValidateOptions struct {
+	Composefile string `long:"compose-file" short:"f" usage:"Set an alternative path of the Compose file"`
+	Kraftfile   string `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+}
+
+// Validate checks the Kraftfile and the Kraft extensions of the Compose file of
+// a project against the specification.
+func Validate(ctx context.Context, opts *ValidateOptions, args ...string) error {
+	if opts == nil {
+		opts = &ValidateOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ValidateOptions{}, cobra.Command{
+		Short: "Validate a Kraftfile against the specification",
+		Use:   "validate [FLAGS] [DIR]",
+		Args:  cmdfactory.MaxDirArgs(1),
+		Long: heredoc.Docf(`
+			Validate a Kraftfile against the specification.
+
+			All violations are reported with the position at which they occur, including
+			fields of the wrong type and fields which are not part of the specification,
+			such as misspelled or misplaced fields.
+
+			If the project contains a Compose file, the Kraftfile specifications which
+			are embedded in its services through the '%s' extension are validated too.
+		`, schema.ComposeExtension),
+		Example: heredoc.Doc(`
+			# Validate the Kraftfile in the current working directory
+			$ kraft validate
+
+			# Validate the project at a path
+			$ kraft validate path/to/app
+
+			# Validate an alternative Kraftfile
+			$ kraft validate --kraftfile Kraftfile.dev
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "build",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ValidateOptions) Run(ctx context.Context, args []string) error {
+	workdir := ""
+	if len(args) > 0 {
+		workdir = args[0]
+	} else {
+		var err error
+		workdir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	kraftfile := opts.Kraftfile
+	if kraftfile == "" {
+		kraftfile = findFile(workdir, app.DefaultFileNames)
+	}
+
+	composefile := opts.Composefile
+	if composefile == "" {
+		composefile = findFile(workdir, compose.DefaultFileNames)
+	}
+
+	if kraftfile == "" && composefile == "" {
+		return fmt.Errorf("no Kraftfile or Compose file found in %s", workdir)
+	}
+
+	var violations schema.Violations
+
+	for _, file := range []struct {
+		path string
+		lint func(context.Context, string, []byte) (schema.Violations, error)
+	}{
+		{kraftfile, schema.Lint},
+		{composefile, schema.LintCompose},
+	} {
+		if file.path == "" {
+			continue
+		}
+
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", file.path, err)
+		}
+
+		found, err := file.lint(ctx, file.path, content)
+		if err != nil {
+			return err
+		}
+
+		violations = append(violations, found...)
+	}
+
+	for _, violation := range violations {
+		fmt.Fprintln(iostreams.G(ctx).Out, violation.Error())
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("found %d violation(s) of the specification", len(violations))
+	}
+
+	log.G(ctx).Info("no violations found")
+
+	return nil
+}
+
+// findFile returns the first of the named files which exists in the directory.
+func findFile(dir string, names []string) string {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
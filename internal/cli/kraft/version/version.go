@@ -12,11 +12,15 @@ import (
 	"github.com/spf13/cobra"
 
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/update"
 	"kraftkit.sh/internal/version"
 	"kraftkit.sh/iostreams"
 )
 
-type VersionOptions struct{}
+type VersionOptions struct {
+	Check bool `long:"check" usage:"Check whether a newer release of kraft is available"`
+}
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&VersionOptions{}, cobra.Command{
@@ -28,6 +32,9 @@ func NewCmd() *cobra.Command {
 		Example: heredoc.Doc(`
 			# Show kraft version information
 			$ kraft version
+
+			# Check whether a newer release of kraft is available
+			$ kraft version --check
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "misc",
@@ -42,5 +49,39 @@ func NewCmd() *cobra.Command {
 
 func (opts *VersionOptions) Run(ctx context.Context, _ []string) error {
 	fmt.Fprintf(iostreams.G(ctx).Out, "kraft %s", version.String())
+
+	if !opts.Check {
+		return nil
+	}
+
+	if config.G[config.KraftKit](ctx).Offline {
+		return fmt.Errorf("cannot check for a newer release in offline mode")
+	}
+
+	release, err := update.LatestRelease(ctx)
+	if err != nil {
+		return err
+	}
+
+	newer, err := update.IsNewer(version.Version(), release.Version())
+	if err != nil {
+		return fmt.Errorf("cannot compare development build with latest release %s", release.Tag)
+	}
+
+	if !newer {
+		fmt.Fprintf(iostreams.G(ctx).Out, "kraft is up to date (latest release is %s)\n", release.Tag)
+		return nil
+	}
+
+	fmt.Fprint(iostreams.G(ctx).Out, heredoc.Docf(`
+		A newer release of kraft is available: %s
+
+		Read the full changelog:
+
+		  %s
+
+		Update by running 'kraft self-update' or through your local package manager.
+	`, release.Tag, release.URL))
+
 	return nil
 }
@@ -7,6 +7,7 @@ package create
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -14,12 +15,16 @@ import (
 
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/inspect"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/volume"
+	volumecfg "kraftkit.sh/unikraft/app/volume"
 )
 
 type CreateOptions struct {
-	Driver string `noattribute:"true"`
+	Driver  string   `noattribute:"true"`
+	DryRun  bool     `noattribute:"true"`
+	Options []string `long:"opt" short:"o" usage:"Set a driver-specific option of the volume, in the format <key>=<value>"`
 }
 
 func NewCmd() *cobra.Command {
@@ -36,6 +41,9 @@ func NewCmd() *cobra.Command {
 
 			# Create a volume with a specific name
 			$ kraft volume create my-volume
+
+			# Create a volume with a larger 9P message size
+			$ kraft volume create --opt msize=1048576 my-volume
 		`),
 	})
 	if err != nil {
@@ -81,14 +89,26 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 		return fmt.Errorf("volume %s already exists", args[0])
 	}
 
-	if vol, err = controller.Create(ctx, &volumeapi.Volume{
+	options, err := volumecfg.ParseOptions(strings.Join(opts.Options, ","))
+	if err != nil {
+		return err
+	}
+
+	vol = &volumeapi.Volume{
 		ObjectMeta: v1.ObjectMeta{
 			Name: args[0],
 		},
 		Spec: volumeapi.VolumeSpec{
-			Driver: opts.Driver,
+			Driver:  opts.Driver,
+			Options: options,
 		},
-	}); err != nil {
+	}
+
+	if opts.DryRun {
+		return inspect.Print(ctx, "json", inspect.FromVolume(vol))
+	}
+
+	if vol, err = controller.Create(ctx, vol); err != nil {
 		return err
 	}
 
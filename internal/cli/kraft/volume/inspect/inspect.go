@@ -15,6 +15,7 @@ import (
 
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/volume"
 )
@@ -25,10 +26,11 @@ type Inspect struct {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&Inspect{}, cobra.Command{
-		Short:   "Inspect a machine volume",
-		Use:     "inspect VOLUME",
-		Aliases: []string{"get"},
-		Args:    cobra.ExactArgs(1),
+		Short:             "Inspect a machine volume",
+		Use:               "inspect VOLUME",
+		Aliases:           []string{"get"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Volumes),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "vol",
 		},
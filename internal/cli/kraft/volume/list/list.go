@@ -20,9 +20,10 @@ import (
 )
 
 type List struct {
+	tableprinter.OutputOptions
+
 	driver string
-	Long   bool   `long:"long" short:"l" usage:"Show more information"`
-	Output string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
+	Long   bool `long:"long" short:"l" usage:"Show more information"`
 }
 
 type colorFunc func(string) string
@@ -108,10 +109,7 @@ func (opts *List) Run(ctx context.Context, args []string) error {
 	defer iostreams.G(ctx).StopPager()
 
 	cs := iostreams.G(ctx).ColorScheme()
-	table, err := tableprinter.NewTablePrinter(ctx,
-		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
-		tableprinter.WithOutputFormatFromString(opts.Output),
-	)
+	table, err := opts.NewTablePrinter(ctx)
 	if err != nil {
 		return err
 	}
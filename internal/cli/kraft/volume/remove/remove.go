@@ -14,6 +14,7 @@ import (
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
 
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/volume"
 )
@@ -33,11 +34,12 @@ func Remove(ctx context.Context, opts *RemoveOptions, args ...string) error {
 
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&RemoveOptions{}, cobra.Command{
-		Short:   "Remove a volume",
-		Use:     "remove",
-		Aliases: []string{"rm", "delete", "del"},
-		Args:    cobra.ExactArgs(1),
-		Long:    "Remove a volume.",
+		Short:             "Remove a volume",
+		Use:               "remove",
+		Aliases:           []string{"rm", "delete", "del"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.Limit(1, completion.Volumes),
+		Long:              "Remove a volume.",
 		Example: heredoc.Doc(`
 			# Remove a volume 
 			$ kraft volume remove my-volume
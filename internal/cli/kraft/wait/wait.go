@@ -0,0 +1,334 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/completion"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+const (
+	// ConditionRunning is met once the machine is running.
+	ConditionRunning = "running"
+
+	// ConditionExited is met once the machine has exited, failed or errored.
+	ConditionExited = "exited"
+
+	// ConditionHealthy is met once the machine is running and each of its
+	// published TCP ports accepts connections.
+	ConditionHealthy = "healthy"
+)
+
+// Conditions returns the conditions which can be waited for.
+func Conditions() []string {
+	return []string{ConditionRunning, ConditionExited, ConditionHealthy}
+}
+
+type WaitOptions struct {
+	Condition string        `long:"condition" short:"c" usage:"Condition to wait for. Choice of: [running, exited, healthy]" default:"exited"`
+	Interval  time.Duration `long:"interval" usage:"Interval at which the state of the machine is checked in addition to its events" default:"1s"`
+	Platform  string        `noattribute:"true"`
+	Timeout   time.Duration `long:"timeout" usage:"Maximum time to wait (0 waits indefinitely)"`
+}
+
+// Wait blocks until each of the provided machines meets the condition.
+func Wait(ctx context.Context, opts *WaitOptions, args ...string) error {
+	if opts == nil {
+		opts = &WaitOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&WaitOptions{}, cobra.Command{
+		Short:             "Wait until one or more machines meet a condition",
+		Use:               "wait [FLAGS] MACHINE [MACHINE [...]]",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completion.Machines,
+		Aliases:           []string{},
+		Long: heredoc.Doc(`
+			Wait until one or more machines meet a condition.
+
+			The following conditions are supported:
+
+			  running  the machine is running
+			  exited   the machine has exited, failed or errored
+			  healthy  the machine is running and each of its published TCP ports
+			           accepts connections
+
+			When waiting for machines to exit, the exit code of each machine is
+			printed and kraft exits with the first non-zero exit code, such that the
+			command can be used in shell scripts and CI pipelines.
+		`),
+		Example: heredoc.Doc(`
+			# Wait until a machine has exited and propagate its exit code
+			$ kraft wait my-machine
+
+			# Wait up to 30 seconds until a machine serves its published ports
+			$ kraft wait --condition healthy --timeout 30s my-machine
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().VarP(
+		cmdfactory.NewEnumFlag[mplatform.Platform](
+			mplatform.Platforms(),
+			mplatform.Platform("auto"),
+		),
+		"plat",
+		"p",
+		"Set the platform virtual machine monitor driver.  Set to 'auto' to detect the guest's platform and 'host' to use the host platform.",
+	)
+
+	return cmd
+}
+
+func (opts *WaitOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if !slices.Contains(Conditions(), opts.Condition) {
+		return fmt.Errorf("unsupported condition: %s (choice of %v)", opts.Condition, Conditions())
+	}
+
+	if opts.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	if opts.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	opts.Platform = cmd.Flag("plat").Value.String()
+
+	return nil
+}
+
+func (opts *WaitOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	if opts.Condition == "" {
+		opts.Condition = ConditionExited
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+
+	platform := mplatform.PlatformUnknown
+	var controller machineapi.MachineService
+
+	if opts.Platform == "" || opts.Platform == "auto" {
+		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	} else {
+		if opts.Platform == "host" {
+			platform, _, err = mplatform.Detect(ctx)
+			if err != nil {
+				return err
+			}
+		} else {
+			var ok bool
+			platform, ok = mplatform.PlatformsByName()[opts.Platform]
+			if !ok {
+				return fmt.Errorf("unknown platform driver: %s", opts.Platform)
+			}
+		}
+
+		strategy, ok := mplatform.Strategies()[platform]
+		if !ok {
+			return fmt.Errorf("unsupported platform driver: %s (contributions welcome!)", platform.String())
+		}
+
+		controller, err = strategy.NewMachineV1alpha1(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	exitCode := 0
+
+	for _, name := range args {
+		machine, err := opts.wait(ctx, controller, name)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("timed out waiting for %s to be %s", name, opts.Condition)
+		} else if err != nil {
+			return err
+		}
+
+		if opts.Condition != ConditionExited {
+			fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+			continue
+		}
+
+		code := machine.Status.ExitCode
+		if code == 0 && machine.Status.State != machineapi.MachineStateExited {
+			code = 1
+		}
+
+		fmt.Fprintln(iostreams.G(ctx).Out, code)
+
+		if exitCode == 0 {
+			exitCode = code
+		}
+	}
+
+	if exitCode != 0 {
+		return &cmdfactory.ExitCodeError{Code: exitCode}
+	}
+
+	return nil
+}
+
+// wait blocks until the named machine meets the condition.  The events of the
+// machine are watched, and the state of the machine is additionally checked
+// at every interval since not every event causes the machine to change state
+// and the event stream is unavailable once the VMM has exited.
+func (opts *WaitOptions) wait(ctx context.Context, controller machineapi.MachineService, name string) (*machineapi.Machine, error) {
+	machine, err := controller.Get(ctx, &machineapi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get machine %s: %w", name, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, errs, err := controller.Watch(ctx, machine)
+	if err != nil {
+		log.G(ctx).
+			WithField("machine", name).
+			Debugf("could not watch events, checking state only: %v", err)
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		met, err := opts.met(ctx, machine)
+		if met || err != nil {
+			return machine, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return machine, ctx.Err()
+
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+
+			log.G(ctx).
+				WithField("machine", name).
+				WithField("state", event.Status.State).
+				Trace("received event")
+
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+
+			log.G(ctx).
+				WithField("machine", name).
+				Debugf("could not receive event: %v", err)
+
+		case <-ticker.C:
+		}
+
+		// Events only indicate the new state, so refresh the machine to also
+		// learn of e.g. its exit code.
+		if refreshed, err := controller.Get(ctx, machine); err == nil {
+			machine = refreshed
+		} else if ctx.Err() != nil {
+			return machine, ctx.Err()
+		}
+	}
+}
+
+// met returns whether the machine meets the condition, or an error if the
+// condition can no longer be met because the machine has stopped.
+func (opts *WaitOptions) met(ctx context.Context, machine *machineapi.Machine) (bool, error) {
+	switch machine.Status.State {
+	case machineapi.MachineStateExited,
+		machineapi.MachineStateFailed,
+		machineapi.MachineStateErrored:
+		if opts.Condition == ConditionExited {
+			return true, nil
+		}
+
+		return false, fmt.Errorf("machine %s is %s and cannot become %s", machine.Name, machine.Status.State, opts.Condition)
+
+	case machineapi.MachineStateRunning:
+		switch opts.Condition {
+		case ConditionRunning:
+			return true, nil
+		case ConditionHealthy:
+			return portsAccept(ctx, machine), nil
+		}
+	}
+
+	return false, nil
+}
+
+// portsAccept returns whether each of the published TCP ports of the machine
+// accepts connections on the host.
+func portsAccept(ctx context.Context, machine *machineapi.Machine) bool {
+	dialer := net.Dialer{Timeout: time.Second}
+
+	for _, port := range machine.Spec.Ports {
+		if port.Protocol != "" && port.Protocol != corev1.ProtocolTCP {
+			continue
+		}
+
+		host := port.HostIP
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "localhost"
+		}
+
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(int(port.HostPort))))
+		if err != nil {
+			log.G(ctx).
+				WithField("machine", machine.Name).
+				WithField("port", port.HostPort).
+				Trace("port does not accept connections yet")
+			return false
+		}
+
+		conn.Close()
+	}
+
+	return true
+}
@@ -6,13 +6,11 @@ package probe
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
 
 	"kraftkit.sh/cmdfactory"
-	"kraftkit.sh/internal/cli/kraft/cloud/utils"
 	"kraftkit.sh/internal/tableprinter"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
@@ -21,9 +19,10 @@ import (
 )
 
 type Probe struct {
+	tableprinter.OutputOptions
+
 	Config    string `long:"config" short:"c" usage:"Set path to generate .config file to"`
 	Kraftfile string `long:"kraftfile" short:"K" usage:"Set path to generate Kraftfile to"`
-	Output    string `long:"output" short:"o" usage:"Set output format. Options: table,yaml,json,list" default:"table"`
 }
 
 func NewCmd() *cobra.Command {
@@ -57,8 +56,8 @@ func (opts *Probe) Pre(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	if !utils.IsValidOutputFormat(opts.Output) {
-		return fmt.Errorf("invalid output format: %s", opts.Output)
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
 	cmd.SetContext(ctx)
@@ -89,9 +88,7 @@ func (opts *Probe) Run(ctx context.Context, args []string) error {
 
 	cs := iostreams.G(ctx).ColorScheme()
 
-	table, err := tableprinter.NewTablePrinter(ctx,
-		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
-		tableprinter.WithOutputFormatFromString(opts.Output))
+	table, err := opts.NewTablePrinter(ctx)
 	if err != nil {
 		return err
 	}
@@ -119,9 +116,7 @@ func (opts *Probe) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	table, err = tableprinter.NewTablePrinter(ctx,
-		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
-		tableprinter.WithOutputFormatFromString(opts.Output))
+	table, err = opts.NewTablePrinter(ctx)
 	if err != nil {
 		return err
 	}
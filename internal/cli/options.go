@@ -6,6 +6,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -19,6 +20,7 @@ import (
 	"kraftkit.sh/log"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/plugins"
+	"kraftkit.sh/tui"
 
 	"kraftkit.sh/internal/httpclient"
 )
@@ -30,6 +32,10 @@ type CliOptions struct {
 	PackageManager packmanager.PackageManager
 	PluginManager  *plugins.PluginManager
 	HTTPClient     *http.Client
+
+	// ProgressEmitter emits the progress of long-running tasks as raw JSON
+	// events, if requested via `--progress rawjson`.
+	ProgressEmitter *tui.ProgressEmitter
 }
 
 type CliOption func(*CliOptions) error
@@ -60,6 +66,33 @@ func WithDefaultLogger() CliOption {
 			copts.ConfigManager.Config.Log.Type = "basic"
 		}
 
+		// The progress of long-running tasks is rendered by the TUI components
+		// only when the log type is 'fancy', such that the plain and JSON
+		// renderers are selected by adjusting the log type.
+		switch progress := copts.ConfigManager.Config.Progress; progress {
+		case "", "auto":
+			if logType == log.FANCY && (iostreams.EnvColorDisabled() || iostreams.EnvCI() || copts.ConfigManager.Config.Quiet) {
+				logType = log.BASIC
+			}
+		case "fancy":
+		case "plain":
+			if logType == log.FANCY {
+				logType = log.BASIC
+			}
+		case "json":
+			logType = log.JSON
+		case "rawjson":
+			// Progress is emitted as raw JSON events, see
+			// WithDefaultProgressEmitter, alongside the plain log.
+			if logType == log.FANCY {
+				logType = log.BASIC
+			}
+		default:
+			return fmt.Errorf("unsupported progress output: %s", progress)
+		}
+
+		copts.ConfigManager.Config.Log.Type = log.LoggerTypeToString(logType)
+
 		switch logType {
 		case log.QUIET:
 			formatter := new(logrus.TextFormatter)
@@ -115,10 +148,38 @@ func WithDefaultLogger() CliOption {
 			logger.Level = level
 		}
 
+		if copts.ConfigManager.Config.Quiet && logger.Level > logrus.WarnLevel {
+			logger.Level = logrus.WarnLevel
+		}
+
 		if copts.IOStreams != nil {
 			logger.SetOutput(copts.IOStreams.Out)
 		}
 
+		filter, err := log.NewLevelFilter(logger.Level, copts.ConfigManager.Config.Log.Subsystems)
+		if err != nil {
+			return err
+		}
+
+		// Entries of subsystems with a more verbose level are only discarded
+		// once their subsystem is known, i.e. when they are formatted.
+		if len(filter.Subsystems) > 0 {
+			logger.Level = filter.MaxLevel()
+			logger.Formatter = &log.FilteredFormatter{
+				Formatter: logger.Formatter,
+				Filter:    filter,
+			}
+		}
+
+		if output := copts.ConfigManager.Config.Log.Output; output != "" {
+			hook, err := log.NewOutputHook(output, filter)
+			if err != nil {
+				return err
+			}
+
+			logger.AddHook(hook)
+		}
+
 		// Save the logger
 		copts.Logger = logger
 
@@ -162,6 +223,24 @@ func WithDefaultConfigManager(cmd *cobra.Command) CliOption {
 			}
 		}
 
+		// Apply the configuration context before attributing flags such that both
+		// environmental variables and flags still take precedence over it.  A
+		// context which is selected in the configuration file but no longer exists
+		// is ignored, such that a different context can still be selected.
+		if name := config.FetchContextFromArgs(os.Args[1:]); name != "" {
+			if err := cfg.ApplyContext(name); err != nil {
+				return err
+			}
+		} else if name := os.Getenv("KRAFTKIT_CONTEXT"); name != "" {
+			if err := cfg.ApplyContext(name); err != nil {
+				return err
+			}
+		} else if _, ok := cfg.Contexts[cfg.CurrentContext]; ok {
+			if err := cfg.ApplyContext(cfg.CurrentContext); err != nil {
+				return err
+			}
+		}
+
 		if err := cmdfactory.AttributeFlags(cmd, cfg, os.Args[1:]...); err != nil {
 			return err
 		}
@@ -187,6 +266,11 @@ func WithDefaultIOStreams() CliOption {
 		io := iostreams.System()
 
 		if copts.ConfigManager != nil {
+			// Never block on user input in continuous integration environments.
+			if iostreams.EnvCI() {
+				copts.ConfigManager.Config.NoPrompt = true
+			}
+
 			if copts.ConfigManager.Config.NoPrompt {
 				io.SetNeverPrompt(true)
 			}
@@ -219,6 +303,40 @@ func WithHTTPClient(httpClient *http.Client) CliOption {
 	}
 }
 
+// ProgressFD is the file descriptor which raw JSON progress events are written
+// to when it is open, such that they are kept apart from the output of
+// commands.  Otherwise, they are written to the standard output.
+const ProgressFD = 3
+
+// WithDefaultProgressEmitter sets up the emitter of raw JSON progress events
+// when the progress output is set to 'rawjson'.
+func WithDefaultProgressEmitter() CliOption {
+	return func(copts *CliOptions) error {
+		if copts.ProgressEmitter != nil || copts.ConfigManager == nil {
+			return nil
+		}
+
+		if copts.ConfigManager.Config.Progress != "rawjson" {
+			return nil
+		}
+
+		var out io.Writer = os.Stdout
+		if copts.IOStreams != nil {
+			out = copts.IOStreams.Out
+		}
+
+		if f := os.NewFile(ProgressFD, "progress"); f != nil {
+			if _, err := f.Stat(); err == nil {
+				out = f
+			}
+		}
+
+		copts.ProgressEmitter = tui.NewProgressEmitter(out)
+
+		return nil
+	}
+}
+
 // WithDefaultHTTPClient initializes a HTTP client using host-provided
 // configuration.
 func WithDefaultHTTPClient() CliOption {
@@ -235,6 +353,18 @@ func WithDefaultHTTPClient() CliOption {
 			return fmt.Errorf("cannot access IO streams")
 		}
 
+		// Apply the proxy and TLS settings to the default transports before any
+		// client is derived from them.
+		if err := httpclient.ConfigureDefaultTransport(httpclient.TransportOptions{
+			Proxy:      copts.ConfigManager.Config.HTTP.Proxy,
+			NoProxy:    copts.ConfigManager.Config.HTTP.NoProxy,
+			CACert:     copts.ConfigManager.Config.HTTP.CACert,
+			ClientCert: copts.ConfigManager.Config.HTTP.ClientCert,
+			ClientKey:  copts.ConfigManager.Config.HTTP.ClientKey,
+		}); err != nil {
+			return fmt.Errorf("could not configure HTTP transport: %w", err)
+		}
+
 		httpClient, err := httpclient.NewHTTPClient(
 			copts.IOStreams,
 			copts.ConfigManager.Config.HTTPUnixSocket,
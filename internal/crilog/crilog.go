@@ -0,0 +1,472 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package crilog writes log lines in the format defined by the Kubernetes
+// Container Runtime Interface (CRI), which is understood by the kubelet and
+// common log collectors without requiring a custom parser.  Each line is
+// prefixed with its RFC3339Nano timestamp, the stream it originates from and a
+// tag which indicates whether the line is complete or was split:
+//
+//	2016-10-06T00:17:09.669794202Z stdout F log content
+//
+// See: https://github.com/kubernetes/design-proposals-archive/blob/main/node/kubelet-cri-logging.md
+package crilog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stream is the origin of a log line.
+type Stream string
+
+const (
+	Stdout = Stream("stdout")
+	Stderr = Stream("stderr")
+)
+
+// Tag indicates whether a log line is complete or is one chunk of a line which
+// was split.
+type Tag string
+
+const (
+	TagFull    = Tag("F")
+	TagPartial = Tag("P")
+)
+
+// Format is the encoding of the lines of a log file.
+type Format string
+
+const (
+	// FormatCRI encodes lines in the Kubernetes CRI log format.
+	FormatCRI = Format("cri")
+
+	// FormatJSON encodes each line as a JSON object in the format of the
+	// json-file log driver of Docker, where a line which was split does not
+	// end with a line delimiter:
+	//
+	//	{"log":"log content\n","stream":"stdout","time":"2016-10-06T00:17:09.669794202Z"}
+	FormatJSON = Format("json")
+)
+
+const (
+	// Filename is the conventional name of the CRI log file of a machine
+	// within its state directory.
+	Filename = "cri.log"
+
+	// JSONFilename is the conventional name of the JSON log file of a machine
+	// within its state directory.
+	JSONFilename = "json.log"
+
+	// DefaultMaxLineSize is the size after which a line is split into partial
+	// lines, which matches that of containerd.
+	DefaultMaxLineSize = 16 * 1024
+
+	// DefaultMaxSize is the size of the log file after which it is rotated,
+	// which matches the default of the kubelet.
+	DefaultMaxSize = 10 * 1024 * 1024
+
+	// DefaultMaxFiles is the number of files, including the file currently
+	// written to, which are kept upon rotation, which matches the default of
+	// the kubelet.
+	DefaultMaxFiles = 5
+)
+
+// Entry is a single parsed line of a CRI log.
+type Entry struct {
+	Time    time.Time
+	Stream  Stream
+	Tag     Tag
+	Content string
+}
+
+// ParseLine parses a single line of a CRI log, without its line delimiter.
+func ParseLine(line string) (Entry, error) {
+	parts := strings.SplitN(line, " ", 4)
+	if len(parts) < 3 {
+		return Entry{}, fmt.Errorf("malformed CRI log line: %q", line)
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Entry{}, fmt.Errorf("parsing CRI log timestamp: %w", err)
+	}
+
+	entry := Entry{
+		Time:   ts,
+		Stream: Stream(parts[1]),
+		Tag:    Tag(parts[2]),
+	}
+
+	if len(parts) == 4 {
+		entry.Content = parts[3]
+	}
+
+	return entry, nil
+}
+
+// jsonLine is a single line of a JSON log.
+type jsonLine struct {
+	Log    string    `json:"log"`
+	Stream Stream    `json:"stream"`
+	Time   time.Time `json:"time"`
+}
+
+// ParseJSONLine parses a single line of a JSON log, without its line
+// delimiter.
+func ParseJSONLine(line string) (Entry, error) {
+	var parsed jsonLine
+	if err := json.Unmarshal([]byte(line), &parsed); err != nil {
+		return Entry{}, fmt.Errorf("malformed JSON log line: %w", err)
+	}
+
+	entry := Entry{
+		Time:    parsed.Time,
+		Stream:  parsed.Stream,
+		Tag:     TagPartial,
+		Content: parsed.Log,
+	}
+
+	if strings.HasSuffix(entry.Content, "\n") {
+		entry.Tag = TagFull
+		entry.Content = strings.TrimSuffix(entry.Content, "\n")
+	}
+
+	return entry, nil
+}
+
+// Writer is an io.WriteCloser which writes each line of its input in the CRI
+// log format to a file and rotates it once it exceeds its maximum size.
+type Writer struct {
+	mu          sync.Mutex
+	path        string
+	file        *os.File
+	size        int64
+	buf         []byte
+	format      Format
+	stream      Stream
+	maxLineSize int
+	maxSize     int64
+	maxFiles    int
+	now         func() time.Time
+}
+
+// WriterOption is an option which customizes the writer.
+type WriterOption func(*Writer) error
+
+// WithFormat sets the encoding of the lines.  By default, lines are encoded in
+// the CRI log format.
+func WithFormat(format Format) WriterOption {
+	return func(w *Writer) error {
+		if format != FormatCRI && format != FormatJSON {
+			return fmt.Errorf("unknown log format: %s", format)
+		}
+
+		w.format = format
+		return nil
+	}
+}
+
+// WithStream sets the stream which all lines are attributed to.  By default,
+// lines are attributed to stdout.
+func WithStream(stream Stream) WriterOption {
+	return func(w *Writer) error {
+		if stream != Stdout && stream != Stderr {
+			return fmt.Errorf("unknown stream: %s", stream)
+		}
+
+		w.stream = stream
+		return nil
+	}
+}
+
+// WithMaxLineSize sets the size after which a line is split into partial
+// lines.
+func WithMaxLineSize(size int) WriterOption {
+	return func(w *Writer) error {
+		if size <= 0 {
+			return fmt.Errorf("max line size must be positive")
+		}
+
+		w.maxLineSize = size
+		return nil
+	}
+}
+
+// WithMaxSize sets the size of the log file after which it is rotated.  A
+// size of 0 disables rotation.
+func WithMaxSize(size int64) WriterOption {
+	return func(w *Writer) error {
+		if size < 0 {
+			return fmt.Errorf("max size must not be negative")
+		}
+
+		w.maxSize = size
+		return nil
+	}
+}
+
+// WithMaxFiles sets the number of files, including the file currently written
+// to, which are kept upon rotation.
+func WithMaxFiles(files int) WriterOption {
+	return func(w *Writer) error {
+		if files < 1 {
+			return fmt.Errorf("max files must be at least 1")
+		}
+
+		w.maxFiles = files
+		return nil
+	}
+}
+
+// NewWriter opens, or creates, the log file at the provided path for
+// appending.
+func NewWriter(path string, opts ...WriterOption) (*Writer, error) {
+	w := &Writer{
+		path:        path,
+		format:      FormatCRI,
+		stream:      Stdout,
+		maxLineSize: DefaultMaxLineSize,
+		maxSize:     DefaultMaxSize,
+		maxFiles:    DefaultMaxFiles,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// open opens the log file for appending.
+func (w *Writer) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = fi.Size()
+
+	return nil
+}
+
+// Write implements io.Writer.  Complete lines are written immediately whereas
+// any trailing incomplete line is buffered until it is either completed,
+// exceeds the maximum line size or the writer is closed.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		if err := w.writeLine(w.buf[:i], TagFull); err != nil {
+			return 0, err
+		}
+
+		w.buf = w.buf[i+1:]
+	}
+
+	for len(w.buf) >= w.maxLineSize {
+		if err := w.writeLine(w.buf[:w.maxLineSize], TagPartial); err != nil {
+			return 0, err
+		}
+
+		w.buf = w.buf[w.maxLineSize:]
+	}
+
+	return len(p), nil
+}
+
+// WriteLine writes the provided line, which must not contain a line
+// delimiter, as a complete line.
+func (w *Writer) WriteLine(line string) error {
+	_, err := w.Write([]byte(line + "\n"))
+	return err
+}
+
+// writeLine formats and writes a single line, rotating the file beforehand if
+// necessary.
+func (w *Writer) writeLine(line []byte, tag Tag) error {
+	// Lines split at the maximum line size are the only partial ones, so a line
+	// which is longer still is written as multiple partial lines followed by a
+	// complete one.
+	for len(line) > w.maxLineSize {
+		if err := w.writeLine(line[:w.maxLineSize], TagPartial); err != nil {
+			return err
+		}
+
+		line = line[w.maxLineSize:]
+	}
+
+	entry, err := w.encode(line, tag)
+	if err != nil {
+		return err
+	}
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(entry)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return fmt.Errorf("rotating log file: %w", err)
+		}
+	}
+
+	n, err := w.file.Write(entry)
+	w.size += int64(n)
+
+	return err
+}
+
+// encode formats a single line, including its line delimiter.
+func (w *Writer) encode(line []byte, tag Tag) ([]byte, error) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	now := w.now().UTC()
+
+	if w.format == FormatJSON {
+		content := string(line)
+		if tag == TagFull {
+			content += "\n"
+		}
+
+		entry, err := json.Marshal(jsonLine{
+			Log:    content,
+			Stream: w.stream,
+			Time:   now,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return append(entry, '\n'), nil
+	}
+
+	entry := make([]byte, 0, len(line)+48)
+	entry = now.AppendFormat(entry, time.RFC3339Nano)
+	entry = append(entry, ' ')
+	entry = append(entry, w.stream...)
+	entry = append(entry, ' ')
+	entry = append(entry, tag...)
+	entry = append(entry, ' ')
+	entry = append(entry, line...)
+	entry = append(entry, '\n')
+
+	return entry, nil
+}
+
+// rotate shifts the existing log files such that the file currently written
+// to becomes <path>.1, discarding the oldest file beyond the maximum number of
+// files, and opens a new file.
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	if w.maxFiles <= 1 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return w.open()
+	}
+
+	oldest := fmt.Sprintf("%s.%d", w.path, w.maxFiles-1)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i := w.maxFiles - 2; i >= 1; i-- {
+		src := fmt.Sprintf("%s.%d", w.path, i)
+		dst := fmt.Sprintf("%s.%d", w.path, i+1)
+
+		if err := os.Rename(src, dst); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return err
+	}
+
+	return w.open()
+}
+
+// Close implements io.Closer.  Any buffered incomplete line is written as a
+// complete line.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	var err error
+	if len(w.buf) > 0 {
+		err = w.writeLine(w.buf, TagFull)
+		w.buf = nil
+	}
+
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+
+	w.file = nil
+
+	return err
+}
+
+// Follow writes each line received from the provided channels, as returned by
+// a machine service's Logs method, until the context is cancelled or a
+// non-EOF error is received.  Reaching the end of the log is not fatal, as
+// the log continues to be followed.
+func Follow(ctx context.Context, w *Writer, logs chan string, errs chan error) error {
+	for {
+		select {
+		case line := <-logs:
+			if err := w.WriteLine(line); err != nil {
+				return err
+			}
+
+		case err := <-errs:
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package crilog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestWriter(t *testing.T, opts ...WriterOption) (*Writer, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), Filename)
+
+	w, err := NewWriter(path, opts...)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	w.now = func() time.Time {
+		return time.Date(2016, 10, 6, 0, 17, 9, 669794202, time.UTC)
+	}
+
+	return w, path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+
+	return string(b)
+}
+
+func TestWriterFormat(t *testing.T) {
+	w, path := newTestWriter(t, WithStream(Stderr))
+
+	if _, err := w.Write([]byte("hello\r\nwor")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte("ld\ndangling")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "2016-10-06T00:17:09.669794202Z stderr F hello\n" +
+		"2016-10-06T00:17:09.669794202Z stderr F world\n" +
+		"2016-10-06T00:17:09.669794202Z stderr F dangling\n"
+
+	if got := readFile(t, path); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestWriterPartial(t *testing.T) {
+	w, path := newTestWriter(t, WithMaxLineSize(4))
+
+	if err := w.WriteLine("abcdefghij"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "2016-10-06T00:17:09.669794202Z stdout P abcd\n" +
+		"2016-10-06T00:17:09.669794202Z stdout P efgh\n" +
+		"2016-10-06T00:17:09.669794202Z stdout F ij\n"
+
+	if got := readFile(t, path); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestWriterRotate(t *testing.T) {
+	// Each entry is 41 bytes long, such that every file holds two entries.
+	w, path := newTestWriter(t, WithMaxSize(90), WithMaxFiles(3))
+
+	for _, line := range []string{"1", "2", "3", "4", "5", "6", "7"} {
+		if err := w.WriteLine(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := "2016-10-06T00:17:09.669794202Z stdout F "
+
+	for file, expected := range map[string]string{
+		path:        prefix + "7\n",
+		path + ".1": prefix + "5\n" + prefix + "6\n",
+		path + ".2": prefix + "3\n" + prefix + "4\n",
+	} {
+		if got := readFile(t, file); got != expected {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", file, expected, got)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected %s.3 to have been discarded", path)
+	}
+}
+
+func TestWriterOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), Filename)
+
+	for name, opt := range map[string]WriterOption{
+		"stream":        WithStream("stdin"),
+		"max line size": WithMaxLineSize(0),
+		"max size":      WithMaxSize(-1),
+		"max files":     WithMaxFiles(0),
+		"format":        WithFormat("xml"),
+	} {
+		if _, err := NewWriter(path, opt); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	entry, err := ParseLine("2016-10-06T00:17:09.669794202Z stderr P hello world")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Entry{
+		Time:    time.Date(2016, 10, 6, 0, 17, 9, 669794202, time.UTC),
+		Stream:  Stderr,
+		Tag:     TagPartial,
+		Content: "hello world",
+	}
+
+	if !entry.Time.Equal(expected.Time) || entry.Stream != expected.Stream || entry.Tag != expected.Tag || entry.Content != expected.Content {
+		t.Errorf("expected %+v, got %+v", expected, entry)
+	}
+
+	if _, err := ParseLine("hello world"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestWriterJSONFormat(t *testing.T) {
+	w, path := newTestWriter(t, WithFormat(FormatJSON), WithMaxLineSize(9))
+
+	if _, err := w.Write([]byte("hi \"you\"\r\nlonger line\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"log":"hi \"you\"\n","stream":"stdout","time":"2016-10-06T00:17:09.669794202Z"}` + "\n" +
+		`{"log":"longer li","stream":"stdout","time":"2016-10-06T00:17:09.669794202Z"}` + "\n" +
+		`{"log":"ne\n","stream":"stdout","time":"2016-10-06T00:17:09.669794202Z"}` + "\n"
+
+	if got := readFile(t, path); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestParseJSONLine(t *testing.T) {
+	for line, expected := range map[string]Entry{
+		`{"log":"hello\n","stream":"stderr","time":"2016-10-06T00:17:09.669794202Z"}`: {
+			Stream:  Stderr,
+			Tag:     TagFull,
+			Content: "hello",
+		},
+		`{"log":"hel","stream":"stdout","time":"2016-10-06T00:17:09.669794202Z"}`: {
+			Stream:  Stdout,
+			Tag:     TagPartial,
+			Content: "hel",
+		},
+	} {
+		entry, err := ParseJSONLine(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !entry.Time.Equal(time.Date(2016, 10, 6, 0, 17, 9, 669794202, time.UTC)) || entry.Stream != expected.Stream || entry.Tag != expected.Tag || entry.Content != expected.Content {
+			t.Errorf("expected %+v, got %+v", expected, entry)
+		}
+	}
+
+	if _, err := ParseJSONLine("hello world"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestParseRotation(t *testing.T) {
+	size, files, err := ParseRotation(map[string]string{
+		OptionMaxSize: "1KiB",
+		OptionMaxFile: "2",
+	}, DefaultMaxSize, DefaultMaxFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size != 1024 || files != 2 {
+		t.Errorf("expected 1024 bytes and 2 files, got %d bytes and %d files", size, files)
+	}
+
+	size, files, err = ParseRotation(nil, DefaultMaxSize, DefaultMaxFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size != DefaultMaxSize || files != DefaultMaxFiles {
+		t.Errorf("expected the defaults, got %d bytes and %d files", size, files)
+	}
+
+	for _, opts := range []map[string]string{
+		{OptionMaxSize: "large"},
+		{OptionMaxFile: "0"},
+		{"compress": "true"},
+	} {
+		if _, _, err := ParseRotation(opts, DefaultMaxSize, DefaultMaxFiles); err == nil {
+			t.Errorf("%v: expected error", opts)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package crilog
+
+import (
+	"context"
+	"sync"
+
+	"kraftkit.sh/log"
+)
+
+// LogsFunc returns the channels which receive the lines and errors of a log,
+// matching the Logs method of a machine service.
+type LogsFunc func(context.Context) (chan string, chan error, error)
+
+// Forwarder follows the logs of any number of machines in the background and
+// writes them to CRI or JSON log files.  Machine drivers use it to offer
+// structured logging as an option.
+type Forwarder struct {
+	mu      sync.Mutex
+	opts    []WriterOption
+	cancels map[string]*context.CancelFunc
+}
+
+// NewForwarder returns a forwarder which creates each log file with the
+// provided options.
+func NewForwarder(opts ...WriterOption) *Forwarder {
+	return &Forwarder{
+		opts:    opts,
+		cancels: map[string]*context.CancelFunc{},
+	}
+}
+
+// Start follows the log returned by the provided function and writes it to
+// the file at the provided path until Stop is called with the same ID.  The
+// log is followed independently of the lifetime of the provided context,
+// though only for as long as the calling process lives.  Calling Start for an
+// ID which is already being followed, e.g. when resuming a paused machine, has
+// no effect.  The provided options are applied to the log file after those of
+// the forwarder.
+func (f *Forwarder) Start(ctx context.Context, id, path string, logs LogsFunc, opts ...WriterOption) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.cancels[id]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	f.cancels[id] = &cancel
+
+	go func() {
+		defer f.stop(id, &cancel)
+
+		w, err := NewWriter(path, append(append([]WriterOption{}, f.opts...), opts...)...)
+		if err != nil {
+			log.G(ctx).Debugf("could not open log of %s: %v", id, err)
+			return
+		}
+
+		defer w.Close()
+
+		lines, errs, err := logs(ctx)
+		if err != nil {
+			log.G(ctx).Debugf("could not access logs of %s: %v", id, err)
+			return
+		}
+
+		if err := Follow(ctx, w, lines, errs); err != nil {
+			log.G(ctx).Debugf("could not write log of %s: %v", id, err)
+		}
+	}()
+}
+
+// Stop stops following the log with the provided ID.
+func (f *Forwarder) Stop(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if cancel, ok := f.cancels[id]; ok {
+		(*cancel)()
+		delete(f.cancels, id)
+	}
+}
+
+// stop stops following the log with the provided ID only if it is still
+// followed by the same call to Start, which is not the case if it has since
+// been stopped and started again.
+func (f *Forwarder) stop(id string, cancel *context.CancelFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cancels[id] == cancel {
+		(*cancel)()
+		delete(f.cancels, id)
+	}
+}
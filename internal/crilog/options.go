@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package crilog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	// OptionMaxSize is the log driver option which sets the size of a log file
+	// after which it is rotated, e.g. `10MiB`.  A size of 0 disables rotation.
+	OptionMaxSize = "max-size"
+
+	// OptionMaxFile is the log driver option which sets the number of files,
+	// including the file currently written to, which are kept upon rotation.
+	OptionMaxFile = "max-file"
+)
+
+// ParseRotation returns the rotation of logs set by the provided log driver
+// options, falling back to the provided size and number of files for options
+// which are not set.  Unknown options are rejected.
+func ParseRotation(opts map[string]string, maxSize int64, maxFiles int) (int64, int, error) {
+	keys := make([]string, 0, len(opts))
+	for key := range opts {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := opts[key]
+
+		switch key {
+		case OptionMaxSize:
+			size, err := humanize.ParseBytes(value)
+			if err != nil {
+				return 0, 0, fmt.Errorf("invalid log option %s=%s: %w", key, value, err)
+			}
+
+			maxSize = int64(size)
+
+		case OptionMaxFile:
+			files, err := strconv.Atoi(value)
+			if err != nil || files < 1 {
+				return 0, 0, fmt.Errorf("invalid log option %s=%s: expected a positive number", key, value)
+			}
+
+			maxFiles = files
+
+		default:
+			return 0, 0, fmt.Errorf("unknown log option: %s (choice of %s, %s)", key, OptionMaxSize, OptionMaxFile)
+		}
+	}
+
+	return maxSize, maxFiles, nil
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	composev1 "kraftkit.sh/api/compose/v1"
+)
+
+// lookupCompose returns the compose project whose name or UID matches the
+// provided identifier.
+func (s *Server) lookupCompose(ctx context.Context, id string) (*composev1.Compose, error) {
+	projects, err := s.compose.List(ctx, &composev1.ComposeList{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, project := range projects.Items {
+		if project.Name == id || string(project.UID) == id {
+			return &project, nil
+		}
+	}
+
+	return nil, fmt.Errorf("compose project '%s': %w", id, errNotFound)
+}
+
+func (s *Server) listCompose(w http.ResponseWriter, r *http.Request) {
+	projects, err := s.compose.List(r.Context(), &composev1.ComposeList{})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, projects)
+}
+
+func (s *Server) createCompose(w http.ResponseWriter, r *http.Request) {
+	project := &composev1.Compose{}
+	if err := readJSON(r, project); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if project.Spec.Workdir == "" {
+		writeError(w, r, http.StatusBadRequest, fmt.Errorf("spec.workdir must be set"))
+		return
+	}
+
+	project, err := s.compose.Create(r.Context(), project)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, project)
+}
+
+func (s *Server) getCompose(w http.ResponseWriter, r *http.Request) {
+	project, err := s.lookupCompose(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	if project, err = s.compose.Get(r.Context(), project); err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	} else if project == nil {
+		// The Composefile of the project no longer exists.
+		writeError(w, r, http.StatusNotFound, fmt.Errorf("compose project '%s': %w", r.PathValue("name"), errNotFound))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, project)
+}
+
+func (s *Server) deleteCompose(w http.ResponseWriter, r *http.Request) {
+	project, err := s.lookupCompose(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	if project, err = s.compose.Delete(r.Context(), project); err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, project)
+}
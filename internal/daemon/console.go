@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// errConsoleUnsupported is returned when an interactive console is requested
+// for a machine whose platform does not expose its serial console.
+var errConsoleUnsupported = errors.New("the platform of the machine does not support attaching to its console")
+
+// machineConsole exposes the serial console of a machine either over a
+// websocket or, mirroring Docker's attach endpoint, over the raw connection
+// after it has been upgraded with `Upgrade: tcp`.  By default the console is
+// read-only and streams the output of the machine from its log, such that the
+// boot log is included and any number of clients can view it at the same
+// time.  An interactive console, which forwards the input of the client to
+// the machine, is only available if it has been enabled for the server and
+// is limited to a single client per machine.
+func (s *Server) machineConsole(w http.ResponseWriter, r *http.Request) {
+	machine, err := s.lookupMachine(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	interactive := false
+	if v := r.URL.Query().Get("interactive"); v != "" {
+		if interactive, err = strconv.ParseBool(v); err != nil {
+			writeError(w, r, http.StatusBadRequest, fmt.Errorf("could not parse interactive: %w", err))
+			return
+		}
+	}
+
+	if interactive && !s.interactiveConsole {
+		writeError(w, r, http.StatusForbidden, fmt.Errorf("interactive consoles are disabled"))
+		return
+	}
+
+	if interactive && machine.Status.ConsoleSocket == "" {
+		writeError(w, r, http.StatusConflict, errConsoleUnsupported)
+		return
+	}
+
+	attach := func(ctx context.Context, conn io.ReadWriter) {
+		var err error
+
+		if interactive {
+			err = attachConsole(ctx, machine, conn)
+		} else {
+			err = s.viewConsole(ctx, machine, conn)
+		}
+
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.G(ctx).
+				WithField("machine", machine.Name).
+				Debugf("console closed: %v", err)
+		}
+	}
+
+	switch {
+	case strings.EqualFold(r.Header.Get("Upgrade"), "websocket"):
+		websocket.Server{
+			Handshake: checkOrigin,
+			Handler: func(ws *websocket.Conn) {
+				ws.PayloadType = websocket.BinaryFrame
+				attach(ws.Request().Context(), ws)
+			},
+		}.ServeHTTP(w, r)
+
+	case strings.EqualFold(r.Header.Get("Upgrade"), "tcp"):
+		conn, err := hijack(w)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		defer conn.Close()
+
+		// The server no longer tracks the connection once it has been hijacked,
+		// so it is closed explicitly when the server shuts down.
+		attach(r.Context(), &closeOnDone{ctx: r.Context(), Conn: conn})
+
+	default:
+		writeError(w, r, http.StatusBadRequest, fmt.Errorf("the connection must be upgraded to either websocket or tcp"))
+	}
+}
+
+// checkOrigin rejects websocket connections initiated by web pages which are
+// not served from the same host as the API, such that arbitrary websites
+// cannot access the console via a browser.
+func checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+
+	if u.Host != r.Host {
+		return fmt.Errorf("origin %s does not match host %s", origin, r.Host)
+	}
+
+	config.Origin = u
+
+	return nil
+}
+
+// hijack takes over the connection of the response after switching protocols.
+func hijack(w http.ResponseWriter) (net.Conn, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("connection cannot be upgraded")
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("could not upgrade connection: %w", err)
+	}
+
+	if _, err := buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := buf.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// closeOnDone closes the connection once the context is done, which unblocks
+// any pending reads.
+type closeOnDone struct {
+	net.Conn
+	ctx  context.Context
+	once sync.Once
+}
+
+// Read implements io.Reader.
+func (c *closeOnDone) Read(p []byte) (int, error) {
+	c.once.Do(func() {
+		go func() {
+			<-c.ctx.Done()
+			c.Conn.Close()
+		}()
+	})
+
+	return c.Conn.Read(p)
+}
+
+// viewConsole writes the output of the machine to the client until the end of
+// the log is reached or the client disconnects.  Input from the client is
+// discarded.
+func (s *Server) viewConsole(ctx context.Context, machine *machineapi.Machine, conn io.ReadWriter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	logs, errs, err := s.machines.Logs(ctx, machine)
+	if err != nil {
+		return fmt.Errorf("could not access logs: %w", err)
+	}
+
+	// Detect the client disconnecting by reading until the connection fails.
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		cancel()
+	}()
+
+	for {
+		select {
+		case line := <-logs:
+			if _, err := io.WriteString(conn, line+"\r\n"); err != nil {
+				return err
+			}
+
+		case err := <-errs:
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+
+			return nil
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// attachConsole forwards the input of the client to the serial console of the
+// machine and its output to the client until either side disconnects.
+func attachConsole(ctx context.Context, machine *machineapi.Machine, conn io.ReadWriter) error {
+	var dialer net.Dialer
+
+	console, err := dialer.DialContext(ctx, "unix", machine.Status.ConsoleSocket)
+	if err != nil {
+		return fmt.Errorf("could not connect to console: %w", err)
+	}
+
+	defer console.Close()
+
+	go func() {
+		<-ctx.Done()
+		console.Close()
+	}()
+
+	errs := make(chan error, 2)
+
+	go func() {
+		_, err := io.Copy(console, conn)
+		errs <- err
+	}()
+
+	go func() {
+		_, err := io.Copy(conn, console)
+		errs <- err
+	}()
+
+	return <-errs
+}
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+// lookupMachine returns the machine whose name or UID matches the provided
+// identifier.
+func (s *Server) lookupMachine(ctx context.Context, id string) (*machineapi.Machine, error) {
+	machines, err := s.machines.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, machine := range machines.Items {
+		if machine.Name == id || string(machine.UID) == id {
+			return &machine, nil
+		}
+	}
+
+	return nil, fmt.Errorf("machine '%s': %w", id, errNotFound)
+}
+
+func (s *Server) listMachines(w http.ResponseWriter, r *http.Request) {
+	machines, err := s.machines.List(r.Context(), &machineapi.MachineList{})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, machines)
+}
+
+func (s *Server) createMachine(w http.ResponseWriter, r *http.Request) {
+	machine := &machineapi.Machine{}
+	if err := readJSON(r, machine); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	service, status, err := s.platformMachines(r.Context(), machine)
+	if err != nil {
+		writeError(w, r, status, err)
+		return
+	}
+
+	machine, err = service.Create(r.Context(), machine)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, machine)
+}
+
+// platformMachines returns the machine service which creates machines of the
+// platform of the provided machine, which defaults to the host's platform, and
+// otherwise the status code of the failure.
+func (s *Server) platformMachines(ctx context.Context, machine *machineapi.Machine) (machineapi.MachineService, int, error) {
+	if machine.Spec.Platform == "" || machine.Spec.Platform == "auto" {
+		platform, _, err := mplatform.Detect(ctx)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("could not detect platform: %w", err)
+		}
+
+		machine.Spec.Platform = platform.String()
+	}
+
+	platform, ok := mplatform.PlatformsByName()[machine.Spec.Platform]
+	if !ok {
+		return nil, http.StatusBadRequest, fmt.Errorf("unknown platform '%s'", machine.Spec.Platform)
+	}
+
+	machine.Spec.Platform = platform.String()
+
+	if s.strategies == nil {
+		return s.machines, 0, nil
+	}
+
+	strategy, ok := s.strategies[platform]
+	if !ok {
+		return nil, http.StatusBadRequest, fmt.Errorf("platform '%s' is not supported by the host", platform)
+	}
+
+	service, err := strategy.NewMachineV1alpha1(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("could not instantiate %s machine service: %w", platform, err)
+	}
+
+	return service, 0, nil
+}
+
+func (s *Server) getMachine(w http.ResponseWriter, r *http.Request) {
+	machine, err := s.lookupMachine(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, machine)
+}
+
+func (s *Server) deleteMachine(w http.ResponseWriter, r *http.Request) {
+	machine, err := s.lookupMachine(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Stop the machine before deleting it.
+	if machine.Status.State == machineapi.MachineStateRunning || machine.Status.State == machineapi.MachineStatePaused {
+		if machine, err = s.machines.Stop(r.Context(), machine); err != nil {
+			writeError(w, r, http.StatusInternalServerError, fmt.Errorf("could not stop machine: %w", err))
+			return
+		}
+	}
+
+	if machine, err = s.machines.Delete(r.Context(), machine); err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, machine)
+}
+
+// machineAction returns a handler which performs the provided action on the
+// requested machine.
+func (s *Server) machineAction(action func(context.Context, *machineapi.Machine) (*machineapi.Machine, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		machine, err := s.lookupMachine(r.Context(), r.PathValue("name"))
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if machine, err = action(r.Context(), machine); err != nil {
+			writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, machine)
+	}
+}
+
+// machineLogs streams the log of the machine as plain text until either the
+// end of the log is reached or the client disconnects.
+func (s *Server) machineLogs(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	machine, err := s.lookupMachine(ctx, r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	logs, errs, err := s.machines.Logs(ctx, machine)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, fmt.Errorf("could not access logs: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		select {
+		case line := <-logs:
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+
+		case err := <-errs:
+			if err != nil && !errors.Is(err, io.EOF) {
+				_, _ = io.WriteString(w, err.Error()+"\n")
+			}
+			return
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,334 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	composev1 "kraftkit.sh/api/compose/v1"
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// fakeMachineService is an in-memory machine service.
+type fakeMachineService struct {
+	mu       sync.Mutex
+	machines []machineapi.Machine
+	err      error
+}
+
+func (fake *fakeMachineService) Create(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if fake.err != nil {
+		return machine, fake.err
+	}
+
+	machine.UID = types.UID(fmt.Sprintf("uid-%d", len(fake.machines)))
+	machine.Status.State = machineapi.MachineStateCreated
+	fake.machines = append(fake.machines, *machine)
+
+	return machine, nil
+}
+
+func (fake *fakeMachineService) state(machine *machineapi.Machine, state machineapi.MachineState) (*machineapi.Machine, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	for i := range fake.machines {
+		if fake.machines[i].UID == machine.UID {
+			fake.machines[i].Status.State = state
+			return &fake.machines[i], nil
+		}
+	}
+
+	return machine, fmt.Errorf("no such machine")
+}
+
+func (fake *fakeMachineService) Start(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	return fake.state(machine, machineapi.MachineStateRunning)
+}
+
+func (fake *fakeMachineService) Pause(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	return fake.state(machine, machineapi.MachineStatePaused)
+}
+
+func (fake *fakeMachineService) Stop(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	return fake.state(machine, machineapi.MachineStateExited)
+}
+
+func (fake *fakeMachineService) Update(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	return machine, nil
+}
+
+func (fake *fakeMachineService) Delete(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if fake.err != nil {
+		return machine, fake.err
+	}
+
+	for i := range fake.machines {
+		if fake.machines[i].UID == machine.UID {
+			fake.machines = append(fake.machines[:i], fake.machines[i+1:]...)
+			return machine, nil
+		}
+	}
+
+	return machine, fmt.Errorf("no such machine")
+}
+
+func (fake *fakeMachineService) Get(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	return machine, nil
+}
+
+func (fake *fakeMachineService) List(_ context.Context, _ *machineapi.MachineList) (*machineapi.MachineList, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if fake.err != nil {
+		return nil, fake.err
+	}
+
+	return &machineapi.MachineList{
+		Items: append([]machineapi.Machine{}, fake.machines...),
+	}, nil
+}
+
+func (fake *fakeMachineService) Watch(context.Context, *machineapi.Machine) (chan *machineapi.Machine, chan error, error) {
+	return nil, nil, fmt.Errorf("not implemented")
+}
+
+func (fake *fakeMachineService) Logs(context.Context, *machineapi.Machine) (chan string, chan error, error) {
+	return nil, nil, fmt.Errorf("not implemented")
+}
+
+// fakeNetworkService only implements the methods which are bound when the
+// routes of the server are registered.
+type fakeNetworkService struct {
+	networkapi.NetworkService
+}
+
+func (fakeNetworkService) Start(_ context.Context, network *networkapi.Network) (*networkapi.Network, error) {
+	return network, nil
+}
+
+func (fakeNetworkService) Stop(_ context.Context, network *networkapi.Network) (*networkapi.Network, error) {
+	return network, nil
+}
+
+type fakeVolumeService struct {
+	volumeapi.VolumeService
+}
+
+type fakeComposeService struct {
+	composev1.ComposeService
+}
+
+func newTestServer(t *testing.T, machines machineapi.MachineService) *httptest.Server {
+	t.Helper()
+
+	s, err := NewServer(context.Background(),
+		WithMachineService(machines),
+		WithNetworkService(fakeNetworkService{}),
+		WithVolumeService(fakeVolumeService{}),
+		WithComposeService(fakeComposeService{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func request(t *testing.T, method, url, body string) (int, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, b
+}
+
+func TestMachines(t *testing.T) {
+	fake := &fakeMachineService{}
+	ts := newTestServer(t, fake)
+	base := ts.URL + "/" + APIVersion + "/machines"
+
+	status, body := request(t, http.MethodPost, base, `{"metadata":{"name":"nginx"},"spec":{"plat":"kvm","arch":"x86_64"}}`)
+	if status != http.StatusCreated {
+		t.Fatalf("create: expected status %d, got %d: %s", http.StatusCreated, status, body)
+	}
+
+	var created machineapi.Machine
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatal(err)
+	}
+
+	if created.Name != "nginx" || created.UID == "" {
+		t.Errorf("create: unexpected machine: %+v", created.ObjectMeta)
+	}
+
+	// Aliases of platforms are resolved to their canonical name.
+	if created.Spec.Platform != "qemu" {
+		t.Errorf("create: expected platform qemu, got %s", created.Spec.Platform)
+	}
+
+	status, body = request(t, http.MethodGet, base, "")
+	if status != http.StatusOK {
+		t.Fatalf("list: expected status %d, got %d: %s", http.StatusOK, status, body)
+	}
+
+	var list machineapi.MachineList
+	if err := json.Unmarshal(body, &list); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list.Items) != 1 || list.Items[0].Name != "nginx" {
+		t.Errorf("list: unexpected machines: %+v", list.Items)
+	}
+
+	// Machines are found by both their name and UID.
+	for _, id := range []string{"nginx", string(created.UID)} {
+		status, body = request(t, http.MethodGet, base+"/"+id, "")
+		if status != http.StatusOK {
+			t.Fatalf("get %s: expected status %d, got %d: %s", id, http.StatusOK, status, body)
+		}
+
+		var got machineapi.Machine
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.UID != created.UID {
+			t.Errorf("get %s: expected UID %s, got %s", id, created.UID, got.UID)
+		}
+	}
+
+	status, body = request(t, http.MethodPost, base+"/nginx/start", "")
+	if status != http.StatusOK {
+		t.Fatalf("start: expected status %d, got %d: %s", http.StatusOK, status, body)
+	}
+
+	// Running machines are stopped before they are deleted.
+	status, body = request(t, http.MethodDelete, base+"/nginx", "")
+	if status != http.StatusOK {
+		t.Fatalf("delete: expected status %d, got %d: %s", http.StatusOK, status, body)
+	}
+
+	if len(fake.machines) != 0 {
+		t.Errorf("delete: expected no machines, got %d", len(fake.machines))
+	}
+}
+
+func TestMachinesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		fail   error
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{
+			name:   "create with malformed body",
+			method: http.MethodPost,
+			path:   "",
+			body:   `{"metadata":`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create with unknown platform",
+			method: http.MethodPost,
+			path:   "",
+			body:   `{"metadata":{"name":"nginx"},"spec":{"plat":"vmware"}}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create fails",
+			fail:   errors.New("out of memory"),
+			method: http.MethodPost,
+			path:   "",
+			body:   `{"metadata":{"name":"nginx"},"spec":{"plat":"qemu"}}`,
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "list fails",
+			fail:   errors.New("store is locked"),
+			method: http.MethodGet,
+			path:   "",
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "get unknown machine",
+			method: http.MethodGet,
+			path:   "/unknown",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "delete unknown machine",
+			method: http.MethodDelete,
+			path:   "/unknown",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "start unknown machine",
+			method: http.MethodPost,
+			path:   "/unknown/start",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, &fakeMachineService{err: tt.fail})
+
+			status, body := request(t, tt.method, ts.URL+"/"+APIVersion+"/machines"+tt.path, tt.body)
+			if status != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, status, body)
+			}
+
+			var resp ErrorResponse
+			if err := json.Unmarshal(body, &resp); err != nil {
+				t.Fatalf("could not decode error response: %v", err)
+			}
+
+			if resp.Message == "" {
+				t.Error("expected error message")
+			}
+		})
+	}
+}
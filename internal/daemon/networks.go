@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+)
+
+// lookupNetwork returns the network whose name or UID matches the provided
+// identifier.
+func (s *Server) lookupNetwork(ctx context.Context, id string) (*networkapi.Network, error) {
+	networks, err := s.networks.List(ctx, &networkapi.NetworkList{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, network := range networks.Items {
+		if network.Name == id || string(network.UID) == id {
+			return &network, nil
+		}
+	}
+
+	return nil, fmt.Errorf("network '%s': %w", id, errNotFound)
+}
+
+func (s *Server) listNetworks(w http.ResponseWriter, r *http.Request) {
+	networks, err := s.networks.List(r.Context(), &networkapi.NetworkList{})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, networks)
+}
+
+func (s *Server) createNetwork(w http.ResponseWriter, r *http.Request) {
+	network := &networkapi.Network{}
+	if err := readJSON(r, network); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	network, err := s.networks.Create(r.Context(), network)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, network)
+}
+
+func (s *Server) getNetwork(w http.ResponseWriter, r *http.Request) {
+	network, err := s.lookupNetwork(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, network)
+}
+
+func (s *Server) deleteNetwork(w http.ResponseWriter, r *http.Request) {
+	network, err := s.lookupNetwork(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(network.Spec.Interfaces) > 0 && r.URL.Query().Get("force") != "true" {
+		writeError(w, r, http.StatusConflict, fmt.Errorf("network '%s' is in use, set force=true to remove it anyway", network.Name))
+		return
+	}
+
+	if network, err = s.networks.Delete(r.Context(), network); err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, network)
+}
+
+// networkAction returns a handler which performs the provided action on the
+// requested network.
+func (s *Server) networkAction(action func(context.Context, *networkapi.Network) (*networkapi.Network, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		network, err := s.lookupNetwork(r.Context(), r.PathValue("name"))
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if network, err = action(r.Context(), network); err != nil {
+			writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, network)
+	}
+}
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	_ "embed"
+	"net/http"
+
+	"kraftkit.sh/log"
+)
+
+// OpenAPISpec is the OpenAPI 3 specification of the REST API.
+//
+//go:embed openapi.yaml
+var OpenAPISpec []byte
+
+func (s *Server) openapi(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(OpenAPISpec); err != nil {
+		log.G(r.Context()).Debugf("could not write specification: %v", err)
+	}
+}
+
+func (s *Server) ping(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+}
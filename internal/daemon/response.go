@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"kraftkit.sh/log"
+)
+
+// maxBodySize is the maximum size of a request body.
+const maxBodySize = 1 << 20
+
+// errNotFound is returned when the requested object does not exist.
+var errNotFound = errors.New("not found")
+
+// ErrorResponse is the body returned by all endpoints upon failure.
+type ErrorResponse struct {
+	Message string `json:"message"`
+}
+
+// writeJSON encodes the provided value as the response body.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.G(r.Context()).Debugf("could not encode response: %v", err)
+	}
+}
+
+// writeError encodes the provided error as the response body.  Errors which
+// wrap errNotFound result in a 404.
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if errors.Is(err, errNotFound) {
+		status = http.StatusNotFound
+	}
+
+	writeJSON(w, r, status, ErrorResponse{Message: err.Error()})
+}
+
+// readJSON decodes the request body into the provided value.
+func readJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodySize)).Decode(v); err != nil {
+		return fmt.Errorf("could not decode request body: %w", err)
+	}
+
+	return nil
+}
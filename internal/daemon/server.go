@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package daemon provides an HTTP REST server which exposes the machine,
+// network, volume and compose APIs such that external orchestrators and user
+// interfaces can manage unikernels without invoking the CLI.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	composev1 "kraftkit.sh/api/compose/v1"
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/volume"
+)
+
+// APIVersion is the version prefix of all REST endpoints.
+const APIVersion = "v1"
+
+// Server serves the KraftKit APIs over HTTP.
+type Server struct {
+	machines machineapi.MachineService
+	networks networkapi.NetworkService
+	volumes  volumeapi.VolumeService
+	compose  composev1.ComposeService
+	mux      *http.ServeMux
+
+	// strategies create machines of the requested platform, unless a machine
+	// service was provided.  The default machine service iterates over the
+	// platforms in no particular order, hence it cannot create machines.
+	strategies map[mplatform.Platform]*mplatform.Strategy
+
+	// interactiveConsole permits clients to send input to the consoles of
+	// machines.
+	interactiveConsole bool
+}
+
+// ServerOption is an option which customizes the server.
+type ServerOption func(*Server) error
+
+// WithMachineService sets the machine service which backs the machine
+// endpoints.
+func WithMachineService(service machineapi.MachineService) ServerOption {
+	return func(s *Server) error {
+		s.machines = service
+		return nil
+	}
+}
+
+// WithNetworkService sets the network service which backs the network
+// endpoints.
+func WithNetworkService(service networkapi.NetworkService) ServerOption {
+	return func(s *Server) error {
+		s.networks = service
+		return nil
+	}
+}
+
+// WithVolumeService sets the volume service which backs the volume endpoints.
+func WithVolumeService(service volumeapi.VolumeService) ServerOption {
+	return func(s *Server) error {
+		s.volumes = service
+		return nil
+	}
+}
+
+// WithComposeService sets the compose service which backs the compose
+// endpoints.
+func WithComposeService(service composev1.ComposeService) ServerOption {
+	return func(s *Server) error {
+		s.compose = service
+		return nil
+	}
+}
+
+// WithInteractiveConsole permits clients to send input to the serial consoles
+// of machines, rather than only view their output.
+func WithInteractiveConsole(interactive bool) ServerOption {
+	return func(s *Server) error {
+		s.interactiveConsole = interactive
+		return nil
+	}
+}
+
+// NewServer instantiates a server.  Any service which has not been provided
+// via an option is instantiated with the host's default implementation.
+func NewServer(ctx context.Context, opts ...ServerOption) (*Server, error) {
+	s := &Server{
+		mux: http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+
+	if s.machines == nil {
+		if s.machines, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx); err != nil {
+			return nil, err
+		}
+
+		s.strategies = mplatform.Strategies()
+	}
+
+	if s.networks == nil {
+		if s.networks, err = network.NewNetworkV1alpha1ServiceIterator(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.volumes == nil {
+		if s.volumes, err = volume.NewVolumeV1alpha1ServiceIterator(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.compose == nil {
+		if s.compose, err = compose.NewComposeProjectV1(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.routes()
+
+	return s, nil
+}
+
+// routes registers all endpoints of the server.
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /_ping", s.ping)
+	s.mux.HandleFunc("GET /openapi.yaml", s.openapi)
+
+	s.mux.HandleFunc("GET /"+APIVersion+"/machines", s.listMachines)
+	s.mux.HandleFunc("POST /"+APIVersion+"/machines", s.createMachine)
+	s.mux.HandleFunc("GET /"+APIVersion+"/machines/{name}", s.getMachine)
+	s.mux.HandleFunc("DELETE /"+APIVersion+"/machines/{name}", s.deleteMachine)
+	s.mux.HandleFunc("POST /"+APIVersion+"/machines/{name}/start", s.machineAction(s.machines.Start))
+	s.mux.HandleFunc("POST /"+APIVersion+"/machines/{name}/stop", s.machineAction(s.machines.Stop))
+	s.mux.HandleFunc("POST /"+APIVersion+"/machines/{name}/pause", s.machineAction(s.machines.Pause))
+	s.mux.HandleFunc("GET /"+APIVersion+"/machines/{name}/logs", s.machineLogs)
+	s.mux.HandleFunc("GET /"+APIVersion+"/machines/{name}/console", s.machineConsole)
+
+	s.mux.HandleFunc("GET /"+APIVersion+"/networks", s.listNetworks)
+	s.mux.HandleFunc("POST /"+APIVersion+"/networks", s.createNetwork)
+	s.mux.HandleFunc("GET /"+APIVersion+"/networks/{name}", s.getNetwork)
+	s.mux.HandleFunc("DELETE /"+APIVersion+"/networks/{name}", s.deleteNetwork)
+	s.mux.HandleFunc("POST /"+APIVersion+"/networks/{name}/up", s.networkAction(s.networks.Start))
+	s.mux.HandleFunc("POST /"+APIVersion+"/networks/{name}/down", s.networkAction(s.networks.Stop))
+
+	s.mux.HandleFunc("GET /"+APIVersion+"/volumes", s.listVolumes)
+	s.mux.HandleFunc("POST /"+APIVersion+"/volumes", s.createVolume)
+	s.mux.HandleFunc("GET /"+APIVersion+"/volumes/{name}", s.getVolume)
+	s.mux.HandleFunc("DELETE /"+APIVersion+"/volumes/{name}", s.deleteVolume)
+
+	s.mux.HandleFunc("GET /"+APIVersion+"/compose", s.listCompose)
+	s.mux.HandleFunc("POST /"+APIVersion+"/compose", s.createCompose)
+	s.mux.HandleFunc("GET /"+APIVersion+"/compose/{name}", s.getCompose)
+	s.mux.HandleFunc("DELETE /"+APIVersion+"/compose/{name}", s.deleteCompose)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+	s.mux.ServeHTTP(rw, r)
+
+	log.G(r.Context()).
+		WithField("method", r.Method).
+		WithField("path", r.URL.Path).
+		WithField("status", rw.status).
+		WithField("elapsed", time.Since(start)).
+		Debug("request")
+}
+
+// Serve accepts connections on each of the provided listeners until the
+// context is cancelled, after which in-flight requests are given a grace
+// period to complete.
+func (s *Server) Serve(ctx context.Context, listeners ...net.Listener) error {
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			log.G(ctx).
+				WithField("addr", l.Addr().String()).
+				Info("listening")
+
+			if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}(l)
+	}
+
+	select {
+	case <-ctx.Done():
+	case err := <-errs:
+		_ = srv.Close()
+		return err
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return srv.Shutdown(shutdownCtx)
+}
+
+// statusWriter records the status code written to the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush implements http.Flusher.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
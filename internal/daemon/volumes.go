@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// lookupVolume returns the volume whose name or UID matches the provided
+// identifier.
+func (s *Server) lookupVolume(ctx context.Context, id string) (*volumeapi.Volume, error) {
+	volumes, err := s.volumes.List(ctx, &volumeapi.VolumeList{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, volume := range volumes.Items {
+		if volume.Name == id || string(volume.UID) == id {
+			return &volume, nil
+		}
+	}
+
+	return nil, fmt.Errorf("volume '%s': %w", id, errNotFound)
+}
+
+func (s *Server) listVolumes(w http.ResponseWriter, r *http.Request) {
+	volumes, err := s.volumes.List(r.Context(), &volumeapi.VolumeList{})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, volumes)
+}
+
+func (s *Server) createVolume(w http.ResponseWriter, r *http.Request) {
+	volume := &volumeapi.Volume{}
+	if err := readJSON(r, volume); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	volume, err := s.volumes.Create(r.Context(), volume)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, volume)
+}
+
+func (s *Server) getVolume(w http.ResponseWriter, r *http.Request) {
+	volume, err := s.lookupVolume(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, volume)
+}
+
+func (s *Server) deleteVolume(w http.ResponseWriter, r *http.Request) {
+	volume, err := s.lookupVolume(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	if volume, err = s.volumes.Delete(r.Context(), volume); err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, volume)
+}
@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package eventlog persists the lifecycle transitions of machines in a bounded
+// history, such that they can be queried after the fact, including for
+// machines which have since exited or been removed.
+//
+// The history is a file of JSON-encoded events, one per line, in the order in
+// which they were recorded.  It is used as a ring buffer: once it holds more
+// than its capacity, the oldest events are dropped.  Every change is
+// performed while holding an exclusive lock on the file, such that concurrent
+// KraftKit processes do not lose each other's events.
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kraftkit.sh/internal/lockedfile"
+)
+
+// DefaultCapacity is the number of events which are kept in the history.
+const DefaultCapacity = 1000
+
+// Event is the transition of a machine into a new state.
+type Event struct {
+	// Time is when the machine entered the state.
+	Time time.Time `json:"time"`
+
+	// Machine is the name of the machine.
+	Machine string `json:"machine"`
+
+	// UID is the unique identifier of the machine.
+	UID string `json:"uid"`
+
+	// Platform is the platform which the machine runs on.
+	Platform string `json:"platform,omitempty"`
+
+	// State is the state which the machine entered.
+	State string `json:"state"`
+
+	// Previous is the state which the machine was last recorded in, or empty
+	// if it was not seen before.
+	Previous string `json:"previous,omitempty"`
+
+	// ExitCode is the exit code of the machine once it has exited.
+	ExitCode int `json:"exitCode,omitempty"`
+}
+
+// Filter selects events from the history.
+type Filter struct {
+	// Since excludes events which happened before the provided time, unless it
+	// is zero.
+	Since time.Time
+
+	// Until excludes events which happened after the provided time, unless it
+	// is zero.
+	Until time.Time
+
+	// Machine only includes events of the machine with the provided name or
+	// UID, unless it is empty.
+	Machine string
+}
+
+// Matches returns whether the event is selected by the filter.
+func (filter Filter) Matches(event Event) bool {
+	if !filter.Since.IsZero() && event.Time.Before(filter.Since) {
+		return false
+	}
+
+	if !filter.Until.IsZero() && event.Time.After(filter.Until) {
+		return false
+	}
+
+	if filter.Machine != "" && filter.Machine != event.Machine && filter.Machine != event.UID {
+		return false
+	}
+
+	return true
+}
+
+// Log is the persisted history of machine events.
+type Log struct {
+	path     string
+	capacity int
+}
+
+// New returns the history at the provided path which keeps at most capacity
+// events.  If capacity is not positive, DefaultCapacity is used.
+func New(path string, capacity int) *Log {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+
+	return &Log{
+		path:     path,
+		capacity: capacity,
+	}
+}
+
+// Path returns the path of the file which holds the history.
+func (log *Log) Path() string {
+	return log.path
+}
+
+// Observe records the observed states of machines.  An event is only appended
+// for machines whose state differs from the state which they were last
+// recorded in, such that the same state can be observed repeatedly.  The
+// Previous field of the observed events is ignored.  The events which were
+// appended are returned.
+func (log *Log) Observe(observed ...Event) ([]Event, error) {
+	if len(observed) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(log.path), 0o755); err != nil {
+		return nil, err
+	}
+
+	var appended []Event
+
+	err := lockedfile.Transform(log.path, func(b []byte) ([]byte, error) {
+		events := decode(b)
+
+		last := make(map[string]string, len(events))
+		for _, event := range events {
+			last[event.UID] = event.State
+		}
+
+		for _, event := range observed {
+			previous, ok := last[event.UID]
+			if ok && previous == event.State {
+				continue
+			}
+
+			event.Previous = previous
+			last[event.UID] = event.State
+
+			events = append(events, event)
+			appended = append(appended, event)
+		}
+
+		if len(appended) == 0 {
+			return b, nil
+		}
+
+		if len(events) > log.capacity {
+			events = events[len(events)-log.capacity:]
+		}
+
+		return encode(events)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return appended, nil
+}
+
+// List returns the events in the history which are selected by the filter, in
+// the order in which they were recorded.
+func (log *Log) List(filter Filter) ([]Event, error) {
+	b, err := lockedfile.Read(log.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, event := range decode(b) {
+		if filter.Matches(event) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// decode parses the events of a history.  Lines which cannot be parsed, e.g.
+// because a write was interrupted, are skipped.
+func decode(b []byte) []Event {
+	var events []Event
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// encode serializes the events of a history.
+func encode(events []Event) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package eventlog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestObserveRecordsTransitions(t *testing.T) {
+	log := New(filepath.Join(t.TempDir(), "runtime", "events.jsonl"), 0)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		state string
+		want  int
+	}{
+		{"created", 1},
+		{"created", 0},
+		{"running", 1},
+		{"running", 0},
+		{"exited", 1},
+	}
+
+	for i, step := range steps {
+		appended, err := log.Observe(Event{
+			Time:    now.Add(time.Duration(i) * time.Minute),
+			Machine: "vm",
+			UID:     "uid",
+			State:   step.state,
+		})
+		if err != nil {
+			t.Fatalf("observing %s: %v", step.state, err)
+		}
+		if len(appended) != step.want {
+			t.Fatalf("observing %s: appended %d events, want %d", step.state, len(appended), step.want)
+		}
+	}
+
+	events, err := log.List(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+
+	if events[2].State != "exited" || events[2].Previous != "running" {
+		t.Errorf("got transition %s -> %s, want running -> exited", events[2].Previous, events[2].State)
+	}
+}
+
+func TestObserveDropsOldestEvents(t *testing.T) {
+	log := New(filepath.Join(t.TempDir(), "events.jsonl"), 2)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, state := range []string{"created", "running", "exited"} {
+		if _, err := log.Observe(Event{
+			Time:  now.Add(time.Duration(i) * time.Minute),
+			UID:   "uid",
+			State: state,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := log.List(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].State != "running" || events[1].State != "exited" {
+		t.Errorf("got %+v, want the running and exited events", events)
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	log := New(filepath.Join(t.TempDir(), "events.jsonl"), 0)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := log.Observe(
+		Event{Time: now, Machine: "a", UID: "uid-a", State: "running"},
+		Event{Time: now.Add(time.Hour), Machine: "b", UID: "uid-b", State: "running"},
+		Event{Time: now.Add(2 * time.Hour), Machine: "c", UID: "uid-c", State: "running"},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all", Filter{}, []string{"a", "b", "c"}},
+		{"since", Filter{Since: now.Add(time.Hour)}, []string{"b", "c"}},
+		{"until", Filter{Until: now.Add(time.Hour)}, []string{"a", "b"}},
+		{"window", Filter{Since: now.Add(time.Minute), Until: now.Add(time.Hour)}, []string{"b"}},
+		{"machine by name", Filter{Machine: "c"}, []string{"c"}},
+		{"machine by uid", Filter{Machine: "uid-a"}, []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := log.List(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, event := range events {
+				got = append(got, event.Machine)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestListMissingHistory(t *testing.T) {
+	events, err := New(filepath.Join(t.TempDir(), "events.jsonl"), 0).List(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 0 {
+		t.Errorf("got %d events, want none", len(events))
+	}
+}
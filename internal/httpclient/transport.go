@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TransportOptions configure how outbound HTTP(S) connections are made.
+type TransportOptions struct {
+	// Proxy is the URL of the proxy used for both HTTP and HTTPS connections,
+	// e.g. http://proxy:3128 or socks5://proxy:1080.  If unset, the standard
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environmental variables are used.
+	Proxy string
+
+	// NoProxy is a comma-separated list of hosts, domains and CIDR ranges which
+	// are connected to directly rather than through the proxy.
+	NoProxy string
+
+	// CACert is the path to a PEM-encoded bundle of certificate authorities
+	// which are trusted in addition to those of the system.
+	CACert string
+
+	// ClientCert and ClientKey are the paths to a PEM-encoded certificate and
+	// key which are presented to servers that request mutual TLS.
+	ClientCert string
+	ClientKey  string
+}
+
+// ConfigureDefaultTransport applies the provided options to the default HTTP
+// transports of the standard library and of go-containerregistry, from which
+// the connections made by package managers, manifest providers and cloud
+// clients are derived.
+func ConfigureDefaultTransport(opts TransportOptions) error {
+	proxy, err := opts.proxy()
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	for _, rt := range []http.RoundTripper{http.DefaultTransport, remote.DefaultTransport} {
+		tr, ok := rt.(*http.Transport)
+		if !ok {
+			continue
+		}
+
+		if proxy != nil {
+			tr.Proxy = proxy
+		}
+
+		if tlsConfig != nil {
+			tr.TLSClientConfig = tlsConfig.Clone()
+		}
+	}
+
+	return nil
+}
+
+// proxy returns the function which selects the proxy of a request, or nil if
+// the default selection based on environmental variables is unchanged.
+func (opts TransportOptions) proxy() (func(*http.Request) (*url.URL, error), error) {
+	if opts.Proxy == "" && opts.NoProxy == "" {
+		return nil, nil
+	}
+
+	cfg := httpproxy.FromEnvironment()
+
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse proxy URL: %w", err)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme '%s': expected one of http, https, socks5 or socks5h", u.Scheme)
+		}
+
+		cfg.HTTPProxy = opts.Proxy
+		cfg.HTTPSProxy = opts.Proxy
+	}
+
+	if opts.NoProxy != "" {
+		cfg.NoProxy = opts.NoProxy
+	}
+
+	proxyFunc := cfg.ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}, nil
+}
+
+// tlsConfig returns the TLS configuration with the additional certificate
+// authorities and client certificate, or nil if neither has been provided.
+func (opts TransportOptions) tlsConfig() (*tls.Config, error) {
+	if opts.CACert == "" && opts.ClientCert == "" && opts.ClientKey == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if opts.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		bundle, err := os.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA bundle: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("could not find any certificates in CA bundle '%s'", opts.CACert)
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	if opts.ClientCert != "" || opts.ClientKey != "" {
+		if opts.ClientCert == "" || opts.ClientKey == "" {
+			return nil, fmt.Errorf("both a client certificate and key must be provided")
+		}
+
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package logdriver records the console output of machines with the log
+// driver set in their specification.  It is shared by the machine drivers
+// whose VMM writes the console output to a file.
+package logdriver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/internal/crilog"
+	"kraftkit.sh/internal/logrotate"
+	"kraftkit.sh/log"
+)
+
+// ConsoleFilename is the name of the file within the state directory of a
+// machine which its console output is written to by the VMM.
+const ConsoleFilename = "machine.log"
+
+// RotateInterval is the interval at which the console log of a machine is
+// rotated whilst its output is followed.
+const RotateInterval = 5 * time.Second
+
+// Drivers follows the console output of machines in the background to write
+// the structured logs which are enabled for them.
+type Drivers struct {
+	// CRI, if set, additionally writes the console output of every machine in
+	// the Kubernetes CRI log format.
+	CRI *crilog.Forwarder
+
+	// MaxSize is the size after which logs are rotated, unless set by the log
+	// options of a machine.  A size of 0 disables rotation.
+	MaxSize int64
+
+	// MaxFiles is the number of files which are kept upon rotation, including
+	// the current one, unless set by the log options of a machine.
+	MaxFiles int
+
+	json *crilog.Forwarder
+
+	mu       sync.Mutex
+	rotators map[string]context.CancelFunc
+}
+
+// NewDrivers returns the log drivers of a machine service.
+func NewDrivers() *Drivers {
+	return &Drivers{
+		json:     crilog.NewForwarder(crilog.WithFormat(crilog.FormatJSON)),
+		rotators: map[string]context.CancelFunc{},
+	}
+}
+
+// Rotation returns the size after which the logs of the machine are rotated
+// and the number of files which are kept, as set by the options of its log
+// driver or otherwise by the drivers.
+func (drivers *Drivers) Rotation(ctx context.Context, machine *machinev1alpha1.Machine) (int64, int) {
+	maxSize, maxFiles, err := crilog.ParseRotation(machine.Spec.Logging.Options, drivers.MaxSize, drivers.MaxFiles)
+	if err != nil {
+		log.G(ctx).Debugf("ignoring log options of %s: %v", machine.Name, err)
+		return drivers.MaxSize, drivers.MaxFiles
+	}
+
+	return maxSize, maxFiles
+}
+
+// Rotate rotates the console log of the machine if it exceeds the size set by
+// the options of its log driver or otherwise by the drivers.
+func (drivers *Drivers) Rotate(ctx context.Context, machine *machinev1alpha1.Machine) {
+	if machine.Status.LogFile == "" || machine.Status.LogFile == os.DevNull {
+		return
+	}
+
+	maxSize, maxFiles := drivers.Rotation(ctx, machine)
+	if _, err := logrotate.Rotate(machine.Status.LogFile, maxSize, maxFiles); err != nil {
+		log.G(ctx).Debugf("could not rotate log of %s: %v", machine.Name, err)
+	}
+}
+
+// Start follows the console output of the machine, as returned by logs, in
+// the background to write the structured logs which are enabled for it and to
+// rotate its console log as it grows.  The output is only followed for as long
+// as the calling process lives.
+func (drivers *Drivers) Start(ctx context.Context, machine *machinev1alpha1.Machine, logs crilog.LogsFunc) {
+	drivers.startRotator(ctx, machine)
+
+	if drivers.CRI != nil {
+		drivers.CRI.Start(ctx, string(machine.UID), filepath.Join(machine.Status.StateDir, crilog.Filename), logs)
+	}
+
+	if machine.Spec.Logging.Driver == machinev1alpha1.MachineLogDriverJSONFile {
+		maxSize, maxFiles := drivers.Rotation(ctx, machine)
+		if maxFiles < 1 {
+			maxFiles = 1
+		}
+
+		drivers.json.Start(ctx, string(machine.UID), filepath.Join(machine.Status.StateDir, crilog.JSONFilename), logs,
+			crilog.WithMaxSize(maxSize),
+			crilog.WithMaxFiles(maxFiles),
+		)
+	}
+}
+
+// startRotator periodically rotates the console log of the machine until it is
+// stopped, such that the log is bounded even if the state of the machine is
+// not retrieved in the meantime.
+func (drivers *Drivers) startRotator(ctx context.Context, machine *machinev1alpha1.Machine) {
+	if maxSize, _ := drivers.Rotation(ctx, machine); maxSize <= 0 {
+		return
+	}
+
+	drivers.mu.Lock()
+	defer drivers.mu.Unlock()
+
+	if _, ok := drivers.rotators[string(machine.UID)]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	drivers.rotators[string(machine.UID)] = cancel
+
+	// Rotate a copy of the machine, as the provided one may be changed.
+	rotate := *machine
+
+	go func() {
+		ticker := time.NewTicker(RotateInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				drivers.Rotate(ctx, &rotate)
+			}
+		}
+	}()
+}
+
+// Stop stops following the console output of the machine.
+func (drivers *Drivers) Stop(machine *machinev1alpha1.Machine) {
+	drivers.mu.Lock()
+	if cancel, ok := drivers.rotators[string(machine.UID)]; ok {
+		cancel()
+		delete(drivers.rotators, string(machine.UID))
+	}
+	drivers.mu.Unlock()
+
+	if drivers.CRI != nil {
+		drivers.CRI.Stop(string(machine.UID))
+	}
+
+	drivers.json.Stop(string(machine.UID))
+}
+
+// ConsoleLogFile returns the file which the console output of the machine is
+// written to by the VMM, which discards it if the machine has no log driver.
+func ConsoleLogFile(machine *machinev1alpha1.Machine) string {
+	if machine.Spec.Logging.Driver == machinev1alpha1.MachineLogDriverNone {
+		return os.DevNull
+	}
+
+	return filepath.Join(machine.Status.StateDir, ConsoleFilename)
+}
+
+// RemoveLogFile removes the console log of the machine along with the lock
+// file of its rotation, unless the console output was discarded.
+func RemoveLogFile(machine *machinev1alpha1.Machine) error {
+	if machine.Status.LogFile == "" || machine.Status.LogFile == os.DevNull {
+		return nil
+	}
+
+	return errors.Join(
+		os.RemoveAll(machine.Status.LogFile),
+		os.RemoveAll(machine.Status.LogFile+".lock"),
+	)
+}
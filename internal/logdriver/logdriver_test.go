@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package logdriver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/internal/crilog"
+)
+
+func TestConsoleLogFile(t *testing.T) {
+	machine := &machinev1alpha1.Machine{}
+	machine.Status.StateDir = "/run/kraftkit/machine"
+
+	for driver, expected := range map[machinev1alpha1.MachineLogDriver]string{
+		"":                                       "/run/kraftkit/machine/machine.log",
+		machinev1alpha1.MachineLogDriverRaw:      "/run/kraftkit/machine/machine.log",
+		machinev1alpha1.MachineLogDriverJSONFile: "/run/kraftkit/machine/machine.log",
+		machinev1alpha1.MachineLogDriverNone:     os.DevNull,
+	} {
+		machine.Spec.Logging.Driver = driver
+		if got := ConsoleLogFile(machine); got != expected {
+			t.Errorf("%q: expected %s, got %s", driver, expected, got)
+		}
+	}
+}
+
+func TestRotation(t *testing.T) {
+	drivers := NewDrivers()
+	drivers.MaxSize = 1024
+	drivers.MaxFiles = 3
+
+	machine := &machinev1alpha1.Machine{}
+
+	if size, files := drivers.Rotation(context.Background(), machine); size != 1024 || files != 3 {
+		t.Errorf("expected defaults of the drivers, got %d and %d", size, files)
+	}
+
+	machine.Spec.Logging.Options = map[string]string{
+		crilog.OptionMaxSize: "2KiB",
+	}
+
+	if size, files := drivers.Rotation(context.Background(), machine); size != 2048 || files != 3 {
+		t.Errorf("expected options of the machine, got %d and %d", size, files)
+	}
+
+	machine.Spec.Logging.Options = map[string]string{
+		"unknown": "1",
+	}
+
+	if size, files := drivers.Rotation(context.Background(), machine); size != 1024 || files != 3 {
+		t.Errorf("expected invalid options to be ignored, got %d and %d", size, files)
+	}
+}
+
+func TestStartJSONFile(t *testing.T) {
+	machine := &machinev1alpha1.Machine{}
+	machine.UID = "uid"
+	machine.Spec.Logging.Driver = machinev1alpha1.MachineLogDriverJSONFile
+	machine.Status.StateDir = t.TempDir()
+
+	lines := make(chan string, 2)
+	lines <- "hello"
+	lines <- "world"
+
+	drivers := NewDrivers()
+	drivers.Start(context.Background(), machine, func(context.Context) (chan string, chan error, error) {
+		return lines, make(chan error), nil
+	})
+
+	defer drivers.Stop(machine)
+
+	path := filepath.Join(machine.Status.StateDir, crilog.JSONFilename)
+
+	var records []string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		records = strings.Split(strings.TrimSpace(string(b)), "\n")
+		if len(records) == 2 {
+			break
+		}
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %v", records)
+	}
+
+	for i, expected := range []string{"hello", "world"} {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(records[i]), &record); err != nil {
+			t.Fatalf("could not decode record %q: %v", records[i], err)
+		}
+
+		if log, _ := record["log"].(string); !strings.HasPrefix(log, expected) {
+			t.Errorf("expected record of %q, got %v", expected, record)
+		}
+	}
+
+	// No CRI log is written unless enabled for the service.
+	if _, err := os.Stat(filepath.Join(machine.Status.StateDir, crilog.Filename)); !os.IsNotExist(err) {
+		t.Errorf("expected no CRI log, got %v", err)
+	}
+}
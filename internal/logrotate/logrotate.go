@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package logrotate bounds the size of log files which are written by
+// processes that cannot be told to re-open them, such as virtual machine
+// monitors, and reads back lines across the resulting set of rotated files.
+//
+// A log file at <path> is rotated by copying its contents to <path>.1, after
+// shifting any older <path>.N to <path>.N+1, and truncating it in place.  The
+// writer must therefore have opened the file for appending, as it would
+// otherwise continue writing at its previous offset.  Rotations are serialized
+// across processes by the lock file <path>.lock.
+package logrotate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"kraftkit.sh/internal/lockedfile"
+)
+
+// DefaultChunkSize is the number of bytes read at once when reading a file
+// backwards.
+const DefaultChunkSize = 32 * 1024
+
+// Rotate rotates the log file at the provided path if it exceeds the provided
+// maximum size, keeping at most the provided number of files including the
+// file itself.  A maximum size of 0 disables rotation.  Whilst the file is
+// rotated, the lock file next to it is held, such that concurrent callers, e.g.
+// multiple invocations of `kraft ps`, rotate it at most once.  Lines which
+// are written between copying the last of the file and truncating it are lost.
+// Whether the file was rotated is returned.
+func Rotate(path string, maxSize int64, maxFiles int) (bool, error) {
+	if maxSize <= 0 {
+		return false, nil
+	}
+
+	// Avoid taking the lock in the common case of a file below the maximum.
+	if rotate, err := exceeds(path, maxSize); err != nil || !rotate {
+		return false, err
+	}
+
+	unlock, err := lockedfile.MutexAt(path + ".lock").Lock()
+	if err != nil {
+		return false, fmt.Errorf("locking log file: %w", err)
+	}
+
+	defer unlock()
+
+	// The file may have been rotated whilst waiting for the lock.
+	if rotate, err := exceeds(path, maxSize); err != nil || !rotate {
+		return false, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	if maxFiles > 1 {
+		oldest := fmt.Sprintf("%s.%d", path, maxFiles-1)
+		if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+
+		for i := maxFiles - 2; i >= 1; i-- {
+			src := fmt.Sprintf("%s.%d", path, i)
+			dst := fmt.Sprintf("%s.%d", path, i+1)
+
+			if err := os.Rename(src, dst); err != nil && !os.IsNotExist(err) {
+				return false, err
+			}
+		}
+
+		if err := copyFile(path, path+".1", fi.Mode()); err != nil {
+			return false, fmt.Errorf("copying log file: %w", err)
+		}
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		return false, fmt.Errorf("truncating log file: %w", err)
+	}
+
+	return true, nil
+}
+
+// exceeds returns whether the file at the provided path exceeds the provided
+// size.
+func exceeds(path string, maxSize int64) (bool, error) {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return fi.Size() > maxSize, nil
+}
+
+// copyFile copies the contents of the file at src to a new file at dst.  The
+// source is copied until it no longer grows, such that lines which are
+// appended during the copy are not lost when it is truncated afterwards.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	for {
+		n, err := io.Copy(out, in)
+		if err != nil {
+			out.Close()
+			return err
+		}
+
+		if n == 0 {
+			break
+		}
+	}
+
+	return out.Close()
+}
+
+// Files returns the paths of the log file at the provided path and of its
+// rotated files which exist, ordered from the oldest to the newest, such that
+// reading them in order yields the log chronologically.
+func Files(path string) ([]string, error) {
+	var rotated []string
+
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		rotated = append(rotated, name)
+	}
+
+	files := make([]string, 0, len(rotated)+1)
+	for i := len(rotated) - 1; i >= 0; i-- {
+		files = append(files, rotated[i])
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// Tail returns the last n lines across the provided files, which are ordered
+// from the oldest to the newest as returned by Files.  Files are read
+// backwards from their end, such that only the requested lines are read
+// regardless of the size of the log.
+func Tail(files []string, n int) ([]string, error) {
+	var lines []string
+
+	for i := len(files) - 1; i >= 0 && len(lines) < n; i-- {
+		tail, err := tailFile(files[i], n-len(lines))
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(tail, lines...)
+	}
+
+	return lines, nil
+}
+
+// tailFile returns the last n lines of the file at the provided path.
+func tailFile(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		// The file may have been rotated away in the meantime.
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := fi.Size()
+	if offset == 0 {
+		return nil, nil
+	}
+
+	var buf []byte
+	newlines := 0
+
+	for offset > 0 && newlines < n {
+		start := offset - DefaultChunkSize
+		if start < 0 {
+			start = 0
+		}
+
+		chunk := make([]byte, offset-start)
+		if _, err := f.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		// The delimiter of the last line does not separate it from another.
+		if offset == fi.Size() {
+			chunk = bytes.TrimSuffix(chunk, []byte{'\n'})
+		}
+
+		newlines += bytes.Count(chunk, []byte{'\n'})
+		buf = append(chunk, buf...)
+		offset = start
+	}
+
+	lines := strings.Split(string(buf), "\n")
+
+	// Unless the file was read from its start, the first line is incomplete,
+	// which is guaranteed to be dropped here since at least n delimiters were
+	// read.
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package logrotate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "machine.log")
+
+	for i := 1; i <= 4; i++ {
+		appendFile(t, path, fmt.Sprintf("line %d\n", i))
+
+		rotated, err := Rotate(path, 4, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !rotated {
+			t.Fatalf("expected %s to have been rotated", path)
+		}
+	}
+
+	appendFile(t, path, "line 5\n")
+
+	files, err := Files(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{path + ".2", path + ".1", path}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+
+	var got []string
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, string(b))
+	}
+
+	if expected := []string{"line 3\n", "line 4\n", "line 5\n"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected contents %q, got %q", expected, got)
+	}
+}
+
+func TestRotateDisabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "machine.log")
+	appendFile(t, path, "line\n")
+
+	for _, maxSize := range []int64{0, 5} {
+		rotated, err := Rotate(path, maxSize, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rotated {
+			t.Errorf("max size %d: expected %s not to have been rotated", maxSize, path)
+		}
+	}
+}
+
+func TestTail(t *testing.T) {
+	dir := t.TempDir()
+	older := filepath.Join(dir, "machine.log.1")
+	newer := filepath.Join(dir, "machine.log")
+
+	appendFile(t, older, "a\nb\nc\n")
+	appendFile(t, newer, "d\r\ne")
+
+	for n, expected := range map[int][]string{
+		1: {"e"},
+		2: {"d", "e"},
+		4: {"b", "c", "d", "e"},
+		9: {"a", "b", "c", "d", "e"},
+	} {
+		got, err := Tail([]string{older, newer}, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("n=%d: expected %q, got %q", n, expected, got)
+		}
+	}
+}
+
+func TestTailChunks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "machine.log")
+
+	var lines []string
+	for i := 0; i < 3*DefaultChunkSize/10; i++ {
+		lines = append(lines, fmt.Sprintf("line %04d", i))
+	}
+
+	appendFile(t, path, strings.Join(lines, "\n")+"\n")
+
+	got, err := Tail([]string{path}, len(lines)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, lines[1:]) {
+		t.Errorf("expected the last %d lines, got %d lines starting with %q", len(lines)-1, len(got), got[0])
+	}
+}
+
+func TestRotateConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "machine.log")
+	content := strings.Repeat("line\n", 100)
+	appendFile(t, path, content)
+
+	const callers = 8
+
+	var wg sync.WaitGroup
+	rotations := make(chan bool, callers)
+	errs := make(chan error, callers)
+
+	// Each caller rotates the log as a separate invocation of `kraft ps` would.
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			rotated, err := Rotate(path, 16, 3)
+			rotations <- rotated
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(rotations)
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := 0
+	for rotated := range rotations {
+		if rotated {
+			count++
+		}
+	}
+
+	if count != 1 {
+		t.Errorf("expected the log to be rotated once, got %d", count)
+	}
+
+	if got, err := os.ReadFile(path + ".1"); err != nil {
+		t.Fatal(err)
+	} else if string(got) != content {
+		t.Errorf("expected the rotated log to hold the original content, got %d bytes", len(got))
+	}
+
+	if _, err := os.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Errorf("expected no further rotated log, got %v", err)
+	}
+}
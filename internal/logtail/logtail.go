@@ -64,6 +64,17 @@ func NewLogTail(ctx context.Context, logFile string) (chan string, chan error, e
 
 				switch event.Op {
 				case fsnotify.Write:
+					// The log file may have been truncated upon rotation, in which case
+					// continue reading from its start.
+					if truncated(f, reader) {
+						if _, err := f.Seek(0, io.SeekStart); err != nil {
+							errs <- err
+							return
+						}
+
+						reader.Reset(f)
+					}
+
 					peekAndRead(f, reader, &logs, &errs)
 				}
 			}
@@ -123,6 +134,22 @@ func peekAndRead(file *os.File, reader *bufio.Reader, logs *chan string, errs *c
 	return false
 }
 
+// truncated returns whether the file has become shorter than the position up
+// to which it has been read.
+func truncated(file *os.File, reader *bufio.Reader) bool {
+	pos, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false
+	}
+
+	fi, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Size() < pos-int64(reader.Buffered())
+}
+
 func nullPrefixLength(b []byte) int {
 	for i := range b {
 		if b[i] != '\x00' {
@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package metacache persists the responses of queries for metadata of remote
+// catalogs, e.g. manifest indexes or the repositories of an OCI registry, on
+// disk for a limited duration.  This spares repeated invocations of KraftKit
+// from re-querying remote catalogs for metadata which has not changed.
+package metacache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+)
+
+// Cache is a directory of cached responses which expire after a duration.  A
+// nil Cache is valid and disables caching.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	refresh bool
+}
+
+// New returns a cache in the provided directory whose entries expire after the
+// provided duration.  If refresh is set, cached entries are never used but are
+// still replaced by fresh responses.
+func New(dir string, ttl time.Duration, refresh bool) *Cache {
+	if dir == "" || ttl <= 0 {
+		return nil
+	}
+
+	return &Cache{
+		dir:     dir,
+		ttl:     ttl,
+		refresh: refresh,
+	}
+}
+
+// G returns the cache as configured in the provided context.
+func G(ctx context.Context) *Cache {
+	cfg := config.G[config.KraftKit](ctx)
+	if cfg.Catalog.CacheTTL == "" {
+		return nil
+	}
+
+	ttl, err := time.ParseDuration(cfg.Catalog.CacheTTL)
+	if err != nil {
+		log.G(ctx).Debugf("not caching catalog metadata: could not parse ttl: %v", err)
+		return nil
+	}
+
+	return New(filepath.Join(cfg.Paths.Cache, "catalog"), ttl, cfg.Catalog.NoCache)
+}
+
+// path returns the location of the entry with the provided key.
+func (cache *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(cache.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get returns the cached response for the key, or false if there is none or
+// it has expired.
+func (cache *Cache) Get(key string) ([]byte, bool) {
+	if cache == nil || cache.refresh {
+		return nil, false
+	}
+
+	path := cache.path(key)
+
+	fi, err := os.Stat(path)
+	if err != nil || time.Since(fi.ModTime()) > cache.ttl {
+		return nil, false
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	return raw, true
+}
+
+// Put saves the response for the key.  The entry is replaced atomically such
+// that concurrent readers never observe a partially written response.
+func (cache *Cache) Put(key string, raw []byte) error {
+	if cache == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(cache.dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(cache.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(raw); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), cache.path(key))
+}
+
+// Invalidate removes the cached response for the key, if any.
+func (cache *Cache) Invalidate(key string) error {
+	if cache == nil {
+		return nil
+	}
+
+	if err := os.Remove(cache.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Fetch returns the cached response for the key or, if there is none, calls
+// fetch and caches its response.  Failures to cache a response are not
+// errors since the response can always be fetched again.
+func (cache *Cache) Fetch(ctx context.Context, key string, fetch func() ([]byte, error)) ([]byte, error) {
+	if raw, ok := cache.Get(key); ok {
+		log.G(ctx).WithField("key", key).Trace("using cached catalog metadata")
+		return raw, nil
+	}
+
+	raw, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cache.Put(key, raw); err != nil {
+		log.G(ctx).WithField("key", key).Debugf("could not cache catalog metadata: %v", err)
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package metacache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	cache := New(t.TempDir(), time.Minute, false)
+
+	calls := 0
+	fetch := func() ([]byte, error) {
+		calls++
+		return []byte("index"), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		raw, err := cache.Fetch(ctx, "key", fetch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(raw) != "index" {
+			t.Fatalf("expected 'index' but got '%s'", raw)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected 1 fetch but got %d", calls)
+	}
+
+	// Expire the entry.
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(cache.path("key"), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cache.Fetch(ctx, "key", fetch); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected expired entry to be fetched again but got %d fetches", calls)
+	}
+
+	// Refreshing never uses cached entries.
+	refresh := New(cache.dir, time.Minute, true)
+	if _, err := refresh.Fetch(ctx, "key", fetch); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected refresh to fetch again but got %d fetches", calls)
+	}
+}
+
+func TestFetchDisabled(t *testing.T) {
+	var cache *Cache
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Fetch(context.Background(), "key", func() ([]byte, error) {
+			calls++
+			return nil, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected disabled cache to always fetch but got %d fetches", calls)
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"kraftkit.sh/log"
+)
+
+// execScanner adapts a scanner which is run as an executable that prints its
+// results as JSON.
+type execScanner struct {
+	name  string
+	bin   string
+	args  func(dir string) []string
+	parse func([]byte) ([]Vulnerability, error)
+}
+
+// String implements fmt.Stringer
+func (s *execScanner) String() string {
+	return s.name
+}
+
+// Available implements Scanner
+func (s *execScanner) Available() bool {
+	_, err := exec.LookPath(s.bin)
+	return err == nil
+}
+
+// Scan implements Scanner
+func (s *execScanner) Scan(ctx context.Context, dir string) (*Report, error) {
+	path, err := exec.LookPath(s.bin)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.name, ErrNotInstalled)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, path, s.args(dir)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.G(ctx).
+		WithField("scanner", s.name).
+		WithField("args", cmd.Args[1:]).
+		Debug("scanning")
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("running %s: %w: %s", s.name, err, msg)
+		}
+
+		return nil, fmt.Errorf("running %s: %w", s.name, err)
+	}
+
+	vulns, err := s.parse(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("parsing results of %s: %w", s.name, err)
+	}
+
+	report := &Report{
+		Scanner:         s.name,
+		Vulnerabilities: vulns,
+	}
+
+	report.sort()
+
+	return report, nil
+}
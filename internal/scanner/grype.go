@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// grypeOutput is the subset of the JSON output of grype which is used.
+type grypeOutput struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID          string `json:"id"`
+			Severity    string `json:"severity"`
+			DataSource  string `json:"dataSource"`
+			Description string `json:"description"`
+			Fix         struct {
+				Versions []string `json:"versions"`
+			} `json:"fix"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+// NewGrype returns a scanner which runs grype.
+//
+// See: https://github.com/anchore/grype
+func NewGrype() Scanner {
+	return &execScanner{
+		name: "grype",
+		bin:  "grype",
+		args: func(dir string) []string {
+			return []string{"dir:" + dir, "--output", "json", "--quiet"}
+		},
+		parse: parseGrype,
+	}
+}
+
+// parseGrype normalises the JSON output of grype.
+func parseGrype(b []byte) ([]Vulnerability, error) {
+	var out grypeOutput
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+
+	vulns := make([]Vulnerability, 0, len(out.Matches))
+
+	for _, match := range out.Matches {
+		// Unlike trivy, grype has no separate title and its descriptions may
+		// span several lines.
+		title, _, _ := strings.Cut(strings.TrimSpace(match.Vulnerability.Description), "\n")
+
+		vulns = append(vulns, Vulnerability{
+			ID:       match.Vulnerability.ID,
+			Package:  match.Artifact.Name,
+			Version:  match.Artifact.Version,
+			FixedIn:  strings.Join(match.Vulnerability.Fix.Versions, ", "),
+			Severity: ParseSeverity(match.Vulnerability.Severity),
+			Title:    title,
+			URL:      match.Vulnerability.DataSource,
+		})
+	}
+
+	return vulns, nil
+}
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package scanner scans root file systems for known vulnerabilities with
+// external scanners, e.g. grype or trivy, whose results are normalised into
+// a common report.
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNotInstalled is returned when the executable of a scanner cannot be found.
+var ErrNotInstalled = errors.New("scanner is not installed")
+
+// Severity is the normalised severity of a vulnerability.
+type Severity string
+
+const (
+	SeverityUnknown    = Severity("unknown")
+	SeverityNegligible = Severity("negligible")
+	SeverityLow        = Severity("low")
+	SeverityMedium     = Severity("medium")
+	SeverityHigh       = Severity("high")
+	SeverityCritical   = Severity("critical")
+)
+
+// Severities returns the known severities from the least to the most severe.
+func Severities() []Severity {
+	return []Severity{
+		SeverityUnknown,
+		SeverityNegligible,
+		SeverityLow,
+		SeverityMedium,
+		SeverityHigh,
+		SeverityCritical,
+	}
+}
+
+// String implements fmt.Stringer
+func (severity Severity) String() string {
+	return string(severity)
+}
+
+// rank returns the position of the severity from the least to the most severe.
+func (severity Severity) rank() int {
+	for i, s := range Severities() {
+		if s == severity {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// AtLeast returns whether the severity is at least as severe as the other.
+func (severity Severity) AtLeast(other Severity) bool {
+	return severity.rank() >= other.rank()
+}
+
+// ParseSeverity normalises the severity reported by a scanner, returning
+// SeverityUnknown for severities it does not know.
+func ParseSeverity(s string) Severity {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "negligible", "none", "info", "informational":
+		return SeverityNegligible
+	case "low":
+		return SeverityLow
+	case "medium", "moderate":
+		return SeverityMedium
+	case "high", "important":
+		return SeverityHigh
+	case "critical":
+		return SeverityCritical
+	}
+
+	return SeverityUnknown
+}
+
+// Vulnerability is a vulnerability of a package of the root file system.
+type Vulnerability struct {
+	// ID is the identifier of the vulnerability, e.g. CVE-2024-1234.
+	ID string `json:"id"`
+
+	// Package is the name of the affected package.
+	Package string `json:"package"`
+
+	// Version is the installed version of the affected package.
+	Version string `json:"version"`
+
+	// FixedIn is the version of the package which fixes the vulnerability, if
+	// any.
+	FixedIn string `json:"fixedIn,omitempty"`
+
+	// Severity is the normalised severity of the vulnerability.
+	Severity Severity `json:"severity"`
+
+	// Title is a short description of the vulnerability, if any.
+	Title string `json:"title,omitempty"`
+
+	// URL is a reference to the details of the vulnerability, if any.
+	URL string `json:"url,omitempty"`
+}
+
+// Report is the result of a scan.
+type Report struct {
+	// Scanner is the name of the scanner which produced the report.
+	Scanner string `json:"scanner"`
+
+	// Vulnerabilities are the vulnerabilities which were found, from the most
+	// to the least severe.
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// AtLeast returns the vulnerabilities of the report which are at least as
+// severe as the threshold.
+func (report *Report) AtLeast(threshold Severity) []Vulnerability {
+	var found []Vulnerability
+
+	for _, vuln := range report.Vulnerabilities {
+		if vuln.Severity.AtLeast(threshold) {
+			found = append(found, vuln)
+		}
+	}
+
+	return found
+}
+
+// sort orders the vulnerabilities from the most to the least severe, and then
+// by package and identifier, such that reports are stable.
+func (report *Report) sort() {
+	sort.SliceStable(report.Vulnerabilities, func(i, j int) bool {
+		a, b := report.Vulnerabilities[i], report.Vulnerabilities[j]
+		if a.Severity != b.Severity {
+			return a.Severity.rank() > b.Severity.rank()
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+
+		return a.ID < b.ID
+	})
+}
+
+// Scanner scans a root file system for known vulnerabilities.
+type Scanner interface {
+	fmt.Stringer
+
+	// Available returns whether the scanner can be used on this host.
+	Available() bool
+
+	// Scan scans the root file system extracted at the provided directory.
+	Scan(ctx context.Context, dir string) (*Report, error)
+}
+
+// Scanners returns the supported scanners by name, in order of preference.
+func Scanners() []Scanner {
+	return []Scanner{
+		NewGrype(),
+		NewTrivy(),
+	}
+}
+
+// ScannerNames returns the names of the supported scanners.
+func ScannerNames() []string {
+	var names []string
+	for _, s := range Scanners() {
+		names = append(names, s.String())
+	}
+
+	return names
+}
+
+// New returns the scanner of the provided name, or the first which is
+// available if the name is "auto".
+func New(name string) (Scanner, error) {
+	for _, s := range Scanners() {
+		if name == "auto" && s.Available() {
+			return s, nil
+		} else if s.String() == name {
+			if !s.Available() {
+				return nil, fmt.Errorf("%s: %w", name, ErrNotInstalled)
+			}
+
+			return s, nil
+		}
+	}
+
+	if name == "auto" {
+		return nil, fmt.Errorf("none of %v: %w", ScannerNames(), ErrNotInstalled)
+	}
+
+	return nil, fmt.Errorf("unsupported scanner: %s (choice of %v)", name, ScannerNames())
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := map[string]Severity{
+		"Critical":   SeverityCritical,
+		"HIGH":       SeverityHigh,
+		"moderate":   SeverityMedium,
+		"Low":        SeverityLow,
+		"Negligible": SeverityNegligible,
+		"UNKNOWN":    SeverityUnknown,
+		"":           SeverityUnknown,
+	}
+
+	for in, want := range tests {
+		if got := ParseSeverity(in); got != want {
+			t.Errorf("ParseSeverity(%q) = %s, want %s", in, got, want)
+		}
+	}
+
+	if !SeverityCritical.AtLeast(SeverityHigh) || SeverityLow.AtLeast(SeverityMedium) || !SeverityMedium.AtLeast(SeverityMedium) {
+		t.Errorf("severities are not ordered")
+	}
+}
+
+func TestParseGrype(t *testing.T) {
+	out := []byte(`{
+		"matches": [
+			{
+				"vulnerability": {
+					"id": "CVE-2023-0001",
+					"severity": "Medium",
+					"dataSource": "https://nvd.nist.gov/vuln/detail/CVE-2023-0001",
+					"description": "Out-of-bounds read.\nMore details.",
+					"fix": {"versions": ["1.2.4", "1.3.1"], "state": "fixed"}
+				},
+				"artifact": {"name": "libfoo", "version": "1.2.3", "type": "apk"}
+			},
+			{
+				"vulnerability": {
+					"id": "GHSA-xxxx",
+					"severity": "Critical",
+					"fix": {"versions": [], "state": "not-fixed"}
+				},
+				"artifact": {"name": "bar", "version": "0.1.0"}
+			}
+		],
+		"source": {"type": "directory"}
+	}`)
+
+	got, err := parseGrype(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Vulnerability{
+		{
+			ID:       "CVE-2023-0001",
+			Package:  "libfoo",
+			Version:  "1.2.3",
+			FixedIn:  "1.2.4, 1.3.1",
+			Severity: SeverityMedium,
+			Title:    "Out-of-bounds read.",
+			URL:      "https://nvd.nist.gov/vuln/detail/CVE-2023-0001",
+		},
+		{
+			ID:       "GHSA-xxxx",
+			Package:  "bar",
+			Version:  "0.1.0",
+			Severity: SeverityCritical,
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGrype() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseTrivy(t *testing.T) {
+	out := []byte(`{
+		"SchemaVersion": 2,
+		"Results": [
+			{"Target": "rootfs (alpine 3.18)", "Class": "os-pkgs"},
+			{
+				"Target": "usr/lib/node_modules/app/package-lock.json",
+				"Vulnerabilities": [
+					{
+						"VulnerabilityID": "CVE-2024-0002",
+						"PkgName": "lodash",
+						"InstalledVersion": "4.17.20",
+						"FixedVersion": "4.17.21",
+						"Severity": "HIGH",
+						"Title": "Prototype pollution",
+						"PrimaryURL": "https://avd.aquasec.com/nvd/cve-2024-0002"
+					}
+				]
+			}
+		]
+	}`)
+
+	got, err := parseTrivy(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Vulnerability{
+		{
+			ID:       "CVE-2024-0002",
+			Package:  "lodash",
+			Version:  "4.17.20",
+			FixedIn:  "4.17.21",
+			Severity: SeverityHigh,
+			Title:    "Prototype pollution",
+			URL:      "https://avd.aquasec.com/nvd/cve-2024-0002",
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTrivy() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReportAtLeast(t *testing.T) {
+	report := &Report{
+		Vulnerabilities: []Vulnerability{
+			{ID: "a", Severity: SeverityLow},
+			{ID: "b", Severity: SeverityCritical},
+			{ID: "c", Severity: SeverityHigh},
+			{ID: "d", Severity: SeverityUnknown},
+		},
+	}
+
+	report.sort()
+
+	var ids []string
+	for _, vuln := range report.AtLeast(SeverityHigh) {
+		ids = append(ids, vuln.ID)
+	}
+
+	if want := []string{"b", "c"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("AtLeast(high) = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package scanner
+
+import (
+	"encoding/json"
+)
+
+// trivyOutput is the subset of the JSON output of trivy which is used.
+type trivyOutput struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+			PrimaryURL       string `json:"PrimaryURL"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// NewTrivy returns a scanner which runs trivy.
+//
+// See: https://github.com/aquasecurity/trivy
+func NewTrivy() Scanner {
+	return &execScanner{
+		name: "trivy",
+		bin:  "trivy",
+		args: func(dir string) []string {
+			return []string{"rootfs", "--format", "json", "--quiet", dir}
+		},
+		parse: parseTrivy,
+	}
+}
+
+// parseTrivy normalises the JSON output of trivy.
+func parseTrivy(b []byte) ([]Vulnerability, error) {
+	var out trivyOutput
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+
+	var vulns []Vulnerability
+
+	for _, result := range out.Results {
+		for _, vuln := range result.Vulnerabilities {
+			vulns = append(vulns, Vulnerability{
+				ID:       vuln.VulnerabilityID,
+				Package:  vuln.PkgName,
+				Version:  vuln.InstalledVersion,
+				FixedIn:  vuln.FixedVersion,
+				Severity: ParseSeverity(vuln.Severity),
+				Title:    vuln.Title,
+				URL:      vuln.PrimaryURL,
+			})
+		}
+	}
+
+	return vulns, nil
+}
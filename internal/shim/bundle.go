@@ -0,0 +1,200 @@
+//go:build linux
+// +build linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package shim
+
+import (
+	"context"
+	"debug/elf"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/api/types"
+	"github.com/containerd/containerd/mount"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	rtspec "github.com/opencontainers/runtime-spec/specs-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+)
+
+const (
+	specAnnotCRIContainerType = "io.kubernetes.cri.container-type"
+
+	kernelRelPath = "unikraft/bin/kernel"
+	initrdRelPath = "unikraft/bin/initrd"
+)
+
+// loadSpec loads the OCI runtime specification of the bundle.
+func loadSpec(bundle string) (*rtspec.Spec, error) {
+	f, err := os.Open(filepath.Join(bundle, oci.ConfigFilename))
+	if err != nil {
+		return nil, fmt.Errorf("opening spec file: %w", err)
+	}
+	defer f.Close()
+
+	spec := &rtspec.Spec{}
+	if err = json.NewDecoder(f).Decode(spec); err != nil {
+		return nil, fmt.Errorf("decoding JSON spec: %w", err)
+	}
+
+	return spec, nil
+}
+
+// isCRISandbox returns whether the spec describes a CRI sandbox (Kubernetes
+// "pause" container).  Sandboxes carry no unikernel and are emulated by the
+// shim itself.
+func isCRISandbox(spec *rtspec.Spec) bool {
+	return spec.Annotations[specAnnotCRIContainerType] == "sandbox"
+}
+
+// rootfsPath returns the absolute path of the root filesystem of the bundle.
+func rootfsPath(bundle string, spec *rtspec.Spec) string {
+	if spec.Root == nil || spec.Root.Path == "" {
+		return filepath.Join(bundle, "rootfs")
+	}
+
+	if filepath.IsAbs(spec.Root.Path) {
+		return spec.Root.Path
+	}
+
+	return filepath.Join(bundle, spec.Root.Path)
+}
+
+// mountRootfs mounts the provided mounts at the root filesystem of the
+// bundle.
+func mountRootfs(rootfs string, mounts []*types.Mount) error {
+	if len(mounts) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(rootfs, 0o711); err != nil {
+		return err
+	}
+
+	mnts := make([]mount.Mount, len(mounts))
+	for i, m := range mounts {
+		mnts[i] = mount.Mount{
+			Type:    m.Type,
+			Source:  m.Source,
+			Options: m.Options,
+		}
+	}
+
+	return mount.All(mnts, rootfs)
+}
+
+// unmountRootfs unmounts the root filesystem of the bundle, if any.
+func unmountRootfs(ctx context.Context, bundle string) {
+	if err := mount.UnmountAll(filepath.Join(bundle, "rootfs"), 0); err != nil {
+		log.G(ctx).Debugf("could not unmount rootfs: %v", err)
+	}
+}
+
+// newMachine returns a new machine which boots the kernel, and optionally the
+// initrd, found in the root filesystem of the bundle.
+func newMachine(id, rootfs string, spec *rtspec.Spec) (*machineapi.Machine, error) {
+	kernel, err := fileAbsPath(rootfs, kernelRelPath)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path of kernel: %w", err)
+	}
+
+	initrd, err := fileAbsPath(rootfs, initrdRelPath)
+	if pErr := (*os.PathError)(nil); errors.As(err, &pErr) && os.IsNotExist(pErr) {
+		initrd, err = "", nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path of initrd: %w", err)
+	}
+
+	arch, err := kernelArchitecture(kernel)
+	if err != nil {
+		return nil, fmt.Errorf("getting kernel architecture: %w", err)
+	}
+
+	machine := &machineapi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: id,
+		},
+		Spec: machineapi.MachineSpec{
+			Platform:     plat.String(),
+			Architecture: arch,
+		},
+		Status: machineapi.MachineStatus{
+			KernelPath: kernel,
+			InitrdPath: initrd,
+		},
+	}
+
+	// The first argument of the process is the entrypoint of the image, which
+	// is represented by the kernel itself.
+	if spec.Process != nil && len(spec.Process.Args) > 1 {
+		machine.Spec.ApplicationArgs = spec.Process.Args[1:]
+	}
+
+	if spec.Process != nil && len(spec.Process.Env) > 0 {
+		machine.Spec.Env = make(map[string]string, len(spec.Process.Env))
+		for _, env := range spec.Process.Env {
+			k, v, _ := strings.Cut(env, "=")
+			machine.Spec.Env[k] = v
+		}
+	}
+
+	return machine, nil
+}
+
+// fileAbsPath returns the absolute path of the regular file at the provided
+// path relative to the root filesystem.
+func fileAbsPath(rootfs, relPath string) (string, error) {
+	path, err := securejoin.SecureJoin(rootfs, relPath)
+	if err != nil {
+		return "", fmt.Errorf("joining path components: %w", err)
+	}
+
+	if path, err = filepath.Abs(path); err != nil {
+		return "", err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	if !fi.Mode().IsRegular() {
+		return "", fmt.Errorf("file is not regular: %s", path)
+	}
+
+	return path, nil
+}
+
+// kernelArchitecture returns the architecture of the provided kernel.
+func kernelArchitecture(path string) (string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening ELF file: %w", err)
+	}
+	defer f.Close()
+
+	switch f.Machine {
+	case elf.EM_X86_64, elf.EM_386:
+		return "x86_64", nil
+	case elf.EM_ARM:
+		return "arm", nil
+	case elf.EM_AARCH64:
+		return "arm64", nil
+	case elf.EM_RISCV:
+		return "riscv64", nil
+	}
+
+	return "", fmt.Errorf("unsupported kernel architecture: %s", f.Machine)
+}
@@ -0,0 +1,308 @@
+//go:build linux
+// +build linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package shim
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rtspec "github.com/opencontainers/runtime-spec/specs-go"
+
+	"kraftkit.sh/oci"
+)
+
+// writeKernel writes a minimal ELF file for the provided machine at the path
+// relative to the root filesystem.
+func writeKernel(t *testing.T, rootfs, relPath string, machine elf.Machine) string {
+	t.Helper()
+
+	hdr := elf.Header64{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(machine),
+		Version:   uint32(elf.EV_CURRENT),
+		Ehsize:    uint16(binary.Size(elf.Header64{})),
+		Phentsize: uint16(binary.Size(elf.Prog64{})),
+		Shentsize: uint16(binary.Size(elf.Section64{})),
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, hdr); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(rootfs, relPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadSpec(t *testing.T) {
+	bundle := t.TempDir()
+
+	if _, err := loadSpec(bundle); err == nil {
+		t.Error("expected error for missing spec")
+	}
+
+	if err := os.WriteFile(filepath.Join(bundle, oci.ConfigFilename), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadSpec(bundle); err == nil {
+		t.Error("expected error for malformed spec")
+	}
+
+	if err := os.WriteFile(filepath.Join(bundle, oci.ConfigFilename), []byte(`{
+		"root": {"path": "rootfs"},
+		"annotations": {"io.kubernetes.cri.container-type": "sandbox"}
+	}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := loadSpec(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isCRISandbox(spec) {
+		t.Error("expected spec to describe a CRI sandbox")
+	}
+
+	if spec.Root == nil || spec.Root.Path != "rootfs" {
+		t.Errorf("expected root path 'rootfs', got %+v", spec.Root)
+	}
+}
+
+func TestRootfsPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     *rtspec.Root
+		expected string
+	}{
+		{
+			name:     "no root",
+			expected: "/run/bundle/rootfs",
+		},
+		{
+			name:     "empty path",
+			root:     &rtspec.Root{},
+			expected: "/run/bundle/rootfs",
+		},
+		{
+			name:     "relative path",
+			root:     &rtspec.Root{Path: "fs"},
+			expected: "/run/bundle/fs",
+		},
+		{
+			name:     "absolute path",
+			root:     &rtspec.Root{Path: "/var/lib/rootfs"},
+			expected: "/var/lib/rootfs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rootfsPath("/run/bundle", &rtspec.Spec{Root: tt.root}); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFileAbsPath(t *testing.T) {
+	dir := t.TempDir()
+	rootfs := filepath.Join(dir, "rootfs")
+	kernel := writeKernel(t, rootfs, kernelRelPath, elf.EM_X86_64)
+
+	// A file outside of the root filesystem which must not be resolved.
+	if err := os.WriteFile(filepath.Join(dir, "secret"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("/secret", filepath.Join(rootfs, "unikraft", "abs")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("../../secret", filepath.Join(rootfs, "unikraft", "rel")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("bin/kernel", filepath.Join(rootfs, "unikraft", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		relPath  string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "regular file",
+			relPath:  kernelRelPath,
+			expected: kernel,
+		},
+		{
+			name:     "symlink within rootfs",
+			relPath:  "unikraft/link",
+			expected: kernel,
+		},
+		{
+			name:    "dot-dot escape",
+			relPath: "../secret",
+			err:     true,
+		},
+		{
+			name:    "absolute symlink escape",
+			relPath: "unikraft/abs",
+			err:     true,
+		},
+		{
+			name:    "relative symlink escape",
+			relPath: "unikraft/rel",
+			err:     true,
+		},
+		{
+			name:    "directory",
+			relPath: "unikraft/bin",
+			err:     true,
+		},
+		{
+			name:    "missing file",
+			relPath: initrdRelPath,
+			err:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fileAbsPath(rootfs, tt.relPath)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestKernelArchitecture(t *testing.T) {
+	tests := []struct {
+		machine  elf.Machine
+		expected string
+		err      bool
+	}{
+		{machine: elf.EM_X86_64, expected: "x86_64"},
+		{machine: elf.EM_AARCH64, expected: "arm64"},
+		{machine: elf.EM_RISCV, expected: "riscv64"},
+		{machine: elf.EM_MIPS, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.machine.String(), func(t *testing.T) {
+			kernel := writeKernel(t, t.TempDir(), "kernel", tt.machine)
+
+			arch, err := kernelArchitecture(kernel)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected error, got %q", arch)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if arch != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, arch)
+			}
+		})
+	}
+}
+
+func TestNewMachine(t *testing.T) {
+	rootfs := t.TempDir()
+	kernel := writeKernel(t, rootfs, kernelRelPath, elf.EM_AARCH64)
+
+	spec := &rtspec.Spec{
+		Process: &rtspec.Process{
+			Args: []string{"/unikraft/bin/kernel", "-v", "serve"},
+			Env:  []string{"PATH=/bin", "EMPTY=", "KV=a=b"},
+		},
+	}
+
+	machine, err := newMachine("test", rootfs, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Status.KernelPath != kernel {
+		t.Errorf("expected kernel %q, got %q", kernel, machine.Status.KernelPath)
+	}
+
+	if machine.Status.InitrdPath != "" {
+		t.Errorf("expected no initrd, got %q", machine.Status.InitrdPath)
+	}
+
+	if machine.Spec.Architecture != "arm64" {
+		t.Errorf("expected architecture arm64, got %q", machine.Spec.Architecture)
+	}
+
+	if args := strings.Join(machine.Spec.ApplicationArgs, " "); args != "-v serve" {
+		t.Errorf("expected arguments '-v serve', got %q", args)
+	}
+
+	if machine.Spec.Env["PATH"] != "/bin" || machine.Spec.Env["KV"] != "a=b" {
+		t.Errorf("unexpected environment: %v", machine.Spec.Env)
+	}
+
+	if v, ok := machine.Spec.Env["EMPTY"]; !ok || v != "" {
+		t.Errorf("expected empty variable, got %v", machine.Spec.Env)
+	}
+
+	initrd := filepath.Join(rootfs, initrdRelPath)
+	if err := os.WriteFile(initrd, []byte("initrd"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if machine, err = newMachine("test", rootfs, spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Status.InitrdPath != initrd {
+		t.Errorf("expected initrd %q, got %q", initrd, machine.Status.InitrdPath)
+	}
+
+	if _, err := newMachine("test", t.TempDir(), spec); err == nil {
+		t.Error("expected error for bundle without kernel")
+	}
+}
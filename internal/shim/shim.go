@@ -0,0 +1,259 @@
+//go:build linux
+// +build linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package shim implements a containerd runtime v2 shim which translates the
+// lifecycle of an OCI task into operations of KraftKit's machine driver such
+// that containerd, and by extension Kubernetes via a RuntimeClass, is able to
+// schedule unikernels.
+package shim
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	taskapi "github.com/containerd/containerd/api/runtime/task/v2"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/protobuf"
+	containerdshim "github.com/containerd/containerd/runtime/v2/shim"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+// RuntimeName is the name of the runtime which must be referenced by
+// containerd's configuration in order to select this shim.  The binary of the
+// shim must be named containerd-shim-kraftkit-v2 and be present in the PATH of
+// containerd.
+const RuntimeName = "io.containerd.kraftkit.v2"
+
+// plat is the platform of all machines which are created by the shim.
+//
+// TODO: The runtime spec does not provide any hint about the desired target
+// platform, which is why only QEMU is currently supported.
+const plat = mplatform.PlatformQEMU
+
+// service implements containerd's TaskService by managing one machine per
+// task.
+type service struct {
+	mu        sync.Mutex
+	id        string
+	cfgm      *config.ConfigManager[config.KraftKit]
+	publisher containerdshim.Publisher
+	shutdown  func()
+	machines  machineapi.MachineService
+	tasks     map[string]*task
+}
+
+var _ containerdshim.Shim = (*service)(nil)
+
+// New instantiates the shim's task service.  It satisfies containerd's
+// shim.Init signature.
+func New(ctx context.Context, id string, publisher containerdshim.Publisher, shutdown func()) (containerdshim.Shim, error) {
+	cfgm, err := newConfigManager()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = config.WithConfigManager(ctx, cfgm)
+	ctx = log.WithLogger(ctx, log.L)
+
+	strategy, ok := mplatform.Strategies()[plat]
+	if !ok {
+		return nil, fmt.Errorf("unsupported platform driver: %s", plat)
+	}
+
+	machines, err := strategy.NewMachineV1alpha1(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("instantiating machine service: %w", err)
+	}
+
+	return &service{
+		id:        id,
+		cfgm:      cfgm,
+		publisher: publisher,
+		shutdown:  shutdown,
+		machines:  machines,
+		tasks:     map[string]*task{},
+	}, nil
+}
+
+// newConfigManager returns a configuration manager for KraftKit's
+// configuration file.  The shim is not invoked via the CLI, so its defaults
+// are otherwise not populated.
+func newConfigManager() (*config.ConfigManager[config.KraftKit], error) {
+	cfg, err := config.NewDefaultKraftKitConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.NewConfigManager(
+		cfg,
+		config.WithFile[config.KraftKit](config.DefaultConfigFile(), false),
+	)
+}
+
+// withKraftKit embeds KraftKit's configuration and logger into the context of
+// a request such that the machine driver can be used.
+func (s *service) withKraftKit(ctx context.Context) context.Context {
+	ctx = config.WithConfigManager(ctx, s.cfgm)
+	ctx = log.WithLogger(ctx, log.L)
+
+	return ctx
+}
+
+// StartShim implements shim.Shim by re-executing the shim binary as a daemon
+// which serves the task API on a socket whose address is returned to
+// containerd.
+func (s *service) StartShim(ctx context.Context, opts containerdshim.StartOpts) (_ string, retErr error) {
+	cmd, err := containerdshim.Command(ctx, &containerdshim.CommandConfig{
+		Runtime:      os.Args[0],
+		Address:      opts.Address,
+		TTRPCAddress: opts.TTRPCAddress,
+		Args:         []string{"-id", opts.ID},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	cmd.Dir = cwd
+
+	address, err := containerdshim.SocketAddress(ctx, opts.Address, opts.ID)
+	if err != nil {
+		return "", err
+	}
+
+	socket, err := containerdshim.NewSocket(address)
+	if err != nil {
+		if !containerdshim.SocketEaddrinuse(err) {
+			return "", fmt.Errorf("creating shim socket: %w", err)
+		}
+
+		// A shim is already serving this task.
+		if containerdshim.CanConnect(address) {
+			if err := containerdshim.WriteAddress("address", address); err != nil {
+				return "", fmt.Errorf("writing existing shim address: %w", err)
+			}
+
+			return address, nil
+		}
+
+		if err := containerdshim.RemoveSocket(address); err != nil {
+			return "", fmt.Errorf("removing stale shim socket: %w", err)
+		}
+
+		if socket, err = containerdshim.NewSocket(address); err != nil {
+			return "", fmt.Errorf("creating shim socket: %w", err)
+		}
+	}
+
+	defer func() {
+		if retErr != nil {
+			socket.Close()
+			_ = containerdshim.RemoveSocket(address)
+		}
+	}()
+
+	if err := containerdshim.WriteAddress("address", address); err != nil {
+		return "", err
+	}
+
+	f, err := socket.File()
+	if err != nil {
+		return "", err
+	}
+
+	cmd.ExtraFiles = append(cmd.ExtraFiles, f)
+
+	if err := cmd.Start(); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	defer func() {
+		if retErr != nil {
+			_ = cmd.Process.Kill()
+		}
+	}()
+
+	// Reap the daemon once it exits.
+	go func() { _ = cmd.Wait() }()
+
+	if err := containerdshim.WritePidFile("shim.pid", cmd.Process.Pid); err != nil {
+		return "", err
+	}
+
+	if err := containerdshim.AdjustOOMScore(cmd.Process.Pid); err != nil {
+		return "", fmt.Errorf("adjusting OOM score of shim: %w", err)
+	}
+
+	return address, nil
+}
+
+// Cleanup implements shim.Shim.  It is invoked by containerd in a separate
+// process after the shim daemon has gone away and removes any machine which
+// may have been left behind by the task.
+func (s *service) Cleanup(ctx context.Context) (*taskapi.DeleteResponse, error) {
+	ctx = s.withKraftKit(ctx)
+
+	log.G(ctx).Debugf("cleaning up task %s", s.id)
+
+	machine, err := s.lookupMachine(ctx, s.id)
+	if err != nil && !errdefs.IsNotFound(err) {
+		return nil, err
+	}
+
+	resp := &taskapi.DeleteResponse{
+		ExitStatus: exitStatusKilled,
+		ExitedAt:   protobuf.ToTimestamp(time.Now()),
+	}
+
+	if machine != nil {
+		resp.Pid = uint32(machine.Status.Pid)
+
+		if _, err := s.machines.Stop(ctx, machine); err != nil {
+			log.G(ctx).Debugf("could not stop machine: %v", err)
+		}
+
+		if _, err := s.machines.Delete(ctx, machine); err != nil {
+			return nil, fmt.Errorf("deleting machine: %w", err)
+		}
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		unmountRootfs(ctx, cwd)
+	}
+
+	return resp, nil
+}
+
+// lookupMachine returns the machine which backs the task with the provided
+// ID.
+func (s *service) lookupMachine(ctx context.Context, id string) (*machineapi.Machine, error) {
+	machines, err := s.machines.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, machine := range machines.Items {
+		if machine.Name == id {
+			return &machine, nil
+		}
+	}
+
+	return nil, fmt.Errorf("machine %s: %w", id, errdefs.ErrNotFound)
+}
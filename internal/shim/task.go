@@ -0,0 +1,532 @@
+//go:build linux
+// +build linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package shim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	eventstypes "github.com/containerd/containerd/api/events"
+	taskapi "github.com/containerd/containerd/api/runtime/task/v2"
+	tasktypes "github.com/containerd/containerd/api/types/task"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/protobuf"
+	ptypes "github.com/containerd/containerd/protobuf/types"
+	"github.com/containerd/containerd/runtime"
+	"github.com/containerd/fifo"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// exitStatusKilled is the exit status reported for tasks which were stopped
+// forcefully.
+const exitStatusKilled = 128 + uint32(syscall.SIGKILL)
+
+// pollInterval is the interval at which the state of a running machine is
+// queried in order to detect its exit.
+const pollInterval = 500 * time.Millisecond
+
+// task is the state of a single task managed by the shim.  The machine is nil
+// for CRI sandboxes.
+type task struct {
+	id      string
+	bundle  string
+	rootfs  string
+	stdout  string
+	machine *machineapi.Machine
+	status  tasktypes.Status
+
+	exitOnce   sync.Once
+	exited     chan struct{}
+	exitStatus uint32
+	exitedAt   time.Time
+}
+
+// pid returns the pid which represents the task, which is the pid of the VMM
+// process or, for sandboxes, that of the shim itself.
+func (t *task) pid() uint32 {
+	if t.machine == nil {
+		return uint32(os.Getpid())
+	}
+
+	return uint32(t.machine.Status.Pid)
+}
+
+// getTask returns the task with the provided ID.
+func (s *service) getTask(id string) (*task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[id]
+	if !ok {
+		return nil, errdefs.ToGRPCf(errdefs.ErrNotFound, "task %s", id)
+	}
+
+	return t, nil
+}
+
+// publish forwards the event to containerd.
+func (s *service) publish(ctx context.Context, topic string, event any) {
+	if err := s.publisher.Publish(ctx, topic, event); err != nil {
+		log.G(ctx).Warnf("could not publish %s event: %v", topic, err)
+	}
+}
+
+// exit marks the task as exited with the provided status and notifies
+// containerd exactly once.
+func (s *service) exit(ctx context.Context, t *task, status uint32) {
+	t.exitOnce.Do(func() {
+		s.mu.Lock()
+		t.status = tasktypes.Status_STOPPED
+		t.exitStatus = status
+		t.exitedAt = time.Now()
+		s.mu.Unlock()
+
+		close(t.exited)
+
+		s.publish(ctx, runtime.TaskExitEventTopic, &eventstypes.TaskExit{
+			ContainerID: t.id,
+			ID:          t.id,
+			Pid:         t.pid(),
+			ExitStatus:  status,
+			ExitedAt:    protobuf.ToTimestamp(t.exitedAt),
+		})
+	})
+}
+
+// monitor polls the state of the machine until it has exited.
+func (s *service) monitor(ctx context.Context, t *task) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.exited:
+			return
+		case <-ticker.C:
+		}
+
+		machine, err := s.machines.Get(ctx, t.machine)
+		if err != nil {
+			log.G(ctx).Debugf("could not get state of machine %s: %v", t.id, err)
+			continue
+		}
+
+		switch machine.Status.State {
+		case machineapi.MachineStateExited:
+			s.exit(ctx, t, uint32(machine.Status.ExitCode))
+			return
+		case machineapi.MachineStateFailed, machineapi.MachineStateErrored:
+			s.exit(ctx, t, exitStatusKilled)
+			return
+		}
+	}
+}
+
+// forwardLogs writes the console output of the machine to the stdout of the
+// task until the machine exits.
+func (s *service) forwardLogs(ctx context.Context, t *task) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w, err := fifo.OpenFifo(ctx, t.stdout, syscall.O_WRONLY, 0)
+	if err != nil {
+		log.G(ctx).Debugf("could not open stdout of %s: %v", t.id, err)
+		return
+	}
+	defer w.Close()
+
+	logs, errs, err := s.machines.Logs(ctx, t.machine)
+	if err != nil {
+		log.G(ctx).Debugf("could not access logs of %s: %v", t.id, err)
+		return
+	}
+
+	for {
+		select {
+		case line := <-logs:
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return
+			}
+		case err := <-errs:
+			// Reaching the end of the log is not fatal as it continues to be
+			// followed.
+			if errors.Is(err, io.EOF) {
+				continue
+			}
+
+			if err != nil {
+				log.G(ctx).Debugf("could not read logs of %s: %v", t.id, err)
+			}
+			return
+		case <-t.exited:
+			return
+		}
+	}
+}
+
+// State implements TaskService.
+func (s *service) State(ctx context.Context, r *taskapi.StateRequest) (*taskapi.StateResponse, error) {
+	t, err := s.getTask(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &taskapi.StateResponse{
+		ID:         t.id,
+		Bundle:     t.bundle,
+		Pid:        t.pid(),
+		Status:     t.status,
+		Stdout:     t.stdout,
+		ExitStatus: t.exitStatus,
+	}
+
+	if !t.exitedAt.IsZero() {
+		resp.ExitedAt = protobuf.ToTimestamp(t.exitedAt)
+	}
+
+	return resp, nil
+}
+
+// Create implements TaskService by mounting the root filesystem of the bundle
+// and creating, but not starting, the machine which boots the kernel it
+// contains.
+func (s *service) Create(ctx context.Context, r *taskapi.CreateTaskRequest) (_ *taskapi.CreateTaskResponse, retErr error) {
+	ctx = s.withKraftKit(ctx)
+
+	if r.Terminal {
+		return nil, errdefs.ToGRPCf(errdefs.ErrNotImplemented, "terminal")
+	}
+
+	if r.Checkpoint != "" {
+		return nil, errdefs.ToGRPCf(errdefs.ErrNotImplemented, "checkpoint")
+	}
+
+	spec, err := loadSpec(r.Bundle)
+	if err != nil {
+		return nil, fmt.Errorf("loading runtime spec: %w", err)
+	}
+
+	t := &task{
+		id:     r.ID,
+		bundle: r.Bundle,
+		rootfs: rootfsPath(r.Bundle, spec),
+		stdout: r.Stdout,
+		status: tasktypes.Status_CREATED,
+		exited: make(chan struct{}),
+	}
+
+	if err := mountRootfs(t.rootfs, r.Rootfs); err != nil {
+		return nil, fmt.Errorf("mounting rootfs: %w", err)
+	}
+
+	defer func() {
+		if retErr != nil {
+			unmountRootfs(ctx, r.Bundle)
+		}
+	}()
+
+	if !isCRISandbox(spec) {
+		machine, err := newMachine(r.ID, t.rootfs, spec)
+		if err != nil {
+			return nil, fmt.Errorf("preparing machine: %w", err)
+		}
+
+		if t.machine, err = s.machines.Create(ctx, machine); err != nil {
+			return nil, fmt.Errorf("creating machine: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.tasks[r.ID] = t
+	s.mu.Unlock()
+
+	s.publish(ctx, runtime.TaskCreateEventTopic, &eventstypes.TaskCreate{
+		ContainerID: r.ID,
+		Bundle:      r.Bundle,
+		Rootfs:      r.Rootfs,
+		IO: &eventstypes.TaskIO{
+			Stdin:    r.Stdin,
+			Stdout:   r.Stdout,
+			Stderr:   r.Stderr,
+			Terminal: r.Terminal,
+		},
+		Pid: t.pid(),
+	})
+
+	return &taskapi.CreateTaskResponse{
+		Pid: t.pid(),
+	}, nil
+}
+
+// Start implements TaskService by starting the machine.
+func (s *service) Start(ctx context.Context, r *taskapi.StartRequest) (*taskapi.StartResponse, error) {
+	ctx = s.withKraftKit(ctx)
+
+	t, err := s.getTask(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.machine != nil {
+		machine, err := s.machines.Start(ctx, t.machine)
+		if err != nil {
+			return nil, fmt.Errorf("starting machine: %w", err)
+		}
+
+		s.mu.Lock()
+		t.machine = machine
+		s.mu.Unlock()
+
+		// The requests of containerd are bound to their own lifetime, whereas
+		// the task outlives them.
+		bg := context.WithoutCancel(ctx)
+
+		go s.monitor(bg, t)
+
+		if t.stdout != "" {
+			go s.forwardLogs(bg, t)
+		}
+	}
+
+	s.mu.Lock()
+	t.status = tasktypes.Status_RUNNING
+	s.mu.Unlock()
+
+	s.publish(ctx, runtime.TaskStartEventTopic, &eventstypes.TaskStart{
+		ContainerID: t.id,
+		Pid:         t.pid(),
+	})
+
+	return &taskapi.StartResponse{
+		Pid: t.pid(),
+	}, nil
+}
+
+// Delete implements TaskService by removing the machine and unmounting the
+// root filesystem of the bundle.
+func (s *service) Delete(ctx context.Context, r *taskapi.DeleteRequest) (*taskapi.DeleteResponse, error) {
+	ctx = s.withKraftKit(ctx)
+
+	if r.ExecID != "" {
+		return nil, errdefs.ToGRPCf(errdefs.ErrNotImplemented, "exec")
+	}
+
+	t, err := s.getTask(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.machine != nil {
+		if _, err := s.machines.Delete(ctx, t.machine); err != nil {
+			return nil, fmt.Errorf("deleting machine: %w", err)
+		}
+	}
+
+	// A task which was never started cannot have exited by itself.
+	s.exit(ctx, t, exitStatusKilled)
+
+	unmountRootfs(ctx, t.bundle)
+
+	s.mu.Lock()
+	delete(s.tasks, r.ID)
+	s.mu.Unlock()
+
+	s.publish(ctx, runtime.TaskDeleteEventTopic, &eventstypes.TaskDelete{
+		ContainerID: t.id,
+		ID:          t.id,
+		Pid:         t.pid(),
+		ExitStatus:  t.exitStatus,
+		ExitedAt:    protobuf.ToTimestamp(t.exitedAt),
+	})
+
+	return &taskapi.DeleteResponse{
+		Pid:        t.pid(),
+		ExitStatus: t.exitStatus,
+		ExitedAt:   protobuf.ToTimestamp(t.exitedAt),
+	}, nil
+}
+
+// Pids implements TaskService.
+func (s *service) Pids(ctx context.Context, r *taskapi.PidsRequest) (*taskapi.PidsResponse, error) {
+	t, err := s.getTask(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskapi.PidsResponse{
+		Processes: []*tasktypes.ProcessInfo{{
+			Pid: t.pid(),
+		}},
+	}, nil
+}
+
+// Pause implements TaskService by pausing the machine.
+func (s *service) Pause(ctx context.Context, r *taskapi.PauseRequest) (*ptypes.Empty, error) {
+	ctx = s.withKraftKit(ctx)
+
+	t, err := s.getTask(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.machine != nil {
+		if _, err := s.machines.Pause(ctx, t.machine); err != nil {
+			return nil, fmt.Errorf("pausing machine: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	t.status = tasktypes.Status_PAUSED
+	s.mu.Unlock()
+
+	s.publish(ctx, runtime.TaskPausedEventTopic, &eventstypes.TaskPaused{
+		ContainerID: t.id,
+	})
+
+	return &ptypes.Empty{}, nil
+}
+
+// Resume implements TaskService by continuing the paused machine.
+func (s *service) Resume(ctx context.Context, r *taskapi.ResumeRequest) (*ptypes.Empty, error) {
+	ctx = s.withKraftKit(ctx)
+
+	t, err := s.getTask(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.machine != nil {
+		if _, err := s.machines.Start(ctx, t.machine); err != nil {
+			return nil, fmt.Errorf("resuming machine: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	t.status = tasktypes.Status_RUNNING
+	s.mu.Unlock()
+
+	s.publish(ctx, runtime.TaskResumedEventTopic, &eventstypes.TaskResumed{
+		ContainerID: t.id,
+	})
+
+	return &ptypes.Empty{}, nil
+}
+
+// Checkpoint implements TaskService.
+func (s *service) Checkpoint(ctx context.Context, r *taskapi.CheckpointTaskRequest) (*ptypes.Empty, error) {
+	return nil, errdefs.ToGRPC(errdefs.ErrNotImplemented)
+}
+
+// Kill implements TaskService by stopping the machine.  Unikernels do not
+// handle signals, so any signal results in the machine being stopped.
+func (s *service) Kill(ctx context.Context, r *taskapi.KillRequest) (*ptypes.Empty, error) {
+	ctx = s.withKraftKit(ctx)
+
+	if r.ExecID != "" {
+		return nil, errdefs.ToGRPCf(errdefs.ErrNotImplemented, "exec")
+	}
+
+	t, err := s.getTask(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.machine != nil {
+		if _, err := s.machines.Stop(ctx, t.machine); err != nil {
+			return nil, fmt.Errorf("stopping machine: %w", err)
+		}
+	}
+
+	s.exit(ctx, t, 128+r.Signal)
+
+	return &ptypes.Empty{}, nil
+}
+
+// Exec implements TaskService.
+func (s *service) Exec(ctx context.Context, r *taskapi.ExecProcessRequest) (*ptypes.Empty, error) {
+	return nil, errdefs.ToGRPC(errdefs.ErrNotImplemented)
+}
+
+// ResizePty implements TaskService.
+func (s *service) ResizePty(ctx context.Context, r *taskapi.ResizePtyRequest) (*ptypes.Empty, error) {
+	return nil, errdefs.ToGRPC(errdefs.ErrNotImplemented)
+}
+
+// CloseIO implements TaskService.  The stdin of tasks is never attached to
+// the machine, so there is nothing to close.
+func (s *service) CloseIO(ctx context.Context, r *taskapi.CloseIORequest) (*ptypes.Empty, error) {
+	return &ptypes.Empty{}, nil
+}
+
+// Update implements TaskService.
+func (s *service) Update(ctx context.Context, r *taskapi.UpdateTaskRequest) (*ptypes.Empty, error) {
+	return nil, errdefs.ToGRPC(errdefs.ErrNotImplemented)
+}
+
+// Wait implements TaskService by blocking until the task has exited.
+func (s *service) Wait(ctx context.Context, r *taskapi.WaitRequest) (*taskapi.WaitResponse, error) {
+	t, err := s.getTask(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-t.exited:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return &taskapi.WaitResponse{
+		ExitStatus: t.exitStatus,
+		ExitedAt:   protobuf.ToTimestamp(t.exitedAt),
+	}, nil
+}
+
+// Stats implements TaskService.
+func (s *service) Stats(ctx context.Context, r *taskapi.StatsRequest) (*taskapi.StatsResponse, error) {
+	return nil, errdefs.ToGRPC(errdefs.ErrNotImplemented)
+}
+
+// Connect implements TaskService.
+func (s *service) Connect(ctx context.Context, r *taskapi.ConnectRequest) (*taskapi.ConnectResponse, error) {
+	resp := &taskapi.ConnectResponse{
+		ShimPid: uint32(os.Getpid()),
+	}
+
+	if t, err := s.getTask(r.ID); err == nil {
+		resp.TaskPid = t.pid()
+	}
+
+	return resp, nil
+}
+
+// Shutdown implements TaskService by terminating the shim once it no longer
+// manages any task.
+func (s *service) Shutdown(ctx context.Context, r *taskapi.ShutdownRequest) (*ptypes.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.tasks) > 0 {
+		return &ptypes.Empty{}, nil
+	}
+
+	s.shutdown()
+
+	return &ptypes.Empty{}, nil
+}
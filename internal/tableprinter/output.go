@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package tableprinter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"kraftkit.sh/iostreams"
+)
+
+// OutputOptions holds the `-o|--output` flag of the commands whose output is
+// rendered by the TablePrinter.  Embed it within the options of a command such
+// that the flag, its usage and its validation are the same for every command.
+type OutputOptions struct {
+	Output string `long:"output" short:"o" usage:"Set output format. Options: table,wide,yaml,json,list,go-template=TEMPLATE" default:"table"`
+}
+
+// Validate returns an error if the selected output format cannot be rendered
+// by the TablePrinter.
+func (opts *OutputOptions) Validate() error {
+	if _, _, err := ParseOutputFormat(opts.Output); err != nil {
+		return fmt.Errorf("invalid output format: %s", opts.Output)
+	}
+
+	return nil
+}
+
+// Wide returns whether the wide output format is selected, in which case
+// commands may show additional columns.
+func (opts *OutputOptions) Wide() bool {
+	return opts.Output == string(OutputFormatWide)
+}
+
+// NewTablePrinter returns a TablePrinter which renders in the selected output
+// format and, unless overridden by the provided options, fits the terminal.
+func (opts *OutputOptions) NewTablePrinter(ctx context.Context, topts ...TablePrinterOption) (*TablePrinter, error) {
+	return NewTablePrinter(ctx, append([]TablePrinterOption{
+		WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		WithOutputFormatFromString(opts.Output),
+	}, topts...)...)
+}
+
+// FieldName returns the name of the field of a column with the provided header
+// in the JSON, YAML and go-template output formats.  The header is
+// lower-cased and every run of characters other than letters and digits is
+// replaced by a single underscore, e.g. "MEM (MiB)" becomes "mem_mib".  The
+// name therefore only depends on the header of the column and not on the
+// selected output format or the other columns.
+func FieldName(header string) string {
+	var name strings.Builder
+	sep := false
+
+	for _, r := range strings.ToLower(header) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if sep && name.Len() > 0 {
+				name.WriteByte('_')
+			}
+
+			name.WriteRune(r)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+
+	return name.String()
+}
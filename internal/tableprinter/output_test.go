@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package tableprinter
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func Test_FieldName(t *testing.T) {
+	tests := []struct {
+		header string
+		name   string
+	}{
+		{header: "NAME", name: "name"},
+		{header: "MACHINE STATE", name: "machine_state"},
+		{header: "SIZE ON DISK", name: "size_on_disk"},
+		{header: "MEM (MiB)", name: "mem_mib"},
+		{header: "CPU %", name: "cpu"},
+		{header: "  ID  ", name: "id"},
+		{header: "VOLUME-ID", name: "volume_id"},
+		{header: "IPv4", name: "ipv4"},
+		{header: "", name: ""},
+	}
+
+	for _, test := range tests {
+		if name := FieldName(test.header); name != test.name {
+			t.Errorf("%q: expected: %q, got: %q", test.header, test.name, name)
+		}
+	}
+}
+
+func Test_OutputOptions_Validate(t *testing.T) {
+	tests := []struct {
+		output string
+		wide   bool
+		err    bool
+	}{
+		{output: "table"},
+		{output: "wide", wide: true},
+		{output: "json"},
+		{output: "yaml"},
+		{output: "list"},
+		{output: "go-template={{.name}}"},
+		{output: "go-template=", err: true},
+		{output: "raw", err: true},
+		{output: "", err: true},
+	}
+
+	for _, test := range tests {
+		opts := OutputOptions{Output: test.output}
+
+		if err := opts.Validate(); (err != nil) != test.err {
+			t.Errorf("%q: expected error: %t, got: %v", test.output, test.err, err)
+		}
+
+		if wide := opts.Wide(); wide != test.wide {
+			t.Errorf("%q: expected wide: %t, got: %t", test.output, test.wide, wide)
+		}
+	}
+}
+
+func Test_OutputOptions_FieldNames(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{
+			output:   "json",
+			expected: `[{"mem_mib":"64","name":"hello","size_on_disk":"1 MiB"},{"mem_mib":"128","name":"world","size_on_disk":"2 MiB"}]`,
+		},
+		{
+			output:   "yaml",
+			expected: "- mem_mib: \"64\"\n  name: hello\n  size_on_disk: 1 MiB\n- mem_mib: \"128\"\n  name: world\n  size_on_disk: 2 MiB\n",
+		},
+		{
+			output:   "go-template={{.name}} {{.mem_mib}} {{.size_on_disk}}",
+			expected: "hello 64 1 MiB\nworld 128 2 MiB\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			opts := OutputOptions{Output: test.output}
+
+			table, err := opts.NewTablePrinter(context.Background(), WithMaxWidth(80))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Columns are colored and named as in the table output format, which
+			// must not affect the names of the fields.
+			table.AddField("NAME", func(s string) string { return "\x1b[1m" + s + "\x1b[0m" })
+			table.AddField("MEM (MiB)", nil)
+			table.AddField("SIZE ON DISK", nil)
+			table.EndRow()
+			table.AddField("hello", nil)
+			table.AddField("64", nil)
+			table.AddField("1 MiB", nil)
+			table.EndRow()
+			table.AddField("world", nil)
+			table.AddField("128", nil)
+			table.AddField("2 MiB", nil)
+			table.EndRow()
+
+			var buf bytes.Buffer
+			if err := table.Render(&buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if buf.String() != test.expected {
+				t.Errorf("expected: %q, got: %q", test.expected, buf.String())
+			}
+		})
+	}
+}
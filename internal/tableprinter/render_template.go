@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package tableprinter
+
+import (
+	"fmt"
+	"io"
+)
+
+func (printer *TablePrinter) renderTemplate(w io.Writer) error {
+	if printer.template == nil {
+		return fmt.Errorf("no output template provided")
+	}
+
+	for _, row := range printer.records() {
+		if err := printer.template.Execute(w, row); err != nil {
+			return fmt.Errorf("could not execute output template: %w", err)
+		}
+
+		if _, err := fmt.Fprint(w, "\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -7,31 +7,12 @@ package tableprinter
 import (
 	"fmt"
 	"io"
-	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
 func (printer *TablePrinter) renderYAML(w io.Writer) error {
-	header := printer.rows[0]
-	var rows []map[string]string
-
-	for i, row := range printer.rows {
-		if i == 0 {
-			continue
-		}
-		m := make(map[string]string)
-
-		for j, column := range row {
-			m[strings.ReplaceAll(strings.ToLower(header[j].text), " ", "_")] = column.text
-		}
-
-		if len(m) > 0 {
-			rows = append(rows, m)
-		}
-	}
-
-	b, err := yaml.Marshal(rows)
+	b, err := yaml.Marshal(printer.records())
 	if err != nil {
 		return err
 	}
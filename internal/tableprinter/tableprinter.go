@@ -25,9 +25,11 @@ package tableprinter
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"sort"
 	"strings"
+	"text/template"
 
 	"kraftkit.sh/internal/text"
 )
@@ -35,14 +37,47 @@ import (
 type TableOutputFormat string
 
 const (
-	OutputFormatTable = TableOutputFormat("table")
-	OutputFormatJSON  = TableOutputFormat("json")
-	OutputFormatYAML  = TableOutputFormat("yaml")
-	OutputFormatList  = TableOutputFormat("list")
+	OutputFormatTable      = TableOutputFormat("table")
+	OutputFormatWide       = TableOutputFormat("wide")
+	OutputFormatJSON       = TableOutputFormat("json")
+	OutputFormatYAML       = TableOutputFormat("yaml")
+	OutputFormatList       = TableOutputFormat("list")
+	OutputFormatGoTemplate = TableOutputFormat("go-template")
 
 	DefaultDelimeter = "  "
 )
 
+// ParseOutputFormat parses the value of an `-o|--output` flag and returns the
+// format along with, for the `go-template=TEMPLATE` format, the template which
+// is executed for every row.
+func ParseOutputFormat(format string) (TableOutputFormat, string, error) {
+	if tmpl, ok := strings.CutPrefix(format, string(OutputFormatGoTemplate)+"="); ok {
+		if tmpl == "" {
+			return "", "", fmt.Errorf("missing template in output format: %s", format)
+		}
+
+		return OutputFormatGoTemplate, tmpl, nil
+	}
+
+	switch f := TableOutputFormat(format); f {
+	case OutputFormatTable,
+		OutputFormatWide,
+		OutputFormatJSON,
+		OutputFormatYAML,
+		OutputFormatList:
+		return f, "", nil
+	}
+
+	return "", "", fmt.Errorf("unsupported table printer format: %s", format)
+}
+
+// IsValidOutputFormat returns whether the provided format can be rendered by
+// the TablePrinter.
+func IsValidOutputFormat(format string) bool {
+	_, _, err := ParseOutputFormat(format)
+	return err == nil
+}
+
 type TableField struct {
 	text  string
 	color func(string) string
@@ -58,6 +93,7 @@ type TablePrinter struct {
 	maxWidth     int
 	delimeter    string
 	truncateFunc func(int, string) string
+	template     *template.Template
 }
 
 // NewTablePrinter returns a pointer instance of TablePrinter struct.
@@ -111,11 +147,37 @@ func (printer *TablePrinter) Render(w io.Writer) error {
 		return printer.renderJSON(w)
 	case OutputFormatYAML:
 		return printer.renderYAML(w)
+	case OutputFormatGoTemplate:
+		return printer.renderTemplate(w)
 	default:
 		return printer.renderTable(w)
 	}
 }
 
+// records returns every row but the header as a map whose keys are the field
+// names of the respective columns, see FieldName.
+func (printer *TablePrinter) records() []map[string]string {
+	header := printer.rows[0]
+	var rows []map[string]string
+
+	for i, row := range printer.rows {
+		if i == 0 {
+			continue
+		}
+		m := make(map[string]string)
+
+		for j, column := range row {
+			m[FieldName(header[j].text)] = column.text
+		}
+
+		if len(m) > 0 {
+			rows = append(rows, m)
+		}
+	}
+
+	return rows
+}
+
 func (printer *TablePrinter) calculateColumnWidths(delimSize int) []int {
 	numCols := len(printer.rows[0])
 	allColWidths := make([][]int, numCols)
@@ -135,6 +197,11 @@ func (printer *TablePrinter) calculateColumnWidths(delimSize int) []int {
 		// medianColWidth[col] = widths[(len(widths)+1)/2]
 	}
 
+	// never truncate any column of the wide format
+	if printer.format == OutputFormatWide {
+		return maxColWidths
+	}
+
 	colWidths := make([]int, numCols)
 
 	// never truncate the first column
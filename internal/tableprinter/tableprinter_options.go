@@ -1,6 +1,9 @@
 package tableprinter
 
-import "fmt"
+import (
+	"fmt"
+	"text/template"
+)
 
 // TablePrinterOption is a type of func(*TablePrinter).
 type TablePrinterOption func(*TablePrinter) error
@@ -16,12 +19,22 @@ func WithOutputFormat(format TableOutputFormat) TablePrinterOption {
 
 // WithOutputFormatFromString returns a function func(opts *TablePrinter)
 // that sets `format` in TablePrinter pointer instance of type `TableOutputFormat` from string.
+// The template of the `go-template=TEMPLATE` format is parsed immediately.
 func WithOutputFormatFromString(format string) TablePrinterOption {
 	return func(opts *TablePrinter) error {
-		if format == "" {
-			return fmt.Errorf("unsupported table printer format: %s", format)
+		f, tmpl, err := ParseOutputFormat(format)
+		if err != nil {
+			return err
 		}
-		opts.format = TableOutputFormat(format)
+
+		if f == OutputFormatGoTemplate {
+			opts.template, err = template.New("output").Parse(tmpl)
+			if err != nil {
+				return fmt.Errorf("could not parse output template: %w", err)
+			}
+		}
+
+		opts.format = f
 		return nil
 	}
 }
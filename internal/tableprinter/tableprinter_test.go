@@ -56,3 +56,100 @@ func Test_TablePrinter_OutputFormatTable(t *testing.T) {
 		t.Errorf("expected: %q, got: %q", expected, buf.String())
 	}
 }
+
+func Test_TablePrinter_OutputFormatWide(t *testing.T) {
+	buf := bytes.Buffer{}
+	tp := &TablePrinter{
+		maxWidth:     5,
+		format:       OutputFormatWide,
+		delimeter:    DefaultDelimeter,
+		truncateFunc: text.Truncate,
+	}
+
+	tp.AddField("1", nil)
+	tp.AddField("hello", nil)
+	tp.EndRow()
+	tp.AddField("2", nil)
+	tp.AddField("world", nil)
+	tp.EndRow()
+
+	err := tp.Render(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "1  hello\n2  world\n"
+	if buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+}
+
+func Test_TablePrinter_OutputFormatGoTemplate(t *testing.T) {
+	buf := bytes.Buffer{}
+	tp := &TablePrinter{
+		delimeter:    DefaultDelimeter,
+		truncateFunc: text.Truncate,
+	}
+
+	if err := WithOutputFormatFromString("go-template={{.name}}={{.machine_state}}")(tp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tp.AddField("NAME", nil)
+	tp.AddField("MACHINE STATE", nil)
+	tp.EndRow()
+	tp.AddField("hello", nil)
+	tp.AddField("running", nil)
+	tp.EndRow()
+	tp.AddField("world", nil)
+	tp.AddField("exited", nil)
+	tp.EndRow()
+
+	err := tp.Render(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "hello=running\nworld=exited\n"
+	if buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+}
+
+func Test_ParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		in       string
+		format   TableOutputFormat
+		template string
+		err      bool
+	}{
+		{in: "table", format: OutputFormatTable},
+		{in: "wide", format: OutputFormatWide},
+		{in: "json", format: OutputFormatJSON},
+		{in: "yaml", format: OutputFormatYAML},
+		{in: "list", format: OutputFormatList},
+		{in: "go-template={{.name}}", format: OutputFormatGoTemplate, template: "{{.name}}"},
+		{in: "go-template=", err: true},
+		{in: "go-template", err: true},
+		{in: "", err: true},
+		{in: "xml", err: true},
+	}
+
+	for _, test := range tests {
+		format, template, err := ParseOutputFormat(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error", test.in)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.in, err)
+		}
+
+		if format != test.format || template != test.template {
+			t.Errorf("%q: expected: (%q, %q), got: (%q, %q)", test.in, test.format, test.template, format, template)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package update
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// releaseKey is the base64-encoded DER form of the public key with which the
+// releases of KraftKit are signed.  It is pinned when a release is built, e.g.:
+//
+//	-ldflags "-X kraftkit.sh/internal/update.releaseKey=MFkwEwYHKoZIzj0CAQYI..."
+var releaseKey = ""
+
+// ErrNoReleaseKey is returned when this build of KraftKit has no pinned
+// release key, e.g. because it was built from source.
+var ErrNoReleaseKey = errors.New("no release key is pinned in this build of kraft")
+
+// ReleaseKey returns the PEM-encoded public key with which the releases of
+// KraftKit are signed, as pinned in this build.
+func ReleaseKey() ([]byte, error) {
+	return encodeKey(releaseKey)
+}
+
+// encodeKey returns the PEM encoding of the base64-encoded DER public key.
+func encodeKey(key string) ([]byte, error) {
+	if key == "" {
+		return nil, ErrNoReleaseKey
+	}
+
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode release key: %w", err)
+	}
+
+	if _, err := x509.ParsePKIXPublicKey(der); err != nil {
+		return nil, fmt.Errorf("could not parse release key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
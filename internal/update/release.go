@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package update
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/sirupsen/logrus"
+
+	"kraftkit.sh/internal/version"
+	"kraftkit.sh/log"
+)
+
+// KraftKitLatestReleaseURL is the GitHub API endpoint which describes the
+// latest stable release of KraftKit.
+const KraftKitLatestReleaseURL = "https://api.github.com/repos/unikraft/kraftkit/releases/latest"
+
+// Asset is a file which is attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Release is a published release of KraftKit.
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	URL    string  `json:"html_url"`
+	Assets []Asset `json:"assets"`
+}
+
+// Version returns the version of the release without the leading `v`.
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// ArchiveName returns the name of the archive of the release which holds the
+// kraft binary for the host.
+func (r *Release) ArchiveName() string {
+	return fmt.Sprintf("kraft_%s_%s_%s.tar.gz", r.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// ChecksumsName returns the name of the file of the release which holds the
+// SHA-256 checksums of all of its assets.
+func (r *Release) ChecksumsName() string {
+	return fmt.Sprintf("kraftkit_%s_checksums.txt", r.Version())
+}
+
+// Asset returns the asset of the release with the provided name.
+func (r *Release) Asset(name string) (*Asset, error) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("release %s has no asset %s", r.Tag, name)
+}
+
+// get performs a GET request to the provided URL and returns the body of the
+// response.
+func get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", version.UserAgent())
+
+	if strings.HasPrefix(url, "https://api.github.com/") {
+		req.Header.Set("Accept", "application/vnd.github+json")
+
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	log.G(ctx).WithFields(logrus.Fields{
+		"url":    url,
+		"method": "GET",
+	}).Trace("http")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// LatestRelease queries the GitHub releases API for the latest stable release
+// of KraftKit.
+func LatestRelease(ctx context.Context) (*Release, error) {
+	body, err := get(ctx, KraftKitLatestReleaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not query latest release: %w", err)
+	}
+
+	var release Release
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("could not parse latest release: %w", err)
+	}
+
+	if release.Tag == "" {
+		return nil, fmt.Errorf("could not parse latest release: missing tag")
+	}
+
+	return &release, nil
+}
+
+// Download fetches the contents of the asset.
+func (a *Asset) Download(ctx context.Context) ([]byte, error) {
+	return get(ctx, a.URL)
+}
+
+// describeSuffix matches the suffix which git-describe(1) appends to the tag
+// of a release for builds of later commits, e.g. `-34-gabcdef` or
+// `-34-gabcdef-dirty`.
+var describeSuffix = regexp.MustCompile(`-[0-9]+-g[0-9a-f]+(-dirty)?$`)
+
+// parseVersion parses the version of a build or release of KraftKit.  Builds
+// of commits after a release are treated as the release itself, since they are
+// not a pre-release of it.
+func parseVersion(v string) (*semver.Version, error) {
+	parsed, err := semver.NewVersion(describeSuffix.ReplaceAllString(strings.TrimPrefix(v, "v"), ""))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse version %q: %w", v, err)
+	}
+
+	return parsed, nil
+}
+
+// IsNewer returns whether the latest version is newer than the current one
+// following the precedence of semantic versions, such that a release is newer
+// than its release candidates.
+func IsNewer(current, latest string) (bool, error) {
+	currentVer, err := parseVersion(current)
+	if err != nil {
+		return false, err
+	}
+
+	latestVer, err := parseVersion(latest)
+	if err != nil {
+		return false, err
+	}
+
+	return currentVer.LessThan(latestVer), nil
+}
+
+// VerifyChecksum checks the SHA-256 digest of the named file against its entry
+// in the provided checksums file, which holds lines in the format of
+// sha256sum(1).
+func VerifyChecksum(checksums []byte, name string, data []byte) error {
+	digest := sha256.Sum256(data)
+
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+
+		if !strings.EqualFold(fields[0], hex.EncodeToString(digest[:])) {
+			return fmt.Errorf("checksum of %s does not match: expected %s, got %x", name, fields[0], digest)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("no checksum of %s found", name)
+}
+
+// VerifySignature checks the base64-encoded ECDSA signature of the data, as
+// produced by `cosign sign-blob`, against the PEM-encoded public key.
+func VerifySignature(publicKey, data, signature []byte) error {
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return fmt.Errorf("could not decode public key: no PEM block found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("could not parse public key: %w", err)
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported public key type: %T", key)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("could not decode signature: %w", err)
+	}
+
+	digest := sha256.Sum256(data)
+	if !ecdsa.VerifyASN1(ecdsaKey, digest[:], sig) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+// ExtractBinary returns the contents of the file with the provided name at the
+// root of the gzip-compressed tarball.
+func ExtractBinary(archive []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress archive: %w", err)
+	}
+
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("could not read archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || filepath.Clean(hdr.Name) != name {
+			continue
+		}
+
+		return io.ReadAll(tr)
+	}
+
+	return nil, fmt.Errorf("archive does not contain %s", name)
+}
+
+// ReplaceBinary atomically replaces the executable at the provided path with
+// the provided contents, retaining its permissions.  The new executable is
+// written alongside the existing one such that it can be renamed over it.
+func ReplaceBinary(path string, contents []byte) error {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return fmt.Errorf("could not create file alongside %s: %w", path, err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
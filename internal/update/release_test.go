@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		latest  string
+		newer   bool
+		err     bool
+	}{
+		{name: "patch release", current: "0.9.0", latest: "v0.9.1", newer: true},
+		{name: "same release", current: "0.9.1", latest: "v0.9.1"},
+		{name: "minor release is compared numerically", current: "0.10.0", latest: "v0.9.5"},
+		{name: "build after release", current: "0.9.1-34-gabcdef", latest: "v0.9.1"},
+		{name: "dirty build after release", current: "0.9.1-34-gabcdef-dirty", latest: "v0.9.1"},
+		{name: "build after older release", current: "0.9.0-34-gabcdef", latest: "v0.9.1", newer: true},
+		{name: "release candidate", current: "0.9.1-rc.1", latest: "v0.9.1", newer: true},
+		{name: "release candidates are ordered", current: "0.9.1-rc.1", latest: "v0.9.1-rc.2", newer: true},
+		{name: "release candidate of next release", current: "0.9.2-rc.1", latest: "v0.9.1"},
+		{name: "build metadata is ignored", current: "0.9.1+linux", latest: "v0.9.1"},
+		{name: "development build", current: "No version provided", latest: "v0.9.1", err: true},
+		{name: "malformed release", current: "0.9.1", latest: "latest", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newer, err := IsNewer(tt.current, tt.latest)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected %s and %s not to be comparable", tt.current, tt.latest)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if newer != tt.newer {
+				t.Errorf("%s < %s: expected %t, got %t", tt.current, tt.latest, tt.newer, newer)
+			}
+		})
+	}
+}
+
+func TestEncodeKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := encodeKey(base64.StdEncoding.EncodeToString(der))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}); !bytes.Equal(publicKey, expected) {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, publicKey)
+	}
+
+	if _, err := encodeKey(""); !errors.Is(err, ErrNoReleaseKey) {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+
+	if _, err := encodeKey("bm90IGEga2V5"); err == nil {
+		t.Errorf("expected malformed key to be rejected")
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("kraft")
+	checksums := []byte(fmt.Sprintf("%x  runu.tar.gz\n%x  kraft.tar.gz\n", sha256.Sum256(nil), sha256.Sum256(data)))
+
+	if err := VerifyChecksum(checksums, "kraft.tar.gz", data); err != nil {
+		t.Errorf("expected checksum to match: %v", err)
+	}
+
+	if err := VerifyChecksum(checksums, "runu.tar.gz", data); err == nil {
+		t.Errorf("expected checksum not to match")
+	}
+
+	if err := VerifyChecksum(checksums, "missing.tar.gz", data); err == nil {
+		t.Errorf("expected missing checksum to fail")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	data := []byte("checksums")
+	digest := sha256.Sum256(data)
+
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+
+	if err := VerifySignature(publicKey, data, signature); err != nil {
+		t.Errorf("expected signature to be valid: %v", err)
+	}
+
+	if err := VerifySignature(publicKey, []byte("tampered"), signature); err == nil {
+		t.Errorf("expected signature of tampered data to be invalid")
+	}
+}
+
+func TestExtractAndReplaceBinary(t *testing.T) {
+	var archive bytes.Buffer
+
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+
+	for name, contents := range map[string]string{
+		"kraftld": "#!/bin/sh",
+		"kraft":   "new",
+	} {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o755,
+			Size:     int64(len(contents)),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	binary, err := ExtractBinary(archive.Bytes(), "kraft")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "kraft")
+	if err := os.WriteFile(path, []byte("old"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReplaceBinary(path, binary); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(contents) != "new" {
+		t.Errorf("expected binary to be replaced, got %q", contents)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o750 {
+		t.Errorf("expected permissions to be retained, got %s", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files to remain, got %d entries", len(entries))
+	}
+}
@@ -65,7 +65,7 @@ func Check(ctx context.Context) error {
 
 Please update KraftKit through your local package manager or run:
 
-curl --proto '=https' --tlsv1.2 -sSf https://get.kraftkit.sh | sh
+kraft self-update
 
 Read the full changelog:
 
@@ -51,6 +51,14 @@ func EnvColorDisabled() bool {
 	return os.Getenv("NO_COLOR") != "" || os.Getenv("CLICOLOR") == "0"
 }
 
+// EnvCI returns whether the process is running within a continuous
+// integration environment, which is signalled by most providers through a
+// non-empty and non-false CI environmental variable.
+func EnvCI() bool {
+	ci := strings.ToLower(os.Getenv("CI"))
+	return ci != "" && ci != "0" && ci != "false"
+}
+
 func EnvColorForced() bool {
 	return os.Getenv("CLICOLOR_FORCE") != "" && os.Getenv("CLICOLOR_FORCE") != "0"
 }
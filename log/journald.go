@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// journaldSocket is the socket of the native protocol of the systemd journal.
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldHook sends entries to the systemd journal using its native
+// protocol, mapping the fields of each entry to journal fields.
+type journaldHook struct {
+	conn   net.Conn
+	filter *LevelFilter
+}
+
+func newJournaldHook(filter *LevelFilter) (*journaldHook, error) {
+	conn, err := net.Dial("unixgram", journaldSocket)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to journald: %w", err)
+	}
+
+	return &journaldHook{
+		conn:   conn,
+		filter: filter,
+	}, nil
+}
+
+// Levels implements logrus.Hook
+func (hook *journaldHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (hook *journaldHook) Fire(entry *logrus.Entry) error {
+	if !hook.filter.Enabled(entry) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+
+	writeJournaldField(&buf, "MESSAGE", entry.Message)
+	writeJournaldField(&buf, "PRIORITY", fmt.Sprintf("%d", journaldPriority(entry.Level)))
+	writeJournaldField(&buf, "SYSLOG_IDENTIFIER", "kraft")
+
+	for k, v := range entry.Data {
+		writeJournaldField(&buf, journaldFieldName(k), fmt.Sprint(v))
+	}
+
+	_, err := hook.conn.Write(buf.Bytes())
+	return err
+}
+
+// writeJournaldField serializes a field in the native journal protocol, in
+// which values containing newlines are prefixed with their length.
+func writeJournaldField(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journaldFieldName converts the key of a field into a valid journal field
+// name, which consists of upper-case letters, digits and underscores and does
+// not start with an underscore.
+func journaldFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	return "KRAFTKIT_" + name
+}
+
+// journaldPriority maps a log level to a syslog priority.
+func journaldPriority(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 0
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OutputJournald is the output which sends entries to the systemd journal
+// instead of writing them to a file.
+const OutputJournald = "journald"
+
+// NewOutputHook returns a hook which additionally persists each entry enabled
+// by the filter to the provided output, which is either the path of a file to
+// which JSON-formatted entries are appended or OutputJournald.
+func NewOutputHook(output string, filter *LevelFilter) (logrus.Hook, error) {
+	if output == OutputJournald {
+		return newJournaldHook(filter)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		return nil, fmt.Errorf("could not create log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open log file: %w", err)
+	}
+
+	return &fileHook{
+		out:       f,
+		filter:    filter,
+		formatter: &logrus.JSONFormatter{},
+	}, nil
+}
+
+// fileHook writes JSON-formatted entries to a file.
+type fileHook struct {
+	mu        sync.Mutex
+	out       io.Writer
+	filter    *LevelFilter
+	formatter logrus.Formatter
+}
+
+// Levels implements logrus.Hook
+func (hook *fileHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (hook *fileHook) Fire(entry *logrus.Entry) error {
+	if !hook.filter.Enabled(entry) {
+		return nil
+	}
+
+	b, err := hook.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+
+	_, err = hook.out.Write(b)
+	return err
+}
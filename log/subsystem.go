@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SubsystemKey is the field of a log entry which holds the name of the
+// subsystem which emitted it.
+const SubsystemKey = "subsystem"
+
+// WithSubsystem returns a new context whose logger tags each entry with the
+// provided subsystem, such that its level can be overridden independently and
+// its entries can be told apart in persisted logs.
+func WithSubsystem(ctx context.Context, subsystem string) context.Context {
+	parent := G(ctx)
+
+	hooks := make(logrus.LevelHooks, len(parent.Hooks))
+	for _, level := range logrus.AllLevels {
+		hooks[level] = append([]logrus.Hook{subsystemHook(subsystem)}, parent.Hooks[level]...)
+	}
+
+	return WithLogger(ctx, &logrus.Logger{
+		Out:          parent.Out,
+		Hooks:        hooks,
+		Formatter:    parent.Formatter,
+		ReportCaller: parent.ReportCaller,
+		Level:        parent.GetLevel(),
+		ExitFunc:     parent.ExitFunc,
+		BufferPool:   parent.BufferPool,
+	})
+}
+
+// subsystemHook sets the subsystem of each entry, unless it already has one.
+type subsystemHook string
+
+// Levels implements logrus.Hook
+func (hook subsystemHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (hook subsystemHook) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[SubsystemKey]; !ok {
+		entry.Data[SubsystemKey] = string(hook)
+	}
+
+	return nil
+}
+
+// LevelFilter decides whether an entry is logged based on the level of the
+// subsystem which emitted it.
+type LevelFilter struct {
+	// Default is the level of entries without a subsystem or whose subsystem
+	// has no override.
+	Default logrus.Level
+
+	// Subsystems holds the level overrides indexed by the name of the
+	// subsystem.
+	Subsystems map[string]logrus.Level
+}
+
+// NewLevelFilter parses the level overrides in the format subsystem=level.
+func NewLevelFilter(level logrus.Level, overrides []string) (*LevelFilter, error) {
+	filter := &LevelFilter{
+		Default:    level,
+		Subsystems: make(map[string]logrus.Level, len(overrides)),
+	}
+
+	for _, override := range overrides {
+		subsystem, name, ok := strings.Cut(override, "=")
+		if !ok || subsystem == "" {
+			return nil, fmt.Errorf("invalid log level override '%s': expected subsystem=level", override)
+		}
+
+		level, ok := Levels()[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid log level '%s' of subsystem %s", name, subsystem)
+		}
+
+		filter.Subsystems[subsystem] = level
+	}
+
+	return filter, nil
+}
+
+// MaxLevel returns the most verbose level of any subsystem, which is the
+// level the logger must be set to such that no entry is discarded before it
+// has been filtered.
+func (filter *LevelFilter) MaxLevel() logrus.Level {
+	ret := filter.Default
+	for _, level := range filter.Subsystems {
+		if level > ret {
+			ret = level
+		}
+	}
+
+	return ret
+}
+
+// Enabled returns whether the entry is logged.
+func (filter *LevelFilter) Enabled(entry *logrus.Entry) bool {
+	level := filter.Default
+
+	if subsystem, ok := entry.Data[SubsystemKey].(string); ok {
+		if override, ok := filter.Subsystems[subsystem]; ok {
+			level = override
+		}
+	}
+
+	return entry.Level <= level
+}
+
+// FilteredFormatter formats only the entries which are enabled by the filter
+// and discards all others.
+type FilteredFormatter struct {
+	logrus.Formatter
+	Filter *LevelFilter
+}
+
+// Format implements logrus.Formatter
+func (formatter *FilteredFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !formatter.Filter.Enabled(entry) {
+		return nil, nil
+	}
+
+	return formatter.Formatter.Format(entry)
+}
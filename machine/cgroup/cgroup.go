@@ -0,0 +1,386 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package cgroup places the processes of virtual machine monitors in cgroup v2
+// groups of their own, whose memory, CPU and I/O limits are derived from the
+// resources requested by their machine.  This prevents a runaway unikernel
+// from starving the host and allows the resource usage of each VMM to be
+// accounted for.
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+)
+
+const (
+	// MemoryOverhead is the memory which a VMM is allowed to use in addition to
+	// the memory of its guest, e.g. for its own code, device emulation and I/O
+	// buffers.
+	MemoryOverhead = 128 * 1024 * 1024
+
+	// CPUPeriod is the period, in microseconds, over which the CPU quota of a
+	// cgroup is enforced.
+	CPUPeriod = 100000
+
+	// DefaultWeight is the CPU and I/O weight of a cgroup whose machine requests
+	// a single CPU.
+	DefaultWeight = 100
+)
+
+// controllers are the cgroup v2 controllers which are enabled for the cgroups
+// of VMMs.
+var controllers = []string{"cpu", "io", "memory"}
+
+// Limits are the resources which the processes of a cgroup are limited to.
+// Zero values leave the respective resource unlimited.
+type Limits struct {
+	// Memory is the maximum number of bytes of memory.
+	Memory int64
+
+	// CPU is the maximum CPU time in millicores, e.g. 1500 for one and a half
+	// CPUs.
+	CPU int64
+
+	// Weight is the share of CPU and I/O bandwidth relative to other cgroups
+	// under contention, in the range of 1 to 10000.
+	Weight uint64
+}
+
+// Stats is the resource usage accounted to the processes of a cgroup.
+type Stats struct {
+	// MemoryCurrent is the number of bytes of memory in use.
+	MemoryCurrent int64
+
+	// MemoryMax is the maximum number of bytes of memory, or 0 if unlimited.
+	MemoryMax int64
+
+	// OOMKills is the number of processes killed for exceeding MemoryMax.
+	OOMKills uint64
+
+	// CPUUsage is the CPU time consumed.
+	CPUUsage time.Duration
+
+	// CPUThrottled is the time for which the processes were throttled for
+	// exceeding their CPU quota.
+	CPUThrottled time.Duration
+
+	// IOReadBytes is the number of bytes read from block devices.
+	IOReadBytes uint64
+
+	// IOWriteBytes is the number of bytes written to block devices.
+	IOWriteBytes uint64
+}
+
+// LimitsFromSpec returns the limits of a VMM which runs a machine with the
+// provided specification.
+func LimitsFromSpec(spec machinev1alpha1.MachineSpec) Limits {
+	limits := Limits{}
+
+	if memory := spec.Resources.Requests.Memory().Value(); memory > 0 {
+		limits.Memory = memory + MemoryOverhead
+	}
+
+	if cpu := spec.Resources.Requests.Cpu().MilliValue(); cpu > 0 {
+		limits.CPU = cpu
+		limits.Weight = uint64(cpu) * DefaultWeight / 1000
+		limits.Weight = min(max(limits.Weight, 1), 10000)
+	}
+
+	return limits
+}
+
+// Supported returns whether the provided directory is on a cgroup v2
+// hierarchy.
+func Supported(dir string) bool {
+	for ; dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "cgroup.controllers")); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// write writes the value to the file of an existing cgroup interface, which
+// is never created.
+func write(dir, file, value string) error {
+	f, err := os.OpenFile(filepath.Join(dir, file), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write %q to %s: %w", value, file, err)
+	}
+
+	return f.Close()
+}
+
+// enableControllers enables the controllers for the children of the cgroup.
+// Each controller is enabled separately since not every host provides all of
+// them.
+func enableControllers(dir string) {
+	for _, controller := range controllers {
+		_ = write(dir, "cgroup.subtree_control", "+"+controller)
+	}
+}
+
+// setLimits writes the limits to the interface files of the cgroup.  I/O
+// weights are only set if the host's I/O scheduler supports them.
+func setLimits(dir string, limits Limits) error {
+	memory := "max"
+	if limits.Memory > 0 {
+		memory = strconv.FormatInt(limits.Memory, 10)
+	}
+
+	if err := write(dir, "memory.max", memory); err != nil {
+		return err
+	}
+
+	cpu := fmt.Sprintf("max %d", CPUPeriod)
+	if limits.CPU > 0 {
+		cpu = fmt.Sprintf("%d %d", limits.CPU*CPUPeriod/1000, CPUPeriod)
+	}
+
+	if err := write(dir, "cpu.max", cpu); err != nil {
+		return err
+	}
+
+	if limits.Weight == 0 {
+		return nil
+	}
+
+	if err := write(dir, "cpu.weight", strconv.FormatUint(limits.Weight, 10)); err != nil {
+		return err
+	}
+
+	if err := write(dir, "io.weight", fmt.Sprintf("default %d", limits.Weight)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Create creates the cgroup in the provided directory, whose parent must be a
+// writable cgroup v2 directory, and applies the limits to it.
+func Create(dir string, limits Limits) error {
+	parent := filepath.Dir(dir)
+
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return fmt.Errorf("could not create parent cgroup: %w", err)
+	}
+
+	enableControllers(filepath.Dir(parent))
+	enableControllers(parent)
+
+	if err := os.Mkdir(dir, 0o755); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("could not create cgroup: %w", err)
+	}
+
+	if err := setLimits(dir, limits); err != nil {
+		_ = os.Remove(dir)
+		return fmt.Errorf("could not set limits of cgroup: %w", err)
+	}
+
+	return nil
+}
+
+// Attach moves the process, including all of its threads, into the cgroup.
+func Attach(dir string, pid int) error {
+	return write(dir, "cgroup.procs", strconv.Itoa(pid))
+}
+
+// Remove kills any processes left in the cgroup and removes it.  Removing a
+// cgroup which does not exist is not an error.
+func Remove(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+
+	// cgroup.kill is only available since Linux 5.14.
+	_ = write(dir, "cgroup.kill", "1")
+
+	var err error
+
+	// The cgroup is busy until the kernel has reaped its killed processes.
+	for i := 0; i < 50; i++ {
+		if err = os.Remove(dir); err == nil || os.IsNotExist(err) {
+			return nil
+		} else if !errors.Is(err, syscall.EBUSY) {
+			break
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return fmt.Errorf("could not remove cgroup: %w", err)
+}
+
+// Read returns the resource usage accounted to the cgroup.
+func Read(dir string) (*Stats, error) {
+	stats := Stats{}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return nil, err
+	}
+
+	stats.MemoryCurrent, _ = strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+
+	if raw, err := os.ReadFile(filepath.Join(dir, "memory.max")); err == nil {
+		stats.MemoryMax, _ = strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+	}
+
+	if raw, err := os.ReadFile(filepath.Join(dir, "memory.events")); err == nil {
+		stats.OOMKills = parseKeyed(raw)["oom_kill"]
+	}
+
+	if raw, err := os.ReadFile(filepath.Join(dir, "cpu.stat")); err == nil {
+		cpu := parseKeyed(raw)
+		stats.CPUUsage = time.Duration(cpu["usage_usec"]) * time.Microsecond
+		stats.CPUThrottled = time.Duration(cpu["throttled_usec"]) * time.Microsecond
+	}
+
+	if raw, err := os.ReadFile(filepath.Join(dir, "io.stat")); err == nil {
+		stats.IOReadBytes, stats.IOWriteBytes = parseIOStat(raw)
+	}
+
+	return &stats, nil
+}
+
+// parseKeyed parses the flat keyed format of cgroup interface files, which
+// consists of a key and a value per line.
+func parseKeyed(raw []byte) map[string]uint64 {
+	values := map[string]uint64{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		values[fields[0]] = value
+	}
+
+	return values
+}
+
+// parseIOStat returns the bytes read and written across all devices listed in
+// the nested keyed format of io.stat, e.g.:
+//
+//	8:0 rbytes=1459200 wbytes=314773504 rios=192 wios=353 dbytes=0 dios=0
+func parseIOStat(raw []byte) (read uint64, written uint64) {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			switch key {
+			case "rbytes":
+				read += n
+			case "wbytes":
+				written += n
+			}
+		}
+	}
+
+	return read, written
+}
+
+// Confine places the VMM process with the provided pid in a cgroup of its own
+// with the limits derived from the machine's specification, and records the
+// cgroup in the machine's status.  Confinement is skipped if it is disabled
+// via the configuration, the host does not support cgroup v2 or the cgroup
+// root is not writable by the user, e.g. as it has not been delegated to a
+// rootless user.  Unless confinement is required via the configuration, in
+// which case an error is returned, any other failure is only logged.
+func Confine(ctx context.Context, machine *machinev1alpha1.Machine, pid int32) error {
+	cfg := config.G[config.KraftKit](ctx)
+	if cfg.Cgroup.Disable || cfg.Cgroup.Root == "" {
+		return nil
+	}
+
+	if !Supported(cfg.Cgroup.Root) {
+		if cfg.Cgroup.Required {
+			return fmt.Errorf("cgroup v2 is unavailable at %s", cfg.Cgroup.Root)
+		}
+
+		log.G(ctx).
+			WithField("root", cfg.Cgroup.Root).
+			Debug("not confining vmm: cgroup v2 is unavailable")
+		return nil
+	}
+
+	dir := filepath.Join(cfg.Cgroup.Root, string(machine.UID))
+	limits := LimitsFromSpec(machine.Spec)
+
+	err := Create(dir, limits)
+	if err == nil {
+		if err = Attach(dir, int(pid)); err != nil {
+			_ = Remove(dir)
+			err = fmt.Errorf("could not attach vmm to cgroup: %w", err)
+		}
+	}
+
+	if err != nil {
+		if cfg.Cgroup.Required {
+			return err
+		}
+
+		entry := log.G(ctx).WithField("root", cfg.Cgroup.Root)
+		if errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS) {
+			entry.Debugf("not confining vmm: cgroup root is not writable: %v", err)
+		} else {
+			entry.Warnf("could not confine vmm: %v", err)
+		}
+
+		return nil
+	}
+
+	log.G(ctx).
+		WithField("cgroup", dir).
+		WithField("memory", limits.Memory).
+		WithField("cpu", limits.CPU).
+		Debug("confined vmm to cgroup")
+
+	machine.Status.Cgroup = dir
+
+	return nil
+}
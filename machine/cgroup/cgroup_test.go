@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package cgroup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+)
+
+func TestLimitsFromSpec(t *testing.T) {
+	spec := machinev1alpha1.MachineSpec{
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+				corev1.ResourceCPU:    resource.MustParse("2"),
+			},
+		},
+	}
+
+	limits := LimitsFromSpec(spec)
+
+	if expected := int64(64*1024*1024 + MemoryOverhead); limits.Memory != expected {
+		t.Errorf("expected memory limit of %d, got %d", expected, limits.Memory)
+	}
+
+	if limits.CPU != 2000 {
+		t.Errorf("expected cpu limit of 2000, got %d", limits.CPU)
+	}
+
+	if limits.Weight != 200 {
+		t.Errorf("expected weight of 200, got %d", limits.Weight)
+	}
+
+	if limits := LimitsFromSpec(machinev1alpha1.MachineSpec{}); limits != (Limits{}) {
+		t.Errorf("expected no limits without resources, got %+v", limits)
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, file := range []string{"memory.max", "cpu.max", "cpu.weight"} {
+		if err := os.WriteFile(filepath.Join(dir, file), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// io.weight is missing, as when the I/O scheduler does not support it.
+	if err := setLimits(dir, Limits{Memory: 1024, CPU: 500, Weight: 50}); err != nil {
+		t.Fatal(err)
+	}
+
+	for file, expected := range map[string]string{
+		"memory.max": "1024",
+		"cpu.max":    "50000 100000",
+		"cpu.weight": "50",
+	} {
+		raw, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(raw) != expected {
+			t.Errorf("expected %s to be %q, got %q", file, expected, raw)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+
+	for file, content := range map[string]string{
+		"memory.current": "4096\n",
+		"memory.max":     "max\n",
+		"memory.events":  "low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n",
+		"cpu.stat":       "usage_usec 1500000\nuser_usec 1000000\nsystem_usec 500000\nthrottled_usec 250\n",
+		"io.stat":        "8:0 rbytes=100 wbytes=200 rios=1 wios=2\n8:16 rbytes=10 wbytes=20 rios=1 wios=1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Stats{
+		MemoryCurrent: 4096,
+		OOMKills:      1,
+		CPUUsage:      1500 * time.Millisecond,
+		CPUThrottled:  250 * time.Microsecond,
+		IOReadBytes:   110,
+		IOWriteBytes:  220,
+	}
+
+	if *stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, *stats)
+	}
+}
+
+func TestConfineUnavailable(t *testing.T) {
+	hierarchy := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(hierarchy, "cgroup.controllers"), []byte("cpu io memory\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A regular file in place of the root, such that no cgroup can be created.
+	if err := os.WriteFile(filepath.Join(hierarchy, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A root which has not been delegated to the user.
+	readOnly := filepath.Join(hierarchy, "readonly")
+	if err := os.Mkdir(readOnly, 0o555); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		root      string
+		privilege bool
+	}{
+		{
+			name: "cgroup v2 unavailable",
+			root: filepath.Join(t.TempDir(), "kraftkit"),
+		},
+		{
+			name: "cgroup cannot be created",
+			root: filepath.Join(hierarchy, "file", "kraftkit"),
+		},
+		{
+			name:      "root is not writable",
+			root:      filepath.Join(readOnly, "kraftkit"),
+			privilege: true,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, required := range []bool{false, true} {
+			t.Run(tt.name, func(t *testing.T) {
+				// Permissions are not enforced for the superuser.
+				if tt.privilege && os.Geteuid() == 0 {
+					t.Skip("requires an unprivileged user")
+				}
+
+				cfg := &config.KraftKit{}
+				cfg.Cgroup.Root = tt.root
+				cfg.Cgroup.Required = required
+
+				cfgm, err := config.NewConfigManager(cfg)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				machine := &machinev1alpha1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						UID: "machine",
+					},
+				}
+
+				err = Confine(config.WithConfigManager(context.Background(), cfgm), machine, int32(os.Getpid()))
+				if required && err == nil {
+					t.Error("expected error when confinement is required")
+				} else if !required && err != nil {
+					t.Errorf("expected confinement to be skipped, got %v", err)
+				}
+
+				if machine.Status.Cgroup != "" {
+					t.Errorf("expected no cgroup, got %q", machine.Status.Cgroup)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,476 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package conformance provides a test suite which validates that an
+// implementation of the MachineV1alpha1Service behaves as expected by the rest
+// of KraftKit, such that new machine drivers can be validated uniformly.
+//
+// A driver is validated by invoking Run from a test of its own package:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, conformance.Driver{
+//			NewService: func(ctx context.Context) (machinev1alpha1.MachineService, error) {
+//				return NewMachineV1alpha1Service(ctx)
+//			},
+//			NewMachine: func(t *testing.T) *machinev1alpha1.Machine {
+//				return &machinev1alpha1.Machine{ ... }
+//			},
+//		})
+//	}
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// DefaultTimeout is the duration within which a driver is expected to complete
+// a state transition of a machine if the driver does not specify one.
+const DefaultTimeout = 30 * time.Second
+
+// pollInterval is the interval at which the state of a machine is checked
+// whilst waiting for a state transition.
+const pollInterval = 100 * time.Millisecond
+
+// Driver describes the machine driver which is validated by the suite.
+type Driver struct {
+	// NewService returns the service which is validated.  It is invoked once
+	// for every scenario.
+	NewService func(ctx context.Context) (machinev1alpha1.MachineService, error)
+
+	// NewMachine returns the specification of a machine which boots and keeps
+	// running until it is stopped.  It is invoked once for every machine which
+	// is created by the suite and must return a machine with a unique name.
+	NewMachine func(t *testing.T) *machinev1alpha1.Machine
+
+	// LogLine is a string which is expected to be contained in one of the lines
+	// which the machine prints to its console.  If empty, the logs scenario is
+	// skipped.
+	LogLine string
+
+	// Kill forcefully terminates the machine without the knowledge of the
+	// service, e.g. by killing the process of its VMM, to inject a failure.  If
+	// nil, the failure injection scenario is skipped.
+	Kill func(ctx context.Context, machine *machinev1alpha1.Machine) error
+
+	// SupportsPause indicates whether the driver can pause and resume a running
+	// machine.
+	SupportsPause bool
+
+	// Timeout is the duration within which every state transition of a machine
+	// is expected to complete.  If zero, DefaultTimeout is used.
+	Timeout time.Duration
+}
+
+// Run validates the driver against every scenario of the suite, each of which
+// is run as a subtest.
+func Run(t *testing.T, driver Driver) {
+	t.Helper()
+
+	if driver.NewService == nil || driver.NewMachine == nil {
+		t.Fatal("conformance: driver must provide NewService and NewMachine")
+	}
+
+	if driver.Timeout == 0 {
+		driver.Timeout = DefaultTimeout
+	}
+
+	for _, scenario := range []struct {
+		name string
+		run  func(*testing.T, *suite)
+	}{
+		{"Lifecycle", testLifecycle},
+		{"Pause", testPause},
+		{"List", testList},
+		{"Watch", testWatch},
+		{"Logs", testLogs},
+		{"Kill", testKill},
+		{"InvalidSpecification", testInvalidSpecification},
+		{"UnknownMachine", testUnknownMachine},
+	} {
+		scenario := scenario
+		t.Run(scenario.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			service, err := driver.NewService(ctx)
+			if err != nil {
+				t.Fatalf("could not instantiate service: %v", err)
+			}
+
+			scenario.run(t, &suite{
+				ctx:     ctx,
+				driver:  driver,
+				service: service,
+			})
+		})
+	}
+}
+
+// suite holds the state of a single scenario.
+type suite struct {
+	ctx     context.Context
+	driver  Driver
+	service machinev1alpha1.MachineService
+}
+
+// create creates a new machine and registers its removal once the scenario
+// has completed.
+func (s *suite) create(t *testing.T) *machinev1alpha1.Machine {
+	t.Helper()
+
+	machine, err := s.service.Create(s.ctx, s.driver.NewMachine(t))
+	if err != nil {
+		t.Fatalf("could not create machine: %v", err)
+	}
+
+	if machine == nil {
+		t.Fatal("Create returned no machine")
+	}
+
+	t.Cleanup(func() {
+		// The context of the scenario has been cancelled by the time the cleanup
+		// is run.
+		ctx, cancel := context.WithTimeout(context.Background(), s.driver.Timeout)
+		defer cancel()
+
+		if current, err := s.service.Get(ctx, machine); err == nil && current != nil {
+			machine = current
+		}
+
+		if machine.Status.State == machinev1alpha1.MachineStateRunning ||
+			machine.Status.State == machinev1alpha1.MachineStatePaused {
+			if _, err := s.service.Stop(ctx, machine); err != nil {
+				t.Logf("could not stop machine during cleanup: %v", err)
+			}
+		}
+
+		if _, err := s.service.Delete(ctx, machine); err != nil {
+			t.Logf("could not delete machine during cleanup: %v", err)
+		}
+	})
+
+	return machine
+}
+
+// start starts the machine and waits until it is running.
+func (s *suite) start(t *testing.T, machine *machinev1alpha1.Machine) *machinev1alpha1.Machine {
+	t.Helper()
+
+	started, err := s.service.Start(s.ctx, machine)
+	if err != nil {
+		t.Fatalf("could not start machine: %v", err)
+	}
+
+	return s.waitForState(t, started, machinev1alpha1.MachineStateRunning)
+}
+
+// waitForState polls the machine until it reaches one of the provided states
+// and fails the test if it does not within the timeout of the driver.
+func (s *suite) waitForState(t *testing.T, machine *machinev1alpha1.Machine, states ...machinev1alpha1.MachineState) *machinev1alpha1.Machine {
+	t.Helper()
+
+	deadline := time.Now().Add(s.driver.Timeout)
+
+	for {
+		current, err := s.service.Get(s.ctx, machine)
+		if err != nil {
+			t.Fatalf("could not get machine: %v", err)
+		}
+
+		for _, state := range states {
+			if current.Status.State == state {
+				return current
+			}
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected machine to be %s within %s, but it is %s",
+				joinStates(states), s.driver.Timeout, current.Status.State,
+			)
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// testLifecycle validates that a machine transitions through its states as it
+// is created, started, stopped and deleted.
+func testLifecycle(t *testing.T, s *suite) {
+	machine := s.create(t)
+
+	if machine.Status.State != machinev1alpha1.MachineStateCreated {
+		t.Errorf("expected machine to be %s after creation, but it is %s",
+			machinev1alpha1.MachineStateCreated, machine.Status.State,
+		)
+	}
+
+	got, err := s.service.Get(s.ctx, machine)
+	if err != nil {
+		t.Fatalf("could not get created machine: %v", err)
+	}
+
+	if got.Name != machine.Name {
+		t.Errorf("expected Get to return machine %s, got %s", machine.Name, got.Name)
+	}
+
+	machine = s.start(t, machine)
+
+	if machine.Status.StartedAt.IsZero() {
+		t.Error("expected the start time of a running machine to be set")
+	}
+
+	stopped, err := s.service.Stop(s.ctx, machine)
+	if err != nil {
+		t.Fatalf("could not stop machine: %v", err)
+	}
+
+	machine = s.waitForState(t, stopped, machinev1alpha1.MachineStateExited)
+
+	// Stopping a machine which has already exited must not fail or change its
+	// state.
+	if _, err := s.service.Stop(s.ctx, machine); err != nil {
+		t.Errorf("expected stopping an exited machine to succeed: %v", err)
+	}
+
+	machine = s.waitForState(t, machine, machinev1alpha1.MachineStateExited)
+
+	if _, err := s.service.Delete(s.ctx, machine); err != nil {
+		t.Fatalf("could not delete machine: %v", err)
+	}
+
+	if s.listed(t, machine) {
+		t.Error("expected a deleted machine not to be listed")
+	}
+}
+
+// testPause validates that a running machine can be paused and resumed.
+func testPause(t *testing.T, s *suite) {
+	if !s.driver.SupportsPause {
+		t.Skip("driver does not support pausing machines")
+	}
+
+	machine := s.start(t, s.create(t))
+
+	paused, err := s.service.Pause(s.ctx, machine)
+	if err != nil {
+		t.Fatalf("could not pause machine: %v", err)
+	}
+
+	machine = s.waitForState(t, paused, machinev1alpha1.MachineStatePaused)
+
+	resumed, err := s.service.Start(s.ctx, machine)
+	if err != nil {
+		t.Fatalf("could not resume machine: %v", err)
+	}
+
+	s.waitForState(t, resumed, machinev1alpha1.MachineStateRunning)
+}
+
+// testList validates that created machines are listed.
+func testList(t *testing.T, s *suite) {
+	first := s.create(t)
+	second := s.create(t)
+
+	if first.Name == second.Name {
+		t.Fatalf("expected NewMachine to return machines with unique names, got %s twice", first.Name)
+	}
+
+	for _, machine := range []*machinev1alpha1.Machine{first, second} {
+		if !s.listed(t, machine) {
+			t.Errorf("expected machine %s to be listed", machine.Name)
+		}
+	}
+}
+
+// listed returns whether the machine is part of the list of machines of the
+// service.
+func (s *suite) listed(t *testing.T, machine *machinev1alpha1.Machine) bool {
+	t.Helper()
+
+	list, err := s.service.List(s.ctx, &machinev1alpha1.MachineList{})
+	if err != nil {
+		t.Fatalf("could not list machines: %v", err)
+	}
+
+	for _, item := range list.Items {
+		if item.Name == machine.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// testWatch validates that the current state of a machine is reported when it
+// is watched and that subsequent state transitions are reported.
+func testWatch(t *testing.T, s *suite) {
+	machine := s.start(t, s.create(t))
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.driver.Timeout)
+	defer cancel()
+
+	events, errs, err := s.service.Watch(ctx, machine)
+	if err != nil {
+		t.Fatalf("could not watch machine: %v", err)
+	}
+
+	stopping := false
+	stopped := make(chan error, 1)
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("events channel was closed before the machine exited")
+			}
+
+			switch event.Status.State {
+			case machinev1alpha1.MachineStateRunning:
+				if !stopping {
+					stopping = true
+
+					// Stop the machine concurrently as the service may block until
+					// its events are received.
+					go func() {
+						_, err := s.service.Stop(s.ctx, machine)
+						stopped <- err
+					}()
+				}
+
+			case machinev1alpha1.MachineStateExited:
+				if !stopping {
+					t.Fatal("expected the current state of the machine to be reported first")
+				}
+
+				if err := <-stopped; err != nil {
+					t.Fatalf("could not stop machine: %v", err)
+				}
+
+				return
+			}
+
+		case err := <-errs:
+			if err != nil {
+				t.Fatalf("watching machine: %v", err)
+			}
+
+		case <-ctx.Done():
+			if !stopping {
+				t.Fatal("expected the current state of the machine to be reported")
+			}
+
+			t.Fatalf("expected the machine exiting to be reported within %s", s.driver.Timeout)
+		}
+	}
+}
+
+// testLogs validates that the console output of a machine is streamed.
+func testLogs(t *testing.T, s *suite) {
+	if s.driver.LogLine == "" {
+		t.Skip("driver does not specify an expected log line")
+	}
+
+	machine := s.start(t, s.create(t))
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.driver.Timeout)
+	defer cancel()
+
+	logs, errs, err := s.service.Logs(ctx, machine)
+	if err != nil {
+		t.Fatalf("could not stream logs of machine: %v", err)
+	}
+
+	for {
+		select {
+		case line, ok := <-logs:
+			if !ok {
+				t.Fatalf("logs were closed before %q was printed", s.driver.LogLine)
+			}
+
+			if strings.Contains(line, s.driver.LogLine) {
+				return
+			}
+
+		case err := <-errs:
+			if err != nil {
+				t.Fatalf("streaming logs of machine: %v", err)
+			}
+
+		case <-ctx.Done():
+			t.Fatalf("expected %q to be printed within %s", s.driver.LogLine, s.driver.Timeout)
+		}
+	}
+}
+
+// testKill validates that a machine which terminates without the knowledge of
+// the service is reported as no longer running.
+func testKill(t *testing.T, s *suite) {
+	if s.driver.Kill == nil {
+		t.Skip("driver does not support injecting failures")
+	}
+
+	machine := s.start(t, s.create(t))
+
+	if err := s.driver.Kill(s.ctx, machine); err != nil {
+		t.Fatalf("could not kill machine: %v", err)
+	}
+
+	machine = s.waitForState(t, machine,
+		machinev1alpha1.MachineStateExited,
+		machinev1alpha1.MachineStateFailed,
+		machinev1alpha1.MachineStateErrored,
+	)
+
+	if _, err := s.service.Delete(s.ctx, machine); err != nil {
+		t.Errorf("could not delete killed machine: %v", err)
+	}
+}
+
+// testInvalidSpecification validates that a machine without a kernel is
+// rejected.
+func testInvalidSpecification(t *testing.T, s *suite) {
+	machine := s.driver.NewMachine(t)
+	machine.Spec.Kernel = ""
+	machine.Status.KernelPath = ""
+
+	created, err := s.service.Create(s.ctx, machine)
+	if err == nil {
+		if created != nil {
+			_, _ = s.service.Delete(s.ctx, created)
+		}
+
+		t.Fatal("expected creating a machine without a kernel to fail")
+	}
+}
+
+// testUnknownMachine validates that operating on a machine which was never
+// created fails.
+func testUnknownMachine(t *testing.T, s *suite) {
+	machine := s.driver.NewMachine(t)
+	machine.ObjectMeta = metav1.ObjectMeta{
+		Name: fmt.Sprintf("conformance-unknown-%d", time.Now().UnixNano()),
+	}
+
+	if _, err := s.service.Start(s.ctx, machine); err == nil {
+		t.Error("expected starting an unknown machine to fail")
+	}
+}
+
+func joinStates(states []machinev1alpha1.MachineState) string {
+	ret := make([]string, len(states))
+	for i, state := range states {
+		ret[i] = state.String()
+	}
+
+	return strings.Join(ret, " or ")
+}
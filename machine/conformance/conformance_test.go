@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// fakeService is an in-memory machine service which behaves as the suite
+// expects of a driver.
+type fakeService struct {
+	mu       sync.Mutex
+	machines map[string]*machinev1alpha1.Machine
+}
+
+func (service *fakeService) lookup(machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	found, ok := service.machines[machine.Name]
+	if !ok {
+		return nil, fmt.Errorf("machine %s not found", machine.Name)
+	}
+
+	return found, nil
+}
+
+func (service *fakeService) transition(machine *machinev1alpha1.Machine, state machinev1alpha1.MachineState) (*machinev1alpha1.Machine, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	found, err := service.lookup(machine)
+	if err != nil {
+		return nil, err
+	}
+
+	found.Status.State = state
+	if state == machinev1alpha1.MachineStateRunning && found.Status.StartedAt.IsZero() {
+		found.Status.StartedAt = time.Now()
+	}
+
+	ret := *found
+	return &ret, nil
+}
+
+func (service *fakeService) Create(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	if machine.Spec.Kernel == "" {
+		return nil, fmt.Errorf("no kernel provided")
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	created := *machine
+	created.Status.State = machinev1alpha1.MachineStateCreated
+	service.machines[machine.Name] = &created
+
+	ret := created
+	return &ret, nil
+}
+
+func (service *fakeService) Start(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return service.transition(machine, machinev1alpha1.MachineStateRunning)
+}
+
+func (service *fakeService) Pause(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return service.transition(machine, machinev1alpha1.MachineStatePaused)
+}
+
+func (service *fakeService) Stop(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return service.transition(machine, machinev1alpha1.MachineStateExited)
+}
+
+func (service *fakeService) Update(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return machine, nil
+}
+
+func (service *fakeService) Delete(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	delete(service.machines, machine.Name)
+
+	return nil, nil
+}
+
+func (service *fakeService) Get(_ context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	found, err := service.lookup(machine)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := *found
+	return &ret, nil
+}
+
+func (service *fakeService) List(_ context.Context, machines *machinev1alpha1.MachineList) (*machinev1alpha1.MachineList, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	for _, machine := range service.machines {
+		machines.Items = append(machines.Items, *machine)
+	}
+
+	return machines, nil
+}
+
+func (service *fakeService) Watch(ctx context.Context, machine *machinev1alpha1.Machine) (chan *machinev1alpha1.Machine, chan error, error) {
+	events := make(chan *machinev1alpha1.Machine)
+	errs := make(chan error)
+
+	go func() {
+		var last machinev1alpha1.MachineState
+
+		for {
+			current, err := service.Get(ctx, machine)
+			if err != nil {
+				return
+			}
+
+			if current.Status.State != last {
+				last = current.Status.State
+
+				select {
+				case events <- current:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, errs, nil
+}
+
+func (service *fakeService) Logs(ctx context.Context, machine *machinev1alpha1.Machine) (chan string, chan error, error) {
+	if _, err := service.Get(ctx, machine); err != nil {
+		return nil, nil, err
+	}
+
+	logs := make(chan string, 2)
+	errs := make(chan error)
+
+	logs <- "Powered by Unikraft"
+	logs <- "Hello, world!"
+
+	return logs, errs, nil
+}
+
+func TestRun(t *testing.T) {
+	service := &fakeService{
+		machines: map[string]*machinev1alpha1.Machine{},
+	}
+
+	var mu sync.Mutex
+	count := 0
+
+	Run(t, Driver{
+		NewService: func(context.Context) (machinev1alpha1.MachineService, error) {
+			return service, nil
+		},
+		NewMachine: func(*testing.T) *machinev1alpha1.Machine {
+			mu.Lock()
+			defer mu.Unlock()
+
+			count++
+
+			return &machinev1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("machine-%d", count),
+				},
+				Spec: machinev1alpha1.MachineSpec{
+					Kernel: "kernel",
+				},
+			}
+		},
+		LogLine: "Hello, world!",
+		Kill: func(_ context.Context, machine *machinev1alpha1.Machine) error {
+			_, err := service.transition(machine, machinev1alpha1.MachineStateFailed)
+			return err
+		},
+		SupportsPause: true,
+		Timeout:       5 * time.Second,
+	})
+}
@@ -0,0 +1,303 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package diagnostics keeps the bundles which are captured when the guest of
+// a machine panics.  A bundle is a directory holding a dump of the memory of
+// the guest, the tail of its console output and a description of the crash.
+// Bundles are kept beneath the runtime directory rather than in the state
+// directory of the machine, such that they outlive removed machines, e.g.
+// those started with `kraft run --rm`.
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"kraftkit.sh/internal/logrotate"
+)
+
+const (
+	// DirName is the name of the directory beneath the runtime directory which
+	// holds the bundles of every machine.
+	DirName = "diagnostics"
+
+	// InfoFile is the name of the file in a bundle describing the crash.
+	InfoFile = "info.json"
+
+	// ConsoleFile is the name of the file in a bundle holding the tail of the
+	// console output of the guest.
+	ConsoleFile = "console.log"
+
+	// MemoryFile is the name of the file in a bundle holding the dump of the
+	// memory of the guest in the ELF core format.
+	MemoryFile = "memory.elf"
+
+	// SymbolsFile is the name of the file in a bundle holding a copy of the
+	// symbolic kernel of the guest, such that the addresses in its console
+	// output can be symbolized after the kernel is removed.
+	SymbolsFile = "kernel.dbg"
+
+	// DefaultConsoleSize is the number of bytes of console output which are
+	// kept in a bundle.
+	DefaultConsoleSize = 64 * 1024
+
+	// timeFormat is the format of the name of a bundle, which sorts
+	// chronologically.
+	timeFormat = "20060102T150405.000000000Z"
+)
+
+// Info describes the crash which a bundle was captured for.
+type Info struct {
+	// Machine is the name of the machine.
+	Machine string `json:"machine"`
+
+	// UID is the unique identifier of the machine.
+	UID string `json:"uid,omitempty"`
+
+	// Platform is the platform which ran the machine.
+	Platform string `json:"platform,omitempty"`
+
+	// Architecture is the architecture of the guest.
+	Architecture string `json:"architecture,omitempty"`
+
+	// Kernel is the path to the kernel of the guest on the host.
+	Kernel string `json:"kernel,omitempty"`
+
+	// Reason is the reason reported by the platform for the crash.
+	Reason string `json:"reason,omitempty"`
+
+	// CapturedAt is the time at which the bundle was captured.
+	CapturedAt time.Time `json:"capturedAt"`
+
+	// Memory is the name of the file holding the memory dump, which is empty if
+	// the memory could not be dumped.
+	Memory string `json:"memory,omitempty"`
+
+	// MemoryError is the reason the memory could not be dumped, if any.
+	MemoryError string `json:"memoryError,omitempty"`
+
+	// Symbols is the name of the file holding the symbolic kernel, which is
+	// empty if the kernel was not built or packaged with its symbols.
+	Symbols string `json:"symbols,omitempty"`
+}
+
+// Dir returns the directory beneath runtimeDir which holds the bundles of the
+// named machine.
+func Dir(runtimeDir, machine string) string {
+	return filepath.Join(runtimeDir, DirName, machine)
+}
+
+// New creates an empty bundle for the named machine and returns its path.
+func New(runtimeDir, machine string, at time.Time) (string, error) {
+	bundle := filepath.Join(Dir(runtimeDir, machine), at.UTC().Format(timeFormat))
+
+	if err := os.MkdirAll(bundle, 0o755); err != nil {
+		return "", fmt.Errorf("could not create diagnostics bundle: %w", err)
+	}
+
+	return bundle, nil
+}
+
+// Latest returns the path of the most recently captured bundle of the named
+// machine.
+func Latest(runtimeDir, machine string) (string, error) {
+	dir := Dir(runtimeDir, machine)
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("no diagnostics were captured for %s: %w", machine, os.ErrNotExist)
+	} else if err != nil {
+		return "", err
+	}
+
+	var bundles []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			bundles = append(bundles, entry.Name())
+		}
+	}
+
+	if len(bundles) == 0 {
+		return "", fmt.Errorf("no diagnostics were captured for %s: %w", machine, os.ErrNotExist)
+	}
+
+	sort.Strings(bundles)
+
+	return filepath.Join(dir, bundles[len(bundles)-1]), nil
+}
+
+// WriteInfo describes the crash in the bundle.
+func WriteInfo(bundle string, info Info) error {
+	b, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(bundle, InfoFile), b, 0o644)
+}
+
+// ReadInfo returns the description of the crash in the bundle.
+func ReadInfo(bundle string) (*Info, error) {
+	b, err := os.ReadFile(filepath.Join(bundle, InfoFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var info Info
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, fmt.Errorf("could not read diagnostics of %s: %w", bundle, err)
+	}
+
+	return &info, nil
+}
+
+// WriteConsole copies the last size bytes of the console log at the provided
+// path, including its rotated files, into the bundle.
+func WriteConsole(bundle, logFile string, size int64) error {
+	files, err := logrotate.Files(logFile)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(filepath.Join(bundle, ConsoleFile))
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	// Determine how much of each file is needed, starting from the newest.
+	offsets := make([]int64, len(files))
+	remaining := size
+
+	for i := len(files) - 1; i >= 0; i-- {
+		fi, err := os.Stat(files[i])
+		if err != nil {
+			return err
+		}
+
+		if fi.Size() >= remaining {
+			offsets[i] = fi.Size() - remaining
+			remaining = 0
+			files = files[i:]
+			offsets = offsets[i:]
+			break
+		}
+
+		remaining -= fi.Size()
+	}
+
+	for i, file := range files {
+		if err := copyFrom(out, file, offsets[i]); err != nil {
+			return err
+		}
+	}
+
+	return out.Close()
+}
+
+// WriteSymbols copies the symbolic kernel which accompanies the kernel at the
+// provided path, i.e. `kernel.dbg`, into the bundle and returns whether there
+// was any.
+func WriteSymbols(bundle, kernel string) (bool, error) {
+	if kernel == "" {
+		return false, nil
+	}
+
+	if _, err := os.Stat(kernel + ".dbg"); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	out, err := os.Create(filepath.Join(bundle, SymbolsFile))
+	if err != nil {
+		return false, err
+	}
+
+	defer out.Close()
+
+	if err := copyFrom(out, kernel+".dbg", 0); err != nil {
+		return false, err
+	}
+
+	return true, out.Close()
+}
+
+// copyFrom appends the file at the provided path from the offset onwards.
+func copyFrom(w io.Writer, path string, offset int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Archive writes the bundle as a gzip-compressed tarball whose entries are
+// placed beneath a directory with the provided name.
+func Archive(bundle, name string, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := filepath.WalkDir(bundle, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(bundle, path)
+		if err != nil {
+			return err
+		}
+
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = filepath.ToSlash(filepath.Join(name, rel))
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		return copyFrom(tw, path, 0)
+	})
+	if err != nil {
+		return fmt.Errorf("could not archive diagnostics: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package diagnostics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteConsole(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "machine.log")
+
+	// The rotated file holds the older output.
+	if err := os.WriteFile(logFile+".1", []byte("booting\npanic: "), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(logFile, []byte("out of memory\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		size     int64
+		expected string
+	}{
+		{
+			name:     "within the current file",
+			size:     4,
+			expected: "ory\n",
+		},
+		{
+			name:     "across rotated files",
+			size:     21,
+			expected: "panic: out of memory\n",
+		},
+		{
+			name:     "larger than the log",
+			size:     DefaultConsoleSize,
+			expected: "booting\npanic: out of memory\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bundle := t.TempDir()
+
+			if err := WriteConsole(bundle, logFile, tc.size); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := os.ReadFile(filepath.Join(bundle, ConsoleFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, string(b))
+			}
+		})
+	}
+}
+
+func TestLatest(t *testing.T) {
+	runtimeDir := t.TempDir()
+
+	if _, err := Latest(runtimeDir, "my-machine"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no bundle, got %v", err)
+	}
+
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := New(runtimeDir, "my-machine", first); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := New(runtimeDir, "my-machine", first.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Latest(runtimeDir, "my-machine")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
@@ -28,10 +28,14 @@ import (
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/config"
 	"kraftkit.sh/exec"
+	"kraftkit.sh/internal/logdriver"
 	"kraftkit.sh/internal/logtail"
 	"kraftkit.sh/internal/run"
 	"kraftkit.sh/log"
+	"kraftkit.sh/machine/cgroup"
+	mnetwork "kraftkit.sh/machine/network"
 	"kraftkit.sh/machine/network/macaddr"
+	"kraftkit.sh/machine/vmm"
 	"kraftkit.sh/unikraft/export/v0/posixenviron"
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
 	"kraftkit.sh/unikraft/export/v0/uknetdev"
@@ -48,11 +52,14 @@ const (
 type machineV1alpha1Service struct {
 	timeout time.Duration
 	debug   bool
+	logs    *logdriver.Drivers
 }
 
 // NewMachineV1alpha1Service implements mdriver.NewDriverConstructor
 func NewMachineV1alpha1Service(ctx context.Context, opts ...any) (machinev1alpha1.MachineService, error) {
-	service := machineV1alpha1Service{}
+	service := machineV1alpha1Service{
+		logs: logdriver.NewDrivers(),
+	}
 
 	for _, opt := range opts {
 		qopt, ok := opt.(MachineServiceV1alpha1Option)
@@ -87,6 +94,22 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, fmt.Errorf("cannot create firecracker instance with emulation")
 	}
 
+	if len(machine.Spec.VMM.CPUFeatures) > 0 {
+		return machine, fmt.Errorf("firecracker does not support individual CPU features: please select a CPU template with the CPU model instead")
+	}
+
+	if machine.Spec.Metrics {
+		return machine, fmt.Errorf("kraftkit does not yet support reading metrics from firecracker (contributions welcome): please use qemu instead")
+	}
+
+	switch machine.Spec.VMM.Accelerator {
+	case "", machinev1alpha1.MachineAcceleratorAuto, machinev1alpha1.MachineAcceleratorKVM:
+	default:
+		return machine, fmt.Errorf("cannot create firecracker instance with accelerator %s: only kvm is supported", machine.Spec.VMM.Accelerator)
+	}
+
+	machine.Status.Accelerator = machinev1alpha1.MachineAcceleratorKVM
+
 	if !cpuid.CPU.Rdrand() || !cpuid.CPU.Rdseed() {
 		log.G(ctx).Warn("RDRAND and RDSEED are not supported by the host CPU to be able to run Unikraft v0.17.0 and greater with hardware randomization")
 	}
@@ -107,7 +130,17 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 
 	// Set and create the log file for this machine
 	if len(machine.Status.LogFile) == 0 {
-		machine.Status.LogFile = filepath.Join(machine.Status.StateDir, "machine.log")
+		machine.Status.LogFile = logdriver.ConsoleLogFile(machine)
+	}
+
+	// Merge the layers of the initramfs, since the VMM accepts only one.
+	if len(machine.Status.InitrdLayers) > 0 {
+		initrdPath, err := vmm.MergeInitrdLayers(machine.Status.StateDir, machine.Status.InitrdLayers)
+		if err != nil {
+			return machine, err
+		}
+
+		machine.Status.InitrdPath = initrdPath
 	}
 
 	var fstab []string
@@ -148,6 +181,12 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		machine.Spec.Resources.Requests[corev1.ResourceCPU] = quantity
 	}
 
+	// Firecracker neither emulates an RTC on x86 nor allows hiding kvmclock from
+	// the guest.
+	if machine.Spec.Clock != (machinev1alpha1.MachineClock{}) {
+		log.G(ctx).Warn("clock settings are not supported by firecracker and are ignored")
+	}
+
 	fcLogFile := filepath.Join(machine.Status.StateDir, "firecracker.log")
 	fi, err := os.Create(fcLogFile)
 	if err != nil {
@@ -170,8 +209,9 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 
 	// If you fork and replace the stdout file descriptor with an fd of a log file
 	// and then execv firecracker, you don't have to care about collecting the
-	// logs
-	logFile, err := os.Create(machine.Status.LogFile)
+	// logs.  The log file is opened in append mode, such that it can be rotated
+	// by truncating it in place.
+	logFile, err := os.OpenFile(machine.Status.LogFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0o666)
 	if err != nil {
 		return machine, err
 	}
@@ -278,6 +318,10 @@ watch:
 		// Iterate over each interface of each network interface associated with
 		// this machine and attach it as a device.
 		for _, network := range machine.Spec.Networks {
+			if network.Driver == mnetwork.DriverUser {
+				return machine, fmt.Errorf("the %s network is not supported by firecracker: use qemu instead", mnetwork.DriverUser)
+			}
+
 			for _, iface := range network.Interfaces {
 				mac := iface.Spec.MacAddress
 				if mac == "" {
@@ -323,10 +367,21 @@ watch:
 	}
 	args = append(args, machine.Spec.ApplicationArgs...)
 
+	// Provide a source of entropy such that the guest does not block waiting
+	// for it, e.g. when seeding TLS libraries at boot.
+	if !machine.Spec.NoRNG {
+		if err := putEntropyDevice(ctx, fccfg.SocketPath); err != nil {
+			log.G(ctx).Warnf("could not attach entropy device, firecracker v1.4.0 or greater is required: %v", err)
+		}
+	}
+
 	// Set the machine's resource configuration.
 	if _, err := client.PutMachineConfiguration(ctx, &models.MachineConfiguration{
 		VcpuCount:  firecracker.Int64(machine.Spec.Resources.Requests.Cpu().Value()),
 		MemSizeMib: firecracker.Int64(machine.Spec.Resources.Requests.Memory().Value() / FirecrackerMemoryScale),
+		// The CPU template masks the features of the host CPU which are exposed
+		// to the guest, e.g. T2 to present those of an AWS T2 instance.
+		CPUTemplate: models.CPUTemplate(machine.Spec.VMM.CPUModel),
 	}); err != nil {
 		return machine, err
 	}
@@ -353,6 +408,12 @@ watch:
 	}
 
 	machine.Status.Pid = int32(pid)
+
+	if err := cgroup.Confine(ctx, machine, machine.Status.Pid); err != nil {
+		_ = process.Kill()
+		return machine, fmt.Errorf("could not confine firecracker process: %w", err)
+	}
+
 	machine.Status.State = machinev1alpha1.MachineStateCreated
 
 	return machine, nil
@@ -374,7 +435,7 @@ func getFirecrackerConfigFromPlatformConfig(platformConfig interface{}) (*Firecr
 
 // Update implements kraftkit.sh/api/machine/v1alpha1.MachineService
 func (service *machineV1alpha1Service) Update(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
-	panic("not implemented: kraftkit.sh/machine/firecracker.machineV1alpha1Service.Update")
+	return machine, fmt.Errorf("updating firecracker machines is not supported")
 }
 
 // Watch implements kraftkit.sh/api/machine/v1alpha1.MachineService
@@ -406,9 +467,25 @@ func (service *machineV1alpha1Service) watch(ctx context.Context, machine *machi
 				return
 			}
 
-			if state.Exited() {
+			if state.Exited() || !state.Success() {
+				var logPath string
+				if fccfg, err := getFirecrackerConfigFromPlatformConfig(machine.Status.PlatformConfig); err == nil {
+					logPath = fccfg.LogPath
+				}
+
+				exit := processExit(state, logPath)
+				exit.At = time.Now()
+
 				machine.Status.State = machinev1alpha1.MachineStateExited
-				machine.Status.ExitCode = state.ExitCode()
+				machine.Status.ExitCode = exit.Code
+				machine.Status.ExitReason = exit.Reason
+				machine.Status.ExitedAt = exit.At
+
+				// Firecracker is detached, such that its exit status is lost unless
+				// it is recorded.
+				if err := vmm.RecordExit(machine.Status.StateDir, exit); err != nil {
+					log.G(ctx).Debugf("could not record exit of %s: %v", machine.Name, err)
+				}
 			}
 
 			*events <- machine
@@ -433,8 +510,20 @@ func (service *machineV1alpha1Service) Start(ctx context.Context, machine *machi
 		return machine, err
 	}
 
+	// Forget how the machine previously exited, if it did.
+	if err := vmm.ClearExit(machine.Status.StateDir); err != nil {
+		log.G(ctx).Debugf("could not clear exit of %s: %v", machine.Name, err)
+	}
+
 	machine.Status.State = machinev1alpha1.MachineStateRunning
 	machine.Status.StartedAt = time.Now()
+	machine.Status.ExitReason = ""
+
+	// Logs are followed in the background, so work on a copy of the machine.
+	follow := *machine
+	service.logs.Start(ctx, machine, func(ctx context.Context) (chan string, chan error, error) {
+		return service.Logs(ctx, &follow)
+	})
 
 	return machine, nil
 }
@@ -454,6 +543,8 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 	state := machinev1alpha1.MachineStateUnknown
 	savedState := machine.Status.State
 
+	service.logs.Rotate(ctx, machine)
+
 	fccfg, err := getFirecrackerConfigFromPlatformConfig(machine.Status.PlatformConfig)
 	if err != nil {
 		return machine, err
@@ -480,22 +571,53 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		if err != nil {
 			state = machinev1alpha1.MachineStateExited
 		}
+
+		// The pid may have been re-assigned to an unrelated process after the
+		// VMM exited.
+		if activeProcess && !vmm.Owns(ctx, process.Pid, machine.Status.StateDir) {
+			activeProcess = false
+		}
 	}
 
 	exitedAt := machine.Status.ExitedAt
 	exitCode := machine.Status.ExitCode
+	exitReason := machine.Status.ExitReason
 
 	defer func() {
-		if exitCode >= 0 && machine.Status.ExitedAt.IsZero() {
+		// Prefer the exit which was recorded when Firecracker exited, or
+		// otherwise which it logged, over what can be derived from its absence.
+		if !activeProcess {
+			exit, err := vmm.RecordedExit(machine.Status.StateDir)
+			if err != nil {
+				log.G(ctx).Debugf("could not read exit of %s: %v", machine.Name, err)
+			}
+
+			if exit == nil {
+				exit, _ = firecrackerExitFromLog(fccfg.LogPath)
+			}
+
+			if exit != nil {
+				exitCode = exit.Code
+				exitReason = exit.Reason
+				if !exit.At.IsZero() {
+					exitedAt = exit.At
+				}
+
+				if exit.Reason == vmm.ExitReasonDeadlineExceeded {
+					state = machinev1alpha1.MachineStateFailed
+				}
+			}
+		}
+
+		if exitCode >= 0 && exitedAt.IsZero() {
 			exitedAt = time.Now()
 		}
 
 		// Update the machine config with the latest values if they are different from
 		// what we have on record
-		if machine.Status.ExitedAt != exitedAt || machine.Status.ExitCode != exitCode {
-			machine.Status.ExitedAt = exitedAt
-			machine.Status.ExitCode = exitCode
-		}
+		machine.Status.ExitedAt = exitedAt
+		machine.Status.ExitCode = exitCode
+		machine.Status.ExitReason = exitReason
 
 		// Set the start time to now if it was not previously set
 		if machine.Status.StartedAt.IsZero() && state == machinev1alpha1.MachineStateRunning {
@@ -573,17 +695,23 @@ func (service *machineV1alpha1Service) List(ctx context.Context, machines *machi
 
 // Stop implements kraftkit.sh/api/machine/v1alpha1.MachineService.Stop
 func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	service.logs.Stop(machine)
+
 	if machine.Status.State == machinev1alpha1.MachineStateExited {
 		return machine, nil
 	}
 
-	process, err := goprocess.NewProcess(machine.Status.Pid)
-	if err != nil {
-		return machine, err
-	}
+	// Never signal a process which is not the VMM of the machine, since the pid
+	// may have been re-assigned after the VMM exited.
+	if vmm.Owns(ctx, machine.Status.Pid, machine.Status.StateDir) {
+		process, err := goprocess.NewProcess(machine.Status.Pid)
+		if err != nil {
+			return machine, err
+		}
 
-	if err := process.Terminate(); err != nil {
-		return machine, err
+		if err := process.Terminate(); err != nil {
+			return machine, err
+		}
 	}
 
 	machine.Status.State = machinev1alpha1.MachineStateExited
@@ -599,11 +727,14 @@ func (service *machineV1alpha1Service) Delete(ctx context.Context, machine *mach
 		return machine, err
 	}
 
+	service.logs.Stop(machine)
+
 	var errs merr.Errors
 
-	errs = append(errs, os.Remove(machine.Status.LogFile))
+	errs = append(errs, logdriver.RemoveLogFile(machine))
 	errs = append(errs, os.Remove(fccfg.LogPath))
 	errs = append(errs, os.RemoveAll(machine.Status.StateDir))
+	errs = append(errs, cgroup.Remove(machine.Status.Cgroup))
 
 	return nil, errs.Err()
 }
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package firecracker
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"kraftkit.sh/machine/vmm"
+)
+
+// firecrackerExitCodeField precedes the exit code in the line which
+// Firecracker logs when it exits, e.g.:
+//
+//	2024-01-01T00:00:00.000000000 [anonymous-instance:main] Firecracker exiting successfully. exit_code=0
+const firecrackerExitCodeField = "exit_code="
+
+// firecrackerExitFromLog returns the exit which Firecracker logged to the file
+// at the provided path, if it logged one.  The reason is the message which
+// accompanies the exit code.
+func firecrackerExitFromLog(logPath string) (*vmm.Exit, bool) {
+	if logPath == "" {
+		return nil, false
+	}
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, false
+	}
+
+	defer f.Close()
+
+	var exit *vmm.Exit
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if e, ok := parseFirecrackerExit(scanner.Text()); ok {
+			exit = e
+		}
+	}
+
+	return exit, exit != nil
+}
+
+// parseFirecrackerExit returns the exit which the line of the Firecracker log
+// reports, if any.
+func parseFirecrackerExit(line string) (*vmm.Exit, bool) {
+	i := strings.LastIndex(line, firecrackerExitCodeField)
+	if i < 0 {
+		return nil, false
+	}
+
+	code, err := strconv.Atoi(strings.TrimSpace(line[i+len(firecrackerExitCodeField):]))
+	if err != nil {
+		return nil, false
+	}
+
+	reason := line[:i]
+	if j := strings.Index(reason, "] "); j >= 0 {
+		reason = reason[j+2:]
+	}
+
+	return &vmm.Exit{
+		Code:   code,
+		Reason: strings.TrimRight(strings.TrimSpace(reason), "."),
+	}, true
+}
+
+// processExit returns the exit of the Firecracker process with the provided
+// state, preferring the exit which Firecracker logged since it explains why
+// it exited.
+func processExit(state *os.ProcessState, logPath string) vmm.Exit {
+	if exit, ok := firecrackerExitFromLog(logPath); ok {
+		return *exit
+	}
+
+	exit := vmm.Exit{
+		Code:   state.ExitCode(),
+		Reason: state.String(),
+	}
+
+	// The process was terminated by a signal.
+	if exit.Code < 0 {
+		exit.Code = 1
+	}
+
+	return exit
+}
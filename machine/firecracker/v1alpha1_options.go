@@ -4,7 +4,11 @@
 // You may not use this file except in compliance with the License.
 package firecracker
 
-import "time"
+import (
+	"time"
+
+	"kraftkit.sh/internal/crilog"
+)
 
 // MachineServiceV1alpha1Option represents an option-method handler for the
 // machinev1alpha1 service.
@@ -26,3 +30,28 @@ func WithDebug(debug bool) MachineServiceV1alpha1Option {
 		return nil
 	}
 }
+
+// WithCRILog additionally writes the console output of each started machine
+// in the Kubernetes CRI log format to the file cri.log within the machine's
+// state directory, such that it can be consumed by log collectors without a
+// custom parser.  The output is written for as long as the process which
+// started the machine is alive.
+func WithCRILog(opts ...crilog.WriterOption) MachineServiceV1alpha1Option {
+	return func(service *machineV1alpha1Service) error {
+		service.logs.CRI = crilog.NewForwarder(opts...)
+		return nil
+	}
+}
+
+// WithLogRotation rotates the console log of a machine once it exceeds the
+// provided size, keeping at most the provided number of files including the
+// current one.  The log is rotated whenever the state of the machine is
+// retrieved and periodically whilst its output is followed by the process
+// which started it.  A size of 0 disables rotation.
+func WithLogRotation(maxSize int64, maxFiles int) MachineServiceV1alpha1Option {
+	return func(service *machineV1alpha1Service) error {
+		service.logs.MaxSize = maxSize
+		service.logs.MaxFiles = maxFiles
+		return nil
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/erikh/ping"
 	"github.com/vishvananda/netlink"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/internal/set"
 	"kraftkit.sh/machine/network/iputils"
 )
@@ -34,8 +36,10 @@ func BridgeIPs(bridge *netlink.Bridge) ([]string, error) {
 }
 
 // For a given IP network, bridge (and its interface), allocate a free IP
-// address.
-func AllocateIP(ctx context.Context, ipnet *net.IPNet, iface *net.Interface, bridge *netlink.Bridge) (net.IP, error) {
+// address from the provided pool, which is a range within the network.  The
+// excluded addresses, e.g. those which are reserved or already assigned, are
+// never allocated.
+func AllocateIP(ctx context.Context, ipnet, pool *net.IPNet, iface *net.Interface, bridge *netlink.Bridge, exclude ...string) (net.IP, error) {
 	bridgeAddrs, err := iface.Addrs()
 	if err != nil {
 		return nil, err
@@ -47,7 +51,8 @@ func AllocateIP(ctx context.Context, ipnet *net.IPNet, iface *net.Interface, bri
 	}
 
 	allocatedSet := set.NewStringSet(allocatedIps...)
-	ip := ipnet.IP
+	excludedSet := set.NewStringSet(exclude...)
+	ip := pool.IP.Mask(pool.Mask)
 
 search:
 	for {
@@ -60,10 +65,10 @@ search:
 		}
 
 		switch {
-		// If the IP is not within the provided network, it is not possible to
+		// If the IP is not within the provided pool, it is not possible to
 		// increment the IP so return with an error.
-		case !ipnet.Contains(ip):
-			return nil, fmt.Errorf("could not allocate IP address in %v", ipnet.String())
+		case !pool.Contains(ip) || !ipnet.Contains(ip):
+			return nil, fmt.Errorf("could not allocate IP address in %v", pool.String())
 
 		// Skip the Bridge IP.
 		case func() bool {
@@ -85,6 +90,10 @@ search:
 		case allocatedSet.Contains(ip.String()):
 			continue
 
+		// Skip excluded IP addresses.
+		case excludedSet.ContainsExactly(ip.String()):
+			continue
+
 		// Use ICMP to check if the IP is in use as a final sanity check.
 		case ping.Ping(&net.IPAddr{IP: ip, Zone: ""}, 150*time.Millisecond):
 			continue
@@ -96,3 +105,74 @@ search:
 
 	return ip, nil
 }
+
+// validateAddressing checks that the gateway, the IP range and the reservations
+// of the network are consistent with its subnet.
+func validateAddressing(spec networkv1alpha1.NetworkSpec) error {
+	gateway := net.ParseIP(spec.Gateway).To4()
+	if gateway == nil {
+		return fmt.Errorf("invalid gateway: %s", spec.Gateway)
+	}
+
+	netmask := net.ParseIP(spec.Netmask).To4()
+	if netmask == nil {
+		return fmt.Errorf("invalid netmask: %s", spec.Netmask)
+	}
+
+	subnet := &net.IPNet{
+		IP:   gateway.Mask(net.IPMask(netmask)),
+		Mask: net.IPMask(netmask),
+	}
+
+	if !iputils.IsUnicastIP(gateway, subnet.Mask) || gateway.Equal(subnet.IP) {
+		return fmt.Errorf("gateway %s is not a host address of %s", spec.Gateway, subnet.String())
+	}
+
+	if spec.IPRange != "" {
+		_, ipRange, err := net.ParseCIDR(spec.IPRange)
+		if err != nil {
+			return fmt.Errorf("invalid IP range: %w", err)
+		}
+
+		rangeSize, _ := ipRange.Mask.Size()
+		subnetSize, _ := subnet.Mask.Size()
+		if !subnet.Contains(ipRange.IP) || rangeSize < subnetSize {
+			return fmt.Errorf("IP range %s is not within %s", spec.IPRange, subnet.String())
+		}
+	}
+
+	machines := set.NewStringSet()
+	ips := set.NewStringSet()
+
+	for _, reservation := range spec.Reservations {
+		if reservation.Machine == "" {
+			return fmt.Errorf("cannot reserve %s without the name of a machine", reservation.IP)
+		}
+
+		ip := net.ParseIP(reservation.IP).To4()
+		if ip == nil {
+			return fmt.Errorf("invalid IP address reserved for %s: %s", reservation.Machine, reservation.IP)
+		}
+
+		if !subnet.Contains(ip) || !iputils.IsUnicastIP(ip, subnet.Mask) || ip.Equal(subnet.IP) {
+			return fmt.Errorf("IP address %s reserved for %s is not a host address of %s", reservation.IP, reservation.Machine, subnet.String())
+		}
+
+		if ip.Equal(gateway) {
+			return fmt.Errorf("IP address %s reserved for %s is the gateway", reservation.IP, reservation.Machine)
+		}
+
+		if machines.ContainsExactly(reservation.Machine) {
+			return fmt.Errorf("machine %s has more than one reserved IP address", reservation.Machine)
+		}
+
+		if ips.ContainsExactly(ip.String()) {
+			return fmt.Errorf("IP address %s is reserved more than once", reservation.IP)
+		}
+
+		machines.Add(reservation.Machine)
+		ips.Add(ip.String())
+	}
+
+	return nil
+}
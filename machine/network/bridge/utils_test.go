@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package bridge
+
+import (
+	"testing"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+func TestValidateAddressing(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		spec    networkv1alpha1.NetworkSpec
+		wantErr bool
+	}{
+		{
+			name: "gateway only",
+			spec: networkv1alpha1.NetworkSpec{Gateway: "172.30.0.1", Netmask: "255.255.255.0"},
+		},
+		{
+			name: "gateway at the end of the subnet",
+			spec: networkv1alpha1.NetworkSpec{Gateway: "172.30.0.254", Netmask: "255.255.255.0"},
+		},
+		{
+			name:    "gateway is the subnet address",
+			spec:    networkv1alpha1.NetworkSpec{Gateway: "172.30.0.0", Netmask: "255.255.255.0"},
+			wantErr: true,
+		},
+		{
+			name:    "gateway is the broadcast address",
+			spec:    networkv1alpha1.NetworkSpec{Gateway: "172.30.0.255", Netmask: "255.255.255.0"},
+			wantErr: true,
+		},
+		{
+			name: "range within the subnet",
+			spec: networkv1alpha1.NetworkSpec{Gateway: "172.30.0.1", Netmask: "255.255.255.0", IPRange: "172.30.0.128/25"},
+		},
+		{
+			name:    "range larger than the subnet",
+			spec:    networkv1alpha1.NetworkSpec{Gateway: "172.30.0.1", Netmask: "255.255.255.0", IPRange: "172.30.0.0/16"},
+			wantErr: true,
+		},
+		{
+			name:    "range outside the subnet",
+			spec:    networkv1alpha1.NetworkSpec{Gateway: "172.30.0.1", Netmask: "255.255.255.0", IPRange: "172.31.0.0/25"},
+			wantErr: true,
+		},
+		{
+			name: "reservations",
+			spec: networkv1alpha1.NetworkSpec{
+				Gateway: "172.30.0.1",
+				Netmask: "255.255.255.0",
+				Reservations: []networkv1alpha1.NetworkReservation{
+					{Machine: "db", IP: "172.30.0.10"},
+					{Machine: "web", IP: "172.30.0.100"},
+				},
+			},
+		},
+		{
+			name: "reservation of the gateway",
+			spec: networkv1alpha1.NetworkSpec{
+				Gateway:      "172.30.0.1",
+				Netmask:      "255.255.255.0",
+				Reservations: []networkv1alpha1.NetworkReservation{{Machine: "db", IP: "172.30.0.1"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "reservation outside the subnet",
+			spec: networkv1alpha1.NetworkSpec{
+				Gateway:      "172.30.0.1",
+				Netmask:      "255.255.255.0",
+				Reservations: []networkv1alpha1.NetworkReservation{{Machine: "db", IP: "172.30.1.10"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "address reserved twice",
+			spec: networkv1alpha1.NetworkSpec{
+				Gateway: "172.30.0.1",
+				Netmask: "255.255.255.0",
+				Reservations: []networkv1alpha1.NetworkReservation{
+					{Machine: "db", IP: "172.30.0.10"},
+					{Machine: "web", IP: "172.30.0.10"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "machine with two reservations",
+			spec: networkv1alpha1.NetworkSpec{
+				Gateway: "172.30.0.1",
+				Netmask: "255.255.255.0",
+				Reservations: []networkv1alpha1.NetworkReservation{
+					{Machine: "db", IP: "172.30.0.10"},
+					{Machine: "db", IP: "172.30.0.11"},
+				},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAddressing(tc.spec)
+			if tc.wantErr && err == nil {
+				t.Errorf("expected an error")
+			} else if !tc.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
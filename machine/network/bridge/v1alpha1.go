@@ -55,6 +55,9 @@ func (service *v1alpha1Network) Create(ctx context.Context, network *networkv1al
 	if len(network.Spec.Netmask) == 0 {
 		return nil, fmt.Errorf("netmask cannot be empty")
 	}
+	if err := validateAddressing(network.Spec); err != nil {
+		return nil, err
+	}
 
 	bridge := &netlink.Bridge{
 		LinkAttrs: netlink.NewLinkAttrs(),
@@ -293,6 +296,27 @@ func (service *v1alpha1Network) Update(ctx context.Context, network *networkv1al
 		Mask: mask,
 	}
 
+	// Addresses are allocated from the IP range of the network, if set.
+	pool := ipnet
+	if network.Spec.IPRange != "" {
+		_, pool, err = net.ParseCIDR(network.Spec.IPRange)
+		if err != nil {
+			return network, fmt.Errorf("could not parse IP range: %v", err)
+		}
+	}
+
+	// Never allocate the addresses which are reserved for machines or which are
+	// already assigned to other interfaces.
+	var exclude []string
+	for _, reservation := range network.Spec.Reservations {
+		exclude = append(exclude, reservation.IP)
+	}
+	for _, iface := range network.Spec.Interfaces {
+		if ip, _, err := net.ParseCIDR(iface.Spec.CIDR); err == nil {
+			exclude = append(exclude, ip.String())
+		}
+	}
+
 	// Start MAC addresses iteratively.
 	startMac, err := macaddr.GenerateMacAddress(true)
 	if err != nil {
@@ -356,11 +380,13 @@ func (service *v1alpha1Network) Update(ctx context.Context, network *networkv1al
 		}
 
 		if iface.Spec.CIDR == "" {
-			ip, err := AllocateIP(ctx, ipnet, bridgeface, bridge)
+			ip, err := AllocateIP(ctx, ipnet, pool, bridgeface, bridge, exclude...)
 			if err != nil {
 				return network, fmt.Errorf("could not allocate interface IP for %s: %v", iface.Spec.IfName, err)
 			}
 
+			exclude = append(exclude, ip.String())
+
 			sz, _ := net.IPMask(net.ParseIP(network.Spec.Netmask).To4()).Size()
 			iface.Spec.CIDR = fmt.Sprintf("%s/%d", ip.String(), sz)
 		}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package network
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+)
+
+// hostRoutes returns the destinations of the IPv4 routes of the host, besides
+// the default route.
+func hostRoutes() ([]net.IPNet, error) {
+	routes, err := netlink.RouteList(nil, netlink.FAMILY_V4)
+	if err != nil {
+		return nil, fmt.Errorf("could not list host routes: %w", err)
+	}
+
+	var ret []net.IPNet
+
+	for _, route := range routes {
+		if route.Dst == nil {
+			continue
+		}
+
+		if ones, _ := route.Dst.Mask.Size(); ones == 0 || route.Dst.IP.IsLoopback() {
+			continue
+		}
+
+		ret = append(ret, *route.Dst)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package network
+
+import "net"
+
+// hostRoutes returns the destinations of the IPv4 routes of the host.  Routes
+// are only inspected on Linux, elsewhere only the addresses of the host's
+// interfaces are considered.
+func hostRoutes() ([]net.IPNet, error) {
+	return nil, nil
+}
@@ -11,6 +11,7 @@ import (
 	zip "api.zip"
 	"github.com/acorn-io/baaah/pkg/merr"
 	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/store"
 )
 
 type networkV1alpha1ServiceIterator struct {
@@ -145,7 +146,11 @@ func (iterator *networkV1alpha1ServiceIterator) List(ctx context.Context, cached
 	found := []zip.Object[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus]{}
 
 	for _, strategy := range iterator.strategies {
-		ret, err := strategy.List(ctx, &networkv1alpha1.NetworkList{})
+		var ret *networkv1alpha1.NetworkList
+		err := store.Retry(ctx, func() (err error) {
+			ret, err = strategy.List(ctx, &networkv1alpha1.NetworkList{})
+			return err
+		})
 		if err != nil {
 			continue
 		}
@@ -8,6 +8,8 @@ package network
 import (
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
 )
@@ -32,12 +34,81 @@ var DefaultNetworkPool = []NetworkPoolEntry{
 	{"192.168.0.0/16", 20},
 }
 
-// FindFreeNetwork finds a free network in the pool.
-func FindFreeNetwork(pool NetworkPool, existingNetworks *networkapi.NetworkList) (*net.IPNet, error) {
-	convertedNetworks := []net.IPNet{}
+// ParseNetworkPool parses the entries of a network pool, each in the format
+// <subnet>:<size>, e.g. 10.10.0.0/16:24 to allocate /24 networks from
+// 10.10.0.0/16.
+func ParseNetworkPool(entries []string) (NetworkPool, error) {
+	var pool NetworkPool
+
+	for _, entry := range entries {
+		subnet, size, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid network pool entry '%s': expected <subnet>:<size>", entry)
+		}
+
+		_, ipnet, err := net.ParseCIDR(subnet)
+		if err != nil || ipnet.IP.To4() == nil {
+			return nil, fmt.Errorf("invalid network pool entry '%s': expected an IPv4 subnet in CIDR format", entry)
+		}
+
+		bits, err := strconv.Atoi(size)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network pool entry '%s': %w", entry, err)
+		}
+
+		ones, _ := ipnet.Mask.Size()
+		if bits < ones || bits > 30 {
+			return nil, fmt.Errorf("invalid network pool entry '%s': size must be between %d and 30", entry, ones)
+		}
+
+		pool = append(pool, NetworkPoolEntry{
+			Subnet: ipnet.String(),
+			Size:   bits,
+		})
+	}
+
+	return pool, nil
+}
+
+// HostNetworks returns the networks which are in use by the host, i.e. those
+// of its routes and of the addresses of its interfaces, such as those of the
+// LAN or of a VPN.  Networks allocated from a pool must not overlap with these
+// as the host would otherwise be unable to reach either of them.
+func HostNetworks() ([]net.IPNet, error) {
+	ret, err := hostRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("could not list addresses of host interfaces: %w", err)
+	}
+
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.To4() == nil || ipnet.IP.IsLoopback() {
+			continue
+		}
+
+		ret = append(ret, *ipnet)
+	}
+
+	return ret, nil
+}
+
+// FindFreeNetwork finds a free network in the pool which does not overlap with
+// any of the existing networks nor with the networks to avoid, e.g. those of
+// the host.
+func FindFreeNetwork(pool NetworkPool, existingNetworks *networkapi.NetworkList, avoid ...net.IPNet) (*net.IPNet, error) {
+	convertedNetworks := append([]net.IPNet{}, avoid...)
 
 	for _, network := range existingNetworks.Items {
 		maskBytes := net.ParseIP(network.Spec.Netmask).To4()
+		if maskBytes == nil {
+			continue
+		}
+
 		mask := net.IPv4Mask(maskBytes[0], maskBytes[1], maskBytes[2], maskBytes[3])
 		// Setup IP address for bridge.
 		convertedNetworks = append(convertedNetworks,
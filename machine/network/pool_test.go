@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package network
+
+import (
+	"net"
+	"testing"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+)
+
+func TestParseNetworkPool(t *testing.T) {
+	pool, err := ParseNetworkPool([]string{"10.10.0.0/16:24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pool) != 1 || pool[0].Subnet != "10.10.0.0/16" || pool[0].Size != 24 {
+		t.Errorf("unexpected pool: %v", pool)
+	}
+
+	for _, entry := range []string{
+		"10.10.0.0/16",
+		"10.10.0.0:24",
+		"10.10.0.0/16:8",
+		"10.10.0.0/16:32",
+		"fd00::/64:80",
+	} {
+		if _, err := ParseNetworkPool([]string{entry}); err == nil {
+			t.Errorf("expected an error for %s", entry)
+		}
+	}
+}
+
+func TestFindFreeNetworkAvoidsHostNetworks(t *testing.T) {
+	pool := NetworkPool{{"10.10.0.0/16", 24}}
+
+	existing := &networkapi.NetworkList{
+		Items: []networkapi.Network{{
+			Spec: networkapi.NetworkSpec{
+				Gateway: "10.10.0.1",
+				Netmask: "255.255.255.0",
+			},
+		}},
+	}
+
+	// E.g. the route of a VPN.
+	_, vpn, _ := net.ParseCIDR("10.10.1.0/24")
+
+	free, err := FindFreeNetwork(pool, existing, *vpn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := free.String(), "10.10.2.1/24"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	// A route which covers the whole pool leaves no network to allocate.
+	_, lan, _ := net.ParseCIDR("10.0.0.0/8")
+	if _, err := FindFreeNetwork(pool, existing, *lan); err == nil {
+		t.Errorf("expected an error")
+	}
+}
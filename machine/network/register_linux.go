@@ -6,14 +6,11 @@ package network
 
 import (
 	"context"
-	"path/filepath"
 
 	zip "api.zip"
 
 	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
-	"kraftkit.sh/config"
 	"kraftkit.sh/machine/network/bridge"
-	"kraftkit.sh/store"
 )
 
 var defaultStrategyName = "bridge"
@@ -29,12 +26,7 @@ func hostSupportedStrategies() map[string]*Strategy {
 					return nil, err
 				}
 
-				embeddedStore, err := store.NewEmbeddedStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus](
-					filepath.Join(
-						config.G[config.KraftKit](ctx).RuntimeDir,
-						"networkv1alpha1",
-					),
-				)
+				embeddedStore, err := storeV1alpha1(ctx)
 				if err != nil {
 					return nil, err
 				}
@@ -45,6 +37,7 @@ func hostSupportedStrategies() map[string]*Strategy {
 					zip.WithStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus](embeddedStore, zip.StoreRehydrationSpecNil),
 				)
 			},
+			NewDriverV1alpha1: bridge.NewNetworkServiceV1alpha1,
 		},
 	}
 }
@@ -24,6 +24,11 @@ var strategies = make(map[string]*Strategy)
 type Strategy struct {
 	Name               string
 	NewNetworkV1alpha1 NewStrategyConstructor[networkv1alpha1.NetworkService]
+
+	// NewDriverV1alpha1 instantiates the driver of the strategy without the
+	// store of networks, such that its changes can be stored atomically, see
+	// UpdateV1alpha1.
+	NewDriverV1alpha1 NewStrategyConstructor[networkv1alpha1.NetworkService]
 }
 
 // DefaultStrategyName return the name of the default strategy of the platform.
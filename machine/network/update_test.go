@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package network
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	zip "api.zip"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apiserver/pkg/storage"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/store"
+)
+
+// addressingDriver is a network driver which assigns the next free address to
+// each interface without one, as the bridge driver does.
+type addressingDriver struct {
+	networkapi.NetworkService
+}
+
+func (addressingDriver) Update(_ context.Context, network *networkapi.Network) (*networkapi.Network, error) {
+	used := map[string]bool{}
+	for _, iface := range network.Spec.Interfaces {
+		used[iface.Spec.CIDR] = true
+	}
+
+	for i, iface := range network.Spec.Interfaces {
+		if iface.Spec.CIDR != "" {
+			continue
+		}
+
+		for n := 2; ; n++ {
+			cidr := fmt.Sprintf("172.16.0.%d/24", n)
+			if !used[cidr] {
+				network.Spec.Interfaces[i].Spec.CIDR = cidr
+				used[cidr] = true
+				break
+			}
+		}
+	}
+
+	return network, nil
+}
+
+func TestUpdateV1alpha1Concurrent(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "networkv1alpha1")
+
+	newStore := func(context.Context) (zip.Store, error) {
+		return store.New[networkapi.NetworkSpec, networkapi.NetworkStatus](store.BackendBadger, path)
+	}
+
+	strategies := map[string]*Strategy{
+		"test": {
+			NewDriverV1alpha1: func(context.Context, ...any) (networkapi.NetworkService, error) {
+				return addressingDriver{}, nil
+			},
+		},
+	}
+
+	s, err := newStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network := &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "kraft0"},
+		Spec:       networkapi.NetworkSpec{Driver: "test"},
+	}
+	if err := s.Create(ctx, network.Name, network, network, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	const machines = 8
+
+	var wg sync.WaitGroup
+	errs := make(chan error, machines)
+
+	// Each machine is attached to the network from what would be a separate
+	// process, such that each would otherwise read the network before the
+	// others have written their interface.
+	for i := 0; i < machines; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := updateV1alpha1(ctx, newStore, strategies, network.Name, func(network *networkapi.Network) error {
+				network.Spec.Interfaces = append(network.Spec.Interfaces, networkapi.NetworkInterfaceTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{UID: uuid.NewUUID()},
+				})
+				return nil
+			})
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got networkapi.Network
+	if err := s.Get(ctx, network.Name, storage.GetOptions{}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Spec.Interfaces) != machines {
+		t.Fatalf("expected %d interfaces, got %d", machines, len(got.Spec.Interfaces))
+	}
+
+	seen := map[string]bool{}
+	for _, iface := range got.Spec.Interfaces {
+		if seen[iface.Spec.CIDR] {
+			t.Errorf("address %s is assigned more than once", iface.Spec.CIDR)
+		}
+		seen[iface.Spec.CIDR] = true
+	}
+}
+
+func TestUpdateV1alpha1UnsupportedDriver(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "networkv1alpha1")
+
+	newStore := func(context.Context) (zip.Store, error) {
+		return store.New[networkapi.NetworkSpec, networkapi.NetworkStatus](store.BackendBadger, path)
+	}
+
+	s, err := newStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network := &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "kraft0"},
+		Spec:       networkapi.NetworkSpec{Driver: "unknown"},
+	}
+	if err := s.Create(ctx, network.Name, network, network, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := updateV1alpha1(ctx, newStore, map[string]*Strategy{}, network.Name, func(*networkapi.Network) error {
+		t.Error("expected network of unknown driver not to be updated")
+		return nil
+	}); err == nil {
+		t.Error("expected error")
+	}
+
+	if _, err := updateV1alpha1(ctx, newStore, map[string]*Strategy{}, "missing", func(*networkapi.Network) error {
+		return nil
+	}); err == nil {
+		t.Error("expected error for missing network")
+	}
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package network
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+)
+
+const (
+	// DriverUser is the name of the user-mode network, which is implemented by
+	// the VMM of each machine rather than by a network driver.  It provides
+	// outbound connectivity and port forwarding without requiring privileges
+	// on the host, e.g. to create bridges or TAP devices.
+	DriverUser = "user"
+
+	// UserGateway is the address of the host as seen from machines on the
+	// user-mode network.
+	UserGateway = "10.0.2.2"
+
+	// UserDNS is the address of the DNS forwarder of the user-mode network.
+	UserDNS = "10.0.2.3"
+
+	// UserCIDR is the address assigned to machines on the user-mode network.
+	// Since every machine has its own user-mode network, each is assigned the
+	// same address.
+	UserCIDR = "10.0.2.15/24"
+
+	// UserSubnet is the subnet of the user-mode network.
+	UserSubnet = "10.0.2.0/24"
+)
+
+// NewUserNetworkSpec returns the specification of the user-mode network with
+// a single interface for a machine.
+func NewUserNetworkSpec() networkapi.NetworkSpec {
+	return networkapi.NetworkSpec{
+		Driver:  DriverUser,
+		Gateway: UserGateway,
+		Netmask: "255.255.255.0",
+		Interfaces: []networkapi.NetworkInterfaceTemplateSpec{{
+			ObjectMeta: metav1.ObjectMeta{
+				UID: uuid.NewUUID(),
+			},
+			Spec: networkapi.NetworkInterfaceSpec{
+				CIDR:    UserCIDR,
+				Gateway: UserGateway,
+				DNS0:    UserDNS,
+			},
+		}},
+	}
+}
@@ -204,6 +204,7 @@ func Detect(ctx context.Context) (Platform, SystemMode, error) {
 		qemu.QemuSystemX86,
 		qemu.QemuSystemArm,
 		qemu.QemuSystemAarch64,
+		qemu.QemuSystemRiscv64,
 		customBin,
 	} {
 		if _, err := exec.LookPath(bin); err != nil {
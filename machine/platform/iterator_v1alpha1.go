@@ -7,26 +7,46 @@ package platform
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"time"
 
 	zip "api.zip"
 	"github.com/acorn-io/baaah/pkg/merr"
 
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/eventlog"
+	"kraftkit.sh/log"
+	"kraftkit.sh/store"
 )
 
 type machineV1alpha1ServiceIterator struct {
 	strategies map[Platform]machinev1alpha1.MachineService
+	events     *eventlog.Log
+}
+
+// EventLog returns the history of machine state transitions which are
+// observed by the machine service iterator.
+func EventLog(ctx context.Context) *eventlog.Log {
+	return eventlog.New(
+		filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "events.jsonl"),
+		eventlog.DefaultCapacity,
+	)
 }
 
 // NewMachineV1alpha1ServiceIterator returns a
 // machinev1alpha1.MachineService-compatible implementation which iterates over
 // each supported host platform and calls the representing method.  This is
 // useful in circumstances where the platform is not supplied.  The first
-// platform strategy to succeed is returned in all circumstances.
+// platform strategy to succeed is returned in all circumstances.  Operations
+// which fail because the store is concurrently accessed by another process
+// are retried.  Every state transition of a machine which is observed through
+// the iterator is recorded in the history returned by EventLog.
 func NewMachineV1alpha1ServiceIterator(ctx context.Context) (machinev1alpha1.MachineService, error) {
 	var err error
 	iterator := machineV1alpha1ServiceIterator{
 		strategies: map[Platform]machinev1alpha1.MachineService{},
+		events:     EventLog(ctx),
 	}
 
 	for platform, strategy := range hostSupportedStrategies() {
@@ -50,6 +70,8 @@ func (iterator *machineV1alpha1ServiceIterator) Create(ctx context.Context, mach
 			continue
 		}
 
+		iterator.observe(ctx, ret)
+
 		return ret, nil
 	}
 
@@ -67,6 +89,8 @@ func (iterator *machineV1alpha1ServiceIterator) Start(ctx context.Context, machi
 			continue
 		}
 
+		iterator.observe(ctx, ret)
+
 		return ret, nil
 	}
 
@@ -84,6 +108,8 @@ func (iterator *machineV1alpha1ServiceIterator) Pause(ctx context.Context, machi
 			continue
 		}
 
+		iterator.observe(ctx, ret)
+
 		return ret, nil
 	}
 
@@ -101,6 +127,8 @@ func (iterator *machineV1alpha1ServiceIterator) Stop(ctx context.Context, machin
 			continue
 		}
 
+		iterator.observe(ctx, ret)
+
 		return ret, nil
 	}
 
@@ -112,12 +140,18 @@ func (iterator *machineV1alpha1ServiceIterator) Update(ctx context.Context, mach
 	var errs []error
 
 	for _, strategy := range iterator.strategies {
-		ret, err := strategy.Update(ctx, machine)
+		var ret *machinev1alpha1.Machine
+		err := store.Retry(ctx, func() (err error) {
+			ret, err = strategy.Update(ctx, machine)
+			return err
+		})
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
 
+		iterator.observe(ctx, ret)
+
 		return ret, nil
 	}
 
@@ -129,7 +163,11 @@ func (iterator *machineV1alpha1ServiceIterator) Delete(ctx context.Context, mach
 	var errs []error
 
 	for _, strategy := range iterator.strategies {
-		ret, err := strategy.Delete(ctx, machine)
+		var ret *machinev1alpha1.Machine
+		err := store.Retry(ctx, func() (err error) {
+			ret, err = strategy.Delete(ctx, machine)
+			return err
+		})
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -146,12 +184,18 @@ func (iterator *machineV1alpha1ServiceIterator) Get(ctx context.Context, machine
 	var errs []error
 
 	for _, strategy := range iterator.strategies {
-		ret, err := strategy.Get(ctx, machine)
+		var ret *machinev1alpha1.Machine
+		err := store.Retry(ctx, func() (err error) {
+			ret, err = strategy.Get(ctx, machine)
+			return err
+		})
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
 
+		iterator.observe(ctx, ret)
+
 		return ret, nil
 	}
 
@@ -163,7 +207,11 @@ func (iterator *machineV1alpha1ServiceIterator) List(ctx context.Context, cached
 	found := []zip.Object[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus]{}
 
 	for _, strategy := range iterator.strategies {
-		ret, err := strategy.List(ctx, &machinev1alpha1.MachineList{})
+		var ret *machinev1alpha1.MachineList
+		err := store.Retry(ctx, func() (err error) {
+			ret, err = strategy.List(ctx, &machinev1alpha1.MachineList{})
+			return err
+		})
 		if err != nil {
 			continue
 		}
@@ -173,6 +221,13 @@ func (iterator *machineV1alpha1ServiceIterator) List(ctx context.Context, cached
 
 	cached.Items = found
 
+	machines := make([]*machinev1alpha1.Machine, len(found))
+	for i := range found {
+		machines[i] = &found[i]
+	}
+
+	iterator.observe(ctx, machines...)
+
 	return cached, nil
 }
 
@@ -209,3 +264,49 @@ func (iterator *machineV1alpha1ServiceIterator) Logs(ctx context.Context, machin
 
 	return nil, nil, fmt.Errorf("all iterated platforms failed: %w", merr.NewErrors(errs...))
 }
+
+// observe records the state of the provided machines in the event history.
+// Failing to do so does not affect the operation which the machines were
+// returned by.
+func (iterator *machineV1alpha1ServiceIterator) observe(ctx context.Context, machines ...*machinev1alpha1.Machine) {
+	if iterator.events == nil {
+		return
+	}
+
+	observed := make([]eventlog.Event, 0, len(machines))
+
+	for _, machine := range machines {
+		if machine == nil || machine.UID == "" || machine.Status.State == "" {
+			continue
+		}
+
+		event := eventlog.Event{
+			Time:     time.Now(),
+			Machine:  machine.Name,
+			UID:      string(machine.UID),
+			Platform: machine.Spec.Platform,
+			State:    machine.Status.State.String(),
+		}
+
+		// Prefer the time at which the machine is known to have transitioned,
+		// since the transition may only be observed long after, e.g. when a
+		// machine has exited while nothing was watching it.
+		switch machine.Status.State {
+		case machinev1alpha1.MachineStateRunning:
+			if !machine.Status.StartedAt.IsZero() {
+				event.Time = machine.Status.StartedAt
+			}
+		case machinev1alpha1.MachineStateExited, machinev1alpha1.MachineStateFailed:
+			if !machine.Status.ExitedAt.IsZero() {
+				event.Time = machine.Status.ExitedAt
+			}
+			event.ExitCode = machine.Status.ExitCode
+		}
+
+		observed = append(observed, event)
+	}
+
+	if _, err := iterator.events.Observe(observed...); err != nil {
+		log.G(ctx).Debugf("could not record machine events: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package platform
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/vmm"
+)
+
+// ReconcileOptions customize the reconciliation of machines with the VMM
+// processes running on the host.
+type ReconcileOptions struct {
+	// DryRun only reports the drift without fixing it.
+	DryRun bool
+
+	// Grace is the time an orphaned VMM is given to exit after it has been
+	// asked to before it is killed.
+	Grace time.Duration
+
+	// MinAge is the age a VMM must have reached before it is considered to be
+	// orphaned, such that VMMs of machines which are still being created, and
+	// therefore not yet saved, are not killed.
+	MinAge time.Duration
+
+	// RuntimeDir is the directory which holds the state directories of
+	// machines.
+	RuntimeDir string
+}
+
+// ReconcileAction is a change performed, or which would be performed, by a
+// reconciliation.
+type ReconcileAction struct {
+	// Machine is the name of the machine whose state was corrected, or empty if
+	// the VMM is orphaned.
+	Machine string
+
+	// Pid is the pid of the VMM.
+	Pid int32
+
+	// From is the state which the machine was recorded in, or empty if the VMM
+	// is orphaned.
+	From machinev1alpha1.MachineState
+
+	// To is the state which the machine was corrected to, or empty if the VMM
+	// is orphaned.
+	To machinev1alpha1.MachineState
+
+	// StateDir is the state directory referenced by the VMM.
+	StateDir string
+
+	// Err is set if the action could not be performed.
+	Err error
+}
+
+// activeStates are the states of machines which have a running VMM.
+var activeStates = map[machinev1alpha1.MachineState]bool{
+	machinev1alpha1.MachineStateCreated:   true,
+	machinev1alpha1.MachineStateRunning:   true,
+	machinev1alpha1.MachineStatePaused:    true,
+	machinev1alpha1.MachineStateSuspended: true,
+}
+
+// Reconcile brings the recorded state of machines in line with the VMM
+// processes on the host.  Machines whose VMM has gone, or whose pid has been
+// re-assigned to an unrelated process, but which are recorded as active, and
+// vice versa, have their state refreshed via the controller, which persists
+// it.  VMMs which reference a state directory in the runtime directory that no
+// machine is recorded with are orphaned and are terminated.
+//
+// The controller must be able to list the machines of every platform, e.g. as
+// returned by NewMachineV1alpha1ServiceIterator, since VMMs of machines which
+// are not listed are terminated.
+func Reconcile(ctx context.Context, controller machinev1alpha1.MachineService, opts ReconcileOptions) ([]ReconcileAction, error) {
+	if opts.RuntimeDir == "" {
+		return nil, fmt.Errorf("runtime directory must be set")
+	}
+
+	machines, err := controller.List(ctx, &machinev1alpha1.MachineList{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list machines: %w", err)
+	}
+
+	processes, err := vmm.Find(ctx, opts.RuntimeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Not every driver records the pid of the VMM before the machine is
+	// started, so VMMs are also matched by the state directory they reference.
+	running := map[string]bool{}
+	for _, process := range processes {
+		running[filepath.Clean(process.StateDir)] = true
+	}
+
+	var actions []ReconcileAction
+	known := map[string]bool{}
+
+	for _, machine := range machines.Items {
+		stateDir := filepath.Clean(machine.Status.StateDir)
+		known[stateDir] = true
+
+		owned := running[stateDir] || vmm.Owns(ctx, machine.Status.Pid, stateDir)
+		active := activeStates[machine.Status.State]
+
+		if owned == active {
+			continue
+		}
+
+		action := ReconcileAction{
+			Machine:  machine.Name,
+			Pid:      machine.Status.Pid,
+			From:     machine.Status.State,
+			StateDir: stateDir,
+		}
+
+		if opts.DryRun {
+			if owned {
+				action.To = machinev1alpha1.MachineStateRunning
+			} else {
+				action.To = machinev1alpha1.MachineStateExited
+			}
+
+			actions = append(actions, action)
+			continue
+		}
+
+		log.G(ctx).
+			WithField("machine", machine.Name).
+			WithField("state", machine.Status.State).
+			Debug("refreshing state of machine which does not match its vmm")
+
+		refreshed, err := controller.Get(ctx, &machine)
+		if err != nil {
+			action.Err = fmt.Errorf("could not refresh state of %s: %w", machine.Name, err)
+		} else {
+			action.To = refreshed.Status.State
+		}
+
+		actions = append(actions, action)
+	}
+
+	for _, process := range processes {
+		if known[filepath.Clean(process.StateDir)] {
+			continue
+		}
+
+		if time.Since(process.CreatedAt) < opts.MinAge {
+			log.G(ctx).
+				WithField("pid", process.Pid).
+				Debug("skipping recently started vmm without a machine")
+			continue
+		}
+
+		action := ReconcileAction{
+			Pid:      process.Pid,
+			StateDir: process.StateDir,
+		}
+
+		if !opts.DryRun {
+			log.G(ctx).
+				WithField("pid", process.Pid).
+				WithField("state_dir", process.StateDir).
+				Debug("terminating orphaned vmm")
+
+			action.Err = vmm.Terminate(ctx, process.Pid, opts.Grace)
+		}
+
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
@@ -11,6 +11,7 @@ import (
 	zip "api.zip"
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/crilog"
 	"kraftkit.sh/internal/set"
 	"kraftkit.sh/machine/firecracker"
 	"kraftkit.sh/store"
@@ -20,12 +21,28 @@ var firecrackerV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev
 	if set.NewStringSet("debug", "trace").Contains(config.G[config.KraftKit](ctx).Log.Level) {
 		opts = append(opts, firecracker.WithDebug(true))
 	}
+
+	maxSize, maxFiles, err := logRotation(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, firecracker.WithLogRotation(maxSize, maxFiles))
+
+	if config.G[config.KraftKit](ctx).Log.CRI {
+		opts = append(opts, firecracker.WithCRILog(
+			crilog.WithMaxSize(maxSize),
+			crilog.WithMaxFiles(maxFiles),
+		))
+	}
+
 	service, err := firecracker.NewMachineV1alpha1Service(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	embeddedStore, err := store.NewEmbeddedStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](
+	embeddedStore, err := store.New[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](
+		store.Backend(config.G[config.KraftKit](ctx).StoreBackend),
 		filepath.Join(
 			config.G[config.KraftKit](ctx).RuntimeDir,
 			"machinev1alpha1",
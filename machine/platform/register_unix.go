@@ -9,23 +9,41 @@ package platform
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 
 	zip "api.zip"
+	"github.com/dustin/go-humanize"
 
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/crilog"
 	"kraftkit.sh/machine/qemu"
 	"kraftkit.sh/store"
 )
 
 var qemuV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev1alpha1.MachineService, error) {
+	maxSize, maxFiles, err := logRotation(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, qemu.WithLogRotation(maxSize, maxFiles))
+
+	if config.G[config.KraftKit](ctx).Log.CRI {
+		opts = append(opts, qemu.WithCRILog(
+			crilog.WithMaxSize(maxSize),
+			crilog.WithMaxFiles(maxFiles),
+		))
+	}
+
 	service, err := qemu.NewMachineV1alpha1Service(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	embeddedStore, err := store.NewEmbeddedStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](
+	embeddedStore, err := store.New[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](
+		store.Backend(config.G[config.KraftKit](ctx).StoreBackend),
 		filepath.Join(
 			config.G[config.KraftKit](ctx).RuntimeDir,
 			"machinev1alpha1",
@@ -43,6 +61,27 @@ var qemuV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev1alpha1
 	)
 }
 
+// logRotation returns the size after which the console log of a machine is
+// rotated and the number of files which are kept, as configured.
+func logRotation(ctx context.Context) (int64, int, error) {
+	var maxSize uint64
+	var err error
+
+	if size := config.G[config.KraftKit](ctx).Log.MaxSize; size != "" {
+		maxSize, err = humanize.ParseBytes(size)
+		if err != nil {
+			return 0, 0, fmt.Errorf("parsing maximum log size: %w", err)
+		}
+	}
+
+	maxFiles := config.G[config.KraftKit](ctx).Log.MaxFiles
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+
+	return int64(maxSize), maxFiles, nil
+}
+
 // hostSupportedStrategies returns the map of known supported drivers for the
 // given host.
 func hostSupportedStrategies() map[Platform]*Strategy {
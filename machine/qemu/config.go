@@ -9,7 +9,9 @@ import "kraftkit.sh/internal/run"
 type QemuConfig struct {
 	// Command-line arguments for qemu-system-*
 	Accel      QemuMachineAccelerator `flag:"-accel"       json:"accel,omitempty"`
+	Action     QemuAction             `flag:"-action"      json:"action,omitempty"`
 	Append     string                 `flag:"-append"      json:"append,omitempty"`
+	BIOS       string                 `flag:"-bios"        json:"bios,omitempty"`
 	CharDevs   []QemuCharDev          `flag:"-chardev"     json:"chardev,omitempty"`
 	CPU        QemuCPU                `flag:"-cpu"         json:"cpu,omitempty"`
 	Daemonize  bool                   `flag:"-daemonize"   json:"daemonize,omitempty"`
@@ -34,6 +36,9 @@ type QemuConfig struct {
 	PidFile    string                 `flag:"-pidfile"     json:"pidfile,omitempty"`
 	QMP        []QemuHostCharDev      `flag:"-qmp"         json:"qmp,omitempty"`
 	RTC        QemuRTC                `flag:"-rtc"         json:"rtc,omitempty"`
+	RunAs      string                 `flag:"-runas"       json:"runas,omitempty"`
+	RunWith    QemuRunWith            `flag:"-run-with"    json:"run_with,omitempty"`
+	Sandbox    QemuSandbox            `flag:"-sandbox"     json:"sandbox,omitempty"`
 	Serial     []QemuHostCharDev      `flag:"-serial"      json:"serial,omitempty"`
 	SMP        QemuSMP                `flag:"-smp"         json:"smp,omitempty"`
 	TBSize     int                    `flag:"-tb-size"     json:"tb_size,omitempty"`
@@ -69,6 +74,13 @@ func WithAccel(accel QemuMachineAccelerator) QemuOption {
 	}
 }
 
+func WithAction(action QemuAction) QemuOption {
+	return func(qc *QemuConfig) error {
+		qc.Action = action
+		return nil
+	}
+}
+
 func WithAppend(append ...string) QemuOption {
 	return func(qc *QemuConfig) error {
 		qc.Append = run.BootArgsPrepare(append...)
@@ -147,6 +159,17 @@ func WithInitRd(initrd string) QemuOption {
 	}
 }
 
+// QemuBIOSDefault selects the firmware which is shipped with QEMU for the
+// machine type, e.g. OpenSBI on RISC-V.
+const QemuBIOSDefault = "default"
+
+func WithBIOS(bios string) QemuOption {
+	return func(qc *QemuConfig) error {
+		qc.BIOS = bios
+		return nil
+	}
+}
+
 func WithKernel(kernel string) QemuOption {
 	return func(qc *QemuConfig) error {
 		qc.Kernel = kernel
@@ -270,6 +293,27 @@ func WithRTC(rtc QemuRTC) QemuOption {
 	}
 }
 
+func WithRunAs(user string) QemuOption {
+	return func(qc *QemuConfig) error {
+		qc.RunAs = user
+		return nil
+	}
+}
+
+func WithRunWith(runWith QemuRunWith) QemuOption {
+	return func(qc *QemuConfig) error {
+		qc.RunWith = runWith
+		return nil
+	}
+}
+
+func WithSandbox(sandbox QemuSandbox) QemuOption {
+	return func(qc *QemuConfig) error {
+		qc.Sandbox = sandbox
+		return nil
+	}
+}
+
 func WithSerial(chardev QemuHostCharDev) QemuOption {
 	return func(qc *QemuConfig) error {
 		if qc.Serial == nil {
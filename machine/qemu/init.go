@@ -23,7 +23,7 @@ func init() {
 	// gob.Register(QemuCharDevUdp{})
 	// gob.Register(QemuCharDevVirtualConsole{})
 	// gob.Register(QemuCharDevRingBuf{})
-	// gob.Register(QemuCharDevFile{})
+	gob.Register(QemuCharDevFile{})
 	// gob.Register(QemuCharDevPipe{})
 	// gob.Register(QemuCharDevPty{})
 	// gob.Register(QemuCharDevStdio{})
@@ -39,7 +39,7 @@ func init() {
 	// gob.Register(QemuHostCharDevPty{})
 	gob.Register(QemuHostCharDevNone{})
 	// gob.Register(QemuHostCharDevNull{})
-	// gob.Register(QemuHostCharDevNamed{})
+	gob.Register(QemuHostCharDevNamed{})
 	// gob.Register(QemuHostCharDevTty{})
 	gob.Register(QemuHostCharDevFile{})
 	// gob.Register(QemuHostCharDevStdio{})
@@ -240,12 +240,12 @@ func init() {
 	// gob.Register(QemuDeviceVirtioMouseDevice{})
 	// gob.Register(QemuDeviceVirtioMousePci{})
 	// gob.Register(QemuDeviceVirtioSerialDevice{})
-	// gob.Register(QemuDeviceVirtioSerialPci{})
+	gob.Register(QemuDeviceVirtioSerialPci{})
 	// gob.Register(QemuDeviceVirtioSerialPciNonTransitional{})
 	// gob.Register(QemuDeviceVirtioSerialPciTransitional{})
 	// gob.Register(QemuDeviceVirtioTabletDevice{})
 	// gob.Register(QemuDeviceVirtioTabletPci{})
-	// gob.Register(QemuDeviceVirtserialport{})
+	gob.Register(QemuDeviceVirtserialport{})
 
 	// Misc devices
 	// gob.Register(QemuDeviceAmdIommu{})
@@ -280,7 +280,7 @@ func init() {
 	// gob.Register(QemuDeviceVhostVsockPci{})
 	// gob.Register(QemuDeviceVhostVsockPciNonTransitional{})
 	// gob.Register(QemuDeviceVirtioBalloonDevice{})
-	// gob.Register(QemuDeviceVirtioBalloonPci{})
+	gob.Register(QemuDeviceVirtioBalloonPci{})
 	// gob.Register(QemuDeviceVirtioBalloonPciNonTransitional{})
 	// gob.Register(QemuDeviceVirtioBalloonPciTransitional{})
 	// gob.Register(QemuDeviceVirtioCryptoDevice{})
@@ -292,7 +292,7 @@ func init() {
 	// gob.Register(QemuDeviceVirtioMemPci{})
 	// gob.Register(QemuDeviceVirtioPmemPci{})
 	// gob.Register(QemuDeviceVirtioRngDevice{})
-	// gob.Register(QemuDeviceVirtioRngPci{})
+	gob.Register(QemuDeviceVirtioRngPci{})
 	// gob.Register(QemuDeviceVirtioRngPciNonTransitional{})
 	// gob.Register(QemuDeviceVirtioRngPciTransitional{})
 	// gob.Register(QemuDeviceVmcoreinfo{})
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	corev1 "k8s.io/api/core/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+)
+
+// userNetDev returns the network backend which implements the user-mode
+// network of the machine, forwarding the ports of the machine through it.
+// passt is preferred since it outperforms QEMU's built-in SLIRP stack, which
+// is used if passt is not installed or QEMU predates support for connecting to
+// it.
+func userNetDev(ctx context.Context, machine *machinev1alpha1.Machine, id string, qemuVersion *semver.Version) (QemuNetDev, error) {
+	passt, err := exec.LookPath("passt")
+	if err != nil || qemuVersion.LessThan(QemuVersion7_2_0) {
+		log.G(ctx).
+			WithField("machine", machine.Name).
+			Debug("using built-in slirp for user-mode network")
+
+		netdev := QemuNetDevUser{
+			Id:   id,
+			Net:  network.UserSubnet,
+			Host: network.UserGateway,
+		}
+
+		for _, port := range machine.Spec.Ports {
+			netdev.Hostfwds = append(netdev.Hostfwds, fmt.Sprintf("%s:%s:%d-:%d",
+				strings.ToLower(string(port.Protocol)),
+				port.HostIP,
+				port.HostPort,
+				port.MachinePort,
+			))
+		}
+
+		return netdev, nil
+	}
+
+	socket, err := startPasst(ctx, passt, machine)
+	if err != nil {
+		return nil, err
+	}
+
+	return QemuNetDevStream{
+		Id:   id,
+		Path: socket,
+	}, nil
+}
+
+// startPasst starts passt in the background for the machine and returns the
+// path of the socket which QEMU connects to.  passt exits once QEMU
+// disconnects, i.e. when the machine is stopped.
+func startPasst(ctx context.Context, passt string, machine *machinev1alpha1.Machine) (string, error) {
+	socket := filepath.Join(machine.Status.StateDir, "passt.sock")
+	address, mask, _ := strings.Cut(network.UserCIDR, "/")
+
+	args := []string{
+		"--one-off",
+		"--socket", socket,
+		"--pid", filepath.Join(machine.Status.StateDir, "passt.pid"),
+		"--address", address,
+		"--netmask", mask,
+		"--gateway", network.UserGateway,
+		"--dns-forward", network.UserDNS,
+	}
+
+	for _, port := range machine.Spec.Ports {
+		flag := "--tcp-ports"
+		if port.Protocol == corev1.ProtocolUDP || strings.EqualFold(string(port.Protocol), "udp") {
+			flag = "--udp-ports"
+		}
+
+		spec := fmt.Sprintf("%d:%d", port.HostPort, port.MachinePort)
+		if port.HostIP != "" {
+			spec = port.HostIP + "/" + spec
+		}
+
+		args = append(args, flag, spec)
+	}
+
+	log.G(ctx).
+		WithField("machine", machine.Name).
+		WithField("args", args).
+		Debug("starting passt for user-mode network")
+
+	// passt daemonizes itself once its socket is ready to be connected to.
+	out, err := exec.CommandContext(ctx, passt, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("could not start passt: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return socket, nil
+}
+
+// stopPasst terminates the passt process of the machine, if any, which is
+// necessary if QEMU never connected to it.
+func stopPasst(machine *machinev1alpha1.Machine) {
+	process, err := processFromPidFile(filepath.Join(machine.Status.StateDir, "passt.pid"))
+	if err != nil {
+		return
+	}
+
+	_ = process.Kill()
+}
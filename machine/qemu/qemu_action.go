@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+type QemuActionPanic string
+
+const (
+	QemuActionPanicPause       = QemuActionPanic("pause")
+	QemuActionPanicShutdown    = QemuActionPanic("shutdown")
+	QemuActionPanicExitFailure = QemuActionPanic("exit-failure")
+	QemuActionPanicNone        = QemuActionPanic("none")
+)
+
+// QemuAction sets how QEMU reacts to events of the guest.  It is supported
+// since QEMU 6.0.
+type QemuAction struct {
+	// Panic is the action which is taken when the guest panics.
+	Panic QemuActionPanic `json:"panic,omitempty"`
+}
+
+// String returns a QEMU command-line compatible -action flag value in the
+// format: panic=pause
+func (qa QemuAction) String() string {
+	if qa.Panic == "" {
+		return ""
+	}
+
+	return "panic=" + string(qa.Panic)
+}
@@ -0,0 +1,359 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/exec"
+	"kraftkit.sh/internal/lockedfile"
+	"kraftkit.sh/log"
+)
+
+// QemuCapabilitiesCacheFile is the name of the file in the runtime directory
+// which holds the capabilities of previously probed QEMU binaries.
+const QemuCapabilitiesCacheFile = "qemu-capabilities.json"
+
+// QemuCapabilities are the features supported by a QEMU binary.  Lists which
+// could not be determined are left empty, in which case the features they
+// contain are assumed to be supported such that QEMU itself reports on them.
+type QemuCapabilities struct {
+	// Bin is the absolute path to the probed QEMU binary.
+	Bin string `json:"bin"`
+
+	// Size and ModTime of the binary when it was probed, which invalidate the
+	// cached capabilities once the binary is replaced, e.g. when upgrading.
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+
+	// Version is the version of QEMU as reported by `-version`.
+	Version *semver.Version `json:"version"`
+
+	// Accelerators are the accelerators as reported by `-accel help`.
+	Accelerators []QemuMachineAccelerator `json:"accelerators,omitempty"`
+
+	// Machines are the machine types, including aliases, as reported by
+	// `-machine help`.
+	Machines []QemuMachineType `json:"machines,omitempty"`
+
+	// Devices are the device models, including aliases, as reported by
+	// `-device help`.
+	Devices []QemuDeviceType `json:"devices,omitempty"`
+}
+
+var (
+	qemuCapabilities   = map[string]*QemuCapabilities{}
+	qemuCapabilitiesMu sync.Mutex
+)
+
+// GetQemuCapabilitiesFromBin returns the capabilities of the provided QEMU
+// binary.  The binary is only probed once: the result is cached in memory and
+// in the runtime directory for as long as the binary is not modified.
+func GetQemuCapabilitiesFromBin(ctx context.Context, bin string) (*QemuCapabilities, error) {
+	path, err := osexec.LookPath(bin)
+	if err != nil {
+		return nil, fmt.Errorf("could not find QEMU binary: %w", err)
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not stat QEMU binary: %w", err)
+	}
+
+	qemuCapabilitiesMu.Lock()
+	defer qemuCapabilitiesMu.Unlock()
+
+	if caps, ok := qemuCapabilities[path]; ok && caps.matches(fi) {
+		return caps, nil
+	}
+
+	var cacheFile string
+	if runtimeDir := config.G[config.KraftKit](ctx).RuntimeDir; runtimeDir != "" {
+		cacheFile = filepath.Join(runtimeDir, QemuCapabilitiesCacheFile)
+	}
+
+	if cacheFile != "" {
+		if caps := readQemuCapabilities(cacheFile, path); caps != nil && caps.matches(fi) {
+			qemuCapabilities[path] = caps
+			return caps, nil
+		}
+	}
+
+	caps, err := probeQemuCapabilities(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	caps.Size = fi.Size()
+	caps.ModTime = fi.ModTime()
+	qemuCapabilities[path] = caps
+
+	if cacheFile != "" {
+		if err := writeQemuCapabilities(cacheFile, caps); err != nil {
+			log.G(ctx).
+				WithField("file", cacheFile).
+				Debugf("could not cache QEMU capabilities: %v", err)
+		}
+	}
+
+	return caps, nil
+}
+
+// probeQemuCapabilities executes the QEMU binary at the provided path to
+// determine its capabilities.  Only the version is required; the remaining
+// lists are left empty if they could not be determined.
+func probeQemuCapabilities(ctx context.Context, path string) (*QemuCapabilities, error) {
+	version, err := GetQemuVersionFromBin(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := &QemuCapabilities{
+		Bin:     path,
+		Version: version,
+	}
+
+	caps.Accelerators, err = GetQemuMachineAccelFromBin(ctx, path)
+	if err != nil {
+		log.G(ctx).
+			WithField("bin", path).
+			Debugf("could not determine QEMU accelerators: %v", err)
+	}
+
+	if out, err := qemuHelp(ctx, path, "-machine", "help"); err != nil {
+		log.G(ctx).
+			WithField("bin", path).
+			Debugf("could not determine QEMU machine types: %v", err)
+	} else {
+		caps.Machines = parseQemuMachineHelp(out)
+	}
+
+	if out, err := qemuHelp(ctx, path, "-device", "help"); err != nil {
+		log.G(ctx).
+			WithField("bin", path).
+			Debugf("could not determine QEMU devices: %v", err)
+	} else {
+		caps.Devices = parseQemuDeviceHelp(out)
+	}
+
+	return caps, nil
+}
+
+// qemuHelp returns the standard output of the QEMU binary when executed with
+// the provided arguments.
+func qemuHelp(ctx context.Context, bin string, args ...string) (string, error) {
+	var buf bytes.Buffer
+	stdout := bufio.NewWriter(&buf)
+
+	process, err := exec.NewProcess(bin, args,
+		exec.WithStdout(stdout),
+	)
+	if err != nil {
+		return "", fmt.Errorf("could not prepare QEMU process: %v", err)
+	}
+
+	if err := process.StartAndWait(ctx); err != nil {
+		return "", fmt.Errorf("could not start and wait for QEMU process: %v", err)
+	}
+
+	if err := stdout.Flush(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// parseQemuMachineHelp returns the machine types listed in the output of
+// `-machine help`, where each line after the header starts with the name of a
+// machine type followed by its description, which may reference an alias:
+//
+//	Supported machines are:
+//	pc                   Standard PC (i440FX + PIIX, 1996) (alias of pc-i440fx-8.2)
+//	pc-i440fx-8.2        Standard PC (i440FX + PIIX, 1996) (default)
+func parseQemuMachineHelp(out string) []QemuMachineType {
+	var machines []QemuMachineType
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasSuffix(line, ":") {
+			continue
+		}
+
+		machines = append(machines, QemuMachineType(fields[0]))
+	}
+
+	return machines
+}
+
+// parseQemuDeviceHelp returns the device models, including their aliases,
+// listed in the output of `-device help`:
+//
+//	Storage devices:
+//	name "virtio-9p-pci", bus PCI, alias "virtio-9p"
+func parseQemuDeviceHelp(out string) []QemuDeviceType {
+	var devices []QemuDeviceType
+
+	for _, line := range strings.Split(out, "\n") {
+		for _, field := range strings.Split(line, ",") {
+			field = strings.TrimSpace(field)
+
+			for _, prefix := range []string{"name ", "alias "} {
+				if !strings.HasPrefix(field, prefix) {
+					continue
+				}
+
+				name := strings.Trim(strings.TrimPrefix(field, prefix), `"`)
+				if name != "" {
+					devices = append(devices, QemuDeviceType(name))
+				}
+			}
+		}
+	}
+
+	return devices
+}
+
+// matches returns whether the capabilities were probed from the binary with
+// the provided file information.
+func (caps *QemuCapabilities) matches(fi os.FileInfo) bool {
+	return caps.Version != nil &&
+		caps.Size == fi.Size() &&
+		caps.ModTime.Equal(fi.ModTime())
+}
+
+// HasAccelerator returns whether QEMU supports the provided accelerator.
+func (caps *QemuCapabilities) HasAccelerator(accel QemuMachineAccelerator) bool {
+	if len(caps.Accelerators) == 0 {
+		return true
+	}
+
+	for _, a := range caps.Accelerators {
+		if a == accel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasMachine returns whether QEMU supports the provided machine type.
+func (caps *QemuCapabilities) HasMachine(machine QemuMachineType) bool {
+	if len(caps.Machines) == 0 {
+		return true
+	}
+
+	for _, m := range caps.Machines {
+		if m == machine {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasDevice returns whether QEMU supports the provided device model.
+func (caps *QemuCapabilities) HasDevice(device QemuDeviceType) bool {
+	if len(caps.Devices) == 0 {
+		return true
+	}
+
+	for _, d := range caps.Devices {
+		if d == device {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequireMachine returns an error explaining that the provided machine type
+// is required for the feature if QEMU does not support it.
+func (caps *QemuCapabilities) RequireMachine(machine QemuMachineType, feature string) error {
+	if caps.HasMachine(machine) {
+		return nil
+	}
+
+	return fmt.Errorf("%s requires the QEMU machine type %s which is not supported by %s (version %s): see `%s -machine help` for the supported machine types",
+		feature, machine, caps.Bin, caps.Version, caps.Bin,
+	)
+}
+
+// RequireDevice returns an error explaining that the provided device model is
+// required for the feature if QEMU does not support it, e.g. because it was
+// built without it.
+func (caps *QemuCapabilities) RequireDevice(device QemuDeviceType, feature string) error {
+	if caps.HasDevice(device) {
+		return nil
+	}
+
+	return fmt.Errorf("%s requires the QEMU device %s which is not supported by %s (version %s): install a QEMU build which includes it",
+		feature, device, caps.Bin, caps.Version,
+	)
+}
+
+// RequireVersion returns an error explaining that the provided version of
+// QEMU is required for the feature if QEMU is older.
+func (caps *QemuCapabilities) RequireVersion(version *semver.Version, feature string) error {
+	if !caps.Version.LessThan(version) {
+		return nil
+	}
+
+	return fmt.Errorf("%s requires QEMU %s or newer but %s is version %s: please upgrade to a newer version",
+		feature, version, caps.Bin, caps.Version,
+	)
+}
+
+// readQemuCapabilities returns the cached capabilities of the binary at the
+// provided path, or nil if there are none.
+func readQemuCapabilities(cacheFile, path string) *QemuCapabilities {
+	b, err := lockedfile.Read(cacheFile)
+	if err != nil {
+		return nil
+	}
+
+	var cache map[string]*QemuCapabilities
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return nil
+	}
+
+	return cache[path]
+}
+
+// writeQemuCapabilities stores the capabilities in the cache file, replacing
+// those previously cached for the same binary.
+func writeQemuCapabilities(cacheFile string, caps *QemuCapabilities) error {
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0o755); err != nil {
+		return err
+	}
+
+	return lockedfile.Transform(cacheFile, func(b []byte) ([]byte, error) {
+		cache := map[string]*QemuCapabilities{}
+		if len(b) > 0 {
+			// A corrupt cache is simply replaced.
+			_ = json.Unmarshal(b, &cache)
+		}
+
+		cache[caps.Bin] = caps
+
+		return json.MarshalIndent(cache, "", "  ")
+	})
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestParseQemuMachineHelp(t *testing.T) {
+	machines := parseQemuMachineHelp(`Supported machines are:
+microvm              microvm (i386)
+pc                   Standard PC (i440FX + PIIX, 1996) (alias of pc-i440fx-8.2)
+pc-i440fx-8.2        Standard PC (i440FX + PIIX, 1996) (default)
+none                 empty machine
+`)
+
+	caps := &QemuCapabilities{Machines: machines}
+
+	for _, want := range []QemuMachineType{QemuMachineTypeMicroVM, QemuMachineTypePC, "pc-i440fx-8.2", QemuMachineTypeNone} {
+		if !caps.HasMachine(want) {
+			t.Errorf("machine type %s not found in %v", want, machines)
+		}
+	}
+
+	if caps.HasMachine(QemuMachineTypeVirt) {
+		t.Errorf("unexpected machine type %s in %v", QemuMachineTypeVirt, machines)
+	}
+}
+
+func TestParseQemuDeviceHelp(t *testing.T) {
+	devices := parseQemuDeviceHelp(`Controller/Bridge/Hub devices:
+name "i82801b11-bridge", bus PCI
+
+Storage devices:
+name "virtio-9p-pci", bus PCI, alias "virtio-9p"
+
+Misc devices:
+name "virtio-rng-pci", bus PCI, alias "virtio-rng"
+`)
+
+	caps := &QemuCapabilities{
+		Bin:     "qemu-system-x86_64",
+		Version: semver.New(8, 2, 0, "", ""),
+		Devices: devices,
+	}
+
+	for _, want := range []QemuDeviceType{"i82801b11-bridge", QemuDeviceTypeVirtio9pPci, "virtio-9p", QemuDeviceTypeVirtioRngPci} {
+		if err := caps.RequireDevice(want, "test"); err != nil {
+			t.Errorf("device %s: %v", want, err)
+		}
+	}
+
+	err := caps.RequireDevice(QemuDeviceTypeVhostUserFsPci, "virtiofs volumes")
+	if err == nil || !strings.Contains(err.Error(), "vhost-user-fs-pci") {
+		t.Errorf("got %v, want an error naming the missing device", err)
+	}
+}
+
+func TestQemuCapabilitiesUnknown(t *testing.T) {
+	caps := &QemuCapabilities{}
+
+	if !caps.HasMachine(QemuMachineTypeVirt) || !caps.HasDevice(QemuDeviceTypeVirtio9pPci) || !caps.HasAccelerator(QemuMachineAccelKVM) {
+		t.Error("features of QEMU whose capabilities are unknown should be assumed supported")
+	}
+}
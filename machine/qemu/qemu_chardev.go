@@ -429,13 +429,14 @@ func (cd QemuCharDevRingBuf) String() string {
 type QemuCharDevFile struct {
 	Id        string
 	Path      string
+	Append    bool
 	Multiplex bool
 	LogFile   string
 	LogAppend bool
 }
 
 // String returns a QEMU command-line compatible chardev string with the format:
-// file,id=id,path=path[,mux=on|off][,logfile=PATH][,logappend=on|off]
+// file,id=id,path=path[,append=on][,mux=on|off][,logfile=PATH][,logappend=on|off]
 func (cd QemuCharDevFile) String() string {
 	if len(cd.Id) == 0 || len(cd.Path) == 0 {
 		// Cannot stringify character device without id or path
@@ -450,6 +451,10 @@ func (cd QemuCharDevFile) String() string {
 	ret.WriteString(",path=")
 	ret.WriteString(cd.Path)
 
+	if cd.Append {
+		ret.WriteString(",append=on")
+	}
+
 	if cd.Multiplex {
 		ret.WriteString(",mux=on")
 	} else {
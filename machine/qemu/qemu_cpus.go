@@ -29,17 +29,29 @@ func (cpu QemuCPU) String() string {
 
 	ret.WriteString(cpu.CPU.String())
 
-	if len(cpu.On) > 0 {
-		for _, on := range cpu.On {
+	// Only x86 CPUs accept the +feature and -feature notation, whereas the
+	// properties of other CPUs are set with feature=on and feature=off.
+	_, x86 := cpu.CPU.(QemuCPUX86)
+
+	for _, on := range cpu.On {
+		if x86 {
 			ret.WriteString(",+")
 			ret.WriteString(string(on))
+		} else {
+			ret.WriteString(",")
+			ret.WriteString(string(on))
+			ret.WriteString("=on")
 		}
 	}
 
-	if len(cpu.Off) > 0 {
-		for _, off := range cpu.Off {
+	for _, off := range cpu.Off {
+		if x86 {
 			ret.WriteString(",-")
 			ret.WriteString(string(off))
+		} else {
+			ret.WriteString(",")
+			ret.WriteString(string(off))
+			ret.WriteString("=off")
 		}
 	}
 
@@ -58,6 +70,18 @@ func (arm QemuCPUArm) String() string {
 	return string(arm)
 }
 
+type QemuCPURiscv string
+
+func (riscv QemuCPURiscv) String() string {
+	return string(riscv)
+}
+
+const (
+	QemuCPURiscvHost = QemuCPURiscv("host")
+	QemuCPURiscvMax  = QemuCPURiscv("max")
+	QemuCPURiscvRv64 = QemuCPURiscv("rv64")
+)
+
 const (
 	QemuCPUX86486                    = QemuCPUX86("486")
 	QemuCPUX86486V1                  = QemuCPUX86("486-v1")
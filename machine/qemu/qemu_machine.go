@@ -108,6 +108,17 @@ const (
 	QemuMachineOptAuto = QemuMachineOptOnOffAuto("auto")
 )
 
+// machineTypePCIe returns whether a PCI bus must be explicitly enabled for the
+// machine type, such that the PCI devices which are attached to machines can
+// be used.
+func machineTypePCIe(machineType QemuMachineType) QemuMachineOptOnOffAuto {
+	if machineType == QemuMachineTypeMicroVM {
+		return QemuMachineOptOn
+	}
+
+	return ""
+}
+
 type QemuMachine struct {
 	Type          QemuMachineType          `json:"type,omitempty"`
 	Accelerators  []QemuMachineAccelerator `json:"accelerator,omitempty"`
@@ -122,6 +133,10 @@ type QemuMachine struct {
 
 	// Added in QEMU 8.0.0
 	Graphics bool `json:"graphics,omitempty"`
+
+	// Only applicable to the microvm machine type, which otherwise lacks a PCI
+	// bus.
+	PCIe QemuMachineOptOnOffAuto `json:"pcie,omitempty"`
 }
 
 // String returns a QEMU command-line compatible -machine flag value
@@ -176,6 +191,10 @@ func (qm QemuMachine) String() string {
 	if qm.HMAT {
 		ret.WriteString(",hmat=on")
 	}
+	if string(qm.PCIe) != "" {
+		ret.WriteString(",pcie=")
+		ret.WriteString(string(qm.PCIe))
+	}
 
 	// Added in QEMU 8.0.0
 	if qm.HMAT {
@@ -5,6 +5,7 @@
 package qemu
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -58,3 +59,52 @@ func (qm QemuMemory) String() string {
 
 	return ret.String()
 }
+
+// qemuMemoryUnitScales are the multiples of the units of memory sizes.  QEMU
+// interprets them as binary multiples, i.e. M is a MiB.
+var qemuMemoryUnitScales = map[string]uint64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// Bytes returns the size of the memory in bytes.
+func (qm QemuMemory) Bytes() uint64 {
+	size := qm.Size
+	if size == 0 {
+		size = QemuMemoryDefault
+	}
+
+	unit := strings.ToUpper(string(qm.Unit))
+	if unit == "" {
+		unit = string(QemuMemoryUnitMB)
+	}
+
+	return size * qemuMemoryUnitScales[unit]
+}
+
+// MaxBytes returns the maximum size of the memory in bytes, which is the
+// maximum memory if memory hot-plug is configured and otherwise its size.
+func (qm QemuMemory) MaxBytes() (uint64, error) {
+	if qm.MaxMem == "" {
+		return qm.Bytes(), nil
+	}
+
+	value := strings.TrimSpace(qm.MaxMem)
+	unit := strings.ToUpper(strings.TrimLeft(value, "0123456789"))
+
+	scale, ok := qemuMemoryUnitScales[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit of maximum memory: %s", qm.MaxMem)
+	}
+
+	size, err := strconv.ParseUint(value[:len(value)-len(unit)], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse maximum memory: %w", err)
+	}
+
+	return size * scale, nil
+}
@@ -21,6 +21,7 @@ const (
 	QemuNetDevTypeHubport   = QemuNetDevType("hubport")
 	QemuNetDevTypeL2tpv3    = QemuNetDevType("l2tpv3")
 	QemuNetDevTypeSocket    = QemuNetDevType("socket")
+	QemuNetDevTypeStream    = QemuNetDevType("stream")
 	QemuNetDevTypeTap       = QemuNetDevType("tap")
 	QemuNetDevTypeUser      = QemuNetDevType("user")
 	QemuNetDevTypeVde       = QemuNetDevType("vde")
@@ -243,6 +244,39 @@ func (nd QemuNetDevSocket) String() string {
 	return ret.String()
 }
 
+// Configure a network backend which connects to a stream socket of another
+// process, e.g. passt, which implements the network.  Supported as of QEMU
+// 7.2.0.
+type QemuNetDevStream struct {
+	// ID of the network device.
+	Id string `json:"id,omitempty"`
+	// Listen on the socket rather than connecting to it.
+	Server bool `json:"server,omitempty"`
+	// Path of the UNIX socket.
+	Path string `json:"path,omitempty"`
+}
+
+// String returns a QEMU command-line compatible netdev string with the format:
+// stream,id=str,server=on|off,addr.type=unix,addr.path=path
+func (nd QemuNetDevStream) String() string {
+	var ret strings.Builder
+
+	ret.WriteString(string(QemuNetDevTypeStream))
+	ret.WriteString(",id=")
+	ret.WriteString(nd.Id)
+
+	if nd.Server {
+		ret.WriteString(",server=on")
+	} else {
+		ret.WriteString(",server=off")
+	}
+
+	ret.WriteString(",addr.type=unix,addr.path=")
+	ret.WriteString(nd.Path)
+
+	return ret.String()
+}
+
 // Configure a host TAP network backend
 type QemuNetDevTap struct {
 	// ID of the network device.
@@ -379,6 +413,9 @@ type QemuNetDevUser struct {
 	Guestfwd       string `json:"guestfwd,omitempty"`
 	Smb            string `json:"smb,omitempty"`
 	Smbserver      string `json:"smbserver,omitempty"`
+
+	// Additional forwarding rules, in the same format as Hostfwd.
+	Hostfwds []string `json:"hostfwds,omitempty"`
 }
 
 // String returns a QEMU command-line compatible netdev string with the format:
@@ -443,6 +480,10 @@ func (nd QemuNetDevUser) String() string {
 		ret.WriteString(",hostfwd=")
 		ret.WriteString(nd.Hostfwd)
 	}
+	for _, hostfwd := range nd.Hostfwds {
+		ret.WriteString(",hostfwd=")
+		ret.WriteString(hostfwd)
+	}
 	if len(nd.Guestfwd) > 0 {
 		ret.WriteString(",guestfwd=")
 		ret.WriteString(nd.Guestfwd)
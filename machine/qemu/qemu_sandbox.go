@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"strings"
+)
+
+type QemuSandboxAction string
+
+const (
+	QemuSandboxActionAllow    = QemuSandboxAction("allow")
+	QemuSandboxActionDeny     = QemuSandboxAction("deny")
+	QemuSandboxActionChildren = QemuSandboxAction("children")
+)
+
+// QemuSandbox enables the seccomp filter of QEMU, which restricts the system
+// calls the VMM is able to make.
+type QemuSandbox struct {
+	Enable bool `json:"enable,omitempty"`
+
+	// Obsolete denies system calls which are obsolete.
+	Obsolete QemuSandboxAction `json:"obsolete,omitempty"`
+
+	// ElevatePrivileges denies set*uid|gid system calls.  Set to children to
+	// permit QEMU to change its own user, e.g. via -runas, whilst preventing
+	// its children from gaining privileges.
+	ElevatePrivileges QemuSandboxAction `json:"elevateprivileges,omitempty"`
+
+	// Spawn denies fork and execve.
+	Spawn QemuSandboxAction `json:"spawn,omitempty"`
+
+	// ResourceControl denies process affinity and scheduler priority.
+	ResourceControl QemuSandboxAction `json:"resourcecontrol,omitempty"`
+}
+
+func (qs QemuSandbox) String() string {
+	if !qs.Enable {
+		return ""
+	}
+
+	var ret strings.Builder
+	ret.WriteString("on")
+
+	if qs.Obsolete != "" {
+		ret.WriteString(",obsolete=")
+		ret.WriteString(string(qs.Obsolete))
+	}
+	if qs.ElevatePrivileges != "" {
+		ret.WriteString(",elevateprivileges=")
+		ret.WriteString(string(qs.ElevatePrivileges))
+	}
+	if qs.Spawn != "" {
+		ret.WriteString(",spawn=")
+		ret.WriteString(string(qs.Spawn))
+	}
+	if qs.ResourceControl != "" {
+		ret.WriteString(",resourcecontrol=")
+		ret.WriteString(string(qs.ResourceControl))
+	}
+
+	return ret.String()
+}
+
+// QemuRunWith sets the properties of the QEMU process itself.  It supersedes
+// -runas as of QEMU 9.1.0.
+type QemuRunWith struct {
+	// User is the unprivileged user to which QEMU switches once it has
+	// initialised, dropping its privileges.
+	User string `json:"user,omitempty"`
+}
+
+func (qr QemuRunWith) String() string {
+	if qr.User == "" {
+		return ""
+	}
+
+	return "user=" + qr.User
+}
@@ -19,10 +19,12 @@ import (
 var (
 	QemuVersion4_2_0 = semver.New(4, 2, 0, "", "")
 	QemuVersion5_2_0 = semver.New(5, 2, 0, "", "")
+	QemuVersion6_0_0 = semver.New(6, 0, 0, "", "")
 	QemuVersion6_2_0 = semver.New(6, 2, 0, "", "")
 	QemuVersion7_2_0 = semver.New(7, 2, 0, "", "")
 	QemuVersion7_2_4 = semver.New(7, 2, 4, "", "")
 	QemuVersion8_0_0 = semver.New(8, 0, 0, "", "")
+	QemuVersion9_1_0 = semver.New(9, 1, 0, "", "")
 )
 
 // GetQemuVersionFromBin is direct method of accessing the version of the
@@ -3,7 +3,27 @@
 
 package qmpv7alpha2
 
+// ErrorResponse is returned in place of a successful response when a command
+// fails.
 type ErrorResponse struct {
 	Class       string `json:"class"`
-	Cescription string `json:"desc"`
+	Description string `json:"desc"`
+}
+
+// Error implements error by joining the non-empty class, desc fields.
+func (m *ErrorResponse) Error() string {
+	var msg string
+	if m.Class != "" {
+		if msg != "" {
+			msg += ": "
+		}
+		msg += m.Class
+	}
+	if m.Description != "" {
+		if msg != "" {
+			msg += ": "
+		}
+		msg += m.Description
+	}
+	return msg
 }
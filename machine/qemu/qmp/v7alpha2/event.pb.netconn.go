@@ -86,3 +86,15 @@ func EventTypes() []EventType {
 		EVENT_WATCHDOG,
 	}
 }
+
+type EventTimestamp struct {
+	Seconds      uint64 `json:"seconds"`
+	Microseconds uint64 `json:"microseconds"`
+}
+
+// An asynchronous event emitted by QEMU.
+type Event struct {
+	Event     EventType      `json:"event"`
+	Data      any            `json:"data"`
+	Timestamp EventTimestamp `json:"timestamp"`
+}
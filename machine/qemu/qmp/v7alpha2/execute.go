@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qmpv7alpha2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Execute sends an arbitrary QMP command, which is a JSON object with an
+// "execute" key and optional "arguments", and returns the value of the
+// "return" key of its response.  It allows issuing commands which are not
+// wrapped by the client.  An "id" key of the command is replaced, such that
+// the response can be correlated.
+func (c *QEMUMachineProtocolClient) Execute(ctx context.Context, command json.RawMessage) (json.RawMessage, error) {
+	var probe struct {
+		Execute string `json:"execute"`
+	}
+
+	if err := json.Unmarshal(command, &probe); err != nil {
+		return nil, fmt.Errorf("invalid QMP command: %w", err)
+	}
+
+	if probe.Execute == "" {
+		return nil, fmt.Errorf("invalid QMP command: missing \"execute\" key")
+	}
+
+	b, err := c.call(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res json.RawMessage
+	if _, err := c.codec.Lookup(b, "return", &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qmpv7alpha2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// serve answers each command received on conn with the response returned by
+// respond, carrying over the ID of the command.
+func serve(t *testing.T, conn net.Conn, respond func(command map[string]any) map[string]any) {
+	t.Helper()
+
+	go func() {
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			var command map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &command); err != nil {
+				return
+			}
+
+			res := respond(command)
+			res["id"] = command["id"]
+
+			b, _ := json.Marshal(res)
+			if _, err := conn.Write(append(b, '\n')); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func TestExecute(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	serve(t, server, func(command map[string]any) map[string]any {
+		switch command["execute"] {
+		case "query-status":
+			return map[string]any{"return": map[string]any{"status": "running"}}
+		default:
+			return map[string]any{"error": map[string]any{"class": "CommandNotFound", "desc": "unknown"}}
+		}
+	})
+
+	c := NewQEMUMachineProtocolClient(client, WithQEMUMachineProtocolClientTimeout(time.Second))
+	defer c.Close()
+
+	res, err := c.Execute(context.Background(), json.RawMessage(`{"execute": "query-status"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(res) != `{"status":"running"}` {
+		t.Errorf("unexpected result: %s", res)
+	}
+
+	_, err = c.Execute(context.Background(), json.RawMessage(`{"execute": "bogus"}`))
+
+	var qmpErr *ErrorResponse
+	if !errors.As(err, &qmpErr) || qmpErr.Class != "CommandNotFound" {
+		t.Errorf("expected a CommandNotFound error, got %v", err)
+	}
+}
+
+func TestExecuteInvalidCommand(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	c := NewQEMUMachineProtocolClient(client)
+	defer c.Close()
+
+	for _, command := range []string{`not json`, `{"arguments": {}}`} {
+		if _, err := c.Execute(context.Background(), json.RawMessage(command)); err == nil {
+			t.Errorf("%s: expected error", command)
+		}
+	}
+}
@@ -27,3 +27,37 @@ type SystemPowerdownRequest struct {
 type SystemWakeupRequest struct {
 	Execute string `json:"execute" default:"system_Wakeup"`
 }
+
+type BalloonRequest struct {
+	Execute string `json:"execute" default:"balloon"`
+
+	Arguments BalloonRequestArguments `json:"arguments,omitempty"`
+}
+
+type BalloonRequestArguments struct {
+	Value int64 `json:"value"`
+}
+
+type QueryBalloonRequest struct {
+	Execute string `json:"execute" default:"query-balloon"`
+}
+
+type BalloonInfo struct {
+	Actual int64 `json:"actual"`
+}
+
+type QueryBalloonResponse struct {
+	Return BalloonInfo `json:"return"`
+}
+
+type DumpGuestMemoryRequest struct {
+	Execute string `json:"execute" default:"dump-guest-memory"`
+
+	Arguments DumpGuestMemoryRequestArguments `json:"arguments,omitempty"`
+}
+
+type DumpGuestMemoryRequestArguments struct {
+	Paging   bool   `json:"paging"`
+	Protocol string `json:"protocol"`
+	Format   string `json:"format,omitempty"`
+}
@@ -5,1033 +5,2085 @@ package qmpv7alpha2
 
 import (
 	"bufio"
+	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type QEMUMachineProtocolClient struct {
-	conn io.ReadWriteCloser
-	lock sync.RWMutex
-	recv *bufio.Reader
-	send *bufio.Writer
+	conn    io.ReadWriteCloser
+	wlock   sync.Mutex
+	recv    *bufio.Reader
+	send    *bufio.Writer
+	codec   QEMUMachineProtocolCodec
+	timeout time.Duration
+	nextID  atomic.Uint64
+
+	// dial, retry and onConnect are only set for clients which were created
+	// from a dialer and therefore reconnect.
+	dial      func() (io.ReadWriteCloser, error)
+	retry     QEMUMachineProtocolClientRetryPolicy
+	onConnect func(*QEMUMachineProtocolClient) error
+	closing   chan struct{}
+
+	// mu guards the connection and the background reader's state below.
+	mu        sync.Mutex
+	closed    bool
+	readErr   error
+	responses chan []byte
+	pending   map[uint64]chan []byte
+	subs      map[chan []byte]<-chan struct{}
 }
 
-func NewQEMUMachineProtocolClient(conn io.ReadWriteCloser) *QEMUMachineProtocolClient {
-	return &QEMUMachineProtocolClient{
-		conn: conn,
-		recv: bufio.NewReader(conn),
-		send: bufio.NewWriter(conn),
-	}
+// QEMUMachineProtocolClientRetryPolicy determines how a client which was created from
+// a dialer re-establishes its connection.
+type QEMUMachineProtocolClientRetryPolicy struct {
+	// MaxAttempts is the maximum number of consecutive dial attempts or
+	// unlimited if zero.
+	MaxAttempts int
+
+	// Backoff is the initial delay between two dial attempts which doubles
+	// after every failed attempt.
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between two dial attempts if non-zero.
+	MaxBackoff time.Duration
 }
 
-func (c *QEMUMachineProtocolClient) Close() error {
-	return c.conn.Close()
+// DefaultQEMUMachineProtocolClientRetryPolicy is the retry policy used by clients
+// created from a dialer unless overridden.
+var DefaultQEMUMachineProtocolClientRetryPolicy = QEMUMachineProtocolClientRetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     100 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
 }
 
-func (c *QEMUMachineProtocolClient) setRpcRequestSetDefaults(face any) error {
-	v := reflect.ValueOf(face)
+// QEMUMachineProtocolClientOption is an option which configures a
+// QEMUMachineProtocolClient.
+type QEMUMachineProtocolClientOption func(*QEMUMachineProtocolClient)
 
-	// If it's an interface or a pointer, unwrap it.
-	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
-		v = v.Elem()
-	} else {
-		return nil
+// WithQEMUMachineProtocolClientTimeout sets the default timeout applied to every
+// call whose context does not already carry a deadline.
+func WithQEMUMachineProtocolClientTimeout(timeout time.Duration) QEMUMachineProtocolClientOption {
+	return func(c *QEMUMachineProtocolClient) {
+		c.timeout = timeout
 	}
+}
 
-	t := reflect.TypeOf(v.Interface())
-
-	for i := 0; i < v.NumField(); i++ {
-		def := t.Field(i).Tag.Get("default")
-		if def == "" {
-			continue
-		}
-
-		f := v.FieldByName(t.Field(i).Name)
-		if !f.IsValid() || !f.CanSet() {
-			continue
-		}
-
-		switch f.Kind() {
-		case reflect.String:
-			f.SetString(def)
-		default:
-			return fmt.Errorf("unsupported default kind: %s", f.Kind().String())
-		}
+// WithQEMUMachineProtocolClientCodec overrides the codec used to serialize and
+// frame messages.
+func WithQEMUMachineProtocolClientCodec(codec QEMUMachineProtocolCodec) QEMUMachineProtocolClientOption {
+	return func(c *QEMUMachineProtocolClient) {
+		c.codec = codec
 	}
-
-	return nil
 }
 
-func (c *QEMUMachineProtocolClient) Greeting() (*GreetingResponse, error) {
-	var b []byte
-	var err error
+// WithQEMUMachineProtocolClientRetryPolicy sets the policy used to re-establish the
+// connection of a client which was created from a dialer.
+func WithQEMUMachineProtocolClientRetryPolicy(policy QEMUMachineProtocolClientRetryPolicy) QEMUMachineProtocolClientOption {
+	return func(c *QEMUMachineProtocolClient) {
+		c.retry = policy
+	}
+}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+// WithQEMUMachineProtocolClientOnConnect sets a hook which is invoked every time a
+// client which was created from a dialer has (re-)established its connection,
+// e.g. to perform a handshake.  The connection is dropped if the hook fails.
+func WithQEMUMachineProtocolClientOnConnect(hook func(*QEMUMachineProtocolClient) error) QEMUMachineProtocolClientOption {
+	return func(c *QEMUMachineProtocolClient) {
+		c.onConnect = hook
+	}
+}
 
-	var res GreetingResponse
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
+func newQEMUMachineProtocolClient(opts ...QEMUMachineProtocolClientOption) *QEMUMachineProtocolClient {
+	c := &QEMUMachineProtocolClient{
+		codec:   QEMUMachineProtocolJSONLinesCodec{},
+		retry:   DefaultQEMUMachineProtocolClientRetryPolicy,
+		closing: make(chan struct{}),
+		pending: make(map[uint64]chan []byte),
+		subs:    make(map[chan []byte]<-chan struct{}),
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	return &res, nil
+	return c
 }
 
-func (c *QEMUMachineProtocolClient) Quit(req QuitRequest) (*QuitResponse, error) {
-	var b []byte
-	var err error
+func NewQEMUMachineProtocolClient(conn io.ReadWriteCloser, opts ...QEMUMachineProtocolClientOption) *QEMUMachineProtocolClient {
+	c := newQEMUMachineProtocolClient(opts...)
+	c.setConn(conn)
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	go c.readLoop()
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
-	}
+	return c
+}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
-	}
+// NewQEMUMachineProtocolClientFromDialer creates a client whose connection is
+// established with the dialer and re-established according to the client's
+// retry policy whenever it is lost, such that subscriptions survive restarts
+// of the peer.
+func NewQEMUMachineProtocolClientFromDialer(dial func() (io.ReadWriteCloser, error), opts ...QEMUMachineProtocolClientOption) (*QEMUMachineProtocolClient, error) {
+	c := newQEMUMachineProtocolClient(opts...)
+	c.dial = dial
 
-	var res QuitResponse
-	b, err = c.recv.ReadBytes('\n')
+	conn, err := c.redial()
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
-	}
 
-	return &res, nil
-}
+	c.setConn(conn)
 
-func (c *QEMUMachineProtocolClient) Stop(req StopRequest) (*any, error) {
-	var b []byte
-	var err error
+	go c.readLoop()
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
+	if c.onConnect != nil {
+		if err := c.onConnect(c); err != nil {
+			c.Close()
+			return nil, err
+		}
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
-	}
+	return c, nil
+}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+func (c *QEMUMachineProtocolClient) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
 	}
 
-	return &res, nil
+	c.closed = true
+	close(c.closing)
+	conn := c.conn
+	c.mu.Unlock()
+
+	return conn.Close()
 }
 
-func (c *QEMUMachineProtocolClient) Cont(req ContRequest) (*any, error) {
-	var b []byte
-	var err error
+// setConn installs a newly established connection.
+func (c *QEMUMachineProtocolClient) setConn(conn io.ReadWriteCloser) {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
-	}
+	c.conn = conn
+	c.recv = bufio.NewReader(conn)
+	c.send = bufio.NewWriter(conn)
+	c.responses = make(chan []byte, 1)
+	c.readErr = nil
+}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
-	}
+// redial invokes the dialer until it succeeds, the retry policy is exhausted
+// or the client is closed.
+func (c *QEMUMachineProtocolClient) redial() (io.ReadWriteCloser, error) {
+	backoff := c.retry.Backoff
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
-	}
+	for attempt := 1; ; attempt++ {
+		conn, err := c.dial()
+		if err == nil {
+			c.mu.Lock()
+			closed := c.closed
+			c.mu.Unlock()
 
-	return &res, nil
-}
+			if closed {
+				conn.Close()
+				return nil, io.ErrClosedPipe
+			}
 
-func (c *QEMUMachineProtocolClient) SystemReset(req SystemResetRequest) (*any, error) {
-	var b []byte
-	var err error
+			return conn, nil
+		}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+		if c.retry.MaxAttempts > 0 && attempt >= c.retry.MaxAttempts {
+			return nil, err
+		}
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
-	}
+		select {
+		case <-time.After(backoff):
+		case <-c.closing:
+			return nil, err
+		}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
+		backoff *= 2
+		if c.retry.MaxBackoff > 0 && backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
 	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
+}
+
+// reconnect re-establishes a lost connection if the client was created from a
+// dialer and reports whether it succeeded.
+func (c *QEMUMachineProtocolClient) reconnect() bool {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+
+	if c.dial == nil || closed {
+		return false
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
+	conn, err := c.redial()
 	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+		return false
 	}
 
-	return &res, nil
-}
+	c.setConn(conn)
 
-func (c *QEMUMachineProtocolClient) SystemPowerdown(req SystemPowerdownRequest) (*any, error) {
-	var b []byte
-	var err error
+	if c.onConnect != nil {
+		// The hook must run asynchronously as its calls are only answered once
+		// the background reader resumes.
+		go func() {
+			if err := c.onConnect(c); err != nil {
+				conn.Close()
+			}
+		}()
+	}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	return true
+}
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
+// withTimeout applies the client's default timeout to the context if it does
+// not already carry a deadline.
+func (c *QEMUMachineProtocolClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
-	}
+	return ctx, func() {}
+}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
+// ctxErr returns the context's error in favour of the provided I/O error if
+// the context is done, such that callers can detect cancellations and
+// timeouts.
+func (c *QEMUMachineProtocolClient) ctxErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+
+	// The deadline of the connection may expire just before the context's.
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
 	}
 
-	return &res, nil
+	return err
 }
 
-func (c *QEMUMachineProtocolClient) SystemWakeup(req SystemWakeupRequest) (*any, error) {
-	var b []byte
-	var err error
+// write sends a single message to the peer.  The deadline of the provided context
+// is applied to the underlying connection if it supports write deadlines and
+// any pending write is interrupted as soon as the context is done.
+func (c *QEMUMachineProtocolClient) write(ctx context.Context, b []byte) error {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	if wd, ok := c.conn.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		deadline, _ := ctx.Deadline()
+		_ = wd.SetWriteDeadline(deadline)
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
-	}
+		done := make(chan struct{})
+		stopped := make(chan struct{})
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
+		go func() {
+			defer close(stopped)
+
+			select {
+			case <-ctx.Done():
+				// Unblock any pending write immediately.
+				_ = wd.SetWriteDeadline(time.Unix(1, 0))
+			case <-done:
+			}
+		}()
+
+		defer func() {
+			close(done)
+			<-stopped
+			_ = wd.SetWriteDeadline(time.Time{})
+		}()
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
+	err := c.codec.WriteMessage(c.send, b)
+	if err == nil {
+		err = c.send.Flush()
+	}
 	if err != nil {
-		return nil, err
+		// The writer retains its error, which would fail all further writes.
+		c.send.Reset(c.conn)
+		return c.ctxErr(ctx, err)
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	return nil
+}
+
+// marshal applies the default values of the request and serializes it.
+func (c *QEMUMachineProtocolClient) marshal(req any) ([]byte, error) {
+	if err := c.setRpcRequestSetDefaults(req); err != nil {
 		return nil, err
 	}
 
-	return &res, nil
+	return c.codec.Marshal(req)
 }
 
-func (c *QEMUMachineProtocolClient) Capabilities(req CapabilitiesRequest) (*any, error) {
-	var b []byte
-	var err error
+// receive waits for the next synchronous response which is not correlated to
+// a specific request.
+func (c *QEMUMachineProtocolClient) receive(ctx context.Context) ([]byte, error) {
+	c.mu.Lock()
+	responses := c.responses
+	c.mu.Unlock()
+
+	select {
+	case b, ok := <-responses:
+		if !ok {
+			return nil, c.lastReadErr()
+		}
+		return b, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+// notify sends the request without waiting for a response.
+func (c *QEMUMachineProtocolClient) notify(ctx context.Context, req any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	b, err = json.Marshal(req)
+	b, err := c.marshal(req)
 	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
+		return err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.withRequestID(b, c.nextID.Add(1))
 	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+		return err
 	}
 
-	return &res, nil
+	return c.write(ctx, b)
 }
 
-func (c *QEMUMachineProtocolClient) QueryKvm(req QueryKvmRequest) (*QueryKvmResponse, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
+// call sends the request and waits for its response.  If the request is nil,
+// nothing is sent and the next unsolicited response is returned instead.
+func (c *QEMUMachineProtocolClient) call(ctx context.Context, req any) ([]byte, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
+	if req == nil {
+		return c.receive(ctx)
 	}
 
-	var res QueryKvmResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err := c.marshal(req)
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	id := c.nextID.Add(1)
+
+	b, err = c.withRequestID(b, id)
+	if err != nil {
 		return nil, err
 	}
 
-	return &res, nil
-}
+	res := make(chan []byte, 1)
 
-func (c *QEMUMachineProtocolClient) QueryStatus(req QueryStatusRequest) (*QueryStatusResponse, error) {
-	var b []byte
-	var err error
+	c.mu.Lock()
+	if c.readErr != nil {
+		c.mu.Unlock()
+		return nil, c.readErr
+	}
+	c.pending[id] = res
+	c.mu.Unlock()
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+	if err := c.write(ctx, b); err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
+	select {
+	case b, ok := <-res:
+		if !ok {
+			return nil, c.lastReadErr()
+		}
+		return b, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
+}
+
+// withRequestID sets the "id" key of the serialized request
+// such that its response can be correlated.
+func (c *QEMUMachineProtocolClient) withRequestID(b []byte, id uint64) ([]byte, error) {
+	return c.codec.Set(b, "id", id)
+}
+
+// responseID returns the value of the "id" key of a response.
+func (c *QEMUMachineProtocolClient) responseID(b []byte) (uint64, bool) {
+	var id uint64
+	if ok, err := c.codec.Lookup(b, "id", &id); !ok || err != nil {
+		return 0, false
 	}
 
-	var res QueryStatusResponse
-	b, err = c.recv.ReadBytes('\n')
+	return id, true
+}
+
+// responseError returns the ErrorResponse carried by the "error" key of
+// a response, if any.
+func (c *QEMUMachineProtocolClient) responseError(b []byte) error {
+	var res ErrorResponse
+	ok, err := c.codec.Lookup(b, "error", &res)
 	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+		return err
+	} else if !ok {
+		return nil
 	}
 
-	return &res, nil
+	return &res
 }
 
-func (c *QEMUMachineProtocolClient) SetLink(req SetLinkRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
+// isAsync determines whether the message is asynchronous, i.e. one which was
+// not sent in response to a call, by the presence of the "event" key.
+func (c *QEMUMachineProtocolClient) isAsync(b []byte) bool {
+	var event any
+	ok, err := c.codec.Lookup(b, "event", &event)
+	return ok && err == nil
+}
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
-	}
+// subscribe registers a subscriber which receives every asynchronous line
+// until the provided context is done.
+func (c *QEMUMachineProtocolClient) subscribe(ctx context.Context) chan []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
+	sub := make(chan []byte, 16)
+	if c.readErr != nil {
+		close(sub)
+		return sub
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
-	}
+	c.subs[sub] = ctx.Done()
 
-	return &res, nil
+	return sub
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddLegacyNic(req NetdevAddLegacyNicRequest) (*any, error) {
-	var b []byte
-	var err error
+// unsubscribe removes a previously registered subscriber.
+func (c *QEMUMachineProtocolClient) unsubscribe(sub chan []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
-	}
+	delete(c.subs, sub)
+}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
-	}
+// lastReadErr returns the error which stopped the background reader.
+func (c *QEMUMachineProtocolClient) lastReadErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+	if c.readErr == nil {
+		return io.EOF
 	}
 
-	return &res, nil
+	return c.readErr
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevUser(req NetdevAddDevUserRequest) (*any, error) {
-	var b []byte
-	var err error
+// disconnect fails all calls which are waiting for a response on the lost
+// connection.
+func (c *QEMUMachineProtocolClient) disconnect(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	c.readErr = err
+	close(c.responses)
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
+	for id, res := range c.pending {
+		close(res)
+		delete(c.pending, id)
 	}
+}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
-	}
+// shutdown ends all subscriptions once the connection cannot be
+// re-established.
+func (c *QEMUMachineProtocolClient) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+	for sub := range c.subs {
+		close(sub)
+		delete(c.subs, sub)
 	}
-
-	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevTap(req NetdevAddDevTapRequest) (*any, error) {
-	var b []byte
-	var err error
+// readLoop continuously reads messages from the connection, delivering
+// asynchronous messages to all subscribers and synchronous responses to the
+// call which is waiting for them.
+func (c *QEMUMachineProtocolClient) readLoop() {
+	for {
+		b, err := c.codec.ReadMessage(c.recv)
+		if err != nil {
+			c.disconnect(err)
+
+			if c.reconnect() {
+				continue
+			}
+
+			c.shutdown()
+			return
+		}
+
+		if c.isAsync(b) {
+			c.publish(b)
+			continue
+		}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+		if id, ok := c.responseID(b); ok {
+			c.mu.Lock()
+			res, ok := c.pending[id]
+			delete(c.pending, id)
+			c.mu.Unlock()
+
+			// Responses to notifications or to calls which stopped waiting are
+			// dropped.
+			if ok {
+				res <- b
+			}
+			continue
+		}
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
+		for {
+			select {
+			case c.responses <- b:
+			default:
+				// Replace a stale response nobody waited for.
+				select {
+				case <-c.responses:
+				default:
+				}
+				continue
+			}
+			break
+		}
 	}
+}
 
-	b, err = json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
+// publish delivers an asynchronous line to all subscribers.
+func (c *QEMUMachineProtocolClient) publish(b []byte) {
+	c.mu.Lock()
+	subs := make(map[chan []byte]<-chan struct{}, len(c.subs))
+	for sub, done := range c.subs {
+		subs[sub] = done
 	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
+	c.mu.Unlock()
+
+	for sub, done := range subs {
+		select {
+		case sub <- b:
+		case <-done:
+		}
 	}
+}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
+func (c *QEMUMachineProtocolClient) setRpcRequestSetDefaults(face any) error {
+	v := reflect.ValueOf(face)
+
+	// If it's an interface or a pointer, unwrap it.
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		v = v.Elem()
+	} else {
+		return nil
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+
+	t := reflect.TypeOf(v.Interface())
+
+	for i := 0; i < v.NumField(); i++ {
+		def := t.Field(i).Tag.Get("default")
+		if def == "" {
+			continue
+		}
+
+		f := v.FieldByName(t.Field(i).Name)
+		if !f.IsValid() || !f.CanSet() {
+			continue
+		}
+
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(def)
+		default:
+			return fmt.Errorf("unsupported default kind: %s", f.Kind().String())
+		}
 	}
 
-	return &res, nil
+	return nil
+}
+
+// QEMUMachineProtocolCodec serializes and frames the messages which are exchanged
+// with the peer.
+type QEMUMachineProtocolCodec interface {
+	// Marshal serializes the value into a single message.
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal deserializes a single message into the value.
+	Unmarshal(b []byte, v any) error
+
+	// Set returns the message with its top-level key set to the value.
+	Set(b []byte, key string, v any) ([]byte, error)
+
+	// Lookup deserializes the value of the message's top-level key into the
+	// value and reports whether the key is present.
+	Lookup(b []byte, key string, v any) (bool, error)
+
+	// ReadMessage reads the next framed message.
+	ReadMessage(r *bufio.Reader) ([]byte, error)
+
+	// WriteMessage writes a single framed message.
+	WriteMessage(w io.Writer, b []byte) error
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevL2TPv3(req NetdevAddDevL2TPv3Request) (*any, error) {
-	var b []byte
-	var err error
+// QEMUMachineProtocolJSONCodec implements the serialization methods of the
+// QEMUMachineProtocolCodec for JSON-encoded messages.
+type QEMUMachineProtocolJSONCodec struct{}
+
+func (QEMUMachineProtocolJSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+func (QEMUMachineProtocolJSONCodec) Unmarshal(b []byte, v any) error {
+	return json.Unmarshal(b, v)
+}
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (QEMUMachineProtocolJSONCodec) Set(b []byte, key string, v any) ([]byte, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
+	val, err := json.Marshal(v)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
-		return nil, err
-	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
+	raw[key] = val
+
+	return json.Marshal(raw)
+}
+
+func (QEMUMachineProtocolJSONCodec) Lookup(b []byte, key string, v any) (bool, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return false, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
+
+	val, ok := raw[key]
+	if !ok {
+		return false, nil
 	}
 
-	return &res, nil
+	return true, json.Unmarshal(val, v)
+}
+
+// QEMUMachineProtocolJSONLinesCodec frames JSON-encoded messages by terminating
+// each with a newline.
+type QEMUMachineProtocolJSONLinesCodec struct {
+	QEMUMachineProtocolJSONCodec
+}
+
+func (QEMUMachineProtocolJSONLinesCodec) ReadMessage(r *bufio.Reader) ([]byte, error) {
+	return r.ReadBytes('\n')
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevSocket(req NetdevAddDevSocketRequest) (*any, error) {
-	var b []byte
-	var err error
+func (QEMUMachineProtocolJSONLinesCodec) WriteMessage(w io.Writer, b []byte) error {
+	_, err := w.Write(append(b, '\x0a'))
+	return err
+}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+// QEMUMachineProtocolLengthPrefixedJSONCodec frames JSON-encoded messages by
+// prefixing each with its length as a 32-bit big-endian unsigned integer.
+type QEMUMachineProtocolLengthPrefixedJSONCodec struct {
+	QEMUMachineProtocolJSONCodec
+}
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (QEMUMachineProtocolLengthPrefixedJSONCodec) ReadMessage(r *bufio.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
+
+	return b, nil
+}
+
+func (QEMUMachineProtocolLengthPrefixedJSONCodec) WriteMessage(w io.Writer, b []byte) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(b))); err != nil {
+		return err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	_, err := w.Write(b)
+	return err
+}
+
+func (c *QEMUMachineProtocolClient) Greeting(ctx context.Context) (*GreetingResponse, error) {
+	b, err := c.call(ctx, nil)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res GreetingResponse
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevStream(req NetdevAddDevStreamRequest) (*any, error) {
-	var b []byte
-	var err error
+func (c *QEMUMachineProtocolClient) SubscribeEvents(ctx context.Context) (<-chan *Event, <-chan error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	sub := c.subscribe(ctx)
+
+	events := make(chan *Event)
+	errs := make(chan error)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+		defer c.unsubscribe(sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case b, ok := <-sub:
+				if !ok {
+					select {
+					case errs <- c.lastReadErr():
+					case <-ctx.Done():
+					}
+					return
+				}
+
+				var event Event
+				if err := c.codec.Unmarshal(b, &event); err != nil {
+					select {
+					case errs <- err:
+					case <-ctx.Done():
+						return
+					}
+					continue
+				}
+
+				select {
+				case events <- &event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	return events, errs, nil
+}
 
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) Quit(ctx context.Context, req QuitRequest) (*QuitResponse, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+
+	var res QuitResponse
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) Stop(ctx context.Context, req StopRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevDgram(req NetdevAddDevDgramRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
-	}
-
-	b, err = json.Marshal(req)
+func (c *QEMUMachineProtocolClient) Cont(ctx context.Context, req ContRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
+
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevVde(req NetdevAddDevVdeRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
-	}
-
-	b, err = json.Marshal(req)
+func (c *QEMUMachineProtocolClient) SystemReset(ctx context.Context, req SystemResetRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
+
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevBridge(req NetdevAddDevBridgeRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return nil, err
-	}
-
-	b, err = json.Marshal(req)
+func (c *QEMUMachineProtocolClient) SystemPowerdown(ctx context.Context, req SystemPowerdownRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
-	}
-	if err := c.send.Flush(); err != nil {
+
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevHubPort(req NetdevAddDevHubPortRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) SystemWakeup(ctx context.Context, req SystemWakeupRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) Capabilities(ctx context.Context, req CapabilitiesRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevNetmap(req NetdevAddDevNetmapRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) QueryKvm(ctx context.Context, req QueryKvmRequest) (*QueryKvmResponse, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+
+	var res QueryKvmResponse
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) QueryStatus(ctx context.Context, req QueryStatusRequest) (*QueryStatusResponse, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res QueryStatusResponse
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevVhostUser(req NetdevAddDevVhostUserRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) Balloon(ctx context.Context, req BalloonRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) QueryBalloon(ctx context.Context, req QueryBalloonRequest) (*QueryBalloonResponse, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res QueryBalloonResponse
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevVhostVDPA(req NetdevAddDevVhostVDPARequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) DumpGuestMemory(ctx context.Context, req DumpGuestMemoryRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) SetLink(ctx context.Context, req SetLinkRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetHost(req NetdevAddDevVmnetHostRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) NetdevAddLegacyNic(ctx context.Context, req NetdevAddLegacyNicRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevUser(ctx context.Context, req NetdevAddDevUserRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetShared(req NetdevAddDevVmnetSharedRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) NetdevAddDevTap(ctx context.Context, req NetdevAddDevTapRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevL2TPv3(ctx context.Context, req NetdevAddDevL2TPv3Request) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetBridged(req NetdevAddDevVmnetBridgedRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) NetdevAddDevSocket(ctx context.Context, req NetdevAddDevSocketRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevStream(ctx context.Context, req NetdevAddDevStreamRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) NetdevDel(req NetdevDelRequest) (*any, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) NetdevAddDevDgram(ctx context.Context, req NetdevAddDevDgramRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevVde(ctx context.Context, req NetdevAddDevVdeRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res any
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-func (c *QEMUMachineProtocolClient) QueryRxFilter(req QueryRxFilterRequest) (*QueryRxFilterResponse, error) {
-	var b []byte
-	var err error
-
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+func (c *QEMUMachineProtocolClient) NetdevAddDevBridge(ctx context.Context, req NetdevAddDevBridgeRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	b, err = json.Marshal(req)
-	if err != nil {
+	if err := c.responseError(b); err != nil {
 		return nil, err
 	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return nil, err
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
 	}
-	if err := c.send.Flush(); err != nil {
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevHubPort(ctx context.Context, req NetdevAddDevHubPortRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
 		return nil, err
 	}
 
-	var res QueryRxFilterResponse
-	b, err = c.recv.ReadBytes('\n')
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevNetmap(ctx context.Context, req NetdevAddDevNetmapRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevVhostUser(ctx context.Context, req NetdevAddDevVhostUserRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevVhostVDPA(ctx context.Context, req NetdevAddDevVhostVDPARequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetHost(ctx context.Context, req NetdevAddDevVmnetHostRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetShared(ctx context.Context, req NetdevAddDevVmnetSharedRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetBridged(ctx context.Context, req NetdevAddDevVmnetBridgedRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) NetdevDel(ctx context.Context, req NetdevDelRequest) (*any, error) {
+	b, err := c.call(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res any
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) QueryRxFilter(ctx context.Context, req QueryRxFilterRequest) (*QueryRxFilterResponse, error) {
+	b, err := c.call(ctx, &req)
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &res); err != nil {
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+
+	var res QueryRxFilterResponse
+	if err := c.codec.Unmarshal(b, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
+
+// QEMUMachineProtocol is the set of calls which are implemented by both the
+// QEMUMachineProtocolClient and the QEMUMachineProtocolMock.
+type QEMUMachineProtocol interface {
+	Greeting(ctx context.Context) (*GreetingResponse, error)
+	SubscribeEvents(ctx context.Context) (<-chan *Event, <-chan error, error)
+	Quit(ctx context.Context, req QuitRequest) (*QuitResponse, error)
+	Stop(ctx context.Context, req StopRequest) (*any, error)
+	Cont(ctx context.Context, req ContRequest) (*any, error)
+	SystemReset(ctx context.Context, req SystemResetRequest) (*any, error)
+	SystemPowerdown(ctx context.Context, req SystemPowerdownRequest) (*any, error)
+	SystemWakeup(ctx context.Context, req SystemWakeupRequest) (*any, error)
+	Capabilities(ctx context.Context, req CapabilitiesRequest) (*any, error)
+	QueryKvm(ctx context.Context, req QueryKvmRequest) (*QueryKvmResponse, error)
+	QueryStatus(ctx context.Context, req QueryStatusRequest) (*QueryStatusResponse, error)
+	SetLink(ctx context.Context, req SetLinkRequest) (*any, error)
+	NetdevAddLegacyNic(ctx context.Context, req NetdevAddLegacyNicRequest) (*any, error)
+	NetdevAddDevUser(ctx context.Context, req NetdevAddDevUserRequest) (*any, error)
+	NetdevAddDevTap(ctx context.Context, req NetdevAddDevTapRequest) (*any, error)
+	NetdevAddDevL2TPv3(ctx context.Context, req NetdevAddDevL2TPv3Request) (*any, error)
+	NetdevAddDevSocket(ctx context.Context, req NetdevAddDevSocketRequest) (*any, error)
+	NetdevAddDevStream(ctx context.Context, req NetdevAddDevStreamRequest) (*any, error)
+	NetdevAddDevDgram(ctx context.Context, req NetdevAddDevDgramRequest) (*any, error)
+	NetdevAddDevVde(ctx context.Context, req NetdevAddDevVdeRequest) (*any, error)
+	NetdevAddDevBridge(ctx context.Context, req NetdevAddDevBridgeRequest) (*any, error)
+	NetdevAddDevHubPort(ctx context.Context, req NetdevAddDevHubPortRequest) (*any, error)
+	NetdevAddDevNetmap(ctx context.Context, req NetdevAddDevNetmapRequest) (*any, error)
+	NetdevAddDevVhostUser(ctx context.Context, req NetdevAddDevVhostUserRequest) (*any, error)
+	NetdevAddDevVhostVDPA(ctx context.Context, req NetdevAddDevVhostVDPARequest) (*any, error)
+	NetdevAddDevVmnetHost(ctx context.Context, req NetdevAddDevVmnetHostRequest) (*any, error)
+	NetdevAddDevVmnetShared(ctx context.Context, req NetdevAddDevVmnetSharedRequest) (*any, error)
+	NetdevAddDevVmnetBridged(ctx context.Context, req NetdevAddDevVmnetBridgedRequest) (*any, error)
+	NetdevDel(ctx context.Context, req NetdevDelRequest) (*any, error)
+	QueryRxFilter(ctx context.Context, req QueryRxFilterRequest) (*QueryRxFilterResponse, error)
+	Close() error
+}
+
+var (
+	_ QEMUMachineProtocol = (*QEMUMachineProtocolClient)(nil)
+	_ QEMUMachineProtocol = (*QEMUMachineProtocolMock)(nil)
+)
+
+// QEMUMachineProtocolMockCall is a call which was made to a QEMUMachineProtocolMock.
+type QEMUMachineProtocolMockCall struct {
+	Method  string
+	Request any
+}
+
+// QEMUMachineProtocolMock is a test double of the QEMUMachineProtocolClient which records
+// every call.  Calls are answered by the respective function field if set, and
+// with the zero value of their response otherwise.
+type QEMUMachineProtocolMock struct {
+	GreetingFunc                 func(ctx context.Context) (*GreetingResponse, error)
+	SubscribeEventsFunc          func(ctx context.Context) (<-chan *Event, <-chan error, error)
+	QuitFunc                     func(ctx context.Context, req QuitRequest) (*QuitResponse, error)
+	StopFunc                     func(ctx context.Context, req StopRequest) (*any, error)
+	ContFunc                     func(ctx context.Context, req ContRequest) (*any, error)
+	SystemResetFunc              func(ctx context.Context, req SystemResetRequest) (*any, error)
+	SystemPowerdownFunc          func(ctx context.Context, req SystemPowerdownRequest) (*any, error)
+	SystemWakeupFunc             func(ctx context.Context, req SystemWakeupRequest) (*any, error)
+	CapabilitiesFunc             func(ctx context.Context, req CapabilitiesRequest) (*any, error)
+	QueryKvmFunc                 func(ctx context.Context, req QueryKvmRequest) (*QueryKvmResponse, error)
+	QueryStatusFunc              func(ctx context.Context, req QueryStatusRequest) (*QueryStatusResponse, error)
+	SetLinkFunc                  func(ctx context.Context, req SetLinkRequest) (*any, error)
+	NetdevAddLegacyNicFunc       func(ctx context.Context, req NetdevAddLegacyNicRequest) (*any, error)
+	NetdevAddDevUserFunc         func(ctx context.Context, req NetdevAddDevUserRequest) (*any, error)
+	NetdevAddDevTapFunc          func(ctx context.Context, req NetdevAddDevTapRequest) (*any, error)
+	NetdevAddDevL2TPv3Func       func(ctx context.Context, req NetdevAddDevL2TPv3Request) (*any, error)
+	NetdevAddDevSocketFunc       func(ctx context.Context, req NetdevAddDevSocketRequest) (*any, error)
+	NetdevAddDevStreamFunc       func(ctx context.Context, req NetdevAddDevStreamRequest) (*any, error)
+	NetdevAddDevDgramFunc        func(ctx context.Context, req NetdevAddDevDgramRequest) (*any, error)
+	NetdevAddDevVdeFunc          func(ctx context.Context, req NetdevAddDevVdeRequest) (*any, error)
+	NetdevAddDevBridgeFunc       func(ctx context.Context, req NetdevAddDevBridgeRequest) (*any, error)
+	NetdevAddDevHubPortFunc      func(ctx context.Context, req NetdevAddDevHubPortRequest) (*any, error)
+	NetdevAddDevNetmapFunc       func(ctx context.Context, req NetdevAddDevNetmapRequest) (*any, error)
+	NetdevAddDevVhostUserFunc    func(ctx context.Context, req NetdevAddDevVhostUserRequest) (*any, error)
+	NetdevAddDevVhostVDPAFunc    func(ctx context.Context, req NetdevAddDevVhostVDPARequest) (*any, error)
+	NetdevAddDevVmnetHostFunc    func(ctx context.Context, req NetdevAddDevVmnetHostRequest) (*any, error)
+	NetdevAddDevVmnetSharedFunc  func(ctx context.Context, req NetdevAddDevVmnetSharedRequest) (*any, error)
+	NetdevAddDevVmnetBridgedFunc func(ctx context.Context, req NetdevAddDevVmnetBridgedRequest) (*any, error)
+	NetdevDelFunc                func(ctx context.Context, req NetdevDelRequest) (*any, error)
+	QueryRxFilterFunc            func(ctx context.Context, req QueryRxFilterRequest) (*QueryRxFilterResponse, error)
+
+	mu     sync.Mutex
+	calls  []QEMUMachineProtocolMockCall
+	closed bool
+
+	// smu guards the subscriptions separately, such that subscribers may call
+	// the mock while a message is being delivered to them.
+	smu        sync.Mutex
+	subsEvents map[chan *Event]<-chan struct{}
+}
+
+// NewQEMUMachineProtocolMock returns a mock without any configured responses.
+func NewQEMUMachineProtocolMock() *QEMUMachineProtocolMock {
+	return &QEMUMachineProtocolMock{
+		subsEvents: make(map[chan *Event]<-chan struct{}),
+	}
+}
+
+// Calls returns all calls which were made to the mock so far.
+func (m *QEMUMachineProtocolMock) Calls() []QEMUMachineProtocolMockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]QEMUMachineProtocolMockCall(nil), m.calls...)
+}
+
+// CallsTo returns the requests of all calls which were made to the method.
+func (m *QEMUMachineProtocolMock) CallsTo(method string) []any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reqs []any
+	for _, call := range m.calls {
+		if call.Method == method {
+			reqs = append(reqs, call.Request)
+		}
+	}
+
+	return reqs
+}
+
+func (m *QEMUMachineProtocolMock) record(method string, req any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, QEMUMachineProtocolMockCall{
+		Method:  method,
+		Request: req,
+	})
+}
+
+// Close records the call and marks the mock as closed.
+func (m *QEMUMachineProtocolMock) Close() error {
+	m.record("Close", nil)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+
+	return nil
+}
+
+// Closed reports whether the mock has been closed.
+func (m *QEMUMachineProtocolMock) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.closed
+}
+
+func (m *QEMUMachineProtocolMock) Greeting(ctx context.Context) (*GreetingResponse, error) {
+	m.record("Greeting", nil)
+
+	if m.GreetingFunc != nil {
+		return m.GreetingFunc(ctx)
+	}
+
+	return new(GreetingResponse), nil
+}
+
+// ReturnGreeting configures Greeting to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnGreeting(res *GreetingResponse, err error) {
+	m.GreetingFunc = func(context.Context) (*GreetingResponse, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) SubscribeEvents(ctx context.Context) (<-chan *Event, <-chan error, error) {
+	m.record("SubscribeEvents", nil)
+
+	if m.SubscribeEventsFunc != nil {
+		return m.SubscribeEventsFunc(ctx)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan *Event)
+	errs := make(chan error)
+
+	m.smu.Lock()
+	m.subsEvents[events] = ctx.Done()
+	m.smu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		m.smu.Lock()
+		delete(m.subsEvents, events)
+		m.smu.Unlock()
+
+		close(events)
+		close(errs)
+	}()
+
+	return events, errs, nil
+}
+
+// SendEvents delivers the message to every active subscription made with
+// SubscribeEvents which is not served by SubscribeEventsFunc.
+func (m *QEMUMachineProtocolMock) SendEvents(res *Event) {
+	m.smu.Lock()
+	defer m.smu.Unlock()
+
+	for sub, done := range m.subsEvents {
+		select {
+		case sub <- res:
+		case <-done:
+		}
+	}
+}
+
+func (m *QEMUMachineProtocolMock) Quit(ctx context.Context, req QuitRequest) (*QuitResponse, error) {
+	m.record("Quit", req)
+
+	if m.QuitFunc != nil {
+		return m.QuitFunc(ctx, req)
+	}
+
+	return new(QuitResponse), nil
+}
+
+// ReturnQuit configures Quit to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnQuit(res *QuitResponse, err error) {
+	m.QuitFunc = func(context.Context, QuitRequest) (*QuitResponse, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) Stop(ctx context.Context, req StopRequest) (*any, error) {
+	m.record("Stop", req)
+
+	if m.StopFunc != nil {
+		return m.StopFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnStop configures Stop to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnStop(res *any, err error) {
+	m.StopFunc = func(context.Context, StopRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) Cont(ctx context.Context, req ContRequest) (*any, error) {
+	m.record("Cont", req)
+
+	if m.ContFunc != nil {
+		return m.ContFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnCont configures Cont to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnCont(res *any, err error) {
+	m.ContFunc = func(context.Context, ContRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) SystemReset(ctx context.Context, req SystemResetRequest) (*any, error) {
+	m.record("SystemReset", req)
+
+	if m.SystemResetFunc != nil {
+		return m.SystemResetFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnSystemReset configures SystemReset to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnSystemReset(res *any, err error) {
+	m.SystemResetFunc = func(context.Context, SystemResetRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) SystemPowerdown(ctx context.Context, req SystemPowerdownRequest) (*any, error) {
+	m.record("SystemPowerdown", req)
+
+	if m.SystemPowerdownFunc != nil {
+		return m.SystemPowerdownFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnSystemPowerdown configures SystemPowerdown to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnSystemPowerdown(res *any, err error) {
+	m.SystemPowerdownFunc = func(context.Context, SystemPowerdownRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) SystemWakeup(ctx context.Context, req SystemWakeupRequest) (*any, error) {
+	m.record("SystemWakeup", req)
+
+	if m.SystemWakeupFunc != nil {
+		return m.SystemWakeupFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnSystemWakeup configures SystemWakeup to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnSystemWakeup(res *any, err error) {
+	m.SystemWakeupFunc = func(context.Context, SystemWakeupRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) Capabilities(ctx context.Context, req CapabilitiesRequest) (*any, error) {
+	m.record("Capabilities", req)
+
+	if m.CapabilitiesFunc != nil {
+		return m.CapabilitiesFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnCapabilities configures Capabilities to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnCapabilities(res *any, err error) {
+	m.CapabilitiesFunc = func(context.Context, CapabilitiesRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) QueryKvm(ctx context.Context, req QueryKvmRequest) (*QueryKvmResponse, error) {
+	m.record("QueryKvm", req)
+
+	if m.QueryKvmFunc != nil {
+		return m.QueryKvmFunc(ctx, req)
+	}
+
+	return new(QueryKvmResponse), nil
+}
+
+// ReturnQueryKvm configures QueryKvm to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnQueryKvm(res *QueryKvmResponse, err error) {
+	m.QueryKvmFunc = func(context.Context, QueryKvmRequest) (*QueryKvmResponse, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) QueryStatus(ctx context.Context, req QueryStatusRequest) (*QueryStatusResponse, error) {
+	m.record("QueryStatus", req)
+
+	if m.QueryStatusFunc != nil {
+		return m.QueryStatusFunc(ctx, req)
+	}
+
+	return new(QueryStatusResponse), nil
+}
+
+// ReturnQueryStatus configures QueryStatus to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnQueryStatus(res *QueryStatusResponse, err error) {
+	m.QueryStatusFunc = func(context.Context, QueryStatusRequest) (*QueryStatusResponse, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) SetLink(ctx context.Context, req SetLinkRequest) (*any, error) {
+	m.record("SetLink", req)
+
+	if m.SetLinkFunc != nil {
+		return m.SetLinkFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnSetLink configures SetLink to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnSetLink(res *any, err error) {
+	m.SetLinkFunc = func(context.Context, SetLinkRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddLegacyNic(ctx context.Context, req NetdevAddLegacyNicRequest) (*any, error) {
+	m.record("NetdevAddLegacyNic", req)
+
+	if m.NetdevAddLegacyNicFunc != nil {
+		return m.NetdevAddLegacyNicFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddLegacyNic configures NetdevAddLegacyNic to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddLegacyNic(res *any, err error) {
+	m.NetdevAddLegacyNicFunc = func(context.Context, NetdevAddLegacyNicRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevUser(ctx context.Context, req NetdevAddDevUserRequest) (*any, error) {
+	m.record("NetdevAddDevUser", req)
+
+	if m.NetdevAddDevUserFunc != nil {
+		return m.NetdevAddDevUserFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevUser configures NetdevAddDevUser to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevUser(res *any, err error) {
+	m.NetdevAddDevUserFunc = func(context.Context, NetdevAddDevUserRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevTap(ctx context.Context, req NetdevAddDevTapRequest) (*any, error) {
+	m.record("NetdevAddDevTap", req)
+
+	if m.NetdevAddDevTapFunc != nil {
+		return m.NetdevAddDevTapFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevTap configures NetdevAddDevTap to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevTap(res *any, err error) {
+	m.NetdevAddDevTapFunc = func(context.Context, NetdevAddDevTapRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevL2TPv3(ctx context.Context, req NetdevAddDevL2TPv3Request) (*any, error) {
+	m.record("NetdevAddDevL2TPv3", req)
+
+	if m.NetdevAddDevL2TPv3Func != nil {
+		return m.NetdevAddDevL2TPv3Func(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevL2TPv3 configures NetdevAddDevL2TPv3 to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevL2TPv3(res *any, err error) {
+	m.NetdevAddDevL2TPv3Func = func(context.Context, NetdevAddDevL2TPv3Request) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevSocket(ctx context.Context, req NetdevAddDevSocketRequest) (*any, error) {
+	m.record("NetdevAddDevSocket", req)
+
+	if m.NetdevAddDevSocketFunc != nil {
+		return m.NetdevAddDevSocketFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevSocket configures NetdevAddDevSocket to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevSocket(res *any, err error) {
+	m.NetdevAddDevSocketFunc = func(context.Context, NetdevAddDevSocketRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevStream(ctx context.Context, req NetdevAddDevStreamRequest) (*any, error) {
+	m.record("NetdevAddDevStream", req)
+
+	if m.NetdevAddDevStreamFunc != nil {
+		return m.NetdevAddDevStreamFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevStream configures NetdevAddDevStream to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevStream(res *any, err error) {
+	m.NetdevAddDevStreamFunc = func(context.Context, NetdevAddDevStreamRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevDgram(ctx context.Context, req NetdevAddDevDgramRequest) (*any, error) {
+	m.record("NetdevAddDevDgram", req)
+
+	if m.NetdevAddDevDgramFunc != nil {
+		return m.NetdevAddDevDgramFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevDgram configures NetdevAddDevDgram to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevDgram(res *any, err error) {
+	m.NetdevAddDevDgramFunc = func(context.Context, NetdevAddDevDgramRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevVde(ctx context.Context, req NetdevAddDevVdeRequest) (*any, error) {
+	m.record("NetdevAddDevVde", req)
+
+	if m.NetdevAddDevVdeFunc != nil {
+		return m.NetdevAddDevVdeFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevVde configures NetdevAddDevVde to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevVde(res *any, err error) {
+	m.NetdevAddDevVdeFunc = func(context.Context, NetdevAddDevVdeRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevBridge(ctx context.Context, req NetdevAddDevBridgeRequest) (*any, error) {
+	m.record("NetdevAddDevBridge", req)
+
+	if m.NetdevAddDevBridgeFunc != nil {
+		return m.NetdevAddDevBridgeFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevBridge configures NetdevAddDevBridge to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevBridge(res *any, err error) {
+	m.NetdevAddDevBridgeFunc = func(context.Context, NetdevAddDevBridgeRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevHubPort(ctx context.Context, req NetdevAddDevHubPortRequest) (*any, error) {
+	m.record("NetdevAddDevHubPort", req)
+
+	if m.NetdevAddDevHubPortFunc != nil {
+		return m.NetdevAddDevHubPortFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevHubPort configures NetdevAddDevHubPort to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevHubPort(res *any, err error) {
+	m.NetdevAddDevHubPortFunc = func(context.Context, NetdevAddDevHubPortRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevNetmap(ctx context.Context, req NetdevAddDevNetmapRequest) (*any, error) {
+	m.record("NetdevAddDevNetmap", req)
+
+	if m.NetdevAddDevNetmapFunc != nil {
+		return m.NetdevAddDevNetmapFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevNetmap configures NetdevAddDevNetmap to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevNetmap(res *any, err error) {
+	m.NetdevAddDevNetmapFunc = func(context.Context, NetdevAddDevNetmapRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevVhostUser(ctx context.Context, req NetdevAddDevVhostUserRequest) (*any, error) {
+	m.record("NetdevAddDevVhostUser", req)
+
+	if m.NetdevAddDevVhostUserFunc != nil {
+		return m.NetdevAddDevVhostUserFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevVhostUser configures NetdevAddDevVhostUser to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevVhostUser(res *any, err error) {
+	m.NetdevAddDevVhostUserFunc = func(context.Context, NetdevAddDevVhostUserRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevVhostVDPA(ctx context.Context, req NetdevAddDevVhostVDPARequest) (*any, error) {
+	m.record("NetdevAddDevVhostVDPA", req)
+
+	if m.NetdevAddDevVhostVDPAFunc != nil {
+		return m.NetdevAddDevVhostVDPAFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevVhostVDPA configures NetdevAddDevVhostVDPA to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevVhostVDPA(res *any, err error) {
+	m.NetdevAddDevVhostVDPAFunc = func(context.Context, NetdevAddDevVhostVDPARequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevVmnetHost(ctx context.Context, req NetdevAddDevVmnetHostRequest) (*any, error) {
+	m.record("NetdevAddDevVmnetHost", req)
+
+	if m.NetdevAddDevVmnetHostFunc != nil {
+		return m.NetdevAddDevVmnetHostFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevVmnetHost configures NetdevAddDevVmnetHost to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevVmnetHost(res *any, err error) {
+	m.NetdevAddDevVmnetHostFunc = func(context.Context, NetdevAddDevVmnetHostRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevVmnetShared(ctx context.Context, req NetdevAddDevVmnetSharedRequest) (*any, error) {
+	m.record("NetdevAddDevVmnetShared", req)
+
+	if m.NetdevAddDevVmnetSharedFunc != nil {
+		return m.NetdevAddDevVmnetSharedFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevVmnetShared configures NetdevAddDevVmnetShared to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevVmnetShared(res *any, err error) {
+	m.NetdevAddDevVmnetSharedFunc = func(context.Context, NetdevAddDevVmnetSharedRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevAddDevVmnetBridged(ctx context.Context, req NetdevAddDevVmnetBridgedRequest) (*any, error) {
+	m.record("NetdevAddDevVmnetBridged", req)
+
+	if m.NetdevAddDevVmnetBridgedFunc != nil {
+		return m.NetdevAddDevVmnetBridgedFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevAddDevVmnetBridged configures NetdevAddDevVmnetBridged to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevAddDevVmnetBridged(res *any, err error) {
+	m.NetdevAddDevVmnetBridgedFunc = func(context.Context, NetdevAddDevVmnetBridgedRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) NetdevDel(ctx context.Context, req NetdevDelRequest) (*any, error) {
+	m.record("NetdevDel", req)
+
+	if m.NetdevDelFunc != nil {
+		return m.NetdevDelFunc(ctx, req)
+	}
+
+	return new(any), nil
+}
+
+// ReturnNetdevDel configures NetdevDel to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnNetdevDel(res *any, err error) {
+	m.NetdevDelFunc = func(context.Context, NetdevDelRequest) (*any, error) {
+		return res, err
+	}
+}
+
+func (m *QEMUMachineProtocolMock) QueryRxFilter(ctx context.Context, req QueryRxFilterRequest) (*QueryRxFilterResponse, error) {
+	m.record("QueryRxFilter", req)
+
+	if m.QueryRxFilterFunc != nil {
+		return m.QueryRxFilterFunc(ctx, req)
+	}
+
+	return new(QueryRxFilterResponse), nil
+}
+
+// ReturnQueryRxFilter configures QueryRxFilter to answer with the canned response
+// and error.
+func (m *QEMUMachineProtocolMock) ReturnQueryRxFilter(res *QueryRxFilterResponse, err error) {
+	m.QueryRxFilterFunc = func(context.Context, QueryRxFilterRequest) (*QueryRxFilterResponse, error) {
+		return res, err
+	}
+}
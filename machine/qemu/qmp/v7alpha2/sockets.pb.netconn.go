@@ -95,9 +95,62 @@ type VsockSocketAddress struct {
 //
 // Since: 2.9
 type SocketAddress struct {
-	Type  SocketAddressType  `json:"type"`
-	Inet  InetSocketAddress  `json:"inet"`
-	Unix  UnixSocketAddress  `json:"unix"`
-	Vsock VsockSocketAddress `json:"vsock"`
-	Fd    string             `json:"fd"`
+	Type  SocketAddressType   `json:"type"`
+	Inet  *InetSocketAddress  `json:"inet,omitempty"`
+	Unix  *UnixSocketAddress  `json:"unix,omitempty"`
+	Vsock *VsockSocketAddress `json:"vsock,omitempty"`
+	Fd    *string             `json:"fd,omitempty"`
+}
+
+// AddressCase returns the JSON name of the field which is set in the
+// address oneof or an empty string if none of them are set.
+func (m *SocketAddress) AddressCase() string {
+	switch {
+	case m.Inet != nil:
+		return "inet"
+	case m.Unix != nil:
+		return "unix"
+	case m.Vsock != nil:
+		return "vsock"
+	case m.Fd != nil:
+		return "fd"
+	}
+
+	return ""
+}
+
+// SetInet sets the inet field and clears all other fields
+// of the address oneof.
+func (m *SocketAddress) SetInet(v InetSocketAddress) {
+	m.Unix = nil
+	m.Vsock = nil
+	m.Fd = nil
+	m.Inet = &v
+}
+
+// SetUnix sets the unix field and clears all other fields
+// of the address oneof.
+func (m *SocketAddress) SetUnix(v UnixSocketAddress) {
+	m.Inet = nil
+	m.Vsock = nil
+	m.Fd = nil
+	m.Unix = &v
+}
+
+// SetVsock sets the vsock field and clears all other fields
+// of the address oneof.
+func (m *SocketAddress) SetVsock(v VsockSocketAddress) {
+	m.Inet = nil
+	m.Unix = nil
+	m.Fd = nil
+	m.Vsock = &v
+}
+
+// SetFd sets the fd field and clears all other fields
+// of the address oneof.
+func (m *SocketAddress) SetFd(v string) {
+	m.Inet = nil
+	m.Unix = nil
+	m.Vsock = nil
+	m.Fd = &v
 }
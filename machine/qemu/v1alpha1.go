@@ -19,7 +19,9 @@ import (
 	"time"
 
 	zip "api.zip"
+	"github.com/Masterminds/semver/v3"
 	"github.com/acorn-io/baaah/pkg/merr"
+	"github.com/dustin/go-humanize"
 	"github.com/klauspost/cpuid"
 	"github.com/mitchellh/mapstructure"
 	goprocess "github.com/shirou/gopsutil/v3/process"
@@ -31,26 +33,34 @@ import (
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/config"
 	"kraftkit.sh/exec"
+	"kraftkit.sh/internal/logdriver"
 	"kraftkit.sh/internal/logtail"
 	"kraftkit.sh/internal/retrytimeout"
 	"kraftkit.sh/log"
+	"kraftkit.sh/machine/cgroup"
+	mnetwork "kraftkit.sh/machine/network"
 	"kraftkit.sh/machine/network/macaddr"
-	"kraftkit.sh/machine/qemu/qmp"
 	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+	"kraftkit.sh/machine/vmm"
+	ninepfs "kraftkit.sh/machine/volume/9pfs"
 	"kraftkit.sh/unikraft/export/v0/posixenviron"
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
 	"kraftkit.sh/unikraft/export/v0/uknetdev"
 	"kraftkit.sh/unikraft/export/v0/vfscore"
+	"kraftkit.sh/unikraft/ukstore"
 )
 
 // machineV1alpha1Service ...
 type machineV1alpha1Service struct {
 	eopts []exec.ExecOption
+	logs  *logdriver.Drivers
 }
 
 // NewMachineV1alpha1Service implements kraftkit.sh/machine/platform.NewStrategyConstructor
 func NewMachineV1alpha1Service(ctx context.Context, opts ...any) (machinev1alpha1.MachineService, error) {
-	service := machineV1alpha1Service{}
+	service := machineV1alpha1Service{
+		logs: logdriver.NewDrivers(),
+	}
 
 	for _, opt := range opts {
 		qopt, ok := opt.(MachineServiceV1alpha1Option)
@@ -76,64 +86,39 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, fmt.Errorf("supplied kernel path does not exist: %s", machine.Status.KernelPath)
 	}
 
-	var bin string
-
-	switch machine.Spec.Architecture {
-	case "x86_64", "amd64":
-		bin = QemuSystemX86
-	case "arm":
-		bin = QemuSystemArm
-	case "arm64":
-		bin = QemuSystemAarch64
-	default:
-		return nil, fmt.Errorf("unsupported architecture: %s", machine.Spec.Architecture)
-	}
-
-	if config.G[config.KraftKit](ctx).Qemu != "" {
-		bin = config.G[config.KraftKit](ctx).Qemu
+	bin, err := qemuBin(ctx, machine)
+	if err != nil {
+		return nil, err
 	}
 
-	// Determine the version of QEMU so as to both determine whether it is a
-	// suitable version and to adjust the supplied command-line arguments.
-	qemuVersion, err := GetQemuVersionFromBin(ctx, bin)
+	// Determine the capabilities of QEMU so as to both determine whether it is
+	// a suitable version, to adjust the supplied command-line arguments and to
+	// reject features which it does not support before it is started.
+	qemuCaps, err := GetQemuCapabilitiesFromBin(ctx, bin)
 	if err != nil {
 		return machine, err
 	}
 
+	qemuVersion := qemuCaps.Version
+
 	if qemuVersion.LessThan(QemuVersion4_2_0) {
 		return machine, fmt.Errorf("unsupported QEMU version: %s: please upgrade to a newer version", qemuVersion.String())
 	}
 
-	// Determine the QEMU machine type to use
-	qemuAccels, err := GetQemuMachineAccelFromBin(ctx, bin)
+	qemuAccel, err := qemuAccelerator(ctx, machine, qemuCaps)
 	if err != nil {
 		return machine, err
 	}
 
-	if machine.Spec.Emulation {
-		emulation := false
-		for _, accel := range qemuAccels {
-			if accel == QemuMachineAccelTCG {
-				emulation = true
-				break
-			}
-		}
+	machineType := qemuMachineType(machine)
 
-		if !emulation {
-			return machine, fmt.Errorf("emulation requested but TCG is not available")
-		}
-	} else {
-		platform := false
-		for _, accel := range qemuAccels {
-			if accel == QemuMachineAccelKVM {
-				platform = true
-				break
-			}
-		}
+	// Record what the machine is run with, such that it can be inspected.
+	machine.Spec.VMM.Binary = qemuCaps.Bin
+	machine.Spec.VMM.MachineType = machineType.String()
+	machine.Status.Accelerator = machinev1alpha1.MachineAccelerator(qemuAccel)
 
-		if !platform {
-			return machine, fmt.Errorf("platform %s requested but it's not available", QemuMachineAccelKVM)
-		}
+	if err := requireQemuCapabilities(machine, qemuCaps); err != nil {
+		return machine, err
 	}
 
 	if machine.ObjectMeta.UID == "" {
@@ -152,7 +137,21 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 
 	// Set and create the log file for this machine
 	if len(machine.Status.LogFile) == 0 {
-		machine.Status.LogFile = filepath.Join(machine.Status.StateDir, "machine.log")
+		machine.Status.LogFile = logdriver.ConsoleLogFile(machine)
+	}
+
+	machine.Status.ConsoleSocket = filepath.Join(machine.Status.StateDir, "console.sock")
+
+	// Merge the layers of the initramfs, since the VMM accepts only one.
+	if len(machine.Status.InitrdLayers) > 0 {
+		machine.Status.InitrdPath, err = vmm.MergeInitrdLayers(machine.Status.StateDir, machine.Status.InitrdLayers)
+		if err != nil {
+			return machine, err
+		}
+	}
+
+	if machine.Spec.Metrics {
+		machine.Status.MetricsSocket = filepath.Join(machine.Status.StateDir, ukstore.SocketFile)
 	}
 
 	if machine.Spec.Resources.Requests == nil {
@@ -179,6 +178,29 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		machine.Spec.Resources.Requests[corev1.ResourceCPU] = quantity
 	}
 
+	rtc := QemuRTC{
+		Base: QemuRTCBaseUtc,
+	}
+
+	switch machine.Spec.Clock.RTCBase {
+	case "", machinev1alpha1.MachineRTCBaseUTC:
+	case machinev1alpha1.MachineRTCBaseLocaltime:
+		rtc.Base = QemuRTCBaseLocaltime
+	default:
+		machine.Status.State = machinev1alpha1.MachineStateFailed
+		return machine, fmt.Errorf("unsupported RTC base: %s", machine.Spec.Clock.RTCBase)
+	}
+
+	// Drive the RTC from the host clock, which keeps running whilst the machine
+	// is paused, and re-inject the ticks which the guest missed in the meantime
+	// such that it catches up once resumed.
+	if machine.Spec.Clock.SyncOnResume {
+		rtc.Clock = QemuRTCClockHost
+		if machine.Spec.Architecture == "x86_64" || machine.Spec.Architecture == "amd64" {
+			rtc.DriftFix = QemuRTCDriftFixSlew
+		}
+	}
+
 	qopts := []QemuOption{
 		WithDaemonize(true),
 		WithNoGraphic(true),
@@ -207,9 +229,20 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 			NoWait:    true,
 			Server:    true,
 		}),
-		WithSerial(QemuHostCharDevFile{
-			Monitor:  false,
-			Filename: machine.Status.LogFile,
+		// Expose the serial console on a unix socket, such that it can be
+		// attached to interactively, and write its output to the log file in
+		// append mode, such that it can be rotated by truncating it in place.
+		// Output is logged regardless of whether a client is attached.
+		WithCharDevice(QemuCharDevSocketUnix{
+			Id:        "serial0",
+			Path:      machine.Status.ConsoleSocket,
+			Server:    true,
+			NoWait:    true,
+			LogFile:   machine.Status.LogFile,
+			LogAppend: true,
+		}),
+		WithSerial(QemuHostCharDevNamed{
+			Id: "serial0",
 		}),
 		WithMonitor(QemuHostCharDevUnix{
 			SocketDir: machine.Status.StateDir,
@@ -217,19 +250,50 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 			NoWait:    true,
 			Server:    true,
 		}),
+		// Attach a balloon device such that the memory available to the guest
+		// can be adjusted at runtime via Update, up to the memory it was
+		// created with.  The guest may reclaim ballooned memory when it runs
+		// out.
+		WithDevice(QemuDeviceVirtioBalloonPci{
+			DeflateOnOom: true,
+		}),
 		WithSMP(QemuSMP{
 			CPUs:    uint64(machine.Spec.Resources.Requests.Cpu().Value()),
 			Threads: 1,
 			Sockets: 1,
 		}),
 		WithVGA(QemuVGANone),
-		WithRTC(QemuRTC{
-			Base: QemuRTCBaseUtc,
-		}),
+		WithRTC(rtc),
 		WithDisplay(QemuDisplayNone{}),
 		WithParallel(QemuHostCharDevNone{}),
 	}
 
+	// Provide a source of entropy such that the guest does not block waiting
+	// for it, e.g. when seeding TLS libraries at boot.
+	if !machine.Spec.NoRNG {
+		qopts = append(qopts,
+			WithDevice(QemuDeviceVirtioRngPci{}),
+		)
+	}
+
+	// Expose the ukstore of the guest on a dedicated virtio-serial port, which
+	// the guest serves its counters on, see `kraft metrics`.
+	if machine.Spec.Metrics {
+		qopts = append(qopts,
+			WithCharDevice(QemuCharDevSocketUnix{
+				Id:     "ukstore0",
+				Path:   machine.Status.MetricsSocket,
+				Server: true,
+				NoWait: true,
+			}),
+			WithDevice(QemuDeviceVirtioSerialPci{}),
+			WithDevice(QemuDeviceVirtserialport{
+				Chardev: "ukstore0",
+				Name:    ukstore.PortName,
+			}),
+		)
+	}
+
 	// TODO: Parse Rootfs types
 	if len(machine.Status.InitrdPath) > 0 {
 		qopts = append(qopts,
@@ -252,6 +316,10 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, err
 	}
 
+	// Ports are forwarded through the user-mode network, if the machine is
+	// attached to it, rather than through a dedicated interface each.
+	userNetwork := false
+
 	if len(machine.Spec.Networks) > 0 {
 		// Iterate over each interface of each network interface associated with
 		// this machine and attach it as a device.
@@ -268,6 +336,24 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 				hostnetid := fmt.Sprintf("hostnet%d", hostnetCounter)
 				hostnetCounter++
 
+				var netdev QemuNetDev = QemuNetDevTap{
+					Id:         hostnetid,
+					Ifname:     iface.Spec.IfName,
+					Br:         network.IfName,
+					Script:     "no", // Disable execution
+					Downscript: "no", // Disable execution
+				}
+
+				if network.Driver == mnetwork.DriverUser {
+					userNetwork = true
+
+					netdev, err = userNetDev(ctx, machine, hostnetid, qemuVersion)
+					if err != nil {
+						machine.Status.State = machinev1alpha1.MachineStateFailed
+						return machine, err
+					}
+				}
+
 				qopts = append(qopts,
 					// TODO(nderjung): The network device should be customizable based on
 					// the network spec or machine spec.  Additional insight can be provided
@@ -278,13 +364,7 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 						Netdev: hostnetid,
 						Mac:    mac,
 					}),
-					WithNetDevice(QemuNetDevTap{
-						Id:         hostnetid,
-						Ifname:     iface.Spec.IfName,
-						Br:         network.IfName,
-						Script:     "no", // Disable execution
-						Downscript: "no", // Disable execution
-					}),
+					WithNetDevice(netdev),
 				)
 
 				kernelArgs = append(kernelArgs,
@@ -301,7 +381,7 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		}
 	}
 
-	if len(machine.Spec.Ports) > 0 {
+	if len(machine.Spec.Ports) > 0 && !userNetwork {
 		for _, port := range machine.Spec.Ports {
 			mac := port.MacAddress
 			if mac == "" {
@@ -329,11 +409,18 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 	for i, vol := range machine.Spec.Volumes {
 		switch vol.Spec.Driver {
 		case "9pfs":
+			// Volumes which were created before options were supported do not
+			// carry any, in which case the defaults apply.
+			options, err := ninepfs.Options(vol.Spec.Options)
+			if err != nil {
+				return machine, fmt.Errorf("volume %s: %w", vol.Name, err)
+			}
+
 			hvirtioid := fmt.Sprintf("hvirtio%d", i+1)
-			mounttag := fmt.Sprintf("fs%d", i+1)
+			mounttag := MountTag(i)
 			qopts = append(qopts,
 				WithFsDevice(QemuFsDevLocal{
-					SecurityModel: QemuFsDevLocalSecurityModelPassthrough,
+					SecurityModel: QemuFsDevLocalSecurityModel(options[ninepfs.OptionSecurityModel]),
 					Id:            hvirtioid,
 					Path:          vol.Spec.Source,
 				}),
@@ -347,10 +434,10 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 				mounttag,
 				vol.Spec.Destination,
 				vol.Spec.Driver,
-				// TODO(nderjung): Options (such as ro/rw) are yet supported by
+				// TODO(nderjung): Flags (such as ro/rw) are not yet supported by
 				// Unikraft:
 				"",
-				"",
+				ninepfs.MountOptions(options),
 				// By default, create the directory if it does not exist when mounting.
 				"mkmp",
 			).String())
@@ -403,12 +490,23 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 	args = append(args, machine.Spec.ApplicationArgs...)
 	qopts = append(qopts, WithAppend(args...))
 
+	var cpu QemuCPU
+
 	switch machine.Spec.Architecture {
 	case "x86_64", "amd64":
 		qopts = append(qopts,
 			WithDevice(QemuDevicePvpanic{}),
 		)
-		if machine.Spec.Emulation {
+
+		// Pause the guest when it panics, rather than shutting it down, such
+		// that its memory can be dumped for diagnostics before it is stopped.
+		if !qemuVersion.LessThan(QemuVersion6_0_0) {
+			qopts = append(qopts, WithAction(QemuAction{
+				Panic: QemuActionPanicPause,
+			}))
+		}
+
+		if qemuAccel == QemuMachineAccelTCG {
 			onFeatures := QemuCPUFeatures{QemuCPUFeaturePdpe1gb}
 
 			if qemuVersion.LessThan(QemuVersion8_0_0) {
@@ -419,31 +517,40 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 
 			qopts = append(qopts,
 				WithMachine(QemuMachine{
-					Type: QemuMachineTypePC,
-				}),
-				WithCPU(QemuCPU{
-					CPU: QemuCPUX86Qemu64,
-					On:  onFeatures,
-					Off: QemuCPUFeatures{QemuCPUFeatureVmx, QemuCPUFeatureSvm},
+					Type: machineType,
+					PCIe: machineTypePCIe(machineType),
 				}),
 			)
+
+			cpu = QemuCPU{
+				CPU: QemuCPUX86Qemu64,
+				On:  onFeatures,
+				Off: QemuCPUFeatures{QemuCPUFeatureVmx, QemuCPUFeatureSvm},
+			}
 		} else {
 			if !cpuid.CPU.Rdrand() || !cpuid.CPU.Rdseed() {
 				log.G(ctx).Warn("RDRAND and RDSEED are not supported by the host CPU, try rerunning with emulation '-W' to be able to run Unikraft v0.17.0 and greater with hardware randomization")
 			}
 
+			offFeatures := QemuCPUFeatures{QemuCPUFeaturePmu}
+			if machine.Spec.Clock.NoParavirt && qemuAccel == QemuMachineAccelKVM {
+				offFeatures = append(offFeatures, QemuCPUFeatureKvmclock)
+			}
+
 			qopts = append(qopts,
-				WithEnableKVM(true),
+				WithEnableKVM(qemuAccel == QemuMachineAccelKVM),
 				WithMachine(QemuMachine{
-					Type:         QemuMachineTypePC,
-					Accelerators: []QemuMachineAccelerator{QemuMachineAccelKVM},
-				}),
-				WithCPU(QemuCPU{
-					CPU: QemuCPUX86Host,
-					On:  QemuCPUFeatures{QemuCPUFeatureX2apic},
-					Off: QemuCPUFeatures{QemuCPUFeaturePmu},
+					Type:         machineType,
+					Accelerators: []QemuMachineAccelerator{qemuAccel},
+					PCIe:         machineTypePCIe(machineType),
 				}),
 			)
+
+			cpu = QemuCPU{
+				CPU: QemuCPUX86Host,
+				On:  QemuCPUFeatures{QemuCPUFeatureX2apic},
+				Off: offFeatures,
+			}
 		}
 		if qemuVersion.LessThan(QemuVersion8_0_0) {
 			qopts = append(qopts,
@@ -451,19 +558,67 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 			)
 		}
 	case "arm", "arm64":
+		qemuMachine := QemuMachine{
+			Type: machineType,
+			PCIe: machineTypePCIe(machineType),
+		}
+
+		// Emulation is implied on Arm unless a hardware accelerator was
+		// selected.
+		if qemuAccel != QemuMachineAccelTCG {
+			qemuMachine.Accelerators = []QemuMachineAccelerator{qemuAccel}
+		}
+
 		qopts = append(qopts,
-			WithMachine(QemuMachine{
-				Type: QemuMachineTypeVirt,
-			}),
-			WithCPU(QemuCPU{
-				CPU: QemuCPUArmMax,
-			}),
+			WithMachine(qemuMachine),
+		)
+
+		cpu = QemuCPU{
+			CPU: QemuCPUArmMax,
+		}
+
+	case "riscv64":
+		qemuMachine := QemuMachine{
+			Type: machineType,
+			PCIe: machineTypePCIe(machineType),
+		}
+
+		cpu = QemuCPU{
+			CPU: QemuCPURiscvRv64,
+		}
+
+		if qemuAccel != QemuMachineAccelTCG {
+			qemuMachine.Accelerators = []QemuMachineAccelerator{qemuAccel}
+			cpu.CPU = QemuCPURiscvHost
+		}
+
+		// Unlike on x86_64 and Arm, the kernel is not booted directly but by the
+		// SBI firmware which is shipped with QEMU.
+		qopts = append(qopts,
+			WithMachine(qemuMachine),
+			WithBIOS(QemuBIOSDefault),
 		)
 
 	default:
 		return nil, fmt.Errorf("unsupported architecture: %s", machine.Spec.Architecture)
 	}
 
+	cpu, err = customiseQemuCPU(cpu, machine, qemuAccel)
+	if err != nil {
+		machine.Status.State = machinev1alpha1.MachineStateFailed
+		return machine, err
+	}
+
+	qopts = append(qopts, WithCPU(cpu))
+
+	hardening, err := hardeningOptions(machine, qemuVersion)
+	if err != nil {
+		machine.Status.State = machinev1alpha1.MachineStateFailed
+		return machine, err
+	}
+
+	qopts = append(qopts, hardening...)
+
 	// Create a log file just for the QEMU process which can be used to debug
 	// issues when starting the VMM.
 	qemuLogFile := filepath.Join(machine.Status.StateDir, "qemu.log")
@@ -505,6 +660,10 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 	if err := process.StartAndWait(ctx); err != nil {
 		machine.Status.State = machinev1alpha1.MachineStateFailed
 
+		if userNetwork {
+			stopPasst(machine)
+		}
+
 		// Propagate the contents of the QEMU log file as an error
 		if errLog, err2 := os.ReadFile(qemuLogFile); err2 == nil {
 			err = errors.Join(fmt.Errorf(strings.TrimSpace(string(errLog))), err)
@@ -513,14 +672,180 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, fmt.Errorf("could not start and wait for QEMU process: %v", err)
 	}
 
+	// The daemonized QEMU process is confined as soon as it has written its pid
+	// file, which it does before the guest is started.
+	if vmmProcess, err := processFromPidFile(qcfg.PidFile); err != nil {
+		log.G(ctx).Warnf("could not confine QEMU process: %v", err)
+	} else if err := cgroup.Confine(ctx, machine, vmmProcess.Pid); err != nil {
+		_ = vmmProcess.Kill()
+		return machine, fmt.Errorf("could not confine QEMU process: %w", err)
+	}
+
 	machine.Status.State = machinev1alpha1.MachineStateCreated
 
 	return machine, nil
 }
 
-// Update implements kraftkit.sh/api/machine/v1alpha1.MachineService
+// Update implements kraftkit.sh/api/machine/v1alpha1.MachineService.  The
+// memory requested by the machine's specification is set as the new target of
+// its balloon device, which must not exceed the memory the machine was created
+// with.  The guest adjusts to the target asynchronously.
 func (service *machineV1alpha1Service) Update(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
-	panic("not implemented: kraftkit.sh/machine/qemu.machineV1alpha1Service.Update")
+	qcfg, ok := machine.Status.PlatformConfig.(QemuConfig)
+	if !ok {
+		return machine, fmt.Errorf("cannot read QEMU platform configuration from machine status")
+	}
+
+	if machine.Status.State != machinev1alpha1.MachineStateRunning && machine.Status.State != machinev1alpha1.MachineStatePaused {
+		return machine, fmt.Errorf("cannot update machine %s in state %s", machine.Name, machine.Status.State)
+	}
+
+	target := machine.Spec.Resources.Requests.Memory().Value()
+	if target <= 0 {
+		return machine, fmt.Errorf("memory must be greater than zero")
+	}
+
+	if qcfg.Memory.String() != "" {
+		maximum, err := qcfg.Memory.MaxBytes()
+		if err != nil {
+			return machine, fmt.Errorf("could not parse memory of machine: %w", err)
+		}
+
+		if uint64(target) > maximum {
+			return machine, fmt.Errorf("cannot grow memory beyond the %s the machine was created with", humanize.IBytes(maximum))
+		}
+	}
+
+	qmpClient, err := service.QMPClient(ctx, machine)
+	if err != nil {
+		return machine, fmt.Errorf("could not attach to QMP client: %v", err)
+	}
+
+	defer qmpClient.Close()
+
+	if _, err := qmpClient.Balloon(ctx, qmpapi.BalloonRequest{
+		Arguments: qmpapi.BalloonRequestArguments{
+			Value: target,
+		},
+	}); err != nil {
+		return machine, fmt.Errorf("could not set balloon target via QMP: %v", err)
+	}
+
+	machine.Status.TargetMemory = target
+
+	return machine, nil
+}
+
+// requireQemuCapabilities returns an error if QEMU lacks a machine type or
+// device which is required to create the machine, such that the user is told
+// which feature is unavailable rather than QEMU failing to start.
+func requireQemuCapabilities(machine *machinev1alpha1.Machine, caps *QemuCapabilities) error {
+	machineType := qemuMachineType(machine)
+	if err := caps.RequireMachine(machineType, fmt.Sprintf("running %s machines", machine.Spec.Architecture)); err != nil {
+		return err
+	}
+
+	switch machine.Spec.Architecture {
+	case "x86_64", "amd64":
+		if err := caps.RequireDevice(QemuDeviceTypePvpanic, "detecting guest panics"); err != nil {
+			return err
+		}
+		if caps.Version.LessThan(QemuVersion8_0_0) {
+			if err := caps.RequireDevice(QemuDeviceTypeSga, "the serial console"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := caps.RequireDevice(QemuDeviceTypeVirtioBalloonPci, "adjusting memory at runtime"); err != nil {
+		return err
+	}
+
+	if !machine.Spec.NoRNG {
+		if err := caps.RequireDevice(QemuDeviceTypeVirtioRngPci, "providing entropy to the guest (disable it with --no-rng)"); err != nil {
+			return err
+		}
+	}
+
+	if len(machine.Spec.Networks) > 0 || len(machine.Spec.Ports) > 0 {
+		if err := caps.RequireDevice(QemuDeviceTypeVirtioNetPci, "networking"); err != nil {
+			return err
+		}
+	}
+
+	for _, vol := range machine.Spec.Volumes {
+		if vol.Spec.Driver != "9pfs" {
+			continue
+		}
+
+		if err := caps.RequireDevice(QemuDeviceTypeVirtio9pPci, "mounting 9pfs volumes"); err != nil {
+			return err
+		}
+
+		break
+	}
+
+	return nil
+}
+
+// hardeningOptions returns the options which confine QEMU as requested by the
+// machine's specification and records the applied hardening in its status.
+func hardeningOptions(machine *machinev1alpha1.Machine, qemuVersion *semver.Version) ([]QemuOption, error) {
+	var qopts []QemuOption
+
+	spec := machine.Spec.Hardening
+	machine.Status.Hardening = machinev1alpha1.MachineHardeningStatus{}
+
+	user := spec.User
+	if user == "" && spec.DropCapabilities && os.Geteuid() == 0 {
+		user = "nobody"
+	}
+
+	if user != "" {
+		if os.Geteuid() != 0 {
+			return nil, fmt.Errorf("cannot run QEMU as user %s: switching users requires running as root", user)
+		}
+
+		if qemuVersion.LessThan(QemuVersion9_1_0) {
+			qopts = append(qopts, WithRunAs(user))
+		} else {
+			qopts = append(qopts, WithRunWith(QemuRunWith{User: user}))
+		}
+
+		machine.Status.Hardening.User = user
+	}
+
+	// Once QEMU has switched to an unprivileged user, or if it was never
+	// started with privileges, it is left without capabilities.
+	machine.Status.Hardening.CapabilitiesDropped = user != "" || os.Geteuid() != 0
+
+	if spec.Seccomp {
+		sandbox := QemuSandbox{
+			Enable:            true,
+			Obsolete:          QemuSandboxActionDeny,
+			ElevatePrivileges: QemuSandboxActionDeny,
+			Spawn:             QemuSandboxActionDeny,
+			ResourceControl:   QemuSandboxActionDeny,
+		}
+
+		// The filter is installed before QEMU switches users, which must
+		// therefore remain permitted.
+		if user != "" {
+			sandbox.ElevatePrivileges = QemuSandboxActionChildren
+		}
+
+		qopts = append(qopts, WithSandbox(sandbox))
+
+		machine.Status.Hardening.Seccomp = sandbox.String()
+	}
+
+	return qopts, nil
+}
+
+// MountTag returns the tag with which the 9P file system of the volume at the
+// provided index of a machine's specification is exposed to its guest.
+func MountTag(index int) string {
+	return fmt.Sprintf("fs%d", index+1)
 }
 
 // getQEMUConfigFromPlatformConfig converts the provided platformConfig
@@ -565,20 +890,33 @@ func getQEMUConfigFromPlatformConfig(platformConfig interface{}) (*QemuConfig, e
 	return &qcfg, nil
 }
 
-func qmpClientHandshake(conn *net.Conn) (*qmpapi.QEMUMachineProtocolClient, error) {
-	qmpClient := qmpapi.NewQEMUMachineProtocolClient(*conn)
+// QMPTimeout is the default amount of time to wait for a QMP call to complete
+// before giving up on an unresponsive VMM socket.
+const QMPTimeout = 10 * time.Second
 
-	greeting, err := qmpClient.Greeting()
+// qmpClientNegotiate reads the QMP greeting and enters command mode.
+func qmpClientNegotiate(ctx context.Context, qmpClient *qmpapi.QEMUMachineProtocolClient) error {
+	greeting, err := qmpClient.Greeting(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	_, err = qmpClient.Capabilities(qmpapi.CapabilitiesRequest{
+	_, err = qmpClient.Capabilities(ctx, qmpapi.CapabilitiesRequest{
 		Arguments: qmpapi.CapabilitiesRequestArguments{
 			Enable: greeting.Qmp.Capabilities,
 		},
 	})
-	if err != nil {
+
+	return err
+}
+
+func qmpClientHandshake(ctx context.Context, conn *net.Conn) (*qmpapi.QEMUMachineProtocolClient, error) {
+	qmpClient := qmpapi.NewQEMUMachineProtocolClient(*conn,
+		qmpapi.WithQEMUMachineProtocolClientTimeout(QMPTimeout),
+	)
+
+	if err := qmpClientNegotiate(ctx, qmpClient); err != nil {
+		qmpClient.Close()
 		return nil, err
 	}
 
@@ -586,6 +924,13 @@ func qmpClientHandshake(conn *net.Conn) (*qmpapi.QEMUMachineProtocolClient, erro
 }
 
 func (service *machineV1alpha1Service) QMPClient(ctx context.Context, machine *machinev1alpha1.Machine) (*qmpapi.QEMUMachineProtocolClient, error) {
+	return NewQMPClient(ctx, machine)
+}
+
+// NewQMPClient connects to the QMP control socket of the provided QEMU machine
+// and negotiates its capabilities, such that commands can be issued.  The
+// caller is responsible for closing the client.
+func NewQMPClient(ctx context.Context, machine *machinev1alpha1.Machine) (*qmpapi.QEMUMachineProtocolClient, error) {
 	qcfg, err := getQEMUConfigFromPlatformConfig(machine.Status.PlatformConfig)
 	if err != nil {
 		return nil, err
@@ -597,7 +942,7 @@ func (service *machineV1alpha1Service) QMPClient(ctx context.Context, machine *m
 		return nil, err
 	}
 
-	return qmpClientHandshake(&conn)
+	return qmpClientHandshake(ctx, &conn)
 }
 
 func processFromPidFile(pidFile string) (*goprocess.Process, error) {
@@ -629,23 +974,24 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 		return nil, nil, fmt.Errorf("cannot cast QEMU platform configuration from machine status")
 	}
 
-	// Always use index 1 for monitoring events
-	conn, err := qcfg.QMP[1].Connection()
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// Perform the handshake
-	_, err = qmpClientHandshake(&conn)
+	// Always use index 1 for monitoring events.  The connection is
+	// re-established and re-negotiated should the monitor socket be restarted.
+	qmpClient, err := qmpapi.NewQEMUMachineProtocolClientFromDialer(
+		func() (io.ReadWriteCloser, error) {
+			return qcfg.QMP[1].Connection()
+		},
+		qmpapi.WithQEMUMachineProtocolClientTimeout(QMPTimeout),
+		qmpapi.WithQEMUMachineProtocolClientOnConnect(func(qmpClient *qmpapi.QEMUMachineProtocolClient) error {
+			return qmpClientNegotiate(ctx, qmpClient)
+		}),
+	)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	monitor, err := qmp.NewQMPEventMonitor(conn,
-		qmpapi.EventTypes(),
-		nil,
-	)
+	qmpEvents, qmpErrs, err := qmpClient.SubscribeEvents(ctx)
 	if err != nil {
+		qmpClient.Close()
 		return nil, nil, err
 	}
 
@@ -653,7 +999,12 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 	// machine, so that it can be immediately acted upon.
 	firstCall := true
 
+	// panicked is set once the guest has reported a panic.
+	panicked := false
+
 	go func() {
+		defer qmpClient.Close()
+
 	accept:
 		for {
 			// First check if the context has been cancelled
@@ -677,10 +1028,20 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 			}
 
 			// Listen for changes in state
-			event, err := monitor.Accept()
-			if err != nil {
+			var event *qmpapi.Event
+			select {
+			case <-ctx.Done():
+				break accept
+			case err, ok := <-qmpErrs:
+				if !ok {
+					break accept
+				}
 				errs <- err
 				continue
+			case event = <-qmpEvents:
+				if event == nil {
+					break accept
+				}
 			}
 
 			// Send the event through the channel
@@ -699,6 +1060,12 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 
 			case qmpapi.EVENT_SHUTDOWN:
 				machine.Status.State = machinev1alpha1.MachineStateExited
+
+				// A shutdown which follows a panic does not replace its exit.
+				if !panicked {
+					service.recordExit(ctx, machine, event)
+				}
+
 				events <- machine
 
 				if !qcfg.NoShutdown {
@@ -706,6 +1073,8 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 				}
 			case qmpapi.EVENT_GUEST_PANICKED:
 				machine.Status.State = machinev1alpha1.MachineStateErrored
+				panicked = true
+				service.guestPanicked(ctx, machine, qcfg, QemuExitReasonGuestPanicked)
 				events <- machine
 
 				if !qcfg.NoShutdown {
@@ -735,7 +1104,7 @@ func (service *machineV1alpha1Service) Start(ctx context.Context, machine *machi
 	}
 
 	defer qmpClient.Close()
-	_, err = qmpClient.Cont(qmpapi.ContRequest{})
+	_, err = qmpClient.Cont(ctx, qmpapi.ContRequest{})
 	if err != nil {
 		return machine, err
 	}
@@ -750,9 +1119,26 @@ func (service *machineV1alpha1Service) Start(ctx context.Context, machine *machi
 		return machine, err
 	}
 
+	// Forget how the machine previously exited, if it did.
+	if err := vmm.ClearExit(machine.Status.StateDir); err != nil {
+		log.G(ctx).Debugf("could not clear exit of %s: %v", machine.Name, err)
+	}
+
+	if err := os.Remove(filepath.Join(machine.Status.StateDir, qemuDiagnosticsLockFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.G(ctx).Debugf("could not clear diagnostics of %s: %v", machine.Name, err)
+	}
+
 	machine.Status.Pid = process.Pid
 	machine.Status.State = machinev1alpha1.MachineStateRunning
 	machine.Status.StartedAt = time.Now()
+	machine.Status.ExitReason = ""
+	machine.Status.Diagnostics = ""
+
+	// Logs are followed in the background, so work on a copy of the machine.
+	follow := *machine
+	service.logs.Start(ctx, machine, func(ctx context.Context) (chan string, chan error, error) {
+		return service.Logs(ctx, &follow)
+	})
 
 	return machine, nil
 }
@@ -766,7 +1152,7 @@ func (service *machineV1alpha1Service) Pause(ctx context.Context, machine *machi
 
 	defer qmpClient.Close()
 
-	_, err = qmpClient.Stop(qmpapi.StopRequest{})
+	_, err = qmpClient.Stop(ctx, qmpapi.StopRequest{})
 	if err != nil {
 		return machine, err
 	}
@@ -818,6 +1204,8 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 	state := machinev1alpha1.MachineStateUnknown
 	savedState := machine.Status.State
 
+	service.logs.Rotate(ctx, machine)
+
 	qcfg, ok := machine.Status.PlatformConfig.(QemuConfig)
 	if !ok {
 		return machine, fmt.Errorf("cannot read QEMU platform configuration from machine status")
@@ -844,22 +1232,48 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		if err != nil {
 			state = machinev1alpha1.MachineStateExited
 		}
+
+		// The pid may have been re-assigned to an unrelated process after the
+		// VMM exited.
+		if activeProcess && !vmm.Owns(ctx, process.Pid, machine.Status.StateDir) {
+			activeProcess = false
+		}
 	}
 
 	exitedAt := machine.Status.ExitedAt
 	exitCode := machine.Status.ExitCode
+	exitReason := machine.Status.ExitReason
 
 	defer func() {
-		if exitCode >= 0 && machine.Status.ExitedAt.IsZero() {
+		// The exit reported by QEMU whilst it was shutting down is more accurate
+		// than what can be derived from its absence.
+		switch state {
+		case machinev1alpha1.MachineStateExited,
+			machinev1alpha1.MachineStateErrored,
+			machinev1alpha1.MachineStateFailed:
+			if exit, err := vmm.RecordedExit(machine.Status.StateDir); err != nil {
+				log.G(ctx).Debugf("could not read exit of %s: %v", machine.Name, err)
+			} else if exit != nil {
+				exitCode = exit.Code
+				exitReason = exit.Reason
+				exitedAt = exit.At
+				machine.Status.Diagnostics = exit.Diagnostics
+
+				if exit.Reason == vmm.ExitReasonDeadlineExceeded {
+					state = machinev1alpha1.MachineStateFailed
+				}
+			}
+		}
+
+		if exitCode >= 0 && exitedAt.IsZero() {
 			exitedAt = time.Now()
 		}
 
 		// Update the machine config with the latest values if they are different from
 		// what we have on record
-		if machine.Status.ExitedAt != exitedAt || machine.Status.ExitCode != exitCode {
-			machine.Status.ExitedAt = exitedAt
-			machine.Status.ExitCode = exitCode
-		}
+		machine.Status.ExitedAt = exitedAt
+		machine.Status.ExitCode = exitCode
+		machine.Status.ExitReason = exitReason
 
 		// Set the start time to now if it was not previously set
 		if machine.Status.StartedAt.IsZero() && state == machinev1alpha1.MachineStateRunning {
@@ -878,6 +1292,7 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		if savedState == machinev1alpha1.MachineStateRunning {
 			exitCode = 1
 		}
+		machine.Status.CurrentMemory = 0
 		return machine, nil
 	}
 
@@ -893,7 +1308,7 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 	defer qmpClient.Close()
 
 	// Grab the actual state of the machine by querying QMP
-	status, err := qmpClient.QueryStatus(qmpapi.QueryStatusRequest{})
+	status, err := qmpClient.QueryStatus(ctx, qmpapi.QueryStatusRequest{})
 	if err != nil {
 		// We cannot amend the status at this point, even if the process is
 		// alive, since it is not an indicator of the state of the VM, only of the
@@ -901,12 +1316,27 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		return machine, fmt.Errorf("could not query machine status via QMP: %v", err)
 	}
 
+	// Machines created without a balloon device do not report their memory
+	if balloon, err := qmpClient.QueryBalloon(ctx, qmpapi.QueryBalloonRequest{}); err == nil {
+		machine.Status.CurrentMemory = balloon.Return.Actual
+		if machine.Status.TargetMemory == 0 {
+			machine.Status.TargetMemory = machine.Spec.Resources.Requests.Memory().Value()
+		}
+	} else {
+		log.G(ctx).Debugf("could not query balloon of %s via QMP: %v", machine.Name, err)
+	}
+
 	// Map the QMP status to supported machine states
 	switch status.Return.Status {
 	case qmpapi.RUN_STATE_GUEST_PANICKED:
 		state = machinev1alpha1.MachineStateErrored
 		exitCode = 1
 
+		// The panic may not have been noticed yet, e.g. if the machine runs in
+		// the background.  QMP is only available to a single client at a time.
+		qmpClient.Close()
+		service.guestPanicked(ctx, machine, qcfg, QemuExitReasonGuestPanicked)
+
 	case qmpapi.RUN_STATE_INTERNAL_ERROR, qmpapi.RUN_STATE_IO_ERROR:
 		state = machinev1alpha1.MachineStateFailed
 		exitCode = 1
@@ -960,6 +1390,8 @@ func (service *machineV1alpha1Service) List(ctx context.Context, machines *machi
 
 // Stop implements kraftkit.sh/api/machine/v1alpha1.MachineService.Stop
 func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	service.logs.Stop(machine)
+
 	qmpClient, err := service.QMPClient(ctx, machine)
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "connect: no such file or directory") {
@@ -972,7 +1404,7 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 	}
 
 	defer qmpClient.Close()
-	_, err = qmpClient.Quit(qmpapi.QuitRequest{})
+	_, err = qmpClient.Quit(ctx, qmpapi.QuitRequest{})
 	if err != nil {
 		return machine, err
 	}
@@ -1004,6 +1436,8 @@ func (service *machineV1alpha1Service) Delete(ctx context.Context, machine *mach
 		return machine, fmt.Errorf("cannot read QEMU platform configuration from machine status")
 	}
 
+	service.logs.Stop(machine)
+
 	var errs merr.Errors
 
 	err := os.RemoveAll(machine.Status.StateDir)
@@ -1017,8 +1451,9 @@ func (service *machineV1alpha1Service) Delete(ctx context.Context, machine *mach
 	_ = os.Remove(qcfg.QMP[0].Resource())
 	_ = os.Remove(qcfg.QMP[1].Resource())
 
-	errs = append(errs, os.RemoveAll(machine.Status.LogFile))
+	errs = append(errs, logdriver.RemoveLogFile(machine))
 	errs = append(errs, os.RemoveAll(machine.Status.StateDir))
+	errs = append(errs, cgroup.Remove(machine.Status.Cgroup))
 
 	return nil, errs.Err()
 }
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/diagnostics"
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+	"kraftkit.sh/machine/vmm"
+)
+
+const (
+	// qemuDiagnosticsLockFile is the name of the file in the state directory of
+	// a machine which is created once diagnostics are captured for a panic,
+	// such that they are only captured once.
+	qemuDiagnosticsLockFile = "diagnostics.lock"
+
+	// qemuDumpTimeout bounds how long dumping the memory of a guest may take,
+	// which grows with the size of its memory.
+	qemuDumpTimeout = 5 * time.Minute
+)
+
+// guestPanicked captures a diagnostics bundle of the machine whose guest
+// panicked and records the exit of the machine.  The guest is paused when it
+// panics, see Create, such that its memory can still be dumped, after which
+// QEMU is asked to quit unless the machine should not shut down.
+//
+// Diagnostics are captured once per run of the machine, by whichever of the
+// watchers of its events or observers of its status notices the panic first.
+func (service *machineV1alpha1Service) guestPanicked(ctx context.Context, machine *machinev1alpha1.Machine, qcfg QemuConfig, reason string) {
+	lock, err := os.OpenFile(filepath.Join(machine.Status.StateDir, qemuDiagnosticsLockFile), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			log.G(ctx).Debugf("could not capture diagnostics of %s: %v", machine.Name, err)
+		}
+
+		return
+	}
+
+	lock.Close()
+
+	exit := vmm.Exit{
+		Code:   1,
+		Reason: reason,
+		At:     time.Now(),
+	}
+
+	bundle, err := service.captureDiagnostics(ctx, machine, qcfg, reason, exit.At)
+	if err != nil {
+		log.G(ctx).
+			WithField("machine", machine.Name).
+			Warnf("guest panicked but diagnostics could not be captured: %v", err)
+	} else {
+		exit.Diagnostics = bundle
+
+		log.G(ctx).
+			WithField("machine", machine.Name).
+			Warnf("guest panicked: diagnostics saved to %s, retrieve them with: kraft debug dump %s", bundle, machine.Name)
+	}
+
+	machine.Status.ExitCode = exit.Code
+	machine.Status.ExitReason = exit.Reason
+	machine.Status.ExitedAt = exit.At
+	machine.Status.Diagnostics = exit.Diagnostics
+
+	if err := vmm.RecordExit(machine.Status.StateDir, exit); err != nil {
+		log.G(ctx).Debugf("could not record exit of %s: %v", machine.Name, err)
+	}
+
+	if qcfg.NoShutdown || qcfg.Action.Panic != QemuActionPanicPause {
+		return
+	}
+
+	qmpClient, err := service.QMPClient(ctx, machine)
+	if err != nil {
+		log.G(ctx).Debugf("could not stop panicked machine %s: %v", machine.Name, err)
+		return
+	}
+
+	defer qmpClient.Close()
+
+	if _, err := qmpClient.Quit(ctx, qmpapi.QuitRequest{}); err != nil {
+		log.G(ctx).Debugf("could not stop panicked machine %s: %v", machine.Name, err)
+	}
+}
+
+// captureDiagnostics saves the memory of the guest and the tail of its console
+// output into a new diagnostics bundle and returns its path.  A bundle without
+// a memory dump is still kept, since the console output typically holds the
+// reason of the panic.
+func (service *machineV1alpha1Service) captureDiagnostics(ctx context.Context, machine *machinev1alpha1.Machine, qcfg QemuConfig, reason string, at time.Time) (string, error) {
+	bundle, err := diagnostics.New(config.G[config.KraftKit](ctx).RuntimeDir, machine.Name, at)
+	if err != nil {
+		return "", err
+	}
+
+	info := diagnostics.Info{
+		Machine:      machine.Name,
+		UID:          string(machine.UID),
+		Platform:     machine.Spec.Platform,
+		Architecture: machine.Spec.Architecture,
+		Kernel:       machine.Status.KernelPath,
+		Reason:       reason,
+		CapturedAt:   at,
+	}
+
+	if err := dumpGuestMemory(ctx, qcfg, filepath.Join(bundle, diagnostics.MemoryFile)); err != nil {
+		info.MemoryError = err.Error()
+		_ = os.Remove(filepath.Join(bundle, diagnostics.MemoryFile))
+	} else {
+		info.Memory = diagnostics.MemoryFile
+	}
+
+	if err := diagnostics.WriteConsole(bundle, machine.Status.LogFile, diagnostics.DefaultConsoleSize); err != nil {
+		log.G(ctx).Debugf("could not save console output of %s: %v", machine.Name, err)
+	}
+
+	if ok, err := diagnostics.WriteSymbols(bundle, machine.Status.KernelPath); err != nil {
+		log.G(ctx).Debugf("could not save symbols of %s: %v", machine.Name, err)
+		_ = os.Remove(filepath.Join(bundle, diagnostics.SymbolsFile))
+	} else if ok {
+		info.Symbols = diagnostics.SymbolsFile
+	}
+
+	if err := diagnostics.WriteInfo(bundle, info); err != nil {
+		return "", err
+	}
+
+	return bundle, nil
+}
+
+// dumpGuestMemory dumps the memory of the paused guest to the file at the
+// provided path in the ELF core format.  The dedicated connection allows the
+// dump to take longer than other QMP commands.
+func dumpGuestMemory(ctx context.Context, qcfg QemuConfig, path string) error {
+	conn, err := qcfg.QMP[0].Connection()
+	if err != nil {
+		return fmt.Errorf("could not connect to QMP socket: %w", err)
+	}
+
+	qmpClient := qmpapi.NewQEMUMachineProtocolClient(conn,
+		qmpapi.WithQEMUMachineProtocolClientTimeout(qemuDumpTimeout),
+	)
+
+	defer qmpClient.Close()
+
+	if err := qmpClientNegotiate(ctx, qmpClient); err != nil {
+		return err
+	}
+
+	if _, err := qmpClient.DumpGuestMemory(ctx, qmpapi.DumpGuestMemoryRequest{
+		Arguments: qmpapi.DumpGuestMemoryRequestArguments{
+			Protocol: "file:" + path,
+		},
+	}); err != nil {
+		return fmt.Errorf("could not dump guest memory: %w", err)
+	}
+
+	return nil
+}
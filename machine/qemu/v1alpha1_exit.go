@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"time"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+	"kraftkit.sh/machine/vmm"
+)
+
+// QemuExitReasonGuestPanicked is the exit reason of a machine whose guest
+// reported a panic, e.g. via the pvpanic device.
+const QemuExitReasonGuestPanicked = "guest-panicked"
+
+// qemuExit returns the exit of the machine which the QMP event reports.  The
+// reason of a SHUTDOWN event is one of QEMU's ShutdownCause values: a guest
+// panic, a reset, which is turned into an exit since machines are not
+// rebooted, or an error of QEMU itself are failures, whereas a shutdown by
+// the guest or a request of the host are not.
+func qemuExit(event *qmpapi.Event) (vmm.Exit, bool) {
+	switch event.Event {
+	case qmpapi.EVENT_GUEST_PANICKED:
+		return vmm.Exit{
+			Code:   1,
+			Reason: QemuExitReasonGuestPanicked,
+		}, true
+
+	case qmpapi.EVENT_SHUTDOWN:
+		exit := vmm.Exit{}
+
+		if data, ok := event.Data.(map[string]any); ok {
+			exit.Reason, _ = data["reason"].(string)
+		}
+
+		switch exit.Reason {
+		case "guest-panic", "guest-reset", "host-error":
+			exit.Code = 1
+		}
+
+		return exit, true
+	}
+
+	return vmm.Exit{}, false
+}
+
+// recordExit records the exit which the QMP event reports in the status of
+// the machine and in its state directory, such that it outlives QEMU.
+func (service *machineV1alpha1Service) recordExit(ctx context.Context, machine *machinev1alpha1.Machine, event *qmpapi.Event) {
+	exit, ok := qemuExit(event)
+	if !ok {
+		return
+	}
+
+	exit.At = time.Now()
+
+	machine.Status.ExitCode = exit.Code
+	machine.Status.ExitReason = exit.Reason
+	machine.Status.ExitedAt = exit.At
+
+	if err := vmm.RecordExit(machine.Status.StateDir, exit); err != nil {
+		log.G(ctx).
+			WithField("machine", machine.Name).
+			Debugf("could not record exit: %v", err)
+	}
+}
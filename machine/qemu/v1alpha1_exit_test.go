@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"testing"
+
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+)
+
+func TestQemuExit(t *testing.T) {
+	tests := []struct {
+		name   string
+		event  qmpapi.Event
+		ok     bool
+		code   int
+		reason string
+	}{
+		{
+			name:   "guest shutdown",
+			event:  qmpapi.Event{Event: qmpapi.EVENT_SHUTDOWN, Data: map[string]any{"guest": true, "reason": "guest-shutdown"}},
+			ok:     true,
+			code:   0,
+			reason: "guest-shutdown",
+		},
+		{
+			name:   "guest panic",
+			event:  qmpapi.Event{Event: qmpapi.EVENT_SHUTDOWN, Data: map[string]any{"guest": true, "reason": "guest-panic"}},
+			ok:     true,
+			code:   1,
+			reason: "guest-panic",
+		},
+		{
+			name:   "guest reset without reboot",
+			event:  qmpapi.Event{Event: qmpapi.EVENT_SHUTDOWN, Data: map[string]any{"guest": true, "reason": "guest-reset"}},
+			ok:     true,
+			code:   1,
+			reason: "guest-reset",
+		},
+		{
+			name:   "stopped by the host",
+			event:  qmpapi.Event{Event: qmpapi.EVENT_SHUTDOWN, Data: map[string]any{"guest": false, "reason": "host-qmp-quit"}},
+			ok:     true,
+			code:   0,
+			reason: "host-qmp-quit",
+		},
+		{
+			name:   "guest panicked",
+			event:  qmpapi.Event{Event: qmpapi.EVENT_GUEST_PANICKED, Data: map[string]any{"action": "pause"}},
+			ok:     true,
+			code:   1,
+			reason: QemuExitReasonGuestPanicked,
+		},
+		{
+			name:  "resumed",
+			event: qmpapi.Event{Event: qmpapi.EVENT_RESUME},
+			ok:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			exit, ok := qemuExit(&tc.event)
+			if ok != tc.ok {
+				t.Fatalf("expected ok to be %v, got %v", tc.ok, ok)
+			}
+
+			if exit.Code != tc.code || exit.Reason != tc.reason {
+				t.Errorf("expected exit %d (%s), got %d (%s)", tc.code, tc.reason, exit.Code, exit.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+func TestQemuMemoryMaxBytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		memory QemuMemory
+		want   uint64
+		err    bool
+	}{
+		{
+			name:   "megabytes are binary",
+			memory: QemuMemory{Size: 64, Unit: QemuMemoryUnitMB},
+			want:   64 << 20,
+		},
+		{
+			name:   "gigabytes are binary",
+			memory: QemuMemory{Size: 2, Unit: QemuMemoryUnitGB},
+			want:   2 << 30,
+		},
+		{
+			name:   "defaults",
+			memory: QemuMemory{},
+			want:   QemuMemoryDefault << 20,
+		},
+		{
+			name:   "hot-plug",
+			memory: QemuMemory{Size: 512, Unit: QemuMemoryUnitMB, Slots: 2, MaxMem: "4G"},
+			want:   4 << 30,
+		},
+		{
+			name:   "hot-plug in bytes",
+			memory: QemuMemory{Size: 512, Unit: QemuMemoryUnitMB, Slots: 2, MaxMem: "1073741824"},
+			want:   1 << 30,
+		},
+		{
+			name:   "unknown unit",
+			memory: QemuMemory{Size: 512, Unit: QemuMemoryUnitMB, MaxMem: "4X"},
+			err:    true,
+		},
+		{
+			name:   "no size",
+			memory: QemuMemory{Size: 512, Unit: QemuMemoryUnitMB, MaxMem: "G"},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.memory.MaxBytes()
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateMemoryBound(t *testing.T) {
+	tests := []struct {
+		name   string
+		memory QemuMemory
+		target string
+		grow   bool
+	}{
+		{
+			name:   "shrink",
+			memory: QemuMemory{Size: 256, Unit: QemuMemoryUnitMB},
+			target: "128Mi",
+		},
+		{
+			name:   "restore",
+			memory: QemuMemory{Size: 256, Unit: QemuMemoryUnitMB},
+			target: "256Mi",
+		},
+		{
+			name:   "grow",
+			memory: QemuMemory{Size: 256, Unit: QemuMemoryUnitMB},
+			target: "257Mi",
+			grow:   true,
+		},
+		{
+			name:   "grow within maximum memory",
+			memory: QemuMemory{Size: 256, Unit: QemuMemoryUnitMB, Slots: 1, MaxMem: "1G"},
+			target: "1Gi",
+		},
+		{
+			name:   "grow beyond maximum memory",
+			memory: QemuMemory{Size: 256, Unit: QemuMemoryUnitMB, Slots: 1, MaxMem: "1G"},
+			target: "2Gi",
+			grow:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := &machinev1alpha1.Machine{
+				Spec: machinev1alpha1.MachineSpec{
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse(tt.target),
+						},
+					},
+				},
+				Status: machinev1alpha1.MachineStatus{
+					State: machinev1alpha1.MachineStateRunning,
+					PlatformConfig: QemuConfig{
+						Memory: tt.memory,
+						// The machine is not running, hence any update within the bound
+						// fails when attaching to it.
+						QMP: []QemuHostCharDev{
+							QemuHostCharDevUnix{
+								SocketDir: t.TempDir(),
+								Name:      "qemu_control",
+							},
+						},
+					},
+				},
+			}
+
+			service := &machineV1alpha1Service{}
+
+			_, err := service.Update(context.Background(), machine)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			if grow := strings.Contains(err.Error(), "cannot grow memory"); grow != tt.grow {
+				t.Errorf("expected growing to be rejected: %t, got: %v", tt.grow, err)
+			}
+		})
+	}
+}
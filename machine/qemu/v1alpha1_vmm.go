@@ -0,0 +1,269 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/klauspost/cpuid"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+)
+
+// qemuBin returns the QEMU binary which runs the machine: the binary recorded
+// in its specification, otherwise the binary set in the configuration or the
+// well-known binary for its architecture.
+func qemuBin(ctx context.Context, machine *machinev1alpha1.Machine) (string, error) {
+	if machine.Spec.VMM.Binary != "" {
+		return machine.Spec.VMM.Binary, nil
+	}
+
+	if bin := config.G[config.KraftKit](ctx).Qemu; bin != "" {
+		return bin, nil
+	}
+
+	switch machine.Spec.Architecture {
+	case "x86_64", "amd64":
+		return QemuSystemX86, nil
+	case "arm":
+		return QemuSystemArm, nil
+	case "arm64":
+		return QemuSystemAarch64, nil
+	case "riscv64":
+		return QemuSystemRiscv64, nil
+	default:
+		return "", fmt.Errorf("unsupported architecture: %s", machine.Spec.Architecture)
+	}
+}
+
+// qemuMachineType returns the type of machine which QEMU emulates: the type
+// recorded in the machine's specification or the default for its
+// architecture.
+func qemuMachineType(machine *machinev1alpha1.Machine) QemuMachineType {
+	if machine.Spec.VMM.MachineType != "" {
+		return QemuMachineType(machine.Spec.VMM.MachineType)
+	}
+
+	switch machine.Spec.Architecture {
+	case "arm", "arm64", "riscv64":
+		return QemuMachineTypeVirt
+	default:
+		return QemuMachineTypePC
+	}
+}
+
+// qemuAccelerator returns the accelerator which QEMU uses to run the machine.
+// Unless set otherwise, x86_64 machines require KVM whereas Arm and RISC-V
+// machines, as well as machines whose architecture differs from the host's,
+// are emulated.  An accelerator which is explicitly requested must be available,
+// whereas automatic selection prefers the hypervisor of the host and
+// otherwise falls back to emulation.
+func qemuAccelerator(ctx context.Context, machine *machinev1alpha1.Machine, caps *QemuCapabilities) (QemuMachineAccelerator, error) {
+	requested := machine.Spec.VMM.Accelerator
+
+	if machine.Spec.Emulation {
+		if requested != "" && requested != machinev1alpha1.MachineAcceleratorAuto && requested != machinev1alpha1.MachineAcceleratorTCG {
+			return "", fmt.Errorf("cannot use accelerator %s when emulation is requested", requested)
+		}
+
+		requested = machinev1alpha1.MachineAcceleratorTCG
+	}
+
+	switch requested {
+	case "":
+		switch machine.Spec.Architecture {
+		case "x86_64", "amd64":
+			requested = machinev1alpha1.MachineAcceleratorKVM
+		default:
+			requested = machinev1alpha1.MachineAcceleratorTCG
+		}
+
+		if !hostArchitecture(machine) {
+			requested = machinev1alpha1.MachineAcceleratorTCG
+		}
+
+	case machinev1alpha1.MachineAcceleratorAuto:
+		if accel, ok := hostAccelerator(machine, caps); ok {
+			return accel, nil
+		}
+
+		if !caps.HasAccelerator(QemuMachineAccelTCG) {
+			return "", fmt.Errorf("no accelerator is available to run %s machines on this host", machine.Spec.Architecture)
+		}
+
+		log.G(ctx).
+			WithField("machine", machine.Name).
+			Warn("no hardware accelerator is available: falling back to software emulation (TCG), which is significantly slower")
+
+		return QemuMachineAccelTCG, nil
+	}
+
+	accel := QemuMachineAccelerator(requested)
+
+	if accel == QemuMachineAccelTCG {
+		if !caps.HasAccelerator(accel) {
+			return "", fmt.Errorf("emulation requested but TCG is not available")
+		}
+
+		return accel, nil
+	}
+
+	if !hostArchitecture(machine) {
+		return "", fmt.Errorf("cannot use accelerator %s to run %s machines on a %s host: use emulation instead", accel, machine.Spec.Architecture, normalizeArchitecture(runtime.GOARCH))
+	}
+
+	if !caps.HasAccelerator(accel) || (accel == QemuMachineAccelKVM && !kvmAvailable()) {
+		return "", fmt.Errorf("platform %s requested but it's not available: use --accel auto to fall back to emulation", accel)
+	}
+
+	return accel, nil
+}
+
+// hostAccelerator returns the hypervisor of the host if it is able to
+// accelerate the machine.
+func hostAccelerator(machine *machinev1alpha1.Machine, caps *QemuCapabilities) (QemuMachineAccelerator, bool) {
+	if !hostArchitecture(machine) {
+		return "", false
+	}
+
+	var accel QemuMachineAccelerator
+
+	switch runtime.GOOS {
+	case "linux":
+		if !kvmAvailable() {
+			return "", false
+		}
+
+		accel = QemuMachineAccelKVM
+	case "darwin":
+		accel = QemuMachineAccelHVF
+	case "windows":
+		accel = QemuMachineAccelWHPX
+	default:
+		return "", false
+	}
+
+	return accel, caps.HasAccelerator(accel)
+}
+
+// kvmAvailable returns whether KVM can be used by the current user.
+func kvmAvailable() bool {
+	f, err := os.OpenFile("/dev/kvm", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+
+	f.Close()
+
+	return true
+}
+
+// hostArchitecture returns whether the machine has the architecture of the
+// host, which is a prerequisite for hardware acceleration.
+func hostArchitecture(machine *machinev1alpha1.Machine) bool {
+	return normalizeArchitecture(runtime.GOARCH) == normalizeArchitecture(machine.Spec.Architecture)
+}
+
+// normalizeArchitecture returns the architecture in the notation used by
+// Unikraft, such that Go and Unikraft architectures can be compared.
+func normalizeArchitecture(arch string) string {
+	switch arch {
+	case "amd64":
+		return "x86_64"
+	default:
+		return arch
+	}
+}
+
+// customiseQemuCPU applies the model and features of the virtual CPU which are
+// requested in the machine's specification over the defaults of its
+// architecture.  Unless the machine is emulated, the guest can only be given
+// features which the host CPU supports.
+func customiseQemuCPU(cpu QemuCPU, machine *machinev1alpha1.Machine, accel QemuMachineAccelerator) (QemuCPU, error) {
+	if model := machine.Spec.VMM.CPUModel; model != "" {
+		switch cpu.CPU.(type) {
+		case QemuCPUX86:
+			cpu.CPU = QemuCPUX86(model)
+		case QemuCPUArm:
+			cpu.CPU = QemuCPUArm(model)
+		case QemuCPURiscv:
+			cpu.CPU = QemuCPURiscv(model)
+		}
+	}
+
+	for _, feature := range machine.Spec.VMM.CPUFeatures {
+		name, enabled, err := machinev1alpha1.ParseMachineCPUFeature(feature)
+		if err != nil {
+			return cpu, err
+		}
+
+		if enabled && accel != QemuMachineAccelTCG {
+			if supported, known := hostCPUFeature(name); known && !supported {
+				return cpu, fmt.Errorf("CPU feature %s is not supported by the host CPU: use emulation to enable it", name)
+			}
+		}
+
+		qfeature := QemuCPUFeature(name)
+		cpu.On = slices.DeleteFunc(cpu.On, func(f QemuCPUFeature) bool { return f == qfeature })
+		cpu.Off = slices.DeleteFunc(cpu.Off, func(f QemuCPUFeature) bool { return f == qfeature })
+
+		if enabled {
+			cpu.On = append(cpu.On, qfeature)
+		} else {
+			cpu.Off = append(cpu.Off, qfeature)
+		}
+	}
+
+	return cpu, nil
+}
+
+// hostCPUFeatureNames maps the names of CPU features in QEMU to the names under
+// which they are reported for the host, where they differ.
+var hostCPUFeatureNames = map[string]string{
+	"aes":       "AESNI",
+	"fma":       "FMA3",
+	"pclmulqdq": "CLMUL",
+	"sha-ni":    "SHA",
+	"sse4.1":    "SSE4",
+	"sse4.2":    "SSE42",
+	"abm":       "LZCNT",
+}
+
+// hostCPUFeature returns whether the host CPU supports the feature and whether
+// this could be determined at all, which is not the case for features which
+// are unknown to the CPUID probe.
+func hostCPUFeature(name string) (supported, known bool) {
+	host, ok := hostCPUFeatureNames[name]
+	if !ok {
+		host = strings.ToUpper(strings.NewReplacer("-", "", "_", "", ".", "").Replace(name))
+	}
+
+	var all, present []string
+
+	switch normalizeArchitecture(runtime.GOARCH) {
+	case "x86_64":
+		all = cpuid.Flags(math.MaxUint64).Strings()
+		present = cpuid.CPU.Features.Strings()
+	case "arm64":
+		all = cpuid.ArmFlags(math.MaxUint64).Strings()
+		present = cpuid.CPU.Arm.Strings()
+	default:
+		return false, false
+	}
+
+	if !slices.Contains(all, host) {
+		return false, false
+	}
+
+	return slices.Contains(present, host), true
+}
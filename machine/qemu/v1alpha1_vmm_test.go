@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// foreignArchitecture returns an architecture which differs from the host's.
+func foreignArchitecture() string {
+	if runtime.GOARCH == "riscv64" {
+		return "x86_64"
+	}
+
+	return "riscv64"
+}
+
+func TestQemuAcceleratorCrossArchitecture(t *testing.T) {
+	caps := &QemuCapabilities{
+		Accelerators: []QemuMachineAccelerator{QemuMachineAccelKVM, QemuMachineAccelTCG},
+	}
+
+	tests := []struct {
+		name      string
+		requested machinev1alpha1.MachineAccelerator
+		want      QemuMachineAccelerator
+		wantErr   bool
+	}{
+		{"default", "", QemuMachineAccelTCG, false},
+		{"auto", machinev1alpha1.MachineAcceleratorAuto, QemuMachineAccelTCG, false},
+		{"tcg", machinev1alpha1.MachineAcceleratorTCG, QemuMachineAccelTCG, false},
+		{"kvm", machinev1alpha1.MachineAcceleratorKVM, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := &machinev1alpha1.Machine{}
+			machine.Spec.Architecture = foreignArchitecture()
+			machine.Spec.VMM.Accelerator = tt.requested
+
+			got, err := qemuAccelerator(context.Background(), machine, caps)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("got accelerator %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQemuAcceleratorEmulation(t *testing.T) {
+	machine := &machinev1alpha1.Machine{}
+	machine.Spec.Architecture = "x86_64"
+	machine.Spec.Emulation = true
+	machine.Spec.VMM.Accelerator = machinev1alpha1.MachineAcceleratorKVM
+
+	if _, err := qemuAccelerator(context.Background(), machine, &QemuCapabilities{}); err == nil {
+		t.Error("expected an error when requesting KVM with emulation")
+	}
+}
+
+func TestCustomiseQemuCPU(t *testing.T) {
+	tests := []struct {
+		name     string
+		cpu      QemuCPU
+		model    string
+		features []string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "defaults",
+			cpu:  QemuCPU{CPU: QemuCPUX86Qemu64, On: QemuCPUFeatures{QemuCPUFeaturePdpe1gb}},
+			want: "qemu64,+pdpe1gb",
+		},
+		{
+			name:     "x86 model and features",
+			cpu:      QemuCPU{CPU: QemuCPUX86Qemu64, On: QemuCPUFeatures{QemuCPUFeaturePdpe1gb}, Off: QemuCPUFeatures{QemuCPUFeatureVmx}},
+			model:    "Skylake-Server",
+			features: []string{"+avx2", "-aes", "+vmx"},
+			want:     "Skylake-Server,+pdpe1gb,+avx2,+vmx,-aes",
+		},
+		{
+			name:     "arm features",
+			cpu:      QemuCPU{CPU: QemuCPUArmMax},
+			features: []string{"+sve", "-pauth"},
+			want:     "max,sve=on,pauth=off",
+		},
+		{
+			name:     "invalid feature",
+			cpu:      QemuCPU{CPU: QemuCPUX86Qemu64},
+			features: []string{"avx2"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := &machinev1alpha1.Machine{}
+			machine.Spec.VMM.CPUModel = tt.model
+			machine.Spec.VMM.CPUFeatures = tt.features
+
+			got, err := customiseQemuCPU(tt.cpu, machine, QemuMachineAccelTCG)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("got CPU %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
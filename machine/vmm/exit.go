@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package vmm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ExitFile is the name of the file in the state directory of a machine which
+// records how its VMM exited.
+const ExitFile = "exit.json"
+
+const (
+	// ExitReasonDeadlineExceeded is the reason of the exit of a machine which
+	// was stopped as it did not exit within the time it was given, e.g. with
+	// `kraft run --timeout`.  Such a machine is considered to have failed.
+	ExitReasonDeadlineExceeded = "DeadlineExceeded"
+
+	// ExitCodeDeadlineExceeded is the exit code of a machine which did not exit
+	// within the time it was given, as that of timeout(1).
+	ExitCodeDeadlineExceeded = 124
+)
+
+// Exit describes how the VMM of a machine exited.  VMMs are detached from the
+// process which started them, such that their exit status is otherwise lost
+// once they exit.
+type Exit struct {
+	// Code is the exit code of the machine.
+	Code int `json:"code"`
+
+	// Reason is the VMM-specific reason for the exit, e.g. `guest-panic`.
+	Reason string `json:"reason,omitempty"`
+
+	// At is the time at which the exit was observed.
+	At time.Time `json:"at"`
+
+	// Diagnostics is the path to the diagnostics bundle which was captured
+	// when the guest crashed, if any.
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+// RecordExit records the exit of the VMM of the machine whose state is kept in
+// stateDir, replacing any previously recorded exit.
+func RecordExit(stateDir string, exit Exit) error {
+	if exit.At.IsZero() {
+		exit.At = time.Now()
+	}
+
+	b, err := json.Marshal(exit)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, such that a concurrent reader never
+	// observes a partially written exit.
+	tmp, err := os.CreateTemp(stateDir, ExitFile+".*")
+	if err != nil {
+		return fmt.Errorf("could not record exit: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not record exit: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not record exit: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(stateDir, ExitFile))
+}
+
+// RecordedExit returns the recorded exit of the VMM of the machine whose state
+// is kept in stateDir, or nil if none was recorded.
+func RecordedExit(stateDir string) (*Exit, error) {
+	if stateDir == "" {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(filepath.Join(stateDir, ExitFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var exit Exit
+	if err := json.Unmarshal(b, &exit); err != nil {
+		return nil, fmt.Errorf("could not read recorded exit: %w", err)
+	}
+
+	return &exit, nil
+}
+
+// ClearExit removes the recorded exit of the VMM of the machine whose state is
+// kept in stateDir, e.g. when the machine is started again.
+func ClearExit(stateDir string) error {
+	if stateDir == "" {
+		return nil
+	}
+
+	if err := os.Remove(filepath.Join(stateDir, ExitFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
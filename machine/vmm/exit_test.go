@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package vmm
+
+import (
+	"testing"
+)
+
+func TestRecordExit(t *testing.T) {
+	stateDir := t.TempDir()
+
+	exit, err := RecordedExit(stateDir)
+	if err != nil || exit != nil {
+		t.Fatalf("expected no recorded exit, got %v (%v)", exit, err)
+	}
+
+	if err := RecordExit(stateDir, Exit{Code: 1, Reason: "guest-panic"}); err != nil {
+		t.Fatal(err)
+	}
+
+	exit, err = RecordedExit(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exit == nil || exit.Code != 1 || exit.Reason != "guest-panic" || exit.At.IsZero() {
+		t.Errorf("expected recorded guest panic, got %v", exit)
+	}
+
+	if err := ClearExit(stateDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if exit, _ := RecordedExit(stateDir); exit != nil {
+		t.Errorf("expected cleared exit, got %v", exit)
+	}
+}
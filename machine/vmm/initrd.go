@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package vmm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"kraftkit.sh/cpio"
+)
+
+// InitrdFile is the name of the file in the state directory of a machine which
+// holds its initramfs layers merged into one.
+const InitrdFile = "initramfs.cpio"
+
+// MergeInitrdLayers merges the initramfs layers into a single initramfs in the
+// state directory of the machine and returns its path.  VMMs accept only one
+// initramfs, hence the layers are merged as the guest would extract them: the
+// entries of a later layer replace those at the same path of earlier ones.
+func MergeInitrdLayers(stateDir string, layers []string) (string, error) {
+	archives := make([]io.ReadSeeker, 0, len(layers))
+	for _, layer := range layers {
+		f, err := os.Open(layer)
+		if err != nil {
+			return "", fmt.Errorf("could not open initramfs layer: %w", err)
+		}
+
+		defer f.Close()
+
+		archives = append(archives, f)
+	}
+
+	path := filepath.Join(stateDir, InitrdFile)
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("could not create initramfs: %w", err)
+	}
+
+	defer out.Close()
+
+	if err := cpio.Merge(out, archives...); err != nil {
+		return "", fmt.Errorf("could not merge initramfs layers: %w", err)
+	}
+
+	return path, nil
+}
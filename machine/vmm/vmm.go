@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package vmm locates the processes of the virtual machine monitors which
+// were started for machines.  Each VMM references the state directory of its
+// machine on its command-line, e.g. for its pid file or control socket, which
+// is used to tell apart the VMM of a machine from an unrelated process which
+// may have been assigned the same pid after the VMM exited.
+package vmm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	goprocess "github.com/shirou/gopsutil/v3/process"
+)
+
+// Process is a running VMM process.
+type Process struct {
+	// Pid of the process.
+	Pid int32
+
+	// Name of the executable, e.g. `qemu-system-x86_64`.
+	Name string
+
+	// StateDir is the state directory of the machine which the process
+	// references.
+	StateDir string
+
+	// CreatedAt is the time at which the process was started.
+	CreatedAt time.Time
+}
+
+// executables are the names of the supported VMMs.  Only processes whose
+// executable contains one of these are searched for.
+var executables = []string{
+	"qemu",
+	"firecracker",
+}
+
+// isVMM returns whether the executable name is one of a supported VMM.
+func isVMM(name string) bool {
+	for _, executable := range executables {
+		if strings.Contains(name, executable) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stateDirIn returns the state directory beneath runtimeDir which the
+// command-line arguments reference, if any.
+func stateDirIn(args []string, runtimeDir string) string {
+	prefix := filepath.Clean(runtimeDir) + string(filepath.Separator)
+
+	for _, arg := range args {
+		i := strings.Index(arg, prefix)
+		if i < 0 {
+			continue
+		}
+
+		rest := arg[i+len(prefix):]
+		if j := strings.IndexAny(rest, `/\,: `); j >= 0 {
+			rest = rest[:j]
+		}
+
+		if rest != "" {
+			return filepath.Join(runtimeDir, rest)
+		}
+	}
+
+	return ""
+}
+
+// Owns returns whether the process with the provided pid is alive and is the
+// VMM of the machine whose state is kept in stateDir.
+func Owns(ctx context.Context, pid int32, stateDir string) bool {
+	if pid <= 0 || stateDir == "" {
+		return false
+	}
+
+	process, err := goprocess.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return false
+	}
+
+	// Unlike when searching for VMMs, the name of the executable is not
+	// checked since it is configurable and the state directory of a machine is
+	// unique.
+	args, err := process.CmdlineSliceWithContext(ctx)
+	if err != nil {
+		return false
+	}
+
+	return stateDirIn(args, filepath.Dir(filepath.Clean(stateDir))) == filepath.Clean(stateDir)
+}
+
+// Find returns the running VMM processes which reference a state directory
+// beneath runtimeDir.
+func Find(ctx context.Context, runtimeDir string) ([]Process, error) {
+	processes, err := goprocess.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list processes: %w", err)
+	}
+
+	var ret []Process
+
+	for _, process := range processes {
+		// Processes may exit or be inaccessible whilst they are inspected,
+		// neither of which are an error.
+		name, err := process.NameWithContext(ctx)
+		if err != nil || !isVMM(name) {
+			continue
+		}
+
+		args, err := process.CmdlineSliceWithContext(ctx)
+		if err != nil {
+			continue
+		}
+
+		stateDir := stateDirIn(args, runtimeDir)
+		if stateDir == "" {
+			continue
+		}
+
+		created, err := process.CreateTimeWithContext(ctx)
+		if err != nil {
+			continue
+		}
+
+		ret = append(ret, Process{
+			Pid:       process.Pid,
+			Name:      name,
+			StateDir:  stateDir,
+			CreatedAt: time.UnixMilli(created),
+		})
+	}
+
+	return ret, nil
+}
+
+// Terminate asks the process to exit and kills it if it is still alive after
+// the grace period.
+func Terminate(ctx context.Context, pid int32, grace time.Duration) error {
+	process, err := goprocess.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		// The process has already exited.
+		return nil
+	}
+
+	if err := process.TerminateWithContext(ctx); err != nil {
+		return fmt.Errorf("could not terminate process %d: %w", pid, err)
+	}
+
+	deadline := time.Now().Add(grace)
+
+	for time.Now().Before(deadline) {
+		if running, err := process.IsRunningWithContext(ctx); err != nil || !running {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	if running, err := process.IsRunningWithContext(ctx); err != nil || !running {
+		return nil
+	}
+
+	if err := process.KillWithContext(ctx); err != nil {
+		return fmt.Errorf("could not kill process %d: %w", pid, err)
+	}
+
+	return nil
+}
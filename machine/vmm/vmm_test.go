@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package vmm
+
+import (
+	"testing"
+)
+
+func TestStateDirIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "qemu pid file",
+			args:     []string{"qemu-system-x86_64", "-pidfile", "/run/kraftkit/7c3a/machine.pid"},
+			expected: "/run/kraftkit/7c3a",
+		},
+		{
+			name:     "qemu qmp socket option",
+			args:     []string{"qemu-system-x86_64", "-qmp", "unix:/run/kraftkit/7c3a/qemu_control.sock,server,nowait"},
+			expected: "/run/kraftkit/7c3a",
+		},
+		{
+			name:     "firecracker api socket",
+			args:     []string{"firecracker", "--api-sock", "/run/kraftkit/9f1e/firecracker.sock"},
+			expected: "/run/kraftkit/9f1e",
+		},
+		{
+			name:     "outside of runtime directory",
+			args:     []string{"qemu-system-x86_64", "-pidfile", "/tmp/7c3a/machine.pid"},
+			expected: "",
+		},
+		{
+			name:     "runtime directory itself",
+			args:     []string{"qemu-system-x86_64", "/run/kraftkit/"},
+			expected: "",
+		},
+		{
+			name:     "sibling with common prefix",
+			args:     []string{"qemu-system-x86_64", "-pidfile", "/run/kraftkit-other/7c3a/machine.pid"},
+			expected: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := stateDirIn(tc.args, "/run/kraftkit"); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package ninepfs
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// OptionMsize is the maximum size in bytes of a 9P message which is
+	// negotiated by the guest.  Larger messages allow for fewer round trips
+	// between the guest and the host when reading and writing files.
+	OptionMsize = "msize"
+
+	// OptionCache is the caching mode of the file system in the guest.
+	OptionCache = "cache"
+
+	// OptionSecurityModel is the security model with which the host exposes the
+	// file system, which determines how file ownership and permissions are
+	// stored.
+	OptionSecurityModel = "security_model"
+)
+
+const (
+	// DefaultMsize is the default maximum size of a 9P message.  The value
+	// which is otherwise negotiated is small enough that the throughput of
+	// IO-heavy workloads is dominated by the number of messages.
+	DefaultMsize = 512 * 1024
+
+	// DefaultCache is the default caching mode, which caches file contents for
+	// memory mapped files only such that changes on the host remain visible.
+	DefaultCache = "mmap"
+
+	// DefaultSecurityModel is the default security model, which stores file
+	// ownership and permissions as they are on the host.
+	DefaultSecurityModel = "passthrough"
+
+	// minMsize is the smallest message size supported by the 9P protocol.
+	minMsize = 4096
+)
+
+// CacheModes returns the supported caching modes.
+func CacheModes() []string {
+	return []string{"none", "loose", "fscache", "mmap"}
+}
+
+// SecurityModels returns the supported security models.
+func SecurityModels() []string {
+	return []string{"passthrough", "mapped-xattr", "mapped-file", "none"}
+}
+
+// Options validates the provided options of a 9pfs volume and returns them
+// with the default of every option which is not set.
+func Options(options map[string]string) (map[string]string, error) {
+	ret := map[string]string{
+		OptionMsize:         strconv.Itoa(DefaultMsize),
+		OptionCache:         DefaultCache,
+		OptionSecurityModel: DefaultSecurityModel,
+	}
+
+	for key, value := range options {
+		switch key {
+		case OptionMsize:
+			msize, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s '%s': %w", key, value, err)
+			}
+
+			if msize < minMsize {
+				return nil, fmt.Errorf("invalid %s '%s': must be at least %d", key, value, minMsize)
+			}
+
+		case OptionCache:
+			if !slices.Contains(CacheModes(), value) {
+				return nil, fmt.Errorf("invalid %s '%s': expected one of %s", key, value, strings.Join(CacheModes(), ", "))
+			}
+
+		case OptionSecurityModel:
+			if !slices.Contains(SecurityModels(), value) {
+				return nil, fmt.Errorf("invalid %s '%s': expected one of %s", key, value, strings.Join(SecurityModels(), ", "))
+			}
+
+		default:
+			return nil, fmt.Errorf("unknown 9pfs volume option '%s'", key)
+		}
+
+		ret[key] = value
+	}
+
+	return ret, nil
+}
+
+// MountOptions returns the options which are passed to the guest when it
+// mounts the file system, in the form `key=value,...`.
+func MountOptions(options map[string]string) string {
+	var ret []string
+
+	for _, key := range []string{OptionMsize, OptionCache} {
+		if value, ok := options[key]; ok {
+			ret = append(ret, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+
+	return strings.Join(ret, ",")
+}
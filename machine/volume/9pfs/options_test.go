@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package ninepfs
+
+import "testing"
+
+func TestOptions(t *testing.T) {
+	options, err := Options(map[string]string{
+		OptionCache: "none",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect, got := "msize=524288,cache=none", MountOptions(options); expect != got {
+		t.Errorf("expected mount options %q, got %q", expect, got)
+	}
+
+	if expect, got := DefaultSecurityModel, options[OptionSecurityModel]; expect != got {
+		t.Errorf("expected security model %q, got %q", expect, got)
+	}
+
+	for _, invalid := range []map[string]string{
+		{OptionMsize: "1024"},
+		{OptionMsize: "big"},
+		{OptionCache: "always"},
+		{OptionSecurityModel: "mapped"},
+		{"trans": "virtio"},
+	} {
+		if _, err := Options(invalid); err == nil {
+			t.Errorf("expected options %v to be invalid", invalid)
+		}
+	}
+}
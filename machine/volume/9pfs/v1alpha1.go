@@ -33,6 +33,11 @@ func (*v1alpha1Volume) Create(ctx context.Context, volume *volumev1alpha1.Volume
 		return volume, fmt.Errorf("cannot use 9pfs driver when driver set to %s", volume.Spec.Driver)
 	}
 
+	volume.Spec.Options, err = Options(volume.Spec.Options)
+	if err != nil {
+		return volume, err
+	}
+
 	if volume.ObjectMeta.UID == "" {
 		volume.ObjectMeta.UID = uuid.NewUUID()
 	}
@@ -11,6 +11,7 @@ import (
 	zip "api.zip"
 	"github.com/acorn-io/baaah/pkg/merr"
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/store"
 )
 
 type volumeV1alpha1ServiceIterator struct {
@@ -111,7 +112,11 @@ func (iterator *volumeV1alpha1ServiceIterator) List(ctx context.Context, cached
 	found := []zip.Object[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus]{}
 
 	for _, strategy := range iterator.strategies {
-		ret, err := strategy.List(ctx, &volumev1alpha1.VolumeList{})
+		var ret *volumev1alpha1.VolumeList
+		err := store.Retry(ctx, func() (err error) {
+			ret, err = strategy.List(ctx, &volumev1alpha1.VolumeList{})
+			return err
+		})
 		if err != nil {
 			continue
 		}
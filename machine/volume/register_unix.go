@@ -6,15 +6,12 @@ package volume
 
 import (
 	"context"
-	"path/filepath"
 
 	zip "api.zip"
 
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
-	"kraftkit.sh/config"
 	"kraftkit.sh/kconfig"
 	ninepfs "kraftkit.sh/machine/volume/9pfs"
-	"kraftkit.sh/store"
 )
 
 var defaultStrategyName = "9pfs"
@@ -38,12 +35,7 @@ func hostSupportedStrategies() map[string]*Strategy {
 					return nil, err
 				}
 
-				embeddedStore, err := store.NewEmbeddedStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](
-					filepath.Join(
-						config.G[config.KraftKit](ctx).RuntimeDir,
-						"volumev1alpha1",
-					),
-				)
+				embeddedStore, err := storeV1alpha1(ctx)
 				if err != nil {
 					return nil, err
 				}
@@ -54,6 +46,7 @@ func hostSupportedStrategies() map[string]*Strategy {
 					zip.WithStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](embeddedStore, zip.StoreRehydrationSpecNil),
 				)
 			},
+			NewDriverV1alpha1: ninepfs.NewVolumeServiceV1alpha1,
 		},
 	}
 }
@@ -30,6 +30,11 @@ func DefaultStrategyName() string {
 type Strategy struct {
 	IsCompatible      func(string, kconfig.KeyValueMap) (bool, error)
 	NewVolumeV1alpha1 NewStrategyConstructor[volumev1alpha1.VolumeService]
+
+	// NewDriverV1alpha1 instantiates the driver of the strategy without the
+	// store of volumes, such that its changes can be stored atomically, see
+	// UpdateV1alpha1.
+	NewDriverV1alpha1 NewStrategyConstructor[volumev1alpha1.VolumeService]
 }
 
 // Strategies returns the list of registered platform implementations.
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	zip "api.zip"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/storage"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/store"
+)
+
+// storeV1alpha1 returns the store of the volumes of the host.
+func storeV1alpha1(ctx context.Context) (zip.Store, error) {
+	return store.New[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](
+		store.Backend(config.G[config.KraftKit](ctx).StoreBackend),
+		filepath.Join(
+			config.G[config.KraftKit](ctx).RuntimeDir,
+			"volumev1alpha1",
+		),
+	)
+}
+
+// UpdateV1alpha1 atomically updates the volume with the provided name.  The
+// volume as it is stored is passed to update, after which the changes are
+// applied by the driver of the volume and stored, all whilst no other process
+// can access the store of volumes.  Unlike updating a volume which has been
+// retrieved beforehand, e.g. as part of the specification of a machine, other
+// changes to the volume in the meantime are not overwritten.
+func UpdateV1alpha1(ctx context.Context, name string, update func(*volumev1alpha1.Volume) error) (*volumev1alpha1.Volume, error) {
+	return updateV1alpha1(ctx, storeV1alpha1, Strategies(), name, update)
+}
+
+// updateV1alpha1 implements UpdateV1alpha1 with the provided store and
+// strategies.
+func updateV1alpha1(ctx context.Context, newStore func(context.Context) (zip.Store, error), strategies map[string]*Strategy, name string, update func(*volumev1alpha1.Volume) error) (*volumev1alpha1.Volume, error) {
+	s, err := newStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := &volumev1alpha1.Volume{}
+
+	if err := s.GuaranteedUpdate(ctx, name, volume, false, nil, func(input runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+		existing := input.(*volumev1alpha1.Volume)
+
+		strategy, ok := strategies[existing.Spec.Driver]
+		if !ok || strategy.NewDriverV1alpha1 == nil {
+			return nil, nil, fmt.Errorf("volume driver '%s' does not support atomic updates", existing.Spec.Driver)
+		}
+
+		if err := update(existing); err != nil {
+			return nil, nil, err
+		}
+
+		driver, err := strategy.NewDriverV1alpha1(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		updated, err := driver.Update(ctx, existing)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return updated, nil, nil
+	}, nil); err != nil {
+		return nil, fmt.Errorf("could not update volume %s: %w", name, err)
+	}
+
+	return volume, nil
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package manifest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	"kraftkit.sh/config"
+)
+
+// authFor returns the credentials registered for the provided source or, if
+// there are none, those registered for its host.  Nil is returned if neither
+// has credentials.
+func authFor(auths map[string]config.AuthConfig, source, host string) *config.AuthConfig {
+	if auth, ok := auths[source]; ok {
+		return &auth
+	}
+
+	if auth, ok := auths[host]; ok {
+		return &auth
+	}
+
+	return nil
+}
+
+// caBundle returns the contents of the certificate authority bundle of the
+// provided credentials, if one has been set.
+func caBundle(auth *config.AuthConfig) ([]byte, error) {
+	if auth == nil || auth.CACert == "" {
+		return nil, nil
+	}
+
+	bundle, err := os.ReadFile(auth.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA bundle: %w", err)
+	}
+
+	return bundle, nil
+}
+
+// newHTTPClient returns an HTTP client which honours the TLS settings of the
+// provided credentials, i.e. whether to verify the server's certificate and
+// which additional certificate authorities to trust.
+func newHTTPClient(auth *config.AuthConfig) (*http.Client, error) {
+	if auth == nil || (auth.VerifySSL && auth.CACert == "") {
+		return &http.Client{}, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+
+	if !auth.VerifySSL {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+
+	bundle, err := caBundle(auth)
+	if err != nil {
+		return nil, err
+	} else if bundle != nil {
+		pool := transport.TLSClientConfig.RootCAs
+		if pool == nil {
+			if pool, err = x509.SystemCertPool(); err != nil {
+				pool = x509.NewCertPool()
+			}
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("could not find any certificates in CA bundle '%s'", auth.CACert)
+		}
+
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// setAuthorization sets the Authorization header of the request based on the
+// provided credentials, using basic authentication if a user is set and a
+// bearer token otherwise.  It returns whether the request is authenticated.
+func setAuthorization(req *http.Request, auth *config.AuthConfig) bool {
+	if auth == nil {
+		return false
+	}
+
+	if len(auth.User) > 0 {
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.
+			EncodeToString([]byte(auth.User+":"+auth.Token)))
+		return true
+	} else if len(auth.Token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+		return true
+	}
+
+	return false
+}
+
+// gitAuth returns the method used to authenticate against a Git repository.
+// For SSH repositories, the private key of the credentials is used if set, in
+// which case the token is its passphrase, and otherwise the SSH agent.  For
+// HTTP(S) repositories, the user and token of the credentials are used.
+func gitAuth(auth *config.AuthConfig, user string, isSSH bool) (transport.AuthMethod, error) {
+	if isSSH {
+		if auth != nil && auth.SSHKey != "" {
+			keys, err := gitssh.NewPublicKeysFromFile(user, auth.SSHKey, auth.Token)
+			if err != nil {
+				return nil, fmt.Errorf("could not load SSH key: %w", err)
+			}
+
+			return keys, nil
+		}
+
+		return gitssh.DefaultAuthBuilder(user)
+	}
+
+	if auth == nil {
+		return nil, nil
+	}
+
+	if len(auth.User) > 0 {
+		return &githttp.BasicAuth{
+			Username: auth.User,
+			Password: auth.Token,
+		}, nil
+	} else if len(auth.Token) > 0 {
+		return &githttp.TokenAuth{
+			Token: auth.Token,
+		}, nil
+	}
+
+	return nil, nil
+}
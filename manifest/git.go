@@ -13,8 +13,6 @@ import (
 	"github.com/go-git/go-git/v5"
 	gitconfig "github.com/go-git/go-git/v5/config"
 	gitplumbing "github.com/go-git/go-git/v5/plumbing"
-	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
-	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	giturl "github.com/kubescape/go-git-url"
 
 	"kraftkit.sh/log"
@@ -66,27 +64,24 @@ func NewGitProvider(ctx context.Context, path string, opts ...ManifestOption) (P
 		ctx:    ctx,
 	}
 
-	if isSSH {
-		user := gitURL.GetURL().User.Username()
-		if user == "" {
-			user = "git"
-		}
+	user := gitURL.GetURL().User.Username()
+	if user == "" {
+		user = "git"
+	}
+
+	auth := authFor(provider.mopts.auths, path, gitURL.GetHostName())
+
+	lopts.Auth, err = gitAuth(auth, user, isSSH)
+	if err != nil {
+		return nil, err
+	}
 
-		lopts.Auth, err = gitssh.DefaultAuthBuilder(user)
+	if auth != nil && !isSSH {
+		lopts.InsecureSkipTLS = !auth.VerifySSL
+		lopts.CABundle, err = caBundle(auth)
 		if err != nil {
 			return nil, err
 		}
-	} else if auth, ok := provider.mopts.auths[gitURL.GetHostName()]; ok {
-		if len(auth.User) > 0 {
-			lopts.Auth = &githttp.BasicAuth{
-				Username: auth.User,
-				Password: auth.Token,
-			}
-		} else if len(auth.Token) > 0 {
-			lopts.Auth = &githttp.TokenAuth{
-				Token: auth.Token,
-			}
-		}
 	}
 
 	// If this is a valid Git repository then let's generate a Manifest based on
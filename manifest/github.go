@@ -59,12 +59,14 @@ func NewGitHubProvider(ctx context.Context, path string, opts ...ManifestOption)
 	provider.client = github.NewClient(nil)
 	if ghauth, ok := provider.mopts.auths[repo.RepoHost()]; ok {
 		if !ghauth.VerifySSL {
+			// Derive from the default transport such that proxy settings are kept.
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+
 			insecureClient := &http.Client{
-				Transport: &http.Transport{
-					TLSClientConfig: &tls.Config{
-						InsecureSkipVerify: true,
-					},
-				},
+				Transport: transport,
 			}
 
 			ctx = context.WithValue(
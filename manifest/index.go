@@ -20,6 +20,7 @@ import (
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
 
+	"kraftkit.sh/internal/metacache"
 	"kraftkit.sh/internal/version"
 )
 
@@ -138,14 +139,46 @@ func NewManifestIndexFromFile(path string, mopts ...ManifestOption) (*ManifestIn
 }
 
 // NewManifestFromURL retrieves a provided path as a ManifestIndex from a remote
-// location over HTTP
+// location over HTTP.  The retrieved index is cached on disk such that it is
+// not retrieved again until the cache expires.
 func NewManifestIndexFromURL(ctx context.Context, path string, mopts ...ManifestOption) (*ManifestIndex, error) {
-	_, err := url.Parse(path)
+	// Check if we're directly pointing to a compatible manifest file
+	ext := filepath.Ext(path)
+	if ext != ".yml" && ext != ".yaml" {
+		return nil, fmt.Errorf("unsupported manifest index extension for path: %s", path)
+	}
+
+	contents, err := metacache.G(ctx).Fetch(ctx, "manifest-index:"+path, func() ([]byte, error) {
+		return fetchManifestIndex(ctx, path, mopts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := NewManifestIndexFromBytes(contents, mopts...)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{}
+	index.Origin = path
+
+	return index, nil
+}
+
+// fetchManifestIndex retrieves the contents of the manifest index at the
+// provided path over HTTP.
+func fetchManifestIndex(ctx context.Context, path string, mopts ...ManifestOption) ([]byte, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := authFor(NewManifestOptions(mopts...).auths, path, u.Host)
+
+	client, err := newHTTPClient(auth)
+	if err != nil {
+		return nil, err
+	}
 
 	head, err := http.NewRequestWithContext(ctx, "HEAD", path, nil)
 	if err != nil {
@@ -153,6 +186,7 @@ func NewManifestIndexFromURL(ctx context.Context, path string, mopts ...Manifest
 	}
 
 	head.Header.Set("User-Agent", version.UserAgent())
+	setAuthorization(head, auth)
 
 	log.G(ctx).WithFields(logrus.Fields{
 		"url":    path,
@@ -174,6 +208,7 @@ func NewManifestIndexFromURL(ctx context.Context, path string, mopts ...Manifest
 	}
 
 	get.Header.Set("User-Agent", version.UserAgent())
+	setAuthorization(get, auth)
 
 	log.G(ctx).WithFields(logrus.Fields{
 		"url":    path,
@@ -190,25 +225,7 @@ func NewManifestIndexFromURL(ctx context.Context, path string, mopts ...Manifest
 		return nil, fmt.Errorf("received %d error when retreiving: %s", resp.StatusCode, path)
 	}
 
-	// Check if we're directly pointing to a compatible manifest file
-	ext := filepath.Ext(path)
-	if ext != ".yml" && ext != ".yaml" {
-		return nil, fmt.Errorf("unsupported manifest index extension for path: %s", path)
-	}
-
-	contents, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	index, err := NewManifestIndexFromBytes(contents, mopts...)
-	if err != nil {
-		return nil, err
-	}
-
-	index.Origin = path
-
-	return index, nil
+	return io.ReadAll(resp.Body)
 }
 
 func (mi *ManifestIndex) WriteToFile(path string) error {
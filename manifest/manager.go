@@ -125,6 +125,10 @@ func (m *manifestManager) update(ctx context.Context) (*ManifestIndex, error) {
 }
 
 func (m *manifestManager) Update(ctx context.Context) error {
+	if packmanager.IsOffline(ctx) {
+		return fmt.Errorf("could not update manifest index: %w", packmanager.ErrOffline)
+	}
+
 	index, err := m.update(ctx)
 	if err != nil {
 		return err
@@ -285,12 +289,21 @@ func (m *manifestManager) Catalog(ctx context.Context, qopts ...packmanager.Quer
 	var manifests []*Manifest
 
 	query := packmanager.NewQuery(qopts...)
+	auths := query.Auths()
+	if auths == nil {
+		auths = config.G[config.KraftKit](ctx).Auth
+	}
+
 	mopts := []ManifestOption{
-		WithAuthConfig(query.Auths()),
+		WithAuthConfig(auths),
 		WithCacheDir(config.G[config.KraftKit](ctx).Paths.Sources),
 		WithUpdate(query.Remote()),
 	}
 
+	if query.Remote() && packmanager.IsOffline(ctx) {
+		return nil, fmt.Errorf("could not search remote manifest catalog: %w", packmanager.ErrOffline)
+	}
+
 	log.G(ctx).WithFields(query.Fields()).Debug("querying manifest catalog")
 
 	if len(query.Source()) > 0 {
@@ -400,6 +413,15 @@ func (m *manifestManager) Catalog(ctx context.Context, qopts ...packmanager.Quer
 				}
 			}
 
+			if len(versions) == 0 && IsVersionConstraint(version) {
+				latest, err := manifest.LatestVersionSatisfying(version)
+				if err != nil {
+					log.G(ctx).Debug(err)
+				} else {
+					versions = append(versions, latest.Version)
+				}
+			}
+
 			if len(versions) == 0 {
 				break
 			}
@@ -480,8 +502,15 @@ func (m *manifestManager) IsCompatible(ctx context.Context, source string, qopts
 		return m, true, nil
 	}
 
+	query := packmanager.NewQuery(qopts...)
+	auths := query.Auths()
+	if auths == nil {
+		auths = config.G[config.KraftKit](ctx).Auth
+	}
+
 	if _, err := NewProvider(ctx, source,
-		WithUpdate(packmanager.NewQuery(qopts...).Remote()),
+		WithAuthConfig(auths),
+		WithUpdate(query.Remote()),
 	); err != nil {
 		return nil, false, fmt.Errorf("incompatible source: %w", err)
 	}
@@ -276,7 +276,13 @@ func NewManifestFromURL(ctx context.Context, path string, mopts ...ManifestOptio
 	}
 
 	var contents []byte
-	client := &http.Client{}
+
+	auth := authFor(NewManifestOptions(mopts...).auths, path, u.Host)
+
+	client, err := newHTTPClient(auth)
+	if err != nil {
+		return nil, err
+	}
 
 	head, err := http.NewRequestWithContext(ctx, "HEAD", path, nil)
 	if err != nil {
@@ -284,6 +290,7 @@ func NewManifestFromURL(ctx context.Context, path string, mopts ...ManifestOptio
 	}
 
 	head.Header.Set("User-Agent", version.UserAgent())
+	setAuthorization(head, auth)
 
 	log.G(ctx).WithFields(logrus.Fields{
 		"url":    path,
@@ -305,6 +312,7 @@ func NewManifestFromURL(ctx context.Context, path string, mopts ...ManifestOptio
 	}
 
 	get.Header.Set("User-Agent", version.UserAgent())
+	setAuthorization(get, auth)
 
 	log.G(ctx).WithFields(logrus.Fields{
 		"url":    path,
@@ -7,7 +7,6 @@ package manifest
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
@@ -21,6 +20,7 @@ import (
 	"kraftkit.sh/internal/version"
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
 	"kraftkit.sh/unikraft"
 )
 
@@ -60,26 +60,26 @@ func pullArchive(ctx context.Context, manifest *Manifest, opts ...pack.PullOptio
 	}
 
 	if f, err := os.Stat(cache); !popts.UseCache() || err != nil || f.Size() == 0 {
+		if packmanager.IsOffline(ctx) {
+			return fmt.Errorf("could not download %s: %w", resource, packmanager.ErrOffline)
+		}
+
 		u, err := url.Parse(resource)
 		if err != nil {
 			return err
 		}
 
-		authHeader := ""
-		authenticated := false
-
-		if auth := popts.Auths(u.Host); auth != nil {
-			if len(auth.User) > 0 {
-				authenticated = true
-				authHeader = "Basic " + base64.StdEncoding.
-					EncodeToString([]byte(auth.User+":"+auth.Token))
-			} else if len(auth.Token) > 0 {
-				authenticated = true
-				authHeader = "Bearer " + auth.Token
-			}
+		// Prefer the credentials registered for this exact resource over those
+		// of its host.
+		auth := popts.Auths(resource)
+		if auth == nil {
+			auth = popts.Auths(u.Host)
 		}
 
-		client := &http.Client{}
+		client, err := newHTTPClient(auth)
+		if err != nil {
+			return err
+		}
 
 		head, err := http.NewRequestWithContext(ctx, "HEAD", resource, nil)
 		if err != nil {
@@ -87,9 +87,7 @@ func pullArchive(ctx context.Context, manifest *Manifest, opts ...pack.PullOptio
 		}
 
 		head.Header.Set("User-Agent", version.UserAgent())
-		if authenticated {
-			head.Header.Set("Authorization", authHeader)
-		}
+		authenticated := setAuthorization(head, auth)
 
 		log.G(ctx).WithFields(logrus.Fields{
 			"url":           resource,
@@ -128,9 +126,7 @@ func pullArchive(ctx context.Context, manifest *Manifest, opts ...pack.PullOptio
 		}
 
 		get.Header.Set("User-Agent", version.UserAgent())
-		if authenticated {
-			head.Header.Set("Authorization", authHeader)
-		}
+		setAuthorization(get, auth)
 
 		log.G(ctx).WithFields(logrus.Fields{
 			"url":           resource,
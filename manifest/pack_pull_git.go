@@ -17,11 +17,10 @@ import (
 
 	"github.com/go-git/go-git/v5"
 	gitplumbing "github.com/go-git/go-git/v5/plumbing"
-	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
-	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
 	"kraftkit.sh/unikraft"
 )
 
@@ -116,6 +115,10 @@ func pullGit(ctx context.Context, manifest *Manifest, opts ...pack.PullOption) e
 		return fmt.Errorf("requesting Git with empty repository in manifest")
 	}
 
+	if packmanager.IsOffline(ctx) {
+		return fmt.Errorf("could not clone %s: %w", manifest.Origin, packmanager.ErrOffline)
+	}
+
 	completeWorker := make(chan struct{})
 	completeParent := make(chan struct{})
 
@@ -139,37 +142,45 @@ func pullGit(ctx context.Context, manifest *Manifest, opts ...pack.PullOption) e
 	path := manifest.Origin
 
 	// Is this an SSH URL?
-	if isSSHURL(path) {
+	isSSH := isSSHURL(path)
+	if isSSH {
 		if strings.HasPrefix(path, "git@") {
 			path = "ssh://" + path
 		}
-		copts.Auth, err = gitssh.NewSSHAgentAuth("git")
-		if err != nil {
-			return fmt.Errorf("could not create SSH agent auth: %w", err)
-		}
-	} else {
-		if !strings.HasPrefix(path, "https://") {
-			path = "https://" + path
-		}
-		u, err := url.Parse(path)
-		if err != nil {
-			return fmt.Errorf("could not parse URL: %w", err)
-		}
-		endpoint := u.Host
+	} else if !strings.HasPrefix(path, "https://") {
+		path = "https://" + path
+	}
 
-		if auth := popts.Auths(endpoint); auth != nil {
-			if auth.User != "" && auth.Token != "" {
-				copts.Auth = &githttp.BasicAuth{
-					Username: auth.User,
-					Password: auth.Token,
-				}
-			} else if auth.Token != "" {
-				copts.Auth = &githttp.TokenAuth{
-					Token: auth.Token,
-				}
-			}
+	u, err := url.Parse(path)
+	if err != nil {
+		return fmt.Errorf("could not parse URL: %w", err)
+	}
+
+	// Prefer the credentials registered for this exact source over those of
+	// its host.
+	auth := popts.Auths(manifest.Origin)
+	if auth == nil {
+		auth = popts.Auths(u.Host)
+	}
+
+	user := "git"
+	if isSSH && u.User != nil && u.User.Username() != "" {
+		user = u.User.Username()
+	}
+
+	copts.Auth, err = gitAuth(auth, user, isSSH)
+	if err != nil {
+		return fmt.Errorf("could not create Git auth: %w", err)
+	}
+
+	if auth != nil && !isSSH {
+		copts.InsecureSkipTLS = !auth.VerifySSL
+		copts.CABundle, err = caBundle(auth)
+		if err != nil {
+			return err
 		}
 	}
+
 	copts.URL = path
 
 	version := ""
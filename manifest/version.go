@@ -33,6 +33,9 @@ package manifest
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
 )
 
 type ManifestVersionType string
@@ -57,3 +60,49 @@ func (mv *ManifestVersion) ShortGitSha() (string, error) {
 
 	return mv.Version[:7], nil
 }
+
+// IsVersionConstraint returns whether the provided version is a semantic
+// version range constraint, e.g. `^0.16` or `~1.2`, rather than a specific
+// version or the name of a channel.
+func IsVersionConstraint(version string) bool {
+	if !strings.ContainsAny(version, "^~<>=*|, ") {
+		return false
+	}
+
+	_, err := semver.NewConstraint(version)
+	return err == nil
+}
+
+// LatestVersionSatisfying returns the newest version of the manifest which
+// satisfies the provided semantic version range constraint.
+func (m Manifest) LatestVersionSatisfying(constraint string) (*ManifestVersion, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint '%s': %w", constraint, err)
+	}
+
+	var latest *ManifestVersion
+	var latestVer *semver.Version
+
+	for i, version := range m.Versions {
+		if version.Type == ManifestVersionGitSha {
+			continue
+		}
+
+		ver, err := semver.NewVersion(version.Version)
+		if err != nil || !c.Check(ver) {
+			continue
+		}
+
+		if latestVer == nil || ver.GreaterThan(latestVer) {
+			latest = &m.Versions[i]
+			latestVer = ver
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("no version of %s satisfies '%s'", m.Name, constraint)
+	}
+
+	return latest, nil
+}
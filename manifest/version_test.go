@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package manifest
+
+import "testing"
+
+func TestIsVersionConstraint(t *testing.T) {
+	for version, expected := range map[string]bool{
+		"^0.16":          true,
+		"~1.2":           true,
+		">= 0.15, <0.17": true,
+		"0.16.1":         false,
+		"stable":         false,
+		"staging":        false,
+		"":               false,
+	} {
+		if got := IsVersionConstraint(version); got != expected {
+			t.Errorf("IsVersionConstraint(%q): expected %t, got %t", version, expected, got)
+		}
+	}
+}
+
+func TestLatestVersionSatisfying(t *testing.T) {
+	m := Manifest{
+		Name: "unikraft",
+		Versions: []ManifestVersion{
+			{Version: "0.15.0", Type: ManifestVersionSemver},
+			{Version: "0.16.3", Type: ManifestVersionSemver},
+			{Version: "0.16.1", Type: ManifestVersionSemver},
+			{Version: "0.17.0", Type: ManifestVersionSemver},
+			{Version: "a1b2c3d4e5f6", Type: ManifestVersionGitSha},
+		},
+	}
+
+	for constraint, expected := range map[string]string{
+		"^0.16":  "0.16.3",
+		"~0.15":  "0.15.0",
+		">=0.16": "0.17.0",
+	} {
+		latest, err := m.LatestVersionSatisfying(constraint)
+		if err != nil {
+			t.Fatalf("LatestVersionSatisfying(%q): %v", constraint, err)
+		}
+
+		if latest.Version != expected {
+			t.Errorf("LatestVersionSatisfying(%q): expected %s, got %s", constraint, expected, latest.Version)
+		}
+	}
+
+	if _, err := m.LatestVersionSatisfying("^1.0"); err == nil {
+		t.Errorf("expected no version to satisfy ^1.0")
+	}
+}
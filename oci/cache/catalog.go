@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"kraftkit.sh/internal/metacache"
+)
+
+// RemoteCatalog is a wrapper for remote.Catalog which caches the list of
+// repositories of the registry on disk until the catalog cache expires.
+func RemoteCatalog(ctx context.Context, registry name.Registry, options ...remote.Option) ([]string, error) {
+	raw, err := metacache.G(ctx).Fetch(ctx, "oci-catalog:"+registry.Name(), func() ([]byte, error) {
+		catalog, err := remote.Catalog(ctx, registry, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(catalog)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog []string
+	if err := json.Unmarshal(raw, &catalog); err != nil {
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+// RemoteIndexManifest returns the manifest of the remote index, which is
+// cached on disk until the catalog cache expires.  Unlike RemoteIndex, only
+// the manifest is retrieved, which suffices for listing the images of the
+// index without further requests.
+func RemoteIndexManifest(ctx context.Context, ref name.Reference, options ...remote.Option) (*v1.IndexManifest, error) {
+	raw, err := metacache.G(ctx).Fetch(ctx, "oci-index:"+ref.Name(), func() ([]byte, error) {
+		index, err := RemoteIndex(ref, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		return index.RawManifest()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v1.ParseIndexManifest(bytes.NewReader(raw))
+}
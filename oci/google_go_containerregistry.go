@@ -45,13 +45,14 @@ func FromGoogleV1DescriptorToOCISpec(from ...v1.Descriptor) []ocispec.Descriptor
 
 	for i := range from {
 		to[i] = ocispec.Descriptor{
-			MediaType:   string(from[i].MediaType),
-			Digest:      digest.Digest(from[i].Digest.String()),
-			Size:        from[i].Size,
-			URLs:        from[i].URLs,
-			Annotations: from[i].Annotations,
-			Data:        from[i].Data,
-			Platform:    FromGoogleV1PlatformToOCISpec(from[i].Platform),
+			MediaType:    string(from[i].MediaType),
+			Digest:       digest.Digest(from[i].Digest.String()),
+			Size:         from[i].Size,
+			URLs:         from[i].URLs,
+			Annotations:  from[i].Annotations,
+			Data:         from[i].Data,
+			Platform:     FromGoogleV1PlatformToOCISpec(from[i].Platform),
+			ArtifactType: from[i].ArtifactType,
 		}
 	}
 
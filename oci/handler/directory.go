@@ -42,22 +42,49 @@ import (
 const (
 	DirectoryHandlerDigestsDir = "digests"
 	DirectoryHandlerIndexesDir = "indexes"
+
+	// DirectoryHandlerBlobsDir holds a single copy of each file which was
+	// unpacked from the images of the store if hard-linking is enabled.
+	// Unpacked files are then hard links to these copies, such that identical
+	// kernels and root file systems of different images, machines and projects
+	// are only stored once.
+	DirectoryHandlerBlobsDir = "blobs"
 )
 
 type DirectoryHandler struct {
-	path  string
-	auths map[string]config.AuthConfig
+	path     string
+	auths    map[string]config.AuthConfig
+	hardLink bool
+}
+
+// DirectoryHandlerOption is an option of a directory handler.
+type DirectoryHandlerOption func(*DirectoryHandler)
+
+// WithDirectoryHardLinks sets whether files which are unpacked from images are
+// hard links to a single shared copy of their contents.  Shared copies are
+// read-only, yet a change of one of the files by a privileged user affects all
+// others, hence each file is its own copy by default.
+func WithDirectoryHardLinks(hardLink bool) DirectoryHandlerOption {
+	return func(handle *DirectoryHandler) {
+		handle.hardLink = hardLink
+	}
 }
 
-func NewDirectoryHandler(path string, auths map[string]config.AuthConfig) (*DirectoryHandler, error) {
+func NewDirectoryHandler(path string, auths map[string]config.AuthConfig, opts ...DirectoryHandlerOption) (*DirectoryHandler, error) {
 	if err := os.MkdirAll(path, 0o775); err != nil {
 		return nil, fmt.Errorf("could not create local oci cache directory: %w", err)
 	}
 
-	return &DirectoryHandler{
+	handle := &DirectoryHandler{
 		path:  path,
 		auths: auths,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(handle)
+	}
+
+	return handle, nil
 }
 
 // DigestInfo implements DigestResolver.
@@ -145,12 +172,10 @@ func (handle *DirectoryHandler) PullDigest(ctx context.Context, mediaType, fullr
 		localManifests := []ocispec.Descriptor{}
 		var indexTagPath string
 
-		if !strings.ContainsRune(fullref, '@') && len(strings.SplitN(fullref, ":", 2)) == 2 {
-			indexTagPath = filepath.Join(
-				handle.path,
-				DirectoryHandlerIndexesDir,
-				strings.ReplaceAll(fullref, ":", string(filepath.Separator)),
-			)
+		// Record the index by its tag or, when pulled by an immutable reference,
+		// by its digest such that it can be resolved locally afterwards.
+		if strings.ContainsRune(fullref, '@') || len(strings.SplitN(fullref, ":", 2)) == 2 {
+			indexTagPath = handle.indexPath(fullref)
 
 			if indexFi, err := os.Stat(indexTagPath); err == nil {
 				if indexFi.Mode()&fs.ModeSymlink == 0 {
@@ -907,11 +932,7 @@ func (handle *DirectoryHandler) DeleteManifest(ctx context.Context, fullref stri
 		manifests = append(manifests, m)
 	}
 
-	indexPath := filepath.Join(
-		handle.path,
-		DirectoryHandlerIndexesDir,
-		strings.ReplaceAll(fullref, ":", string(filepath.Separator)),
-	)
+	indexPath := handle.indexPath(fullref)
 
 	if len(manifests) == 0 {
 		indexFi, err := os.Stat(indexPath)
@@ -965,6 +986,23 @@ func (handle *DirectoryHandler) DeleteManifest(ctx context.Context, fullref stri
 	return nil
 }
 
+// indexPath returns the location of the index for the provided reference in
+// the format `name:tag` or `name@digest`.  Indexes referenced by digest are
+// stored under the encoded digest in place of the tag.
+func (handle *DirectoryHandler) indexPath(fullref string) string {
+	if name, dgst, ok := strings.Cut(fullref, "@"); ok {
+		if parsed, err := digest.Parse(dgst); err == nil {
+			fullref = fmt.Sprintf("%s:%s", name, parsed.Encoded())
+		}
+	}
+
+	return filepath.Join(
+		handle.path,
+		DirectoryHandlerIndexesDir,
+		strings.ReplaceAll(fullref, ":", string(filepath.Separator)),
+	)
+}
+
 // ResolveIndex implements IndexResolver.
 func (handle *DirectoryHandler) ResolveIndex(ctx context.Context, fullref string) (*ocispec.Index, error) {
 	// Find the index of this image
@@ -1085,11 +1123,7 @@ func (handle *DirectoryHandler) ListIndexes(ctx context.Context) (map[string]*oc
 }
 
 func (handle *DirectoryHandler) DeleteIndex(ctx context.Context, fullref string, deps bool) error {
-	indexPath := filepath.Join(
-		handle.path,
-		DirectoryHandlerIndexesDir,
-		strings.ReplaceAll(fullref, ":", string(filepath.Separator)),
-	)
+	indexPath := handle.indexPath(fullref)
 
 	// Check whether the index exists
 	indexFi, err := os.Stat(indexPath)
@@ -1273,20 +1307,7 @@ func (handle *DirectoryHandler) UnpackImage(ctx context.Context, fullref string,
 			}
 
 			// Otherwise, create the file
-			writer, err := os.Create(path)
-			if err != nil {
-				return nil, fmt.Errorf("creating file: %w", err)
-			}
-
-			defer writer.Close()
-
-			if _, err = io.Copy(writer, tr); err != nil {
-				if err2 := writer.Close(); err2 != nil {
-					return nil, fmt.Errorf("%w: could not close unpack blob: %w", err, err2)
-				}
-				if err2 := os.RemoveAll(path); err2 != nil {
-					return nil, fmt.Errorf("%w: could not remove unpack blob: %w", err, err2)
-				}
+			if err := handle.unpackFile(path, tr); err != nil {
 				return nil, fmt.Errorf("writing file: %w", err)
 			}
 		}
@@ -1299,3 +1320,126 @@ func (handle *DirectoryHandler) UnpackImage(ctx context.Context, fullref string,
 func (handle *DirectoryHandler) FinalizeImage(ctx context.Context, image ocispec.Image) error {
 	return fmt.Errorf("not implemented: oci.handler.DirectoryHandler.FinalizeImage")
 }
+
+// DanglingDigests returns the descriptors of all blobs in the digests
+// directory which are not reachable from any tagged index, i.e. those left
+// behind by interrupted pulls or replaced indexes.
+func (handle *DirectoryHandler) DanglingDigests(ctx context.Context) ([]ocispec.Descriptor, error) {
+	digestsDir := filepath.Join(handle.path, DirectoryHandlerDigestsDir)
+	indexesDir := filepath.Join(handle.path, DirectoryHandlerIndexesDir)
+	referenced := map[digest.Digest]struct{}{}
+
+	if err := filepath.WalkDir(indexesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rawIndex, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		index := ocispec.Index{}
+		if err := json.Unmarshal(rawIndex, &index); err != nil {
+			return nil
+		}
+
+		referenced[digest.FromBytes(rawIndex)] = struct{}{}
+
+		for _, desc := range index.Manifests {
+			referenced[desc.Digest] = struct{}{}
+
+			rawManifest, err := os.ReadFile(filepath.Join(
+				digestsDir,
+				desc.Digest.Algorithm().String(),
+				desc.Digest.Encoded(),
+			))
+			if err != nil {
+				continue
+			}
+
+			manifest := ocispec.Manifest{}
+			if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+				continue
+			}
+
+			referenced[manifest.Config.Digest] = struct{}{}
+			for _, layer := range manifest.Layers {
+				referenced[layer.Digest] = struct{}{}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not walk indexes directory: %w", err)
+	}
+
+	var dangling []ocispec.Descriptor
+
+	if err := filepath.WalkDir(digestsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		dgst := digest.NewDigestFromEncoded(
+			digest.Algorithm(filepath.Base(filepath.Dir(path))),
+			d.Name(),
+		)
+		if dgst.Validate() != nil {
+			return nil
+		}
+
+		if _, ok := referenced[dgst]; ok {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		log.G(ctx).
+			WithField("digest", dgst.String()).
+			Trace("found dangling digest")
+
+		dangling = append(dangling, ocispec.Descriptor{
+			Digest: dgst,
+			Size:   info.Size(),
+		})
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not walk digests directory: %w", err)
+	}
+
+	return dangling, nil
+}
+
+// DeleteDigest removes the blob of the provided digest from the digests
+// directory.
+func (handle *DirectoryHandler) DeleteDigest(ctx context.Context, dgst digest.Digest) error {
+	log.G(ctx).
+		WithField("digest", dgst.String()).
+		Trace("deleting digest")
+
+	return os.Remove(filepath.Join(
+		handle.path,
+		DirectoryHandlerDigestsDir,
+		dgst.Algorithm().String(),
+		dgst.Encoded(),
+	))
+}
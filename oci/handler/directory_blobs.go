@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/log"
+)
+
+// DirectoryUsage is the disk usage of the store of a directory handler, both
+// as it would be if nothing was shared (logical) and as it is on disk.
+type DirectoryUsage struct {
+	// Images is the number of tagged images.
+	Images int
+
+	// ImagesLogical is the sum of the sizes of the blobs of each image, where
+	// blobs shared between images count once per image.
+	ImagesLogical int64
+
+	// ImagesSize is the size of the blobs of the images on disk, where each
+	// blob counts once.
+	ImagesSize int64
+
+	// Unpacked is the number of files unpacked from images which are hard links
+	// to shared copies, e.g. the kernels in the state directories of machines.
+	// Files which were copied are not accounted for.
+	Unpacked int
+
+	// UnpackedLogical is the sum of the sizes of the unpacked files, as if each
+	// was a copy.
+	UnpackedLogical int64
+
+	// UnpackedSize is the size of the shared copies of the unpacked files on
+	// disk, including those which are no longer referenced.
+	UnpackedSize int64
+}
+
+// blobPath returns the path of the shared copy of the unpacked file with the
+// provided digest.
+func (handle *DirectoryHandler) blobPath(dgst digest.Digest) string {
+	return filepath.Join(
+		handle.path,
+		DirectoryHandlerBlobsDir,
+		dgst.Algorithm().String(),
+		dgst.Encoded(),
+	)
+}
+
+// unpackFile writes the contents of the reader to the path.  Unless hard-linking
+// is enabled, the file is a copy of its own which can be changed freely.
+func (handle *DirectoryHandler) unpackFile(path string, r io.Reader) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("could not replace file: %w", err)
+	}
+
+	if !handle.hardLink {
+		return writeFile(path, r)
+	}
+
+	return handle.linkFile(path, r)
+}
+
+// linkFile writes the contents of the reader to the path as a hard link to the
+// shared copy of the contents, which is created if it does not yet exist.
+// Shared copies are read-only, since a change through one link would affect
+// all others.  If the path cannot be linked, e.g. because it is on a
+// different file system, the contents are copied instead.
+func (handle *DirectoryHandler) linkFile(path string, r io.Reader) error {
+	blobsDir := filepath.Join(handle.path, DirectoryHandlerBlobsDir, digest.Canonical.String())
+	if err := os.MkdirAll(blobsDir, 0o775); err != nil {
+		return fmt.Errorf("could not make directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(blobsDir, ".unpack-*")
+	if err != nil {
+		return fmt.Errorf("could not create blob: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	digester := digest.Canonical.Digester()
+	if _, err := io.Copy(io.MultiWriter(tmp, digester.Hash()), r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write blob: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write blob: %w", err)
+	}
+
+	blobPath := handle.blobPath(digester.Digest())
+
+	if _, err := os.Stat(blobPath); errors.Is(err, fs.ErrNotExist) {
+		if err := os.Chmod(tmp.Name(), 0o444); err != nil {
+			return fmt.Errorf("could not write blob: %w", err)
+		}
+
+		if err := os.Rename(tmp.Name(), blobPath); err != nil {
+			return fmt.Errorf("could not write blob: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("could not access blob: %w", err)
+	}
+
+	if err := os.Link(blobPath, path); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(blobPath)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	return writeFile(path, in)
+}
+
+// writeFile writes the contents of the reader to a new file at the path, which
+// is removed again if the contents cannot be written.
+func writeFile(path string, r io.Reader) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(path)
+		return err
+	}
+
+	return out.Close()
+}
+
+// Usage returns the disk usage of the store.
+func (handle *DirectoryHandler) Usage(ctx context.Context) (*DirectoryUsage, error) {
+	usage := &DirectoryUsage{}
+
+	digestsDir := filepath.Join(handle.path, DirectoryHandlerDigestsDir)
+	indexesDir := filepath.Join(handle.path, DirectoryHandlerIndexesDir)
+	blobsDir := filepath.Join(handle.path, DirectoryHandlerBlobsDir)
+
+	size := func(dgst digest.Digest) int64 {
+		fi, err := os.Stat(filepath.Join(digestsDir, dgst.Algorithm().String(), dgst.Encoded()))
+		if err != nil {
+			return 0
+		}
+
+		return fi.Size()
+	}
+
+	if err := filepath.WalkDir(indexesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rawIndex, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		index := ocispec.Index{}
+		if err := json.Unmarshal(rawIndex, &index); err != nil {
+			return nil
+		}
+
+		usage.Images++
+		usage.ImagesLogical += int64(len(rawIndex))
+
+		for _, desc := range index.Manifests {
+			rawManifest, err := os.ReadFile(filepath.Join(
+				digestsDir,
+				desc.Digest.Algorithm().String(),
+				desc.Digest.Encoded(),
+			))
+			if err != nil {
+				continue
+			}
+
+			usage.ImagesLogical += int64(len(rawManifest))
+
+			manifest := ocispec.Manifest{}
+			if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+				continue
+			}
+
+			usage.ImagesLogical += size(manifest.Config.Digest)
+			for _, layer := range manifest.Layers {
+				usage.ImagesLogical += size(layer.Digest)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not walk indexes directory: %w", err)
+	}
+
+	if err := filepath.WalkDir(digestsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		if info, err := d.Info(); err == nil {
+			usage.ImagesSize += info.Size()
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not walk digests directory: %w", err)
+	}
+
+	blobs, err := handle.sharedBlobs(blobsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blob := range blobs {
+		usage.Unpacked += blob.references
+		usage.UnpackedLogical += int64(blob.references) * blob.size
+		usage.UnpackedSize += blob.size
+	}
+
+	log.G(ctx).
+		WithField("images", usage.Images).
+		WithField("unpacked", usage.Unpacked).
+		Trace("computed usage")
+
+	return usage, nil
+}
+
+// sharedBlob is the shared copy of unpacked files.
+type sharedBlob struct {
+	digest     digest.Digest
+	size       int64
+	references int
+}
+
+// sharedBlobs returns the shared copies of unpacked files and the number of
+// files which reference each of them.
+func (handle *DirectoryHandler) sharedBlobs(blobsDir string) ([]sharedBlob, error) {
+	var blobs []sharedBlob
+
+	if err := filepath.WalkDir(blobsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		dgst := digest.NewDigestFromEncoded(
+			digest.Algorithm(filepath.Base(filepath.Dir(path))),
+			d.Name(),
+		)
+		if dgst.Validate() != nil {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		// The shared copy itself is one of the links.
+		blobs = append(blobs, sharedBlob{
+			digest:     dgst,
+			size:       info.Size(),
+			references: int(linkCount(info)) - 1,
+		})
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not walk blobs directory: %w", err)
+	}
+
+	return blobs, nil
+}
+
+// UnreferencedBlobs returns the descriptors of the shared copies of unpacked
+// files which are no longer referenced by any file, e.g. because the machines
+// they were unpacked for have been removed.
+func (handle *DirectoryHandler) UnreferencedBlobs(ctx context.Context) ([]ocispec.Descriptor, error) {
+	blobs, err := handle.sharedBlobs(filepath.Join(handle.path, DirectoryHandlerBlobsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var unreferenced []ocispec.Descriptor
+
+	for _, blob := range blobs {
+		if blob.references > 0 {
+			continue
+		}
+
+		log.G(ctx).
+			WithField("digest", blob.digest.String()).
+			Trace("found unreferenced blob")
+
+		unreferenced = append(unreferenced, ocispec.Descriptor{
+			Digest: blob.digest,
+			Size:   blob.size,
+		})
+	}
+
+	return unreferenced, nil
+}
+
+// DeleteBlob removes the shared copy of unpacked files with the provided
+// digest.  Files which still link to it are unaffected.
+func (handle *DirectoryHandler) DeleteBlob(ctx context.Context, dgst digest.Digest) error {
+	log.G(ctx).
+		WithField("digest", dgst.String()).
+		Trace("deleting blob")
+
+	return os.Remove(handle.blobPath(dgst))
+}
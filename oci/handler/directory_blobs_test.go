@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func newTestDirectoryHandler(t *testing.T, opts ...DirectoryHandlerOption) *DirectoryHandler {
+	t.Helper()
+
+	handle, err := NewDirectoryHandler(filepath.Join(t.TempDir(), "oci"), nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handle
+}
+
+func TestUnpackFileIsolated(t *testing.T) {
+	handle := newTestDirectoryHandler(t)
+	dir := t.TempDir()
+
+	kernels := []string{
+		filepath.Join(dir, "a", "kernel"),
+		filepath.Join(dir, "b", "kernel"),
+	}
+
+	for _, kernel := range kernels {
+		if err := os.MkdirAll(filepath.Dir(kernel), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := handle.unpackFile(kernel, strings.NewReader("kernel")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each unpacked file is writable and changing it leaves the others intact.
+	if err := os.WriteFile(kernels[0], []byte("patched"), 0o644); err != nil {
+		t.Fatalf("expected unpacked file to be writable: %v", err)
+	}
+
+	if b, err := os.ReadFile(kernels[1]); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "kernel" {
+		t.Errorf("expected other unpacked file to be unchanged, got %q", b)
+	}
+
+	usage, err := handle.Usage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if usage.Unpacked != 0 || usage.UnpackedSize != 0 {
+		t.Errorf("expected no shared copies, got %+v", usage)
+	}
+}
+
+func TestUnpackFileHardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the number of links to a file is not reported on Windows")
+	}
+
+	ctx := context.Background()
+	handle := newTestDirectoryHandler(t, WithDirectoryHardLinks(true))
+	dir := t.TempDir()
+
+	kernels := []string{
+		filepath.Join(dir, "a"),
+		filepath.Join(dir, "b"),
+		filepath.Join(dir, "c"),
+	}
+
+	for _, kernel := range kernels {
+		if err := handle.unpackFile(kernel, strings.NewReader("kernel")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := handle.unpackFile(filepath.Join(dir, "initrd"), strings.NewReader("initrd!")); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := handle.Usage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Three links to the kernel and one to the initrd, each stored once.
+	if usage.Unpacked != 4 {
+		t.Errorf("expected 4 unpacked files, got %d", usage.Unpacked)
+	}
+
+	if expected := int64(3*len("kernel") + len("initrd!")); usage.UnpackedLogical != expected {
+		t.Errorf("expected logical size %d, got %d", expected, usage.UnpackedLogical)
+	}
+
+	if expected := int64(len("kernel") + len("initrd!")); usage.UnpackedSize != expected {
+		t.Errorf("expected size on disk %d, got %d", expected, usage.UnpackedSize)
+	}
+
+	// Shared copies cannot be changed through any of their links.
+	if os.Getuid() != 0 {
+		if err := os.WriteFile(kernels[0], []byte("patched"), 0o644); err == nil {
+			t.Error("expected hard-linked file to be read-only")
+		}
+	}
+
+	// Re-unpacking a file replaces its link rather than writing through it.
+	if err := handle.unpackFile(kernels[0], strings.NewReader("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := os.ReadFile(kernels[1]); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "kernel" {
+		t.Errorf("expected other unpacked file to be unchanged, got %q", b)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "initrd")); err != nil {
+		t.Fatal(err)
+	}
+
+	unreferenced, err := handle.UnreferencedBlobs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unreferenced) != 1 || unreferenced[0].Size != int64(len("initrd!")) {
+		t.Fatalf("expected the initrd to be unreferenced, got %+v", unreferenced)
+	}
+
+	if err := handle.DeleteBlob(ctx, unreferenced[0].Digest); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err = handle.Usage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if usage.Unpacked != 3 {
+		t.Errorf("expected 3 unpacked files, got %d", usage.Unpacked)
+	}
+
+	if expected := int64(len("kernel") + len("other")); usage.UnpackedSize != expected {
+		t.Errorf("expected size on disk %d, got %d", expected, usage.UnpackedSize)
+	}
+}
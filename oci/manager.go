@@ -70,6 +70,10 @@ func NewOCIManager(ctx context.Context, opts ...any) (packmanager.PackageManager
 
 // Update implements packmanager.PackageManager
 func (manager *ociManager) Update(ctx context.Context) error {
+	if packmanager.IsOffline(ctx) {
+		return fmt.Errorf("could not update oci index: %w", packmanager.ErrOffline)
+	}
+
 	packs, err := manager.update(ctx, nil, nil)
 	if err != nil {
 		return err
@@ -130,7 +134,7 @@ func (manager *ociManager) update(ctx context.Context, auths map[string]config.A
 			continue
 		}
 
-		catalog, err := remote.Catalog(ctx, regName,
+		catalog, err := cache.RemoteCatalog(ctx, regName,
 			remote.WithContext(ctx),
 			remote.WithAuth(&simpleauth.SimpleAuthenticator{
 				Auth: authConfig,
@@ -163,7 +167,7 @@ func (manager *ociManager) update(ctx context.Context, auths map[string]config.A
 					return
 				}
 
-				index, err := cache.RemoteIndex(ref,
+				v1IndexManifest, err := cache.RemoteIndexManifest(ctx, ref,
 					remote.WithContext(ctx),
 					remote.WithAuth(&simpleauth.SimpleAuthenticator{
 						Auth: authConfig,
@@ -173,15 +177,7 @@ func (manager *ociManager) update(ctx context.Context, auths map[string]config.A
 				if err != nil {
 					log.G(ctx).
 						WithField("ref", fullref).
-						Tracef("skipping index: could not retrieve image: %s", err.Error())
-					return
-				}
-
-				v1IndexManifest, err := index.IndexManifest()
-				if err != nil {
-					log.G(ctx).
-						WithField("ref", fullref).
-						Tracef("could not access the index's manifest object: %s", err.Error())
+						Tracef("skipping index: could not retrieve index manifest: %s", err.Error())
 					return
 				}
 
@@ -364,6 +360,10 @@ func (manager *ociManager) Catalog(ctx context.Context, qopts ...packmanager.Que
 		return nil, nil
 	}
 
+	if query.Remote() && packmanager.IsOffline(ctx) {
+		return nil, fmt.Errorf("could not search remote oci catalog: %w", packmanager.ErrOffline)
+	}
+
 	var qglob glob.Glob
 	var err error
 	packs := make(map[string]pack.Package)
@@ -400,7 +400,7 @@ func (manager *ociManager) Catalog(ctx context.Context, qopts ...packmanager.Que
 	// No default registry found, re-parse with
 	if ref != nil && ref.Context().RegistryStr() == "" {
 		unsetRegistry = true
-		ref, refErr = name.ParseReference(fmt.Sprintf("%s:%s", qname, qversion),
+		ref, refErr = name.ParseReference(ociutils.JoinReference(qname, qversion),
 			name.WithDefaultRegistry(DefaultRegistry),
 			name.WithDefaultTag(DefaultTag),
 		)
@@ -456,21 +456,13 @@ func (manager *ociManager) Catalog(ctx context.Context, qopts ...packmanager.Que
 			WithField("ref", ref.Name()).
 			Trace("getting remote index")
 
-		v1ImageIndex, err := cache.RemoteIndex(ref, ropts...)
+		v1IndexManifest, err := cache.RemoteIndexManifest(ctx, ref, ropts...)
 		if err != nil {
 			log.G(ctx).
 				Debugf("could not get index: %v", err)
 			goto resolveLocalIndex
 		}
 
-		v1IndexManifest, err := v1ImageIndex.IndexManifest()
-		if err != nil {
-			log.G(ctx).
-				WithField("ref", ref).
-				Tracef("could not access the index's manifest object: %s", err.Error())
-			goto resolveLocalIndex
-		}
-
 		for checksum, pack := range processV1IndexManifests(ctx,
 			handle,
 			ref.String(),
@@ -495,7 +487,7 @@ func (manager *ociManager) Catalog(ctx context.Context, qopts ...packmanager.Que
 			for checksum, pack := range more {
 				total++
 
-				ref, err := name.ParseReference(fmt.Sprintf("%s:%s", pack.Name(), pack.Version()))
+				ref, err := name.ParseReference(ociutils.JoinReference(pack.Name(), pack.Version()))
 				if err != nil {
 					log.G(ctx).
 						WithField("ref", pack.Name()).
@@ -503,7 +495,7 @@ func (manager *ociManager) Catalog(ctx context.Context, qopts ...packmanager.Que
 					continue
 				}
 
-				fullref := fmt.Sprintf("%s:%s", ref.Context().RepositoryStr(), ref.Identifier())
+				fullref := ociutils.JoinReference(ref.Context().RepositoryStr(), ref.Identifier())
 
 				// If the query did specify a registry include this in check otherwise
 				// search for indexes without this as prefix.
@@ -519,9 +511,9 @@ func (manager *ociManager) Catalog(ctx context.Context, qopts ...packmanager.Que
 					continue
 				} else if qglob == nil {
 					if len(qversion) > 0 && len(qname) > 0 {
-						if fullref != fmt.Sprintf("%s:%s", qname, qversion) {
+						if !ociutils.ReferenceMatches(fullref, qname, qversion) {
 							log.G(ctx).
-								WithField("want", fmt.Sprintf("%s:%s", qname, qversion)).
+								WithField("want", ociutils.JoinReference(qname, qversion)).
 								WithField("got", fullref).
 								Trace("skipping manifest: name does not match")
 							continue
@@ -544,7 +536,7 @@ resolveLocalIndex:
 	// If the query is local and the reference is a fully qualified OCI reference,
 	// attempt to resolve the exact index and generate packages from it.
 	if query.Local() && len(qversion) > 0 && len(qname) > 0 {
-		oref := fmt.Sprintf("%s:%s", qname, qversion)
+		oref := ociutils.JoinReference(qname, qversion)
 		index, err := handle.ResolveIndex(ctx, oref)
 		if err != nil {
 			log.G(ctx).
@@ -591,7 +583,7 @@ searchLocalIndexes:
 				continue
 			}
 
-			fullref := fmt.Sprintf("%s:%s", ref.Context().RepositoryStr(), ref.Identifier())
+			fullref := ociutils.JoinReference(ref.Context().RepositoryStr(), ref.Identifier())
 
 			// If the query did specify a registry include this in check otherwise
 			// search for indexes without this as prefix.
@@ -616,9 +608,9 @@ searchLocalIndexes:
 				continue
 			} else if qglob == nil {
 				if len(qversion) > 0 && len(qname) > 0 {
-					if fullref != fmt.Sprintf("%s:%s", qname, qversion) {
+					if !ociutils.ReferenceMatches(fullref, qname, qversion) {
 						log.G(ctx).
-							WithField("want", fmt.Sprintf("%s:%s", qname, qversion)).
+							WithField("want", ociutils.JoinReference(qname, qversion)).
 							WithField("got", fullref).
 							Trace("skipping index: name does not match")
 						total += len(index.Manifests)
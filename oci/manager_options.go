@@ -54,7 +54,9 @@ func WithDetectHandler() OCIManagerOption {
 			Trace("using directory handler")
 
 		manager.handle = func(ctx context.Context) (context.Context, handler.Handler, error) {
-			handle, err := handler.NewDirectoryHandler(ociDir, manager.auths)
+			handle, err := handler.NewDirectoryHandler(ociDir, manager.auths,
+				handler.WithDirectoryHardLinks(config.G[config.KraftKit](ctx).Unpack.HardLink),
+			)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -99,7 +101,9 @@ func WithDirectory(ctx context.Context, path string) OCIManagerOption {
 			Trace("using directory handler")
 
 		manager.handle = func(ctx context.Context) (context.Context, handler.Handler, error) {
-			handle, err := handler.NewDirectoryHandler(path, manager.auths)
+			handle, err := handler.NewDirectoryHandler(path, manager.auths,
+				handler.WithDirectoryHardLinks(config.G[config.KraftKit](ctx).Unpack.HardLink),
+			)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -233,6 +233,11 @@ func (manifest *Manifest) SetCmd(_ context.Context, cmd []string) {
 	manifest.config.Config.Cmd = cmd
 }
 
+// Set the entrypoint of the image.
+func (manifest *Manifest) SetEntrypoint(_ context.Context, entrypoint []string) {
+	manifest.config.Config.Entrypoint = entrypoint
+}
+
 // Set the environment variables of the image.
 func (manifest *Manifest) SetEnv(_ context.Context, env []string) {
 	manifest.config.Config.Env = env
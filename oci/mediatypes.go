@@ -10,6 +10,10 @@ const (
 	MediaTypeInitrdCpio  = "application/vnd.unikraft.initrd.v1"
 	MediaTypeConfig      = "application/vnd.unikraft.config.v1"
 
+	// MediaTypeKernelDbg is the artifact type of the symbolic kernel image when
+	// it is attached to an image as a referrer rather than packaged with it.
+	MediaTypeKernelDbg = "application/vnd.unikraft.kernel.dbg.v1"
+
 	MediaTypeLayerGzip       = MediaTypeLayer + "+gzip"
 	MediaTypeImageKernelGzip = MediaTypeImageKernel + "+gzip"
 	MediaTypeInitrdCpioGzip  = MediaTypeInitrdCpio + "+gzip"
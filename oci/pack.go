@@ -65,14 +65,15 @@ type ociPackage struct {
 	auths    map[string]config.AuthConfig
 
 	// Embedded attributes which represent target.Target
-	arch      arch.Architecture
-	plat      plat.Platform
-	kconfig   kconfig.KeyValueMap
-	kernel    string
-	kernelDbg string
-	initrd    initrd.Initrd
-	command   []string
-	labels    map[string]string
+	arch       arch.Architecture
+	plat       plat.Platform
+	kconfig    kconfig.KeyValueMap
+	kernel     string
+	kernelDbg  string
+	initrd     initrd.Initrd
+	command    []string
+	entrypoint []string
+	labels     map[string]string
 
 	original *ociPackage
 }
@@ -94,14 +95,15 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 
 	// Initialize the ociPackage by copying over target.Target attributes
 	ocipack := ociPackage{
-		arch:      targ.Architecture(),
-		plat:      targ.Platform(),
-		kconfig:   targ.KConfig(),
-		initrd:    targ.Initrd(),
-		kernel:    targ.Kernel(),
-		kernelDbg: targ.KernelDbg(),
-		command:   popts.Args(),
-		labels:    popts.Labels(),
+		arch:       targ.Architecture(),
+		plat:       targ.Platform(),
+		kconfig:    targ.KConfig(),
+		initrd:     targ.Initrd(),
+		kernel:     targ.Kernel(),
+		kernelDbg:  targ.KernelDbg(),
+		command:    popts.Args(),
+		entrypoint: popts.Entrypoint(),
+		labels:     popts.Labels(),
 	}
 
 	// It is possible that `NewPackageFromTarget` is called with an existing
@@ -149,7 +151,9 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 			WithField("path", ociDir).
 			Trace("directory handler")
 
-		ocipack.handle, err = handler.NewDirectoryHandler(ociDir, config.G[config.KraftKit](ctx).Auth)
+		ocipack.handle, err = handler.NewDirectoryHandler(ociDir, config.G[config.KraftKit](ctx).Auth,
+			handler.WithDirectoryHardLinks(config.G[config.KraftKit](ctx).Unpack.HardLink),
+		)
 	}
 	if err != nil {
 		return nil, err
@@ -198,8 +202,9 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 
 		layer, err := NewLayerFromFile(ctx,
 			ocispec.MediaTypeImageLayer,
-			ocipack.Kernel(),
+			ocipack.KernelDbg(),
 			WellKnownKernelDbgPath,
+			WithLayerAnnotation(AnnotationKernelDbgPath, WellKnownKernelDbgPath),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("could not create new layer structure from file: %w", err)
@@ -277,6 +282,22 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 		ocipack.manifest.SetCmd(ctx, cmd)
 	}
 
+	if len(ocipack.Entrypoint()) > 0 {
+		entrypoint := ocipack.Entrypoint()
+		log.G(ctx).
+			WithField("args", entrypoint).
+			Debug("entrypoint")
+
+		ocipack.manifest.SetEntrypoint(ctx, entrypoint)
+	} else if ocipack.original != nil {
+		entrypoint := ocipack.original.manifest.config.Config.Entrypoint
+		log.G(ctx).
+			WithField("args", entrypoint).
+			Debug("entrypoint")
+
+		ocipack.manifest.SetEntrypoint(ctx, entrypoint)
+	}
+
 	ocipack.manifest.SetOS(ctx, ocipack.Platform().Name())
 	ocipack.manifest.SetArchitecture(ctx, ocipack.Architecture().Name())
 	ocipack.manifest.SetEnv(ctx, popts.Env())
@@ -419,6 +440,10 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 // manifest from a remote reference and digest and returns, if found, an
 // instantiated Index and Manifest structure based on its contents.
 func newIndexAndManifestFromRemoteDigest(ctx context.Context, handle handler.Handler, fullref string, auths map[string]config.AuthConfig, dgst digest.Digest) (*Index, *Manifest, error) {
+	if packmanager.IsOffline(ctx) {
+		return nil, nil, fmt.Errorf("could not retrieve '%s': %w", fullref, packmanager.ErrOffline)
+	}
+
 	ref, err := name.ParseReference(fullref,
 		name.WithDefaultRegistry(""),
 		name.WithDefaultTag(DefaultTag),
@@ -664,6 +689,9 @@ func NewPackageFromOCIManifestDigest(ctx context.Context, handle handler.Handler
 		ocipack.kconfig.Override(kval)
 	}
 
+	ocipack.command = ocipack.manifest.config.Config.Cmd
+	ocipack.entrypoint = ocipack.manifest.config.Config.Entrypoint
+
 	return &ocipack, nil
 }
 
@@ -682,6 +710,11 @@ func (ocipack *ociPackage) ID() string {
 	return fmt.Sprintf("%s@%s", ocipack.Name(), ocipack.index.desc.Digest.String())
 }
 
+// Digest returns the digest of the index of the package.
+func (ocipack *ociPackage) Digest() string {
+	return ocipack.index.desc.Digest.String()
+}
+
 // Name implements fmt.Stringer
 func (ocipack *ociPackage) String() string {
 	return fmt.Sprintf("%s (%s/%s)", ocipack.imageRef(), ocipack.Platform().Name(), ocipack.Architecture().Name())
@@ -694,10 +727,7 @@ func (ocipack *ociPackage) Version() string {
 
 // imageRef returns the OCI-standard image name in the format `name:tag`
 func (ocipack *ociPackage) imageRef() string {
-	if strings.HasPrefix(ocipack.Version(), "sha256:") {
-		return fmt.Sprintf("%s@%s", ocipack.Name(), ocipack.Version())
-	}
-	return fmt.Sprintf("%s:%s", ocipack.Name(), ocipack.Version())
+	return ociutils.JoinReference(ocipack.Name(), ocipack.Version())
 }
 
 // Metadata implements pack.Package
@@ -733,6 +763,7 @@ func (ocipack *ociPackage) Columns() []tableprinter.Column {
 		{Name: "index", Value: ocipack.index.desc.Digest.String()[7:14]},
 		{Name: "plat", Value: fmt.Sprintf("%s/%s", ocipack.Platform().Name(), ocipack.Architecture().Name())},
 		{Name: "size", Value: size},
+		{Name: "command", Value: strings.Join(ocipack.EffectiveCommand(), " ")},
 	}
 }
 
@@ -815,8 +846,14 @@ func (ocipack *ociPackage) Unpack(ctx context.Context, dir string) error {
 	// Set the kernel, since it is a well-known within the destination path
 	ocipack.kernel = filepath.Join(dir, WellKnownKernelPath)
 
-	// Set the command
+	// Set the symbolic kernel if it was packaged alongside the kernel
+	if _, err := os.Stat(filepath.Join(dir, WellKnownKernelDbgPath)); err == nil {
+		ocipack.kernelDbg = filepath.Join(dir, WellKnownKernelDbgPath)
+	}
+
+	// Set the command and entrypoint
 	ocipack.command = image.Config.Cmd
+	ocipack.entrypoint = image.Config.Entrypoint
 
 	// Set the initrd if available
 	initrdPath := filepath.Join(dir, WellKnownInitrdPath)
@@ -843,6 +880,10 @@ func (ocipack *ociPackage) Pull(ctx context.Context, opts ...pack.PullOption) er
 		return err
 	}
 
+	if packmanager.IsOffline(ctx) {
+		return fmt.Errorf("could not pull '%s': %w", ocipack.imageRef(), packmanager.ErrOffline)
+	}
+
 	// Pull the index but set the platform such that the relevant manifests can
 	// be retrieved as well.
 	if err := ocipack.handle.PullDigest(
@@ -1036,6 +1077,18 @@ func (ocipack *ociPackage) Command() []string {
 	return ocipack.command
 }
 
+// Entrypoint returns the arguments which always precede the command of the
+// package, even when the command is overridden at run time.
+func (ocipack *ociPackage) Entrypoint() []string {
+	return ocipack.entrypoint
+}
+
+// EffectiveCommand returns the arguments the application is run with unless
+// they are overridden, i.e. the entrypoint followed by the command.
+func (ocipack *ociPackage) EffectiveCommand() []string {
+	return append(append([]string{}, ocipack.entrypoint...), ocipack.command...)
+}
+
 // ConfigFilename implements unikraft.target.Target
 func (ocipack *ociPackage) ConfigFilename() string {
 	return ""
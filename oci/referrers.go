@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/version"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci/simpleauth"
+	"kraftkit.sh/packmanager"
+)
+
+// The kinds of artifacts which are commonly attached to images as referrers.
+const (
+	ArtifactKindSBOM        = "sbom"
+	ArtifactKindSignature   = "signature"
+	ArtifactKindAttestation = "attestation"
+	ArtifactKindSymbols     = "symbols"
+	ArtifactKindOther       = "other"
+)
+
+// ArtifactKinds returns the known kinds of artifacts.
+func ArtifactKinds() []string {
+	return []string{
+		ArtifactKindSBOM,
+		ArtifactKindSignature,
+		ArtifactKindAttestation,
+		ArtifactKindSymbols,
+		ArtifactKindOther,
+	}
+}
+
+// artifactKindPrefixes maps the prefixes of well-known artifact types to the
+// kind of the artifact.
+var artifactKindPrefixes = []struct {
+	prefix string
+	kind   string
+}{
+	{"application/spdx", ArtifactKindSBOM},
+	{"text/spdx", ArtifactKindSBOM},
+	{"application/vnd.cyclonedx", ArtifactKindSBOM},
+	{"application/vnd.syft", ArtifactKindSBOM},
+	{"application/vnd.dev.cosign.artifact.sbom", ArtifactKindSBOM},
+	{"application/vnd.dev.cosign.artifact.sig", ArtifactKindSignature},
+	{"application/vnd.dev.cosign.simplesigning", ArtifactKindSignature},
+	{"application/vnd.cncf.notary.signature", ArtifactKindSignature},
+	{"application/vnd.dev.sigstore.bundle", ArtifactKindSignature},
+	{"application/pgp-signature", ArtifactKindSignature},
+	{"application/vnd.dev.cosign.artifact.att", ArtifactKindAttestation},
+	{"application/vnd.in-toto", ArtifactKindAttestation},
+	{"application/vnd.dsse.envelope", ArtifactKindAttestation},
+	{MediaTypeKernelDbg, ArtifactKindSymbols},
+}
+
+// ArtifactKindOf returns the kind of artifact of the provided artifact type,
+// or ArtifactKindOther if it is not well-known.
+func ArtifactKindOf(artifactType string) string {
+	for _, known := range artifactKindPrefixes {
+		if strings.HasPrefix(artifactType, known.prefix) {
+			return known.kind
+		}
+	}
+
+	return ArtifactKindOther
+}
+
+// Referrer is an artifact which refers to an image, e.g. its SBOM or
+// signature.
+type Referrer struct {
+	// Subject is the descriptor of the index or manifest the artifact refers
+	// to.
+	Subject ocispec.Descriptor
+
+	// Descriptor is the descriptor of the manifest of the artifact.
+	Descriptor ocispec.Descriptor
+
+	// Kind is the kind of the artifact, see ArtifactKindOf.
+	Kind string
+}
+
+// remoteOptions returns the options to access the registry of the reference
+// with the provided authentication.
+func remoteOptions(ctx context.Context, ref name.Reference, auths map[string]config.AuthConfig) []remote.Option {
+	ropts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithUserAgent(version.UserAgent()),
+	}
+
+	if auths == nil {
+		auths = config.G[config.KraftKit](ctx).Auth
+	}
+
+	// Annoyingly convert between regtypes and authn.
+	if auth, ok := auths[ref.Context().RegistryStr()]; ok {
+		ropts = append(ropts,
+			remote.WithAuth(&simpleauth.SimpleAuthenticator{
+				Auth: &authn.AuthConfig{
+					Username: auth.User,
+					Password: auth.Token,
+				},
+			}),
+		)
+
+		if !auth.VerifySSL {
+			transport := remote.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+
+			ropts = append(ropts, remote.WithTransport(transport))
+		}
+	}
+
+	return ropts
+}
+
+// parseRemoteReference parses the reference of a remote image, which defaults
+// to the default registry and tag.
+func parseRemoteReference(fullref string) (name.Reference, error) {
+	return name.ParseReference(fullref,
+		name.WithDefaultRegistry(DefaultRegistry),
+		name.WithDefaultTag(DefaultTag),
+	)
+}
+
+// ListReferrers returns the artifacts which refer to the remote image, either
+// to its index or to any of the manifests of the index.  Registries which do
+// not implement the referrers API of the OCI distribution specification v1.1
+// are queried through the fallback tag schema.
+func ListReferrers(ctx context.Context, fullref string, auths map[string]config.AuthConfig) ([]Referrer, error) {
+	if packmanager.IsOffline(ctx) {
+		return nil, fmt.Errorf("could not list referrers of '%s': %w", fullref, packmanager.ErrOffline)
+	}
+
+	ref, err := parseRemoteReference(fullref)
+	if err != nil {
+		return nil, err
+	}
+
+	ropts := remoteOptions(ctx, ref, auths)
+
+	desc, err := remote.Get(ref, ropts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get '%s': %w", ref.Name(), err)
+	}
+
+	subjects := FromGoogleV1DescriptorToOCISpec(desc.Descriptor)
+
+	if desc.MediaType.IsIndex() {
+		index, err := v1.ParseIndexManifest(bytes.NewReader(desc.Manifest))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse index of '%s': %w", ref.Name(), err)
+		}
+
+		subjects = append(subjects, FromGoogleV1DescriptorToOCISpec(index.Manifests...)...)
+	}
+
+	var referrers []Referrer
+
+	for _, subject := range subjects {
+		log.G(ctx).
+			WithField("ref", ref.Name()).
+			WithField("subject", subject.Digest.String()).
+			Trace("listing referrers")
+
+		index, err := remote.Referrers(ref.Context().Digest(subject.Digest.String()), ropts...)
+		if err != nil {
+			return nil, fmt.Errorf("could not list referrers of %s: %w", subject.Digest, err)
+		}
+
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return nil, fmt.Errorf("could not list referrers of %s: %w", subject.Digest, err)
+		}
+
+		for _, artifact := range FromGoogleV1DescriptorToOCISpec(manifest.Manifests...) {
+			referrers = append(referrers, Referrer{
+				Subject:    subject,
+				Descriptor: artifact,
+				Kind:       ArtifactKindOf(artifact.ArtifactType),
+			})
+		}
+	}
+
+	return referrers, nil
+}
+
+// FetchReferrer downloads the blobs of the artifact with the provided digest
+// from the repository of the remote image into dir, and returns the paths of
+// the downloaded files.  Blobs are named after their title annotation, if any,
+// and otherwise after their digest.  Artifacts without layers, whose content
+// is their config, have their config downloaded instead.
+func FetchReferrer(ctx context.Context, fullref string, dgst digest.Digest, dir string, auths map[string]config.AuthConfig) ([]string, error) {
+	if packmanager.IsOffline(ctx) {
+		return nil, fmt.Errorf("could not fetch '%s': %w", dgst, packmanager.ErrOffline)
+	}
+
+	ref, err := parseRemoteReference(fullref)
+	if err != nil {
+		return nil, err
+	}
+
+	ropts := remoteOptions(ctx, ref, auths)
+
+	desc, err := remote.Get(ref.Context().Digest(dgst.String()), ropts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get artifact %s: %w", dgst, err)
+	}
+
+	manifest, err := v1.ParseManifest(bytes.NewReader(desc.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse manifest of artifact %s: %w", dgst, err)
+	}
+
+	blobs := manifest.Layers
+	if len(blobs) == 0 && manifest.Config.MediaType != ocispec.MediaTypeEmptyJSON {
+		blobs = []v1.Descriptor{manifest.Config}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not make directory: %w", err)
+	}
+
+	var paths []string
+
+	for _, blob := range blobs {
+		// The title is set by the author of the artifact, hence it is reduced to
+		// a file name such that it cannot escape the directory.
+		filename := filepath.Base(filepath.Clean("/" + blob.Annotations[ocispec.AnnotationTitle]))
+		if filename == "/" || filename == "." {
+			filename = blob.Digest.Hex
+		}
+
+		path := filepath.Join(dir, filename)
+
+		log.G(ctx).
+			WithField("digest", blob.Digest.String()).
+			WithField("dest", path).
+			Debug("fetching")
+
+		layer, err := remote.Layer(ref.Context().Digest(blob.Digest.String()), ropts...)
+		if err != nil {
+			return nil, fmt.Errorf("could not get blob %s: %w", blob.Digest, err)
+		}
+
+		if err := writeLayer(layer, path); err != nil {
+			return nil, fmt.Errorf("could not fetch blob %s: %w", blob.Digest, err)
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// writeLayer writes the contents of the layer, as they are stored in the
+// registry, to path.  The contents are verified against the digest of the
+// layer as they are read.
+func writeLayer(layer v1.Layer, path string) error {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/packmanager"
+)
+
+func TestArtifactKindOf(t *testing.T) {
+	tests := []struct {
+		artifactType string
+		kind         string
+	}{
+		{artifactType: "application/spdx+json", kind: ArtifactKindSBOM},
+		{artifactType: "application/vnd.cyclonedx+json", kind: ArtifactKindSBOM},
+		{artifactType: "application/vnd.dev.cosign.artifact.sig.v1+json", kind: ArtifactKindSignature},
+		{artifactType: "application/vnd.cncf.notary.signature", kind: ArtifactKindSignature},
+		{artifactType: "application/vnd.in-toto+json", kind: ArtifactKindAttestation},
+		{artifactType: MediaTypeKernelDbg, kind: ArtifactKindSymbols},
+		{artifactType: "application/octet-stream", kind: ArtifactKindOther},
+		{artifactType: "", kind: ArtifactKindOther},
+	}
+
+	for _, tt := range tests {
+		if kind := ArtifactKindOf(tt.artifactType); kind != tt.kind {
+			t.Errorf("%q: expected: %q, got: %q", tt.artifactType, tt.kind, kind)
+		}
+	}
+}
+
+// pushArtifact pushes an artifact of the provided type, whose layers have the
+// provided titles and contents, which refers to the subject.
+func pushArtifact(t *testing.T, repo name.Repository, subject v1.Descriptor, artifactType string, layers map[string]string) v1.Hash {
+	t.Helper()
+
+	img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, types.MediaType(artifactType))
+
+	for title, content := range layers {
+		annotations := map[string]string{}
+		if title != "" {
+			annotations[ocispec.AnnotationTitle] = title
+		}
+
+		var err error
+		img, err = mutate.Append(img, mutate.Addendum{
+			Layer:       static.NewLayer([]byte(content), types.MediaType("application/octet-stream")),
+			Annotations: annotations,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	img = mutate.Subject(img, subject).(v1.Image)
+
+	dgst, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(repo.Digest(dgst.String()), img); err != nil {
+		t.Fatal(err)
+	}
+
+	return dgst
+}
+
+// newRegistry starts an in-memory registry holding an image which is referred
+// to by an SBOM and a signature, and returns the reference to the image and
+// the digests of the artifacts.
+func newRegistry(t *testing.T) (string, v1.Hash, v1.Hash) {
+	t.Helper()
+
+	server := httptest.NewServer(registry.New())
+	t.Cleanup(server.Close)
+
+	ref, err := name.ParseReference(strings.TrimPrefix(server.URL, "http://") + "/unikraft/hello:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+
+	desc, err := remote.Head(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sbom := pushArtifact(t, ref.Context(), *desc, "application/spdx+json", map[string]string{
+		"sbom.spdx.json": `{"spdxVersion":"SPDX-2.3"}`,
+	})
+
+	sig := pushArtifact(t, ref.Context(), *desc, "application/vnd.cncf.notary.signature", map[string]string{
+		"../../signature.sig": "signature",
+		"":                    "payload",
+	})
+
+	return ref.Name(), sbom, sig
+}
+
+func TestListReferrers(t *testing.T) {
+	ref, sbom, sig := newRegistry(t)
+
+	referrers, err := ListReferrers(context.Background(), ref, map[string]config.AuthConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(referrers) != 2 {
+		t.Fatalf("expected 2 referrers, got %d", len(referrers))
+	}
+
+	kinds := map[string]string{}
+	for _, referrer := range referrers {
+		kinds[referrer.Descriptor.Digest.String()] = referrer.Kind
+
+		if referrer.Subject.Digest == "" {
+			t.Errorf("expected the subject of %s to be set", referrer.Descriptor.Digest)
+		}
+	}
+
+	if kinds[sbom.String()] != ArtifactKindSBOM {
+		t.Errorf("expected %s to be an SBOM, got %q", sbom, kinds[sbom.String()])
+	}
+
+	if kinds[sig.String()] != ArtifactKindSignature {
+		t.Errorf("expected %s to be a signature, got %q", sig, kinds[sig.String()])
+	}
+
+	if _, err := ListReferrers(context.Background(), ref+"-missing", map[string]config.AuthConfig{}); err == nil {
+		t.Error("expected error for missing image")
+	}
+}
+
+func TestFetchReferrer(t *testing.T) {
+	ref, sbom, sig := newRegistry(t)
+
+	dir := filepath.Join(t.TempDir(), "artifacts")
+
+	paths, err := FetchReferrer(context.Background(), ref, digest.Digest(sbom.String()), dir, map[string]config.AuthConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(paths) != 1 || paths[0] != filepath.Join(dir, "sbom.spdx.json") {
+		t.Fatalf("expected the SBOM to be named after its title, got %v", paths)
+	}
+
+	if b, err := os.ReadFile(paths[0]); err != nil || string(b) != `{"spdxVersion":"SPDX-2.3"}` {
+		t.Errorf("unexpected contents: %q (%v)", b, err)
+	}
+
+	paths, err = FetchReferrer(context.Background(), ref, digest.Digest(sig.String()), dir, map[string]config.AuthConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	contents := map[string]string{}
+	for _, path := range paths {
+		if filepath.Dir(path) != dir {
+			t.Errorf("expected %s to be within %s", path, dir)
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		contents[filepath.Base(path)] = string(b)
+	}
+
+	// The title cannot escape the directory, and blobs without a title are named
+	// after their digest.
+	if contents["signature.sig"] != "signature" {
+		t.Errorf("expected the signature to be fetched into the directory, got %v", contents)
+	}
+
+	payload := static.NewLayer([]byte("payload"), types.MediaType("application/octet-stream"))
+	hash, err := payload.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if contents[hash.Hex] != "payload" {
+		t.Errorf("expected the untitled blob to be named %s, got %v", hash.Hex, contents)
+	}
+}
+
+func TestReferrersOffline(t *testing.T) {
+	cfgm, err := config.NewConfigManager(&config.KraftKit{Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := config.WithConfigManager(context.Background(), cfgm)
+
+	if _, err := ListReferrers(ctx, "unikraft.org/hello:latest", nil); !errors.Is(err, packmanager.ErrOffline) {
+		t.Errorf("expected offline error, got %v", err)
+	}
+
+	if _, err := FetchReferrer(ctx, "unikraft.org/hello:latest", digest.FromString("sbom"), t.TempDir(), nil); !errors.Is(err, packmanager.ErrOffline) {
+		t.Errorf("expected offline error, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// IsDigest returns whether the provided version identifier is a content
+// digest, e.g. `sha256:...`, rather than a tag.
+func IsDigest(version string) bool {
+	_, err := digest.Parse(version)
+	return err == nil
+}
+
+// JoinReference returns the OCI-standard reference for the provided name and
+// version, which is `name@digest` if the version is a digest and `name:tag`
+// otherwise.
+func JoinReference(name, version string) string {
+	if IsDigest(version) {
+		return fmt.Sprintf("%s@%s", name, version)
+	}
+
+	return fmt.Sprintf("%s:%s", name, version)
+}
+
+// ReferenceMatches returns whether the reference in the format `name:tag` or
+// `name@digest` refers to the provided name and version.  Indexes which have
+// been stored by digest may be listed with the encoded digest as their tag,
+// which is also considered a match.
+func ReferenceMatches(ref, name, version string) bool {
+	if ref == JoinReference(name, version) {
+		return true
+	}
+
+	if dgst, err := digest.Parse(version); err == nil {
+		return ref == fmt.Sprintf("%s:%s", name, dgst.Encoded())
+	}
+
+	return false
+}
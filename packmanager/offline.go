@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package packmanager
+
+import (
+	"context"
+	"errors"
+
+	"kraftkit.sh/config"
+)
+
+// ErrOffline is returned when a remote registry or manifest is accessed whilst
+// offline mode is enabled.
+var ErrOffline = errors.New("remote access is disabled in offline mode")
+
+// IsOffline returns whether offline mode has been enabled, either via the
+// --offline flag or the `offline` configuration key, in which case package
+// managers must only use packages and sources which have been pre-fetched.
+func IsOffline(ctx context.Context) bool {
+	return config.G[config.KraftKit](ctx).Offline
+}
@@ -9,6 +9,7 @@ package packmanager
 type PackOptions struct {
 	appSourceFiles                   bool
 	args                             []string
+	entrypoint                       []string
 	env                              []string
 	initrd                           string
 	kconfig                          bool
@@ -42,6 +43,12 @@ func (popts *PackOptions) Args() []string {
 	return popts.args
 }
 
+// Entrypoint returns the arguments which precede the arguments passed to the
+// kernel.
+func (popts *PackOptions) Entrypoint() []string {
+	return popts.entrypoint
+}
+
 // Env returns the environment variables to be passed to the kernel.
 func (popts *PackOptions) Env() []string {
 	return popts.env
@@ -120,6 +127,15 @@ func PackArgs(args ...string) PackOption {
 	}
 }
 
+// PackEntrypoint sets the arguments which precede the arguments passed to the
+// application, which are not replaced when the arguments are overridden at
+// run time.
+func PackEntrypoint(entrypoint ...string) PackOption {
+	return func(popts *PackOptions) {
+		popts.entrypoint = entrypoint
+	}
+}
+
 // PackKConfig marks to include the kconfig `.config` file into the package.
 func PackKConfig(kconfig bool) PackOption {
 	return func(popts *PackOptions) {
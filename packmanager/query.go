@@ -5,6 +5,8 @@
 package packmanager
 
 import (
+	"time"
+
 	"kraftkit.sh/config"
 	"kraftkit.sh/unikraft"
 	"kraftkit.sh/utils"
@@ -46,6 +48,10 @@ type Query struct {
 
 	// KConfig specifies the list of config options of the package
 	kConfig []string
+
+	// Timeout is the maximum duration each package manager is given to query
+	// its catalog.
+	timeout time.Duration
 }
 
 // Source specifies where the origin of the package
@@ -83,6 +89,12 @@ func (query *Query) KConfig() []string {
 	return query.kConfig
 }
 
+// Timeout is the maximum duration each package manager is given to query its
+// catalog, or zero if there is no limit.
+func (query *Query) Timeout() time.Duration {
+	return query.timeout
+}
+
 // Remote indicates whether the package manager should use remote manifests
 // when making its query.
 func (query *Query) Remote() bool {
@@ -224,6 +236,15 @@ func WithAuthConfig(auths map[string]config.AuthConfig) QueryOption {
 	}
 }
 
+// WithTimeout sets the maximum duration each package manager is given to
+// query its catalog.  Package managers which do not respond in time are
+// skipped such that the results of the others are still returned.
+func WithTimeout(timeout time.Duration) QueryOption {
+	return func(query *Query) {
+		query.timeout = timeout
+	}
+}
+
 func WithAll(all bool) QueryOption {
 	return func(query *Query) {
 		query.all = all
@@ -8,9 +8,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"kraftkit.sh/config"
 	"kraftkit.sh/log"
 
 	"kraftkit.sh/pack"
@@ -56,6 +59,10 @@ func (u UmbrellaManager) From(sub pack.PackageFormat) (PackageManager, error) {
 }
 
 func (u UmbrellaManager) Update(ctx context.Context) error {
+	if IsOffline(ctx) {
+		return fmt.Errorf("could not update package index: %w", ErrOffline)
+	}
+
 	for _, manager := range u.packageManagers {
 		log.G(ctx).WithFields(logrus.Fields{
 			"format": manager.Format(),
@@ -163,23 +170,98 @@ func (u UmbrellaManager) Unpack(ctx context.Context, source pack.Package, opts .
 	return ret, nil
 }
 
+// Catalog queries the catalogs of all registered package managers
+// concurrently and returns the packages of those which succeeded.  Package
+// managers which fail, or do not respond within the timeout of the query, are
+// skipped.
 func (u UmbrellaManager) Catalog(ctx context.Context, qopts ...QueryOption) ([]pack.Package, error) {
-	var packages []pack.Package
+	query := NewQuery(qopts...)
+	if query.Remote() && IsOffline(ctx) {
+		return nil, fmt.Errorf("could not search remote catalog: %w", ErrOffline)
+	}
+
+	managers := make([]PackageManager, 0, len(u.packageManagers))
 	for _, manager := range u.packageManagers {
-		pack, err := manager.Catalog(ctx, qopts...)
-		if err != nil {
-			log.G(ctx).
-				WithField("format", manager.Format()).
-				Debugf("could not query catalog: %v", err)
+		managers = append(managers, manager)
+	}
+
+	results := make([][]pack.Package, len(managers))
+	parallel := !config.G[config.KraftKit](ctx).NoParallel
+
+	var wg sync.WaitGroup
+
+	for i, manager := range managers {
+		if !parallel {
+			results[i] = catalog(ctx, manager, query.Timeout(), qopts...)
 			continue
 		}
 
-		packages = append(packages, pack...)
+		wg.Add(1)
+
+		go func(i int, manager PackageManager) {
+			defer wg.Done()
+			results[i] = catalog(ctx, manager, query.Timeout(), qopts...)
+		}(i, manager)
+	}
+
+	wg.Wait()
+
+	var packages []pack.Package
+	for _, result := range results {
+		packages = append(packages, result...)
 	}
 
 	return packages, nil
 }
 
+// catalog queries the catalog of a single package manager, giving up once the
+// timeout elapses even if the package manager does not respect the
+// cancellation of the context.  Failures are logged rather than returned such
+// that the results of other package managers can still be used.
+func catalog(ctx context.Context, manager PackageManager, timeout time.Duration, qopts ...QueryOption) []pack.Package {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		packages []pack.Package
+		err      error
+	}
+
+	done := make(chan result, 1)
+	start := time.Now()
+
+	go func() {
+		packages, err := manager.Catalog(ctx, qopts...)
+		done <- result{packages, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			log.G(ctx).
+				WithField("format", manager.Format()).
+				Debugf("could not query catalog: %v", res.err)
+			return nil
+		}
+
+		log.G(ctx).
+			WithField("format", manager.Format()).
+			WithField("duration", time.Since(start)).
+			Tracef("queried catalog")
+
+		return res.packages
+
+	case <-ctx.Done():
+		log.G(ctx).
+			WithField("format", manager.Format()).
+			Warnf("skipping catalog which did not respond in time: %v", ctx.Err())
+		return nil
+	}
+}
+
 func (u UmbrellaManager) IsCompatible(ctx context.Context, source string, qopts ...QueryOption) (PackageManager, bool, error) {
 	if source == "" {
 		return nil, false, fmt.Errorf("cannot determine compatibility of empty source")
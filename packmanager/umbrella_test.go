@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package packmanager
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"kraftkit.sh/pack"
+)
+
+// fakeManager is a package manager whose catalog responds after a delay.
+type fakeManager struct {
+	PackageManager
+
+	format   pack.PackageFormat
+	delay    time.Duration
+	packages []pack.Package
+	err      error
+}
+
+func (manager *fakeManager) Catalog(ctx context.Context, _ ...QueryOption) ([]pack.Package, error) {
+	time.Sleep(manager.delay)
+	return manager.packages, manager.err
+}
+
+func (manager *fakeManager) Format() pack.PackageFormat {
+	return manager.format
+}
+
+func TestUmbrellaCatalogPartialResults(t *testing.T) {
+	umbrella := UmbrellaManager{
+		packageManagers: map[pack.PackageFormat]PackageManager{
+			"fast": &fakeManager{
+				format:   "fast",
+				delay:    10 * time.Millisecond,
+				packages: make([]pack.Package, 2),
+			},
+			"slow": &fakeManager{
+				format:   "slow",
+				delay:    time.Minute,
+				packages: make([]pack.Package, 1),
+			},
+			"failing": &fakeManager{
+				format: "failing",
+				err:    fmt.Errorf("unreachable"),
+			},
+			"other": &fakeManager{
+				format:   "other",
+				delay:    10 * time.Millisecond,
+				packages: make([]pack.Package, 3),
+			},
+		},
+	}
+
+	start := time.Now()
+
+	packages, err := umbrella.Catalog(context.Background(), WithTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packages) != 5 {
+		t.Errorf("expected 5 packages from the responsive managers, got %d", len(packages))
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the slow manager to be skipped after its timeout, took %s", elapsed)
+	}
+}
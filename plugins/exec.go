@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// ExecutablePluginPrefix is the prefix of standalone executables which are
+	// surfaced as kraft subcommands, e.g. `kraft-foo` becomes `kraft foo`.
+	ExecutablePluginPrefix = "kraft-"
+
+	// PluginContextEnv is the environmental variable which holds the JSON
+	// encoded PluginContext when invoking an executable plugin.
+	PluginContextEnv = "KRAFTKIT_PLUGIN_CONTEXT"
+)
+
+// PluginContext is the shared context which is passed to executable plugins
+// so that they can behave consistently with the invoking kraft binary.
+type PluginContext struct {
+	// Version of the invoking kraft binary.
+	Version string `json:"version"`
+
+	// ConfigFile is the path to the configuration file in use, if any.
+	ConfigFile string `json:"config_file,omitempty"`
+
+	// Config is the fully resolved KraftKit configuration, without any
+	// credentials.
+	Config any `json:"config,omitempty"`
+
+	// LogLevel is the log level set for the invocation.
+	LogLevel string `json:"log_level"`
+
+	// LogType is the log type set for the invocation.
+	LogType string `json:"log_type"`
+}
+
+// Executables returns the list of executable plugins which are found first in
+// the plugin directory and subsequently in each directory of the PATH.  When
+// more than one executable exists with the same name, the first is used.
+func (pm *PluginManager) Executables() ([]*Plugin, error) {
+	dirs := []string{}
+	if pm.dataDir != "" {
+		dirs = append(dirs, pm.dataDir)
+	}
+
+	dirs = append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+
+	seen := map[string]bool{}
+	found := []*Plugin{}
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), ExecutablePluginPrefix) {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+
+			plugin := &Plugin{
+				path:    path,
+				isLocal: true,
+				kind:    ExecutableKind,
+			}
+
+			if plugin.Name() == "" || seen[plugin.Name()] {
+				continue
+			}
+
+			seen[plugin.Name()] = true
+			found = append(found, plugin)
+		}
+	}
+
+	return found, nil
+}
+
+// Command prepares the invocation of an executable plugin with the provided
+// arguments, passing the shared plugin context via the environment.
+func (p *Plugin) Command(ctx context.Context, pctx PluginContext, args ...string) (*exec.Cmd, error) {
+	if p.kind != ExecutableKind {
+		return nil, fmt.Errorf("plugin '%s' is not executable", p.Name())
+	}
+
+	b, err := json.Marshal(pctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode plugin context: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, p.path, args...)
+	cmd.Env = append(os.Environ(), PluginContextEnv+"="+string(b))
+
+	return cmd, nil
+}
+
+// isExecutable checks whether the provided path is a regular file which can be
+// executed by the current user.
+func isExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() {
+		return false
+	}
+
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+
+	return fi.Mode().Perm()&0o111 != 0
+}
@@ -47,6 +47,7 @@ type PluginKind int
 const (
 	GitKind PluginKind = iota
 	BinaryKind
+	ExecutableKind
 )
 
 type Plugin struct {
@@ -62,6 +63,14 @@ type Plugin struct {
 type PluginOption func(p *Plugin)
 
 func (p *Plugin) Name() string {
+	if p.kind == ExecutableKind {
+		name := strings.TrimPrefix(filepath.Base(p.path), ExecutablePluginPrefix)
+		if runtime.GOOS == "windows" {
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		return name
+	}
+
 	name := strings.TrimPrefix(filepath.Base(p.path), PluginNamePrefix)
 	ext := ".so"
 	if runtime.GOOS == "windows" {
@@ -97,6 +106,10 @@ func (p *Plugin) IsBinary() bool {
 	return p.kind == BinaryKind
 }
 
+func (p *Plugin) IsExecutable() bool {
+	return p.kind == ExecutableKind
+}
+
 func (p *Plugin) Aliases() []string {
 	return p.aliases
 }
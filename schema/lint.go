@@ -0,0 +1,498 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// Violation is a single violation of the Kraftfile specification at a position
+// within a file.
+type Violation struct {
+	// File is the path of the file which contains the violation.
+	File string
+
+	// Line and Column are the 1-indexed position of the violation in File.
+	Line   int
+	Column int
+
+	// Field is the dot-separated path of the offending field, e.g.
+	// `targets.0.arch`.
+	Field string
+
+	// Message describes the violation.
+	Message string
+
+	// Unknown is set when the field is not part of the specification, as opposed
+	// to being of the wrong type.  Unknown fields are ignored when loading a
+	// Kraftfile.
+	Unknown bool
+}
+
+// Error implements error.
+func (v *Violation) Error() string {
+	field := v.Field
+	if field == "" {
+		field = "(root)"
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s: %s", v.File, v.Line, v.Column, field, v.Message)
+}
+
+// Violations is a list of violations which are reported together.
+type Violations []*Violation
+
+// Error implements error.
+func (vs Violations) Error() string {
+	lines := make([]string, len(vs))
+	for i, v := range vs {
+		lines[i] = v.Error()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Unknown returns the violations of fields which are not part of the
+// specification.
+func (vs Violations) Unknown() Violations {
+	var ret Violations
+	for _, v := range vs {
+		if v.Unknown {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+// Invalid returns the violations of fields which are part of the
+// specification but whose values are invalid.
+func (vs Violations) Invalid() Violations {
+	var ret Violations
+	for _, v := range vs {
+		if !v.Unknown {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+var (
+	strictOnce   sync.Once
+	strictSchema *gojsonschema.Schema
+	strictErr    error
+	knownFields  map[string][]*regexp.Regexp
+)
+
+// definitionPlaces are the human readable names of the places in a Kraftfile
+// which are described by the definitions of the specification.
+var definitionPlaces = map[string]string{
+	"library": "a library",
+	"target":  "a target",
+	"volume":  "a volume",
+}
+
+// loadStrictSchema returns the latest specification in which no properties
+// besides those specified are allowed, along with the fields which are known
+// to each of its definitions.
+func loadStrictSchema() (*gojsonschema.Schema, error) {
+	strictOnce.Do(func() {
+		var spec map[string]interface{}
+		if strictErr = json.Unmarshal([]byte(SchemaV_06), &spec); strictErr != nil {
+			return
+		}
+
+		knownFields = map[string][]*regexp.Regexp{}
+		collectKnownFields("the top level", spec)
+
+		if definitions, ok := spec["definitions"].(map[string]interface{}); ok {
+			for name, definition := range definitions {
+				if place, ok := definitionPlaces[name]; ok {
+					name = place
+				}
+
+				collectKnownFields(name, definition)
+			}
+		}
+
+		disallowAdditionalProperties(spec)
+
+		strictSchema, strictErr = gojsonschema.NewSchema(gojsonschema.NewGoLoader(spec))
+	})
+
+	return strictSchema, strictErr
+}
+
+// disallowAdditionalProperties recursively sets `additionalProperties` to false
+// for every object in the schema which declares its properties.
+func disallowAdditionalProperties(node interface{}) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		_, hasProperties := value["properties"]
+		_, hasPatternProperties := value["patternProperties"]
+		if hasProperties || hasPatternProperties {
+			value["additionalProperties"] = false
+		}
+
+		for _, child := range value {
+			disallowAdditionalProperties(child)
+		}
+
+	case []interface{}:
+		for _, child := range value {
+			disallowAdditionalProperties(child)
+		}
+	}
+}
+
+// collectKnownFields records the properties which are declared by the schema
+// node, including those of its `oneOf` alternatives, under the provided name.
+func collectKnownFields(name string, node interface{}) {
+	value, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if properties, ok := value["properties"].(map[string]interface{}); ok {
+		for property := range properties {
+			knownFields[name] = append(knownFields[name], regexp.MustCompile("^"+regexp.QuoteMeta(property)+"$"))
+		}
+	}
+
+	if properties, ok := value["patternProperties"].(map[string]interface{}); ok {
+		for pattern := range properties {
+			// Skip patterns which match arbitrary keys, e.g. names of libraries, as
+			// they would match any misplaced field.
+			if pattern == ".+" || pattern == "^x-" || strings.Contains(pattern, "[") {
+				continue
+			}
+
+			knownFields[name] = append(knownFields[name], regexp.MustCompile(pattern))
+		}
+	}
+
+	if alternatives, ok := value["oneOf"].([]interface{}); ok {
+		for _, alternative := range alternatives {
+			collectKnownFields(name, alternative)
+		}
+	}
+}
+
+// placesOf returns the names of the places in the specification where the
+// field is known.
+func placesOf(field string) []string {
+	var places []string
+	for name, patterns := range knownFields {
+		for _, pattern := range patterns {
+			if pattern.MatchString(field) {
+				places = append(places, name)
+				break
+			}
+		}
+	}
+
+	sort.Strings(places)
+
+	return places
+}
+
+// Lint validates the contents of the Kraftfile at the provided path against
+// the latest specification and returns all violations, each located at its
+// position within the file.  In contrast to Validate, fields which are not
+// part of the specification are reported too.
+func Lint(ctx context.Context, file string, content []byte) (Violations, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", file, err)
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return Violations{{
+			File:    file,
+			Line:    1,
+			Column:  1,
+			Message: "must be a mapping",
+		}}, nil
+	}
+
+	root := doc.Content[0]
+
+	violations, err := LintNode(ctx, file, root)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, node := lookup(root, []string{"spec"}); node == nil {
+		if _, node := lookup(root, []string{"specification"}); node == nil {
+			violations = append(Violations{{
+				File:    file,
+				Line:    root.Line,
+				Column:  root.Column,
+				Message: "missing 'spec' version attribute",
+			}}, violations...)
+		}
+	}
+
+	return violations, nil
+}
+
+// LintNode validates the YAML mapping node against the latest specification of
+// the Kraftfile and returns all violations, each located at its position within
+// the file.  This allows for Kraftfile specifications which are embedded in
+// other files, e.g. as an extension of a Compose service.
+func LintNode(_ context.Context, file string, node *yaml.Node) (Violations, error) {
+	schema, err := loadStrictSchema()
+	if err != nil {
+		return nil, fmt.Errorf("could not load specification: %w", err)
+	}
+
+	var data interface{}
+	if err := node.Decode(&data); err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", file, err)
+	}
+
+	result, err := schema.Validate(gojsonschema.NewGoLoader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var violations Violations
+
+	for _, rerr := range relevantErrors(result.Errors()) {
+		path := strings.Split(rerr.Context().String("\x00"), "\x00")[1:]
+
+		violation := &Violation{
+			File: file,
+		}
+
+		var at *yaml.Node
+
+		switch rerr.Type() {
+		case "additional_property_not_allowed":
+			property, _ := rerr.Details()["property"].(string)
+			path = append(path, property)
+
+			violation.Unknown = true
+			violation.Message = "unknown field"
+
+			if places := placesOf(property); len(places) > 0 {
+				violation.Message += ": did you mean to place it in " + strings.Join(places, " or ") + "?"
+			}
+
+			at, _ = lookup(node, path)
+
+		default:
+			violation.Message = describe(rerr)
+			_, at = lookup(node, path)
+		}
+
+		violation.Field = strings.Join(path, ".")
+
+		if at == nil {
+			at = node
+		}
+
+		violation.Line = at.Line
+		violation.Column = at.Column
+
+		violations = append(violations, violation)
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Line != violations[j].Line {
+			return violations[i].Line < violations[j].Line
+		}
+
+		return violations[i].Column < violations[j].Column
+	})
+
+	return violations, nil
+}
+
+// relevantErrors reduces the errors of `oneOf` and `anyOf` schemas to those of
+// the alternative which matched best: when the type of an alternative matched,
+// only the errors within the value are kept, otherwise the type mismatch.
+func relevantErrors(errs []gojsonschema.ResultError) []gojsonschema.ResultError {
+	field := func(err gojsonschema.ResultError) string {
+		return err.Context().String("\x00")
+	}
+
+	drop := map[int]bool{}
+
+	for i, err := range errs {
+		if err.Type() != jsonschemaOneOf && err.Type() != jsonschemaAnyOf {
+			continue
+		}
+
+		drop[i] = true
+
+		prefix := field(err)
+		deeper := slices.ContainsFunc(errs, func(other gojsonschema.ResultError) bool {
+			return strings.HasPrefix(field(other), prefix+"\x00") ||
+				(field(other) == prefix && other.Type() == "additional_property_not_allowed")
+		})
+		if !deeper {
+			continue
+		}
+
+		for j, other := range errs {
+			if field(other) == prefix && other.Type() == "invalid_type" {
+				drop[j] = true
+			}
+		}
+	}
+
+	var ret []gojsonschema.ResultError
+	seen := map[string]bool{}
+
+	for i, err := range errs {
+		if drop[i] {
+			continue
+		}
+
+		key := field(err) + "\x00" + err.Type() + "\x00" + err.Description()
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		ret = append(ret, err)
+	}
+
+	return ret
+}
+
+// describe returns a human readable description of the error.
+func describe(err gojsonschema.ResultError) string {
+	if err.Type() == "invalid_type" {
+		if expected, ok := err.Details()["expected"].(string); ok {
+			if strings.HasPrefix(expected, "[") && !strings.Contains(expected, ",") {
+				expected = strings.Trim(expected, "[]")
+			}
+
+			return fmt.Sprintf("must be a %s", humanReadableType(expected))
+		}
+	}
+
+	return err.Description()
+}
+
+// lookup returns the key and value nodes of the field at the path within the
+// node, where the key node is nil for items of sequences.
+func lookup(node *yaml.Node, path []string) (*yaml.Node, *yaml.Node) {
+	var key *yaml.Node
+
+	for i, field := range path {
+		if node.Kind == yaml.AliasNode {
+			return lookup(node.Alias, path[i:])
+		}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			var next *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == field {
+					key, next = node.Content[i], node.Content[i+1]
+					break
+				}
+			}
+
+			if next == nil {
+				return nil, nil
+			}
+
+			node = next
+
+		case yaml.SequenceNode:
+			index, err := strconv.Atoi(field)
+			if err != nil || index < 0 || index >= len(node.Content) {
+				return nil, nil
+			}
+
+			key, node = nil, node.Content[index]
+
+		default:
+			return nil, nil
+		}
+	}
+
+	return key, node
+}
+
+// ComposeExtension is the extension field of Compose services which embeds the
+// Kraftfile specification of the service.
+const ComposeExtension = "x-kraft"
+
+// LintCompose validates the Kraftfile specifications which are embedded as
+// extensions of the services of the Compose file at the provided path and
+// returns all violations, each located at its position within the file.
+func LintCompose(ctx context.Context, file string, content []byte) (Violations, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", file, err)
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	_, services := lookup(doc.Content[0], []string{"services"})
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var violations Violations
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name := services.Content[i].Value
+
+		_, extension := lookup(services.Content[i+1], []string{ComposeExtension})
+		if extension == nil {
+			continue
+		}
+
+		prefix := strings.Join([]string{"services", name, ComposeExtension}, ".")
+
+		if extension.Kind != yaml.MappingNode {
+			violations = append(violations, &Violation{
+				File:    file,
+				Line:    extension.Line,
+				Column:  extension.Column,
+				Field:   prefix,
+				Message: "must be a mapping",
+			})
+			continue
+		}
+
+		found, err := LintNode(ctx, file, extension)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, violation := range found {
+			if violation.Field == "" {
+				violation.Field = prefix
+			} else {
+				violation.Field = prefix + "." + violation.Field
+			}
+		}
+
+		violations = append(violations, found...)
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package schema
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name       string
+		kraftfile  string
+		violations []string
+	}{
+		{
+			name: "valid runtime",
+			kraftfile: `spec: v0.6
+name: nginx
+runtime: nginx:1.25
+rootfs: ./Dockerfile
+cmd: ["/usr/bin/nginx", "-c", "/etc/nginx/nginx.conf"]
+labels:
+  cloud.unikraft.v1.instances/scale_to_zero.policy: "idle"
+  cloud.unikraft.v1.instances/scale_to_zero.stateful: true
+volumes:
+  - ./html:/wwwroot
+env:
+  - DEBUG
+x-custom:
+  anything: goes
+`,
+		},
+		{
+			name: "valid unikraft",
+			kraftfile: `specification: '0.6'
+unikraft:
+  version: stable
+  kconfig:
+    CONFIG_LIBUKDEBUG: 'y'
+libraries:
+  musl: stable
+  lwip:
+    version: stable
+    kconfig:
+      - CONFIG_LWIP_TCP=y
+targets:
+  - qemu/x86_64
+  - plat: firecracker
+    arch: arm64
+    rootfs: ./Dockerfile.arm64
+    command: /server
+    env:
+      MODE: fc
+`,
+		},
+		{
+			name: "unknown and misplaced fields",
+			kraftfile: `spec: v0.6
+runtime: base:latest
+kernel: ./kernel
+targets:
+  - plat: qemu
+    arch: x86_64
+    foo: bar
+`,
+			violations: []string{
+				"Kraftfile:3:1: kernel: unknown field: did you mean to place it in a target?",
+				"Kraftfile:7:5: targets.0.foo: unknown field",
+			},
+		},
+		{
+			name: "type mismatches",
+			kraftfile: `spec: v0.6
+name: [nginx]
+runtime: base:latest
+targets:
+  - 42
+  - plat: qemu
+    arch: x86_64
+    cmd: 80
+`,
+			violations: []string{
+				"Kraftfile:2:7: name: must be a string",
+				"Kraftfile:5:5: targets.0: must be a string",
+				"Kraftfile:8:10: targets.1.cmd: must be a string or list",
+			},
+		},
+		{
+			name:      "missing specification",
+			kraftfile: "runtime: base:latest\n",
+			violations: []string{
+				"Kraftfile:1:1: (root): missing 'spec' version attribute",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := Lint(context.Background(), "Kraftfile", []byte(tt.kraftfile))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(violations) != len(tt.violations) {
+				t.Fatalf("expected %d violations, got %d:\n%s", len(tt.violations), len(violations), violations.Error())
+			}
+
+			for i, violation := range violations {
+				if violation.Error() != tt.violations[i] {
+					t.Errorf("expected violation %q, got %q", tt.violations[i], violation.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestLintCompose(t *testing.T) {
+	composefile := `services:
+  nginx:
+    image: nginx:latest
+    x-kraft:
+      runtime: nginx:latest
+      rootfs: [./Dockerfile]
+  redis:
+    image: redis:latest
+`
+
+	violations, err := LintCompose(context.Background(), "compose.yaml", []byte(composefile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(violations) != 1 {
+		t.Fatalf("expected 1 violation, got %d:\n%s", len(violations), violations.Error())
+	}
+
+	if expect, got := "compose.yaml:6:15: services.nginx.x-kraft.rootfs: must be a string", violations[0].Error(); expect != got {
+		t.Errorf("expected violation %q, got %q", expect, got)
+	}
+}
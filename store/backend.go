@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	zip "api.zip"
+)
+
+// Backend is the database which an object store persists its objects in.
+type Backend string
+
+const (
+	// BackendBadger stores objects in a Badger key-value database, which is a
+	// directory at the path of the store.
+	BackendBadger = Backend("badger")
+
+	// BackendBolt stores objects in a single BoltDB file at the path of the
+	// store with the `.db` extension.  Every change is synced to disk before it
+	// is acknowledged and, unlike the Badger backend, counting objects and
+	// watching for changes within the same process are supported.
+	BackendBolt = Backend("bolt")
+)
+
+// String implements fmt.Stringer
+func (backend Backend) String() string {
+	return string(backend)
+}
+
+// Backends returns the supported store backends.
+func Backends() []Backend {
+	return []Backend{
+		BackendBadger,
+		BackendBolt,
+	}
+}
+
+// New returns an api.zip.Store-compatible storage interface at the provided
+// path which persists its objects in the provided backend.  If no backend is
+// set, the Badger backend is used.
+func New[Spec, Status any](backend Backend, path string) (zip.Store, error) {
+	switch backend {
+	case "", BackendBadger:
+		return NewEmbeddedStore[Spec, Status](path)
+	case BackendBolt:
+		return NewBoltStore[Spec, Status](path)
+	}
+
+	names := []string{}
+	for _, b := range Backends() {
+		names = append(names, b.String())
+	}
+
+	return nil, fmt.Errorf("unsupported store backend '%s': expected one of %s", backend, strings.Join(names, ", "))
+}
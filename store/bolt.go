@@ -0,0 +1,404 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	zip "api.zip"
+	bolt "go.etcd.io/bbolt"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+)
+
+// boltBucket is the name of the bucket which holds all objects of a store.
+var boltBucket = []byte("objects")
+
+// boltWatchBuffer is the number of events which are buffered for each watcher
+// before further events are dropped.
+const boltWatchBuffer = 100
+
+// boltStore is an object store based on the embeddable database BoltDB.  The
+// database is only held open for the duration of each operation such that
+// multiple KraftKit processes can access the store, with BoltDB's own file
+// lock serializing them.
+type boltStore[Spec, Status any] struct {
+	path      string
+	versioner *embeddedVersioner
+	timeout   time.Duration
+
+	mu       sync.Mutex
+	watchers map[*boltWatcher]struct{}
+}
+
+// NewBoltStore returns a api.zip.Store-compatible storage interface based on
+// the embeddable database BoltDB.  The database is kept in a single file at
+// the provided path with the `.db` extension.
+func NewBoltStore[Spec, Status any](path string) (zip.Store, error) {
+	if len(path) == 0 {
+		dir, err := os.MkdirTemp("", "")
+		if err != nil {
+			return nil, err
+		}
+
+		path = filepath.Join(dir, "store")
+	}
+
+	return &boltStore[Spec, Status]{
+		path:      filepath.Clean(path) + ".db",
+		versioner: &embeddedVersioner{},
+		timeout:   5 * time.Second,
+		watchers:  map[*boltWatcher]struct{}{},
+	}, nil
+}
+
+// transact opens the database and runs the provided function within a single
+// transaction, which is committed if it is writable.
+func (store *boltStore[_, _]) transact(ctx context.Context, update bool, fn func(*bolt.Bucket) error) error {
+	if err := os.MkdirAll(filepath.Dir(store.path), 0o755); err != nil {
+		return fmt.Errorf("could not prepare bolt store: %w", err)
+	}
+
+	return Retry(ctx, func() error {
+		db, err := bolt.Open(store.path, 0o600, &bolt.Options{
+			Timeout: store.timeout,
+		})
+		if errors.Is(err, bolt.ErrTimeout) {
+			return fmt.Errorf("could not open bolt store within %s: %w", store.timeout, ErrBusy)
+		} else if err != nil {
+			return fmt.Errorf("could not open bolt store: %w", err)
+		}
+
+		defer db.Close()
+
+		if !update {
+			return db.View(func(tx *bolt.Tx) error {
+				bucket := tx.Bucket(boltBucket)
+				if bucket == nil {
+					// Nothing has been written to the store yet.
+					return fn(nil)
+				}
+
+				return fn(bucket)
+			})
+		}
+
+		return db.Update(func(tx *bolt.Tx) error {
+			bucket, err := tx.CreateBucketIfNotExists(boltBucket)
+			if err != nil {
+				return err
+			}
+
+			return fn(bucket)
+		})
+	})
+}
+
+// decode the value of the key in the bucket into the object.
+func (store *boltStore[_, _]) decode(bucket *bolt.Bucket, key string, objPtr runtime.Object) error {
+	if bucket == nil {
+		return storage.NewKeyNotFoundError(key, 0)
+	}
+
+	val := bucket.Get([]byte(key))
+	if val == nil {
+		return storage.NewKeyNotFoundError(key, 0)
+	}
+
+	return decode(val, objPtr)
+}
+
+// notify the watchers of keys with a matching prefix of a change.
+func (store *boltStore[_, _]) notify(key string, eventType watch.EventType, obj runtime.Object) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for watcher := range store.watchers {
+		if !strings.HasPrefix(key, watcher.prefix) {
+			continue
+		}
+
+		select {
+		case watcher.result <- watch.Event{Type: eventType, Object: obj.DeepCopyObject()}:
+		default:
+			// The watcher is not keeping up, drop the event rather than blocking
+			// the store.
+		}
+	}
+}
+
+// Versioner implements storage.Interface
+func (store *boltStore[_, _]) Versioner() storage.Versioner {
+	return store.versioner
+}
+
+// RequestWatchProgress implements storage.Interface
+func (store *boltStore[_, _]) RequestWatchProgress(ctx context.Context) error {
+	return fmt.Errorf("not implemented: kraftkit.sh/store.boltStore.RequestWatchProgress")
+}
+
+// Create implements storage.Interface
+func (store *boltStore[_, _]) Create(ctx context.Context, key string, _, out runtime.Object, ttl uint64) error {
+	b := bytes.Buffer{}
+	if err := gob.NewEncoder(&b).Encode(out); err != nil {
+		return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+	}
+
+	eventType := watch.Added
+
+	if err := store.transact(ctx, true, func(bucket *bolt.Bucket) error {
+		if bucket.Get([]byte(key)) != nil {
+			eventType = watch.Modified
+		}
+
+		return bucket.Put([]byte(key), b.Bytes())
+	}); err != nil {
+		return fmt.Errorf("could not save machine driver to store for %s: %w", key, err)
+	}
+
+	store.notify(key, eventType, out)
+
+	return nil
+}
+
+// Delete implements storage.Interface
+//
+// If preconditions or validateDeletion are provided, they are checked against
+// the stored object within the same transaction as it is deleted, and the
+// object must exist.
+func (store *boltStore[Spec, Status]) Delete(ctx context.Context, key string, out runtime.Object, preconditions *storage.Preconditions, validateDeletion storage.ValidateObjectFunc, cachedExistingObject runtime.Object) error {
+	existed := false
+
+	if out == nil {
+		out = &zip.Object[Spec, Status]{}
+	}
+
+	if err := store.transact(ctx, true, func(bucket *bolt.Bucket) error {
+		if err := store.decode(bucket, key, out); storage.IsNotFound(err) {
+			if preconditions != nil || validateDeletion != nil {
+				return err
+			}
+
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		existed = true
+
+		if preconditions != nil {
+			if err := preconditions.Check(key, out); err != nil {
+				return err
+			}
+		}
+
+		if validateDeletion != nil {
+			if err := validateDeletion(ctx, out); err != nil {
+				return err
+			}
+		}
+
+		return bucket.Delete([]byte(key))
+	}); err != nil {
+		return err
+	}
+
+	if existed {
+		store.notify(key, watch.Deleted, out)
+	}
+
+	return nil
+}
+
+// Watch implements storage.Interface
+//
+// Only changes made through this instance of the store are observed.  Since
+// the database is not monitored, writes by other processes, e.g. another
+// invocation of KraftKit, or by other instances of the store at the same path
+// within this process are not delivered to the watcher.
+func (store *boltStore[_, _]) Watch(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
+	watcher := &boltWatcher{
+		prefix: key,
+		result: make(chan watch.Event, boltWatchBuffer),
+		done:   make(chan struct{}),
+		stop: func(watcher *boltWatcher) {
+			store.mu.Lock()
+			defer store.mu.Unlock()
+
+			delete(store.watchers, watcher)
+			close(watcher.result)
+		},
+	}
+
+	store.mu.Lock()
+	store.watchers[watcher] = struct{}{}
+	store.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			watcher.Stop()
+		case <-watcher.done:
+		}
+	}()
+
+	return watcher, nil
+}
+
+// Get implements storage.Interface
+func (store *boltStore[_, _]) Get(ctx context.Context, key string, opts storage.GetOptions, objPtr runtime.Object) error {
+	if err := store.transact(ctx, false, func(bucket *bolt.Bucket) error {
+		return store.decode(bucket, key, objPtr)
+	}); storage.IsNotFound(err) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("could not read from store for %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// GetList implements storage.Interface
+func (store *boltStore[Spec, Status]) GetList(ctx context.Context, key string, opts storage.ListOptions, listObj runtime.Object) error {
+	// Re-cast the list
+	list := listObj.(*zip.ObjectList[Spec, Status])
+
+	if err := store.transact(ctx, false, func(bucket *bolt.Bucket) error {
+		// Truncate the list of results as we are about to re-populate
+		list.Items = make([]zip.Object[Spec, Status], 0)
+
+		if bucket == nil {
+			return nil
+		}
+
+		// Keys are kept sorted, so only those with the prefix are visited.
+		prefix := []byte(key)
+		cursor := bucket.Cursor()
+
+		for k, val := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, val = cursor.Next() {
+			var obj zip.Object[Spec, Status]
+
+			if err := gob.NewDecoder(bytes.NewReader(val)).Decode(&obj); err != nil {
+				return err
+			}
+
+			list.Items = append(list.Items, obj)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("could not list from store at %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// GuaranteedUpdate implements storage.Interface
+//
+// The current object is read, passed to tryUpdate and the result is written
+// back within a single transaction, such that no other process can modify the
+// object in between.  If the object does not exist and ignoreNotFound is set,
+// tryUpdate is passed a zero-valued object.
+func (store *boltStore[_, _]) GuaranteedUpdate(ctx context.Context, key string, destination runtime.Object, ignoreNotFound bool, preconditions *storage.Preconditions, tryUpdate storage.UpdateFunc, cachedExistingObject runtime.Object) error {
+	eventType := watch.Modified
+
+	if err := store.transact(ctx, true, func(bucket *bolt.Bucket) error {
+		existing := newObject(destination)
+
+		if err := store.decode(bucket, key, existing); storage.IsNotFound(err) {
+			if !ignoreNotFound {
+				return err
+			}
+
+			eventType = watch.Added
+		} else if err != nil {
+			return err
+		} else if preconditions != nil {
+			if err := preconditions.Check(key, existing); err != nil {
+				return err
+			}
+		}
+
+		updated, _, err := tryUpdate(existing, storage.ResponseMeta{})
+		if err != nil {
+			return err
+		}
+
+		b := bytes.Buffer{}
+		if err := gob.NewEncoder(&b).Encode(updated); err != nil {
+			return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+		}
+
+		if err := bucket.Put([]byte(key), b.Bytes()); err != nil {
+			return err
+		}
+
+		return decode(b.Bytes(), destination)
+	}); err != nil {
+		return err
+	}
+
+	store.notify(key, eventType, destination)
+
+	return nil
+}
+
+// Count implements storage.Interface
+func (store *boltStore[_, _]) Count(key string) (int64, error) {
+	var count int64
+
+	if err := store.transact(context.Background(), false, func(bucket *bolt.Bucket) error {
+		if bucket == nil {
+			return nil
+		}
+
+		prefix := []byte(key)
+		cursor := bucket.Cursor()
+
+		for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Next() {
+			count++
+		}
+
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("could not count objects in store at %s: %w", key, err)
+	}
+
+	return count, nil
+}
+
+// boltWatcher delivers the changes of objects in a bolt store whose key has
+// the prefix of the watcher.
+type boltWatcher struct {
+	prefix string
+	result chan watch.Event
+	done   chan struct{}
+	stop   func(*boltWatcher)
+	once   sync.Once
+}
+
+// Stop implements watch.Interface
+func (watcher *boltWatcher) Stop() {
+	watcher.once.Do(func() {
+		close(watcher.done)
+		watcher.stop(watcher)
+	})
+}
+
+// ResultChan implements watch.Interface
+func (watcher *boltWatcher) ResultChan() <-chan watch.Event {
+	return watcher.result
+}
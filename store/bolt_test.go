@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	zip "api.zip"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+)
+
+func TestBoltStoreListByPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewBoltStore[counterSpec, counterStatus](filepath.Join(t.TempDir(), "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, key := range []string{"a/1", "b/1", "a/2"} {
+		obj := &counter{Spec: counterSpec{Count: i}}
+		if err := s.Create(ctx, key, obj, obj, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := &zip.ObjectList[counterSpec, counterStatus]{}
+	if err := s.GetList(ctx, "a/", storage.ListOptions{}, list); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list.Items) != 2 || list.Items[0].Spec.Count != 0 || list.Items[1].Spec.Count != 2 {
+		t.Errorf("unexpected list items: %+v", list.Items)
+	}
+
+	count, err := s.Count("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 3 {
+		t.Errorf("expected 3 objects, got %d", count)
+	}
+}
+
+func TestBoltStoreWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := NewBoltStore[counterSpec, counterStatus](filepath.Join(t.TempDir(), "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := s.Watch(ctx, "a/", storage.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := &counter{}
+	for _, key := range []string{"b/1", "a/1", "a/1"} {
+		if err := s.Create(ctx, key, obj, obj, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Delete(ctx, "a/1", &counter{}, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []watch.EventType{watch.Added, watch.Modified, watch.Deleted} {
+		select {
+		case event := <-w.ResultChan():
+			if event.Type != expected {
+				t.Errorf("expected %s event, got %s", expected, event.Type)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %s event, got none", expected)
+		}
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-w.ResultChan():
+		if ok {
+			t.Error("expected no further events")
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the watcher to be stopped")
+	}
+}
+
+func TestBoltStoreDeletePreconditions(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewBoltStore[counterSpec, counterStatus](filepath.Join(t.TempDir(), "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := &counter{ObjectMeta: metav1.ObjectMeta{Name: "counter", UID: "a"}}
+	if err := s.Create(ctx, "counter", stored, stored, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Delete(ctx, "counter", nil, storage.NewUIDPreconditions("b"), nil, nil); err == nil {
+		t.Error("expected delete with mismatching UID to fail")
+	}
+
+	if err := s.Delete(ctx, "counter", nil, nil, func(context.Context, runtime.Object) error {
+		return errors.New("in use")
+	}, nil); err == nil {
+		t.Error("expected delete which fails validation to fail")
+	}
+
+	if err := s.Get(ctx, "counter", storage.GetOptions{}, &counter{}); err != nil {
+		t.Fatalf("expected object to be kept, got %v", err)
+	}
+
+	if err := s.Delete(ctx, "counter", nil, storage.NewUIDPreconditions("a"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Get(ctx, "counter", storage.GetOptions{}, &counter{}); !storage.IsNotFound(err) {
+		t.Errorf("expected not found error once deleted, got %v", err)
+	}
+
+	// Preconditions cannot hold for an object which does not exist, whereas
+	// deleting it unconditionally is a no-op.
+	if err := s.Delete(ctx, "counter", nil, storage.NewUIDPreconditions("a"), nil, nil); !storage.IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if err := s.Delete(ctx, "counter", nil, nil, nil, nil); err != nil {
+		t.Errorf("expected deleting a missing object to succeed, got %v", err)
+	}
+}
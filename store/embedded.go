@@ -8,8 +8,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
@@ -18,6 +21,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/storage"
+
+	"kraftkit.sh/internal/lockedfile"
 	"kraftkit.sh/internal/retrytimeout"
 )
 
@@ -49,12 +54,16 @@ func (version *embeddedVersioner) ParseResourceVersion(resourceVersion string) (
 }
 
 // embedded is KraftKit's default internal storage mechanism which is based on
+// the embeddable key-value database Badger.  Since multiple KraftKit processes
+// may access the same store simultaneously, each operation is performed whilst
+// holding an advisory lock on a file next to the database.
 type embedded[Spec, Status any] struct {
 	path      string
 	versioner *embeddedVersioner
 	db        *badger.DB
 	bopts     badger.Options
 	timeout   time.Duration
+	lock      *lockedfile.Mutex
 }
 
 // NewEmbeddedStore returns a api.zip.Store-compatible storage interface based
@@ -69,11 +78,14 @@ func NewEmbeddedStore[Spec, Status any](path string) (zip.Store, error) {
 		}
 	}
 
+	path = filepath.Clean(path)
+
 	storage := embedded[Spec, Status]{
 		bopts:     badger.DefaultOptions(path),
 		timeout:   5 * time.Second,
 		path:      path,
 		versioner: &embeddedVersioner{},
+		lock:      lockedfile.MutexAt(path + ".lock"),
 	}
 
 	// TODO: Badger uses an internal `Infof` logger method entry which is too low
@@ -89,6 +101,57 @@ func NewEmbeddedStore[Spec, Status any](path string) (zip.Store, error) {
 	return &storage, nil
 }
 
+// acquire the advisory lock of the store, waiting at most for the timeout of
+// the store or until the context is cancelled.
+func (store *embedded[_, _]) acquire(ctx context.Context) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(store.path), 0o755); err != nil {
+		return nil, fmt.Errorf("could not prepare machine store: %w", err)
+	}
+
+	type result struct {
+		unlock func()
+		err    error
+	}
+
+	// The channel is unbuffered such that the lock is only handed over whilst
+	// the caller is still waiting for it, and otherwise released.
+	locked := make(chan result)
+	abandoned := make(chan struct{})
+
+	go func() {
+		unlock, err := store.lock.Lock()
+
+		select {
+		case locked <- result{unlock, err}:
+		case <-abandoned:
+			// The caller has given up waiting, release the lock immediately.
+			if err == nil {
+				unlock()
+			}
+		}
+	}()
+
+	timer := time.NewTimer(store.timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-locked:
+		if res.err != nil {
+			return nil, fmt.Errorf("could not lock machine store: %w", res.err)
+		}
+
+		return res.unlock, nil
+
+	case <-timer.C:
+		close(abandoned)
+		return nil, fmt.Errorf("could not lock machine store within %s: %w", store.timeout, ErrBusy)
+
+	case <-ctx.Done():
+		close(abandoned)
+		return nil, ctx.Err()
+	}
+}
+
 // open the embedded key-value store
 func (store *embedded[_, _]) open() error {
 	var db *badger.DB
@@ -98,7 +161,9 @@ func (store *embedded[_, _]) open() error {
 		return fmt.Errorf("could not open machine store: %v", err)
 	} else if err != nil {
 		// Perform a continuous re-try to check for the dir lock on the badger
-		// database which may become free during a specified timeout period
+		// database which may become free during a specified timeout period.  This
+		// remains necessary for processes of older versions of KraftKit which do
+		// not hold the advisory lock of the store.
 		if err := retrytimeout.RetryTimeout(store.timeout, func() error {
 			var err error
 			db, err = badger.Open(store.bopts)
@@ -108,7 +173,7 @@ func (store *embedded[_, _]) open() error {
 
 			return nil
 		}); err != nil {
-			return fmt.Errorf("could not open machine store: %v", err)
+			return fmt.Errorf("could not open machine store: %v: %w", err, ErrBusy)
 		}
 	}
 
@@ -122,6 +187,32 @@ func (store *embedded[_, _]) close() error {
 	return store.db.Close()
 }
 
+// transact locks and opens the store and runs the provided function within a
+// single transaction, which is committed if it is writable.  The transaction
+// is re-run when it conflicts with another one.
+func (store *embedded[_, _]) transact(ctx context.Context, update bool, fn func(*badger.Txn) error) error {
+	unlock, err := store.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	if err := store.open(); err != nil {
+		return err
+	}
+
+	defer store.close()
+
+	return Retry(ctx, func() error {
+		if update {
+			return store.db.Update(fn)
+		}
+
+		return store.db.View(fn)
+	})
+}
+
 // Versioner implements storage.Interface
 func (store *embedded[_, _]) Versioner() storage.Versioner {
 	return store.versioner
@@ -134,42 +225,26 @@ func (store *embedded[_, _]) RequestWatchProgress(ctx context.Context) error {
 
 // Create implements storage.Interface
 func (store *embedded[_, _]) Create(ctx context.Context, key string, _, out runtime.Object, ttl uint64) error {
-	if err := store.open(); err != nil {
-		return err
-	}
-
-	defer store.close()
-
 	b := bytes.Buffer{}
 	if err := gob.NewEncoder(&b).Encode(out); err != nil {
 		return fmt.Errorf("could not encode driver config for %s: %v", key, err)
 	}
 
-	txn := store.db.NewTransaction(true)
-	if err := txn.SetEntry(badger.NewEntry([]byte(key), b.Bytes())); err != nil {
-		return fmt.Errorf("could not save machine driver to store for %s: %v", key, err)
-	}
+	return store.transact(ctx, true, func(txn *badger.Txn) error {
+		if err := txn.SetEntry(badger.NewEntry([]byte(key), b.Bytes())); err != nil {
+			return fmt.Errorf("could not save machine driver to store for %s: %w", key, err)
+		}
 
-	return txn.Commit()
+		return nil
+	})
 }
 
 // Delete implements storage.Interface
 func (store *embedded[_, _]) Delete(ctx context.Context, key string, out runtime.Object, preconditions *storage.Preconditions, validateDeletion storage.ValidateObjectFunc, cachedExistingObject runtime.Object) error {
-	if err := store.open(); err != nil {
-		return err
-	}
-
-	defer store.close()
-
-	txn := store.db.NewTransaction(true)
-
-	if err := txn.Delete([]byte(key)); err != nil {
-		return err
-	}
-
 	// TODO(nderjung): preconditions, validateDelete, cachedExistingObject
-
-	return txn.Commit()
+	return store.transact(ctx, true, func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	})
 }
 
 // Watch implements storage.Interface
@@ -179,49 +254,58 @@ func (store *embedded[_, _]) Watch(ctx context.Context, key string, opts storage
 
 // Get implements storage.Interface
 func (store *embedded[_, _]) Get(ctx context.Context, key string, opts storage.GetOptions, objPtr runtime.Object) error {
-	if err := store.open(); err != nil {
+	if err := store.transact(ctx, false, func(txn *badger.Txn) error {
+		return get(txn, key, objPtr)
+	}); storage.IsNotFound(err) {
 		return err
+	} else if err != nil {
+		return fmt.Errorf("could not read from store for %s: %w", key, err)
 	}
 
-	defer store.close()
+	return nil
+}
 
-	if err := store.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
-		if err != nil {
-			return fmt.Errorf("could not access store for %s: %v", key, err)
-		}
+// get decodes the value of the key within the transaction into the object.
+func get(txn *badger.Txn, key string, objPtr runtime.Object) error {
+	item, err := txn.Get([]byte(key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return storage.NewKeyNotFoundError(key, 0)
+	} else if err != nil {
+		return fmt.Errorf("could not access store for %s: %w", key, err)
+	}
 
-		val, err := item.ValueCopy(nil)
-		if err != nil {
-			return fmt.Errorf("could not copy from store for %s: %v", key, err)
-		}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return fmt.Errorf("could not copy from store for %s: %w", key, err)
+	}
 
-		b := bytes.Buffer{}
-		b.Write(val)
+	return decode(val, objPtr)
+}
 
-		return gob.NewDecoder(&b).Decode(objPtr)
-	}); err != nil {
-		return fmt.Errorf("could not read from store for %s: %v", key, err)
+// decode the gob-encoded object into objPtr, which is reset beforehand since
+// gob leaves the fields untouched whose encoded value is their zero value.
+func decode(b []byte, objPtr runtime.Object) error {
+	if v := reflect.ValueOf(objPtr); v.Kind() == reflect.Pointer && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
 	}
 
-	return nil
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(objPtr)
+}
+
+// newObject returns a new zero-valued object of the same type as obj.
+func newObject(obj runtime.Object) runtime.Object {
+	return reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
 }
 
 // GetList implements storage.Interface
 func (store *embedded[Spec, Status]) GetList(ctx context.Context, key string, opts storage.ListOptions, listObj runtime.Object) error {
-	if err := store.open(); err != nil {
-		return err
-	}
-
-	defer store.close()
-
 	// Re-cast the list
 	list := listObj.(*zip.ObjectList[Spec, Status])
 
-	// Truncate the list of results as we are about to re-populate
-	list.Items = make([]zip.Object[Spec, Status], 0)
+	if err := store.transact(ctx, false, func(txn *badger.Txn) error {
+		// Truncate the list of results as we are about to re-populate
+		list.Items = make([]zip.Object[Spec, Status], 0)
 
-	if err := store.db.View(func(txn *badger.Txn) error {
 		itr := txn.NewIterator(badger.IteratorOptions{
 			Prefix:       []byte(key),
 			PrefetchSize: 10, // TODO(nderjung): Arbitrarily picked
@@ -249,15 +333,51 @@ func (store *embedded[Spec, Status]) GetList(ctx context.Context, key string, op
 
 		return nil
 	}); err != nil {
-		return fmt.Errorf("could not list from store at %s: %v", key, err)
+		return fmt.Errorf("could not list from store at %s: %w", key, err)
 	}
 
 	return nil
 }
 
 // GuaranteedUpdate implements storage.Interface
+//
+// The current object is read, passed to tryUpdate and the result is written
+// back within a single transaction whilst the store is locked, such that no
+// other process can modify the object in between.  If the object does not
+// exist and ignoreNotFound is set, tryUpdate is passed a zero-valued object.
 func (store *embedded[_, _]) GuaranteedUpdate(ctx context.Context, key string, destination runtime.Object, ignoreNotFound bool, preconditions *storage.Preconditions, tryUpdate storage.UpdateFunc, cachedExistingObject runtime.Object) error {
-	panic("not implemented: kraftkit.sh/machine/store.embedded.GuaranteedUpdate")
+	return store.transact(ctx, true, func(txn *badger.Txn) error {
+		// The transaction may be re-run, start from the stored object each time.
+		existing := newObject(destination)
+
+		if err := get(txn, key, existing); storage.IsNotFound(err) {
+			if !ignoreNotFound {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else if preconditions != nil {
+			if err := preconditions.Check(key, existing); err != nil {
+				return err
+			}
+		}
+
+		updated, _, err := tryUpdate(existing, storage.ResponseMeta{})
+		if err != nil {
+			return err
+		}
+
+		b := bytes.Buffer{}
+		if err := gob.NewEncoder(&b).Encode(updated); err != nil {
+			return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+		}
+
+		if err := txn.SetEntry(badger.NewEntry([]byte(key), b.Bytes())); err != nil {
+			return fmt.Errorf("could not save machine driver to store for %s: %w", key, err)
+		}
+
+		return decode(b.Bytes(), destination)
+	})
 }
 
 // Count implements storage.Interface
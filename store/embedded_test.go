@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	zip "api.zip"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/storage"
+
+	"kraftkit.sh/internal/lockedfile"
+)
+
+type counterSpec struct {
+	Count int
+}
+
+type counterStatus struct{}
+
+type counter = zip.Object[counterSpec, counterStatus]
+
+func TestGuaranteedUpdateConcurrent(t *testing.T) {
+	for _, backend := range Backends() {
+		t.Run(backend.String(), func(t *testing.T) {
+			testGuaranteedUpdateConcurrent(t, backend)
+		})
+	}
+}
+
+func testGuaranteedUpdateConcurrent(t *testing.T, backend Backend) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store")
+
+	const workers = 8
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Each worker uses its own store, as separate processes would.
+			s, err := New[counterSpec, counterStatus](backend, path)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			errs <- s.GuaranteedUpdate(ctx, "counter", &counter{}, true, nil, func(input runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+				obj := input.(*counter)
+				obj.Spec.Count++
+				return obj, nil, nil
+			}, nil)
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := New[counterSpec, counterStatus](backend, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got counter
+	if err := s.Get(ctx, "counter", storage.GetOptions{}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Spec.Count != workers {
+		t.Errorf("expected count %d, got %d", workers, got.Spec.Count)
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	for _, backend := range Backends() {
+		s, err := New[counterSpec, counterStatus](backend, filepath.Join(t.TempDir(), "store"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Get(context.Background(), "missing", storage.GetOptions{}, &counter{}); !storage.IsNotFound(err) {
+			t.Errorf("%s: expected not found error, got %v", backend, err)
+		}
+	}
+}
+
+func TestEmbeddedLockTimeout(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store")
+
+	s, err := NewEmbeddedStore[counterSpec, counterStatus](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.(*embedded[counterSpec, counterStatus]).timeout = 100 * time.Millisecond
+
+	// Hold the lock of the store as another process would.
+	unlock, err := lockedfile.MutexAt(path + ".lock").Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Get(ctx, "counter", storage.GetOptions{}, &counter{}); !errors.Is(err, ErrBusy) {
+		t.Errorf("expected busy error whilst locked, got %v", err)
+	}
+
+	unlock()
+
+	if err := s.Get(ctx, "counter", storage.GetOptions{}, &counter{}); !storage.IsNotFound(err) {
+		t.Errorf("expected not found error once unlocked, got %v", err)
+	}
+}
+
+func TestGuaranteedUpdateZeroesObject(t *testing.T) {
+	for _, backend := range Backends() {
+		t.Run(backend.String(), func(t *testing.T) {
+			ctx := context.Background()
+
+			s, err := New[counterSpec, counterStatus](backend, filepath.Join(t.TempDir(), "store"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored := &counter{ObjectMeta: metav1.ObjectMeta{Name: "counter"}}
+			if err := s.Create(ctx, "counter", stored, stored, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			// The destination holds a stale count which must neither be passed to
+			// the update nor survive it, even though gob does not encode zero
+			// values.
+			destination := &counter{Spec: counterSpec{Count: 5}}
+
+			if err := s.GuaranteedUpdate(ctx, "counter", destination, false, nil, func(input runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+				if count := input.(*counter).Spec.Count; count != 0 {
+					t.Errorf("expected stored count 0, got %d", count)
+				}
+				return input, nil, nil
+			}, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if destination.Spec.Count != 0 || destination.Name != "counter" {
+				t.Errorf("expected destination to be the stored object, got %+v", destination)
+			}
+		})
+	}
+}
+
+func TestGuaranteedUpdatePreconditions(t *testing.T) {
+	for _, backend := range Backends() {
+		t.Run(backend.String(), func(t *testing.T) {
+			ctx := context.Background()
+
+			s, err := New[counterSpec, counterStatus](backend, filepath.Join(t.TempDir(), "store"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored := &counter{ObjectMeta: metav1.ObjectMeta{Name: "counter", UID: "a"}}
+			if err := s.Create(ctx, "counter", stored, stored, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			increment := func(input runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+				obj := input.(*counter)
+				obj.Spec.Count++
+				return obj, nil, nil
+			}
+
+			other := types.UID("b")
+			if err := s.GuaranteedUpdate(ctx, "counter", &counter{}, false, storage.NewUIDPreconditions(string(other)), increment, nil); err == nil {
+				t.Error("expected update with mismatching UID to fail")
+			}
+
+			if err := s.GuaranteedUpdate(ctx, "counter", &counter{}, false, storage.NewUIDPreconditions("a"), increment, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			var got counter
+			if err := s.Get(ctx, "counter", storage.GetOptions{}, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Spec.Count != 1 {
+				t.Errorf("expected count 1, got %d", got.Spec.Count)
+			}
+		})
+	}
+}
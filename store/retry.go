@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// ErrBusy is returned when the store is held by another process for longer
+// than the timeout of the store.
+var ErrBusy = errors.New("store is busy")
+
+const (
+	retryAttempts   = 5
+	retryMinBackoff = 50 * time.Millisecond
+	retryMaxBackoff = time.Second
+)
+
+// IsRetryable returns whether the error is the result of a concurrent access
+// to the store and the operation may succeed if it is attempted again.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrBusy) || errors.Is(err, badger.ErrConflict)
+}
+
+// Retry calls the provided function until it succeeds, returns an error which
+// is not retryable or a number of attempts have been exhausted.  The delay
+// between attempts grows exponentially.
+func Retry(ctx context.Context, fn func() error) error {
+	backoff := retryMinBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) || attempt == retryAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+}
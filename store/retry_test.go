@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+func TestRetry(t *testing.T) {
+	errOther := errors.New("other")
+
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{
+			name:     "success",
+			errs:     []error{nil},
+			attempts: 1,
+		},
+		{
+			name:     "busy then success",
+			errs:     []error{fmt.Errorf("locked: %w", ErrBusy), nil},
+			attempts: 2,
+		},
+		{
+			name:     "conflict then success",
+			errs:     []error{badger.ErrConflict, badger.ErrConflict, nil},
+			attempts: 3,
+		},
+		{
+			name:     "not retryable",
+			errs:     []error{errOther, nil},
+			attempts: 1,
+			err:      errOther,
+		},
+		{
+			name:     "exhausted",
+			errs:     []error{ErrBusy, ErrBusy, ErrBusy, ErrBusy, ErrBusy, nil},
+			attempts: retryAttempts,
+			err:      ErrBusy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+
+			err := Retry(context.Background(), func() error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+
+			if attempts != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+
+	err := Retry(ctx, func() error {
+		attempts++
+		cancel()
+		return ErrBusy
+	})
+
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrBusy) {
+		t.Errorf("expected both busy and cancellation error, got %v", err)
+	}
+
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
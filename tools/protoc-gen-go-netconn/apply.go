@@ -32,9 +32,15 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/golang/glog"
@@ -54,6 +60,111 @@ type Options struct {
 	EmitEnumPrefix       bool
 	RemapEnumViaJsonName bool
 	MapEnumToMessage     bool
+	StreamEventKey       string
+	RequestIDKey         string
+	Codec                string
+	EmitMocks            bool
+
+	// ErrorType and ErrorKey are set when the package declares a message with
+	// the "error" option, which responses carry under the ErrorKey top-level key
+	// in case of failure.
+	ErrorType string
+	ErrorKey  string
+
+	// Imports collects the Go packages of the types which are referenced by the
+	// rendered file but declared in another Go package.
+	Imports *goImports
+}
+
+// goImport is a Go package which is imported by the rendered file.
+type goImport struct {
+	Name string
+	Path string
+}
+
+// goImports resolves references to types which are declared in other Go
+// packages, e.g. in a proto file imported from elsewhere, and tracks the
+// packages which need to be imported as a result.
+type goImports struct {
+	self  protogen.GoImportPath
+	names map[protogen.GoImportPath]string
+	used  map[string]bool
+}
+
+func newGoImports(f *protogen.File) *goImports {
+	return &goImports{
+		self:  f.GoImportPath,
+		names: make(map[protogen.GoImportPath]string),
+		used: map[string]bool{
+			// Packages which may be imported by the header.
+			"binary":  true,
+			"bufio":   true,
+			"bytes":   true,
+			"context": true,
+			"fmt":     true,
+			"io":      true,
+			"json":    true,
+			"msgpack": true,
+			"reflect": true,
+			"sync":    true,
+			"atomic":  true,
+			"time":    true,
+		},
+	}
+}
+
+// Qualify returns the name of the Go type declared in the file of the
+// descriptor, prefixed with its package's name if the type is declared in
+// another Go package.
+func (imp *goImports) Qualify(name string, ident protogen.GoIdent, desc protoreflect.Descriptor) string {
+	if imp == nil || ident.GoImportPath == imp.self {
+		return name
+	}
+
+	if pkg, ok := imp.names[ident.GoImportPath]; ok {
+		return pkg + "." + name
+	}
+
+	pkg := path.Base(string(ident.GoImportPath))
+	if options, ok := desc.ParentFile().Options().(*descriptorpb.FileOptions); ok {
+		if _, goPkg, ok := strings.Cut(options.GetGoPackage(), ";"); ok {
+			pkg = goPkg
+		}
+	}
+
+	pkg = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, pkg)
+
+	for i, orig := 1, pkg; imp.used[pkg]; i++ {
+		pkg = orig + strconv.Itoa(i)
+	}
+
+	imp.names[ident.GoImportPath] = pkg
+	imp.used[pkg] = true
+
+	return pkg + "." + name
+}
+
+// List returns the imported packages sorted by their path.
+func (imp *goImports) List() []goImport {
+	if imp == nil {
+		return nil
+	}
+
+	list := make([]goImport, 0, len(imp.names))
+	for path, name := range imp.names {
+		list = append(list, goImport{Name: name, Path: string(path)})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+
+	return list
 }
 
 type header struct {
@@ -61,6 +172,7 @@ type header struct {
 	Options
 	HasService bool
 	HasEnumMap bool
+	Imports    []goImport
 }
 
 type service struct {
@@ -94,6 +206,7 @@ type message struct {
 	Message     *protogen.Message
 	Options     Options
 	ExtraFields []messageExtraField
+	ErrorKey    string
 }
 
 func (e message) ToCamel(name string) string {
@@ -139,17 +252,39 @@ func (m message) KindToGoType(kind protoreflect.Kind) (typ string) {
 	return
 }
 
-func (m message) FieldToGoType(field protogen.Field) (typ string) {
+// elemToGoType returns the Go type of a single element of the field, i.e.
+// ignoring whether it is a list, map or tracks presence.
+func (m message) elemToGoType(field *protogen.Field) (typ string) {
 	kind := field.Desc.Kind()
 	switch kind {
 	case protoreflect.EnumKind:
-		typ = strcase.ToCamel(field.Enum.GoIdent.GoName)
+		typ = m.Options.Imports.Qualify(strcase.ToCamel(field.Enum.GoIdent.GoName), field.Enum.GoIdent, field.Enum.Desc)
 	case protoreflect.MessageKind:
-		typ = strcase.ToCamel(field.Message.GoIdent.GoName)
+		if field.Message.Desc.FullName() == "google.protobuf.Any" {
+			typ = "any"
+		} else {
+			typ = m.Options.Imports.Qualify(strcase.ToCamel(field.Message.GoIdent.GoName), field.Message.GoIdent, field.Message.Desc)
+		}
 	default:
 		typ = m.KindToGoType(kind)
 	}
 
+	return
+}
+
+func (m message) FieldToGoType(field protogen.Field) (typ string) {
+	if field.Desc.IsMap() {
+		key := m.elemToGoType(field.Message.Fields[0])
+		val := m.elemToGoType(field.Message.Fields[1])
+		if key == "" || val == "" {
+			glog.V(2).Infof("skipping %s, unsupported map type", field.Desc.FullName())
+			return ""
+		}
+
+		return "map[" + key + "]" + val
+	}
+
+	typ = m.elemToGoType(&field)
 	if typ == "" {
 		glog.V(2).Infof("skipping %s, unsupported field type %s", field.Desc.FullName(), field.Desc.Kind().String())
 		return
@@ -157,219 +292,1390 @@ func (m message) FieldToGoType(field protogen.Field) (typ string) {
 
 	if field.Desc.IsList() {
 		typ = "[]" + typ
+	} else if m.HasPresence(field) && typ != "any" {
+		typ = "*" + typ
+	}
+
+	return
+}
+
+// HasPresence returns whether the field explicitly tracks its presence, i.e.
+// it is a proto3 optional field or a member of a oneof, and is therefore
+// rendered as a pointer which is omitted when unset.
+func (m message) HasPresence(field protogen.Field) bool {
+	return field.Desc.HasOptionalKeyword() || field.Oneof != nil
+}
+
+// JSONTag returns the value of the json struct tag for the field.
+func (m message) JSONTag(field protogen.Field) string {
+	tag := field.Desc.JSONName()
+	if m.HasPresence(field) && !strings.Contains(tag, ",omitempty") {
+		tag += ",omitempty"
+	}
+
+	return tag
+}
+
+// ErrorFields returns the string fields of the message which make up its error
+// text.
+func (m message) ErrorFields() []*protogen.Field {
+	var fields []*protogen.Field
+	for _, field := range m.Message.Fields {
+		if field.Desc.Kind() == protoreflect.StringKind && !field.Desc.IsList() && !field.Desc.IsMap() && !m.HasPresence(*field) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// Oneofs returns all oneofs of the message which were declared in the proto,
+// excluding the synthetic ones which are created for proto3 optional fields.
+func (m message) Oneofs() []*protogen.Oneof {
+	var oneofs []*protogen.Oneof
+	for _, oneof := range m.Message.Oneofs {
+		if oneof.Desc.IsSynthetic() {
+			continue
+		}
+
+		oneofs = append(oneofs, oneof)
+	}
+
+	return oneofs
+}
+
+type method struct {
+	*protogen.Method
+	Options
+	ServiceGoName string
+}
+
+// InputType returns the name of the method's request type.
+func (m method) InputType() string {
+	return m.Imports.Qualify(m.Input.GoIdent.GoName, m.Input.GoIdent, m.Input.Desc)
+}
+
+// OutputType returns the name of the method's response type.
+func (m method) OutputType() string {
+	return m.Imports.Qualify(m.Output.GoIdent.GoName, m.Output.GoIdent, m.Output.Desc)
+}
+
+// mockMethod describes the signature of a method implemented by the mock.
+type mockMethod struct {
+	GoName   string
+	Request  string
+	Response string
+	Stream   bool
+}
+
+type mock struct {
+	*protogen.Service
+	Options
+	Methods []mockMethod
+}
+
+var (
+	headerTemplate = template.Must(template.New("header").Parse(HeaderTemplate))
+	HeaderTemplate = `
+// Code generated by kraftkit.sh/tools/protoc-gen-go-netconn. DO NOT EDIT.
+// source: {{ .Proto.Name }}
+
+package {{.GoPackageName}}
+{{ if (or .HasService .HasEnumMap .Imports) }}
+import (
+{{ if .HasService }}
+	"bufio"
+{{- if eq .Codec "msgpack" }}
+	"bytes"
+{{- end }}
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+{{- end }}
+{{- if (or .HasService .HasEnumMap) }}
+	"reflect"
+{{- end }}
+{{ if .HasService -}}
+	"sync"
+{{- if .RequestIDKey }}
+	"sync/atomic"
+{{- end }}
+	"time"
+{{- if eq .Codec "msgpack" }}
+
+	"github.com/vmihailenco/msgpack/v5"
+{{- end }}
+{{ end }}
+{{- range $i, $imp := .Imports }}
+{{- if eq $i 0 }}
+{{ end }}
+	{{ $imp.Name }} "{{ $imp.Path }}"
+{{- end }}
+)
+{{ end }}
+`
+
+	serviceTemplate = template.Must(template.New("service").Parse(ServiceTemplate))
+	ServiceTemplate = `
+type {{ .GoName }}Client struct {
+	conn    io.ReadWriteCloser
+{{- if not .RequestIDKey }}
+	lock    sync.RWMutex
+{{- end }}
+	wlock   sync.Mutex
+	recv    *bufio.Reader
+	send    *bufio.Writer
+	codec   {{ .GoName }}Codec
+	timeout time.Duration
+{{- if .RequestIDKey }}
+	nextID  atomic.Uint64
+{{- end }}
+
+	// dial, retry and onConnect are only set for clients which were created
+	// from a dialer and therefore reconnect.
+	dial      func() (io.ReadWriteCloser, error)
+	retry     {{ .GoName }}ClientRetryPolicy
+	onConnect func(*{{ .GoName }}Client) error
+	closing   chan struct{}
+
+	// mu guards the connection and the background reader's state below.
+	mu        sync.Mutex
+	closed    bool
+	readErr   error
+	responses chan []byte
+{{- if .RequestIDKey }}
+	pending   map[uint64]chan []byte
+{{- end }}
+	subs      map[chan []byte]<-chan struct{}
+}
+
+// {{ .GoName }}ClientRetryPolicy determines how a client which was created from
+// a dialer re-establishes its connection.
+type {{ .GoName }}ClientRetryPolicy struct {
+	// MaxAttempts is the maximum number of consecutive dial attempts or
+	// unlimited if zero.
+	MaxAttempts int
+
+	// Backoff is the initial delay between two dial attempts which doubles
+	// after every failed attempt.
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between two dial attempts if non-zero.
+	MaxBackoff time.Duration
+}
+
+// Default{{ .GoName }}ClientRetryPolicy is the retry policy used by clients
+// created from a dialer unless overridden.
+var Default{{ .GoName }}ClientRetryPolicy = {{ .GoName }}ClientRetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     100 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+}
+
+// {{ .GoName }}ClientOption is an option which configures a
+// {{ .GoName }}Client.
+type {{ .GoName }}ClientOption func(*{{ .GoName }}Client)
+
+// With{{ .GoName }}ClientTimeout sets the default timeout applied to every
+// call whose context does not already carry a deadline.
+func With{{ .GoName }}ClientTimeout(timeout time.Duration) {{ .GoName }}ClientOption {
+	return func(c *{{ .GoName }}Client) {
+		c.timeout = timeout
+	}
+}
+
+// With{{ .GoName }}ClientCodec overrides the codec used to serialize and
+// frame messages.
+func With{{ .GoName }}ClientCodec(codec {{ .GoName }}Codec) {{ .GoName }}ClientOption {
+	return func(c *{{ .GoName }}Client) {
+		c.codec = codec
+	}
+}
+
+// With{{ .GoName }}ClientRetryPolicy sets the policy used to re-establish the
+// connection of a client which was created from a dialer.
+func With{{ .GoName }}ClientRetryPolicy(policy {{ .GoName }}ClientRetryPolicy) {{ .GoName }}ClientOption {
+	return func(c *{{ .GoName }}Client) {
+		c.retry = policy
+	}
+}
+
+// With{{ .GoName }}ClientOnConnect sets a hook which is invoked every time a
+// client which was created from a dialer has (re-)established its connection,
+// e.g. to perform a handshake.  The connection is dropped if the hook fails.
+func With{{ .GoName }}ClientOnConnect(hook func(*{{ .GoName }}Client) error) {{ .GoName }}ClientOption {
+	return func(c *{{ .GoName }}Client) {
+		c.onConnect = hook
+	}
+}
+
+func new{{ .GoName }}Client(opts ...{{ .GoName }}ClientOption) *{{ .GoName }}Client {
+	c := &{{ .GoName }}Client{
+{{- if eq .Codec "msgpack" }}
+		codec:   {{ .GoName }}MsgpackCodec{},
+{{- else if eq .Codec "json-length-prefixed" }}
+		codec:   {{ .GoName }}LengthPrefixedJSONCodec{},
+{{- else }}
+		codec:   {{ .GoName }}JSONLinesCodec{},
+{{- end }}
+		retry:   Default{{ .GoName }}ClientRetryPolicy,
+		closing: make(chan struct{}),
+{{- if .RequestIDKey }}
+		pending: make(map[uint64]chan []byte),
+{{- end }}
+		subs:    make(map[chan []byte]<-chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func New{{ .GoName }}Client(conn io.ReadWriteCloser, opts ...{{ .GoName }}ClientOption) *{{ .GoName }}Client {
+	c := new{{ .GoName }}Client(opts...)
+	c.setConn(conn)
+
+	go c.readLoop()
+
+	return c
+}
+
+// New{{ .GoName }}ClientFromDialer creates a client whose connection is
+// established with the dialer and re-established according to the client's
+// retry policy whenever it is lost, such that subscriptions survive restarts
+// of the peer.
+func New{{ .GoName }}ClientFromDialer(dial func() (io.ReadWriteCloser, error), opts ...{{ .GoName }}ClientOption) (*{{ .GoName }}Client, error) {
+	c := new{{ .GoName }}Client(opts...)
+	c.dial = dial
+
+	conn, err := c.redial()
+	if err != nil {
+		return nil, err
+	}
+
+	c.setConn(conn)
+
+	go c.readLoop()
+
+	if c.onConnect != nil {
+		if err := c.onConnect(c); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (c *{{ .GoName }}Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+
+	c.closed = true
+	close(c.closing)
+	conn := c.conn
+	c.mu.Unlock()
+
+	return conn.Close()
+}
+
+// setConn installs a newly established connection.
+func (c *{{ .GoName }}Client) setConn(conn io.ReadWriteCloser) {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conn = conn
+	c.recv = bufio.NewReader(conn)
+	c.send = bufio.NewWriter(conn)
+	c.responses = make(chan []byte, 1)
+	c.readErr = nil
+}
+
+// redial invokes the dialer until it succeeds, the retry policy is exhausted
+// or the client is closed.
+func (c *{{ .GoName }}Client) redial() (io.ReadWriteCloser, error) {
+	backoff := c.retry.Backoff
+
+	for attempt := 1; ; attempt++ {
+		conn, err := c.dial()
+		if err == nil {
+			c.mu.Lock()
+			closed := c.closed
+			c.mu.Unlock()
+
+			if closed {
+				conn.Close()
+				return nil, io.ErrClosedPipe
+			}
+
+			return conn, nil
+		}
+
+		if c.retry.MaxAttempts > 0 && attempt >= c.retry.MaxAttempts {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-c.closing:
+			return nil, err
+		}
+
+		backoff *= 2
+		if c.retry.MaxBackoff > 0 && backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
+	}
+}
+
+// reconnect re-establishes a lost connection if the client was created from a
+// dialer and reports whether it succeeded.
+func (c *{{ .GoName }}Client) reconnect() bool {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+
+	if c.dial == nil || closed {
+		return false
+	}
+
+	conn, err := c.redial()
+	if err != nil {
+		return false
+	}
+
+	c.setConn(conn)
+
+	if c.onConnect != nil {
+		// The hook must run asynchronously as its calls are only answered once
+		// the background reader resumes.
+		go func() {
+			if err := c.onConnect(c); err != nil {
+				conn.Close()
+			}
+		}()
+	}
+
+	return true
+}
+
+// withTimeout applies the client's default timeout to the context if it does
+// not already carry a deadline.
+func (c *{{ .GoName }}Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+
+	return ctx, func() {}
+}
+
+// ctxErr returns the context's error in favour of the provided I/O error if
+// the context is done, such that callers can detect cancellations and
+// timeouts.
+func (c *{{ .GoName }}Client) ctxErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	// The deadline of the connection may expire just before the context's.
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
+
+// write sends a single message to the peer.  The deadline of the provided context
+// is applied to the underlying connection if it supports write deadlines and
+// any pending write is interrupted as soon as the context is done.
+func (c *{{ .GoName }}Client) write(ctx context.Context, b []byte) error {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	if wd, ok := c.conn.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		deadline, _ := ctx.Deadline()
+		_ = wd.SetWriteDeadline(deadline)
+
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+
+		go func() {
+			defer close(stopped)
+
+			select {
+			case <-ctx.Done():
+				// Unblock any pending write immediately.
+				_ = wd.SetWriteDeadline(time.Unix(1, 0))
+			case <-done:
+			}
+		}()
+
+		defer func() {
+			close(done)
+			<-stopped
+			_ = wd.SetWriteDeadline(time.Time{})
+		}()
+	}
+
+	err := c.codec.WriteMessage(c.send, b)
+	if err == nil {
+		err = c.send.Flush()
+	}
+	if err != nil {
+		// The writer retains its error, which would fail all further writes.
+		c.send.Reset(c.conn)
+		return c.ctxErr(ctx, err)
+	}
+
+	return nil
+}
+
+// marshal applies the default values of the request and serializes it.
+func (c *{{ .GoName }}Client) marshal(req any) ([]byte, error) {
+	if err := c.setRpcRequestSetDefaults(req); err != nil {
+		return nil, err
+	}
+
+	return c.codec.Marshal(req)
+}
+
+// receive waits for the next synchronous response which is not correlated to
+// a specific request.
+func (c *{{ .GoName }}Client) receive(ctx context.Context) ([]byte, error) {
+	c.mu.Lock()
+	responses := c.responses
+	c.mu.Unlock()
+
+	select {
+	case b, ok := <-responses:
+		if !ok {
+			return nil, c.lastReadErr()
+		}
+		return b, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// notify sends the request without waiting for a response.
+func (c *{{ .GoName }}Client) notify(ctx context.Context, req any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b, err := c.marshal(req)
+	if err != nil {
+		return err
+	}
+
+{{- if .RequestIDKey }}
+
+	b, err = c.withRequestID(b, c.nextID.Add(1))
+	if err != nil {
+		return err
+	}
+{{- end }}
+
+	return c.write(ctx, b)
+}
+
+// call sends the request and waits for its response.  If the request is nil,
+// nothing is sent and the next unsolicited response is returned instead.
+func (c *{{ .GoName }}Client) call(ctx context.Context, req any) ([]byte, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+{{ if .RequestIDKey }}
+	if req == nil {
+		return c.receive(ctx)
+	}
+
+	b, err := c.marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	id := c.nextID.Add(1)
+
+	b, err = c.withRequestID(b, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(chan []byte, 1)
+
+	c.mu.Lock()
+	if c.readErr != nil {
+		c.mu.Unlock()
+		return nil, c.readErr
+	}
+	c.pending[id] = res
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(ctx, b); err != nil {
+		return nil, err
+	}
+
+	select {
+	case b, ok := <-res:
+		if !ok {
+			return nil, c.lastReadErr()
+		}
+		return b, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// withRequestID sets the "{{ .RequestIDKey }}" key of the serialized request
+// such that its response can be correlated.
+func (c *{{ .GoName }}Client) withRequestID(b []byte, id uint64) ([]byte, error) {
+	return c.codec.Set(b, "{{ .RequestIDKey }}", id)
+}
+
+// responseID returns the value of the "{{ .RequestIDKey }}" key of a response.
+func (c *{{ .GoName }}Client) responseID(b []byte) (uint64, bool) {
+	var id uint64
+	if ok, err := c.codec.Lookup(b, "{{ .RequestIDKey }}", &id); !ok || err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
+{{ else }}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if req == nil {
+		return c.receive(ctx)
+	}
+
+	b, err := c.marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.discardResponses()
+
+	if err := c.write(ctx, b); err != nil {
+		return nil, err
+	}
+
+	return c.receive(ctx)
+}
+
+// discardResponses drops any synchronous response which was received by the
+// background reader after its caller stopped waiting for it.
+func (c *{{ .GoName }}Client) discardResponses() {
+	for {
+		select {
+		case <-c.responses:
+		default:
+			return
+		}
+	}
+}
+{{ end }}
+{{- if .ErrorType }}
+// responseError returns the {{ .ErrorType }} carried by the "{{ .ErrorKey }}" key of
+// a response, if any.
+func (c *{{ .GoName }}Client) responseError(b []byte) error {
+	var res {{ .ErrorType }}
+	ok, err := c.codec.Lookup(b, "{{ .ErrorKey }}", &res)
+	if err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+
+	return &res
+}
+
+{{ end -}}
+// isAsync determines whether the message is asynchronous, i.e. one which was
+// not sent in response to a call, by the presence of the "{{ .StreamEventKey }}" key.
+func (c *{{ .GoName }}Client) isAsync(b []byte) bool {
+	var event any
+	ok, err := c.codec.Lookup(b, "{{ .StreamEventKey }}", &event)
+	return ok && err == nil
+}
+
+// subscribe registers a subscriber which receives every asynchronous line
+// until the provided context is done.
+func (c *{{ .GoName }}Client) subscribe(ctx context.Context) chan []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sub := make(chan []byte, 16)
+	if c.readErr != nil {
+		close(sub)
+		return sub
+	}
+
+	c.subs[sub] = ctx.Done()
+
+	return sub
+}
+
+// unsubscribe removes a previously registered subscriber.
+func (c *{{ .GoName }}Client) unsubscribe(sub chan []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.subs, sub)
+}
+
+// lastReadErr returns the error which stopped the background reader.
+func (c *{{ .GoName }}Client) lastReadErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.readErr == nil {
+		return io.EOF
+	}
+
+	return c.readErr
+}
+
+// disconnect fails all calls which are waiting for a response on the lost
+// connection.
+func (c *{{ .GoName }}Client) disconnect(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readErr = err
+	close(c.responses)
+{{- if .RequestIDKey }}
+
+	for id, res := range c.pending {
+		close(res)
+		delete(c.pending, id)
+	}
+{{- end }}
+}
+
+// shutdown ends all subscriptions once the connection cannot be
+// re-established.
+func (c *{{ .GoName }}Client) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for sub := range c.subs {
+		close(sub)
+		delete(c.subs, sub)
+	}
+}
+
+// readLoop continuously reads messages from the connection, delivering
+// asynchronous messages to all subscribers and synchronous responses to the
+// call which is waiting for them.
+func (c *{{ .GoName }}Client) readLoop() {
+	for {
+		b, err := c.codec.ReadMessage(c.recv)
+		if err != nil {
+			c.disconnect(err)
+
+			if c.reconnect() {
+				continue
+			}
+
+			c.shutdown()
+			return
+		}
+
+		if c.isAsync(b) {
+			c.publish(b)
+			continue
+		}
+{{ if .RequestIDKey }}
+		if id, ok := c.responseID(b); ok {
+			c.mu.Lock()
+			res, ok := c.pending[id]
+			delete(c.pending, id)
+			c.mu.Unlock()
+
+			// Responses to notifications or to calls which stopped waiting are
+			// dropped.
+			if ok {
+				res <- b
+			}
+			continue
+		}
+{{ end }}
+		for {
+			select {
+			case c.responses <- b:
+			default:
+				// Replace a stale response nobody waited for.
+				select {
+				case <-c.responses:
+				default:
+				}
+				continue
+			}
+			break
+		}
+	}
+}
+
+// publish delivers an asynchronous line to all subscribers.
+func (c *{{ .GoName }}Client) publish(b []byte) {
+	c.mu.Lock()
+	subs := make(map[chan []byte]<-chan struct{}, len(c.subs))
+	for sub, done := range c.subs {
+		subs[sub] = done
+	}
+	c.mu.Unlock()
+
+	for sub, done := range subs {
+		select {
+		case sub <- b:
+		case <-done:
+		}
+	}
+}
+
+func (c *{{ .GoName }}Client) setRpcRequestSetDefaults(face any) error {
+	v := reflect.ValueOf(face)
+
+	// If it's an interface or a pointer, unwrap it.
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		v = v.Elem()
+	} else {
+		return nil
+	}
+
+	t := reflect.TypeOf(v.Interface())
+
+	for i := 0; i < v.NumField(); i++ {
+		def := t.Field(i).Tag.Get("default")
+		if def == "" {
+			continue
+		}
+
+		f := v.FieldByName(t.Field(i).Name)
+		if !f.IsValid() || !f.CanSet() {
+			continue
+		}
+
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(def)
+		default:
+			return fmt.Errorf("unsupported default kind: %s", f.Kind().String())
+		}
+	}
+
+	return nil
+}
+`
+
+	messageTemplate = template.Must(template.New("message").Parse(MessageTemplate))
+	MessageTemplate = `
+{{ $this := . }}
+{{ $tick := "` + "`" + `" }}
+{{ if .Message.Comments.Leading -}}
+{{ .Message.Comments.Leading -}}
+{{ end -}}
+type {{ .ToCamel .Message.GoIdent.GoName }} struct {
+{{ range $field := $this.ExtraFields }}
+{{ $type := $this.KindToGoType $field.Kind -}}
+{{ if ne $type "" -}}
+{{ $field.GoName }} {{ $type }} {{ $tick }}json:"{{ $field.JSONName }}"
+	{{- if ne $field.Value "" }} default:"{{ $field.Value }}"{{ end }}{{ $tick }}
+{{ end }}
+{{ end -}}
+{{ range $field := .Message.Fields -}}
+{{ if $field.Comments.Leading -}}
+	{{ $field.Comments.Leading -}}
+{{ end -}}
+{{ $type := $this.FieldToGoType $field -}}
+{{ if ne $type "" -}}
+{{ $this.ToCamel $field.GoName }} {{ $type }} {{ $tick }}json:"{{ $this.JSONTag $field }}"{{ $tick }}
+{{ end -}}
+{{ end -}}
+}
+{{ if .ErrorKey }}
+// Error implements error by joining the non-empty {{ range $i, $field := .ErrorFields }}{{ if $i }}, {{ end }}{{ $field.Desc.JSONName }}{{ end }} fields.
+func (m *{{ .ToCamel .Message.GoIdent.GoName }}) Error() string {
+	var msg string
+	{{ range $field := .ErrorFields -}}
+	if m.{{ $this.ToCamel $field.GoName }} != "" {
+		if msg != "" {
+			msg += ": "
+		}
+		msg += m.{{ $this.ToCamel $field.GoName }}
+	}
+	{{ end -}}
+	return msg
+}
+{{ end -}}
+{{ range $oneof := .Oneofs }}
+{{ $msg := $this.ToCamel $this.Message.GoIdent.GoName }}
+// {{ $oneof.GoName }}Case returns the JSON name of the field which is set in the
+// {{ $oneof.Desc.Name }} oneof or an empty string if none of them are set.
+func (m *{{ $msg }}) {{ $oneof.GoName }}Case() string {
+	switch {
+	{{ range $field := $oneof.Fields -}}
+	{{ if ne ($this.FieldToGoType $field) "" -}}
+	case m.{{ $this.ToCamel $field.GoName }} != nil:
+		return "{{ $field.Desc.JSONName }}"
+	{{ end -}}
+	{{ end -}}
+	}
+
+	return ""
+}
+{{ range $field := $oneof.Fields }}
+{{ $type := $this.FieldToGoType $field -}}
+{{ if ne $type "" -}}
+// Set{{ $this.ToCamel $field.GoName }} sets the {{ $field.Desc.JSONName }} field and clears all other fields
+// of the {{ $oneof.Desc.Name }} oneof.
+func (m *{{ $msg }}) Set{{ $this.ToCamel $field.GoName }}(v {{ if eq $type "any" }}any{{ else }}{{ slice $type 1 }}{{ end }}) {
+	{{ range $other := $oneof.Fields -}}
+	{{ if and (ne $other.GoName $field.GoName) (ne ($this.FieldToGoType $other) "") -}}
+	m.{{ $this.ToCamel $other.GoName }} = nil
+	{{ end -}}
+	{{ end -}}
+	m.{{ $this.ToCamel $field.GoName }} = {{ if eq $type "any" }}v{{ else }}&v{{ end }}
+}
+{{ end -}}
+{{ end -}}
+{{ end -}}
+`
+
+	enumTemplate = template.Must(template.New("enum").Funcs(sprig.TxtFuncMap()).Parse(EnumTemplate))
+	EnumTemplate = `
+{{ $this := . }}
+{{ if .Enum.Comments.Leading -}}
+{{ .Enum.Comments.Leading -}}
+{{ end -}}
+type {{ .Enum.GoIdent.GoName }} string
+const (
+	{{ range $field := .Enum.Values -}}
+		{{ if $field.Comments.Leading -}}
+			{{ $field.Comments.Leading -}}
+		{{ end -}}
+		{{ $field.GoIdent.GoName }} = {{ $this.Enum.GoIdent.GoName }}("{{ with (index $this.JSONNames ( toString $field.Desc.Name )) }}{{ . }}{{ else }}{{ $field.Desc.Name }}{{ end }}")
+	{{ end -}}
+)
+
+func (e {{ .Enum.GoIdent.GoName }}) String() string {
+	return string(e)
+}
+
+func {{ .Enum.GoIdent.GoName }}s() []{{ .Enum.GoIdent.GoName }} {
+	return []{{ .Enum.GoIdent.GoName }}{
+		{{ range $field := .Enum.Values -}}
+		{{ $field.GoIdent.GoName }},
+		{{ end }}
+	}
+}
+
+{{ if (and .Options.MapEnumToMessage (ne (len .MapValues) 0)) }}
+func {{ .Enum.GoIdent.GoName }}TypeMap() map[{{ .Enum.GoIdent.GoName }}]reflect.Type {
+	return map[{{ .Enum.GoIdent.GoName }}]reflect.Type{
+		{{ range $key, $val := .MapValues -}}
+		{{ $key }}: reflect.TypeOf({{ $val }}{}),
+		{{ end }}
+	}
+}
+{{ end }}
+`
+
+	methodTemplate = template.Must(template.New("method").Parse(MethodTemplate))
+	MethodTemplate = `
+{{ $hasReq := or (ne .Input.Desc.FullName "google.protobuf.Empty") (and .EmitEmpty (eq .Input.Desc.FullName "google.protobuf.Empty")) }}
+{{ $hasRes := or (ne .Output.Desc.FullName "google.protobuf.Empty") (and .EmitEmpty (eq .Output.Desc.FullName "google.protobuf.Empty")) }}
+{{ $resAsAny := and (eq .Output.Desc.FullName "google.protobuf.Any") .EmitAnyAsGeneric }}
+func (c *{{ .ServiceGoName }}Client) {{ .GoName }}(ctx context.Context
+	{{- if $hasReq -}}
+	, req {{ .InputType -}}
+	{{ end -}}
+) ({{ if and $hasRes $resAsAny }}*any, {{ else if $hasRes }}*{{ .OutputType }}, {{ end }}error) {
+	{{- if $hasRes }}
+	b, err := c.call(ctx, {{ if $hasReq }}&req{{ else }}nil{{ end }})
+	if err != nil {
+		return nil, err
+	}
+
+{{- if .ErrorType }}
+
+	if err := c.responseError(b); err != nil {
+		return nil, err
+	}
+{{- end }}
+
+	var res {{ if $resAsAny }}any{{ else }}{{ .OutputType }}{{ end }}
+	if err := c.codec.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+	{{ else if $hasReq }}
+	return c.notify(ctx, &req)
+	{{ else }}
+	return nil
+	{{ end -}}
+}
+`
+
+	codecTemplate = template.Must(template.New("codec").Parse(CodecTemplate))
+	CodecTemplate = `
+// {{ .GoName }}Codec serializes and frames the messages which are exchanged
+// with the peer.
+type {{ .GoName }}Codec interface {
+	// Marshal serializes the value into a single message.
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal deserializes a single message into the value.
+	Unmarshal(b []byte, v any) error
+
+	// Set returns the message with its top-level key set to the value.
+	Set(b []byte, key string, v any) ([]byte, error)
+
+	// Lookup deserializes the value of the message's top-level key into the
+	// value and reports whether the key is present.
+	Lookup(b []byte, key string, v any) (bool, error)
+
+	// ReadMessage reads the next framed message.
+	ReadMessage(r *bufio.Reader) ([]byte, error)
+
+	// WriteMessage writes a single framed message.
+	WriteMessage(w io.Writer, b []byte) error
+}
+
+// {{ .GoName }}JSONCodec implements the serialization methods of the
+// {{ .GoName }}Codec for JSON-encoded messages.
+type {{ .GoName }}JSONCodec struct{}
+
+func ({{ .GoName }}JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func ({{ .GoName }}JSONCodec) Unmarshal(b []byte, v any) error {
+	return json.Unmarshal(b, v)
+}
+
+func ({{ .GoName }}JSONCodec) Set(b []byte, key string, v any) ([]byte, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	val, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	raw[key] = val
+
+	return json.Marshal(raw)
+}
+
+func ({{ .GoName }}JSONCodec) Lookup(b []byte, key string, v any) (bool, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return false, err
+	}
+
+	val, ok := raw[key]
+	if !ok {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(val, v)
+}
+
+// {{ .GoName }}JSONLinesCodec frames JSON-encoded messages by terminating
+// each with a newline.
+type {{ .GoName }}JSONLinesCodec struct {
+	{{ .GoName }}JSONCodec
+}
+
+func ({{ .GoName }}JSONLinesCodec) ReadMessage(r *bufio.Reader) ([]byte, error) {
+	return r.ReadBytes('\n')
+}
+
+func ({{ .GoName }}JSONLinesCodec) WriteMessage(w io.Writer, b []byte) error {
+	_, err := w.Write(append(b, '\x0a'))
+	return err
+}
+
+// {{ .GoName }}LengthPrefixedJSONCodec frames JSON-encoded messages by
+// prefixing each with its length as a 32-bit big-endian unsigned integer.
+type {{ .GoName }}LengthPrefixedJSONCodec struct {
+	{{ .GoName }}JSONCodec
+}
+
+func ({{ .GoName }}LengthPrefixedJSONCodec) ReadMessage(r *bufio.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func ({{ .GoName }}LengthPrefixedJSONCodec) WriteMessage(w io.Writer, b []byte) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(b))); err != nil {
+		return err
+	}
+
+	_, err := w.Write(b)
+	return err
+}
+{{ if eq .Codec "msgpack" }}
+// {{ .GoName }}MsgpackCodec serializes messages with MessagePack, which are
+// self-delimiting and therefore need no additional framing.  The json struct
+// tags of the messages are honoured.
+type {{ .GoName }}MsgpackCodec struct{}
+
+func ({{ .GoName }}MsgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func ({{ .GoName }}MsgpackCodec) Unmarshal(b []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	dec.SetCustomStructTag("json")
+
+	return dec.Decode(v)
+}
+
+func (c {{ .GoName }}MsgpackCodec) Set(b []byte, key string, v any) ([]byte, error) {
+	var raw map[string]msgpack.RawMessage
+	if err := c.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	val, err := c.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	raw[key] = val
+
+	return c.Marshal(raw)
+}
+
+func (c {{ .GoName }}MsgpackCodec) Lookup(b []byte, key string, v any) (bool, error) {
+	var raw map[string]msgpack.RawMessage
+	if err := c.Unmarshal(b, &raw); err != nil {
+		return false, err
+	}
+
+	val, ok := raw[key]
+	if !ok {
+		return false, nil
 	}
 
-	return
+	return true, c.Unmarshal(val, v)
 }
 
-type method struct {
-	*protogen.Method
-	Options
-	ServiceGoName string
+func ({{ .GoName }}MsgpackCodec) ReadMessage(r *bufio.Reader) ([]byte, error) {
+	return msgpack.NewDecoder(r).DecodeRaw()
 }
 
-var (
-	headerTemplate = template.Must(template.New("header").Parse(HeaderTemplate))
-	HeaderTemplate = `
-// Code generated by kraftkit.sh/tools/protoc-gen-go-netconn. DO NOT EDIT.
-// source: {{ .Proto.Name }}
-
-package {{.GoPackageName}}
-{{ if (or .HasService .HasEnumMap) }}
-import (
-{{ if .HasService }}
-	"bufio"
-	"encoding/json"
-	"fmt"
-	"io"
-{{- end }}
-	"reflect"
-{{ if .HasService -}}
-	"sync"
-{{ end }}
-)
+func ({{ .GoName }}MsgpackCodec) WriteMessage(w io.Writer, b []byte) error {
+	_, err := w.Write(b)
+	return err
+}
 {{ end }}
 `
 
-	serviceTemplate = template.Must(template.New("service").Parse(ServiceTemplate))
-	ServiceTemplate = `
-type {{ .GoName }}Client struct {
-	conn io.ReadWriteCloser
-	lock sync.RWMutex
-	recv *bufio.Reader
-	send *bufio.Writer
+	mockTemplate = template.Must(template.New("mock").Parse(MockTemplate))
+	MockTemplate = `
+// {{ .GoName }} is the set of calls which are implemented by both the
+// {{ .GoName }}Client and the {{ .GoName }}Mock.
+type {{ .GoName }} interface {
+	{{- range .Methods }}
+	{{ if .Stream }}Subscribe{{ end }}{{ .GoName }}(ctx context.Context{{ if .Request }}, req {{ .Request }}{{ end }}) (
+		{{- if .Stream }}<-chan *{{ .Response }}, <-chan error, error
+		{{- else if .Response }}*{{ .Response }}, error
+		{{- else }}error{{ end }})
+	{{- end }}
+	Close() error
 }
 
-func New{{ .GoName }}Client(conn io.ReadWriteCloser) *{{ .GoName }}Client {
-	return &{{ .GoName }}Client{
-		conn: conn,
-		recv: bufio.NewReader(conn),
-		send: bufio.NewWriter(conn),
-	}
-}
+var (
+	_ {{ .GoName }} = (*{{ .GoName }}Client)(nil)
+	_ {{ .GoName }} = (*{{ .GoName }}Mock)(nil)
+)
 
-func (c *{{ .GoName }}Client) Close() error {
-	return c.conn.Close()
+// {{ .GoName }}MockCall is a call which was made to a {{ .GoName }}Mock.
+type {{ .GoName }}MockCall struct {
+	Method  string
+	Request any
 }
 
-func (c *{{ .GoName }}Client) setRpcRequestSetDefaults(face any) error {
-	v := reflect.ValueOf(face)
+// {{ .GoName }}Mock is a test double of the {{ .GoName }}Client which records
+// every call.  Calls are answered by the respective function field if set, and
+// with the zero value of their response otherwise.
+type {{ .GoName }}Mock struct {
+	{{- range .Methods }}
+	{{ if .Stream }}Subscribe{{ end }}{{ .GoName }}Func func(ctx context.Context{{ if .Request }}, req {{ .Request }}{{ end }}) (
+		{{- if .Stream }}<-chan *{{ .Response }}, <-chan error, error
+		{{- else if .Response }}*{{ .Response }}, error
+		{{- else }}error{{ end }})
+	{{- end }}
+
+	mu     sync.Mutex
+	calls  []{{ .GoName }}MockCall
+	closed bool
+
+	// smu guards the subscriptions separately, such that subscribers may call
+	// the mock while a message is being delivered to them.
+	smu sync.Mutex
+	{{- range .Methods }}
+	{{- if .Stream }}
+	subs{{ .GoName }} map[chan *{{ .Response }}]<-chan struct{}
+	{{- end }}
+	{{- end }}
+}
 
-	// If it's an interface or a pointer, unwrap it.
-	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
-		v = v.Elem()
-	} else {
-		return nil
+// New{{ .GoName }}Mock returns a mock without any configured responses.
+func New{{ .GoName }}Mock() *{{ .GoName }}Mock {
+	return &{{ .GoName }}Mock{
+		{{- range .Methods }}
+		{{- if .Stream }}
+		subs{{ .GoName }}: make(map[chan *{{ .Response }}]<-chan struct{}),
+		{{- end }}
+		{{- end }}
 	}
+}
 
-	t := reflect.TypeOf(v.Interface())
+// Calls returns all calls which were made to the mock so far.
+func (m *{{ .GoName }}Mock) Calls() []{{ .GoName }}MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	for i := 0; i < v.NumField(); i++ {
-		def := t.Field(i).Tag.Get("default")
-		if def == "" {
-			continue
-		}
+	return append([]{{ .GoName }}MockCall(nil), m.calls...)
+}
 
-		f := v.FieldByName(t.Field(i).Name)
-		if !f.IsValid() || !f.CanSet() {
-			continue
-		}
+// CallsTo returns the requests of all calls which were made to the method.
+func (m *{{ .GoName }}Mock) CallsTo(method string) []any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-		switch f.Kind() {
-		case reflect.String:
-			f.SetString(def)
-		default:
-			return fmt.Errorf("unsupported default kind: %s", f.Kind().String())
+	var reqs []any
+	for _, call := range m.calls {
+		if call.Method == method {
+			reqs = append(reqs, call.Request)
 		}
 	}
 
+	return reqs
+}
+
+func (m *{{ .GoName }}Mock) record(method string, req any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, {{ .GoName }}MockCall{
+		Method:  method,
+		Request: req,
+	})
+}
+
+// Close records the call and marks the mock as closed.
+func (m *{{ .GoName }}Mock) Close() error {
+	m.record("Close", nil)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+
 	return nil
 }
-`
 
-	messageTemplate = template.Must(template.New("message").Parse(MessageTemplate))
-	MessageTemplate = `
-{{ $this := . }}
-{{ $tick := "` + "`" + `" }}
-{{ if .Message.Comments.Leading -}}
-{{ .Message.Comments.Leading -}}
-{{ end -}}
-type {{ .ToCamel .Message.GoIdent.GoName }} struct {
-{{ range $field := $this.ExtraFields }}
-{{ $type := $this.KindToGoType $field.Kind -}}
-{{ if ne $type "" -}}
-{{ $field.GoName }} {{ $type }} {{ $tick }}json:"{{ $field.JSONName }}"
-	{{- if ne $field.Value "" }} default:"{{ $field.Value }}"{{ end }}{{ $tick }}
-{{ end }}
-{{ end -}}
-{{ range $field := .Message.Fields -}}
-{{ if $field.Comments.Leading -}}
-	{{ $field.Comments.Leading -}}
-{{ end -}}
-{{ $type := "any" -}}
-{{ if not (and ($field.Message) (eq $field.Message.Desc.FullName "google.protobuf.Any")) -}}
-{{ $type = $this.FieldToGoType $field -}}
-{{ end -}}
-{{ if ne $type "" -}}
-{{ $this.ToCamel $field.GoName }} {{ $type }} {{ $tick }}json:"{{ $field.Desc.JSONName }}"{{ $tick }}
-{{ end -}}
-{{ end -}}
+// Closed reports whether the mock has been closed.
+func (m *{{ .GoName }}Mock) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.closed
 }
-`
+{{ $svc := .GoName }}
+{{- range .Methods }}
+{{- if .Stream }}
+func (m *{{ $svc }}Mock) Subscribe{{ .GoName }}(ctx context.Context{{ if .Request }}, req {{ .Request }}{{ end }}) (<-chan *{{ .Response }}, <-chan error, error) {
+	m.record("Subscribe{{ .GoName }}", {{ if .Request }}req{{ else }}nil{{ end }})
+
+	if m.Subscribe{{ .GoName }}Func != nil {
+		return m.Subscribe{{ .GoName }}Func(ctx{{ if .Request }}, req{{ end }})
+	}
 
-	enumTemplate = template.Must(template.New("enum").Funcs(sprig.TxtFuncMap()).Parse(EnumTemplate))
-	EnumTemplate = `
-{{ $this := . }}
-{{ if .Enum.Comments.Leading -}}
-{{ .Enum.Comments.Leading -}}
-{{ end -}}
-type {{ .Enum.GoIdent.GoName }} string
-const (
-	{{ range $field := .Enum.Values -}}
-		{{ if $field.Comments.Leading -}}
-			{{ $field.Comments.Leading -}}
-		{{ end -}}
-		{{ $field.GoIdent.GoName }} = {{ $this.Enum.GoIdent.GoName }}("{{ with (index $this.JSONNames ( toString $field.Desc.Name )) }}{{ . }}{{ else }}{{ $field.Desc.Name }}{{ end }}")
-	{{ end -}}
-)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 
-func (e {{ .Enum.GoIdent.GoName }}) String() string {
-	return string(e)
+	events := make(chan *{{ .Response }})
+	errs := make(chan error)
+
+	m.smu.Lock()
+	m.subs{{ .GoName }}[events] = ctx.Done()
+	m.smu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		m.smu.Lock()
+		delete(m.subs{{ .GoName }}, events)
+		m.smu.Unlock()
+
+		close(events)
+		close(errs)
+	}()
+
+	return events, errs, nil
 }
 
-func {{ .Enum.GoIdent.GoName }}s() []{{ .Enum.GoIdent.GoName }} {
-	return []{{ .Enum.GoIdent.GoName }}{
-		{{ range $field := .Enum.Values -}}
-		{{ $field.GoIdent.GoName }},
-		{{ end }}
+// Send{{ .GoName }} delivers the message to every active subscription made with
+// Subscribe{{ .GoName }} which is not served by Subscribe{{ .GoName }}Func.
+func (m *{{ $svc }}Mock) Send{{ .GoName }}(res *{{ .Response }}) {
+	m.smu.Lock()
+	defer m.smu.Unlock()
+
+	for sub, done := range m.subs{{ .GoName }} {
+		select {
+		case sub <- res:
+		case <-done:
+		}
 	}
 }
+{{- else }}
+func (m *{{ $svc }}Mock) {{ .GoName }}(ctx context.Context{{ if .Request }}, req {{ .Request }}{{ end }}) ({{ if .Response }}*{{ .Response }}, {{ end }}error) {
+	m.record("{{ .GoName }}", {{ if .Request }}req{{ else }}nil{{ end }})
 
-{{ if (and .Options.MapEnumToMessage (ne (len .MapValues) 0)) }}
-func {{ .Enum.GoIdent.GoName }}TypeMap() map[{{ .Enum.GoIdent.GoName }}]reflect.Type {
-	return map[{{ .Enum.GoIdent.GoName }}]reflect.Type{
-		{{ range $key, $val := .MapValues -}}
-		{{ $key }}: reflect.TypeOf({{ $val }}{}),
-		{{ end }}
+	if m.{{ .GoName }}Func != nil {
+		return m.{{ .GoName }}Func(ctx{{ if .Request }}, req{{ end }})
+	}
+{{ if .Response }}
+	return new({{ .Response }}), nil
+{{- else }}
+	return nil
+{{- end }}
+}
+{{- if .Response }}
+
+// Return{{ .GoName }} configures {{ .GoName }} to answer with the canned response
+// and error.
+func (m *{{ $svc }}Mock) Return{{ .GoName }}(res *{{ .Response }}, err error) {
+	m.{{ .GoName }}Func = func(context.Context{{ if .Request }}, {{ .Request }}{{ end }}) (*{{ .Response }}, error) {
+		return res, err
 	}
 }
+{{- end }}
+{{- end }}
 {{ end }}
 `
 
-	methodTemplate = template.Must(template.New("method").Parse(MethodTemplate))
-	MethodTemplate = `
+	streamTemplate = template.Must(template.New("stream").Parse(StreamTemplate))
+	StreamTemplate = `
 {{ $hasReq := or (ne .Input.Desc.FullName "google.protobuf.Empty") (and .EmitEmpty (eq .Input.Desc.FullName "google.protobuf.Empty")) }}
-{{ $hasRes := or (ne .Output.Desc.FullName "google.protobuf.Empty") (and .EmitEmpty (eq .Output.Desc.FullName "google.protobuf.Empty")) }}
 {{ $resAsAny := and (eq .Output.Desc.FullName "google.protobuf.Any") .EmitAnyAsGeneric }}
-func (c *{{ .ServiceGoName }}Client) {{ .GoName }}(
+{{ $res := .OutputType }}{{ if $resAsAny }}{{ $res = "any" }}{{ end }}
+func (c *{{ .ServiceGoName }}Client) Subscribe{{ .GoName }}(ctx context.Context
 	{{- if $hasReq -}}
-	req {{ .Input.GoIdent.GoName -}}
+	, req {{ .InputType -}}
 	{{ end -}}
-) ({{ if and $hasRes $resAsAny }}*any, {{ else if $hasRes }}*{{ .Output.GoIdent.GoName }}, {{ end }}error) {
-	var b []byte
-	var err error
+) (<-chan *{{ $res }}, <-chan error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	sub := c.subscribe(ctx)
 
 	{{ if $hasReq }}
-	if err := c.setRpcRequestSetDefaults(&req); err != nil {
-		return {{ if $hasRes }}nil, {{ end }}err
+	if err := c.notify(ctx, &req); err != nil {
+		c.unsubscribe(sub)
+		return nil, nil, err
 	}
 	{{ end }}
 
-	{{ if $hasReq }}
-	b, err = json.Marshal(req)
-	if err != nil {
-		return {{ if $hasRes }}nil, {{ end }}err
-	}
-	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
-		return {{ if $hasRes }}nil, {{ end }}err
-	}
-	if err := c.send.Flush(); err != nil {
-		return {{ if $hasRes }}nil, {{ end }}err
-	}
-	{{ end }}
+	events := make(chan *{{ $res }})
+	errs := make(chan error)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+		defer c.unsubscribe(sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case b, ok := <-sub:
+				if !ok {
+					select {
+					case errs <- c.lastReadErr():
+					case <-ctx.Done():
+					}
+					return
+				}
 
-	{{ if $hasRes }}
-	var res {{ if $resAsAny }}any{{ else }}{{ .Output.GoIdent.GoName }}{{ end }}
-	b, err = c.recv.ReadBytes('\n')
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, err
-	}
+				var event {{ $res }}
+				if err := c.codec.Unmarshal(b, &event); err != nil {
+					select {
+					case errs <- err:
+					case <-ctx.Done():
+						return
+					}
+					continue
+				}
 
-	return &res, nil
-	{{ else }}
-	return nil
-	{{ end -}}
+				select {
+				case events <- &event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, errs, nil
 }
 `
 )
 
-func applyEnums(w io.Writer, enums []*protogen.Enum, opts Options) error {
+func applyEnums(w io.Writer, enums []*protogen.Enum, parent *protogen.Message, opts Options) error {
 	for _, e := range enums {
 		if e.Desc.IsPlaceholder() {
 			glog.V(2).Infof("Skipping placeholder enum %s", e.GoIdent.GoName)
@@ -378,12 +1684,21 @@ func applyEnums(w io.Writer, enums []*protogen.Enum, opts Options) error {
 		jsonNames := make(map[string]string)
 		mapVals := make(map[string]string)
 
+		// Values of enums which are nested in a message are prefixed with the
+		// message's name rather than the enum's and remain scoped by it, whereas
+		// the enum itself is named like nested messages are.
+		prefix := e.GoIdent.GoName + "_"
+		if parent != nil {
+			prefix = parent.GoIdent.GoName + "_"
+			e.GoIdent.GoName = strcase.ToCamel(e.GoIdent.GoName)
+		}
+
 		glog.V(2).Infof("Processing enum %s", e.GoIdent.GoName)
 
 		for i, ev := range e.Values {
 			// Temporarily remove the parent prefix name from the GoIdent so we can
 			// later re-apply it in case its value has changed
-			e.Values[i].GoIdent.GoName = strings.TrimPrefix(e.Values[i].GoIdent.GoName, e.Values[i].Parent.GoIdent.GoName+"_")
+			e.Values[i].GoIdent.GoName = strings.TrimPrefix(e.Values[i].GoIdent.GoName, prefix)
 
 			desc := protodesc.ToEnumValueDescriptorProto(ev.Desc)
 			if desc.Options != nil && (opts.RemapEnumViaJsonName || opts.MapEnumToMessage) {
@@ -418,6 +1733,8 @@ func applyEnums(w io.Writer, enums []*protogen.Enum, opts Options) error {
 			//
 			if opts.EmitEnumPrefix {
 				e.Values[i].GoIdent.GoName = e.Values[i].Parent.GoIdent.GoName + "_" + e.Values[i].GoIdent.GoName
+			} else if parent != nil {
+				e.Values[i].GoIdent.GoName = strcase.ToCamel(parent.GoIdent.GoName) + "_" + e.Values[i].GoIdent.GoName
 			}
 		}
 
@@ -466,6 +1783,12 @@ func applyMessages(w io.Writer, messages []*protogen.Message, opts Options) erro
 					return true
 				}
 
+				// The error option determines how the message is decoded rather than
+				// being part of it.
+				if fd.Name() == "error" {
+					return true
+				}
+
 				extraFields = append(extraFields, messageExtraField{
 					GoName:   strcase.ToCamel(string(fd.Name())),
 					JSONName: string(fd.Name()),
@@ -477,16 +1800,26 @@ func applyMessages(w io.Writer, messages []*protogen.Message, opts Options) erro
 			})
 		}
 
+		errorKey, err := errorOption(m)
+		if err != nil {
+			return err
+		}
+
 		glog.V(2).Infof("Processing message %s", m.GoIdent.GoName)
 
 		if err := messageTemplate.Execute(w, message{
 			Message:     m,
 			Options:     opts,
 			ExtraFields: extraFields,
+			ErrorKey:    errorKey,
 		}); err != nil {
 			return err
 		}
 
+		if err := applyEnums(w, m.Enums, m, opts); err != nil {
+			return err
+		}
+
 		if err := applyMessages(w, m.Messages, opts); err != nil {
 			return err
 		}
@@ -495,9 +1828,117 @@ func applyMessages(w io.Writer, messages []*protogen.Message, opts Options) erro
 	return nil
 }
 
+// mockMethods returns the signatures of the service's methods which are
+// implemented by the client.
+func mockMethods(s *protogen.Service, opts Options) []mockMethod {
+	var methods []mockMethod
+
+	for _, m := range s.Methods {
+		if m.Desc.IsStreamingClient() {
+			continue
+		}
+
+		mm := mockMethod{
+			GoName: m.GoName,
+			Stream: m.Desc.IsStreamingServer(),
+		}
+
+		if m.Input.Desc.FullName() != "google.protobuf.Empty" || opts.EmitEmpty {
+			mm.Request = opts.Imports.Qualify(m.Input.GoIdent.GoName, m.Input.GoIdent, m.Input.Desc)
+		}
+
+		if m.Output.Desc.FullName() == "google.protobuf.Any" && opts.EmitAnyAsGeneric {
+			mm.Response = "any"
+		} else if m.Output.Desc.FullName() != "google.protobuf.Empty" || opts.EmitEmpty || mm.Stream {
+			mm.Response = opts.Imports.Qualify(m.Output.GoIdent.GoName, m.Output.GoIdent, m.Output.Desc)
+		}
+
+		methods = append(methods, mm)
+	}
+
+	return methods
+}
+
+// errorOption returns the value of the message's "error" option, i.e. the
+// top-level key under which responses carry the message in case of failure.
+func errorOption(m *protogen.Message) (string, error) {
+	desc := protodesc.ToDescriptorProto(m.Desc)
+	if desc.Options == nil {
+		return "", nil
+	}
+
+	options := m.Desc.Options().(*descriptorpb.MessageOptions)
+	b, err := proto.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+
+	options.Reset()
+	err = proto.UnmarshalOptions{Resolver: extTypes}.Unmarshal(b, options)
+	if err != nil {
+		return "", err
+	}
+
+	var key string
+	options.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsExtension() && fd.Name() == "error" {
+			key = v.String()
+			return false
+		}
+
+		return true
+	})
+
+	return key, nil
+}
+
+// FindErrorMessage returns the message with the "error" option which is
+// declared in the given Go package together with the option's value.
+func FindErrorMessage(files []*protogen.File, path protogen.GoImportPath) (*protogen.Message, string, error) {
+	var found *protogen.Message
+	var foundKey string
+
+	var walk func(messages []*protogen.Message) error
+	walk = func(messages []*protogen.Message) error {
+		for _, m := range messages {
+			key, err := errorOption(m)
+			if err != nil {
+				return err
+			}
+
+			if key != "" {
+				if found != nil {
+					return fmt.Errorf("multiple error messages in %s: %s and %s", path, found.Desc.FullName(), m.Desc.FullName())
+				}
+
+				found = m
+				foundKey = key
+			}
+
+			if err := walk(m.Messages); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, f := range files {
+		if f.GoImportPath != path {
+			continue
+		}
+
+		if err := walk(f.Messages); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return found, foundKey, nil
+}
+
 // ApplyTemplate accepts an input proto file and emits each service method,
 // enums and messages.
-func ApplyTemplate(w io.Writer, f *protogen.File, opts Options) error {
+func ApplyTemplate(out io.Writer, f *protogen.File, opts Options) error {
 	hasService := false
 	hasEnumMap := false
 
@@ -512,7 +1953,7 @@ func ApplyTemplate(w io.Writer, f *protogen.File, opts Options) error {
 
 	if opts.MapEnumToMessage {
 	loop:
-		for _, e := range f.Enums {
+		for _, e := range fileEnums(f) {
 			for _, ev := range e.Values {
 				desc := protodesc.ToEnumValueDescriptorProto(ev.Desc)
 				if desc.Options != nil {
@@ -548,11 +1989,9 @@ func ApplyTemplate(w io.Writer, f *protogen.File, opts Options) error {
 		}
 	}
 
-	if err := headerTemplate.Execute(w, header{
-		f, opts, hasService, hasEnumMap,
-	}); err != nil {
-		return err
-	}
+	// The body is rendered first as it determines the imports of the header.
+	opts.Imports = newGoImports(f)
+	w := &bytes.Buffer{}
 
 	for _, s := range f.Services {
 		if s.Desc.IsPlaceholder() {
@@ -568,8 +2007,24 @@ func ApplyTemplate(w io.Writer, f *protogen.File, opts Options) error {
 			return err
 		}
 
+		if err := codecTemplate.Execute(w, service{
+			s, opts,
+		}); err != nil {
+			return err
+		}
+
 		for _, m := range s.Methods {
-			if err := methodTemplate.Execute(w, method{
+			if m.Desc.IsStreamingClient() {
+				glog.V(2).Infof("Skipping %s, client-streaming methods are not supported", m.Desc.FullName())
+				continue
+			}
+
+			tmpl := methodTemplate
+			if m.Desc.IsStreamingServer() {
+				tmpl = streamTemplate
+			}
+
+			if err := tmpl.Execute(w, method{
 				Method:        m,
 				Options:       opts,
 				ServiceGoName: s.GoName,
@@ -577,9 +2032,17 @@ func ApplyTemplate(w io.Writer, f *protogen.File, opts Options) error {
 				return err
 			}
 		}
+
+		if opts.EmitMocks {
+			if err := mockTemplate.Execute(w, mock{
+				s, opts, mockMethods(s, opts),
+			}); err != nil {
+				return err
+			}
+		}
 	}
 
-	if err := applyEnums(w, f.Enums, opts); err != nil {
+	if err := applyEnums(w, f.Enums, nil, opts); err != nil {
 		return err
 	}
 
@@ -587,5 +2050,29 @@ func ApplyTemplate(w io.Writer, f *protogen.File, opts Options) error {
 		return err
 	}
 
-	return nil
+	if err := headerTemplate.Execute(out, header{
+		f, opts, hasService, hasEnumMap, opts.Imports.List(),
+	}); err != nil {
+		return err
+	}
+
+	_, err := w.WriteTo(out)
+	return err
+}
+
+// fileEnums returns all enums of the file including the ones which are nested
+// in its messages.
+func fileEnums(f *protogen.File) []*protogen.Enum {
+	enums := append([]*protogen.Enum(nil), f.Enums...)
+
+	var walk func(messages []*protogen.Message)
+	walk = func(messages []*protogen.Message) {
+		for _, m := range messages {
+			enums = append(enums, m.Enums...)
+			walk(m.Messages)
+		}
+	}
+	walk(f.Messages)
+
+	return enums
 }
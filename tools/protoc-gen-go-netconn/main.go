@@ -36,6 +36,7 @@ import (
 	"fmt"
 
 	"github.com/golang/glog"
+	"github.com/iancoleman/strcase"
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
@@ -51,6 +52,10 @@ var (
 	emitEnumPrefix       = flag.Bool("emit_enum_prefix", false, "render enums with name prefix")
 	remapEnumViaJsonName = flag.Bool("remap_enum_via_json_name", false, "recognize 'json_name' enum value option and use as string value for enums")
 	mapEnumToMessage     = flag.Bool("map_enum_to_message", false, "create a map between an enum and a known message")
+	streamEventKey       = flag.String("stream_event_key", "event", "top-level key which identifies asynchronous messages delivered to server-streaming methods")
+	requestIDKey         = flag.String("request_id_key", "", "top-level key used to correlate responses with concurrent requests, disabled if empty")
+	emitMocks            = flag.Bool("emit_mocks", false, "render a mock of every service which records calls and returns canned responses")
+	codec                = flag.String("codec", "jsonl", "default codec of generated clients: jsonl, json-length-prefixed or msgpack")
 )
 
 // Recursively register all extensions into the provided protoregistry.Types,
@@ -83,6 +88,59 @@ func registerAllExtensions(extTypes *protoregistry.Types, descs interface {
 	return nil
 }
 
+// generate renders the files to generate of the request into the plugin's
+// response.
+func generate(gen *protogen.Plugin, opts Options) error {
+	for _, f := range gen.Files {
+		if err := registerAllExtensions(extTypes, f.Desc); err != nil {
+			return err
+		}
+	}
+
+	switch opts.Codec {
+	case "jsonl", "json-length-prefixed", "msgpack":
+	default:
+		return fmt.Errorf("unsupported codec: %s", opts.Codec)
+	}
+
+	for _, name := range gen.Request.FileToGenerate {
+		f := gen.FilesByPath[name]
+
+		if len(f.Messages) == 0 && len(f.Services) == 0 && len(f.Enums) == 0 {
+			glog.V(1).Infof("Skipping %s, no messages and services", name)
+			continue
+		}
+
+		glog.V(1).Infof("Processing %s", name)
+		glog.V(2).Infof("Generating %s\n", fmt.Sprintf("%s.pb.netconn.go", f.GeneratedFilenamePrefix))
+
+		gf := gen.NewGeneratedFile(fmt.Sprintf("%s.pb.netconn.go", f.GeneratedFilenamePrefix), f.GoImportPath)
+
+		fopts := opts
+
+		errMsg, errKey, err := FindErrorMessage(gen.Files, f.GoImportPath)
+		if err != nil {
+			gf.Skip()
+			gen.Error(err)
+			continue
+		}
+
+		if errMsg != nil {
+			fopts.ErrorType = strcase.ToCamel(errMsg.GoIdent.GoName)
+			fopts.ErrorKey = errKey
+		}
+
+		err = ApplyTemplate(gf, f, fopts)
+		if err != nil {
+			gf.Skip()
+			gen.Error(err)
+			continue
+		}
+	}
+
+	return nil
+}
+
 func main() {
 	flag.Parse()
 	defer glog.Flush()
@@ -90,42 +148,17 @@ func main() {
 	protogen.Options{
 		ParamFunc: flag.CommandLine.Set,
 	}.Run(func(gen *protogen.Plugin) error {
-		for _, f := range gen.Files {
-			if err := registerAllExtensions(extTypes, f.Desc); err != nil {
-				return err
-			}
-		}
-
-		opts := Options{
+		return generate(gen, Options{
 			EmitEmpty:            *emitEmpty,
 			EmitMessageOptions:   *emitMessageOptions,
 			EmitAnyAsGeneric:     *emitAnyAsGeneric,
 			EmitEnumPrefix:       *emitEnumPrefix,
 			RemapEnumViaJsonName: *remapEnumViaJsonName,
 			MapEnumToMessage:     *mapEnumToMessage,
-		}
-
-		for _, name := range gen.Request.FileToGenerate {
-			f := gen.FilesByPath[name]
-
-			if len(f.Messages) == 0 && len(f.Services) == 0 && len(f.Enums) == 0 {
-				glog.V(1).Infof("Skipping %s, no messages and services", name)
-				continue
-			}
-
-			glog.V(1).Infof("Processing %s", name)
-			glog.V(2).Infof("Generating %s\n", fmt.Sprintf("%s.pb.netconn.go", f.GeneratedFilenamePrefix))
-
-			gf := gen.NewGeneratedFile(fmt.Sprintf("%s.pb.netconn.go", f.GeneratedFilenamePrefix), f.GoImportPath)
-
-			err := ApplyTemplate(gf, f, opts)
-			if err != nil {
-				gf.Skip()
-				gen.Error(err)
-				continue
-			}
-		}
-
-		return nil
+			StreamEventKey:       *streamEventKey,
+			RequestIDKey:         *requestIDKey,
+			Codec:                *codec,
+			EmitMocks:            *emitMocks,
+		})
 	})
 }
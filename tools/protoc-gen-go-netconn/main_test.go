@@ -0,0 +1,1340 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// testModule is the Go module of the packages generated by the tests.
+const testModule = "example.com/test"
+
+// testFile returns a proto3 file which is generated into the Go package of the
+// test module with the provided name.
+func testFile(name, pkg string, deps ...string) *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String(name),
+		Package:    proto.String(pkg),
+		Syntax:     proto.String("proto3"),
+		Dependency: deps,
+		Options: &descriptorpb.FileOptions{
+			GoPackage: proto.String(testModule + "/" + pkg + ";" + pkg),
+		},
+	}
+}
+
+// testMessage returns a message with the provided fields.
+func testMessage(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{
+		Name:  proto.String(name),
+		Field: fields,
+	}
+}
+
+// testField returns a singular field of the provided type.  The type name
+// refers to the message or enum of the field, if any.
+func testField(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	field := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   typ.Enum(),
+	}
+
+	if typeName != "" {
+		field.TypeName = proto.String(typeName)
+	}
+
+	return field
+}
+
+// testMethod returns a unary or server-streaming method.
+func testMethod(name, input, output string, stream bool) *descriptorpb.MethodDescriptorProto {
+	return &descriptorpb.MethodDescriptorProto{
+		Name:            proto.String(name),
+		InputType:       proto.String(input),
+		OutputType:      proto.String(output),
+		ServerStreaming: proto.Bool(stream),
+	}
+}
+
+// testEchoFile returns a file of the "echo" package with an Echo service whose
+// Ping method answers a PingRequest with a PingResponse.
+func testEchoFile() *descriptorpb.FileDescriptorProto {
+	f := testFile("echo.proto", "echo")
+	f.MessageType = []*descriptorpb.DescriptorProto{
+		testMessage("PingRequest",
+			testField("value", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+		),
+		testMessage("PingResponse",
+			testField("value", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+		),
+	}
+	f.Service = []*descriptorpb.ServiceDescriptorProto{{
+		Name: proto.String("Echo"),
+		Method: []*descriptorpb.MethodDescriptorProto{
+			testMethod("Ping", ".echo.PingRequest", ".echo.PingResponse", false),
+		},
+	}}
+
+	return f
+}
+
+// testOptions returns the options with which the tests generate files unless
+// they override them.
+func testOptions() Options {
+	return Options{
+		StreamEventKey: "event",
+		Codec:          "jsonl",
+	}
+}
+
+// generateFiles runs the generator for the provided files, along with the
+// well-known files they may depend on, and returns the generated sources by
+// the path of their Go package relative to the test module.
+func generateFiles(t *testing.T, opts Options, files ...*descriptorpb.FileDescriptorProto) map[string]string {
+	t.Helper()
+
+	req := &pluginpb.CodeGeneratorRequest{
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(emptypb.File_google_protobuf_empty_proto),
+		},
+	}
+
+	for _, f := range files {
+		req.ProtoFile = append(req.ProtoFile, f)
+		req.FileToGenerate = append(req.FileToGenerate, f.GetName())
+	}
+
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatalf("creating plugin: %v", err)
+	}
+
+	// Extensions are registered globally by the generator.
+	extTypes = new(protoregistry.Types)
+
+	if err := generate(gen, opts); err != nil {
+		t.Fatalf("generating: %v", err)
+	}
+
+	res := gen.Response()
+	if res.Error != nil {
+		t.Fatalf("generating: %s", res.GetError())
+	}
+
+	srcs := make(map[string]string)
+	for _, f := range res.File {
+		srcs[strings.TrimPrefix(filepath.Dir(f.GetName()), testModule+"/")] = f.GetContent()
+	}
+
+	return srcs
+}
+
+// runGenerated writes the generated sources into a module together with the
+// provided tests and runs them.  The tests are written to the package at the
+// path relative to the module which they are keyed by.
+func runGenerated(t *testing.T, srcs, tests map[string]string) {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping tests of generated code in short mode")
+	}
+
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"go.mod": "module " + testModule + "\n\ngo 1.22\n",
+	}
+
+	for path, src := range srcs {
+		files[filepath.Join(path, "generated.go")] = src
+	}
+
+	for path, src := range tests {
+		files[filepath.Join(path, "generated_test.go")] = src
+	}
+
+	for name, content := range files {
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(gobin, "test", "-count=1", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GOFLAGS=-mod=mod",
+		"GOPROXY=off",
+		"GOWORK=off",
+	)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("testing generated code: %v\n%s", err, out)
+	}
+}
+
+// assertContains fails the test if the source lacks any of the snippets,
+// regardless of the whitespace which separates their tokens.
+func assertContains(t *testing.T, src string, snippets ...string) {
+	t.Helper()
+
+	src = strings.Join(strings.Fields(src), " ")
+
+	for _, snippet := range snippets {
+		if !strings.Contains(src, strings.Join(strings.Fields(snippet), " ")) {
+			t.Errorf("expected generated code to contain %q", snippet)
+		}
+	}
+}
+
+func TestGenerateUnsupportedCodec(t *testing.T) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"echo.proto"},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{testEchoFile()},
+	}
+
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := testOptions()
+	opts.Codec = "xml"
+
+	if err := generate(gen, opts); err == nil {
+		t.Error("expected error for unsupported codec")
+	}
+}
+
+func TestGenerateContext(t *testing.T) {
+	srcs := generateFiles(t, testOptions(), testEchoFile())
+
+	assertContains(t, srcs["echo"],
+		"func (c *EchoClient) Ping(ctx context.Context, req PingRequest) (*PingResponse, error)",
+		"func WithEchoClientTimeout(timeout time.Duration) EchoClientOption",
+	)
+
+	runGenerated(t, srcs, map[string]string{"echo": `package echo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPing(t *testing.T) {
+	client, server := net.Pipe()
+	c := NewEchoClient(client)
+	defer c.Close()
+
+	go func() {
+		r := bufio.NewReader(server)
+		for {
+			if _, err := r.ReadBytes('\n'); err != nil {
+				return
+			}
+
+			server.Write([]byte("{\"value\":\"pong\"}\n"))
+		}
+	}()
+
+	res, err := c.Ping(context.Background(), PingRequest{Value: "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Value != "pong" {
+		t.Errorf("expected pong, got %q", res.Value)
+	}
+}
+
+func TestPingTimeout(t *testing.T) {
+	// The peer never reads, such that the request cannot even be written.
+	client, server := net.Pipe()
+	defer server.Close()
+
+	c := NewEchoClient(client, WithEchoClientTimeout(50*time.Millisecond))
+	defer c.Close()
+
+	if _, err := c.Ping(context.Background(), PingRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline to be exceeded, got %v", err)
+	}
+
+	// The deadline of the context takes precedence over the default timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	if _, err := c.Ping(ctx, PingRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context to be canceled, got %v", err)
+	}
+}
+
+func TestPingNoResponse(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	c := NewEchoClient(client)
+	defer c.Close()
+
+	go bufio.NewReader(server).ReadBytes('\n')
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.Ping(ctx, PingRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline to be exceeded, got %v", err)
+	}
+}
+`})
+}
+
+func TestGenerateStreaming(t *testing.T) {
+	f := testEchoFile()
+	f.Dependency = append(f.Dependency, "google/protobuf/empty.proto")
+	f.MessageType = append(f.MessageType,
+		testMessage("Event",
+			testField("event", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			testField("data", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+		),
+	)
+	f.Service[0].Method = append(f.Service[0].Method,
+		testMethod("Events", ".google.protobuf.Empty", ".echo.Event", true),
+		testMethod("Watch", ".echo.PingRequest", ".echo.Event", true),
+	)
+
+	srcs := generateFiles(t, testOptions(), f)
+
+	assertContains(t, srcs["echo"],
+		"func (c *EchoClient) SubscribeEvents(ctx context.Context) (<-chan *Event, <-chan error, error)",
+		"func (c *EchoClient) SubscribeWatch(ctx context.Context, req PingRequest) (<-chan *Event, <-chan error, error)",
+	)
+
+	runGenerated(t, srcs, map[string]string{"echo": `package echo
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	client, server := net.Pipe()
+	c := NewEchoClient(client)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, errs, err := c.SubscribeEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watched := make(chan string, 1)
+
+	go func() {
+		r := bufio.NewReader(server)
+
+		// The request of the subscription with a request is sent first.
+		b, _ := r.ReadBytes('\n')
+		watched <- string(b)
+
+		// Events are interleaved with the response to the call.
+		if _, err := r.ReadBytes('\n'); err != nil {
+			return
+		}
+
+		server.Write([]byte("{\"event\":\"STOP\",\"data\":\"1\"}\n"))
+		server.Write([]byte("{\"value\":\"pong\"}\n"))
+		server.Write([]byte("{\"event\":\"RESUME\",\"data\":\"2\"}\n"))
+	}()
+
+	watch, _, err := c.SubscribeWatch(ctx, PingRequest{Value: "watch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b := <-watched; b != "{\"value\":\"watch\"}\n" {
+		t.Errorf("unexpected subscription request: %q", b)
+	}
+
+	res, err := c.Ping(ctx, PingRequest{Value: "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Value != "pong" {
+		t.Errorf("expected pong, got %q", res.Value)
+	}
+
+	for _, sub := range []<-chan *Event{events, watch} {
+		for _, expected := range []string{"STOP", "RESUME"} {
+			select {
+			case event := <-sub:
+				if event.Event != expected {
+					t.Errorf("expected event %s, got %s", expected, event.Event)
+				}
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for event")
+			}
+		}
+	}
+
+	// Subscriptions end with the error of the connection once it is lost.
+	server.Close()
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("expected error after connection loss")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for error")
+	}
+
+	if _, ok := <-events; ok {
+		t.Error("expected events to be closed")
+	}
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	c := NewEchoClient(client)
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, _, err := c.SubscribeEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected no event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected events to be closed once the context is canceled")
+	}
+
+	if _, _, err := c.SubscribeEvents(ctx); err == nil {
+		t.Error("expected error for canceled context")
+	}
+}
+`})
+}
+
+func TestGenerateFieldPresence(t *testing.T) {
+	oneof := func(field *descriptorpb.FieldDescriptorProto, index int32) *descriptorpb.FieldDescriptorProto {
+		field.OneofIndex = proto.Int32(index)
+		return field
+	}
+
+	labels := testField("labels", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".echo.Config.LabelsEntry")
+	labels.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+
+	tags := testField("tags", 7, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")
+	tags.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+
+	timeout := oneof(testField("timeout", 6, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""), 1)
+	timeout.Proto3Optional = proto.Bool(true)
+
+	config := testMessage("Config",
+		testField("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+		labels,
+		oneof(testField("path", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""), 0),
+		oneof(testField("url", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""), 0),
+		oneof(testField("ping", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".echo.PingRequest"), 0),
+		timeout,
+		tags,
+	)
+	config.OneofDecl = []*descriptorpb.OneofDescriptorProto{
+		{Name: proto.String("source")},
+		{Name: proto.String("_timeout")},
+	}
+	config.NestedType = []*descriptorpb.DescriptorProto{
+		testMessage("LabelsEntry",
+			testField("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			testField("value", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+		),
+	}
+	config.NestedType[0].Options = &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)}
+
+	f := testEchoFile()
+	f.MessageType = append(f.MessageType, config)
+
+	srcs := generateFiles(t, testOptions(), f)
+
+	assertContains(t, srcs["echo"],
+		"Labels map[string]int64 `json:\"labels\"`",
+		"Path *string `json:\"path,omitempty\"`",
+		"Ping *PingRequest `json:\"ping,omitempty\"`",
+		"Timeout *int64 `json:\"timeout,omitempty\"`",
+		"Tags []string `json:\"tags\"`",
+		"func (m *Config) SourceCase() string",
+		"func (m *Config) SetPing(v PingRequest)",
+	)
+
+	if strings.Contains(srcs["echo"], "LabelsEntry") {
+		t.Error("expected no type for the entries of the map")
+	}
+
+	if strings.Contains(srcs["echo"], "TimeoutCase") {
+		t.Error("expected no case method for the synthetic oneof of the optional field")
+	}
+
+	runGenerated(t, srcs, map[string]string{"echo": `package echo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFieldPresence(t *testing.T) {
+	var config Config
+	if c := config.SourceCase(); c != "" {
+		t.Errorf("expected no case, got %q", c)
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "{\"name\":\"\",\"labels\":null,\"tags\":null}" {
+		t.Errorf("expected unset fields to be omitted, got %s", b)
+	}
+
+	config.SetPath("/tmp")
+	config.SetPing(PingRequest{Value: "ping"})
+
+	if config.Path != nil || config.Ping == nil || config.Ping.Value != "ping" {
+		t.Errorf("expected setting a field of the oneof to clear the others: %+v", config)
+	}
+
+	if c := config.SourceCase(); c != "ping" {
+		t.Errorf("expected case ping, got %q", c)
+	}
+
+	var timeout int64
+	config.Timeout = &timeout
+	config.Labels = map[string]int64{"a": 1}
+
+	b, err = json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "{\"name\":\"\",\"labels\":{\"a\":1},\"ping\":{\"value\":\"ping\"},\"timeout\":0,\"tags\":null}" {
+		t.Errorf("expected set fields to be present, got %s", b)
+	}
+
+	var decoded Config
+	if err := json.Unmarshal([]byte("{\"url\":\"https://example.com\",\"timeout\":5}"), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.SourceCase() != "url" || decoded.Timeout == nil || *decoded.Timeout != 5 {
+		t.Errorf("unexpected decoded message: %+v", decoded)
+	}
+}
+`})
+}
+
+func TestGenerateRequestID(t *testing.T) {
+	opts := testOptions()
+	opts.RequestIDKey = "id"
+
+	srcs := generateFiles(t, opts, testEchoFile())
+
+	assertContains(t, srcs["echo"],
+		"nextID atomic.Uint64",
+		`return c.codec.Set(b, "id", id)`,
+	)
+
+	runGenerated(t, srcs, map[string]string{"echo": `package echo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentCalls(t *testing.T) {
+	client, server := net.Pipe()
+	c := NewEchoClient(client)
+	defer c.Close()
+
+	const calls = 8
+
+	go func() {
+		r := bufio.NewReader(server)
+
+		// Answer all requests in the reverse order of their arrival.
+		var reqs []map[string]any
+		for len(reqs) < calls {
+			b, err := r.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+
+			var req map[string]any
+			if err := json.Unmarshal(b, &req); err != nil {
+				return
+			}
+
+			reqs = append(reqs, req)
+		}
+
+		// A response which matches no pending call is dropped.
+		server.Write([]byte("{\"id\":1000,\"value\":\"stale\"}\n"))
+
+		for i := len(reqs) - 1; i >= 0; i-- {
+			b, _ := json.Marshal(map[string]any{"id": reqs[i]["id"], "value": reqs[i]["value"]})
+			server.Write(append(b, '\n'))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+
+		go func(value string) {
+			defer wg.Done()
+
+			res, err := c.Ping(ctx, PingRequest{Value: value})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if res.Value != value {
+				t.Errorf("expected response %q, got %q", value, res.Value)
+			}
+		}(string(rune('a' + i)))
+	}
+
+	wg.Wait()
+}
+
+func TestPendingCallsFailOnDisconnect(t *testing.T) {
+	client, server := net.Pipe()
+	c := NewEchoClient(client)
+	defer c.Close()
+
+	go func() {
+		bufio.NewReader(server).ReadBytes('\n')
+		server.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := c.Ping(ctx, PingRequest{}); err == nil || ctx.Err() != nil {
+		t.Errorf("expected call to fail with the connection, got %v", err)
+	}
+}
+`})
+}
+
+func TestGenerateCodecs(t *testing.T) {
+	tests := []struct {
+		codec    string
+		expected []string
+	}{
+		{
+			codec:    "jsonl",
+			expected: []string{"codec: EchoJSONLinesCodec{},"},
+		},
+		{
+			codec:    "json-length-prefixed",
+			expected: []string{"codec: EchoLengthPrefixedJSONCodec{},"},
+		},
+		{
+			codec: "msgpack",
+			expected: []string{
+				"codec: EchoMsgpackCodec{},",
+				`"github.com/vmihailenco/msgpack/v5"`,
+				`enc.SetCustomStructTag("json")`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			opts := testOptions()
+			opts.Codec = tt.codec
+
+			srcs := generateFiles(t, opts, testEchoFile())
+			assertContains(t, srcs["echo"], tt.expected...)
+
+			if tt.codec != "msgpack" && strings.Contains(srcs["echo"], "msgpack") {
+				t.Error("expected no MessagePack codec")
+			}
+		})
+	}
+
+	opts := testOptions()
+	opts.Codec = "json-length-prefixed"
+
+	runGenerated(t, generateFiles(t, opts, testEchoFile()), map[string]string{"echo": `package echo
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func serveLengthPrefixed(t *testing.T, conn net.Conn) {
+	var size uint32
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		return
+	}
+
+	b := make([]byte, size)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return
+	}
+
+	if string(b) != "{\"value\":\"ping\"}" {
+		t.Errorf("unexpected request: %q", b)
+	}
+
+	// Messages need not be terminated by a newline.
+	res := []byte("{\"value\":\n\"pong\"}")
+	binary.Write(conn, binary.BigEndian, uint32(len(res)))
+	conn.Write(res)
+}
+
+func TestLengthPrefixed(t *testing.T) {
+	client, server := net.Pipe()
+	c := NewEchoClient(client)
+	defer c.Close()
+
+	go serveLengthPrefixed(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := c.Ping(ctx, PingRequest{Value: "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Value != "pong" {
+		t.Errorf("expected pong, got %q", res.Value)
+	}
+}
+
+func TestWithCodec(t *testing.T) {
+	client, server := net.Pipe()
+	c := NewEchoClient(client, WithEchoClientCodec(EchoJSONLinesCodec{}))
+	defer c.Close()
+
+	go func() {
+		b, _ := bufio.NewReader(server).ReadBytes('\n')
+		if string(b) != "{\"value\":\"ping\"}\n" {
+			t.Errorf("unexpected request: %q", b)
+		}
+
+		server.Write([]byte("{\"value\":\"pong\"}\n"))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := c.Ping(ctx, PingRequest{Value: "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Value != "pong" {
+		t.Errorf("expected pong, got %q", res.Value)
+	}
+}
+`})
+}
+
+func TestGenerateReconnect(t *testing.T) {
+	f := testEchoFile()
+	f.Dependency = append(f.Dependency, "google/protobuf/empty.proto")
+	f.MessageType = append(f.MessageType,
+		testMessage("Event",
+			testField("event", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+		),
+	)
+	f.Service[0].Method = append(f.Service[0].Method,
+		testMethod("Events", ".google.protobuf.Empty", ".echo.Event", true),
+	)
+
+	srcs := generateFiles(t, testOptions(), f)
+
+	assertContains(t, srcs["echo"],
+		"func NewEchoClientFromDialer(dial func() (io.ReadWriteCloser, error), opts ...EchoClientOption) (*EchoClient, error)",
+		"func WithEchoClientRetryPolicy(policy EchoClientRetryPolicy) EchoClientOption",
+		"func WithEchoClientOnConnect(hook func(*EchoClient) error) EchoClientOption",
+	)
+
+	runGenerated(t, srcs, map[string]string{"echo": `package echo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var policy = EchoClientRetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     time.Millisecond,
+}
+
+func TestReconnect(t *testing.T) {
+	var dials, connects atomic.Int32
+	servers := make(chan net.Conn, 2)
+
+	dial := func() (io.ReadWriteCloser, error) {
+		// The first attempt fails and is retried.
+		if dials.Add(1) == 1 {
+			return nil, errors.New("refused")
+		}
+
+		client, server := net.Pipe()
+		servers <- server
+		return client, nil
+	}
+
+	c, err := NewEchoClientFromDialer(dial,
+		WithEchoClientRetryPolicy(policy),
+		WithEchoClientOnConnect(func(*EchoClient) error {
+			connects.Add(1)
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, _, err := c.SubscribeEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first peer emits an event and goes away.
+	server := <-servers
+	server.Write([]byte("{\"event\":\"first\"}\n"))
+	server.Close()
+
+	// The subscription survives the restart of the peer.
+	server = <-servers
+	defer server.Close()
+
+	go func() {
+		r := bufio.NewReader(server)
+		if _, err := r.ReadBytes('\n'); err != nil {
+			return
+		}
+
+		server.Write([]byte("{\"value\":\"pong\"}\n"))
+		server.Write([]byte("{\"event\":\"second\"}\n"))
+	}()
+
+	res, err := c.Ping(ctx, PingRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Value != "pong" {
+		t.Errorf("expected pong, got %q", res.Value)
+	}
+
+	for _, expected := range []string{"first", "second"} {
+		select {
+		case event := <-events:
+			if event.Event != expected {
+				t.Errorf("expected event %s, got %s", expected, event.Event)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for event %s", expected)
+		}
+	}
+
+	if n := dials.Load(); n != 3 {
+		t.Errorf("expected 3 dial attempts, got %d", n)
+	}
+
+	// The hook of the reconnection runs asynchronously.
+	for connects.Load() != 2 && ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := connects.Load(); n != 2 {
+		t.Errorf("expected 2 connections, got %d", n)
+	}
+}
+
+func TestReconnectExhausted(t *testing.T) {
+	var dials atomic.Int32
+
+	dial := func() (io.ReadWriteCloser, error) {
+		dials.Add(1)
+		return nil, errors.New("refused")
+	}
+
+	if _, err := NewEchoClientFromDialer(dial, WithEchoClientRetryPolicy(policy)); err == nil {
+		t.Fatal("expected error once all attempts failed")
+	}
+
+	if n := dials.Load(); n != int32(policy.MaxAttempts) {
+		t.Errorf("expected %d dial attempts, got %d", policy.MaxAttempts, n)
+	}
+}
+
+func TestReconnectOnConnectFails(t *testing.T) {
+	dial := func() (io.ReadWriteCloser, error) {
+		client, _ := net.Pipe()
+		return client, nil
+	}
+
+	_, err := NewEchoClientFromDialer(dial, WithEchoClientOnConnect(func(*EchoClient) error {
+		return errors.New("handshake failed")
+	}))
+	if err == nil {
+		t.Fatal("expected error of the hook")
+	}
+}
+
+func TestCloseStopsReconnecting(t *testing.T) {
+	var dials atomic.Int32
+	servers := make(chan net.Conn, 1)
+
+	dial := func() (io.ReadWriteCloser, error) {
+		if dials.Add(1) > 1 {
+			return nil, errors.New("refused")
+		}
+
+		client, server := net.Pipe()
+		servers <- server
+		return client, nil
+	}
+
+	c, err := NewEchoClientFromDialer(dial, WithEchoClientRetryPolicy(EchoClientRetryPolicy{
+		Backoff: time.Hour,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Losing the connection makes the client wait before dialing again, which
+	// is interrupted by closing it.
+	(<-servers).Close()
+
+	closed := make(chan error)
+	go func() {
+		closed <- c.Close()
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected client to close")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := c.Ping(ctx, PingRequest{}); err == nil {
+		t.Error("expected call on closed client to fail")
+	}
+}
+`})
+}
+
+// testErrorFile returns the echo file in which the messages with the provided
+// names carry the "error" option with the key "error".
+func testErrorFile(names ...string) *descriptorpb.FileDescriptorProto {
+	f := testEchoFile()
+	f.Dependency = append(f.Dependency, "google/protobuf/descriptor.proto")
+	f.Extension = []*descriptorpb.FieldDescriptorProto{{
+		Name:     proto.String("error"),
+		Number:   proto.Int32(51003),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		Extendee: proto.String(".google.protobuf.MessageOptions"),
+	}}
+
+	for _, name := range names {
+		options := &descriptorpb.MessageOptions{}
+		options.ProtoReflect().SetUnknown(protowire.AppendString(protowire.AppendTag(nil, 51003, protowire.BytesType), "error"))
+
+		msg := testMessage(name,
+			testField("class", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			testField("desc", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+		)
+		msg.Options = options
+
+		f.MessageType = append(f.MessageType, msg)
+	}
+
+	return f
+}
+
+func TestGenerateErrorResponses(t *testing.T) {
+	srcs := generateFiles(t, testOptions(), testErrorFile("Error"))
+
+	assertContains(t, srcs["echo"],
+		"func (m *Error) Error() string",
+		"func (c *EchoClient) responseError(b []byte) error",
+		`ok, err := c.codec.Lookup(b, "error", &res)`,
+	)
+
+	if srcs := generateFiles(t, testOptions(), testEchoFile()); strings.Contains(srcs["echo"], "responseError") {
+		t.Error("expected no error decoding without a message with the error option")
+	}
+
+	runGenerated(t, srcs, map[string]string{"echo": `package echo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestErrorResponse(t *testing.T) {
+	client, server := net.Pipe()
+	c := NewEchoClient(client)
+	defer c.Close()
+
+	go func() {
+		r := bufio.NewReader(server)
+
+		r.ReadBytes('\n')
+		server.Write([]byte("{\"error\":{\"class\":\"GenericError\",\"desc\":\"boom\"}}\n"))
+
+		r.ReadBytes('\n')
+		server.Write([]byte("{\"error\":{\"desc\":\"boom\"}}\n"))
+
+		r.ReadBytes('\n')
+		server.Write([]byte("{\"value\":\"pong\"}\n"))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := c.Ping(ctx, PingRequest{})
+
+	var res *Error
+	if !errors.As(err, &res) {
+		t.Fatalf("expected *Error, got %T: %v", err, err)
+	}
+
+	if res.Class != "GenericError" || err.Error() != "GenericError: boom" {
+		t.Errorf("unexpected error: %+v: %v", res, err)
+	}
+
+	if _, err := c.Ping(ctx, PingRequest{}); err == nil || err.Error() != "boom" {
+		t.Errorf("expected error without class, got %v", err)
+	}
+
+	if res, err := c.Ping(ctx, PingRequest{}); err != nil || res.Value != "pong" {
+		t.Errorf("expected successful response, got %v, %v", res, err)
+	}
+}
+`})
+}
+
+func TestGenerateMultipleErrorMessages(t *testing.T) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"echo.proto"},
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			testErrorFile("Error", "OtherError"),
+		},
+	}
+
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extTypes = new(protoregistry.Types)
+
+	if err := generate(gen, testOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := gen.Response(); res.Error == nil || !strings.Contains(res.GetError(), "multiple error messages") {
+		t.Errorf("expected error for multiple error messages, got %q", res.GetError())
+	}
+}
+
+func TestGenerateMocks(t *testing.T) {
+	f := testEchoFile()
+	f.Dependency = append(f.Dependency, "google/protobuf/empty.proto")
+	f.MessageType = append(f.MessageType,
+		testMessage("Event",
+			testField("event", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+		),
+	)
+	f.Service[0].Method = append(f.Service[0].Method,
+		testMethod("Notify", ".echo.PingRequest", ".google.protobuf.Empty", false),
+		testMethod("Events", ".google.protobuf.Empty", ".echo.Event", true),
+	)
+
+	if srcs := generateFiles(t, testOptions(), f); strings.Contains(srcs["echo"], "EchoMock") {
+		t.Error("expected no mock unless enabled")
+	}
+
+	opts := testOptions()
+	opts.EmitMocks = true
+
+	srcs := generateFiles(t, opts, f)
+
+	assertContains(t, srcs["echo"],
+		"type Echo interface {",
+		"Ping(ctx context.Context, req PingRequest) (*PingResponse, error)",
+		"Notify(ctx context.Context, req PingRequest) error",
+		"SubscribeEvents(ctx context.Context) (<-chan *Event, <-chan error, error)",
+		"func NewEchoMock() *EchoMock",
+	)
+
+	runGenerated(t, srcs, map[string]string{"echo": `package echo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMock(t *testing.T) {
+	m := NewEchoMock()
+
+	var echo Echo = m
+	ctx := context.Background()
+
+	// Calls are answered with the zero value of their response by default.
+	if res, err := echo.Ping(ctx, PingRequest{Value: "a"}); err != nil || res == nil || res.Value != "" {
+		t.Errorf("expected zero response, got %v, %v", res, err)
+	}
+
+	m.ReturnPing(&PingResponse{Value: "pong"}, nil)
+
+	if res, err := echo.Ping(ctx, PingRequest{Value: "b"}); err != nil || res.Value != "pong" {
+		t.Errorf("expected canned response, got %v, %v", res, err)
+	}
+
+	failed := errors.New("failed")
+	m.NotifyFunc = func(_ context.Context, req PingRequest) error {
+		return failed
+	}
+
+	if err := echo.Notify(ctx, PingRequest{Value: "c"}); !errors.Is(err, failed) {
+		t.Errorf("expected error of the function, got %v", err)
+	}
+
+	if err := echo.Close(); err != nil || !m.Closed() {
+		t.Error("expected mock to be closed")
+	}
+
+	var methods []string
+	for _, call := range m.Calls() {
+		methods = append(methods, call.Method)
+	}
+
+	if len(methods) != 4 || methods[0] != "Ping" || methods[2] != "Notify" || methods[3] != "Close" {
+		t.Errorf("unexpected calls: %v", methods)
+	}
+
+	reqs := m.CallsTo("Ping")
+	if len(reqs) != 2 || reqs[1].(PingRequest).Value != "b" {
+		t.Errorf("unexpected requests: %v", reqs)
+	}
+}
+
+func TestMockSubscribe(t *testing.T) {
+	m := NewEchoMock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, errs, err := m.SubscribeEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go m.SendEvents(&Event{Event: "STOP"})
+
+	select {
+	case event := <-events:
+		if event.Event != "STOP" {
+			t.Errorf("expected event STOP, got %s", event.Event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	cancel()
+
+	if _, ok := <-events; ok {
+		t.Error("expected events to be closed")
+	}
+
+	if _, ok := <-errs; ok {
+		t.Error("expected errors to be closed")
+	}
+
+	// Messages sent without subscribers are dropped.
+	m.SendEvents(&Event{})
+
+	if reqs := m.CallsTo("SubscribeEvents"); len(reqs) != 1 {
+		t.Errorf("expected one subscription, got %d", len(reqs))
+	}
+}
+`})
+}
+
+func TestGenerateImports(t *testing.T) {
+	types := testFile("types.proto", "types")
+	types.MessageType = []*descriptorpb.DescriptorProto{
+		testMessage("Resource",
+			testField("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+		),
+	}
+	types.EnumType = []*descriptorpb.EnumDescriptorProto{{
+		Name: proto.String("Status"),
+		Value: []*descriptorpb.EnumValueDescriptorProto{
+			{Name: proto.String("ok"), Number: proto.Int32(0)},
+			{Name: proto.String("failed"), Number: proto.Int32(1)},
+		},
+	}}
+
+	// The name of the Go package collides with a package imported by clients.
+	timeFile := testFile("time.proto", "time")
+	timeFile.MessageType = []*descriptorpb.DescriptorProto{
+		testMessage("Duration",
+			testField("seconds", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+		),
+	}
+
+	machine := testMessage("Machine",
+		testField("resource", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".types.Resource"),
+		testField("status", 2, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".types.Status"),
+		testField("state", 3, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".echo.Machine.State"),
+		testField("uptime", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".time.Duration"),
+	)
+	machine.EnumType = []*descriptorpb.EnumDescriptorProto{{
+		Name: proto.String("State"),
+		Value: []*descriptorpb.EnumValueDescriptorProto{
+			{Name: proto.String("stopped"), Number: proto.Int32(0)},
+			{Name: proto.String("running"), Number: proto.Int32(1)},
+		},
+	}}
+
+	echo := testEchoFile()
+	echo.Dependency = append(echo.Dependency, "types.proto", "time.proto")
+	echo.MessageType = append(echo.MessageType, machine)
+	echo.Service[0].Method = append(echo.Service[0].Method,
+		testMethod("Inspect", ".types.Resource", ".echo.Machine", false),
+	)
+
+	srcs := generateFiles(t, testOptions(), types, timeFile, echo)
+
+	assertContains(t, srcs["echo"],
+		`time1 "example.com/test/time"`,
+		`types "example.com/test/types"`,
+		"Resource types.Resource `json:\"resource\"`",
+		"Status types.Status `json:\"status\"`",
+		"State MachineState `json:\"state\"`",
+		"Uptime time1.Duration `json:\"uptime\"`",
+		`Machine_running = MachineState("running")`,
+		"func (c *EchoClient) Inspect(ctx context.Context, req types.Resource) (*Machine, error)",
+	)
+
+	assertContains(t, srcs["types"], `ok = Status("ok")`)
+
+	runGenerated(t, srcs, map[string]string{"echo": `package echo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"example.com/test/types"
+)
+
+func TestImports(t *testing.T) {
+	var machine Machine
+	if err := json.Unmarshal([]byte("{\"resource\":{\"name\":\"vm\"},\"status\":\"failed\",\"state\":\"running\",\"uptime\":{\"seconds\":3}}"), &machine); err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Resource.Name != "vm" || machine.Status != types.Status("failed") || machine.State != Machine_running || machine.Uptime.Seconds != 3 {
+		t.Errorf("unexpected machine: %+v", machine)
+	}
+
+	if states := MachineStates(); len(states) != 2 || states[0] != Machine_stopped {
+		t.Errorf("unexpected states: %v", states)
+	}
+}
+`})
+}
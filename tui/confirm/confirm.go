@@ -14,6 +14,10 @@ import (
 // NewConfirm is a utility method used in a CLI context to prompt the user with
 // a yes/no question.
 func NewConfirm(question string) (bool, error) {
+	if !tui.IsInteractive() {
+		return false, tui.ErrNonInteractive
+	}
+
 	input := confirmation.New(
 		tui.TextWhiteBgBlue("[?]")+" "+
 			question,
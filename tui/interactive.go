@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package tui
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/term"
+
+	"kraftkit.sh/iostreams"
+)
+
+// ErrNonInteractive is returned by prompts which cannot be displayed because
+// the user is unable to answer them.
+var ErrNonInteractive = errors.New("cannot prompt in a non-interactive environment")
+
+// IsInteractive returns whether prompts can be displayed, which requires both
+// standard input and output to be terminals outside of continuous integration
+// environments.
+func IsInteractive() bool {
+	return !iostreams.EnvCI() &&
+		term.IsTerminal(int(os.Stdin.Fd())) &&
+		term.IsTerminal(int(os.Stdout.Fd()))
+}
@@ -20,6 +20,10 @@ var selectedText = lipgloss.NewStyle().
 // MultiSelect is a utility method used in a CLI context to prompt the
 // user given a slice of options based on the generic type.
 func MultiSelect[T fmt.Stringer](question string, options ...T) ([]T, error) {
+	if !tui.IsInteractive() {
+		return nil, tui.ErrNonInteractive
+	}
+
 	mapped := make(map[string]T)
 	items := make([]item, 0, len(options))
 	for _, option := range options {
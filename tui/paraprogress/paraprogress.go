@@ -77,7 +77,20 @@ func NewParaProgress(ctx context.Context, processes []*Process, opts ...ParaProg
 		process.NameWidth = maxNameLen
 		process.timeout = md.timeout
 
-		if md.norender {
+		if emitter := tui.ProgressEmitterFromContext(ctx); md.norender && emitter != nil {
+			// Emit the output of the process as log events of its task.
+			process.emitID = emitter.NextID()
+
+			logger, err := kamino.Clone(log.G(ctx),
+				kamino.WithZeroUnexported(),
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			logger.Out = emitter.LogWriter(process.emitID, process.Name)
+			process.ctx = log.WithLogger(ctx, logger)
+		} else if md.norender {
 			process.ctx = ctx
 		} else {
 			pctx := ctx
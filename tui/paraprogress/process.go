@@ -18,7 +18,6 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/reflow/indent"
 
-	"kraftkit.sh/log"
 	"kraftkit.sh/tui"
 	"kraftkit.sh/utils"
 )
@@ -79,6 +78,8 @@ type Process struct {
 	norender    bool
 	ctx         context.Context
 	timeout     time.Duration
+	emitID      string
+	emitPercent int
 
 	Name      string
 	NameWidth int
@@ -128,15 +129,25 @@ func (p *Process) Start() tea.Cmd {
 		p := p // golang closures
 
 		if p.norender {
-			log.G(p.ctx).Info(p.Name)
+			p.report(tui.ProgressStarted, 0, nil)
 		}
 
+		started := time.Now()
+
 		err := p.processFunc(p.ctx, p.onProgress)
 		p.Status = StatusSuccess
 		if err != nil {
 			p.Status = StatusFailed
 		}
 
+		if p.norender {
+			if err != nil {
+				p.report(tui.ProgressFailed, time.Since(started), err)
+			} else {
+				p.report(tui.ProgressSucceeded, time.Since(started), nil)
+			}
+		}
+
 		if tprog != nil {
 			tprog.Send(StatusMsg{
 				ID:     p.id,
@@ -151,10 +162,53 @@ func (p *Process) Start() tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// report outputs the event of the process when it is not rendered, either as a
+// raw JSON event or as a line of the log.
+func (p *Process) report(event tui.ProgressEvent, elapsed time.Duration, err error) {
+	emitter := tui.ProgressEmitterFromContext(p.ctx)
+	if emitter == nil {
+		tui.LogProgress(p.ctx, event, p.Name, elapsed)
+		return
+	}
+
+	record := tui.ProgressRecord{
+		Event: event,
+		ID:    p.emitID,
+		Task:  p.Name,
+	}
+
+	if event != tui.ProgressStarted {
+		record.Elapsed = elapsed.Seconds()
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	emitter.Emit(record)
+}
+
 // onProgress is called to dynamically inject ProgressMsg into the bubbletea
 // runtime
-func (p Process) onProgress(progress float64) {
-	if tprog == nil || progress < 0 {
+func (p *Process) onProgress(progress float64) {
+	if progress < 0 {
+		return
+	}
+
+	// Emit whole percentages only, since progress is reported frequently.
+	if emitter := tui.ProgressEmitterFromContext(p.ctx); p.norender && emitter != nil {
+		if percent := int(progress * 100); percent != p.emitPercent {
+			p.emitPercent = percent
+			emitter.Emit(tui.ProgressRecord{
+				Event:    tui.ProgressUpdated,
+				ID:       p.emitID,
+				Task:     p.Name,
+				Progress: progress,
+			})
+		}
+	}
+
+	if tprog == nil {
 		return
 	}
 
@@ -6,9 +6,11 @@ package processtree
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/LastPossum/kamino"
@@ -20,11 +22,21 @@ import (
 
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
+	"kraftkit.sh/tui"
 )
 
 type (
 	SpinnerProcess func(ctx context.Context) error
 	processExitMsg *ProcessTreeItem
+
+	// spawnMsg adds children to an item whose process is running.
+	spawnMsg struct {
+		parent   *ProcessTreeItem
+		children []*ProcessTreeItem
+	}
+
+	// itemContextKey is the key of the item in the context of its process.
+	itemContextKey struct{}
 )
 
 type SpinnerProcessStatus uint
@@ -42,6 +54,7 @@ const (
 const (
 	INDENTS = 4
 	LOGLEN  = 5
+	LOGPANE = 10
 )
 
 var tprog *tea.Program
@@ -62,6 +75,13 @@ type ProcessTreeItem struct {
 	err       error
 	ellipsis  string
 	hideError bool
+	id        string
+	parent    *ProcessTreeItem
+	tree      *ProcessTree
+	started   bool
+	done      chan struct{}
+	doneOnce  sync.Once
+	logsMu    sync.Mutex
 }
 
 type ProcessTree struct {
@@ -84,6 +104,16 @@ type ProcessTree struct {
 	hide      bool
 	hideError bool
 	timeout   time.Duration
+	program   *tea.Program
+	exited    chan struct{}
+
+	// interactive is set when keys are read from a terminal, which allows
+	// selecting an item and showing its logs in a pane.
+	interactive bool
+	height      int
+	selected    *ProcessTreeItem
+	expanded    bool
+	scroll      int
 }
 
 func NewProcessTree(ctx context.Context, opts []ProcessTreeOption, tree ...*ProcessTreeItem) (*ProcessTree, error) {
@@ -97,6 +127,7 @@ func NewProcessTree(ctx context.Context, opts []ProcessTreeOption, tree ...*Proc
 		timer:     stopwatch.NewWithInterval(time.Millisecond * 100),
 		channel:   make(chan *ProcessTreeItem),
 		errChan:   make(chan error),
+		exited:    make(chan struct{}),
 		finished:  0,
 		oldOut:    iostreams.G(ctx).Out,
 		hideError: false,
@@ -108,58 +139,159 @@ func NewProcessTree(ctx context.Context, opts []ProcessTreeOption, tree ...*Proc
 		}
 	}
 
+	setParents(tree, nil)
+
 	total := 0
 
-	_ = pt.traverseTreeAndCall(tree, func(item *ProcessTreeItem) error {
+	if err := pt.traverseTreeAndCall(tree, func(item *ProcessTreeItem) error {
 		total++
-		item.norender = pt.norender
-		item.timeout = pt.timeout
-		item.hideError = pt.hideError
+		return pt.prepare(item)
+	}); err != nil {
+		return nil, err
+	}
 
-		if pt.norender {
-			item.ctx = pt.ctx
-			return nil
-		}
+	pt.total = total
 
-		ictx := pt.ctx
+	return pt, nil
+}
 
-		logger, err := kamino.Clone(log.G(ictx),
+// setParents links the items, and their children, to the provided parent.
+func setParents(items []*ProcessTreeItem, parent *ProcessTreeItem) {
+	for _, item := range items {
+		item.parent = parent
+		setParents(item.children, item)
+	}
+}
+
+// prepare sets up the context in which the process of the item runs, which
+// captures its output as the logs of the item.
+func (pt *ProcessTree) prepare(item *ProcessTreeItem) error {
+	item.norender = pt.norender
+	item.timeout = pt.timeout
+	item.hideError = pt.hideError
+	item.tree = pt
+
+	emitter := tui.ProgressEmitterFromContext(pt.ctx)
+	if emitter != nil && item.id == "" {
+		item.id = emitter.NextID()
+	}
+
+	if pt.norender && emitter == nil {
+		item.ctx = context.WithValue(pt.ctx, itemContextKey{}, item)
+		return nil
+	}
+
+	// Emit the output of the process as log events of its item.
+	if pt.norender {
+		logger, err := kamino.Clone(log.G(pt.ctx),
 			kamino.WithZeroUnexported(),
 		)
 		if err != nil {
 			return err
 		}
 
-		logger.Out = item
+		logger.Out = emitter.LogWriter(item.id, item.textLeft)
 
-		if formatter, ok := logger.Formatter.(*log.TextFormatter); ok {
-			formatter.ForceColors = termenv.DefaultOutput().ColorProfile() != termenv.Ascii
-			formatter.ForceFormatting = true
-			logger.Formatter = formatter
-		}
+		item.ctx = context.WithValue(log.WithLogger(pt.ctx, logger), itemContextKey{}, item)
+		return nil
+	}
 
-		ictx = log.WithLogger(ictx, logger)
+	ictx := pt.ctx
 
-		ios, err := kamino.Clone(iostreams.G(ictx),
-			kamino.WithZeroUnexported(),
-		)
-		if err != nil {
-			return err
-		}
+	logger, err := kamino.Clone(log.G(ictx),
+		kamino.WithZeroUnexported(),
+	)
+	if err != nil {
+		return err
+	}
 
-		ios.Out = iostreams.NewNoTTYWriter(item, iostreams.G(ctx).Out.Fd())
-		ios.ErrOut = item
-		ios.In = iostreams.G(ctx).In
-		ictx = iostreams.WithIOStreams(ictx, ios)
+	logger.Out = item
 
-		item.ctx = ictx
+	if formatter, ok := logger.Formatter.(*log.TextFormatter); ok {
+		formatter.ForceColors = termenv.DefaultOutput().ColorProfile() != termenv.Ascii
+		formatter.ForceFormatting = true
+		logger.Formatter = formatter
+	}
 
-		return nil
+	ictx = log.WithLogger(ictx, logger)
+
+	ios, err := kamino.Clone(iostreams.G(ictx),
+		kamino.WithZeroUnexported(),
+	)
+	if err != nil {
+		return err
+	}
+
+	ios.Out = iostreams.NewNoTTYWriter(item, iostreams.G(pt.ctx).Out.Fd())
+	ios.ErrOut = item
+	ios.In = iostreams.G(pt.ctx).In
+	ictx = iostreams.WithIOStreams(ictx, ios)
+
+	item.ctx = context.WithValue(ictx, itemContextKey{}, item)
+
+	return nil
+}
+
+// RunChildren runs the provided items as sub-tasks of the item whose process
+// is running with the provided context, such that sub-tasks which are only
+// discovered whilst a process runs, e.g. the services of a compose project or
+// the targets of a build, can be nested arbitrarily deep.  The sub-tasks are
+// run in parallel if the tree is and otherwise one after another.  It blocks
+// until all of them have finished and returns the errors of those which
+// failed.
+//
+// Outside of a process tree, the processes of the items are run directly one
+// after another.
+func RunChildren(ctx context.Context, children ...*ProcessTreeItem) error {
+	parent, ok := ctx.Value(itemContextKey{}).(*ProcessTreeItem)
+	if !ok || parent.tree == nil || parent.tree.program == nil {
+		var errs []error
+		for _, child := range children {
+			errs = append(errs, runDetached(ctx, child))
+		}
+
+		return errors.Join(errs...)
+	}
+
+	pt := parent.tree
+	pt.program.Send(spawnMsg{
+		parent:   parent,
+		children: children,
 	})
 
-	pt.total = total
+	var errs []error
 
-	return pt, nil
+	for _, child := range children {
+		select {
+		case <-child.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-pt.exited:
+			return fmt.Errorf("process tree exited before %s finished", child.textLeft)
+		}
+
+		if child.status == StatusFailed || child.status == StatusFailedChild {
+			if child.err != nil {
+				errs = append(errs, child.err)
+			} else {
+				errs = append(errs, fmt.Errorf("%s failed", child.textLeft))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runDetached runs the processes of the item and its children, children first,
+// outside of a process tree.
+func runDetached(ctx context.Context, item *ProcessTreeItem) error {
+	for _, child := range item.children {
+		if err := runDetached(ctx, child); err != nil {
+			return err
+		}
+	}
+
+	return item.process(ctx)
 }
 
 func NewProcessTreeItem(textLeft, textRight string, process SpinnerProcess, children ...*ProcessTreeItem) *ProcessTreeItem {
@@ -172,9 +304,46 @@ func NewProcessTreeItem(textLeft, textRight string, process SpinnerProcess, chil
 		timer:     stopwatch.NewWithInterval(time.Millisecond * 100),
 		logChan:   make(chan *ProcessTreeItem),
 		spinner:   spinner.New(),
+		done:      make(chan struct{}),
+	}
+}
+
+// finish marks the item as finished for those waiting on it.
+func (pti *ProcessTreeItem) finish() {
+	pti.doneOnce.Do(func() {
+		close(pti.done)
+	})
+}
+
+// finished returns whether the item has finished.
+func (pti *ProcessTreeItem) finished() bool {
+	select {
+	case <-pti.done:
+		return true
+	default:
+		return false
 	}
 }
 
+// isAncestorOf returns whether the item is an ancestor of the other item.
+func (pti *ProcessTreeItem) isAncestorOf(other *ProcessTreeItem) bool {
+	for parent := other.parent; parent != nil; parent = parent.parent {
+		if parent == pti {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logLines returns a snapshot of the logs of the item.
+func (pti *ProcessTreeItem) logLines() []string {
+	pti.logsMu.Lock()
+	defer pti.logsMu.Unlock()
+
+	return pti.logs[:len(pti.logs):len(pti.logs)]
+}
+
 // Write implements `io.Writer` so we can correctly direct the output from tree
 // process to an inline fancy logger
 func (pti *ProcessTreeItem) Write(p []byte) (int, error) {
@@ -184,7 +353,9 @@ func (pti *ProcessTreeItem) Write(p []byte) (int, error) {
 	// Split all lines up so we can individually append them
 	lines := strings.Split(strings.ReplaceAll(line, "\r\n", "\n"), "\n")
 
+	pti.logsMu.Lock()
 	pti.logs = append(pti.logs, lines...)
+	pti.logsMu.Unlock()
 
 	return len(p), nil
 }
@@ -204,12 +375,16 @@ func (pt *ProcessTree) Start() error {
 
 	if iostreams.G(pt.ctx).IsStdinTTY() {
 		teaOpts = append(teaOpts, tea.WithInput(iostreams.G(pt.ctx).In))
+		pt.interactive = !pt.norender
 	} else {
 		teaOpts = append(teaOpts, tea.WithInput(nil))
 	}
 
-	// Restore the old output for the IOStreams which is manipulated per process.
+	// Restore the old output for the IOStreams which is manipulated per process
+	// and release those waiting on sub-tasks.
 	defer func() {
+		close(pt.exited)
+
 		iostreams.G(pt.ctx).Out = pt.oldOut
 		log.G(pt.ctx).Out = iostreams.G(pt.ctx).Out
 	}()
@@ -220,10 +395,11 @@ func (pt *ProcessTree) Start() error {
 		// Set this super early (even before bubbletea), as fast exiting processes
 		// may not have received the window size update and therefore pt.width is
 		// set to zero.
-		pt.width, _, _ = term.GetSize(int(os.Stdout.Fd()))
+		pt.width, pt.height, _ = term.GetSize(int(os.Stdout.Fd()))
 	}
 
 	tprog = tea.NewProgram(pt, teaOpts...)
+	pt.program = tprog
 
 	if _, err := tprog.Run(); err != nil {
 		return err
@@ -241,7 +417,7 @@ func (pt *ProcessTree) Init() tea.Cmd {
 	}
 
 	// Start all child processes
-	children := pt.getNextReadyChildren(pt.tree)
+	children := pt.nextReadyChildren()
 	for _, pti := range children {
 		pti := pti
 		pti.timeout = pt.timeout
@@ -285,8 +461,10 @@ func (pt ProcessTree) getNextReadyChildren(tree []*ProcessTreeItem) []*ProcessTr
 		}
 
 		// Only start the parent process if all children have succeeded or if there
-		// no children and the status is pending
+		// no children and the status is pending.  Items which spawned children
+		// whilst running have already been started.
 		if len(subprocesses) == 0 &&
+			!item.started &&
 			failed == 0 &&
 			(pt.parallel || (len(items) == 0 && !pt.parallel)) &&
 			completed == len(item.children) &&
@@ -298,6 +476,36 @@ func (pt ProcessTree) getNextReadyChildren(tree []*ProcessTreeItem) []*ProcessTr
 	return items
 }
 
+// nextReadyChildren returns the items whose processes can be started next and
+// marks them as started.  When the tree is not parallel, items are only
+// started once every other started item has finished or is waiting on its
+// sub-tasks.
+func (pt *ProcessTree) nextReadyChildren() []*ProcessTreeItem {
+	var ready []*ProcessTreeItem
+
+	for _, item := range pt.getNextReadyChildren(pt.tree) {
+		if !pt.parallel {
+			busy := false
+
+			_ = pt.traverseTreeAndCall(pt.tree, func(other *ProcessTreeItem) error {
+				if other.started && !other.finished() && !other.isAncestorOf(item) {
+					busy = true
+				}
+				return nil
+			})
+
+			if busy || len(ready) > 0 {
+				continue
+			}
+		}
+
+		item.started = true
+		ready = append(ready, item)
+	}
+
+	return ready
+}
+
 func (pt *ProcessTree) traverseTreeAndCall(items []*ProcessTreeItem, callback func(*ProcessTreeItem) error) error {
 	for _, child := range items {
 		if len(child.children) > 0 {
@@ -320,20 +528,17 @@ func (pt *ProcessTree) waitForProcessCmd(item *ProcessTreeItem) tea.Cmd {
 		item := item // golang closures
 
 		if pt.norender {
-			txt := item.textLeft
-			if len(item.textRight) > 0 {
-				txt += " (" + item.textRight + ")"
-			}
-
-			log.G(item.ctx).Info(txt)
+			pt.report(item, tui.ProgressStarted, 0)
 		}
 
 		// Set the process to running
 		item.status = StatusRunning
+		started := time.Now()
 
 		if err := item.process(item.ctx); err != nil {
 			log.G(item.ctx).Error(err)
 			item.status = StatusFailed
+			item.err = err
 			pt.err = err
 			if pt.failFast {
 				pt.quitting = true
@@ -342,12 +547,58 @@ func (pt *ProcessTree) waitForProcessCmd(item *ProcessTreeItem) tea.Cmd {
 			item.status = StatusSuccess
 		}
 
+		if pt.norender {
+			if item.status == StatusFailed {
+				pt.report(item, tui.ProgressFailed, time.Since(started))
+			} else {
+				pt.report(item, tui.ProgressSucceeded, time.Since(started))
+			}
+		}
+
+		item.finish()
+
 		pt.channel <- item
 
 		return item.timer.Stop()
 	}
 }
 
+// report outputs the event of the item when the tree is not rendered, either
+// as a raw JSON event or as a line of the log.
+func (pt *ProcessTree) report(item *ProcessTreeItem, event tui.ProgressEvent, elapsed time.Duration) {
+	if emitter := tui.ProgressEmitterFromContext(pt.ctx); emitter != nil {
+		record := tui.ProgressRecord{
+			Event:  event,
+			ID:     item.id,
+			Task:   item.textLeft,
+			Detail: item.textRight,
+		}
+
+		if item.parent != nil {
+			record.Parent = item.parent.id
+		}
+
+		if event != tui.ProgressStarted {
+			record.Elapsed = elapsed.Seconds()
+		}
+
+		if event == tui.ProgressFailed && item.err != nil {
+			record.Error = item.err.Error()
+		}
+
+		emitter.Emit(record)
+
+		return
+	}
+
+	txt := item.textLeft
+	if len(item.textRight) > 0 {
+		txt += " (" + item.textRight + ")"
+	}
+
+	tui.LogProgress(item.ctx, event, txt, elapsed)
+}
+
 func waitForProcessExit(sub chan *ProcessTreeItem) tea.Cmd {
 	return func() tea.Msg {
 		return processExitMsg(<-sub)
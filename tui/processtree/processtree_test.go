@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package processtree
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/tui"
+)
+
+func TestRunChildrenNested(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel=%t", parallel), func(t *testing.T) {
+			ctx := iostreams.WithIOStreams(context.Background(), iostreams.System())
+
+			var mu sync.Mutex
+			var ran []string
+
+			record := func(name string) {
+				mu.Lock()
+				defer mu.Unlock()
+				ran = append(ran, name)
+			}
+
+			leaf := func(name string, err error) *ProcessTreeItem {
+				return NewProcessTreeItem(name, "", func(ctx context.Context) error {
+					record(name)
+					return err
+				})
+			}
+
+			root := NewProcessTreeItem("root", "", func(ctx context.Context) error {
+				err := RunChildren(ctx,
+					leaf("a", nil),
+					NewProcessTreeItem("b", "", func(ctx context.Context) error {
+						return RunChildren(ctx,
+							leaf("b1", nil),
+							leaf("b2", fmt.Errorf("b2 failed")),
+						)
+					}),
+				)
+
+				record("root")
+
+				return err
+			})
+
+			pt, err := NewProcessTree(ctx,
+				[]ProcessTreeOption{
+					WithRenderer(true),
+					IsParallel(parallel),
+				},
+				root,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = pt.Start()
+			if err == nil || !strings.Contains(err.Error(), "b2 failed") {
+				t.Fatalf("expected failure of b2 to propagate, got %v", err)
+			}
+
+			if len(ran) != 4 || ran[len(ran)-1] != "root" {
+				t.Errorf("expected every process to run and root to finish last, got %v", ran)
+			}
+
+			if pt.total != 5 || pt.finished != pt.total {
+				t.Errorf("expected 5 of 5 processes to finish, got %d of %d", pt.finished, pt.total)
+			}
+
+			if b := root.children[1]; len(b.children) != 2 || b.children[0].parent != b {
+				t.Errorf("expected nested children to be attached to their parent")
+			}
+		})
+	}
+}
+
+func TestRunChildrenDetached(t *testing.T) {
+	var ran []string
+
+	err := RunChildren(context.Background(),
+		NewProcessTreeItem("a", "", func(ctx context.Context) error {
+			ran = append(ran, "a")
+			return nil
+		}),
+		NewProcessTreeItem("b", "", func(ctx context.Context) error {
+			ran = append(ran, "b")
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(ran, ",") != "a,b" {
+		t.Errorf("expected processes to run in order, got %v", ran)
+	}
+}
+
+func TestProgressEmitter(t *testing.T) {
+	var out bytes.Buffer
+
+	ctx := iostreams.WithIOStreams(context.Background(), iostreams.System())
+	ctx = tui.WithProgressEmitter(ctx, tui.NewProgressEmitter(&out))
+
+	pt, err := NewProcessTree(ctx,
+		[]ProcessTreeOption{
+			WithRenderer(true),
+		},
+		NewProcessTreeItem("parent", "x86_64/qemu", func(ctx context.Context) error {
+			return RunChildren(ctx,
+				NewProcessTreeItem("child", "", func(ctx context.Context) error {
+					log.G(ctx).Info("hello")
+					return fmt.Errorf("oops")
+				}),
+			)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = pt.Start()
+
+	ids := map[string]string{}
+	var events []string
+
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record tui.ProgressRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("could not parse event %q: %v", line, err)
+		}
+
+		ids[record.Task] = record.ID
+		events = append(events, record.Task+":"+string(record.Event))
+
+		if record.Task == "child" && record.Event != tui.ProgressLogged && record.Parent != ids["parent"] {
+			t.Errorf("expected child to reference its parent, got %q", record.Parent)
+		}
+
+		if record.Task == "parent" && record.Event == tui.ProgressStarted && record.Detail != "x86_64/qemu" {
+			t.Errorf("expected detail of parent, got %q", record.Detail)
+		}
+
+		if record.Task == "child" && record.Event == tui.ProgressFailed && record.Error != "oops" {
+			t.Errorf("expected error of child, got %q", record.Error)
+		}
+	}
+
+	expected := "parent:started,child:started,child:log,child:log,child:failed,parent:log,parent:failed"
+	if strings.Join(events, ",") != expected {
+		t.Errorf("expected events %s, got %s", expected, strings.Join(events, ","))
+	}
+}
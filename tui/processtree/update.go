@@ -5,6 +5,7 @@
 package processtree
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -41,6 +42,10 @@ func (pt *ProcessTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return pt, tea.Quit
 		}
 
+		if pt.interactive {
+			pt.navigate(msg.String())
+		}
+
 	case spinner.TickMsg:
 		_ = pt.traverseTreeAndCall(pt.tree, func(pti *ProcessTreeItem) error {
 			if pti.timeout != 0 && pti.timer.Elapsed() > pti.timeout {
@@ -79,6 +84,17 @@ func (pt *ProcessTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			pt.finished++
 		}
 
+		// Ancestors which were waiting on the failed item to start will never
+		// start, such that they are finished too.
+		if msg.status == StatusFailed {
+			for parent := msg.parent; parent != nil && !parent.started; parent = parent.parent {
+				parent.started = true
+				parent.status = StatusFailedChild
+				parent.finish()
+				pt.finished++
+			}
+		}
+
 		// No more processes then exit
 		if pt.total == pt.finished {
 			pt.quitting = true
@@ -91,7 +107,7 @@ func (pt *ProcessTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return nil
 			})
 
-			children := pt.getNextReadyChildren(pt.tree)
+			children := pt.nextReadyChildren()
 			for _, pti := range children {
 				pti := pti
 				cmds = append(cmds, pt.waitForProcessCmd(pti))
@@ -102,10 +118,121 @@ func (pt *ProcessTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		return pt, tea.Batch(cmds...)
 
+	case spawnMsg:
+		setParents(msg.children, msg.parent)
+		msg.parent.children = append(msg.parent.children, msg.children...)
+
+		_ = pt.traverseTreeAndCall(msg.children, func(pti *ProcessTreeItem) error {
+			pt.total++
+			if err := pt.prepare(pti); err != nil {
+				pti.ctx = context.WithValue(pt.ctx, itemContextKey{}, pti)
+			}
+			return nil
+		})
+
+		for _, pti := range pt.nextReadyChildren() {
+			pti := pti
+			cmds = append(cmds, pt.waitForProcessCmd(pti))
+			cmds = append(cmds, pti.timer.Init())
+		}
+
+		return pt, tea.Batch(cmds...)
+
 	case tea.WindowSizeMsg:
 		pt.width = msg.Width
+		pt.height = msg.Height
 		return pt, nil
 	}
 
 	return pt, tea.Batch(cmds...)
 }
+
+// navigate moves the selection through the items of the tree and shows or
+// scrolls through the logs of the selected item in a pane.
+func (pt *ProcessTree) navigate(key string) {
+	items := pt.visibleItems()
+	if len(items) == 0 {
+		pt.selected = nil
+		pt.expanded = false
+		return
+	}
+
+	current := -1
+	for i, item := range items {
+		if item == pt.selected {
+			current = i
+			break
+		}
+	}
+
+	switch key {
+	case "up", "k":
+		if current <= 0 {
+			current = len(items)
+		}
+		pt.selected = items[current-1]
+		pt.scroll = 0
+
+	case "down", "j":
+		pt.selected = items[(current+1)%len(items)]
+		pt.scroll = 0
+
+	case "enter", "l":
+		if current < 0 {
+			pt.selected = items[0]
+		}
+		pt.expanded = !pt.expanded
+		pt.scroll = 0
+
+	case "esc", "h":
+		pt.expanded = false
+		pt.scroll = 0
+
+	case "pgup", "ctrl+u":
+		if pt.expanded {
+			pt.scroll = min(pt.scroll+pt.paneHeight(), max(len(pt.selected.logLines())-pt.paneHeight(), 0))
+		}
+
+	case "pgdown", "ctrl+d":
+		if pt.expanded {
+			pt.scroll = max(pt.scroll-pt.paneHeight(), 0)
+		}
+	}
+}
+
+// visibleItems returns the items of the tree in the order in which they are
+// displayed.
+func (pt *ProcessTree) visibleItems() []*ProcessTreeItem {
+	var items []*ProcessTreeItem
+
+	var visit func([]*ProcessTreeItem)
+	visit = func(tree []*ProcessTreeItem) {
+		for _, pti := range tree {
+			if pt.hidden(pti) {
+				continue
+			}
+
+			items = append(items, pti)
+			visit(pti.children)
+		}
+	}
+
+	visit(pt.tree)
+
+	return items
+}
+
+// hidden returns whether the item, and thereby its children, is not displayed.
+func (pt *ProcessTree) hidden(pti *ProcessTreeItem) bool {
+	return pti.status == StatusSuccess && pt.hide ||
+		((pti.status == StatusFailed || pti.status == StatusFailedChild) && pt.hideError)
+}
+
+// paneHeight returns the number of lines of logs shown in the pane.
+func (pt *ProcessTree) paneHeight() int {
+	if pt.height > 0 && pt.height/3 > LOGPANE {
+		return pt.height / 3
+	}
+
+	return LOGPANE
+}
@@ -6,6 +6,7 @@ package processtree
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/reflow/indent"
@@ -49,15 +50,55 @@ func (pt ProcessTree) View() string {
 		s += " no render! \n"
 	}
 
+	if pt.interactive && pt.expanded && pt.selected != nil {
+		s += pt.printLogsPane(pt.selected)
+	}
+
 	if !pt.quitting {
-		s += tui.TextLightGray("ctrl+c to cancel\n")
+		switch {
+		case !pt.interactive:
+			s += tui.TextLightGray("ctrl+c to cancel\n")
+		case pt.expanded:
+			s += tui.TextLightGray("↑/↓ to select, pgup/pgdown to scroll, esc to hide logs, ctrl+c to cancel\n")
+		default:
+			s += tui.TextLightGray("↑/↓ to select, enter to show logs, ctrl+c to cancel\n")
+		}
 	}
 
 	return s
 }
 
+// printLogsPane renders the scrollback of the logs of the item in a pane.
+func (pt ProcessTree) printLogsPane(pti *ProcessTreeItem) string {
+	logs := pti.logLines()
+	height := pt.paneHeight()
+
+	end := max(len(logs)-pt.scroll, 0)
+	start := max(end-height, 0)
+
+	title := "logs of " + pti.textLeft
+	if len(logs) > 0 {
+		title += " (" + strconv.Itoa(start+1) + "-" + strconv.Itoa(end) + "/" + strconv.Itoa(len(logs)) + ")"
+	}
+
+	lines := logs[start:end]
+	if len(lines) == 0 {
+		lines = []string{tui.TextLightGray("no logs")}
+	}
+
+	style := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), true, false).
+		BorderForeground(lipgloss.Color("245"))
+
+	if pt.width > 0 {
+		style = style.Width(pt.width)
+	}
+
+	return tui.TextTitle(title) + "\n" + style.Render(strings.Join(lines, "\n")) + "\n"
+}
+
 func (stm ProcessTree) printItem(pti *ProcessTreeItem, offset uint) string {
-	if pti.status == StatusSuccess && stm.hide || ((pti.status == StatusFailed || pti.status == StatusFailedChild) && stm.hideError) {
+	if stm.hidden(pti) {
 		return ""
 	}
 
@@ -103,7 +144,11 @@ func (stm ProcessTree) printItem(pti *ProcessTreeItem, offset uint) string {
 		textLeft += "[ ]"
 	}
 
-	textLeft += " " + pti.textLeft
+	if stm.interactive && pti == stm.selected {
+		textLeft += " " + lipgloss.NewStyle().Reverse(true).Render(pti.textLeft)
+	} else {
+		textLeft += " " + pti.textLeft
+	}
 
 	if pti.status == StatusRunning || pti.status == StatusRunningChild {
 		textLeft += pti.ellipsis
@@ -149,18 +194,23 @@ func (stm ProcessTree) printItem(pti *ProcessTreeItem, offset uint) string {
 		right,
 	) + "\n"
 
-	// Print the logs for this item
+	// Print the logs for this item, unless they are shown in the pane
+	logs := pti.logLines()
+	if stm.interactive && stm.expanded && pti == stm.selected {
+		logs = nil
+	}
+
 	truncate := 0
-	loglen := len(pti.logs) - LOGLEN
+	loglen := len(logs) - LOGLEN
 	if pti.status == StatusFailed && !pti.hideError {
 		truncate = 0
 	} else if loglen > 0 {
 		truncate = loglen
 	}
 	if pti.status == StatusRunning || ((pti.status == StatusFailed || pti.status == StatusFailedChild) && !pti.hideError) {
-		for i, line := range pti.logs[truncate:] {
+		for i, line := range logs[truncate:] {
 			s += line
-			if i < len(pti.logs[truncate:]) {
+			if i < len(logs[truncate:]) {
 				s += "\n"
 			}
 		}
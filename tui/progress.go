@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"kraftkit.sh/log"
+)
+
+// ProgressEvent is the state of a task as reported by the plain renderers of
+// the processtree and paraprogress components.
+type ProgressEvent string
+
+const (
+	ProgressStarted   = ProgressEvent("started")
+	ProgressSucceeded = ProgressEvent("succeeded")
+	ProgressFailed    = ProgressEvent("failed")
+
+	// ProgressUpdated and ProgressLogged are only emitted as raw JSON events,
+	// when the completion of a task changes and when it outputs a line,
+	// respectively.
+	ProgressUpdated = ProgressEvent("progress")
+	ProgressLogged  = ProgressEvent("log")
+)
+
+// ProgressRecord is a single raw JSON progress event.  Tasks are identified by
+// an ID which is unique within the process, and reference the ID of the task
+// they are nested in, such that the progress tree can be reconstructed.
+type ProgressRecord struct {
+	// Time at which the event occurred.
+	Time time.Time `json:"time"`
+
+	// Event which occurred.
+	Event ProgressEvent `json:"event"`
+
+	// ID of the task.
+	ID string `json:"id"`
+
+	// Parent is the ID of the task which the task is nested in, if any.
+	Parent string `json:"parent,omitempty"`
+
+	// Task is the name of the task.
+	Task string `json:"task"`
+
+	// Detail is additional information about the task, e.g. the architecture
+	// and platform of a target.
+	Detail string `json:"detail,omitempty"`
+
+	// Elapsed is the number of seconds the task ran for once it finished.
+	Elapsed float64 `json:"elapsed,omitempty"`
+
+	// Progress is the completion of the task between 0 and 1, if known.
+	Progress float64 `json:"progress,omitempty"`
+
+	// Message is the line which the task output.
+	Message string `json:"message,omitempty"`
+
+	// Error is the reason the task failed.
+	Error string `json:"error,omitempty"`
+}
+
+// ProgressEmitter writes raw JSON progress events, one per line, such that
+// other programs, e.g. IDEs or web interfaces, can render the progress of
+// tasks themselves.  It is safe for concurrent use.
+type ProgressEmitter struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	lastID uint64
+}
+
+// NewProgressEmitter returns an emitter which writes events to w.
+func NewProgressEmitter(w io.Writer) *ProgressEmitter {
+	return &ProgressEmitter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// NextID returns a new unique ID of a task.
+func (pe *ProgressEmitter) NextID() string {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	pe.lastID++
+
+	return strconv.FormatUint(pe.lastID, 10)
+}
+
+// Emit writes the event.
+func (pe *ProgressEmitter) Emit(record ProgressRecord) {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	_ = pe.enc.Encode(record)
+}
+
+// LogWriter returns a writer which emits every line written to it as a log
+// event of the task.
+func (pe *ProgressEmitter) LogWriter(id, task string) io.Writer {
+	return &progressLogWriter{
+		emitter: pe,
+		id:      id,
+		task:    task,
+	}
+}
+
+type progressLogWriter struct {
+	emitter *ProgressEmitter
+	id      string
+	task    string
+}
+
+// Write implements io.Writer
+func (w *progressLogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+
+	for _, line := range strings.Split(strings.ReplaceAll(line, "\r\n", "\n"), "\n") {
+		w.emitter.Emit(ProgressRecord{
+			Event:   ProgressLogged,
+			ID:      w.id,
+			Task:    w.task,
+			Message: line,
+		})
+	}
+
+	return len(p), nil
+}
+
+type progressEmitterContextKey struct{}
+
+// WithProgressEmitter returns a context in which the progress of tasks is
+// emitted as raw JSON events by the provided emitter.
+func WithProgressEmitter(ctx context.Context, pe *ProgressEmitter) context.Context {
+	return context.WithValue(ctx, progressEmitterContextKey{}, pe)
+}
+
+// ProgressEmitterFromContext returns the emitter of raw JSON progress events of
+// the context, or nil if progress is not emitted as such.
+func ProgressEmitterFromContext(ctx context.Context) *ProgressEmitter {
+	pe, _ := ctx.Value(progressEmitterContextKey{}).(*ProgressEmitter)
+	return pe
+}
+
+// LogProgress outputs a single line for the event of the named task.  When the
+// logger of the context outputs JSON, the event, task and elapsed time are
+// attached as fields such that the progress can be consumed by other programs.
+func LogProgress(ctx context.Context, event ProgressEvent, task string, elapsed time.Duration) {
+	logger := log.G(ctx)
+
+	if _, ok := logger.Formatter.(*logrus.JSONFormatter); ok {
+		entry := logger.WithFields(logrus.Fields{
+			"progress": event,
+			"task":     task,
+		})
+
+		if event != ProgressStarted {
+			entry = entry.WithField("elapsed", elapsed.Seconds())
+		}
+
+		entry.Info(task)
+		return
+	}
+
+	switch event {
+	case ProgressStarted:
+		logger.Info(task)
+	case ProgressSucceeded:
+		logger.Infof("%s: done in %s", task, elapsed.Round(time.Millisecond))
+	case ProgressFailed:
+		logger.Infof("%s: failed after %s", task, elapsed.Round(time.Millisecond))
+	}
+}
@@ -20,6 +20,10 @@ func Select[T fmt.Stringer](question string, options ...T) (*T, error) {
 		return &options[0], nil
 	}
 
+	if !tui.IsInteractive() {
+		return nil, tui.ErrNonInteractive
+	}
+
 	strings := make([]string, 0, len(options))
 	for _, option := range options {
 		strings = append(strings, option.String())
@@ -3,6 +3,8 @@ package textinput
 import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/erikgeiser/promptkit/textinput"
+
+	"kraftkit.sh/tui"
 )
 
 var queryMark = lipgloss.NewStyle().
@@ -16,6 +18,10 @@ var queryMark = lipgloss.NewStyle().
 // NewSpecify is a utility method used in a CLI context to prompt the user with
 // a question to specify answer as string.
 func NewTextInput(question, placeholder, defaultAns string) (string, error) {
+	if !tui.IsInteractive() {
+		return "", tui.ErrNonInteractive
+	}
+
 	input := textinput.New(queryMark("[?] ") + question)
 	input.Placeholder = placeholder
 	input.InitialValue = defaultAns
@@ -151,6 +151,27 @@ type Application interface {
 	// Env variables to be used during building and runtime of application.
 	Env() map[string]string
 
+	// TargetRootfs returns the root filesystem of the provided target, which is
+	// that of the application unless the target overrides it.
+	TargetRootfs(target.Target) string
+
+	// TargetCommand returns the command of the provided target, which is that of
+	// the application unless the target overrides it.
+	TargetCommand(target.Target) []string
+
+	// TargetEnv returns the environmental variables of the application merged
+	// with those of the provided target, where the latter take precedence.
+	TargetEnv(target.Target) map[string]string
+
+	// Lockfile returns the versions which the semantic version range
+	// constraints of the components of the application are pinned to.
+	Lockfile() *Lockfile
+
+	// LockedVersion returns the version of the provided component which should
+	// be retrieved, which is the pinned version if the version of the component
+	// is a constraint which has been locked.
+	LockedVersion(component.Component) string
+
 	// Removes library from the project directory
 	RemoveLibrary(ctx context.Context, libraryName string) error
 
@@ -177,6 +198,8 @@ type application struct {
 	command       []string
 	rootfs        string
 	kraftfile     *Kraftfile
+	lockfile      *Lockfile
+	vendored      bool
 	configuration kconfig.KeyValueMap
 	extensions    component.Extensions
 }
@@ -327,6 +350,12 @@ func (app *application) MergeTemplate(ctx context.Context, merge Application) (A
 		}
 	}
 
+	if app.vendored {
+		if err := app.useVendoredSources(); err != nil {
+			return nil, err
+		}
+	}
+
 	return app, nil
 }
 
@@ -1119,6 +1148,56 @@ func (app *application) Env() map[string]string {
 	return app.env
 }
 
+// TargetRootfs implements Application
+func (app *application) TargetRootfs(targ target.Target) string {
+	if tc, ok := targ.(*target.TargetConfig); ok && tc.Rootfs() != "" {
+		return tc.Rootfs()
+	}
+
+	return app.rootfs
+}
+
+// TargetCommand implements Application
+func (app *application) TargetCommand(targ target.Target) []string {
+	if tc, ok := targ.(*target.TargetConfig); ok && len(tc.Command()) > 0 {
+		return tc.Command()
+	}
+
+	return app.command
+}
+
+// TargetEnv implements Application
+func (app *application) TargetEnv(targ target.Target) map[string]string {
+	tc, ok := targ.(*target.TargetConfig)
+	if !ok || len(tc.Env()) == 0 {
+		return app.env
+	}
+
+	env := map[string]string{}
+	for k, v := range app.env {
+		env[k] = v
+	}
+	for k, v := range tc.Env() {
+		env[k] = v
+	}
+
+	return env
+}
+
+// Lockfile implements Application
+func (app *application) Lockfile() *Lockfile {
+	return app.lockfile
+}
+
+// LockedVersion implements Application
+func (app *application) LockedVersion(comp component.Component) string {
+	if version, ok := app.lockfile.Locked(comp); ok {
+		return version
+	}
+
+	return comp.Version()
+}
+
 func (app *application) RemoveLibrary(ctx context.Context, libraryName string) error {
 	isLibraryExistInProject := false
 	for libKey, lib := range app.libraries {
@@ -1126,28 +1205,14 @@ func (app *application) RemoveLibrary(ctx context.Context, libraryName string) e
 			isLibraryExistInProject = true
 			delete(app.libraries, libKey)
 
-			yamlFile, err := os.ReadFile(app.kraftfile.path)
-			if err != nil {
-				return err
-			}
-
-			yamlMap := app
-			err = yaml.Unmarshal(yamlFile, &yamlMap)
-			if err != nil {
-				return err
-			}
-
-			delete(yamlMap.libraries, libKey)
-			err = saveNewKraftfile(ctx, yamlMap)
-			if err != nil {
+			if err := RemoveKraftfileLibrary(app.kraftfile, libKey); err != nil {
 				return err
 			}
 
 			// Remove library directory from the project directory
 			libPath := filepath.Join(app.WorkingDir(), unikraft.LibsDir, libraryName)
-			if _, err = os.Stat(libPath); err == nil {
-				err = os.RemoveAll(libPath)
-				if err != nil {
+			if _, err := os.Stat(libPath); err == nil {
+				if err := os.RemoveAll(libPath); err != nil {
 					return err
 				}
 			}
@@ -53,6 +53,12 @@ func NewApplicationFromOptions(aopts ...ApplicationOption) (Application, error)
 		ac.outDir = filepath.Join(ac.workingDir, unikraft.BuildDir)
 	}
 
+	if ac.vendored {
+		if err := ac.useVendoredSources(); err != nil {
+			return nil, err
+		}
+	}
+
 	if ac.unikraft != nil && len(ac.unikraft.Source()) > 0 {
 		if p, err := os.Stat(ac.unikraft.Source()); err == nil && p.IsDir() {
 			ac.configuration.Set(unikraft.UK_BASE, ac.unikraft.Source())
@@ -211,6 +217,24 @@ func WithEnv(env map[string]string) ApplicationOption {
 	}
 }
 
+// WithLockfile sets the versions which the constraints of the components are
+// pinned to.
+func WithLockfile(lockfile *Lockfile) ApplicationOption {
+	return func(ac *application) error {
+		ac.lockfile = lockfile
+		return nil
+	}
+}
+
+// WithVendoredSources sets whether the vendored sources of the components of
+// the application are used.
+func WithVendoredSources(vendored bool) ApplicationOption {
+	return func(ac *application) error {
+		ac.vendored = vendored
+		return nil
+	}
+}
+
 func WithLabel(key, value string) ApplicationOption {
 	return func(ac *application) error {
 		if ac.labels == nil {
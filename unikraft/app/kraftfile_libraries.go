@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetKraftfileLibrary adds the library with the provided version and KConfig
+// options to the `libraries` section of the Kraftfile, or updates the library
+// if it is already present.  Unlike saving the whole project, only the entry
+// of the library is changed such that the comments and ordering of the rest of
+// the Kraftfile are preserved.
+func SetKraftfileLibrary(kraftfile *Kraftfile, name, version string, kconfig map[string]string) error {
+	return editKraftfile(kraftfile, func(root *yaml.Node) error {
+		libraries := mappingValue(root, "libraries")
+		if libraries == nil {
+			libraries = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			root.Content = append(root.Content, scalarNode("libraries"), libraries)
+		} else if libraries.Kind != yaml.MappingNode {
+			// An empty section, i.e. `libraries:`, is parsed as a null scalar.
+			*libraries = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+
+		entry := mappingValue(libraries, name)
+		if entry == nil {
+			entry = &yaml.Node{}
+			libraries.Content = append(libraries.Content, scalarNode(name), entry)
+		}
+
+		// The short-hand format `name: version` is kept unless KConfig options
+		// must also be set.
+		if entry.Kind != yaml.MappingNode && len(kconfig) == 0 {
+			comment := entry.LineComment
+			*entry = *scalarNode(version)
+			entry.LineComment = comment
+			return nil
+		}
+
+		if entry.Kind != yaml.MappingNode {
+			comment := entry.LineComment
+			*entry = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", LineComment: comment}
+		}
+
+		if version != "" {
+			setMappingValue(entry, "version", scalarNode(version))
+		}
+
+		if len(kconfig) > 0 {
+			setKConfig(entry, kconfig)
+		}
+
+		return nil
+	})
+}
+
+// RemoveKraftfileLibrary removes the library from the `libraries` section of
+// the Kraftfile, removing the section itself if it becomes empty, whilst
+// preserving the comments and ordering of the rest of the Kraftfile.
+func RemoveKraftfileLibrary(kraftfile *Kraftfile, name string) error {
+	return editKraftfile(kraftfile, func(root *yaml.Node) error {
+		libraries := mappingValue(root, "libraries")
+		if libraries == nil || !removeMappingValue(libraries, name) {
+			return fmt.Errorf("library %s does not exist in the Kraftfile", name)
+		}
+
+		if len(libraries.Content) == 0 {
+			removeMappingValue(root, "libraries")
+		}
+
+		return nil
+	})
+}
+
+// KraftfileLibraries returns the versions of the libraries listed in the
+// `libraries` section of the Kraftfile, indexed by their name.  Libraries
+// without a version are returned with an empty version.
+func KraftfileLibraries(kraftfile *Kraftfile) (map[string]string, error) {
+	root, err := parseKraftfile(kraftfile)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[string]string{}
+
+	libraries := mappingValue(root.Content[0], "libraries")
+	if libraries == nil || libraries.Kind != yaml.MappingNode {
+		return ret, nil
+	}
+
+	for i := 0; i+1 < len(libraries.Content); i += 2 {
+		name, entry := libraries.Content[i].Value, libraries.Content[i+1]
+
+		switch entry.Kind {
+		case yaml.ScalarNode:
+			ret[name] = entry.Value
+		case yaml.MappingNode:
+			if version := mappingValue(entry, "version"); version != nil {
+				ret[name] = version.Value
+			} else {
+				ret[name] = ""
+			}
+		default:
+			ret[name] = ""
+		}
+	}
+
+	return ret, nil
+}
+
+// parseKraftfile reads the Kraftfile from disk into a YAML node tree whose
+// document node contains a single top-level mapping.
+func parseKraftfile(kraftfile *Kraftfile) (*yaml.Node, error) {
+	if kraftfile == nil || kraftfile.path == "" || kraftfile.path == "-" {
+		return nil, fmt.Errorf("cannot use a Kraftfile which has not been read from a file")
+	}
+
+	raw, err := os.ReadFile(kraftfile.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(markBlankLines(raw), &doc); err != nil {
+		return nil, fmt.Errorf("could not parse Kraftfile: %w", err)
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("could not parse Kraftfile: expected a mapping at the top-level")
+	}
+
+	return &doc, nil
+}
+
+// editKraftfile parses the Kraftfile into a YAML node tree, applies the
+// provided edit to its top-level mapping and writes the result back.
+func editKraftfile(kraftfile *Kraftfile, edit func(*yaml.Node) error) error {
+	doc, err := parseKraftfile(kraftfile)
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(kraftfile.path)
+	if err != nil {
+		return err
+	}
+
+	if err := edit(doc.Content[0]); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("could not encode Kraftfile: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(kraftfile.path, restoreBlankLines(buf.Bytes()), fi.Mode().Perm())
+}
+
+// blankLineMarker temporarily replaces blank lines between top-level entries
+// of a Kraftfile, which would otherwise be dropped when it is re-encoded.
+const blankLineMarker = "#kraftfile:blank"
+
+// markBlankLines replaces each blank line which precedes a top-level entry
+// with a comment such that it is retained in the YAML node tree.  Blank lines
+// within indented content, e.g. block scalars, are left untouched.
+func markBlankLines(raw []byte) []byte {
+	lines := strings.Split(string(raw), "\n")
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			continue
+		}
+
+		for _, next := range lines[i+1:] {
+			if strings.TrimSpace(next) == "" {
+				continue
+			}
+
+			if next[0] != ' ' && next[0] != '\t' {
+				lines[i] = blankLineMarker
+			}
+
+			break
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// restoreBlankLines reverts the comments inserted by markBlankLines.
+func restoreBlankLines(raw []byte) []byte {
+	lines := strings.Split(string(raw), "\n")
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == blankLineMarker {
+			lines[i] = ""
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// setKConfig sets the provided KConfig options of a library entry, either in
+// the list format `- KEY=value` or the map format `KEY: value` depending on
+// which the entry already uses.
+func setKConfig(entry *yaml.Node, kconfig map[string]string) {
+	keys := make([]string, 0, len(kconfig))
+	for k := range kconfig {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	options := mappingValue(entry, "kconfig")
+	if options == nil || (options.Kind != yaml.MappingNode && options.Kind != yaml.SequenceNode) {
+		options = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingValue(entry, "kconfig", options)
+	}
+
+	switch options.Kind {
+	case yaml.MappingNode:
+		for _, k := range keys {
+			setMappingValue(options, k, scalarNode(kconfig[k]))
+		}
+
+	case yaml.SequenceNode:
+	keys:
+		for _, k := range keys {
+			option := scalarNode(fmt.Sprintf("%s=%s", k, kconfig[k]))
+
+			for i, item := range options.Content {
+				if item.Kind == yaml.ScalarNode && (item.Value == k || strings.HasPrefix(item.Value, k+"=")) {
+					options.Content[i] = option
+					continue keys
+				}
+			}
+
+			options.Content = append(options.Content, option)
+		}
+	}
+}
+
+// scalarNode returns a YAML string node with the provided value.
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// mappingValue returns the value of the key in the mapping or nil if the key
+// is not present.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// setMappingValue sets the value of the key in the mapping, appending the key
+// if it is not present.
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			value.LineComment = mapping.Content[i+1].LineComment
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+
+	mapping.Content = append(mapping.Content, scalarNode(key), value)
+}
+
+// removeMappingValue removes the key and its value from the mapping and
+// returns whether the key was present.
+func removeMappingValue(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return true
+		}
+	}
+
+	return false
+}
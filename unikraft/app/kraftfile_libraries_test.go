@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKraftfile(t *testing.T, content string) *Kraftfile {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "Kraftfile")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal("WriteFile:", err)
+	}
+
+	return &Kraftfile{path: path}
+}
+
+func readKraftfile(t *testing.T, kraftfile *Kraftfile) string {
+	t.Helper()
+
+	raw, err := os.ReadFile(kraftfile.path)
+	if err != nil {
+		t.Fatal("ReadFile:", err)
+	}
+
+	return string(raw)
+}
+
+func TestSetKraftfileLibrary(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		lib     string
+		version string
+		kconfig map[string]string
+		want    string
+	}{
+		{
+			name: "adds section",
+			in: `spec: v0.6
+
+# The unikernel's name.
+name: helloworld
+`,
+			lib:     "musl",
+			version: "stable",
+			want: `spec: v0.6
+
+# The unikernel's name.
+name: helloworld
+libraries:
+  musl: stable
+`,
+		},
+		{
+			name: "updates short-hand version",
+			in: `spec: v0.6
+libraries:
+  # C library
+  musl: staging # pinned
+  lwip: stable
+`,
+			lib:     "musl",
+			version: "stable",
+			want: `spec: v0.6
+libraries:
+  # C library
+  musl: stable # pinned
+  lwip: stable
+`,
+		},
+		{
+			name: "appends to existing kconfig list",
+			in: `spec: v0.6
+libraries:
+  lwip:
+    version: stable
+    kconfig:
+      - CONFIG_LWIP_TCP=y
+`,
+			lib:     "lwip",
+			version: "0.16.0",
+			kconfig: map[string]string{
+				"CONFIG_LWIP_TCP":  "n",
+				"CONFIG_LWIP_IPV6": "y",
+			},
+			want: `spec: v0.6
+libraries:
+  lwip:
+    version: 0.16.0
+    kconfig:
+      - CONFIG_LWIP_TCP=n
+      - CONFIG_LWIP_IPV6=y
+`,
+		},
+		{
+			name: "expands short-hand to set kconfig",
+			in: `spec: v0.6
+libraries:
+  musl: stable
+`,
+			lib:     "musl",
+			version: "stable",
+			kconfig: map[string]string{
+				"CONFIG_LIBMUSL_LOCALE": "y",
+			},
+			want: `spec: v0.6
+libraries:
+  musl:
+    version: stable
+    kconfig:
+      CONFIG_LIBMUSL_LOCALE: y
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kraftfile := writeKraftfile(t, tc.in)
+
+			if err := SetKraftfileLibrary(kraftfile, tc.lib, tc.version, tc.kconfig); err != nil {
+				t.Fatal("SetKraftfileLibrary:", err)
+			}
+
+			if got := readKraftfile(t, kraftfile); got != tc.want {
+				t.Errorf("unexpected Kraftfile:\ngot:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRemoveKraftfileLibrary(t *testing.T) {
+	kraftfile := writeKraftfile(t, `spec: v0.6
+# The libraries of the unikernel.
+libraries:
+  musl: stable
+  lwip: stable
+`)
+
+	if err := RemoveKraftfileLibrary(kraftfile, "musl"); err != nil {
+		t.Fatal("RemoveKraftfileLibrary:", err)
+	}
+
+	want := `spec: v0.6
+# The libraries of the unikernel.
+libraries:
+  lwip: stable
+`
+	if got := readKraftfile(t, kraftfile); got != want {
+		t.Errorf("unexpected Kraftfile:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := RemoveKraftfileLibrary(kraftfile, "lwip"); err != nil {
+		t.Fatal("RemoveKraftfileLibrary:", err)
+	}
+
+	if got := readKraftfile(t, kraftfile); got != "spec: v0.6\n" {
+		t.Errorf("expected the empty section to be removed, got:\n%s", got)
+	}
+
+	if err := RemoveKraftfileLibrary(kraftfile, "lwip"); err == nil {
+		t.Error("expected an error when removing a library which does not exist")
+	}
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/component"
+)
+
+// LockfileName is the name of the file next to the Kraftfile which pins the
+// versions which the semantic version range constraints of the components of
+// the project resolved to.
+const LockfileName = "Kraftfile.lock"
+
+// LockedVersion is the version which a constraint of a component resolved to.
+type LockedVersion struct {
+	// Constraint is the semantic version range constraint of the component as
+	// it is specified in the Kraftfile, e.g. `^0.16`.
+	Constraint string `yaml:"constraint"`
+
+	// Version is the newest version which satisfied the constraint at the time
+	// of resolution.
+	Version string `yaml:"version"`
+}
+
+// Lockfile pins the versions of the components of a project whose versions are
+// specified as semantic version range constraints.
+type Lockfile struct {
+	// Unikraft is the locked version of the Unikraft core.
+	Unikraft *LockedVersion `yaml:"unikraft,omitempty"`
+
+	// Libraries are the locked versions of the libraries, by name.
+	Libraries map[string]LockedVersion `yaml:"libraries,omitempty"`
+}
+
+// LockfileDir returns the directory which holds the lockfile of the project
+// with the provided Kraftfile, which is the directory of the Kraftfile.  If the
+// Kraftfile was not read from a file, e.g. from standard input, the provided
+// working directory of the project is returned instead.
+func LockfileDir(kraftfile *Kraftfile, workdir string) string {
+	if kraftfile == nil || kraftfile.path == "" || kraftfile.path == "-" {
+		return workdir
+	}
+
+	return filepath.Dir(kraftfile.path)
+}
+
+// LoadLockfile reads the lockfile of the project in the provided directory.
+// If the project does not have a lockfile, nil is returned.
+func LoadLockfile(dir string) (*Lockfile, error) {
+	contents, err := os.ReadFile(filepath.Join(dir, LockfileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	lockfile := &Lockfile{}
+	if err := yaml.Unmarshal(contents, lockfile); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", LockfileName, err)
+	}
+
+	return lockfile, nil
+}
+
+// Save writes the lockfile to the project in the provided directory.
+func (lockfile *Lockfile) Save(dir string) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("# This file is generated by `kraft pkg update`.  Do not edit it manually.\n")
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(lockfile); err != nil {
+		return err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, LockfileName), buf.Bytes(), 0o644)
+}
+
+// Lock pins the version which the constraint of the component resolved to.
+func (lockfile *Lockfile) Lock(comp component.Component, version string) {
+	locked := LockedVersion{
+		Constraint: comp.Version(),
+		Version:    version,
+	}
+
+	if comp.Type() == unikraft.ComponentTypeCore {
+		lockfile.Unikraft = &locked
+		return
+	}
+
+	if lockfile.Libraries == nil {
+		lockfile.Libraries = map[string]LockedVersion{}
+	}
+
+	lockfile.Libraries[comp.Name()] = locked
+}
+
+// Locked returns the version which the constraint of the component is pinned
+// to.  A version is only pinned as long as the constraint of the component is
+// unchanged since it was locked.
+func (lockfile *Lockfile) Locked(comp component.Component) (string, bool) {
+	if lockfile == nil {
+		return "", false
+	}
+
+	var locked *LockedVersion
+
+	if comp.Type() == unikraft.ComponentTypeCore {
+		locked = lockfile.Unikraft
+	} else if l, ok := lockfile.Libraries[comp.Name()]; ok {
+		locked = &l
+	}
+
+	if locked == nil || locked.Constraint != comp.Version() {
+		return "", false
+	}
+
+	return locked.Version, true
+}
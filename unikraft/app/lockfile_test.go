@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockfileDir(t *testing.T) {
+	tests := []struct {
+		name      string
+		kraftfile *Kraftfile
+		want      string
+	}{
+		{
+			name:      "next to the Kraftfile",
+			kraftfile: &Kraftfile{path: "/src/app/Kraftfile"},
+			want:      "/src/app",
+		},
+		{
+			name:      "relative Kraftfile",
+			kraftfile: &Kraftfile{path: "app/Kraftfile"},
+			want:      "app",
+		},
+		{
+			name:      "standard input",
+			kraftfile: &Kraftfile{path: "-"},
+			want:      "/workdir",
+		},
+		{
+			name: "no Kraftfile",
+			want: "/workdir",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LockfileDir(tt.kraftfile, "/workdir"); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestProjectLockfileOutsideWorkdir(t *testing.T) {
+	kraftfile := writeKraftfile(t, "spec: v0.6\n\nname: helloworld\n\nunikraft: ^0.16\n")
+	dir := filepath.Dir(kraftfile.path)
+	workdir := t.TempDir()
+
+	locked := &Lockfile{
+		Unikraft: &LockedVersion{Constraint: "^0.16", Version: "0.16.3"},
+	}
+
+	if err := locked.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// A lockfile in the working directory belongs to another project.
+	if err := os.WriteFile(filepath.Join(workdir, LockfileName), []byte("unikraft:\n  constraint: ^0.15\n  version: 0.15.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := NewProjectFromOptions(context.Background(),
+		WithProjectWorkdir(workdir),
+		WithProjectKraftfile(kraftfile.path),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lockfile := project.Lockfile()
+	if lockfile == nil || lockfile.Unikraft == nil {
+		t.Fatalf("expected the lockfile next to the Kraftfile to be loaded, got %+v", lockfile)
+	}
+
+	if lockfile.Unikraft.Version != "0.16.3" {
+		t.Errorf("expected locked version 0.16.3, got %s", lockfile.Unikraft.Version)
+	}
+
+	if got := LockfileDir(project.Kraftfile(), project.WorkingDir()); got != dir {
+		t.Errorf("expected the lockfile to be saved in %s, got %s", dir, got)
+	}
+}
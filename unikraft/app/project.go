@@ -91,7 +91,7 @@ func NewProjectFromOptions(ctx context.Context, opts ...ProjectOption) (Applicat
 	}
 
 	if !popts.skipValidation {
-		if err := schema.Validate(ctx, iface); err != nil {
+		if err := validateKraftfile(ctx, popts.kraftfile, iface); err != nil {
 			return nil, err
 		}
 	}
@@ -161,6 +161,11 @@ func NewProjectFromOptions(ctx context.Context, opts ...ProjectOption) (Applicat
 		target.KConfig().OverrideBy(kvmap)
 	}
 
+	lockfile, err := LoadLockfile(LockfileDir(popts.kraftfile, popts.workdir))
+	if err != nil {
+		return nil, err
+	}
+
 	project, err := NewApplicationFromOptions(
 		WithName(projectName),
 		WithWorkingDir(popts.workdir),
@@ -177,6 +182,8 @@ func NewProjectFromOptions(ctx context.Context, opts ...ProjectOption) (Applicat
 		WithConfiguration(popts.kconfig.Slice()...),
 		WithExtensions(app.extensions),
 		WithKraftfile(popts.kraftfile),
+		WithLockfile(lockfile),
+		WithVendoredSources(!popts.skipVendored),
 		WithVolumes(app.volumes...),
 		WithEnv(app.env),
 	)
@@ -193,3 +200,38 @@ func NewProjectFromOptions(ctx context.Context, opts ...ProjectOption) (Applicat
 
 	return project, nil
 }
+
+// validateKraftfile validates the configuration of the Kraftfile against the
+// specification.  When the contents of the Kraftfile are available, violations
+// are reported at their position within the file and fields which are not part
+// of the specification are warned about.
+func validateKraftfile(ctx context.Context, kraftfile *Kraftfile, config map[string]interface{}) error {
+	verr := schema.Validate(ctx, config)
+
+	if len(kraftfile.content) == 0 {
+		return verr
+	}
+
+	path := kraftfile.path
+	if path == "" {
+		path = "Kraftfile"
+	}
+
+	violations, err := schema.Lint(ctx, path, kraftfile.content)
+	if err != nil {
+		log.G(ctx).Debugf("could not lint Kraftfile: %v", err)
+		return verr
+	}
+
+	for _, violation := range violations.Unknown() {
+		log.G(ctx).Warn(violation.Error())
+	}
+
+	if verr != nil {
+		if invalid := violations.Invalid(); len(invalid) > 0 {
+			return invalid
+		}
+	}
+
+	return verr
+}
@@ -52,6 +52,7 @@ type ProjectOptions struct {
 	skipValidation    bool
 	skipInterpolation bool
 	skipNormalization bool
+	skipVendored      bool
 	resolvePaths      bool
 	interpolate       *interp.Options
 
@@ -243,6 +244,15 @@ func WithProjectInterpolation(interpolation bool) ProjectOption {
 	}
 }
 
+// WithProjectVendoredSources set ProjectOptions to enable/skip the use of the
+// sources of components which are vendored in the project
+func WithProjectVendoredSources(vendored bool) ProjectOption {
+	return func(popts *ProjectOptions) error {
+		popts.skipVendored = !vendored
+		return nil
+	}
+}
+
 // WithProjectNormalization set ProjectOptions to enable/skip normalization
 func WithProjectNormalization(normalization bool) ProjectOption {
 	return func(popts *ProjectOptions) error {
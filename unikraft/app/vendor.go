@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package app
+
+import (
+	"os"
+
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/core"
+	"kraftkit.sh/unikraft/lib"
+	"kraftkit.sh/unikraft/template"
+)
+
+// vendoredSource returns the path of the vendored sources of the component
+// within the project if they exist.
+func vendoredSource(workdir string, t unikraft.ComponentType, name string) (string, bool) {
+	path, err := unikraft.PlaceVendoredComponent(workdir, t, name)
+	if err != nil {
+		return "", false
+	}
+
+	if f, err := os.Stat(path); err != nil || !f.IsDir() {
+		return "", false
+	}
+
+	return path, true
+}
+
+// useVendoredSources points the components of the application whose sources
+// have been vendored at the vendored sources, such that they are used instead
+// of retrieving the components.  Both the path and the source are set to the
+// vendored directory which indicates to consumers that the component is
+// already available on disk.
+func (app *application) useVendoredSources() error {
+	if app.unikraft != nil {
+		if path, ok := vendoredSource(app.workingDir, unikraft.ComponentTypeCore, app.unikraft.Name()); ok {
+			for _, opt := range []core.UnikraftOption{
+				core.WithPath(path),
+				core.WithSource(path),
+			} {
+				if err := opt(app.unikraft); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, library := range app.libraries {
+		if path, ok := vendoredSource(app.workingDir, unikraft.ComponentTypeLib, library.Name()); ok {
+			for _, opt := range []lib.LibraryOption{
+				lib.WithPath(path),
+				lib.WithSource(path),
+			} {
+				if err := opt(library); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if app.template != nil {
+		if path, ok := vendoredSource(app.workingDir, unikraft.ComponentTypeApp, app.template.Name()); ok {
+			for _, opt := range []template.TemplateOption{
+				template.WithPath(path),
+				template.WithSource(path),
+			} {
+				if err := opt(app.template); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -20,14 +20,23 @@ func TransformFromSchema(ctx context.Context, data interface{}) (interface{}, er
 		var split []string
 		if strings.Contains(entry, ":") {
 			split = strings.Split(entry, ":")
-			if len(split) > 2 {
-				return nil, fmt.Errorf("expected format template value to be <url>@<version>")
+			if len(split) > 3 {
+				return nil, fmt.Errorf("expected volume to be in the format <source>:<destination>[:<options>]")
 			}
 
 			volume.source = split[0]
-			if len(split) == 2 {
+			if len(split) >= 2 {
 				volume.destination = split[1]
 			}
+
+			if len(split) == 3 {
+				options, err := ParseOptions(split[2])
+				if err != nil {
+					return nil, err
+				}
+
+				volume.options = options
+			}
 		} else {
 			// When no colon is specified, assume the root file system
 			volume.source = entry
@@ -49,9 +58,40 @@ func TransformFromSchema(ctx context.Context, data interface{}) (interface{}, er
 			case "readonly":
 				volume.readOnly = prop.(bool)
 
+			case "options":
+				options, ok := prop.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("expected volume options to be a map")
+				}
+
+				volume.options = map[string]string{}
+				for k, v := range options {
+					volume.options[k] = fmt.Sprint(v)
+				}
 			}
 		}
 	}
 
 	return volume, nil
 }
+
+// ParseOptions parses the options of a volume which are provided in the format
+// `key=value[,key=value...]`.
+func ParseOptions(value string) (map[string]string, error) {
+	options := map[string]string{}
+
+	for _, option := range strings.Split(value, ",") {
+		if len(option) == 0 {
+			continue
+		}
+
+		k, v, ok := strings.Cut(option, "=")
+		if !ok || len(k) == 0 {
+			return nil, fmt.Errorf("invalid volume option '%s': expected the format <key>=<value>", option)
+		}
+
+		options[k] = v
+	}
+
+	return options, nil
+}
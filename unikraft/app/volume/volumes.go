@@ -28,6 +28,10 @@ type Volume interface {
 
 	// Whether the volume is readonly.
 	ReadOnly() bool
+
+	// Options are driver-specific options which tune how the volume is exposed
+	// to the unikernel instance.
+	Options() map[string]string
 }
 
 // VolumeConfig contains information about an individual volume that is to be
@@ -38,6 +42,7 @@ type VolumeConfig struct {
 	destination string
 	mode        string
 	readOnly    bool
+	options     map[string]string
 }
 
 // Driver implements Volume.
@@ -65,6 +70,11 @@ func (volume *VolumeConfig) ReadOnly() bool {
 	return volume.readOnly
 }
 
+// Options implements Volume.
+func (volume *VolumeConfig) Options() map[string]string {
+	return volume.options
+}
+
 // MarshalYAML makes LibraryConfig implement yaml.Marshaller
 func (volume *VolumeConfig) MarshalYAML() (interface{}, error) {
 	ret := map[string]interface{}{}
@@ -78,6 +88,9 @@ func (volume *VolumeConfig) MarshalYAML() (interface{}, error) {
 	if len(volume.Driver()) > 0 {
 		ret["driver"] = volume.Driver()
 	}
+	if len(volume.Options()) > 0 {
+		ret["options"] = volume.Options()
+	}
 	if len(ret) == 0 {
 		return nil, nil
 	}
@@ -33,6 +33,14 @@ func WithSource(source string) LibraryOption {
 	}
 }
 
+// WithPath sets the location on disk of the library's sources.
+func WithPath(path string) LibraryOption {
+	return func(lc *LibraryConfig) error {
+		lc.path = path
+		return nil
+	}
+}
+
 // WithVersion sets the version of this library component.
 func WithVersion(version string) LibraryOption {
 	return func(lc *LibraryConfig) error {
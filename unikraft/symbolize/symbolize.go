@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package symbolize resolves addresses of a unikernel to the functions and
+// source lines they belong to, akin to addr2line, using the symbol table and
+// the DWARF debugging information of its symbolic (unstripped) kernel image.
+package symbolize
+
+import (
+	"bufio"
+	"debug/dwarf"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Frame is an address resolved to its location in the kernel.
+type Frame struct {
+	// Address which was resolved.
+	Address uint64
+
+	// Function which contains the address, if known.
+	Function string
+
+	// Offset of the address from the start of the function.
+	Offset uint64
+
+	// File and Line of the source which the address was compiled from, if the
+	// kernel contains DWARF line information.
+	File string
+	Line int
+}
+
+// String returns the frame in the format: function+0x1f at file.c:42
+func (f Frame) String() string {
+	var ret strings.Builder
+
+	if f.Function == "" {
+		ret.WriteString("??")
+	} else {
+		ret.WriteString(f.Function)
+		if f.Offset > 0 {
+			fmt.Fprintf(&ret, "+0x%x", f.Offset)
+		}
+	}
+
+	if f.File != "" {
+		fmt.Fprintf(&ret, " at %s:%d", f.File, f.Line)
+	}
+
+	return ret.String()
+}
+
+// Symbolizer resolves the addresses of a single kernel.
+type Symbolizer struct {
+	file  *elf.File
+	dwarf *dwarf.Data
+	funcs []elf.Symbol
+}
+
+// Open prepares the symbolization of the kernel at the provided path, which
+// must contain a symbol table or DWARF debugging information.
+func Open(path string) (*Symbolizer, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open kernel %s: %w", path, err)
+	}
+
+	s := &Symbolizer{file: file}
+
+	if symbols, err := file.Symbols(); err == nil {
+		for _, symbol := range symbols {
+			if elf.ST_TYPE(symbol.Info) == elf.STT_FUNC && symbol.Value != 0 {
+				s.funcs = append(s.funcs, symbol)
+			}
+		}
+
+		sort.Slice(s.funcs, func(i, j int) bool {
+			return s.funcs[i].Value < s.funcs[j].Value
+		})
+	}
+
+	if data, err := file.DWARF(); err == nil {
+		s.dwarf = data
+	}
+
+	if len(s.funcs) == 0 && s.dwarf == nil {
+		file.Close()
+		return nil, fmt.Errorf("kernel %s contains neither symbols nor debugging information: use the symbolic (.dbg) kernel", path)
+	}
+
+	return s, nil
+}
+
+// HasLineInfo returns whether addresses can be resolved to source lines.
+func (s *Symbolizer) HasLineInfo() bool {
+	return s.dwarf != nil
+}
+
+// Close releases the kernel.
+func (s *Symbolizer) Close() error {
+	return s.file.Close()
+}
+
+// Resolve returns the location of the provided address, or false if it is
+// not part of any function of the kernel.
+func (s *Symbolizer) Resolve(addr uint64) (Frame, bool) {
+	frame := Frame{Address: addr}
+
+	if symbol, ok := s.function(addr); ok {
+		frame.Function = symbol.Name
+		frame.Offset = addr - symbol.Value
+	}
+
+	if file, line, ok := s.line(addr); ok {
+		frame.File = file
+		frame.Line = line
+	}
+
+	return frame, frame.Function != "" || frame.File != ""
+}
+
+// function returns the function symbol which contains the address.
+func (s *Symbolizer) function(addr uint64) (elf.Symbol, bool) {
+	i := sort.Search(len(s.funcs), func(i int) bool {
+		return s.funcs[i].Value > addr
+	})
+
+	if i == 0 {
+		return elf.Symbol{}, false
+	}
+
+	symbol := s.funcs[i-1]
+
+	// Symbols without a size are assumed to extend to the next function.
+	if symbol.Size > 0 && addr >= symbol.Value+symbol.Size {
+		return elf.Symbol{}, false
+	}
+
+	return symbol, true
+}
+
+// line returns the source file and line which the address was compiled from.
+func (s *Symbolizer) line(addr uint64) (string, int, bool) {
+	if s.dwarf == nil {
+		return "", 0, false
+	}
+
+	cu, err := s.dwarf.Reader().SeekPC(addr)
+	if err != nil || cu == nil {
+		return "", 0, false
+	}
+
+	lr, err := s.dwarf.LineReader(cu)
+	if err != nil || lr == nil {
+		return "", 0, false
+	}
+
+	var entry dwarf.LineEntry
+	if err := lr.SeekPC(addr, &entry); err != nil || entry.File == nil {
+		return "", 0, false
+	}
+
+	return entry.File.Name, entry.Line, true
+}
+
+// ParseAddress parses a hexadecimal address with or without the `0x` prefix.
+func ParseAddress(s string) (uint64, error) {
+	addr, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	return addr, nil
+}
+
+// addressPattern matches the hexadecimal addresses in a trace.  Short
+// numbers are ignored since they are unlikely to be addresses.
+var addressPattern = regexp.MustCompile(`\b0x[0-9a-fA-F]{4,16}\b`)
+
+// Trace copies the trace, e.g. the console output of a crashed unikernel,
+// from r to w and follows every line with the locations of the addresses it
+// contains, each on a line of its own, indented by a tab.
+func (s *Symbolizer) Trace(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+
+		for _, match := range addressPattern.FindAllString(line, -1) {
+			addr, err := ParseAddress(match)
+			if err != nil {
+				continue
+			}
+
+			frame, ok := s.Resolve(addr)
+			if !ok {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "\t%s: %s\n", match, frame); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
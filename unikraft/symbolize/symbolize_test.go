@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package symbolize
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testKernel builds the program in testdata/crash, whose symbols and debugging
+// information stand in for those of a kernel, and returns its path and the
+// symbol of its main function.
+func testKernel(t *testing.T) (string, elf.Symbol) {
+	t.Helper()
+
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	path := filepath.Join(t.TempDir(), "crash")
+
+	cmd := exec.Command(gobin, "build", "-o", path, "./testdata/crash")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("could not build test kernel: %v: %s", err, out)
+	}
+
+	f, err := elf.Open(path)
+	if err != nil {
+		t.Skipf("test kernel is not an ELF file: %v", err)
+	}
+
+	defer f.Close()
+
+	symbols, err := f.Symbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, symbol := range symbols {
+		if symbol.Name == "main.main" {
+			return path, symbol
+		}
+	}
+
+	t.Fatal("main.main not found in test kernel")
+
+	return "", elf.Symbol{}
+}
+
+func TestResolve(t *testing.T) {
+	path, symbol := testKernel(t)
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	frame, ok := s.Resolve(symbol.Value + 1)
+	if !ok {
+		t.Fatalf("could not resolve 0x%x", symbol.Value+1)
+	}
+
+	if frame.Function != symbol.Name || frame.Offset != 1 {
+		t.Errorf("expected %s+0x1, got %s+0x%x", symbol.Name, frame.Function, frame.Offset)
+	}
+
+	if !s.HasLineInfo() {
+		t.Fatal("expected test kernel to contain debugging information")
+	}
+
+	if !strings.HasSuffix(frame.File, "crash/main.go") || frame.Line != 7 {
+		t.Errorf("expected address in crash/main.go:7, got %s:%d", frame.File, frame.Line)
+	}
+
+	if _, ok := s.Resolve(0); ok {
+		t.Errorf("expected address 0 not to resolve")
+	}
+
+	var out bytes.Buffer
+	trace := fmt.Sprintf("CRIT: [libkvmplat] RIP: 0x%x\n", symbol.Value+1)
+
+	if err := s.Trace(strings.NewReader(trace), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := fmt.Sprintf("%s\t0x%x: %s\n", trace, symbol.Value+1, frame)
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	for input, expected := range map[string]uint64{
+		"0x113a2f": 0x113a2f,
+		"113A2F":   0x113a2f,
+		"0X10":     0x10,
+	} {
+		addr, err := ParseAddress(input)
+		if err != nil || addr != expected {
+			t.Errorf("%s: expected 0x%x, got 0x%x (%v)", input, expected, addr, err)
+		}
+	}
+
+	if _, err := ParseAddress("kernel.log"); err == nil {
+		t.Errorf("expected invalid address to fail")
+	}
+}
@@ -5,4 +5,48 @@
 
 package target
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Env map[string]string
+
+// NewEnvFromSchema parses the environmental variables of a target which are
+// either provided as a map or as a list of `KEY=VALUE` entries.
+func NewEnvFromSchema(data interface{}) (Env, error) {
+	env := Env{}
+
+	switch value := data.(type) {
+	case map[string]interface{}:
+		for k, v := range value {
+			if v == nil {
+				env[k] = ""
+				continue
+			}
+
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string for environmental variable %s, got %T", k, v)
+			}
+
+			env[k] = str
+		}
+
+	case []interface{}:
+		for _, entry := range value {
+			str, ok := entry.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string for environmental variable, got %T", entry)
+			}
+
+			k, v, _ := strings.Cut(str, "=")
+			env[k] = v
+		}
+
+	default:
+		return nil, fmt.Errorf("expected a map or a list of environmental variables, got %T", data)
+	}
+
+	return env, nil
+}
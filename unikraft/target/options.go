@@ -69,3 +69,17 @@ func WithCommand(command []string) TargetOption {
 		tc.command = command
 	}
 }
+
+// WithRootfs sets the root filesystem of the target.
+func WithRootfs(rootfs string) TargetOption {
+	return func(tc *TargetConfig) {
+		tc.rootfs = rootfs
+	}
+}
+
+// WithEnv sets the environmental variables of the target.
+func WithEnv(env map[string]string) TargetOption {
+	return func(tc *TargetConfig) {
+		tc.env = env
+	}
+}
@@ -74,6 +74,14 @@ type TargetConfig struct {
 
 	// command is the command-line arguments set for this target.
 	command []string
+
+	// rootfs is the path to the root filesystem of this target, which overrides
+	// that of the application.
+	rootfs string
+
+	// env is the set of environmental variables of this target, which are
+	// merged over those of the application.
+	env Env
 }
 
 // NewTargetFromOptions is a constructor for TargetConfig.
@@ -135,6 +143,17 @@ func (tc *TargetConfig) Command() []string {
 	return tc.command
 }
 
+// Rootfs is the path to the root filesystem of this target, if it overrides
+// that of the application.
+func (tc *TargetConfig) Rootfs() string {
+	return tc.rootfs
+}
+
+// Env is the set of environmental variables specific to this target.
+func (tc *TargetConfig) Env() map[string]string {
+	return tc.env
+}
+
 func (tc *TargetConfig) IsUnpacked() bool {
 	return false
 }
@@ -218,6 +237,15 @@ func (tc TargetConfig) MarshalYAML() (interface{}, error) {
 	if len(tc.kconfig) > 0 {
 		ret["kconfig"] = tc.kconfig
 	}
+	if len(tc.rootfs) > 0 {
+		ret["rootfs"] = tc.rootfs
+	}
+	if len(tc.command) > 0 {
+		ret["cmd"] = tc.command
+	}
+	if len(tc.env) > 0 {
+		ret["env"] = tc.env
+	}
 
 	return ret, nil
 }
@@ -94,6 +94,32 @@ func TransformFromSchema(ctx context.Context, data interface{}) (interface{}, er
 				if err != nil {
 					return nil, err
 				}
+
+			case "rootfs":
+				rootfs, ok := prop.(string)
+				if !ok {
+					return nil, fmt.Errorf("rootfs of target must be a string")
+				}
+
+				t.rootfs = rootfs
+
+			case "cmd", "command":
+				switch tprop := prop.(type) {
+				case string:
+					t.command = []string{tprop}
+				case []interface{}:
+					for _, arg := range tprop {
+						t.command = append(t.command, fmt.Sprint(arg))
+					}
+				default:
+					return nil, fmt.Errorf("invalid type %T for target command", prop)
+				}
+
+			case "env":
+				t.env, err = NewEnvFromSchema(prop)
+				if err != nil {
+					return nil, err
+				}
 			}
 		}
 	default:
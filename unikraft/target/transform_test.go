@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package target
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTransformFromSchema_Overrides(t *testing.T) {
+	data, err := TransformFromSchema(context.Background(), map[string]interface{}{
+		"platform":     "firecracker",
+		"architecture": "arm64",
+		"rootfs":       "./Dockerfile.arm64",
+		"cmd":          []interface{}{"/server", "--port", "8080"},
+		"env":          []interface{}{"MODE=fc", "DEBUG"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := data.(TargetConfig)
+
+	if expect, got := "./Dockerfile.arm64", tc.Rootfs(); expect != got {
+		t.Errorf("expected rootfs %q, got %q", expect, got)
+	}
+
+	if expect, got := 3, len(tc.Command()); expect != got {
+		t.Errorf("expected %d arguments, got %v", expect, tc.Command())
+	}
+
+	if expect, got := "fc", tc.Env()["MODE"]; expect != got {
+		t.Errorf("expected MODE=%q, got %q", expect, got)
+	}
+
+	if _, ok := tc.Env()["DEBUG"]; !ok {
+		t.Errorf("expected DEBUG to be set")
+	}
+
+	if _, err := TransformFromSchema(context.Background(), map[string]interface{}{
+		"platform":     "qemu",
+		"architecture": "x86_64",
+		"env":          map[string]interface{}{"PORT": 8080},
+	}); err == nil {
+		t.Errorf("expected non-string environmental variable to fail")
+	}
+}
@@ -30,3 +30,11 @@ func WithSource(source string) TemplateOption {
 		return nil
 	}
 }
+
+// WithPath sets the location on disk of the template.
+func WithPath(path string) TemplateOption {
+	return func(tc *TemplateConfig) error {
+		tc.path = path
+		return nil
+	}
+}
@@ -96,14 +96,24 @@ func GuessTypeNameVersion(input string) (ComponentType, string, string, error) {
 // place a component
 func PlaceComponent(workdir string, t ComponentType, name string) (string, error) {
 	// TODO: Should the hidden-file (`.`) be optional?
+	return placeComponent(filepath.Join(workdir, VendorDir), t, name)
+}
+
+// PlaceVendoredComponent returns the path of the vendored sources of a
+// component within the project at the provided working directory.
+func PlaceVendoredComponent(workdir string, t ComponentType, name string) (string, error) {
+	return placeComponent(filepath.Join(workdir, SourcesDir), t, name)
+}
+
+func placeComponent(dir string, t ComponentType, name string) (string, error) {
 	switch t {
 	case ComponentTypeCore:
-		return filepath.Join(workdir, VendorDir, "unikraft"), nil
+		return filepath.Join(dir, "unikraft"), nil
 	case ComponentTypeApp,
 		ComponentTypeLib,
 		ComponentTypeArch,
 		ComponentTypePlat:
-		return filepath.Join(workdir, VendorDir, t.Plural(), name), nil
+		return filepath.Join(dir, t.Plural(), name), nil
 	}
 
 	return "", fmt.Errorf("cannot place component of unknown type")
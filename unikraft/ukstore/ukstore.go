@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package ukstore reads the counters which the libraries of a unikernel export
+// through Unikraft's ukstore, as well as the hit counts of its uktrace
+// tracepoints, from the guest.
+//
+// The guest serves its entries on a dedicated virtio-serial port.  The host
+// requests a snapshot by sending a line holding `GET`, optionally followed by
+// the names of the libraries of interest, to which the guest responds with one
+// line per entry, terminated by an empty line:
+//
+//	<library>/<entry> <type> <value>
+//
+// A guest which cannot serve the request responds with a single line starting
+// with `ERR`, followed by the reason.
+package ukstore
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PortName is the name of the virtio-serial port on which the guest serves
+	// its entries.
+	PortName = "org.unikraft.ukstore"
+
+	// SocketFile is the name of the unix socket in the state directory of a
+	// machine which is connected to the port of the guest.
+	SocketFile = "ukstore.sock"
+)
+
+// Type is the type of the value of an entry.
+type Type string
+
+const (
+	TypeU8         = Type("u8")
+	TypeU16        = Type("u16")
+	TypeU32        = Type("u32")
+	TypeU64        = Type("u64")
+	TypeS8         = Type("s8")
+	TypeS16        = Type("s16")
+	TypeS32        = Type("s32")
+	TypeS64        = Type("s64")
+	TypeUptr       = Type("uptr")
+	TypeCharp      = Type("charp")
+	TypeTracepoint = Type("tracepoint")
+)
+
+// Entry is a single counter, or tracepoint, of a library of the guest.
+type Entry struct {
+	// Library is the name of the library which exports the entry, e.g.
+	// `ukalloc`.
+	Library string `json:"library"`
+
+	// Name is the name of the entry within the library.
+	Name string `json:"name"`
+
+	// Type is the type of the value of the entry.
+	Type Type `json:"type"`
+
+	// Value is the value of the entry as reported by the guest, or the number
+	// of times a tracepoint was hit.
+	Value string `json:"value"`
+}
+
+// Parse parses a single entry in the format: <library>/<entry> <type> <value>
+func Parse(line string) (Entry, error) {
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+	if len(fields) != 3 {
+		return Entry{}, fmt.Errorf("malformed entry: %q", line)
+	}
+
+	library, name, ok := strings.Cut(fields[0], "/")
+	if !ok || library == "" || name == "" {
+		return Entry{}, fmt.Errorf("malformed entry name: %q", fields[0])
+	}
+
+	entry := Entry{
+		Library: library,
+		Name:    name,
+		Type:    Type(fields[1]),
+		Value:   fields[2],
+	}
+
+	switch entry.Type {
+	case TypeU8, TypeU16, TypeU32, TypeU64, TypeTracepoint:
+		_, err := strconv.ParseUint(entry.Value, 10, 64)
+		if err != nil {
+			return Entry{}, fmt.Errorf("malformed value of %s: %q", fields[0], entry.Value)
+		}
+
+	case TypeS8, TypeS16, TypeS32, TypeS64:
+		_, err := strconv.ParseInt(entry.Value, 10, 64)
+		if err != nil {
+			return Entry{}, fmt.Errorf("malformed value of %s: %q", fields[0], entry.Value)
+		}
+	}
+
+	return entry, nil
+}
+
+// Dial connects to the unix socket of the port of a guest.
+func Dial(ctx context.Context, path string) (net.Conn, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to ukstore of guest: %w", err)
+	}
+
+	return conn, nil
+}
+
+// Query requests a snapshot of the entries of the provided libraries, or of
+// every library if none are provided, from the guest on the other end of the
+// connection.  The request is abandoned when the context is done.
+func Query(ctx context.Context, conn net.Conn, libraries ...string) ([]Entry, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	request := strings.Join(append([]string{"GET"}, libraries...), " ") + "\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return nil, fmt.Errorf("could not query ukstore of guest: %w", err)
+	}
+
+	var entries []Entry
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if line == "" {
+			return entries, nil
+		}
+
+		if reason, ok := strings.CutPrefix(line, "ERR"); ok {
+			return nil, fmt.Errorf("guest could not serve ukstore: %s", strings.TrimSpace(reason))
+		}
+
+		entry, err := Parse(line)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read ukstore of guest: %w", err)
+	}
+
+	return nil, fmt.Errorf("could not read ukstore of guest: connection closed")
+}
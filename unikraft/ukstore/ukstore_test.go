@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package ukstore
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// serve responds to a single request on the guest's end of the connection and
+// returns the request it received.
+func serve(t *testing.T, conn net.Conn, response string) <-chan string {
+	t.Helper()
+
+	requests := make(chan string, 1)
+
+	go func() {
+		defer conn.Close()
+
+		request, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			close(requests)
+			return
+		}
+
+		requests <- request
+
+		_, _ = conn.Write([]byte(response))
+	}()
+
+	return requests
+}
+
+func TestQuery(t *testing.T) {
+	host, guest := net.Pipe()
+	defer host.Close()
+
+	requests := serve(t, guest, "ukalloc/free_mem u64 1048576\r\nuksched/threads s32 -1\nuknetdev/rx tracepoint 42\n\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entries, err := Query(ctx, host, "ukalloc", "uksched", "uknetdev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request := <-requests; request != "GET ukalloc uksched uknetdev\n" {
+		t.Errorf("unexpected request: %q", request)
+	}
+
+	expected := []Entry{
+		{Library: "ukalloc", Name: "free_mem", Type: TypeU64, Value: "1048576"},
+		{Library: "uksched", Name: "threads", Type: TypeS32, Value: "-1"},
+		{Library: "uknetdev", Name: "rx", Type: TypeTracepoint, Value: "42"},
+	}
+
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d: %v", len(expected), len(entries), entries)
+	}
+
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], entries[i])
+		}
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	host, guest := net.Pipe()
+	defer host.Close()
+
+	serve(t, guest, "ERR ukstore is not enabled\n")
+
+	if _, err := Query(context.Background(), host); err == nil {
+		t.Errorf("expected error response to fail")
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, line := range []string{
+		"ukalloc free_mem u64 1",
+		"ukalloc/free_mem u64",
+		"ukalloc/free_mem u64 -1",
+		"ukalloc/ s64 1",
+	} {
+		if _, err := Parse(line); err == nil {
+			t.Errorf("expected %q to be malformed", line)
+		}
+	}
+
+	entry, err := Parse("vfscore/mount charp / (ramfs)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Value != "/ (ramfs)" {
+		t.Errorf("expected value to retain spaces, got %q", entry.Value)
+	}
+}
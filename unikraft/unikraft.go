@@ -35,4 +35,8 @@ const (
 	VendorDir = ".unikraft"
 	BuildDir  = ".unikraft/build"
 	LibsDir   = ".unikraft/libs"
+
+	// SourcesDir is the directory of a project which contains the vendored
+	// sources of its components, as created by `kraft pkg vendor`.
+	SourcesDir = ".vendor"
 )